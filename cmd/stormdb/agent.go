@@ -0,0 +1,179 @@
+// cmd/stormdb/agent.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/database"
+	"github.com/elchinoo/stormdb/internal/distributed"
+	"github.com/elchinoo/stormdb/internal/hoststats"
+	"github.com/elchinoo/stormdb/internal/workload"
+	"github.com/elchinoo/stormdb/pkg/plugin"
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// createAgentCommand creates the agent command, which runs a share of a
+// distributed run for a coordinator
+func createAgentCommand() *cobra.Command {
+	var (
+		listen string
+		token  string
+	)
+
+	agentCmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Run a load generation agent for distributed runs",
+		Long: `Run an agent that executes its share of a distributed run. A coordinator
+(stormdb with distributed.agents or --agents set) splits the workers across
+agents, starts them at a synchronized time and merges their metrics.
+
+The agent connects to the database with the configuration sent by the
+coordinator; schema setup, results storage and history stay on the
+coordinator. Routes under /agent/v1 require the bearer token when one is set.
+A token is required unless the agent listens on a loopback address.`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if token == "" {
+				token = os.Getenv("STORMDB_AGENT_TOKEN")
+			}
+			if token == "" {
+				if !isLoopbackAddr(listen) {
+					return fmt.Errorf("--token or $STORMDB_AGENT_TOKEN is required when listening on non-loopback address %s", listen)
+				}
+				log.Printf("⚠️  No agent token set, any local user can start runs on %s", listen)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			agent := distributed.NewAgent(runAgentWorkload, distributed.AgentOptions{
+				Addr:    listen,
+				Token:   token,
+				Version: Version,
+			})
+
+			log.Printf("🛰️  StormDB agent listening on %s", listen)
+			return agent.ListenAndServe(ctx)
+		},
+	}
+
+	agentCmd.Flags().StringVar(&listen, "listen", "127.0.0.1:7070", "Address to listen on")
+	agentCmd.Flags().StringVar(&token, "token", "", "Bearer token required by the coordinator (default: $STORMDB_AGENT_TOKEN)")
+
+	return agentCmd
+}
+
+// runAgentWorkload executes the agent's share of a distributed run. It
+// connects before startAt so every agent starts its workers on time.
+func runAgentWorkload(ctx context.Context, cfg *types.Config, m *types.Metrics, startAt time.Time, started func()) error {
+	duration, err := time.ParseDuration(cfg.Duration)
+	if err != nil {
+		return fmt.Errorf("invalid duration '%s': %w", cfg.Duration, err)
+	}
+
+	factory, err := workload.NewFactory(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize workload factory: %w", err)
+	}
+	defer func() { _ = factory.Cleanup() }()
+
+	if _, err := factory.DiscoverPlugins(); err != nil {
+		log.Printf("Warning: Plugin discovery issues: %v", err)
+	}
+
+	wl, err := factory.Get(cfg.Workload)
+	if err != nil {
+		return fmt.Errorf("failed to create workload '%s': %w", cfg.Workload, err)
+	}
+
+	statements, err := workloadStatements(wl, cfg)
+	if err != nil {
+		return err
+	}
+	db, err := database.NewPostgresWithExecMode(cfg, cfg.QueryExecMode, statements)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if cfg.Replicas.Enabled {
+		replicaSet, err := database.NewReplicaSet(cfg, db.Pool, statements)
+		if err != nil {
+			return fmt.Errorf("failed to connect to read replicas: %w", err)
+		}
+		defer replicaSet.Close()
+
+		if rw, ok := wl.(plugin.ReadRoutingWorkload); ok {
+			rw.SetReadPoolSelector(replicaSet)
+		}
+	}
+
+	if wait := time.Until(startAt); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	} else if wait < -time.Second {
+		log.Printf("⚠️  Starting %v after the scheduled start", -wait)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	// The coordinator samples the server; the agent samples its own host
+	if cfg.HostStats.Enabled {
+		sampler, err := hoststats.NewSampler(cfg, m, nil, true)
+		if err != nil {
+			return fmt.Errorf("invalid host_stats configuration: %w", err)
+		}
+		sampler.Start()
+		defer sampler.Stop()
+	}
+
+	log.Printf("🚀 Starting %s workload for %v with %d workers", cfg.Workload, duration, cfg.Workers)
+	started()
+	if err := wl.Run(runCtx, db.Pool, cfg, m); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("workload failed: %w", err)
+	}
+	log.Printf("✅ %s workload finished: %d transactions", cfg.Workload, m.TPS)
+	return nil
+}
+
+// newFleet connects to the agents of cfg and wraps wl so its runs are
+// distributed across them
+func newFleet(cfg *types.Config, wl plugin.Workload) (*distributed.FleetWorkload, error) {
+	if cfg.ExecModeComparison.Enabled {
+		return nil, fmt.Errorf("exec mode comparison is not supported with agents")
+	}
+
+	var startDelay time.Duration
+	if cfg.Distributed.StartDelay != "" {
+		d, err := time.ParseDuration(cfg.Distributed.StartDelay)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start_delay '%s': %w", cfg.Distributed.StartDelay, err)
+		}
+		startDelay = d
+	}
+	token := cfg.Distributed.Token
+	if token == "" {
+		token = os.Getenv("STORMDB_AGENT_TOKEN")
+	}
+
+	coord := distributed.NewCoordinator(cfg.Distributed.Agents, token, startDelay)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := coord.Check(ctx); err != nil {
+		return nil, fmt.Errorf("agents are not ready: %w", err)
+	}
+	log.Printf("🛰️  Distributing %d workers across %d agent(s)", cfg.Workers, len(cfg.Distributed.Agents))
+
+	return distributed.NewFleetWorkload(coord, wl, viper.AllSettings()), nil
+}
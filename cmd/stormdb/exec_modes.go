@@ -0,0 +1,151 @@
+// cmd/stormdb/exec_modes.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/database"
+	"github.com/elchinoo/stormdb/internal/metrics"
+	"github.com/elchinoo/stormdb/internal/util"
+	"github.com/elchinoo/stormdb/internal/workload"
+	"github.com/elchinoo/stormdb/pkg/plugin"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// preparedStatements returns the statements a workload wants explicitly
+// prepared, or nil when the workload does not declare any
+func preparedStatements(wl workload.Workload, cfg *types.Config) []string {
+	if psw, ok := wl.(plugin.PreparedStatementWorkload); ok {
+		return psw.PreparedStatements(cfg)
+	}
+	return nil
+}
+
+// workloadStatements returns the statements to prepare for a run. The
+// prepared mode is rejected for workloads that declare none, where it
+// would silently behave like cache_statement.
+func workloadStatements(wl workload.Workload, cfg *types.Config) ([]string, error) {
+	statements := preparedStatements(wl, cfg)
+	if cfg.QueryExecMode == types.ExecModePrepared && len(statements) == 0 {
+		return nil, fmt.Errorf("workload '%s' does not declare prepared statements, query_exec_mode 'prepared' would behave like cache_statement; use cache_statement instead", cfg.Workload)
+	}
+	return statements, nil
+}
+
+// runExecModeComparison runs the workload once per query execution mode,
+// each against a fresh pool, and reports the TPS and latency deltas
+func runExecModeComparison(cfg *types.Config, wl workload.Workload) error {
+	modes := cfg.ExecModeComparison.Modes
+	if len(modes) == 0 {
+		modes = types.QueryExecModes
+	}
+
+	durationStr := cfg.Duration
+	if cfg.ExecModeComparison.Duration != "" {
+		durationStr = cfg.ExecModeComparison.Duration
+	}
+	duration, err := time.ParseDuration(durationStr)
+	if err != nil {
+		return fmt.Errorf("invalid exec_mode_comparison duration '%s': %w", durationStr, err)
+	}
+
+	// Without declared statements the prepared mode is cache_statement
+	// under another name, and its delta would be noise
+	statements := preparedStatements(wl, cfg)
+	if len(statements) == 0 {
+		kept := make([]string, 0, len(modes))
+		for _, mode := range modes {
+			if mode != types.ExecModePrepared {
+				kept = append(kept, mode)
+			}
+		}
+		if len(kept) < len(modes) {
+			log.Printf("⚠️  Workload '%s' does not declare prepared statements, leaving 'prepared' out of the comparison", cfg.Workload)
+		}
+		modes = kept
+	}
+	if len(modes) == 0 {
+		return fmt.Errorf("no query execution modes left to compare")
+	}
+
+	// Stop the whole comparison on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("🔬 Comparing %d query execution modes, %v per mode", len(modes), duration)
+
+	results := make([]types.ExecModeResult, 0, len(modes))
+	for _, mode := range modes {
+		if ctx.Err() != nil {
+			log.Printf("🛑 Comparison interrupted, skipping remaining modes")
+			break
+		}
+
+		log.Printf("🚀 Running %s workload with query_exec_mode=%s", cfg.Workload, mode)
+		result := runExecMode(ctx, cfg, wl, mode, statements, duration)
+		if result.Error != "" {
+			log.Printf("⚠️  Mode %s failed: %s", mode, result.Error)
+		} else {
+			log.Printf("✅ Mode %s: %.2f TPS, avg %.2fms", mode, result.TPS, result.AvgLatencyMs)
+		}
+		results = append(results, result)
+	}
+
+	metrics.ReportExecModeComparison(results)
+	return nil
+}
+
+// runExecMode executes a single comparison run with the given mode
+func runExecMode(parent context.Context, cfg *types.Config, wl workload.Workload, mode string,
+	statements []string, duration time.Duration) types.ExecModeResult {
+
+	result := types.ExecModeResult{Mode: mode}
+
+	runCfg := *cfg
+	runCfg.QueryExecMode = mode
+	runCfg.Duration = duration.String()
+
+	db, err := database.NewPostgresWithExecMode(&runCfg, mode, statements)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer db.Close()
+
+	m := &types.Metrics{
+		ErrorTypes: make(map[string]int64),
+		Mu:         sync.Mutex{},
+	}
+	m.InitializeLatencyHistogram()
+
+	ctx, cancel := context.WithTimeout(parent, duration)
+	defer cancel()
+
+	start := time.Now()
+	if err := wl.Run(ctx, db.Pool, &runCfg, m); err != nil && ctx.Err() == nil {
+		result.Error = err.Error()
+	}
+	elapsed := time.Since(start).Seconds()
+
+	result.Transactions = m.TPS
+	result.Errors = m.Errors + m.TPSAborted
+	if elapsed > 0 {
+		result.TPS = float64(m.TPS) / elapsed
+	}
+	if len(m.TransactionDur) > 0 {
+		avg, _, _, _ := util.Stats(m.TransactionDur)
+		pvals := util.CalculatePercentiles(m.TransactionDur, []int{95, 99})
+		result.AvgLatencyMs = float64(avg) / 1e6
+		result.P95LatencyMs = float64(pvals[0]) / 1e6
+		result.P99LatencyMs = float64(pvals[1]) / 1e6
+	}
+
+	return result
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/explain"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// recordQueryPlans stops plan capture, marks the plans whose shape changed
+// since the previous run of the workload, saves them to the plan store and
+// attaches them to m for the report. Failures are logged and never fail the
+// run.
+func recordQueryPlans(cfg *types.Config, tracer *explain.Tracer, m *types.Metrics, start time.Time) {
+	plans := tracer.Stop()
+	if len(plans) == 0 {
+		log.Printf("🔍 No query plans captured")
+		return
+	}
+
+	store := explain.NewStore(cfg.Explain.Dir)
+	previous, err := store.Previous(cfg.Workload, start)
+	if err != nil {
+		log.Printf("⚠️  Failed to load the previous run's query plans: %v", err)
+	}
+	changed := explain.DetectChanges(previous, plans)
+
+	path, err := store.Save(cfg.Workload, start, plans)
+	if err != nil {
+		log.Printf("⚠️  Failed to save query plans: %v", err)
+	} else {
+		log.Printf("🔍 Captured %d query plan(s), saved to %s", len(plans), path)
+	}
+	if changed > 0 {
+		log.Printf("⚠️  %d query plan(s) changed since the previous run", changed)
+	}
+
+	m.Mu.Lock()
+	m.QueryPlans = plans
+	m.QueryPlanFile = path
+	m.Mu.Unlock()
+}
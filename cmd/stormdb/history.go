@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/config"
+	"github.com/elchinoo/stormdb/internal/core/domain"
+	"github.com/elchinoo/stormdb/internal/core/ports"
+	"github.com/elchinoo/stormdb/internal/core/usecases"
+	"github.com/elchinoo/stormdb/internal/infrastructure/adapters"
+	"github.com/elchinoo/stormdb/internal/metrics"
+	"github.com/elchinoo/stormdb/internal/results"
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// historyTimeout bounds recording a run and each history command
+const historyTimeout = 30 * time.Second
+
+// recordHistory stores a finished run in the run history: the results
+// backend database when one is open, else the local file store. Failures
+// are logged and never fail the run.
+func recordHistory(cfg *types.Config, m *types.Metrics, backend *results.Backend, interrupted bool, runErr error, start, end time.Time) {
+	if cfg.History.Disabled {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+
+	repo, err := adapters.OpenRepository(ctx, cfg, backend)
+	if err != nil {
+		log.Printf("⚠️  Failed to open run history: %v", err)
+		return
+	}
+	defer repo.Close()
+
+	summary := metrics.BuildSummary(cfg, m, interrupted, runErr, start, end)
+	execution, points := adapters.ExecutionFromRun(cfg, summary)
+	if err := newHistoryUseCase(repo).RecordExecution(ctx, execution, points); err != nil {
+		log.Printf("⚠️  Failed to record run history: %v", err)
+		return
+	}
+	log.Printf("🗂️  Run recorded in history as %s", execution.ID)
+}
+
+// newHistoryUseCase builds the execution use case on a run history store.
+// Recording and browsing runs only needs its repositories.
+func newHistoryUseCase(repo adapters.Repository) *usecases.TestExecutionUseCase {
+	return usecases.NewTestExecutionUseCase(repo, repo, repo, nil, nil, nil, nil)
+}
+
+// createHistoryCommand creates the history command and its subcommands
+func createHistoryCommand() *cobra.Command {
+	var configFile, dir string
+
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Browse and compare recorded runs",
+		Long: `Browse and compare the runs recorded after each workload.
+
+Runs are recorded in the results backend database when results_backend is
+enabled, and otherwise in a local file store (~/.stormdb/history by default,
+see history.dir). Pass the run's configuration file with --config to read
+the same store it wrote to.`,
+	}
+	historyCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "Configuration file selecting the history store")
+	historyCmd.PersistentFlags().StringVar(&dir, "dir", "", "File store directory (overrides config)")
+
+	// openHistory opens the store selected by --config and --dir
+	openHistory := func(ctx context.Context) (*usecases.TestExecutionUseCase, func(), error) {
+		cfg := &types.Config{}
+		if configFile != "" {
+			loaded, err := config.Load(configFile)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to load config: %w", err)
+			}
+			cfg = loaded
+		}
+		if dir != "" {
+			cfg.History.Dir = dir
+			cfg.ResultsBackend.Enabled = false
+		}
+
+		backend, err := results.CreateBackendFromConfig(cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to results backend: %w", err)
+		}
+		repo, err := adapters.OpenRepository(ctx, cfg, backend)
+		if err != nil {
+			if backend != nil {
+				backend.Close()
+			}
+			return nil, nil, fmt.Errorf("failed to open run history: %w", err)
+		}
+		closeAll := func() {
+			_ = repo.Close()
+			if backend != nil {
+				backend.Close()
+			}
+		}
+		return newHistoryUseCase(repo), closeAll, nil
+	}
+
+	historyCmd.AddCommand(createHistoryListCommand(openHistory))
+	historyCmd.AddCommand(createHistoryShowCommand(openHistory))
+	historyCmd.AddCommand(createHistoryCompareCommand(openHistory))
+	historyCmd.AddCommand(createHistoryTrendsCommand(openHistory))
+	historyCmd.AddCommand(createHistoryDeleteCommand(openHistory))
+	return historyCmd
+}
+
+// historyOpener opens the run history store for a subcommand
+type historyOpener func(ctx context.Context) (*usecases.TestExecutionUseCase, func(), error)
+
+// createHistoryListCommand creates the history list subcommand
+func createHistoryListCommand(open historyOpener) *cobra.Command {
+	var workload, status string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List recorded runs, newest first",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+			defer cancel()
+			history, closeHistory, err := open(ctx)
+			if err != nil {
+				return err
+			}
+			defer closeHistory()
+
+			filters := ports.TestExecutionFilters{Limit: limit}
+			if workload != "" {
+				filters.WorkloadType = &workload
+			}
+			if status != "" {
+				s := domain.ExecutionStatus(status)
+				filters.Status = &s
+			}
+			executions, err := history.ListExecutions(ctx, filters)
+			if err != nil {
+				return err
+			}
+			if len(executions) == 0 {
+				fmt.Println("No recorded runs")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tSTARTED\tWORKLOAD\tNAME\tSTATUS\tWORKERS\tTPS\tP95 (ms)")
+			for _, e := range executions {
+				var workers int
+				var tps, p95 float64
+				if band := adapters.HeadlineBand(e.Results); band != nil {
+					workers, tps, p95 = band.Workers, band.Performance.TotalTPS, band.Performance.P95Latency
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%.2f\t%.2f\n", e.ID, e.StartTime.Local().Format("2006-01-02 15:04:05"),
+					e.WorkloadType, e.Name, e.Status, workers, tps, p95)
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().StringVarP(&workload, "workload", "w", "", "Only runs of this workload")
+	cmd.Flags().StringVar(&status, "status", "", "Only runs with this status: completed, failed, cancelled")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "Maximum runs to list (0 lists all)")
+	return cmd
+}
+
+// createHistoryShowCommand creates the history show subcommand
+func createHistoryShowCommand(open historyOpener) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <id>",
+		Short: "Print a recorded run and its results as JSON",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+			defer cancel()
+			history, closeHistory, err := open(ctx)
+			if err != nil {
+				return err
+			}
+			defer closeHistory()
+
+			e, err := history.GetExecution(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			// The error interface does not encode; show its message
+			out := struct {
+				*domain.TestExecution
+				Error string `json:"Error,omitempty"`
+			}{TestExecution: e}
+			if e.Error != nil {
+				out.Error = e.Error.Error()
+			}
+			return printJSON(out)
+		},
+	}
+}
+
+// createHistoryCompareCommand creates the history compare subcommand
+func createHistoryCompareCommand(open historyOpener) *cobra.Command {
+	return &cobra.Command{
+		Use:   "compare <id> <id>...",
+		Short: "Compare recorded runs side by side",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+			defer cancel()
+			history, closeHistory, err := open(ctx)
+			if err != nil {
+				return err
+			}
+			defer closeHistory()
+
+			comparison, err := history.CompareExecutions(ctx, args)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tSTARTED\tWORKLOAD\tNAME\tWORKERS\tCONNECTIONS\tTPS\tP95 (ms)")
+			for _, e := range comparison.Executions {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%.2f\t%.2f\n", e.ID, e.Timestamp.Local().Format("2006-01-02 15:04:05"),
+					e.WorkloadType, e.Name, e.Workers, e.Connections, e.TPS, e.Latency)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			fmt.Println()
+			fmt.Printf("Best performer: %s\n", comparison.Analysis.BestPerformer)
+			if comparison.Analysis.MostEfficient != "" {
+				fmt.Printf("Most efficient: %s\n", comparison.Analysis.MostEfficient)
+			}
+			if comparison.Analysis.MostStable != "" {
+				fmt.Printf("Most stable:    %s\n", comparison.Analysis.MostStable)
+			}
+			for _, rec := range comparison.Analysis.Recommendations {
+				fmt.Printf("  • %s\n", rec)
+			}
+			return nil
+		},
+	}
+}
+
+// createHistoryTrendsCommand creates the history trends subcommand
+func createHistoryTrendsCommand(open historyOpener) *cobra.Command {
+	var since time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "trends <workload>",
+		Short: "Show TPS and latency of a workload's completed runs over time",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+			defer cancel()
+			history, closeHistory, err := open(ctx)
+			if err != nil {
+				return err
+			}
+			defer closeHistory()
+
+			var tr ports.TimeRange
+			if since > 0 {
+				tr.Start = time.Now().Add(-since)
+			}
+			trends, err := history.GetPerformanceTrends(ctx, args[0], tr)
+			if err != nil {
+				return err
+			}
+			if len(trends) == 0 {
+				fmt.Printf("No completed runs of %s\n", args[0])
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "STARTED\tWORKERS\tTPS\tP95 (ms)")
+			for _, t := range trends {
+				fmt.Fprintf(w, "%s\t%d\t%.2f\t%.2f\n", t.Timestamp.Local().Format("2006-01-02 15:04:05"), t.Workers, t.TPS, t.Latency)
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().DurationVar(&since, "since", 0, "Only runs started within this duration, e.g., 168h")
+	return cmd
+}
+
+// createHistoryDeleteCommand creates the history delete subcommand
+func createHistoryDeleteCommand(open historyOpener) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <id>...",
+		Short: "Delete recorded runs and their metrics",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+			defer cancel()
+			history, closeHistory, err := open(ctx)
+			if err != nil {
+				return err
+			}
+			defer closeHistory()
+
+			for _, id := range args {
+				if err := history.DeleteExecution(ctx, id); err != nil {
+					return err
+				}
+				fmt.Printf("Deleted %s\n", id)
+			}
+			return nil
+		},
+	}
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
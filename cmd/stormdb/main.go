@@ -15,10 +15,8 @@ import (
 	"time"
 
 	"github.com/elchinoo/stormdb/internal/config"
-	"github.com/elchinoo/stormdb/internal/database"
 	"github.com/elchinoo/stormdb/internal/metrics"
 	"github.com/elchinoo/stormdb/internal/progressive"
-	"github.com/elchinoo/stormdb/internal/results"
 	"github.com/elchinoo/stormdb/internal/workload"
 	"github.com/elchinoo/stormdb/pkg/types"
 
@@ -57,6 +55,11 @@ func main() {
 		progressiveMode   bool
 		enableProfiling   bool
 		profilingPort     string
+		queryExecMode     string
+		compareExecModes  bool
+		output            string
+		outputFile        string
+		agents            []string
 	)
 
 	rootCmd := &cobra.Command{
@@ -88,6 +91,11 @@ func main() {
 				ProgressiveMode:   progressiveMode,
 				EnableProfiling:   enableProfiling,
 				ProfilingPort:     profilingPort,
+				QueryExecMode:     queryExecMode,
+				CompareExecModes:  compareExecModes,
+				Output:            output,
+				OutputFile:        outputFile,
+				Agents:            agents,
 			})
 		},
 	}
@@ -108,6 +116,15 @@ func main() {
 	// Plugins command
 	rootCmd.AddCommand(createPluginsCommand())
 
+	// HTTP control API
+	rootCmd.AddCommand(createServeCommand())
+
+	// Run history
+	rootCmd.AddCommand(createHistoryCommand())
+
+	// Distributed load generation agent
+	rootCmd.AddCommand(createAgentCommand())
+
 	// File and setup options
 	rootCmd.Flags().StringVarP(&configFile, "config", "c", "config.yaml", "Path to config file")
 	rootCmd.Flags().BoolVar(&setup, "setup", false, "Ensure schema exists (create if needed, but do not load data)")
@@ -131,6 +148,11 @@ func main() {
 	rootCmd.Flags().BoolVar(&collectPgStats, "collect-pg-stats", false, "Enable PostgreSQL statistics collection")
 	rootCmd.Flags().BoolVar(&pgStatsStatements, "pg-stat-statements", false, "Enable pg_stat_statements collection (requires extension)")
 	rootCmd.Flags().BoolVar(&progressiveMode, "progressive", false, "Enable progressive connection scaling (overrides config)")
+	rootCmd.Flags().StringVar(&queryExecMode, "query-exec-mode", "", "Query execution mode: cache_statement, cache_describe, describe_exec, exec, simple_protocol, prepared (overrides config)")
+	rootCmd.Flags().BoolVar(&compareExecModes, "compare-exec-modes", false, "Run the workload under each query execution mode and compare results")
+	rootCmd.Flags().StringVarP(&output, "output", "o", metrics.OutputText, "Final report format: text, json, csv, junit")
+	rootCmd.Flags().StringVar(&outputFile, "output-file", "", "Write the final report to this file instead of stdout")
+	rootCmd.Flags().StringSliceVar(&agents, "agents", nil, "Distribute the workload across these agents, host:port (overrides config)")
 	rootCmd.Flags().BoolVarP(&showVersion, "version", "V", false, "Show version information and exit")
 	rootCmd.Flags().BoolVar(&enableProfiling, "profile", false, "Enable memory profiling server")
 	rootCmd.Flags().StringVar(&profilingPort, "profile-port", "6060", "Port for profiling server (default: 6060)")
@@ -159,6 +181,11 @@ type CLIOptions struct {
 	ProgressiveMode   bool
 	EnableProfiling   bool
 	ProfilingPort     string
+	QueryExecMode     string
+	CompareExecModes  bool
+	Output            string // Final report format (text, json, csv, junit)
+	OutputFile        string // Final report destination; stdout when empty
+	Agents            []string
 }
 
 func runLoadTest(configFile string, setup bool, rebuild bool, cliOpts *CLIOptions) error {
@@ -170,6 +197,13 @@ func runLoadTest(configFile string, setup bool, rebuild bool, cliOpts *CLIOption
 	// Apply CLI overrides to config
 	applyCliOverrides(cfg, cliOpts)
 
+	if err := metrics.ValidateOutputFormat(cliOpts.Output); err != nil {
+		return err
+	}
+	if err := checkOutputSupported(cfg, cliOpts); err != nil {
+		return err
+	}
+
 	// Start profiling server if enabled
 	if cliOpts.EnableProfiling {
 		go func() {
@@ -198,15 +232,14 @@ func runLoadTest(configFile string, setup bool, rebuild bool, cliOpts *CLIOption
 		}()
 	}
 
-	duration, err := time.ParseDuration(cfg.Duration)
-	if err != nil {
+	if _, err := time.ParseDuration(cfg.Duration); err != nil {
 		return fmt.Errorf("invalid duration '%s': %w", cfg.Duration, err)
 	}
 
 	// Handle summary interval with default and no-summary flag
 	var summaryInterval time.Duration
-	if cliOpts.NoSummary {
-		// If --no-summary is set, disable summary reporting
+	if cliOpts.NoSummary || summaryToStdout(cliOpts) {
+		// If --no-summary is set or stdout carries the run summary, disable summary reporting
 		summaryInterval = 0
 	} else if cfg.SummaryInterval != "" {
 		// If summary interval is configured, use it
@@ -219,61 +252,19 @@ func runLoadTest(configFile string, setup bool, rebuild bool, cliOpts *CLIOption
 		summaryInterval = 10 * time.Second
 	}
 
-	db, err := database.NewPostgres(cfg)
-	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
-	}
-	defer db.Close()
-
-	// Initialize workload factory with plugin support
-	factory, err := workload.NewFactory(cfg)
-	if err != nil {
-		return fmt.Errorf("failed to initialize workload factory: %w", err)
-	}
-	defer func() { _ = factory.Cleanup() }()
-
-	// Discover and initialize plugins
-	if err := factory.Initialize(); err != nil {
-		log.Printf("Warning: Failed to initialize factory: %v", err)
-	}
-
-	// Discover plugins (this will search configured plugin paths)
-	pluginCount, err := factory.DiscoverPlugins()
-	if err != nil {
-		log.Printf("Warning: Plugin discovery issues: %v", err)
-	} else if pluginCount > 0 {
-		log.Printf("🔌 Discovered %d plugin(s)", pluginCount)
-	}
-
-	// Create workload instance
-	wl, err := factory.Get(cfg.Workload)
-	if err != nil {
-		return fmt.Errorf("failed to create workload '%s': %w", cfg.Workload, err)
-	}
-
 	// -------------------------------
-	// Phase 1: Schema & Data Control
+	// Phase 1: Workload, connections and schema
 	// -------------------------------
 
-	switch {
-	case rebuild:
-		log.Printf("💥 Rebuilding: dropping and recreating schema + data")
-		if err := wl.Cleanup(context.Background(), db.Pool, cfg); err != nil {
-			return fmt.Errorf("failed to cleanup: %w", err)
-		}
-		// ✅ Add Setup after Cleanup
-		if err := wl.Setup(context.Background(), db.Pool, cfg); err != nil {
-			return fmt.Errorf("failed to setup after rebuild: %w", err)
-		}
-
-	case setup:
-		log.Printf("🔧 Ensuring schema exists (no data load)")
-		if err := wl.Setup(context.Background(), db.Pool, cfg); err != nil {
-			return fmt.Errorf("failed to setup schema: %w", err)
-		}
+	prepared, err := prepareRun(context.Background(), cfg, setup, rebuild)
+	if err != nil {
+		return err
+	}
+	defer prepared.Close()
 
-	default:
-		log.Printf("⏭️  Skipping setup (--setup or --rebuild not used). Assuming schema and data exist.")
+	// Run the workload once per query execution mode instead of a single run
+	if cfg.ExecModeComparison.Enabled {
+		return runExecModeComparison(cfg, prepared.wl)
 	}
 
 	// -------------------------------
@@ -283,12 +274,24 @@ func runLoadTest(configFile string, setup bool, rebuild bool, cliOpts *CLIOption
 	// Check if progressive scaling is enabled
 	if cfg.Progressive.Enabled {
 		log.Printf("🎯 Starting progressive scaling mode")
+		if cfg.FaultInjection.Enabled {
+			log.Printf("⚠️  Fault injection is not supported in progressive scaling mode, ignoring schedule")
+		}
+		if prepared.planTracer != nil {
+			log.Printf("⚠️  Query plan capture is not supported in progressive scaling mode, ignoring explain")
+		}
+		if cfg.HostStats.Enabled {
+			log.Printf("⚠️  Host resource sampling is not supported in progressive scaling mode, ignoring host_stats")
+		}
 
-		// Create a workload adapter for the progressive engine
-		workloadAdapter := &WorkloadAdapter{workload: wl}
+		// Create a workload adapter for the progressive engine; a fleet runs each band on the agents
+		var workloadAdapter progressive.WorkloadInterface = &WorkloadAdapter{workload: prepared.wl}
+		if prepared.fleet != nil {
+			workloadAdapter = prepared.fleet
+		}
 
 		// Create progressive scaling engine
-		engine := progressive.NewScalingEngine(cfg, workloadAdapter, db.Pool)
+		engine := progressive.NewScalingEngine(cfg, workloadAdapter, prepared.db.Pool)
 
 		// Execute progressive scaling
 		ctx, cancel := context.WithCancel(context.Background())
@@ -338,8 +341,6 @@ func runLoadTest(configFile string, setup bool, rebuild bool, cliOpts *CLIOption
 	// Phase 2: Run the regular workload
 	// -------------------------------
 
-	log.Printf("🚀 Starting %s workload for %v with %d workers", cfg.Workload, duration, cfg.Workers)
-
 	metricsData := &types.Metrics{
 		ErrorTypes: make(map[string]int64),
 		Mu:         sync.Mutex{},
@@ -348,131 +349,31 @@ func runLoadTest(configFile string, setup bool, rebuild bool, cliOpts *CLIOption
 	// Initialize latency histogram
 	metricsData.InitializeLatencyHistogram()
 
-	// Start PostgreSQL statistics collector if enabled
-	var pgStatsCollector *database.PgStatsCollector
-	if cfg.CollectPgStats {
-		pgStatsCollector = database.NewPgStatsCollector(db.Pool, metricsData, cfg.PgStatsStatements)
-		pgStatsCollector.Start()
-		log.Printf("📊 PostgreSQL statistics collection enabled (pg_stat_statements: %v)", cfg.PgStatsStatements)
-		defer pgStatsCollector.Stop()
-	}
-
-	// Set up signal handling for graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), duration)
-	defer cancel()
-
-	// Create a channel to receive OS signals
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	// Start workload in a goroutine
-	errChan := make(chan error, 1)
-	go func() {
-		// Capture baseline statistics immediately before workload starts
-		if cfg.CollectPgStats && pgStatsCollector != nil {
-			pgStatsCollector.CaptureWorkloadBaseline()
-		}
+	// Shut the workload down gracefully on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		errChan <- wl.Run(ctx, db.Pool, cfg, metricsData)
-	}()
-
-	// Start periodic summary reporting if interval is configured
-	var summaryTicker *time.Ticker
-	var summaryDone chan bool
-	startTime := time.Now() // Always record start time for results storage
-	if summaryInterval > 0 {
-		summaryTicker = time.NewTicker(summaryInterval)
-		summaryDone = make(chan bool)
-		go func() {
-			for {
-				select {
-				case <-summaryTicker.C:
-					elapsed := time.Since(startTime)
-					metrics.ReportSummary(cfg, metricsData, elapsed)
-				case <-summaryDone:
-					return
-				}
-			}
-		}()
-	}
-
-	// Wait for either completion, error, or signal
-	var workloadErr error
-	var interrupted bool
-
-	select {
-	case workloadErr = <-errChan:
-		// Workload completed normally
-	case sig := <-sigChan:
-		log.Printf("\n🛑 Received signal %v, shutting down gracefully...", sig)
-		interrupted = true
-		cancel() // Cancel the context to stop the workload
-
-		// Wait a bit for workload to finish gracefully
-		select {
-		case workloadErr = <-errChan:
-			// Workload finished
-		case <-time.After(5 * time.Second):
-			log.Printf("⚠️  Workload didn't finish in 5 seconds, forcing shutdown")
-		}
-	}
-
-	// Clean up periodic summary ticker
-	if summaryTicker != nil {
-		summaryTicker.Stop()
-		summaryDone <- true
-	}
-
-	// Calculate final PostgreSQL statistics after workload completion
-	if cfg.CollectPgStats && pgStatsCollector != nil {
-		if finalStats := pgStatsCollector.CalculateFinalStats(); finalStats != nil {
-			metricsData.UpdatePgStats(finalStats)
-		}
-	}
-
-	// -------------------------------
-	// Phase 3: Store results in database backend (if configured)
-	// -------------------------------
-
-	// Record end time for test results storage
-	testEndTime := time.Now()
-
-	// Initialize and store test results in database backend if configured
-	if resultsBackend, err := results.CreateBackendFromConfig(cfg); err != nil {
-		log.Printf("⚠️  Failed to create results backend: %v", err)
-	} else if resultsBackend != nil {
-		defer resultsBackend.Close()
-
-		// Store test results
-		if err := results.StoreTestResults(context.Background(), resultsBackend, cfg, metricsData, startTime, testEndTime); err != nil {
-			log.Printf("⚠️  Failed to store test results: %v", err)
-		} else {
-			log.Printf("💾 Test results stored in database backend")
-		}
-
-		// Perform maintenance (cleanup old results)
-		if err := results.PerformMaintenance(context.Background(), resultsBackend); err != nil {
-			log.Printf("⚠️  Failed to perform backend maintenance: %v", err)
-		}
+	outcome, err := prepared.run(ctx, metricsData, runOptions{SummaryInterval: summaryInterval, AllowShellFaults: true})
+	if err != nil {
+		return err
 	}
 
 	// -------------------------------
-	// Phase 4: Report results
+	// Phase 3: Report results
 	// -------------------------------
 
-	if interrupted {
+	if outcome.Interrupted {
 		log.Printf("\n📊 Final Summary (interrupted):")
-		metrics.ReportWithContext(cfg, metricsData, true, time.Now())
 	} else {
 		log.Printf("\n📊 Final Summary:")
-		metrics.Report(cfg, metricsData)
 	}
+	reportErr := writeFinalReport(cfg, metricsData, cliOpts, outcome.Interrupted, outcome.WorkloadErr, outcome.StartTime, time.Now())
 
-	if workloadErr != nil && !interrupted {
-		return fmt.Errorf("workload failed: %w", workloadErr)
+	if outcome.WorkloadErr != nil && !outcome.Interrupted {
+		return fmt.Errorf("workload failed: %w", outcome.WorkloadErr)
 	}
 
-	return nil
+	return reportErr
 }
 
 // applyCliOverrides applies command-line options to the config, giving CLI higher priority
@@ -526,6 +427,19 @@ func applyCliOverrides(cfg *types.Config, cliOpts *CLIOptions) {
 	if cliOpts.ProgressiveMode {
 		cfg.Progressive.Enabled = true
 	}
+
+	// Query execution mode overrides
+	if cliOpts.QueryExecMode != "" {
+		cfg.QueryExecMode = cliOpts.QueryExecMode
+	}
+	if cliOpts.CompareExecModes {
+		cfg.ExecModeComparison.Enabled = true
+	}
+
+	// Distributed run override
+	if len(cliOpts.Agents) > 0 {
+		cfg.Distributed.Agents = cliOpts.Agents
+	}
 }
 
 // WorkloadAdapter adapts the plugin workload interface to the progressive engine interface
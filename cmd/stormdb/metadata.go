@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/database"
+	"github.com/elchinoo/stormdb/internal/workload"
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// metadataTimeout bounds reading the server settings of a run
+const metadataTimeout = 10 * time.Second
+
+// captureRunMetadata fingerprints the server configuration and the client
+// environment of a run. A server that cannot be read is logged and leaves
+// only the client fields.
+func captureRunMetadata(cfg *types.Config, factory *workload.Factory, pool *pgxpool.Pool) *types.RunMetadata {
+	host, _ := os.Hostname()
+	md := &types.RunMetadata{
+		CapturedAt:    time.Now(),
+		ClientVersion: Version,
+		ClientHost:    host,
+		ClientOS:      runtime.GOOS + "/" + runtime.GOARCH,
+		ClientCPUs:    runtime.NumCPU(),
+		GoVersion:     runtime.Version(),
+	}
+	if meta, err := factory.Metadata(cfg.Workload); err == nil {
+		md.Plugin = meta.Name
+		md.PluginVersion = meta.Version
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), metadataTimeout)
+	defer cancel()
+	if err := database.CaptureServerMetadata(ctx, pool, md); err != nil {
+		log.Printf("⚠️  Failed to capture server settings: %v", err)
+	}
+	return md
+}
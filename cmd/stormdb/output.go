@@ -0,0 +1,83 @@
+// cmd/stormdb/output.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/metrics"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// summaryToStdout reports whether the machine-readable summary takes over
+// stdout, in which case nothing else may be printed there
+func summaryToStdout(cliOpts *CLIOptions) bool {
+	return cliOpts.Output != metrics.OutputText && cliOpts.OutputFile == ""
+}
+
+// checkOutputSupported rejects --output and --output-file in run modes that
+// print their own report instead of a run summary
+func checkOutputSupported(cfg *types.Config, cliOpts *CLIOptions) error {
+	if cliOpts.Output == metrics.OutputText && cliOpts.OutputFile == "" {
+		return nil
+	}
+	switch {
+	case cfg.Progressive.Enabled:
+		return fmt.Errorf("--output and --output-file are not supported in progressive scaling mode")
+	case cfg.ExecModeComparison.Enabled:
+		return fmt.Errorf("--output and --output-file are not supported with the query execution mode comparison")
+	}
+	return nil
+}
+
+// writeFinalReport prints the end-of-run report in the requested format.
+// The text report goes to stdout unless it is written to --output-file or
+// stdout carries a machine-readable summary, in which case it goes to stderr.
+func writeFinalReport(cfg *types.Config, m *types.Metrics, cliOpts *CLIOptions, interrupted bool, workloadErr error, startTime, endTime time.Time) error {
+	var text io.Writer = os.Stdout
+	if summaryToStdout(cliOpts) {
+		text = os.Stderr
+	}
+
+	if cliOpts.Output == metrics.OutputText {
+		if cliOpts.OutputFile == "" {
+			metrics.ReportTo(text, cfg, m, interrupted, endTime)
+			return nil
+		}
+		return writeOutputFile(cliOpts.OutputFile, func(w io.Writer) error {
+			metrics.ReportTo(w, cfg, m, interrupted, endTime)
+			return nil
+		})
+	}
+
+	metrics.ReportTo(text, cfg, m, interrupted, endTime)
+
+	summary := metrics.BuildSummary(cfg, m, interrupted, workloadErr, startTime, endTime)
+	summary.StormDBVersion = Version
+	if cliOpts.OutputFile == "" {
+		return metrics.WriteSummary(os.Stdout, cliOpts.Output, summary)
+	}
+	return writeOutputFile(cliOpts.OutputFile, func(w io.Writer) error {
+		return metrics.WriteSummary(w, cliOpts.Output, summary)
+	})
+}
+
+// writeOutputFile creates path and fills it with write
+func writeOutputFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	log.Printf("📄 Run report written to %s", path)
+	return nil
+}
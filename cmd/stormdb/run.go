@@ -0,0 +1,377 @@
+// cmd/stormdb/run.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/chaos"
+	"github.com/elchinoo/stormdb/internal/database"
+	"github.com/elchinoo/stormdb/internal/distributed"
+	"github.com/elchinoo/stormdb/internal/explain"
+	"github.com/elchinoo/stormdb/internal/hoststats"
+	"github.com/elchinoo/stormdb/internal/metrics"
+	"github.com/elchinoo/stormdb/internal/results"
+	"github.com/elchinoo/stormdb/internal/workload"
+	"github.com/elchinoo/stormdb/pkg/plugin"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// preparedRun is a workload that is connected and has its schema in place.
+// The CLI and the HTTP API both prepare and run workloads through it, so
+// every run mode goes through the same pipeline.
+type preparedRun struct {
+	cfg        *types.Config
+	factory    *workload.Factory
+	wl         workload.Workload
+	db         *database.Postgres
+	replicaSet *database.ReplicaSet       // Read replicas, when configured
+	planTracer *explain.Tracer            // Query plan capture, when configured
+	fleet      *distributed.FleetWorkload // Agents of a distributed run, when configured
+	statements []string                   // Statements prepared in prepared mode
+}
+
+// runOptions controls the regular run of a prepared workload
+type runOptions struct {
+	SummaryInterval  time.Duration // Interval between progress summaries; 0 disables them
+	Started          func()        // Called once the workload begins, may be nil
+	AllowShellFaults bool          // Accept fault events that run shell commands
+}
+
+// runOutcome describes how a regular run ended
+type runOutcome struct {
+	StartTime   time.Time
+	EndTime     time.Time
+	Interrupted bool  // ctx ended before the workload finished
+	WorkloadErr error // Error returned by the workload
+}
+
+// prepareRun loads the workload, connects to the database and read replicas,
+// applies setup or rebuild and connects to the agents of a distributed run
+func prepareRun(ctx context.Context, cfg *types.Config, setup, rebuild bool) (p *preparedRun, err error) {
+	// Initialize workload factory with plugin support
+	factory, err := workload.NewFactory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize workload factory: %w", err)
+	}
+	p = &preparedRun{cfg: cfg, factory: factory}
+	defer func() {
+		if err != nil {
+			p.Close()
+		}
+	}()
+
+	// Discover and initialize plugins
+	if err := factory.Initialize(); err != nil {
+		log.Printf("Warning: Failed to initialize factory: %v", err)
+	}
+
+	// Discover plugins (this will search configured plugin paths)
+	pluginCount, err := factory.DiscoverPlugins()
+	if err != nil {
+		log.Printf("Warning: Plugin discovery issues: %v", err)
+	} else if pluginCount > 0 {
+		log.Printf("🔌 Discovered %d plugin(s)", pluginCount)
+	}
+
+	// Create workload instance
+	p.wl, err = factory.Get(cfg.Workload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workload '%s': %w", cfg.Workload, err)
+	}
+
+	// Create the workload pool with the configured query execution mode
+	p.statements, err = workloadStatements(p.wl, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Trace the workload's statements to capture plans of slow or sampled ones
+	var poolOpts []database.PoolOption
+	if cfg.Explain.Enabled {
+		if len(cfg.Distributed.Agents) > 0 {
+			log.Printf("⚠️  Query plan capture is not supported with agents, ignoring explain")
+		} else {
+			p.planTracer, err = explain.NewTracer(cfg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to prepare query plan capture: %w", err)
+			}
+			poolOpts = append(poolOpts, database.WithTracer(p.planTracer))
+		}
+	}
+
+	p.db, err = database.NewPostgresWithExecMode(cfg, cfg.QueryExecMode, p.statements, poolOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if cfg.QueryExecMode != "" {
+		log.Printf("🔧 Using query execution mode: %s", cfg.QueryExecMode)
+	}
+
+	// Route read operations to replicas when configured
+	if cfg.Replicas.Enabled {
+		p.replicaSet, err = database.NewReplicaSet(cfg, p.db.Pool, p.statements, poolOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to read replicas: %w", err)
+		}
+
+		if rw, ok := p.wl.(plugin.ReadRoutingWorkload); ok {
+			rw.SetReadPoolSelector(p.replicaSet)
+			log.Printf("📚 Routing reads to %d replica(s) using %s balancing", p.replicaSet.Size(), p.replicaSet.Balancing())
+		} else {
+			log.Printf("⚠️  Workload '%s' does not support read routing, all operations will use the primary", cfg.Workload)
+		}
+	}
+
+	// Schema & data control
+	switch {
+	case rebuild:
+		log.Printf("💥 Rebuilding: dropping and recreating schema + data")
+		if err := p.wl.Cleanup(ctx, p.db.Pool, cfg); err != nil {
+			return nil, fmt.Errorf("failed to cleanup: %w", err)
+		}
+		if err := p.wl.Setup(ctx, p.db.Pool, cfg); err != nil {
+			return nil, fmt.Errorf("failed to setup after rebuild: %w", err)
+		}
+
+	case setup:
+		log.Printf("🔧 Ensuring schema exists (no data load)")
+		if err := p.wl.Setup(ctx, p.db.Pool, cfg); err != nil {
+			return nil, fmt.Errorf("failed to setup schema: %w", err)
+		}
+
+	default:
+		log.Printf("⏭️  Skipping setup (--setup or --rebuild not used). Assuming schema and data exist.")
+	}
+
+	// Reconnect so statements can be prepared against the freshly created schema
+	if (rebuild || setup) && len(p.statements) > 0 && cfg.QueryExecMode == types.ExecModePrepared {
+		p.db.Pool.Reset()
+		if p.replicaSet != nil {
+			p.replicaSet.Reset()
+		}
+	}
+
+	// Distribute the load across agents when configured
+	if len(cfg.Distributed.Agents) > 0 {
+		p.fleet, err = newFleet(cfg, p.wl)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Close releases the connections and plugins of the run
+func (p *preparedRun) Close() {
+	if p.replicaSet != nil {
+		p.replicaSet.Close()
+	}
+	if p.db != nil {
+		p.db.Close()
+	}
+	_ = p.factory.Cleanup()
+}
+
+// run executes the workload for the configured duration, or until ctx is
+// done, with the configured collectors, samplers and fault schedule, then
+// stores the results and records the run in the history
+func (p *preparedRun) run(ctx context.Context, m *types.Metrics, opts runOptions) (*runOutcome, error) {
+	cfg := p.cfg
+	duration, err := time.ParseDuration(cfg.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration '%s': %w", cfg.Duration, err)
+	}
+
+	log.Printf("🚀 Starting %s workload for %v with %d workers", cfg.Workload, duration, cfg.Workers)
+
+	// Fingerprint the server settings and client environment of the run
+	m.SetRunMetadata(captureRunMetadata(cfg, p.factory, p.db.Pool))
+
+	// Start PostgreSQL statistics collector if enabled
+	var pgStatsCollector *database.PgStatsCollector
+	if cfg.CollectPgStats {
+		pgStatsCollector = database.NewPgStatsCollector(p.db.Pool, m, cfg.PgStatsStatements)
+		pgStatsCollector.SetStatementLimit(cfg.PgStatsStatementsTop)
+		pgStatsCollector.Start()
+		log.Printf("📊 PostgreSQL statistics collection enabled (pg_stat_statements: %v)", cfg.PgStatsStatements)
+		defer pgStatsCollector.Stop()
+	}
+
+	// Sample host resources alongside the workload. With agents the load
+	// is generated on the agent hosts, which sample themselves.
+	var hostSampler *hoststats.Sampler
+	if cfg.HostStats.Enabled {
+		hostSampler, err = hoststats.NewSampler(cfg, m, p.db.Pool, p.fleet == nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid host_stats configuration: %w", err)
+		}
+		hostSampler.Start()
+		log.Printf("🖥️  Sampling host resources every %s (server: %v)", hostSampler.Options().Interval, hostSampler.Options().Server)
+	}
+
+	// Prepare the fault injection schedule before the workload starts
+	var injector *chaos.Injector
+	if cfg.FaultInjection.Enabled {
+		injector, err = chaos.NewInjector(cfg, m, chaos.Options{AllowShell: opts.AllowShellFaults})
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare fault injection: %w", err)
+		}
+		log.Printf("💥 Fault injection enabled with %d scheduled event(s)", len(cfg.FaultInjection.Events))
+	}
+
+	// Sample replica replay lag alongside the workload
+	if p.replicaSet != nil {
+		p.replicaSet.StartLagSampler(m)
+	}
+
+	// A fleet runs on the agents, which start after the coordinator's start delay
+	runWorkload := p.wl.Run
+	runTimeout := duration
+	if p.fleet != nil {
+		runWorkload = p.fleet.Run
+		runTimeout += p.fleet.StartDelay()
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+
+	// Capture query plans while the workload runs
+	if p.planTracer != nil {
+		p.planTracer.Start()
+		log.Printf("🔍 Capturing query plans of statements slower than %s", p.planTracer.Options().Threshold)
+	}
+
+	// Start workload in a goroutine
+	errChan := make(chan error, 1)
+	go func() {
+		// Capture baseline statistics immediately before workload starts
+		if pgStatsCollector != nil {
+			pgStatsCollector.CaptureWorkloadBaseline()
+		}
+
+		errChan <- runWorkload(runCtx, p.db.Pool, cfg, m)
+	}()
+
+	// Fire fault events relative to the workload start
+	if injector != nil {
+		injector.Start(runCtx)
+	}
+	if opts.Started != nil {
+		opts.Started()
+	}
+
+	// Start periodic summary reporting if interval is configured
+	var summaryTicker *time.Ticker
+	var summaryDone chan bool
+	startTime := time.Now()
+	if opts.SummaryInterval > 0 {
+		summaryTicker = time.NewTicker(opts.SummaryInterval)
+		summaryDone = make(chan bool)
+		go func() {
+			for {
+				select {
+				case <-summaryTicker.C:
+					metrics.ReportSummary(cfg, m, time.Since(startTime))
+				case <-summaryDone:
+					return
+				}
+			}
+		}()
+	}
+
+	// Wait for either completion or the end of ctx
+	var workloadErr error
+	select {
+	case workloadErr = <-errChan:
+		// Workload completed normally
+	case <-ctx.Done():
+		log.Printf("🛑 Run interrupted, shutting down gracefully...")
+
+		// Wait a bit for workload to finish gracefully
+		select {
+		case workloadErr = <-errChan:
+			// Workload finished
+		case <-time.After(5 * time.Second):
+			log.Printf("⚠️  Workload didn't finish in 5 seconds, forcing shutdown")
+		}
+	}
+	endTime := time.Now()
+	interrupted := ctx.Err() != nil
+
+	// Clean up periodic summary ticker
+	if summaryTicker != nil {
+		summaryTicker.Stop()
+		summaryDone <- true
+	}
+
+	// Agents started together at the coordinator's synchronized start time
+	if p.fleet != nil && !p.fleet.StartedAt().IsZero() {
+		startTime = p.fleet.StartedAt()
+	}
+
+	// Calculate final PostgreSQL statistics after workload completion
+	if pgStatsCollector != nil {
+		if finalStats := pgStatsCollector.CalculateFinalStats(); finalStats != nil {
+			m.UpdatePgStats(finalStats)
+		}
+	}
+
+	// Stop observing fault events once the workload has finished
+	if injector != nil {
+		cancel()
+		injector.Wait()
+	}
+
+	// Stop sampling host resources once the workload has finished
+	if hostSampler != nil {
+		hostSampler.Stop()
+	}
+
+	// Publish final replica routing and lag statistics
+	if p.replicaSet != nil {
+		p.replicaSet.StopLagSampler(m)
+	}
+
+	// Save the captured plans and compare them with the previous run's
+	if p.planTracer != nil {
+		recordQueryPlans(cfg, p.planTracer, m, startTime)
+	}
+
+	// Initialize and store test results in database backend if configured
+	resultsBackend, err := results.CreateBackendFromConfig(cfg)
+	if err != nil {
+		log.Printf("⚠️  Failed to create results backend: %v", err)
+	} else if resultsBackend != nil {
+		defer resultsBackend.Close()
+
+		if err := results.StoreTestResults(context.Background(), resultsBackend, cfg, m, startTime, endTime); err != nil {
+			log.Printf("⚠️  Failed to store test results: %v", err)
+		} else {
+			log.Printf("💾 Test results stored in database backend")
+		}
+
+		// Perform maintenance (cleanup old results)
+		if err := results.PerformMaintenance(context.Background(), resultsBackend); err != nil {
+			log.Printf("⚠️  Failed to perform backend maintenance: %v", err)
+		}
+	}
+
+	// Record the run in the history store, the results database or local
+	// files. A stopped or interrupted run ends with a context error, not a failure.
+	historyErr := workloadErr
+	if interrupted {
+		historyErr = nil
+	}
+	recordHistory(cfg, m, resultsBackend, interrupted, historyErr, startTime, endTime)
+
+	return &runOutcome{
+		StartTime:   startTime,
+		EndTime:     endTime,
+		Interrupted: interrupted,
+		WorkloadErr: workloadErr,
+	}, nil
+}
@@ -0,0 +1,128 @@
+// cmd/stormdb/serve.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/server"
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// createServeCommand creates the serve command, which runs the HTTP control API
+func createServeCommand() *cobra.Command {
+	var (
+		listen           string
+		token            string
+		eventInterval    time.Duration
+		history          int
+		allowShellFaults bool
+	)
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the HTTP API for submitting and controlling test runs",
+		Long: `Run an HTTP server that accepts test configurations and executes them
+on this host, one run at a time. A token is required unless the server
+listens on a loopback address, and submitted fault_injection events with the
+shell action are rejected unless --allow-shell-faults is set.
+
+Endpoints (all under /api/v1 require the bearer token when one is set):
+  POST /api/v1/runs                 Submit a YAML or JSON config (?start=true&setup=true&rebuild=true&name=...)
+  GET  /api/v1/runs                 List runs
+  GET  /api/v1/runs/{id}            Run status
+  POST /api/v1/runs/{id}/start      Start a pending run
+  POST /api/v1/runs/{id}/stop       End a run early, keeping its results
+  POST /api/v1/runs/{id}/cancel     Cancel a pending or running run
+  GET  /api/v1/runs/{id}/metrics    Current metrics snapshot
+  GET  /api/v1/runs/{id}/events     Live status and metrics as server-sent events (?interval=1s)
+  GET  /api/v1/runs/{id}/results    Results of a finished run (?format=json|csv|junit|text)
+  GET  /healthz                     Health check`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if token == "" {
+				token = os.Getenv("STORMDB_API_TOKEN")
+			}
+			if token == "" {
+				if !isLoopbackAddr(listen) {
+					return fmt.Errorf("--token or $STORMDB_API_TOKEN is required when listening on non-loopback address %s", listen)
+				}
+				log.Printf("⚠️  No API token set, any local user can start runs on %s", listen)
+			}
+			if allowShellFaults {
+				log.Printf("⚠️  Shell fault actions are enabled, API clients can run commands on this host")
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			mgr := server.NewManager(runServedWorkload, server.ManagerOptions{
+				MaxHistory:       history,
+				AllowShellFaults: allowShellFaults,
+			})
+			srv := server.New(mgr, server.Options{
+				Addr:          listen,
+				Token:         token,
+				EventInterval: eventInterval,
+				Version:       Version,
+			})
+
+			log.Printf("🌐 StormDB API listening on %s", listen)
+			return srv.ListenAndServe(ctx)
+		},
+	}
+
+	serveCmd.Flags().StringVar(&listen, "listen", "127.0.0.1:8080", "Address to listen on")
+	serveCmd.Flags().StringVar(&token, "token", "", "Bearer token required by the API (default: $STORMDB_API_TOKEN)")
+	serveCmd.Flags().DurationVar(&eventInterval, "event-interval", time.Second, "Default interval between live metric events")
+	serveCmd.Flags().IntVar(&history, "history", 100, "Number of finished runs to keep (0 keeps all)")
+	serveCmd.Flags().BoolVar(&allowShellFaults, "allow-shell-faults", false, "Accept fault_injection events that run shell commands on this host")
+
+	return serveCmd
+}
+
+// isLoopbackAddr reports whether the host of a listen address only accepts
+// local connections. An empty host listens on every interface.
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// runServedWorkload executes a run submitted through the API. It prepares
+// and runs the workload like a regular CLI run, without periodic summaries
+// or the final report, which the API serves instead.
+func runServedWorkload(ctx context.Context, cfg *types.Config, m *types.Metrics, opts server.RunOptions, started func()) error {
+	prepared, err := prepareRun(ctx, cfg, opts.Setup, opts.Rebuild)
+	if err != nil {
+		return err
+	}
+	defer prepared.Close()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	outcome, err := prepared.run(ctx, m, runOptions{Started: started, AllowShellFaults: opts.AllowShellFaults})
+	if err != nil {
+		return err
+	}
+
+	// A stopped or cancelled run ends with a context error, not a failure
+	if outcome.WorkloadErr != nil && !outcome.Interrupted {
+		return fmt.Errorf("workload failed: %w", outcome.WorkloadErr)
+	}
+	log.Printf("✅ %s workload finished: %d transactions", cfg.Workload, m.TPS)
+	return nil
+}
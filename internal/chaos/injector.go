@@ -0,0 +1,344 @@
+// Package chaos runs a schedule of fault injection events against the
+// database while a workload is running. Each event executes an action such
+// as terminating the workload's sessions, forcing a checkpoint or running a
+// promote script, and the injector then watches the shared metrics to
+// measure how long the workload needs to recover.
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/database"
+	"github.com/elchinoo/stormdb/pkg/types"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Supported fault actions
+const (
+	ActionSQL               = "sql"
+	ActionShell             = "shell"
+	ActionTerminateBackends = "terminate_backends"
+	ActionCheckpoint        = "checkpoint"
+)
+
+// terminateBackendsSQL terminates every workload session in the target
+// database. Workload pools identify themselves via application_name.
+var terminateBackendsSQL = fmt.Sprintf(`
+SELECT count(pg_terminate_backend(pid))
+FROM pg_stat_activity
+WHERE application_name = '%s'
+  AND datname = current_database()
+  AND pid <> pg_backend_pid()`, database.ApplicationName)
+
+const (
+	defaultActionTimeout  = 30 * time.Second
+	defaultRecoveryWindow = time.Second
+	defaultObserveTimeout = 60 * time.Second
+	pollInterval          = 10 * time.Millisecond
+)
+
+// scheduledEvent is a validated fault event with parsed timings
+type scheduledEvent struct {
+	event   types.FaultEvent
+	at      time.Duration
+	timeout time.Duration
+}
+
+// Options controls which fault actions an injector accepts
+type Options struct {
+	AllowShell bool // Accept shell actions, which run commands on this host
+}
+
+// Injector fires scheduled fault events and records their impact
+type Injector struct {
+	cfg            *types.Config
+	metrics        *types.Metrics
+	events         []scheduledEvent
+	recoveryWindow time.Duration
+	observeTimeout time.Duration
+	wg             sync.WaitGroup
+}
+
+// NewInjector validates the fault injection schedule in the configuration
+// and returns an injector that records results into the given metrics
+func NewInjector(cfg *types.Config, metrics *types.Metrics, opts Options) (*Injector, error) {
+	in := &Injector{
+		cfg:            cfg,
+		metrics:        metrics,
+		recoveryWindow: defaultRecoveryWindow,
+		observeTimeout: defaultObserveTimeout,
+	}
+
+	var err error
+	if cfg.FaultInjection.RecoveryWindow != "" {
+		if in.recoveryWindow, err = time.ParseDuration(cfg.FaultInjection.RecoveryWindow); err != nil {
+			return nil, fmt.Errorf("invalid recovery_window '%s': %w", cfg.FaultInjection.RecoveryWindow, err)
+		}
+	}
+	if cfg.FaultInjection.ObserveTimeout != "" {
+		if in.observeTimeout, err = time.ParseDuration(cfg.FaultInjection.ObserveTimeout); err != nil {
+			return nil, fmt.Errorf("invalid observe_timeout '%s': %w", cfg.FaultInjection.ObserveTimeout, err)
+		}
+	}
+
+	for i, event := range cfg.FaultInjection.Events {
+		at, err := time.ParseDuration(event.At)
+		if err != nil {
+			return nil, fmt.Errorf("event %d: invalid at '%s': %w", i, event.At, err)
+		}
+
+		timeout := defaultActionTimeout
+		if event.Timeout != "" {
+			if timeout, err = time.ParseDuration(event.Timeout); err != nil {
+				return nil, fmt.Errorf("event %d: invalid timeout '%s': %w", i, event.Timeout, err)
+			}
+		}
+
+		if err := validateAction(event, opts); err != nil {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+
+		if event.Name == "" {
+			event.Name = fmt.Sprintf("%s@%s", event.Action, event.At)
+		}
+
+		in.events = append(in.events, scheduledEvent{event: event, at: at, timeout: timeout})
+	}
+
+	return in, nil
+}
+
+// validateAction checks that the event action is known and complete
+func validateAction(event types.FaultEvent, opts Options) error {
+	switch event.Action {
+	case ActionSQL:
+		if event.SQL == "" {
+			return fmt.Errorf("sql is required for action '%s'", ActionSQL)
+		}
+	case ActionShell:
+		if !opts.AllowShell {
+			return fmt.Errorf("shell actions are not allowed")
+		}
+		if event.Command == "" {
+			return fmt.Errorf("command is required for action '%s'", ActionShell)
+		}
+	case ActionTerminateBackends, ActionCheckpoint:
+	default:
+		return fmt.Errorf("unknown fault action: %s", event.Action)
+	}
+	return nil
+}
+
+// Start schedules every event relative to the current time. Events whose
+// offset falls after ctx is done are skipped.
+func (in *Injector) Start(ctx context.Context) {
+	start := time.Now()
+	for _, se := range in.events {
+		in.wg.Add(1)
+		go func(se scheduledEvent) {
+			defer in.wg.Done()
+
+			timer := time.NewTimer(time.Until(start.Add(se.at)))
+			defer timer.Stop()
+
+			select {
+			case <-ctx.Done():
+				log.Printf("⏭️  Skipping fault event '%s': run ended before %v", se.event.Name, se.at)
+				return
+			case <-timer.C:
+			}
+
+			in.metrics.RecordFaultEvent(in.fire(ctx, se))
+		}(se)
+	}
+}
+
+// Wait blocks until every scheduled event has fired and been observed
+func (in *Injector) Wait() {
+	in.wg.Wait()
+}
+
+// fire runs the event action while concurrently observing the workload
+func (in *Injector) fire(ctx context.Context, se scheduledEvent) types.FaultEventResult {
+	result := types.FaultEventResult{
+		Name:       se.event.Name,
+		Action:     se.event.Action,
+		FiredAt:    time.Now(),
+		Resolution: pollInterval,
+	}
+
+	log.Printf("💥 Injecting fault '%s' (%s)", se.event.Name, se.event.Action)
+	in.metrics.RecordTimeSeriesEvent(se.event.Name)
+
+	// Run the action in the background so the observer sees the impact
+	// while the action is still executing (e.g., a slow promote script)
+	actionDone := make(chan struct{})
+	go func() {
+		defer close(actionDone)
+		actionCtx, cancel := context.WithTimeout(context.Background(), se.timeout)
+		defer cancel()
+
+		if err := in.runAction(actionCtx, se.event); err != nil {
+			result.ActionError = err.Error()
+			log.Printf("⚠️  Fault '%s' action failed: %v", se.event.Name, err)
+		}
+		result.ActionDuration = time.Since(result.FiredAt)
+	}()
+
+	in.observe(ctx, &result)
+	<-actionDone
+
+	if result.Recovered {
+		log.Printf("✅ Recovered from fault '%s' in %v (%d errors, first txn after %v)",
+			result.Name, result.RecoveryTime, result.ErrorBurst, result.TimeToFirstTxn)
+	} else {
+		log.Printf("⚠️  Workload did not recover from fault '%s' within %v (%d errors)",
+			result.Name, in.observeTimeout, result.ErrorBurst)
+	}
+
+	return result
+}
+
+// observe polls the shared counters until the workload has seen successful
+// transactions followed by an error-free recovery window. Only transactions
+// that started after the fault, or after the last error, count when the
+// workload records commit start times; otherwise any new commit counts,
+// including one that was already in flight. Times are rounded up to the
+// polling interval.
+func (in *Injector) observe(ctx context.Context, result *types.FaultEventResult) {
+	fired := result.FiredAt
+	deadline := time.NewTimer(in.observeTimeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	baseTxns := atomic.LoadInt64(&in.metrics.TPS)
+	baseCommitStart := in.metrics.LatestCommitStart()
+	result.CommitTracking = !baseCommitStart.IsZero()
+	baseErrors := in.errorCount()
+	lastErrors := baseErrors
+	lastErrorAt := fired
+	txnsAtLastError := baseTxns
+
+	for {
+		select {
+		case <-ctx.Done():
+			result.ErrorBurst = lastErrors - baseErrors
+			return
+		case <-deadline.C:
+			result.ErrorBurst = lastErrors - baseErrors
+			return
+		case now := <-ticker.C:
+			txns := atomic.LoadInt64(&in.metrics.TPS)
+			errors := in.errorCount()
+
+			// A workload that records commit starts at all does so for
+			// every commit, so its first record switches to tracking
+			latestStart := in.metrics.LatestCommitStart()
+			if latestStart.After(baseCommitStart) {
+				result.CommitTracking = true
+			}
+			committedAfter := func(t time.Time, txnsAt int64) bool {
+				if result.CommitTracking {
+					return !latestStart.Before(t)
+				}
+				return txns > txnsAt
+			}
+
+			if !result.FirstTxnObserved && committedAfter(fired, baseTxns) {
+				result.FirstTxnObserved = true
+				result.TimeToFirstTxn = now.Sub(fired)
+			}
+
+			if errors > lastErrors {
+				lastErrors = errors
+				lastErrorAt = now
+				txnsAtLastError = txns
+				continue
+			}
+
+			// Recovered once transactions succeed after the last error and
+			// no new errors arrived for a full recovery window
+			if result.FirstTxnObserved && committedAfter(lastErrorAt, txnsAtLastError) &&
+				now.Sub(lastErrorAt) >= in.recoveryWindow {
+				result.Recovered = true
+				result.ErrorBurst = lastErrors - baseErrors
+				result.RecoveryTime = lastErrorAt.Sub(fired)
+				if result.TimeToFirstTxn > result.RecoveryTime {
+					result.RecoveryTime = result.TimeToFirstTxn
+				}
+				return
+			}
+		}
+	}
+}
+
+// errorCount returns errors plus aborted transactions
+func (in *Injector) errorCount() int64 {
+	return atomic.LoadInt64(&in.metrics.Errors) + atomic.LoadInt64(&in.metrics.TPSAborted)
+}
+
+// runAction executes the action of a single event
+func (in *Injector) runAction(ctx context.Context, event types.FaultEvent) error {
+	switch event.Action {
+	case ActionSQL:
+		return in.execSQL(ctx, event.SQL)
+	case ActionTerminateBackends:
+		return in.execSQL(ctx, terminateBackendsSQL)
+	case ActionCheckpoint:
+		return in.execSQL(ctx, "CHECKPOINT")
+	case ActionShell:
+		return in.execShell(ctx, event)
+	default:
+		return fmt.Errorf("unknown fault action: %s", event.Action)
+	}
+}
+
+// execSQL runs a statement on a dedicated connection outside the workload pool
+func (in *Injector) execSQL(ctx context.Context, sql string) error {
+	dsn := database.BuildConnectionString(in.cfg) + " application_name=stormdb_faults"
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("failed to execute fault SQL: %w", err)
+	}
+	return nil
+}
+
+// execShell runs a shell command with the target database exposed through
+// the standard libpq environment variables
+func (in *Injector) execShell(ctx context.Context, event types.FaultEvent) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", event.Command)
+	cmd.Env = append(os.Environ(),
+		"PGHOST="+in.cfg.Database.Host,
+		"PGPORT="+strconv.Itoa(in.cfg.Database.Port),
+		"PGDATABASE="+in.cfg.Database.Dbname,
+		"PGUSER="+in.cfg.Database.Username,
+		"PGPASSWORD="+in.cfg.Database.Password,
+		"STORMDB_FAULT_NAME="+event.Name,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		out := strings.TrimSpace(string(output))
+		if out != "" {
+			return fmt.Errorf("command failed: %w: %s", err, out)
+		}
+		return fmt.Errorf("command failed: %w", err)
+	}
+	return nil
+}
@@ -2,9 +2,13 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/elchinoo/stormdb/pkg/keydist"
 	"github.com/elchinoo/stormdb/pkg/types"
 
 	"github.com/spf13/viper"
@@ -15,9 +19,35 @@ func Load(configFile string) (*types.Config, error) {
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
 	}
+	return decode(viper.GetViper())
+}
 
+// Parse reads a configuration document of configType ("yaml" or "json")
+// into the global viper instance, where plugins look up workload_config,
+// the same way Load does for a file.
+func Parse(data []byte, configType string) (*types.Config, error) {
+	viper.SetConfigType(configType)
+	if err := viper.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return decode(viper.GetViper())
+}
+
+// Check validates a configuration document like Parse without touching the
+// global viper instance, so it is safe while a workload is running.
+func Check(data []byte, configType string) (*types.Config, error) {
+	v := viper.New()
+	v.SetConfigType(configType)
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return decode(v)
+}
+
+// decode unmarshals and validates the configuration held by v
+func decode(v *viper.Viper) (*types.Config, error) {
 	var cfg types.Config
-	if err := viper.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, err
 	}
 
@@ -93,6 +123,75 @@ func validateConfig(cfg *types.Config) error {
 		return fmt.Errorf("invalid sslmode: %s (valid: disable, require, verify-ca, verify-full)", cfg.Database.Sslmode)
 	}
 
+	// Validate query execution mode and comparison modes
+	if cfg.QueryExecMode != "" && !slices.Contains(types.QueryExecModes, cfg.QueryExecMode) {
+		return fmt.Errorf("invalid query_exec_mode: %s (valid: %s)", cfg.QueryExecMode, strings.Join(types.QueryExecModes, ", "))
+	}
+	if cfg.ExecModeComparison.Enabled {
+		for _, mode := range cfg.ExecModeComparison.Modes {
+			if !slices.Contains(types.QueryExecModes, mode) {
+				return fmt.Errorf("invalid exec_mode_comparison mode: %s", mode)
+			}
+		}
+		if cfg.ExecModeComparison.Duration != "" {
+			if _, err := time.ParseDuration(cfg.ExecModeComparison.Duration); err != nil {
+				return fmt.Errorf("invalid exec_mode_comparison duration format: %s", cfg.ExecModeComparison.Duration)
+			}
+		}
+	}
+
+	// Validate key distribution (if specified)
+	if err := keydist.ConfigFrom(cfg).Validate(); err != nil {
+		return fmt.Errorf("key_distribution configuration error: %w", err)
+	}
+
+	// Validate read replica configuration (if enabled)
+	if cfg.Replicas.Enabled {
+		if err := validateReplicaConfig(cfg); err != nil {
+			return fmt.Errorf("replica configuration error: %w", err)
+		}
+	}
+
+	// Validate fault injection schedule (if enabled)
+	if cfg.FaultInjection.Enabled {
+		if err := validateFaultInjectionConfig(cfg); err != nil {
+			return fmt.Errorf("fault injection configuration error: %w", err)
+		}
+	}
+
+	// Validate distributed load generation (if agents are listed)
+	if len(cfg.Distributed.Agents) > 0 {
+		if err := validateDistributedConfig(cfg); err != nil {
+			return fmt.Errorf("distributed configuration error: %w", err)
+		}
+	}
+
+	// Validate pg_stat_statements ranking size
+	if cfg.PgStatsStatementsTop < 0 {
+		return fmt.Errorf("pg_stats_statements_top must not be negative, got %d", cfg.PgStatsStatementsTop)
+	}
+
+	// Validate query plan capture (if enabled)
+	if cfg.Explain.Enabled {
+		if err := validateExplainConfig(cfg); err != nil {
+			return fmt.Errorf("explain configuration error: %w", err)
+		}
+	}
+
+	// Validate host resource sampling (if enabled)
+	if cfg.HostStats.Enabled {
+		if err := validateHostStatsConfig(cfg); err != nil {
+			return fmt.Errorf("host_stats configuration error: %w", err)
+		}
+	}
+
+	// Validate traffic replay (if a capture is configured)
+	if cfg.Replay.File != "" || cfg.Workload == "replay" {
+		if err := validateReplayConfig(cfg); err != nil {
+			return fmt.Errorf("replay configuration error: %w", err)
+		}
+	}
+
 	// Validate data loading configuration (if specified)
 	if cfg.DataLoading.Mode != "" {
 		validModes := map[string]bool{
@@ -117,6 +216,172 @@ func validateConfig(cfg *types.Config) error {
 	return nil
 }
 
+// validateDistributedConfig validates the coordinator settings
+func validateDistributedConfig(cfg *types.Config) error {
+	seen := make(map[string]bool)
+	for _, agent := range cfg.Distributed.Agents {
+		if agent == "" {
+			return fmt.Errorf("agent address must not be empty")
+		}
+		if seen[agent] {
+			return fmt.Errorf("duplicate agent address: %s", agent)
+		}
+		seen[agent] = true
+	}
+	if cfg.Distributed.StartDelay != "" {
+		if d, err := time.ParseDuration(cfg.Distributed.StartDelay); err != nil || d < 0 {
+			return fmt.Errorf("invalid start_delay: %s", cfg.Distributed.StartDelay)
+		}
+	}
+	if cfg.ExecModeComparison.Enabled {
+		return fmt.Errorf("exec_mode_comparison is not supported with agents")
+	}
+	return nil
+}
+
+// validateExplainConfig validates the query plan capture settings
+func validateExplainConfig(cfg *types.Config) error {
+	if cfg.Explain.Threshold != "" {
+		if d, err := time.ParseDuration(cfg.Explain.Threshold); err != nil || d < 0 {
+			return fmt.Errorf("invalid threshold: %s", cfg.Explain.Threshold)
+		}
+	}
+	if cfg.Explain.SampleRate < 0 || cfg.Explain.SampleRate > 1 {
+		return fmt.Errorf("sample_rate must be between 0 and 1, got %g", cfg.Explain.SampleRate)
+	}
+	if cfg.Explain.MaxPlans < 0 {
+		return fmt.Errorf("max_plans must not be negative, got %d", cfg.Explain.MaxPlans)
+	}
+	return nil
+}
+
+// validateHostStatsConfig validates the host resource sampling settings
+func validateHostStatsConfig(cfg *types.Config) error {
+	if cfg.HostStats.Interval != "" {
+		if d, err := time.ParseDuration(cfg.HostStats.Interval); err != nil || d <= 0 {
+			return fmt.Errorf("invalid interval: %s", cfg.HostStats.Interval)
+		}
+	}
+	if cfg.HostStats.CPUThreshold < 0 || cfg.HostStats.CPUThreshold > 100 {
+		return fmt.Errorf("cpu_threshold must be between 0 and 100, got %g", cfg.HostStats.CPUThreshold)
+	}
+	return nil
+}
+
+// validateReplayConfig validates the traffic replay settings
+func validateReplayConfig(cfg *types.Config) error {
+	if cfg.Replay.File == "" {
+		return fmt.Errorf("file is required for the replay workload")
+	}
+	switch cfg.Replay.Format {
+	case "", "csvlog", "jsonlog", "jsonl":
+	default:
+		return fmt.Errorf("invalid format: %s (valid: csvlog, jsonlog, jsonl)", cfg.Replay.Format)
+	}
+	if cfg.Replay.Speed < 0 {
+		return fmt.Errorf("speed must not be negative, got %g", cfg.Replay.Speed)
+	}
+	return nil
+}
+
+// validateReplicaConfig validates read replica routing configuration
+func validateReplicaConfig(cfg *types.Config) error {
+	if len(cfg.Replicas.Endpoints) == 0 {
+		return fmt.Errorf("at least one replica endpoint is required when replicas are enabled")
+	}
+
+	switch cfg.Replicas.Balancing {
+	case "", "round_robin", "least_loaded":
+	default:
+		return fmt.Errorf("invalid balancing: %s (valid: round_robin, least_loaded)", cfg.Replicas.Balancing)
+	}
+
+	if cfg.Replicas.LagSampleInterval != "" {
+		interval, err := time.ParseDuration(cfg.Replicas.LagSampleInterval)
+		if err != nil {
+			return fmt.Errorf("invalid lag_sample_interval format: %s", cfg.Replicas.LagSampleInterval)
+		}
+		if interval <= 0 {
+			return fmt.Errorf("lag_sample_interval must be positive, got: %s", cfg.Replicas.LagSampleInterval)
+		}
+	}
+
+	if cfg.Replicas.MaxLag != "" {
+		maxLag, err := time.ParseDuration(cfg.Replicas.MaxLag)
+		if err != nil {
+			return fmt.Errorf("invalid max_lag format: %s", cfg.Replicas.MaxLag)
+		}
+		if maxLag <= 0 {
+			return fmt.Errorf("max_lag must be positive, got: %s", cfg.Replicas.MaxLag)
+		}
+	}
+
+	for i, endpoint := range cfg.Replicas.Endpoints {
+		if endpoint.Host == "" {
+			return fmt.Errorf("replica endpoint %d: host is required", i)
+		}
+		if endpoint.Port < 0 || endpoint.Port > 65535 {
+			return fmt.Errorf("replica endpoint %d: port must be between 1-65535, got: %d", i, endpoint.Port)
+		}
+	}
+
+	return nil
+}
+
+// validateFaultInjectionConfig validates the fault injection schedule
+func validateFaultInjectionConfig(cfg *types.Config) error {
+	if len(cfg.FaultInjection.Events) == 0 {
+		return fmt.Errorf("at least one event is required when fault injection is enabled")
+	}
+
+	for name, value := range map[string]string{
+		"recovery_window": cfg.FaultInjection.RecoveryWindow,
+		"observe_timeout": cfg.FaultInjection.ObserveTimeout,
+	} {
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s format: %s", name, value)
+		}
+		if d <= 0 {
+			return fmt.Errorf("%s must be positive, got: %s", name, value)
+		}
+	}
+
+	for i, event := range cfg.FaultInjection.Events {
+		at, err := time.ParseDuration(event.At)
+		if err != nil {
+			return fmt.Errorf("event %d: invalid at format: %s", i, event.At)
+		}
+		if at < 0 {
+			return fmt.Errorf("event %d: at must be non-negative, got: %s", i, event.At)
+		}
+		if event.Timeout != "" {
+			if _, err := time.ParseDuration(event.Timeout); err != nil {
+				return fmt.Errorf("event %d: invalid timeout format: %s", i, event.Timeout)
+			}
+		}
+
+		switch event.Action {
+		case "sql":
+			if event.SQL == "" {
+				return fmt.Errorf("event %d: sql is required for action 'sql'", i)
+			}
+		case "shell":
+			if event.Command == "" {
+				return fmt.Errorf("event %d: command is required for action 'shell'", i)
+			}
+		case "terminate_backends", "checkpoint":
+		default:
+			return fmt.Errorf("event %d: invalid action: %s (valid: sql, shell, terminate_backends, checkpoint)", i, event.Action)
+		}
+	}
+
+	return nil
+}
+
 // validateProgressiveConfig validates progressive scaling configuration
 func validateProgressiveConfig(p *struct {
 	Enabled           bool   `mapstructure:"enabled"`
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/elchinoo/stormdb/pkg/types"
@@ -523,3 +524,151 @@ func TestValidateDataLoadingConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateReplicaConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		balancing   string
+		interval    string
+		endpoints   []types.ReplicaEndpoint
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:      "valid round robin",
+			endpoints: []types.ReplicaEndpoint{{Host: "replica1"}, {Host: "replica2", Port: 5433}},
+		},
+		{
+			name:      "valid least loaded with interval",
+			balancing: "least_loaded",
+			interval:  "2s",
+			endpoints: []types.ReplicaEndpoint{{Host: "replica1"}},
+		},
+		{
+			name:        "no endpoints",
+			expectError: true,
+			errorMsg:    "at least one replica endpoint is required",
+		},
+		{
+			name:        "invalid balancing",
+			balancing:   "random",
+			endpoints:   []types.ReplicaEndpoint{{Host: "replica1"}},
+			expectError: true,
+			errorMsg:    "invalid balancing",
+		},
+		{
+			name:        "invalid lag sample interval",
+			interval:    "often",
+			endpoints:   []types.ReplicaEndpoint{{Host: "replica1"}},
+			expectError: true,
+			errorMsg:    "invalid lag_sample_interval",
+		},
+		{
+			name:        "missing host",
+			endpoints:   []types.ReplicaEndpoint{{Port: 5433}},
+			expectError: true,
+			errorMsg:    "host is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &types.Config{
+				Duration:    "30s",
+				Workers:     4,
+				Connections: 8,
+			}
+			cfg.Database.Host = "localhost"
+			cfg.Database.Port = 5432
+			cfg.Database.Dbname = "test"
+			cfg.Database.Username = "user"
+			cfg.Replicas.Enabled = true
+			cfg.Replicas.Balancing = tt.balancing
+			cfg.Replicas.LagSampleInterval = tt.interval
+			cfg.Replicas.Endpoints = tt.endpoints
+
+			err := validateConfig(cfg)
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("Expected error containing '%s', got: %v", tt.errorMsg, err)
+				}
+			} else if err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateFaultInjectionConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		window      string
+		events      []types.FaultEvent
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name: "valid schedule",
+			events: []types.FaultEvent{
+				{At: "30s", Action: "terminate_backends"},
+				{At: "1m", Action: "shell", Command: "./promote.sh", Timeout: "20s"},
+			},
+		},
+		{
+			name:        "no events",
+			expectError: true,
+			errorMsg:    "at least one event is required",
+		},
+		{
+			name:        "invalid at",
+			events:      []types.FaultEvent{{At: "soon", Action: "checkpoint"}},
+			expectError: true,
+			errorMsg:    "invalid at format",
+		},
+		{
+			name:        "unknown action",
+			events:      []types.FaultEvent{{At: "10s", Action: "reboot"}},
+			expectError: true,
+			errorMsg:    "invalid action",
+		},
+		{
+			name:        "sql action without statement",
+			events:      []types.FaultEvent{{At: "10s", Action: "sql"}},
+			expectError: true,
+			errorMsg:    "sql is required",
+		},
+		{
+			name:        "invalid recovery window",
+			window:      "0s",
+			events:      []types.FaultEvent{{At: "10s", Action: "checkpoint"}},
+			expectError: true,
+			errorMsg:    "recovery_window must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &types.Config{
+				Duration:    "30s",
+				Workers:     4,
+				Connections: 8,
+			}
+			cfg.Database.Host = "localhost"
+			cfg.Database.Port = 5432
+			cfg.Database.Dbname = "test"
+			cfg.Database.Username = "user"
+			cfg.FaultInjection.Enabled = true
+			cfg.FaultInjection.RecoveryWindow = tt.window
+			cfg.FaultInjection.Events = tt.events
+
+			err := validateConfig(cfg)
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("Expected error containing '%s', got: %v", tt.errorMsg, err)
+				}
+			} else if err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -23,20 +24,89 @@ type TestExecutionUseCase struct {
 	workloadRegistry ports.WorkloadRegistry
 	executionEngine  ports.TestExecutionEngine
 
-	// Active executions tracking (thread-safe)
+	// Active executions tracking (thread-safe). Submitted executions stay
+	// tracked after they finish, until ForgetExecution.
 	activeExecutions sync.Map // map[string]*ExecutionContext
 }
 
+// Errors returned by the execution lifecycle
+var (
+	ErrExecutionNotFound = errors.New("execution not found")
+	ErrExecutionState    = errors.New("execution is not in a valid state for this action")
+)
+
 type ExecutionContext struct {
-	ID          string
-	CancelFunc  context.CancelFunc
+	ID           string
+	Name         string
+	WorkloadType string
+	CancelFunc   context.CancelFunc
+	Status       domain.ExecutionStatus
+	CreatedAt    time.Time
+	StartTime    time.Time // When the workload began, after setup
+	EndTime      time.Time
+	Err          error
+	Interrupted  bool // Stopped or cancelled before the workload ended
+	CurrentBand  int
+	TotalBands   int
+	Results      *domain.TestResults
+	Config       *domain.TestConfiguration
+	done         chan struct{}
+	mutex        sync.RWMutex
+}
+
+// ExecutionState is a consistent view of an execution's lifecycle
+type ExecutionState struct {
 	Status      domain.ExecutionStatus
+	CreatedAt   time.Time
 	StartTime   time.Time
-	CurrentBand int
-	TotalBands  int
-	Results     *domain.TestResults
-	Config      *domain.TestConfiguration
-	mutex       sync.RWMutex
+	EndTime     time.Time
+	Err         error
+	Interrupted bool
+}
+
+// Finished reports whether the execution has ended
+func (s ExecutionState) Finished() bool {
+	switch s.Status {
+	case domain.StatusCompleted, domain.StatusFailed, domain.StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// State returns the lifecycle state of ec
+func (ec *ExecutionContext) State() ExecutionState {
+	ec.mutex.RLock()
+	defer ec.mutex.RUnlock()
+	return ExecutionState{
+		Status:      ec.Status,
+		CreatedAt:   ec.CreatedAt,
+		StartTime:   ec.StartTime,
+		EndTime:     ec.EndTime,
+		Err:         ec.Err,
+		Interrupted: ec.Interrupted,
+	}
+}
+
+// Done returns a channel closed when a submitted execution has finished
+func (ec *ExecutionContext) Done() <-chan struct{} {
+	return ec.done
+}
+
+// markStarted records when the workload began
+func (ec *ExecutionContext) markStarted() {
+	ec.mutex.Lock()
+	defer ec.mutex.Unlock()
+	if ec.StartTime.IsZero() {
+		ec.StartTime = time.Now()
+	}
+}
+
+// finish records the end of ec and releases its waiters. Callers hold
+// ec.mutex and have just moved ec out of the pending or running status, so
+// it runs once per execution.
+func (ec *ExecutionContext) finish() {
+	ec.EndTime = time.Now()
+	close(ec.done)
 }
 
 func NewTestExecutionUseCase(
@@ -79,9 +149,11 @@ func (uc *TestExecutionUseCase) ExecuteTest(ctx context.Context, configName stri
 		ID:         executionID,
 		CancelFunc: cancelFunc,
 		Status:     domain.StatusRunning,
+		CreatedAt:  time.Now(),
 		StartTime:  time.Now(),
 		TotalBands: len(config.ProgressiveConfig.WorkerSteps),
 		Config:     config,
+		done:       make(chan struct{}),
 	}
 
 	uc.activeExecutions.Store(executionID, execContext)
@@ -323,6 +395,9 @@ func (uc *TestExecutionUseCase) updateExecutionStatus(executionID string, status
 		execContext.mutex.Unlock()
 
 		// Also update in repository (best effort)
+		if uc.testRepo == nil {
+			return
+		}
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
@@ -338,7 +413,7 @@ func (uc *TestExecutionUseCase) updateExecutionStatus(executionID string, status
 			}
 			if err := uc.testRepo.Store(ctx, testExecution); err != nil {
 				// Log storage error but continue monitoring
-				fmt.Printf("Warning: Failed to store test execution: %v\n", err)
+				log.Printf("Warning: Failed to store test execution: %v", err)
 			}
 		}
 	}
@@ -351,7 +426,10 @@ func (uc *TestExecutionUseCase) GetExecutionStatus(executionID string) (*Executi
 		execContext.mutex.RLock()
 		defer execContext.mutex.RUnlock()
 
-		progress := float64(execContext.CurrentBand) / float64(execContext.TotalBands)
+		var progress float64
+		if execContext.TotalBands > 0 {
+			progress = float64(execContext.CurrentBand) / float64(execContext.TotalBands)
+		}
 
 		return &ExecutionStatusInfo{
 			ID:          execContext.ID,
@@ -365,15 +443,186 @@ func (uc *TestExecutionUseCase) GetExecutionStatus(executionID string) (*Executi
 	return nil, false
 }
 
-// CancelExecution cancels a running execution
-func (uc *TestExecutionUseCase) CancelExecution(executionID string) error {
+// ExecutionFunc performs the work of a submitted execution. It calls
+// started once any setup is done and the workload begins, and returns when
+// the workload ends or ctx is done.
+type ExecutionFunc func(ctx context.Context, started func()) error
+
+// SubmitExecution registers a pending execution, run later by StartExecution
+func (uc *TestExecutionUseCase) SubmitExecution(name, workloadType string) *ExecutionContext {
+	execContext := &ExecutionContext{
+		ID:           uuid.New().String(),
+		Name:         name,
+		WorkloadType: workloadType,
+		Status:       domain.StatusPending,
+		CreatedAt:    time.Now(),
+		done:         make(chan struct{}),
+	}
+	uc.activeExecutions.Store(execContext.ID, execContext)
+	return execContext
+}
+
+// TrackedExecution returns a submitted or running execution
+func (uc *TestExecutionUseCase) TrackedExecution(executionID string) (*ExecutionContext, error) {
 	if execContextValue, exists := uc.activeExecutions.Load(executionID); exists {
-		execContext := execContextValue.(*ExecutionContext)
+		return execContextValue.(*ExecutionContext), nil
+	}
+	return nil, ErrExecutionNotFound
+}
+
+// StartExecution runs a pending execution with run in the background
+func (uc *TestExecutionUseCase) StartExecution(executionID string, run ExecutionFunc) (*ExecutionContext, error) {
+	execContext, err := uc.TrackedExecution(executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	execContext.mutex.Lock()
+	defer execContext.mutex.Unlock()
+	if execContext.Status != domain.StatusPending {
+		return nil, ErrExecutionState
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	execContext.Status = domain.StatusRunning
+	execContext.CancelFunc = cancel
+
+	go uc.runExecution(ctx, execContext, run)
+	return execContext, nil
+}
+
+// runExecution performs a started execution and records its outcome
+func (uc *TestExecutionUseCase) runExecution(ctx context.Context, execContext *ExecutionContext, run ExecutionFunc) {
+	err := run(ctx, execContext.markStarted)
+
+	execContext.mutex.Lock()
+	defer execContext.mutex.Unlock()
+	if execContext.StartTime.IsZero() && err == nil && ctx.Err() == nil {
+		err = fmt.Errorf("execution returned before the workload started")
+	}
+	switch {
+	case execContext.Status == domain.StatusCancelled:
+		// Cancelled by the user, keep the status
+	case ctx.Err() != nil:
+		// Stopped by the user, the results so far are kept
+		execContext.Status = domain.StatusCompleted
+	case err != nil:
+		execContext.Status = domain.StatusFailed
+		execContext.Err = err
+	default:
+		execContext.Status = domain.StatusCompleted
+	}
+	execContext.Interrupted = ctx.Err() != nil
+	execContext.CancelFunc()
+	execContext.finish()
+}
+
+// StopExecution ends a running execution early. It completes with the
+// results gathered so far.
+func (uc *TestExecutionUseCase) StopExecution(executionID string) (*ExecutionContext, error) {
+	execContext, err := uc.TrackedExecution(executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	execContext.mutex.Lock()
+	defer execContext.mutex.Unlock()
+	if execContext.Status != domain.StatusRunning {
+		return nil, ErrExecutionState
+	}
+	execContext.CancelFunc()
+	return execContext, nil
+}
+
+// CancelExecution cancels a pending or running execution. A running
+// workload is stopped and its partial results are kept but marked cancelled.
+func (uc *TestExecutionUseCase) CancelExecution(executionID string) error {
+	execContext, err := uc.TrackedExecution(executionID)
+	if err != nil {
+		return err
+	}
+
+	execContext.mutex.Lock()
+	switch execContext.Status {
+	case domain.StatusPending:
+		execContext.Status = domain.StatusCancelled
+		execContext.finish()
+	case domain.StatusRunning:
+		execContext.Status = domain.StatusCancelled
 		execContext.CancelFunc()
-		uc.updateExecutionStatus(executionID, domain.StatusCancelled, "Cancelled by user")
+	default:
+		execContext.mutex.Unlock()
+		return ErrExecutionState
+	}
+	execContext.mutex.Unlock()
+
+	uc.updateExecutionStatus(executionID, domain.StatusCancelled, "Cancelled by user")
+	return nil
+}
+
+// ForgetExecution stops tracking a finished execution
+func (uc *TestExecutionUseCase) ForgetExecution(executionID string) {
+	uc.activeExecutions.Delete(executionID)
+}
+
+// RecordExecution stores an execution that ran outside the use case, such
+// as a standard CLI or API run, with its results, the aggregated metrics of
+// each band and its time-series points
+func (uc *TestExecutionUseCase) RecordExecution(ctx context.Context, execution *domain.TestExecution, points []ports.MetricPoint) error {
+	if err := uc.testRepo.Store(ctx, execution); err != nil {
+		return fmt.Errorf("failed to store execution: %w", err)
+	}
+	results := execution.Results
+	if results == nil {
 		return nil
 	}
-	return fmt.Errorf("execution %s not found or not active", executionID)
+	if err := uc.testRepo.StoreResults(ctx, execution.ID, results); err != nil {
+		return fmt.Errorf("failed to store results: %w", err)
+	}
+
+	var bands []domain.BandResults
+	if results.SingleBandResults != nil {
+		bands = append(bands, *results.SingleBandResults)
+	}
+	if results.ProgressiveResults != nil {
+		bands = append(bands, results.ProgressiveResults.Bands...)
+	}
+	for i := range bands {
+		if err := uc.metricsRepo.StoreAggregatedMetrics(ctx, execution.ID, i, &bands[i]); err != nil {
+			return fmt.Errorf("failed to store aggregated metrics: %w", err)
+		}
+	}
+	if len(points) > 0 {
+		if err := uc.metricsRepo.StoreMetricBatch(ctx, execution.ID, 0, points); err != nil {
+			return fmt.Errorf("failed to store time-series metrics: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListExecutions returns the recorded executions matching filters
+func (uc *TestExecutionUseCase) ListExecutions(ctx context.Context, filters ports.TestExecutionFilters) ([]*domain.TestExecution, error) {
+	return uc.testRepo.List(ctx, filters)
+}
+
+// GetExecution returns a recorded execution
+func (uc *TestExecutionUseCase) GetExecution(ctx context.Context, executionID string) (*domain.TestExecution, error) {
+	return uc.testRepo.GetByID(ctx, executionID)
+}
+
+// DeleteExecution removes a recorded execution and its metrics
+func (uc *TestExecutionUseCase) DeleteExecution(ctx context.Context, executionID string) error {
+	return uc.testRepo.Delete(ctx, executionID)
+}
+
+// CompareExecutions compares recorded executions side by side
+func (uc *TestExecutionUseCase) CompareExecutions(ctx context.Context, executionIDs []string) (*ports.ExecutionComparison, error) {
+	return uc.testRepo.CompareExecutions(ctx, executionIDs)
+}
+
+// GetPerformanceTrends returns the performance of a workload's completed
+// executions over time
+func (uc *TestExecutionUseCase) GetPerformanceTrends(ctx context.Context, workloadType string, timeRange ports.TimeRange) ([]ports.PerformanceTrend, error) {
+	return uc.testRepo.GetPerformanceTrends(ctx, workloadType, timeRange)
 }
 
 // Supporting types for the use case
@@ -0,0 +1,71 @@
+// internal/database/execmode.go
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var pgxExecModes = map[string]pgx.QueryExecMode{
+	types.ExecModeCacheStatement: pgx.QueryExecModeCacheStatement,
+	types.ExecModeCacheDescribe:  pgx.QueryExecModeCacheDescribe,
+	types.ExecModeDescribeExec:   pgx.QueryExecModeDescribeExec,
+	types.ExecModeExec:           pgx.QueryExecModeExec,
+	types.ExecModeSimpleProtocol: pgx.QueryExecModeSimpleProtocol,
+	// Statements that were not explicitly prepared fall back to the cache
+	types.ExecModePrepared: pgx.QueryExecModeCacheStatement,
+}
+
+// IsValidQueryExecMode reports whether mode is a supported query_exec_mode
+func IsValidQueryExecMode(mode string) bool {
+	_, ok := pgxExecModes[mode]
+	return ok
+}
+
+// ConfigureQueryExecMode applies a query execution mode to a pool
+// configuration. An empty mode keeps the pgx default (cache_statement).
+// In prepared mode the given statements are prepared on every new
+// connection, named after their SQL text so pgx uses them directly.
+// Statements that cannot be prepared yet (e.g., before the schema exists)
+// fall back to the statement cache.
+func ConfigureQueryExecMode(poolCfg *pgxpool.Config, mode string, statements []string) error {
+	if mode == "" {
+		return nil
+	}
+
+	execMode, ok := pgxExecModes[mode]
+	if !ok {
+		return fmt.Errorf("unsupported query_exec_mode: %s", mode)
+	}
+	poolCfg.ConnConfig.DefaultQueryExecMode = execMode
+
+	if mode != types.ExecModePrepared || len(statements) == 0 {
+		return nil
+	}
+
+	var warnOnce sync.Once
+	afterConnect := poolCfg.AfterConnect
+	poolCfg.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		if afterConnect != nil {
+			if err := afterConnect(ctx, conn); err != nil {
+				return err
+			}
+		}
+		for _, sql := range statements {
+			if _, err := conn.Prepare(ctx, sql, sql); err != nil {
+				warnOnce.Do(func() {
+					log.Printf("⚠️  Warning: failed to prepare statement, using statement cache instead: %v", err)
+				})
+			}
+		}
+		return nil
+	}
+
+	return nil
+}
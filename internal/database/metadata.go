@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/elchinoo/stormdb/pkg/types"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// sizingSettings are recorded whatever their source, as hints of the
+// resources the server was sized for
+var sizingSettings = []string{
+	"shared_buffers",
+	"effective_cache_size",
+	"work_mem",
+	"maintenance_work_mem",
+	"max_wal_size",
+	"max_connections",
+	"max_worker_processes",
+	"max_parallel_workers",
+}
+
+// settingsQuery lists the settings changed in the server configuration,
+// leaving out those set by the client or the session, plus the sizing
+// settings. current_setting formats values with their unit (e.g. 128MB).
+const settingsQuery = `
+	SELECT name, current_setting(name)
+	FROM pg_settings
+	WHERE source NOT IN ('default', 'override', 'client', 'session')
+	   OR name = ANY($1)`
+
+// RedactedValue replaces setting values that may hold credentials
+const RedactedValue = "[redacted]"
+
+// conninfoPassword matches the password of a key/value connection string
+// and the user info of a connection URI
+var conninfoPassword = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)|(://[^:/@\s]*:)[^@\s]*@`)
+
+// RedactSetting hides credentials in a server setting before it is stored
+// with the run. Connection strings (primary_conninfo and other *_conninfo
+// settings) keep everything but their password; shell commands such as
+// archive_command or ssl_passphrase_command are replaced entirely, as they
+// may embed secrets anywhere.
+func RedactSetting(name, value string) string {
+	switch {
+	case value == "":
+		return value
+	case strings.HasSuffix(name, "_conninfo"):
+		return conninfoPassword.ReplaceAllStringFunc(value, func(match string) string {
+			parts := conninfoPassword.FindStringSubmatch(match)
+			if parts[1] != "" {
+				return parts[1] + RedactedValue
+			}
+			return parts[3] + RedactedValue + "@"
+		})
+	case strings.HasSuffix(name, "_command"):
+		return RedactedValue
+	}
+	return value
+}
+
+// CaptureServerMetadata fills md with the server version, its non-default
+// and sizing settings, and its installed extensions
+func CaptureServerMetadata(ctx context.Context, pool *pgxpool.Pool, md *types.RunMetadata) error {
+	err := pool.QueryRow(ctx, "SELECT version(), current_setting('server_version_num')::int").
+		Scan(&md.ServerVersion, &md.ServerVersionNum)
+	if err != nil {
+		return fmt.Errorf("failed to read server version: %w", err)
+	}
+
+	if md.Settings, err = queryPairs(ctx, pool, settingsQuery, sizingSettings); err != nil {
+		return fmt.Errorf("failed to read server settings: %w", err)
+	}
+	for name, value := range md.Settings {
+		md.Settings[name] = RedactSetting(name, value)
+	}
+	if md.Extensions, err = queryPairs(ctx, pool, "SELECT extname, extversion FROM pg_extension"); err != nil {
+		return fmt.Errorf("failed to read installed extensions: %w", err)
+	}
+	return nil
+}
+
+// queryPairs collects the two text columns of a query into a map
+func queryPairs(ctx context.Context, pool *pgxpool.Pool, query string, args ...any) (map[string]string, error) {
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pairs := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		pairs[key] = value
+	}
+	return pairs, rows.Err()
+}
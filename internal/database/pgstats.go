@@ -15,8 +15,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/elchinoo/stormdb/pkg/types"
@@ -58,8 +60,19 @@ type PgStatsCollector struct {
 	baselineStats     *types.PostgreSQLStats // Baseline statistics captured at workload start
 	startTime         time.Time              // When statistics collection started
 	workloadBaseline  *types.PostgreSQLStats // Precise baseline captured at workload start
+
+	statementLimit    int                            // Statements reported per ranking dimension
+	statementBaseline map[int64]types.StatementStats // pg_stat_statements snapshot at workload start
+	finished          atomic.Bool                    // Final statistics calculated; periodic updates stop
 }
 
+// DefaultStatementLimit is the number of statements reported per ranking
+// dimension when the configuration sets none
+const DefaultStatementLimit = 10
+
+// topQueriesLimit is the number of statements kept in TopQueries
+const topQueriesLimit = 5
+
 // NewPgStatsCollector creates a new PostgreSQL statistics collector with
 // automatic version detection and configuration.
 //
@@ -91,6 +104,7 @@ func NewPgStatsCollector(pool *pgxpool.Pool, metrics *types.Metrics, collectStat
 		metrics:           metrics,
 		collectInterval:   5 * time.Second, // Collect every 5 seconds
 		collectStatements: collectStatements,
+		statementLimit:    DefaultStatementLimit,
 		ctx:               ctx,
 		cancel:            cancel,
 	}
@@ -106,6 +120,14 @@ func (c *PgStatsCollector) Start() {
 	go c.collectLoop()
 }
 
+// SetStatementLimit sets the number of statements reported per ranking
+// dimension of the pg_stat_statements deltas
+func (c *PgStatsCollector) SetStatementLimit(n int) {
+	if n > 0 {
+		c.statementLimit = n
+	}
+}
+
 // CaptureWorkloadBaseline captures baseline statistics immediately before workload execution
 // This provides more accurate delta calculations by excluding setup/preparation activity
 func (c *PgStatsCollector) CaptureWorkloadBaseline() {
@@ -133,6 +155,15 @@ func (c *PgStatsCollector) CaptureWorkloadBaseline() {
 		log.Printf("Warning: Failed to collect workload baseline checkpoint stats: %v", err)
 	}
 
+	// Snapshot pg_stat_statements so only the workload's executions are reported
+	if c.collectStatements {
+		snapshot, err := c.snapshotStatements()
+		if err != nil {
+			log.Printf("Warning: Failed to snapshot pg_stat_statements: %v", err)
+		}
+		c.statementBaseline = snapshot
+	}
+
 	c.workloadBaseline = baseline
 	log.Printf("📊 Captured PostgreSQL workload baseline statistics")
 }
@@ -171,13 +202,16 @@ func (c *PgStatsCollector) CalculateFinalStats() *types.PostgreSQLStats {
 	// Calculate and return final deltas using workload baseline
 	deltaStats := c.calculateWorkloadDeltas(final)
 
-	// Collect pg_stat_statements for final summary
+	// Collect pg_stat_statements deltas for final summary
 	if c.collectStatements {
-		if err := c.collectStatementStats(deltaStats); err != nil {
+		if err := c.collectStatementDeltas(deltaStats); err != nil {
 			log.Printf("Warning: Failed to collect final statement stats: %v", err)
 		}
 	}
 
+	// The final deltas must not be overwritten by a periodic collection
+	c.finished.Store(true)
+
 	log.Printf("📊 Calculated final PostgreSQL workload statistics")
 	return deltaStats
 }
@@ -265,14 +299,11 @@ func (c *PgStatsCollector) collectStats() {
 	// Calculate deltas from baseline for cumulative statistics
 	deltaStats := c.calculateDeltas(current)
 
-	// Collect pg_stat_statements if enabled (these need special handling)
-	if c.collectStatements {
-		if err := c.collectStatementStats(deltaStats); err != nil {
-			log.Printf("Error collecting statement stats: %v", err)
-		}
+	// Update metrics unless the final workload statistics are in place.
+	// pg_stat_statements deltas are only calculated at workload end.
+	if c.finished.Load() {
+		return
 	}
-
-	// Update metrics
 	c.metrics.UpdatePgStats(deltaStats)
 }
 
@@ -423,6 +454,17 @@ func (c *PgStatsCollector) collectWALStats(stats *types.PostgreSQLStats) error {
 	return nil
 }
 
+// WALCounters returns the cumulative WAL records and bytes from pg_stat_wal
+// (PostgreSQL 14+). Workloads take the difference of two calls to measure
+// the WAL generated by one phase of a run. The counters are cluster-wide.
+func (c *PgStatsCollector) WALCounters(ctx context.Context) (records, bytes int64, err error) {
+	err = c.pool.QueryRow(ctx, "SELECT wal_records, wal_bytes FROM pg_stat_wal").Scan(&records, &bytes)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read pg_stat_wal: %w", err)
+	}
+	return records, bytes, nil
+}
+
 // collectCheckpointStats collects checkpoint statistics based on PostgreSQL version
 func (c *PgStatsCollector) collectCheckpointStats(stats *types.PostgreSQLStats) error {
 	if c.pgVersion >= 15 {
@@ -452,59 +494,134 @@ func (c *PgStatsCollector) collectActivityStats(stats *types.PostgreSQLStats) er
 	return nil
 }
 
-// collectStatementStats collects pg_stat_statements statistics if available
-func (c *PgStatsCollector) collectStatementStats(stats *types.PostgreSQLStats) error {
+// statementSnapshotQuery reads the pg_stat_statements counters of the
+// benchmark's user and database, summed over top-level and nested entries of
+// each statement. Statistics queries, including the collector's own, are
+// excluded.
+const statementSnapshotQuery = `
+SELECT
+	s.queryid,
+	min(s.query),
+	sum(s.calls)::bigint,
+	sum(s.total_exec_time),
+	sum(s.total_plan_time),
+	sum(s.rows)::bigint,
+	sum(s.shared_blks_hit)::bigint,
+	sum(s.shared_blks_read)::bigint,
+	sum(s.shared_blks_dirtied)::bigint,
+	sum(s.shared_blks_written)::bigint,
+	sum(s.local_blks_hit)::bigint,
+	sum(s.local_blks_read)::bigint,
+	sum(s.local_blks_written)::bigint,
+	sum(s.wal_records)::bigint,
+	sum(s.wal_bytes)::bigint
+FROM pg_stat_statements s
+WHERE s.userid = (SELECT oid FROM pg_roles WHERE rolname = current_user)
+	AND s.dbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+	AND s.queryid IS NOT NULL
+	AND s.query NOT LIKE '%pg_stat%'
+	AND s.query NOT LIKE '%pg_settings%'
+GROUP BY s.queryid
+`
+
+// snapshotStatements reads the current pg_stat_statements counters by
+// queryid. It returns nil when the extension is not installed.
+func (c *PgStatsCollector) snapshotStatements() (map[int64]types.StatementStats, error) {
 	// Check if pg_stat_statements is available
 	var exists bool
 	err := c.pool.QueryRow(c.ctx, "SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_stat_statements')").Scan(&exists)
-
 	if err != nil || !exists {
-		return nil // Extension not available, skip
-	}
-
-	// Get top 5 queries by total time
-	query := `
-SELECT 
-query,
-calls,
-total_exec_time,
-mean_exec_time,
-rows
-FROM pg_stat_statements 
-WHERE query NOT LIKE '%pg_stat_statements%'
-ORDER BY total_exec_time DESC 
-LIMIT 5
-`
+		return nil, nil // Extension not available, skip
+	}
 
-	rows, err := c.pool.Query(c.ctx, query)
+	rows, err := c.pool.Query(c.ctx, statementSnapshotQuery)
 	if err != nil {
-		return fmt.Errorf("failed to collect statement stats: %w", err)
+		return nil, fmt.Errorf("failed to read pg_stat_statements: %w", err)
 	}
 	defer rows.Close()
 
-	var topQueries []types.QueryStats
+	snapshot := make(map[int64]types.StatementStats)
 	for rows.Next() {
-		var qs types.QueryStats
-
-		err := rows.Scan(
-			&qs.Query,
-			&qs.Calls,
-			&qs.TotalTime,
-			&qs.MeanTime,
-			&qs.Rows,
-		)
-		if err != nil {
-			continue
+		var s types.StatementStats
+		var planTime *float64 // NULL when the server does not track planning
+		if err := rows.Scan(
+			&s.QueryID, &s.Query, &s.Calls, &s.TotalExecTime, &planTime, &s.Rows,
+			&s.SharedBlksHit, &s.SharedBlksRead, &s.SharedBlksDirtied, &s.SharedBlksWritten,
+			&s.LocalBlksHit, &s.LocalBlksRead, &s.LocalBlksWritten,
+			&s.WALRecords, &s.WALBytes,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan pg_stat_statements: %w", err)
 		}
-
-		qs.LastUpdated = time.Now()
-		topQueries = append(topQueries, qs)
+		if planTime != nil {
+			s.TotalPlanTime = *planTime
+		}
+		snapshot[s.QueryID] = s
 	}
+	return snapshot, rows.Err()
+}
 
-	stats.TopQueries = topQueries
+// collectStatementDeltas stores the pg_stat_statements changes since the
+// workload baseline: the statements ranking in the top N of any dimension,
+// and the top queries by execution time
+func (c *PgStatsCollector) collectStatementDeltas(stats *types.PostgreSQLStats) error {
+	if c.statementBaseline == nil {
+		return nil // No baseline, lifetime counters are not reported
+	}
+	final, err := c.snapshotStatements()
+	if err != nil || final == nil {
+		return err
+	}
+
+	deltas := StatementDeltas(c.statementBaseline, final)
+	stats.Statements = types.SelectStatements(deltas, c.statementLimit)
+	stats.StatementLimit = c.statementLimit
+
+	stats.TopQueries = nil
+	for _, s := range types.TopStatements(deltas, types.StatementDimensions[0], topQueriesLimit) {
+		stats.TopQueries = append(stats.TopQueries, types.QueryStats{
+			Query:       s.Query,
+			Calls:       s.Calls,
+			TotalTime:   s.TotalExecTime,
+			MeanTime:    s.MeanExecTime(),
+			Rows:        s.Rows,
+			HitPercent:  s.HitPercent(),
+			LastUpdated: time.Now(),
+		})
+	}
 	return nil
 }
 
+// StatementDeltas returns the change of each statement between two
+// pg_stat_statements snapshots, omitting statements without calls in
+// between. A statement whose calls went down was deallocated or reset during
+// the run, so its final counters are taken as the change.
+func StatementDeltas(baseline, final map[int64]types.StatementStats) []types.StatementStats {
+	var deltas []types.StatementStats
+	for id, f := range final {
+		d := f
+		if b, ok := baseline[id]; ok && f.Calls >= b.Calls {
+			d.Calls -= b.Calls
+			d.TotalExecTime -= b.TotalExecTime
+			d.TotalPlanTime -= b.TotalPlanTime
+			d.Rows -= b.Rows
+			d.SharedBlksHit -= b.SharedBlksHit
+			d.SharedBlksRead -= b.SharedBlksRead
+			d.SharedBlksDirtied -= b.SharedBlksDirtied
+			d.SharedBlksWritten -= b.SharedBlksWritten
+			d.LocalBlksHit -= b.LocalBlksHit
+			d.LocalBlksRead -= b.LocalBlksRead
+			d.LocalBlksWritten -= b.LocalBlksWritten
+			d.WALRecords -= b.WALRecords
+			d.WALBytes -= b.WALBytes
+		}
+		if d.Calls > 0 {
+			deltas = append(deltas, d)
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].QueryID < deltas[j].QueryID })
+	return deltas
+}
+
 // Version-specific buffer statistics collection methods
 
 // collectBufferStatsV16Plus collects buffer statistics for PostgreSQL 16+
@@ -4,29 +4,61 @@ package database
 import (
 	"context"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ApplicationName identifies workload connections in pg_stat_activity
+const ApplicationName = "stormdb"
+
 type Postgres struct {
 	Pool *pgxpool.Pool
 }
 
+// PoolOption adjusts the workload pool configuration before the pool is created
+type PoolOption func(*pgxpool.Config)
+
+// WithTracer traces every statement executed on the pool's connections
+func WithTracer(tracer pgx.QueryTracer) PoolOption {
+	return func(poolCfg *pgxpool.Config) {
+		poolCfg.ConnConfig.Tracer = tracer
+	}
+}
+
 func NewPostgres(cfg *types.Config) (*Postgres, error) {
+	return NewPostgresWithExecMode(cfg, cfg.QueryExecMode, nil)
+}
+
+// NewPostgresWithExecMode creates the workload pool using the given query
+// execution mode. Statements are only used by the "prepared" mode.
+func NewPostgresWithExecMode(cfg *types.Config, execMode string, statements []string, opts ...PoolOption) (*Postgres, error) {
 	dsn := fmt.Sprintf(
-		"user=%s password=%s host=%s port=%d dbname=%s sslmode=%s pool_max_conns=%d pool_min_conns=%d pool_max_conn_lifetime=1h pool_max_conn_idle_time=30m pool_health_check_period=1m connect_timeout=10",
+		"user=%s password=%s host=%s port=%d dbname=%s sslmode=%s application_name=%s pool_max_conns=%d pool_min_conns=%d pool_max_conn_lifetime=1h pool_max_conn_idle_time=30m pool_health_check_period=1m connect_timeout=10",
 		cfg.Database.Username, cfg.Database.Password,
 		cfg.Database.Host, cfg.Database.Port,
-		cfg.Database.Dbname, cfg.Database.Sslmode,
+		cfg.Database.Dbname, cfg.Database.Sslmode, ApplicationName,
 		cfg.Connections, cfg.Connections/2, // min connections = half of max
 	)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	pool, err := pgxpool.New(ctx, dsn)
+	poolCfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection config: %w", err)
+	}
+	if err := ConfigureQueryExecMode(poolCfg, execMode, statements); err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		opt(poolCfg)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
 	}
@@ -60,7 +92,7 @@ func (p *Postgres) SetupTestTable(scale int) error {
 	}
 
 	if count == 0 {
-		fmt.Printf("Seeding %d rows into loadtest...\n", scale)
+		log.Printf("Seeding %d rows into loadtest...", scale)
 		for i := 1; i <= scale; i++ {
 			_, err := p.Pool.Exec(ctx,
 				"INSERT INTO loadtest (id, val, updated) VALUES ($1, $2, NOW()) ON CONFLICT (id) DO NOTHING",
@@ -0,0 +1,308 @@
+// internal/database/replicas.go
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Replica balancing strategies
+const (
+	BalanceRoundRobin  = "round_robin"
+	BalanceLeastLoaded = "least_loaded"
+)
+
+// replicaLagQuery reports replay lag in milliseconds. A replica that has
+// replayed everything it received reports zero lag even when the primary
+// is idle and the last replayed transaction is old.
+const replicaLagQuery = `
+SELECT CASE
+	WHEN NOT pg_is_in_recovery() THEN 0
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM (now() - pg_last_xact_replay_timestamp())) * 1000, 0)
+END::float8`
+
+// replica holds the pool and routing counters for a single replica endpoint
+type replica struct {
+	name  string
+	pool  *pgxpool.Pool
+	reads int64
+
+	// Set by the lag sampler when the last sample failed or exceeded max_lag
+	unavailable atomic.Bool
+
+	mu           sync.Mutex
+	lagSamples   int64
+	sampleErrors int64
+	lagSumMs     float64
+	maxLagMs     float64
+	lastLagMs    float64
+}
+
+// ReplicaSet manages connection pools for read replicas and balances read
+// operations across them. Reads fall back to the primary while every
+// replica is unhealthy or lagging beyond max_lag. It implements
+// plugin.ReadPoolSelector so it can be handed directly to workloads that
+// support read routing.
+type ReplicaSet struct {
+	replicas       []*replica
+	primary        *pgxpool.Pool
+	balancing      string
+	sampleInterval time.Duration
+	maxLagMs       float64 // 0 disables the lag limit
+	next           uint64
+	fallbackReads  int64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewReplicaSet connects to every replica endpoint in the configuration.
+// Endpoint fields left empty inherit the primary database settings. The
+// pools are built like the primary pool, with the same statements and
+// pool options, and primary serves reads while no replica is available.
+func NewReplicaSet(cfg *types.Config, primary *pgxpool.Pool, statements []string, opts ...PoolOption) (*ReplicaSet, error) {
+	if len(cfg.Replicas.Endpoints) == 0 {
+		return nil, fmt.Errorf("no replica endpoints configured")
+	}
+
+	balancing := cfg.Replicas.Balancing
+	if balancing == "" {
+		balancing = BalanceRoundRobin
+	}
+
+	sampleInterval := 5 * time.Second
+	if cfg.Replicas.LagSampleInterval != "" {
+		interval, err := time.ParseDuration(cfg.Replicas.LagSampleInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lag_sample_interval '%s': %w", cfg.Replicas.LagSampleInterval, err)
+		}
+		sampleInterval = interval
+	}
+
+	rs := &ReplicaSet{
+		primary:        primary,
+		balancing:      balancing,
+		sampleInterval: sampleInterval,
+	}
+	if cfg.Replicas.MaxLag != "" {
+		maxLag, err := time.ParseDuration(cfg.Replicas.MaxLag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_lag '%s': %w", cfg.Replicas.MaxLag, err)
+		}
+		rs.maxLagMs = float64(maxLag) / float64(time.Millisecond)
+	}
+
+	for _, endpoint := range cfg.Replicas.Endpoints {
+		r, err := connectReplica(cfg, endpoint, statements, opts)
+		if err != nil {
+			rs.Close()
+			return nil, err
+		}
+		rs.replicas = append(rs.replicas, r)
+	}
+
+	return rs, nil
+}
+
+// connectReplica opens and verifies a pool for a single replica endpoint
+func connectReplica(cfg *types.Config, endpoint types.ReplicaEndpoint, statements []string, opts []PoolOption) (*replica, error) {
+	replicaCfg := replicaDatabaseConfig(cfg, endpoint)
+	name := valueOr(endpoint.Name, fmt.Sprintf("%s:%d", endpoint.Host, replicaCfg.Database.Port))
+
+	db, err := NewPostgresWithExecMode(replicaCfg, cfg.QueryExecMode, statements, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("replica %s: %w", name, err)
+	}
+	return &replica{name: name, pool: db.Pool}, nil
+}
+
+// replicaDatabaseConfig returns a copy of cfg pointing at a replica endpoint
+func replicaDatabaseConfig(cfg *types.Config, endpoint types.ReplicaEndpoint) *types.Config {
+	replicaCfg := *cfg
+	replicaCfg.Database.Host = endpoint.Host
+	if endpoint.Port != 0 {
+		replicaCfg.Database.Port = endpoint.Port
+	}
+	replicaCfg.Database.Dbname = valueOr(endpoint.Dbname, cfg.Database.Dbname)
+	replicaCfg.Database.Username = valueOr(endpoint.Username, cfg.Database.Username)
+	replicaCfg.Database.Password = valueOr(endpoint.Password, cfg.Database.Password)
+	replicaCfg.Database.Sslmode = valueOr(endpoint.Sslmode, cfg.Database.Sslmode)
+	return &replicaCfg
+}
+
+// ReadPool returns the replica pool that should serve the next read
+// operation, or the primary pool while no replica is available
+func (rs *ReplicaSet) ReadPool() *pgxpool.Pool {
+	chosen := rs.choose(func(r *replica) int32 { return r.pool.Stat().AcquiredConns() })
+	if chosen == nil {
+		atomic.AddInt64(&rs.fallbackReads, 1)
+		return rs.primary
+	}
+	atomic.AddInt64(&chosen.reads, 1)
+	return chosen.pool
+}
+
+// choose picks the replica for the next read among the available ones, or
+// nil when none is. Least-loaded balancing compares the busy connections
+// reported by load.
+func (rs *ReplicaSet) choose(load func(*replica) int32) *replica {
+	n := len(rs.replicas)
+	start := int(atomic.AddUint64(&rs.next, 1) % uint64(n))
+
+	var chosen *replica
+	var best int32
+	// Scan from the round-robin position so ties rotate between replicas
+	for i := 0; i < n; i++ {
+		candidate := rs.replicas[(start+i)%n]
+		if candidate.unavailable.Load() {
+			continue
+		}
+		if rs.balancing != BalanceLeastLoaded {
+			return candidate
+		}
+		if busy := load(candidate); chosen == nil || busy < best {
+			chosen, best = candidate, busy
+		}
+	}
+	return chosen
+}
+
+// StartLagSampler periodically samples replay lag on every replica and
+// publishes the routing statistics into the given metrics
+func (rs *ReplicaSet) StartLagSampler(metrics *types.Metrics) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rs.cancel = cancel
+	rs.done = make(chan struct{})
+
+	go func() {
+		defer close(rs.done)
+
+		ticker := time.NewTicker(rs.sampleInterval)
+		defer ticker.Stop()
+
+		for {
+			rs.sampleLag(ctx)
+			metrics.UpdateReplicaStats(rs.Stats(), atomic.LoadInt64(&rs.fallbackReads))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// StopLagSampler stops the lag sampler and publishes a final snapshot
+func (rs *ReplicaSet) StopLagSampler(metrics *types.Metrics) {
+	if rs.cancel == nil {
+		return
+	}
+	rs.cancel()
+	<-rs.done
+	rs.cancel = nil
+
+	metrics.UpdateReplicaStats(rs.Stats(), atomic.LoadInt64(&rs.fallbackReads))
+}
+
+// sampleLag takes one replay lag sample from each replica
+func (rs *ReplicaSet) sampleLag(ctx context.Context) {
+	for _, r := range rs.replicas {
+		queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		var lagMs float64
+		err := r.pool.QueryRow(queryCtx, replicaLagQuery).Scan(&lagMs)
+		cancel()
+
+		rs.recordLag(r, lagMs, err)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Warning: Failed to sample replay lag on replica %s: %v", r.name, err)
+		}
+	}
+}
+
+// recordLag records one replay lag sample of r and marks r unavailable
+// while its samples fail or exceed max_lag
+func (rs *ReplicaSet) recordLag(r *replica, lagMs float64, err error) {
+	r.unavailable.Store(err != nil || (rs.maxLagMs > 0 && lagMs > rs.maxLagMs))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.sampleErrors++
+		return
+	}
+	r.lagSamples++
+	r.lagSumMs += lagMs
+	r.lastLagMs = lagMs
+	if lagMs > r.maxLagMs {
+		r.maxLagMs = lagMs
+	}
+}
+
+// Stats returns the current routing and lag statistics for every replica
+func (rs *ReplicaSet) Stats() []types.ReplicaStats {
+	stats := make([]types.ReplicaStats, 0, len(rs.replicas))
+	for _, r := range rs.replicas {
+		r.mu.Lock()
+		s := types.ReplicaStats{
+			Name:         r.name,
+			Reads:        atomic.LoadInt64(&r.reads),
+			LagSamples:   r.lagSamples,
+			SampleErrors: r.sampleErrors,
+			MaxLagMs:     r.maxLagMs,
+			LastLagMs:    r.lastLagMs,
+		}
+		if r.lagSamples > 0 {
+			s.AvgLagMs = r.lagSumMs / float64(r.lagSamples)
+		}
+		r.mu.Unlock()
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// Balancing returns the active balancing strategy
+func (rs *ReplicaSet) Balancing() string {
+	return rs.balancing
+}
+
+// Size returns the number of replicas in the set
+func (rs *ReplicaSet) Size() int {
+	return len(rs.replicas)
+}
+
+// Reset closes the idle connections of every replica pool, so new
+// connections prepare statements against the current schema
+func (rs *ReplicaSet) Reset() {
+	for _, r := range rs.replicas {
+		r.pool.Reset()
+	}
+}
+
+// Close stops lag sampling and closes all replica pools
+func (rs *ReplicaSet) Close() {
+	if rs.cancel != nil {
+		rs.cancel()
+		<-rs.done
+		rs.cancel = nil
+	}
+	for _, r := range rs.replicas {
+		r.pool.Close()
+	}
+}
+
+// valueOr returns value when it is set, otherwise fallback
+func valueOr(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
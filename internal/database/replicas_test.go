@@ -0,0 +1,166 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// newTestReplicaSet builds a replica set without pools for selector tests
+func newTestReplicaSet(balancing string, maxLagMs float64, names ...string) *ReplicaSet {
+	rs := &ReplicaSet{balancing: balancing, maxLagMs: maxLagMs}
+	for _, name := range names {
+		rs.replicas = append(rs.replicas, &replica{name: name})
+	}
+	return rs
+}
+
+// chooseNames runs n selections and returns the chosen replica names, with
+// "primary" for a fallback
+func chooseNames(rs *ReplicaSet, n int, load map[string]int32) []string {
+	names := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		r := rs.choose(func(r *replica) int32 { return load[r.name] })
+		if r == nil {
+			names = append(names, "primary")
+			continue
+		}
+		names = append(names, r.name)
+	}
+	return names
+}
+
+func TestReplicaSelection(t *testing.T) {
+	tests := []struct {
+		name        string
+		balancing   string
+		load        map[string]int32
+		unavailable []string
+		expected    []string
+	}{
+		{
+			name:      "round robin",
+			balancing: BalanceRoundRobin,
+			expected:  []string{"b", "c", "a", "b", "c", "a"},
+		},
+		{
+			name:        "round robin skips unavailable",
+			balancing:   BalanceRoundRobin,
+			unavailable: []string{"b"},
+			expected:    []string{"c", "c", "a", "c", "c", "a"},
+		},
+		{
+			name:      "least loaded",
+			balancing: BalanceLeastLoaded,
+			load:      map[string]int32{"a": 3, "b": 1, "c": 2},
+			expected:  []string{"b", "b", "b"},
+		},
+		{
+			name:      "least loaded rotates ties",
+			balancing: BalanceLeastLoaded,
+			load:      map[string]int32{"a": 1, "b": 1, "c": 5},
+			expected:  []string{"b", "a", "a", "b", "a", "a"},
+		},
+		{
+			name:        "least loaded skips unavailable",
+			balancing:   BalanceLeastLoaded,
+			load:        map[string]int32{"a": 3, "b": 1, "c": 2},
+			unavailable: []string{"b"},
+			expected:    []string{"c", "c", "c"},
+		},
+		{
+			name:        "falls back to primary",
+			balancing:   BalanceRoundRobin,
+			unavailable: []string{"a", "b", "c"},
+			expected:    []string{"primary", "primary"},
+		},
+		{
+			name:        "least loaded falls back to primary",
+			balancing:   BalanceLeastLoaded,
+			unavailable: []string{"a", "b", "c"},
+			expected:    []string{"primary"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := newTestReplicaSet(tt.balancing, 0, "a", "b", "c")
+			for _, r := range rs.replicas {
+				for _, name := range tt.unavailable {
+					if r.name == name {
+						r.unavailable.Store(true)
+					}
+				}
+			}
+
+			got := chooseNames(rs, len(tt.expected), tt.load)
+			for i := range tt.expected {
+				if got[i] != tt.expected[i] {
+					t.Fatalf("Expected selections %v, got %v", tt.expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestReplicaAvailabilityFromLag(t *testing.T) {
+	rs := newTestReplicaSet(BalanceRoundRobin, 100, "a", "b")
+	a, b := rs.replicas[0], rs.replicas[1]
+
+	// A lagging and a failing replica leave only the primary
+	rs.recordLag(a, 250, nil)
+	rs.recordLag(b, 0, errors.New("connection refused"))
+	if got := chooseNames(rs, 2, nil); got[0] != "primary" || got[1] != "primary" {
+		t.Errorf("Expected primary fallback, got %v", got)
+	}
+
+	// Replicas come back once they catch up or answer again
+	rs.recordLag(a, 50, nil)
+	if got := chooseNames(rs, 2, nil); got[0] != "a" || got[1] != "a" {
+		t.Errorf("Expected reads on the caught-up replica, got %v", got)
+	}
+	rs.recordLag(b, 10, nil)
+	if got := chooseNames(rs, 2, nil); got[0] == got[1] {
+		t.Errorf("Expected reads on both replicas, got %v", got)
+	}
+
+	stats := rs.Stats()
+	if stats[0].LagSamples != 2 || stats[0].MaxLagMs != 250 || stats[0].LastLagMs != 50 {
+		t.Errorf("Unexpected lag statistics for a: %+v", stats[0])
+	}
+	if stats[1].SampleErrors != 1 || stats[1].LagSamples != 1 {
+		t.Errorf("Unexpected lag statistics for b: %+v", stats[1])
+	}
+
+	// Without max_lag only failing samples make a replica unavailable
+	rs = newTestReplicaSet(BalanceRoundRobin, 0, "a")
+	rs.recordLag(rs.replicas[0], 60000, nil)
+	if got := chooseNames(rs, 1, nil); got[0] != "a" {
+		t.Errorf("Expected a lagging replica without max_lag, got %v", got)
+	}
+}
+
+func TestReplicaDatabaseConfig(t *testing.T) {
+	cfg := &types.Config{}
+	cfg.Database.Host = "primary"
+	cfg.Database.Port = 5432
+	cfg.Database.Dbname = "app"
+	cfg.Database.Username = "bench"
+	cfg.Database.Password = "secret"
+	cfg.Database.Sslmode = "require"
+
+	replicaCfg := replicaDatabaseConfig(cfg, types.ReplicaEndpoint{Host: "replica1", Port: 6432, Username: "reader"})
+	db := replicaCfg.Database
+	if db.Host != "replica1" || db.Port != 6432 || db.Username != "reader" ||
+		db.Dbname != "app" || db.Password != "secret" || db.Sslmode != "require" {
+		t.Errorf("Unexpected replica database settings: %+v", db)
+	}
+	if cfg.Database.Host != "primary" || cfg.Database.Username != "bench" {
+		t.Errorf("Primary settings were modified: %+v", cfg.Database)
+	}
+
+	if replicaCfg := replicaDatabaseConfig(cfg, types.ReplicaEndpoint{Host: "replica2"}); replicaCfg.Database.Port != 5432 {
+		t.Errorf("Expected the primary port by default, got %d", replicaCfg.Database.Port)
+	}
+}
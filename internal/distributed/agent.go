@@ -0,0 +1,361 @@
+// Package distributed spreads a workload over several stormdb processes.
+// Agents run their share of the workers against the database and report
+// their metrics over HTTP; a coordinator splits the load, starts the
+// agents at a synchronized time and merges their metrics into one report.
+package distributed
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/config"
+	"github.com/elchinoo/stormdb/internal/core/domain"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// maxRequestBytes limits the size of a start request
+const maxRequestBytes = 1 << 20
+
+// Errors returned by the agent
+var (
+	ErrAgentBusy   = errors.New("agent is running another workload")
+	ErrRunNotFound = errors.New("run not found")
+	ErrRunState    = errors.New("run has not finished")
+)
+
+// Runner executes an agent's share of a run with cfg, recording into m. It
+// connects to the database, waits until startAt, calls started and runs
+// the workload for the configured duration or until ctx is done.
+type Runner func(ctx context.Context, cfg *types.Config, m *types.Metrics, startAt time.Time, started func()) error
+
+// AgentOptions configures an agent
+type AgentOptions struct {
+	Addr    string // Listen address, e.g. 0.0.0.0:7070
+	Token   string // Bearer token required by /agent routes; empty disables auth
+	Version string // StormDB version reported by the health check
+}
+
+// Agent serves the agent protocol and executes one run at a time
+type Agent struct {
+	runner Runner
+	opts   AgentOptions
+
+	mu  sync.Mutex
+	run *agentRun // Current or last run
+}
+
+// agentRun is the agent's share of a distributed run
+type agentRun struct {
+	id           string
+	workerOffset int
+	workers      int
+
+	mu        sync.RWMutex
+	metrics   *types.Metrics
+	status    domain.ExecutionStatus
+	err       error
+	startedAt time.Time
+	endedAt   time.Time
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewAgent creates an agent executing runs with runner
+func NewAgent(runner Runner, opts AgentOptions) *Agent {
+	return &Agent{runner: runner, opts: opts}
+}
+
+// Handler returns the HTTP handler of the agent
+func (a *Agent) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", a.handleHealth)
+
+	api := http.NewServeMux()
+	api.HandleFunc("POST /agent/v1/runs", a.handleStart)
+	api.HandleFunc("GET /agent/v1/runs/{id}", a.handleStatus)
+	api.HandleFunc("POST /agent/v1/runs/{id}/stop", a.handleStop)
+	api.HandleFunc("GET /agent/v1/runs/{id}/metrics", a.handleMetrics)
+	mux.Handle("/agent/", a.authenticate(api))
+
+	return mux
+}
+
+// ListenAndServe serves the agent until ctx is done, then stops the current
+// run and shuts the listener down
+func (a *Agent) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              a.opts.Addr,
+		Handler:           a.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if run := a.current(); run != nil {
+		run.stop()
+		select {
+		case <-run.done:
+		case <-shutdownCtx.Done():
+			log.Printf("⚠️  Agent run did not finish before shutdown")
+		}
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down agent: %w", err)
+	}
+	return nil
+}
+
+// Start schedules a run from req. The configuration is loaded into the
+// global viper instance, where plugins read workload_config, so only one
+// run executes at a time.
+func (a *Agent) Start(req *StartRequest) (*RunStatus, error) {
+	if req.ID == "" {
+		return nil, errors.New("run id is required")
+	}
+	data, err := json.Marshal(req.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode settings: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.run != nil && !a.run.finished() {
+		return nil, ErrAgentBusy
+	}
+	cfg, err := config.Parse(data, "json")
+	if err != nil {
+		return nil, err
+	}
+
+	m := &types.Metrics{ErrorTypes: make(map[string]int64)}
+	m.InitializeLatencyHistogram()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &agentRun{
+		id:           req.ID,
+		workerOffset: req.WorkerOffset,
+		workers:      cfg.Workers,
+		metrics:      m,
+		status:       domain.StatusPending,
+		cancel:       cancel,
+		done:         make(chan struct{}),
+	}
+	a.run = run
+
+	log.Printf("📥 Run %s: %d workers, %d connections, starting at %s",
+		req.ID, cfg.Workers, cfg.Connections, req.StartAt.Format(time.RFC3339Nano))
+	go a.execute(ctx, run, cfg, req.StartAt)
+
+	status := run.snapshot(0)
+	return &status, nil
+}
+
+// execute runs the workload and records the outcome
+func (a *Agent) execute(ctx context.Context, run *agentRun, cfg *types.Config, startAt time.Time) {
+	run.mu.Lock()
+	run.status = domain.StatusRunning
+	run.mu.Unlock()
+
+	started := func() {
+		run.mu.Lock()
+		if run.startedAt.IsZero() {
+			run.startedAt = time.Now()
+		}
+		run.mu.Unlock()
+	}
+	err := a.runner(ctx, cfg, run.metrics, startAt, started)
+
+	run.mu.Lock()
+	run.endedAt = time.Now()
+	switch {
+	case ctx.Err() != nil && run.startedAt.IsZero():
+		run.status = domain.StatusCancelled
+	case ctx.Err() != nil:
+		// Stopped by the coordinator, the results so far are kept
+		run.status = domain.StatusCompleted
+	case err != nil:
+		run.status = domain.StatusFailed
+		run.err = err
+	default:
+		run.status = domain.StatusCompleted
+	}
+	run.cancel()
+	status := run.status
+	run.mu.Unlock()
+	log.Printf("🏁 Run %s %s", run.id, status)
+	close(run.done)
+}
+
+// current returns the current or last run
+func (a *Agent) current() *agentRun {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.run
+}
+
+// get returns the run with id
+func (a *Agent) get(id string) (*agentRun, error) {
+	if run := a.current(); run != nil && run.id == id {
+		return run, nil
+	}
+	return nil, ErrRunNotFound
+}
+
+// snapshot returns the live state of run with the latency samples since since
+func (run *agentRun) snapshot(since int) RunStatus {
+	run.mu.RLock()
+	s := RunStatus{ID: run.id, Status: run.status, Workers: run.workers}
+	if run.err != nil {
+		s.Error = run.err.Error()
+	}
+	if !run.startedAt.IsZero() {
+		started := run.startedAt
+		s.StartedAt = &started
+	}
+	if !run.endedAt.IsZero() {
+		ended := run.endedAt
+		s.EndedAt = &ended
+	}
+	m := run.metrics
+	run.mu.RUnlock()
+
+	s.Counters = loadCounters(m)
+	s.Latencies, s.Samples = recentLatencies(m, since)
+	return s
+}
+
+// stop ends the run early
+func (run *agentRun) stop() {
+	run.mu.RLock()
+	defer run.mu.RUnlock()
+	run.cancel()
+}
+
+// finished reports whether run has ended
+func (run *agentRun) finished() bool {
+	run.mu.RLock()
+	defer run.mu.RUnlock()
+	switch run.status {
+	case domain.StatusCompleted, domain.StatusFailed, domain.StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// authenticate requires the configured bearer token
+func (a *Agent) authenticate(next http.Handler) http.Handler {
+	if a.opts.Token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.opts.Token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *Agent) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	health := Health{Status: "ok", Version: a.opts.Version, Time: time.Now()}
+	if run := a.current(); run != nil && !run.finished() {
+		health.Busy, health.RunID = true, run.id
+	}
+	writeJSON(w, http.StatusOK, health)
+}
+
+func (a *Agent) handleStart(w http.ResponseWriter, r *http.Request) {
+	var req StartRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid start request: %w", err))
+		return
+	}
+	status, err := a.Start(&req)
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, ErrAgentBusy) {
+			code = http.StatusConflict
+		}
+		writeError(w, code, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+// handleStatus returns the live state of a run; the since query parameter
+// is the samples count of the previous poll
+func (a *Agent) handleStatus(w http.ResponseWriter, r *http.Request) {
+	run, err := a.get(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	since := 0
+	if v := r.URL.Query().Get("since"); v != "" {
+		if since, err = strconv.Atoi(v); err != nil || since < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid since %q", v))
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, run.snapshot(since))
+}
+
+func (a *Agent) handleStop(w http.ResponseWriter, r *http.Request) {
+	run, err := a.get(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	run.stop()
+	writeJSON(w, http.StatusAccepted, run.snapshot(0))
+}
+
+// handleMetrics returns the full metrics of a finished run
+func (a *Agent) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	run, err := a.get(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if !run.finished() {
+		writeError(w, http.StatusConflict, ErrRunState)
+		return
+	}
+	run.mu.RLock()
+	m, offset, started := run.metrics, run.workerOffset, run.startedAt
+	run.mu.RUnlock()
+	writeJSON(w, http.StatusOK, CaptureMetrics(m, offset, started))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("⚠️  Failed to write agent response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,442 @@
+// internal/distributed/coordinator.go
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elchinoo/stormdb/pkg/plugin"
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// DefaultStartDelay is the lead time agents get to connect before the
+	// synchronized start
+	DefaultStartDelay = 3 * time.Second
+
+	// pollInterval is how often the coordinator polls agent status
+	pollInterval = 500 * time.Millisecond
+
+	// busyTimeout is how long to wait for agents still finishing a run
+	busyTimeout = 30 * time.Second
+
+	// maxPollFailures is the number of failed polls after which an agent
+	// is considered lost
+	maxPollFailures = 10
+)
+
+// Coordinator splits runs across agents and merges their metrics
+type Coordinator struct {
+	agents     []*agentClient
+	startDelay time.Duration
+
+	mu        sync.Mutex
+	startedAt time.Time
+}
+
+// agentClient talks to one agent
+type agentClient struct {
+	addr    string
+	baseURL string
+	token   string
+	client  *http.Client
+	skew    time.Duration // Agent clock minus coordinator clock
+}
+
+// NewCoordinator creates a coordinator for the agents at addrs, which are
+// host:port pairs or URLs. A zero startDelay uses DefaultStartDelay.
+func NewCoordinator(addrs []string, token string, startDelay time.Duration) *Coordinator {
+	if startDelay <= 0 {
+		startDelay = DefaultStartDelay
+	}
+	c := &Coordinator{startDelay: startDelay}
+	client := &http.Client{Timeout: 10 * time.Second}
+	for _, addr := range addrs {
+		baseURL := addr
+		if !strings.Contains(addr, "://") {
+			baseURL = "http://" + addr
+		}
+		c.agents = append(c.agents, &agentClient{
+			addr:    addr,
+			baseURL: strings.TrimRight(baseURL, "/"),
+			token:   token,
+			client:  client,
+		})
+	}
+	return c
+}
+
+// StartDelay returns the lead time before agents start
+func (c *Coordinator) StartDelay() time.Duration {
+	return c.startDelay
+}
+
+// StartedAt returns the synchronized start time of the last run
+func (c *Coordinator) StartedAt() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.startedAt
+}
+
+// Check verifies every agent is reachable and estimates its clock skew.
+// It fails with ErrAgentBusy when an agent is running another workload.
+func (c *Coordinator) Check(ctx context.Context) error {
+	var errs []error
+	for _, a := range c.agents {
+		health, err := a.health(ctx)
+		if err != nil {
+			errs = append(errs, agentError(a.addr, err))
+			continue
+		}
+		if health.Busy {
+			errs = append(errs, agentError(a.addr, fmt.Errorf("%w (run %s)", ErrAgentBusy, health.RunID)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// waitIdle checks the agents until none is busy, for up to busyTimeout;
+// agents may still be finishing a previous band
+func (c *Coordinator) waitIdle(ctx context.Context) error {
+	deadline := time.Now().Add(busyTimeout)
+	for {
+		err := c.Check(ctx)
+		if err == nil || !errors.Is(err, ErrAgentBusy) || time.Now().After(deadline) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Run executes cfg across the agents and records into m. Live counters and
+// latencies are updated while the run progresses; when it ends the metrics
+// of m are replaced by the merged agent metrics. settings is the full
+// configuration document, as returned by viper.AllSettings. The agents run
+// until the deadline of ctx, or for cfg.Duration when ctx has none.
+func (c *Coordinator) Run(ctx context.Context, settings map[string]interface{}, cfg *types.Config, m *types.Metrics) error {
+	if len(c.agents) == 0 {
+		return errors.New("no agents configured")
+	}
+	if err := c.waitIdle(ctx); err != nil {
+		return err
+	}
+
+	agents := c.agents
+	if cfg.Workers < len(agents) {
+		agents = agents[:cfg.Workers]
+	}
+	workers := SplitEven(cfg.Workers, len(agents))
+	connections := SplitEven(cfg.Connections, len(agents))
+
+	startAt := time.Now().Add(c.startDelay)
+	duration, err := time.ParseDuration(cfg.Duration)
+	if err != nil {
+		return fmt.Errorf("invalid duration '%s': %w", cfg.Duration, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		duration = deadline.Sub(startAt)
+	}
+	if duration <= 0 {
+		return fmt.Errorf("no time left to run after the %v start delay", c.startDelay)
+	}
+
+	id := uuid.NewString()
+	offset := 0
+	var started []*agentClient
+	for i, a := range agents {
+		// Agents validate their configuration, which requires a connection per worker
+		req := &StartRequest{
+			ID:           id,
+			Settings:     agentSettings(settings, cfg, workers[i], max(connections[i], workers[i]), duration),
+			WorkerOffset: offset,
+			StartAt:      startAt.Add(a.skew),
+		}
+		if err := a.start(ctx, req); err != nil {
+			for _, s := range started {
+				_ = s.stop(context.Background(), id)
+			}
+			return agentError(a.addr, err)
+		}
+		started = append(started, a)
+		offset += workers[i]
+	}
+	c.mu.Lock()
+	c.startedAt = startAt
+	c.mu.Unlock()
+	log.Printf("🛰️  Distributed run %s on %d agent(s), starting at %s",
+		id, len(agents), startAt.Format(time.RFC3339))
+
+	errs := make([]error, len(agents))
+	parts := make([]*MetricsPayload, len(agents))
+	var wg sync.WaitGroup
+	for i, a := range agents {
+		wg.Add(1)
+		go func(i int, a *agentClient) {
+			defer wg.Done()
+			parts[i], errs[i] = a.follow(ctx, id, m)
+		}(i, a)
+	}
+	wg.Wait()
+
+	var merged []*MetricsPayload
+	for _, p := range parts {
+		if p != nil {
+			merged = append(merged, p)
+		}
+	}
+	if len(merged) > 0 {
+		MergeMetrics(m, merged, startAt)
+	}
+	return errors.Join(errs...)
+}
+
+// follow polls the agent until its run ends, applying counter deltas and
+// latency samples to m, then fetches the final metrics. The run is stopped
+// when ctx is done.
+func (a *agentClient) follow(ctx context.Context, id string, m *types.Metrics) (*MetricsPayload, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var last Counters
+	samples, failures := 0, 0
+	done := ctx.Done()
+	for {
+		select {
+		case <-ticker.C:
+		case <-done:
+			if err := a.stop(context.Background(), id); err != nil {
+				log.Printf("⚠️  Failed to stop agent %s: %v", a.addr, err)
+			}
+			done = nil
+		}
+
+		status, err := a.status(context.Background(), id, samples)
+		if err != nil {
+			failures++
+			if failures >= maxPollFailures {
+				return nil, agentError(a.addr, fmt.Errorf("lost contact: %w", err))
+			}
+			continue
+		}
+		failures = 0
+
+		addCounters(m, status.Counters.sub(last))
+		last = status.Counters
+		recordLatencies(m, status.Latencies)
+		samples = status.Samples
+
+		if status.Finished() {
+			payload, err := a.metrics(context.Background(), id)
+			if err != nil {
+				return nil, agentError(a.addr, err)
+			}
+			if status.Error != "" {
+				return payload, agentError(a.addr, errors.New(status.Error))
+			}
+			return payload, nil
+		}
+	}
+}
+
+// recordLatencies adds live latency samples to m
+func recordLatencies(m *types.Metrics, latencies []int64) {
+	if len(latencies) == 0 {
+		return
+	}
+	m.Mu.Lock()
+	histogram := m.LatencyHistogram != nil
+	m.Mu.Unlock()
+	for _, l := range latencies {
+		m.RecordLatencyWithLimit(l)
+		if histogram {
+			m.RecordLatency(l)
+		}
+	}
+}
+
+// agentSettings derives the configuration document of one agent. Features
+// the coordinator owns, or that would run once per agent, are disabled.
+func agentSettings(settings map[string]interface{}, cfg *types.Config, workers, connections int, duration time.Duration) map[string]interface{} {
+	doc := make(map[string]interface{}, len(settings))
+	for k, v := range settings {
+		doc[k] = v
+	}
+	delete(doc, "distributed")
+
+	// Values the CLI may have overridden
+	doc["database"] = map[string]interface{}{
+		"type":     cfg.Database.Type,
+		"host":     cfg.Database.Host,
+		"port":     cfg.Database.Port,
+		"dbname":   cfg.Database.Dbname,
+		"username": cfg.Database.Username,
+		"password": cfg.Database.Password,
+		"sslmode":  cfg.Database.Sslmode,
+	}
+	doc["workload"] = cfg.Workload
+	doc["mode"] = cfg.Mode
+	doc["scale"] = cfg.Scale
+	doc["query_exec_mode"] = cfg.QueryExecMode
+
+	doc["workers"] = workers
+	doc["connections"] = connections
+	doc["duration"] = duration.String()
+	doc["collect_pg_stats"] = false
+	doc["pg_stats_statements"] = false
+	setNested(doc, "progressive", "enabled", false)
+	setNested(doc, "exec_mode_comparison", "enabled", false)
+	setNested(doc, "fault_injection", "enabled", false)
+	setNested(doc, "results_backend", "enabled", false)
+	setNested(doc, "explain", "enabled", false)
+	setNested(doc, "history", "disabled", true)
+	return doc
+}
+
+// setNested sets section.key in doc, copying the section so the original
+// settings are left untouched
+func setNested(doc map[string]interface{}, section, key string, value interface{}) {
+	copied := make(map[string]interface{})
+	if existing, ok := doc[section].(map[string]interface{}); ok {
+		for k, v := range existing {
+			copied[k] = v
+		}
+	}
+	copied[key] = value
+	doc[section] = copied
+}
+
+// health fetches the health check of the agent and updates its clock skew
+func (a *agentClient) health(ctx context.Context) (*Health, error) {
+	sent := time.Now()
+	var health Health
+	if err := a.do(ctx, http.MethodGet, "/healthz", nil, &health); err != nil {
+		return nil, err
+	}
+	received := time.Now()
+	if !health.Time.IsZero() {
+		a.skew = health.Time.Sub(sent.Add(received.Sub(sent) / 2))
+	}
+	return &health, nil
+}
+
+func (a *agentClient) start(ctx context.Context, req *StartRequest) error {
+	return a.do(ctx, http.MethodPost, "/agent/v1/runs", req, nil)
+}
+
+func (a *agentClient) stop(ctx context.Context, id string) error {
+	return a.do(ctx, http.MethodPost, "/agent/v1/runs/"+id+"/stop", nil, nil)
+}
+
+func (a *agentClient) status(ctx context.Context, id string, since int) (*RunStatus, error) {
+	var status RunStatus
+	path := fmt.Sprintf("/agent/v1/runs/%s?since=%d", id, since)
+	if err := a.do(ctx, http.MethodGet, path, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func (a *agentClient) metrics(ctx context.Context, id string) (*MetricsPayload, error) {
+	var payload MetricsPayload
+	if err := a.do(ctx, http.MethodGet, "/agent/v1/runs/"+id+"/metrics", nil, &payload); err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
+
+// do sends a request to the agent and decodes the JSON response into out
+func (a *agentClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if a.token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) != nil || e.Error == "" {
+			e.Error = resp.Status
+		}
+		if resp.StatusCode == http.StatusConflict && strings.Contains(e.Error, ErrAgentBusy.Error()) {
+			return ErrAgentBusy
+		}
+		return errors.New(e.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// FleetWorkload runs a workload across agents. Setup and cleanup run
+// locally against the database; Run distributes the load.
+type FleetWorkload struct {
+	*Coordinator
+	local    plugin.Workload
+	settings map[string]interface{}
+}
+
+// NewFleetWorkload wraps local so its runs execute on the coordinator's
+// agents with the given configuration document
+func NewFleetWorkload(coord *Coordinator, local plugin.Workload, settings map[string]interface{}) *FleetWorkload {
+	return &FleetWorkload{Coordinator: coord, local: local, settings: settings}
+}
+
+// Setup ensures the schema exists, from the coordinator
+func (f *FleetWorkload) Setup(ctx context.Context, db *pgxpool.Pool, cfg *types.Config) error {
+	return f.local.Setup(ctx, db, cfg)
+}
+
+// Cleanup drops and reloads the data, from the coordinator
+func (f *FleetWorkload) Cleanup(ctx context.Context, db *pgxpool.Pool, cfg *types.Config) error {
+	return f.local.Cleanup(ctx, db, cfg)
+}
+
+// Run executes cfg on the agents; db is not used
+func (f *FleetWorkload) Run(ctx context.Context, _ *pgxpool.Pool, cfg *types.Config, m *types.Metrics) error {
+	return f.Coordinator.Run(ctx, f.settings, cfg, m)
+}
+
+// Remote tells the progressive engine the fleet connects from the agents
+func (f *FleetWorkload) Remote() bool {
+	return true
+}
@@ -0,0 +1,461 @@
+// internal/distributed/protocol.go
+package distributed
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/core/domain"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// liveLatencySamples bounds the latency samples an agent returns per
+// status poll, so polls stay cheap on long runs
+const liveLatencySamples = 10000
+
+// StartRequest asks an agent to run its share of a distributed run
+type StartRequest struct {
+	ID           string                 `json:"id"`
+	Settings     map[string]interface{} `json:"settings"`      // Full configuration document, as viper settings
+	WorkerOffset int                    `json:"worker_offset"` // First global worker id of this agent
+	StartAt      time.Time              `json:"start_at"`      // When to start, in the agent's clock
+}
+
+// Health is the agent health check response
+type Health struct {
+	Status  string    `json:"status"`
+	Version string    `json:"version,omitempty"`
+	Time    time.Time `json:"time"` // Agent clock, used to estimate skew
+	Busy    bool      `json:"busy"`
+	RunID   string    `json:"run_id,omitempty"`
+}
+
+// Counters are the cumulative counters of a run
+type Counters struct {
+	Transactions  int64 `json:"transactions"`
+	Aborted       int64 `json:"aborted"`
+	Queries       int64 `json:"queries"`
+	SelectQueries int64 `json:"select_queries"`
+	InsertQueries int64 `json:"insert_queries"`
+	UpdateQueries int64 `json:"update_queries"`
+	DeleteQueries int64 `json:"delete_queries"`
+	RowsRead      int64 `json:"rows_read"`
+	RowsModified  int64 `json:"rows_modified"`
+	Errors        int64 `json:"errors"`
+}
+
+// RunStatus is the live state of an agent run. Latencies holds the samples
+// recorded since the Samples count the caller passed as since.
+type RunStatus struct {
+	ID        string                 `json:"id"`
+	Status    domain.ExecutionStatus `json:"status"`
+	Error     string                 `json:"error,omitempty"`
+	Workers   int                    `json:"workers"`
+	StartedAt *time.Time             `json:"started_at,omitempty"`
+	EndedAt   *time.Time             `json:"ended_at,omitempty"`
+	Counters  Counters               `json:"counters"`
+	Latencies []int64                `json:"latencies,omitempty"`
+	Samples   int                    `json:"samples"` // Latency samples seen, where the next poll continues
+}
+
+// Finished reports whether the run has ended
+func (s *RunStatus) Finished() bool {
+	switch s.Status {
+	case domain.StatusCompleted, domain.StatusFailed, domain.StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// WorkerPayload is the wire form of types.WorkerStats
+type WorkerPayload struct {
+	WorkerID       int     `json:"worker_id"`
+	TPS            int64   `json:"tps"`
+	TPSAborted     int64   `json:"tps_aborted"`
+	QPS            int64   `json:"qps"`
+	Errors         int64   `json:"errors"`
+	TransactionDur []int64 `json:"transaction_dur"`
+}
+
+// OperationPayload is the wire form of types.OperationStats
+type OperationPayload struct {
+	Name        string           `json:"name"`
+	Count       int64            `json:"count"`
+	Errors      int64            `json:"errors"`
+	Rows        int64            `json:"rows"`
+	TotalNs     int64            `json:"total_ns"`
+	MinNs       int64            `json:"min_ns"`
+	MaxNs       int64            `json:"max_ns"`
+	Histogram   map[string]int64 `json:"histogram"`
+	Latencies   []int64          `json:"latencies"`
+	SampleCount int64            `json:"sample_count"`
+}
+
+// MetricsPayload is the wire form of the workload metrics of an agent run
+type MetricsPayload struct {
+	Counters         Counters           `json:"counters"`
+	NewOrderCount    int64              `json:"new_order_count"`
+	PaymentCount     int64              `json:"payment_count"`
+	OrderStatusCount int64              `json:"order_status_count"`
+	ThinkCount       int64              `json:"think_count"`
+	ErrorTypes       map[string]int64   `json:"error_types"`
+	LatencyHistogram map[string]int64   `json:"latency_histogram"`
+	TransactionDur   []int64            `json:"transaction_dur"`
+	Workers          []WorkerPayload    `json:"workers"`
+	Operations       []OperationPayload `json:"operations"`
+	WorkerOffset     int                `json:"worker_offset"`
+	StartedAt        time.Time          `json:"started_at"`
+	BucketInterval   time.Duration      `json:"bucket_interval"`
+	TimeSeries       []types.TimeBucket `json:"time_series"`
+	HostSamples      []types.HostSample `json:"host_samples,omitempty"`
+}
+
+// loadCounters reads the counters of m
+func loadCounters(m *types.Metrics) Counters {
+	return Counters{
+		Transactions:  atomic.LoadInt64(&m.TPS),
+		Aborted:       atomic.LoadInt64(&m.TPSAborted),
+		Queries:       atomic.LoadInt64(&m.QPS),
+		SelectQueries: atomic.LoadInt64(&m.SelectQueries),
+		InsertQueries: atomic.LoadInt64(&m.InsertQueries),
+		UpdateQueries: atomic.LoadInt64(&m.UpdateQueries),
+		DeleteQueries: atomic.LoadInt64(&m.DeleteQueries),
+		RowsRead:      atomic.LoadInt64(&m.RowsRead),
+		RowsModified:  atomic.LoadInt64(&m.RowsModified),
+		Errors:        atomic.LoadInt64(&m.Errors),
+	}
+}
+
+// add sums c and o
+func (c Counters) add(o Counters) Counters {
+	return Counters{
+		Transactions:  c.Transactions + o.Transactions,
+		Aborted:       c.Aborted + o.Aborted,
+		Queries:       c.Queries + o.Queries,
+		SelectQueries: c.SelectQueries + o.SelectQueries,
+		InsertQueries: c.InsertQueries + o.InsertQueries,
+		UpdateQueries: c.UpdateQueries + o.UpdateQueries,
+		DeleteQueries: c.DeleteQueries + o.DeleteQueries,
+		RowsRead:      c.RowsRead + o.RowsRead,
+		RowsModified:  c.RowsModified + o.RowsModified,
+		Errors:        c.Errors + o.Errors,
+	}
+}
+
+// sub returns the difference c - o
+func (c Counters) sub(o Counters) Counters {
+	return Counters{
+		Transactions:  c.Transactions - o.Transactions,
+		Aborted:       c.Aborted - o.Aborted,
+		Queries:       c.Queries - o.Queries,
+		SelectQueries: c.SelectQueries - o.SelectQueries,
+		InsertQueries: c.InsertQueries - o.InsertQueries,
+		UpdateQueries: c.UpdateQueries - o.UpdateQueries,
+		DeleteQueries: c.DeleteQueries - o.DeleteQueries,
+		RowsRead:      c.RowsRead - o.RowsRead,
+		RowsModified:  c.RowsModified - o.RowsModified,
+		Errors:        c.Errors - o.Errors,
+	}
+}
+
+// addCounters adds c to the counters of m atomically
+func addCounters(m *types.Metrics, c Counters) {
+	atomic.AddInt64(&m.TPS, c.Transactions)
+	atomic.AddInt64(&m.TPSAborted, c.Aborted)
+	atomic.AddInt64(&m.QPS, c.Queries)
+	atomic.AddInt64(&m.SelectQueries, c.SelectQueries)
+	atomic.AddInt64(&m.InsertQueries, c.InsertQueries)
+	atomic.AddInt64(&m.UpdateQueries, c.UpdateQueries)
+	atomic.AddInt64(&m.DeleteQueries, c.DeleteQueries)
+	atomic.AddInt64(&m.RowsRead, c.RowsRead)
+	atomic.AddInt64(&m.RowsModified, c.RowsModified)
+	atomic.AddInt64(&m.Errors, c.Errors)
+}
+
+// storeCounters replaces the counters of m atomically
+func storeCounters(m *types.Metrics, c Counters) {
+	atomic.StoreInt64(&m.TPS, c.Transactions)
+	atomic.StoreInt64(&m.TPSAborted, c.Aborted)
+	atomic.StoreInt64(&m.QPS, c.Queries)
+	atomic.StoreInt64(&m.SelectQueries, c.SelectQueries)
+	atomic.StoreInt64(&m.InsertQueries, c.InsertQueries)
+	atomic.StoreInt64(&m.UpdateQueries, c.UpdateQueries)
+	atomic.StoreInt64(&m.DeleteQueries, c.DeleteQueries)
+	atomic.StoreInt64(&m.RowsRead, c.RowsRead)
+	atomic.StoreInt64(&m.RowsModified, c.RowsModified)
+	atomic.StoreInt64(&m.Errors, c.Errors)
+}
+
+// recentLatencies returns the samples of m recorded since the since count,
+// at most liveLatencySamples of them, and the count to continue from
+func recentLatencies(m *types.Metrics, since int) ([]int64, int) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	n := len(m.TransactionDur)
+	from := since
+	if from > n || from < 0 {
+		// The sample slice wrapped at max_latency_samples; take the latest
+		from = 0
+	}
+	if n-from > liveLatencySamples {
+		from = n - liveLatencySamples
+	}
+	return append([]int64(nil), m.TransactionDur[from:]...), n
+}
+
+// CaptureMetrics copies the workload metrics of m into a payload
+func CaptureMetrics(m *types.Metrics, workerOffset int, startedAt time.Time) *MetricsPayload {
+	p := &MetricsPayload{
+		Counters:         loadCounters(m),
+		NewOrderCount:    atomic.LoadInt64(&m.NewOrderCount),
+		PaymentCount:     atomic.LoadInt64(&m.PaymentCount),
+		OrderStatusCount: atomic.LoadInt64(&m.OrderStatusCount),
+		ThinkCount:       atomic.LoadInt64(&m.ThinkCount),
+		ErrorTypes:       make(map[string]int64),
+		LatencyHistogram: make(map[string]int64),
+		WorkerOffset:     workerOffset,
+		StartedAt:        startedAt,
+		BucketInterval:   m.BucketInterval,
+	}
+
+	m.Mu.Lock()
+	for k, v := range m.ErrorTypes {
+		p.ErrorTypes[k] = v
+	}
+	for k, v := range m.LatencyHistogram {
+		p.LatencyHistogram[k] = v
+	}
+	p.TransactionDur = append([]int64(nil), m.TransactionDur...)
+	p.HostSamples = append([]types.HostSample(nil), m.HostSamples...)
+	workers := make([]*types.WorkerStats, 0, len(m.WorkerMetrics))
+	for _, w := range m.WorkerMetrics {
+		workers = append(workers, w)
+	}
+	operations := make([]*types.OperationStats, 0, len(m.Operations))
+	for _, op := range m.Operations {
+		operations = append(operations, op)
+	}
+	ts := m.TimeSeries
+	m.Mu.Unlock()
+
+	for _, w := range workers {
+		w.Mu.Lock()
+		p.Workers = append(p.Workers, WorkerPayload{
+			WorkerID:       w.WorkerID,
+			TPS:            atomic.LoadInt64(&w.TPS),
+			TPSAborted:     atomic.LoadInt64(&w.TPSAborted),
+			QPS:            atomic.LoadInt64(&w.QPS),
+			Errors:         atomic.LoadInt64(&w.Errors),
+			TransactionDur: append([]int64(nil), w.TransactionDur...),
+		})
+		w.Mu.Unlock()
+	}
+	sort.Slice(p.Workers, func(i, j int) bool { return p.Workers[i].WorkerID < p.Workers[j].WorkerID })
+
+	for _, op := range operations {
+		op.Mu.Lock()
+		payload := OperationPayload{
+			Name:        op.Name,
+			Count:       op.Count,
+			Errors:      op.Errors,
+			Rows:        op.Rows,
+			TotalNs:     op.TotalNs,
+			MinNs:       op.MinNs,
+			MaxNs:       op.MaxNs,
+			Histogram:   make(map[string]int64, len(op.Histogram)),
+			Latencies:   append([]int64(nil), op.Latencies...),
+			SampleCount: op.SampleCount,
+		}
+		for k, v := range op.Histogram {
+			payload.Histogram[k] = v
+		}
+		op.Mu.Unlock()
+		p.Operations = append(p.Operations, payload)
+	}
+
+	if ts != nil {
+		ts.Mu.RLock()
+		p.TimeSeries = append([]types.TimeBucket(nil), ts.Buckets...)
+		if b := ts.CurrentBucket; b != nil && (b.TPS > 0 || len(b.Latencies) > 0) {
+			p.TimeSeries = append(p.TimeSeries, *b)
+		}
+		ts.Mu.RUnlock()
+	}
+	return p
+}
+
+// MergeMetrics replaces the workload metrics of dst with the sum of the
+// agent payloads. Worker ids are shifted by each agent's worker offset and
+// time-series buckets are aligned by their offset from each agent's start,
+// with merged buckets counted from start. The host resource samples of all
+// agents are kept, each labelled with its agent's host name.
+func MergeMetrics(dst *types.Metrics, parts []*MetricsPayload, start time.Time) {
+	var total Counters
+	var newOrder, payment, orderStatus, think int64
+	errorTypes := make(map[string]int64)
+	histogram := make(map[string]int64)
+	var durations []int64
+	workers := make(map[int]*types.WorkerStats)
+	operations := make(map[string]*types.OperationStats)
+	var hostSamples []types.HostSample
+	var interval time.Duration
+	for _, p := range parts {
+		total = total.add(p.Counters)
+		newOrder += p.NewOrderCount
+		payment += p.PaymentCount
+		orderStatus += p.OrderStatusCount
+		think += p.ThinkCount
+		for k, v := range p.ErrorTypes {
+			errorTypes[k] += v
+		}
+		for k, v := range p.LatencyHistogram {
+			histogram[k] += v
+		}
+		durations = append(durations, p.TransactionDur...)
+		for _, w := range p.Workers {
+			id := p.WorkerOffset + w.WorkerID
+			workers[id] = &types.WorkerStats{
+				WorkerID:       id,
+				TPS:            w.TPS,
+				TPSAborted:     w.TPSAborted,
+				QPS:            w.QPS,
+				Errors:         w.Errors,
+				TransactionDur: w.TransactionDur,
+			}
+		}
+		for _, op := range p.Operations {
+			mergeOperation(operations, op)
+		}
+		hostSamples = append(hostSamples, p.HostSamples...)
+		if p.BucketInterval > interval {
+			interval = p.BucketInterval
+		}
+	}
+
+	storeCounters(dst, total)
+	atomic.StoreInt64(&dst.NewOrderCount, newOrder)
+	atomic.StoreInt64(&dst.PaymentCount, payment)
+	atomic.StoreInt64(&dst.OrderStatusCount, orderStatus)
+	atomic.StoreInt64(&dst.ThinkCount, think)
+
+	dst.Mu.Lock()
+	dst.ErrorTypes = errorTypes
+	if len(histogram) > 0 {
+		dst.LatencyHistogram = histogram
+	}
+	dst.TransactionDur = durations
+	atomic.StoreInt64(&dst.LatencySampleCount, int64(len(durations)))
+	if len(workers) > 0 {
+		dst.WorkerMetrics = workers
+	}
+	if len(operations) > 0 {
+		dst.Operations = operations
+	}
+	if len(hostSamples) > 0 {
+		sort.SliceStable(hostSamples, func(i, j int) bool { return hostSamples[i].Time.Before(hostSamples[j].Time) })
+		dst.HostSamples = hostSamples
+	}
+	if buckets := mergeBuckets(parts, interval, start); len(buckets) > 0 {
+		dst.BucketInterval = interval
+		dst.TimeSeries = &types.TimeSeriesMetrics{Buckets: buckets, StartTime: start}
+	}
+	dst.Mu.Unlock()
+}
+
+// mergeOperation adds the operation of one agent to the merged operations;
+// the latency samples of all agents are kept so percentiles stay unbiased
+func mergeOperation(operations map[string]*types.OperationStats, p OperationPayload) {
+	op, ok := operations[p.Name]
+	if !ok {
+		op = &types.OperationStats{Name: p.Name, MinNs: p.MinNs, Histogram: make(map[string]int64)}
+		operations[p.Name] = op
+	}
+	op.Count += p.Count
+	op.Errors += p.Errors
+	op.Rows += p.Rows
+	op.TotalNs += p.TotalNs
+	if p.Count > 0 && p.MinNs < op.MinNs {
+		op.MinNs = p.MinNs
+	}
+	if p.MaxNs > op.MaxNs {
+		op.MaxNs = p.MaxNs
+	}
+	for k, v := range p.Histogram {
+		op.Histogram[k] += v
+	}
+	op.Latencies = append(op.Latencies, p.Latencies...)
+	op.SampleCount += p.SampleCount
+}
+
+// mergeBuckets sums the time-series buckets of the payloads by their index
+// from each agent's start
+func mergeBuckets(parts []*MetricsPayload, interval time.Duration, start time.Time) []types.TimeBucket {
+	if interval <= 0 {
+		return nil
+	}
+	merged := make(map[int]*types.TimeBucket)
+	for _, p := range parts {
+		for _, b := range p.TimeSeries {
+			idx := int((b.StartTime.Sub(p.StartedAt) + interval/2) / interval)
+			if idx < 0 {
+				idx = 0
+			}
+			m, ok := merged[idx]
+			if !ok {
+				bucketStart := start.Add(time.Duration(idx) * interval)
+				m = &types.TimeBucket{StartTime: bucketStart, EndTime: bucketStart.Add(interval)}
+				merged[idx] = m
+			}
+			m.QPS += b.QPS
+			m.TPS += b.TPS
+			m.Errors += b.Errors
+			m.RowsRead += b.RowsRead
+			m.RowsModified += b.RowsModified
+			m.SelectQueries += b.SelectQueries
+			m.InsertQueries += b.InsertQueries
+			m.UpdateQueries += b.UpdateQueries
+			m.DeleteQueries += b.DeleteQueries
+			m.Latencies = append(m.Latencies, b.Latencies...)
+			m.RowsPerQuery = append(m.RowsPerQuery, b.RowsPerQuery...)
+			m.StmtsPerTxn = append(m.StmtsPerTxn, b.StmtsPerTxn...)
+			m.RowsPerTxn = append(m.RowsPerTxn, b.RowsPerTxn...)
+			m.SelectRows = append(m.SelectRows, b.SelectRows...)
+			m.UpdateRows = append(m.UpdateRows, b.UpdateRows...)
+			m.InsertRows = append(m.InsertRows, b.InsertRows...)
+			m.DeleteRows = append(m.DeleteRows, b.DeleteRows...)
+			m.Events = append(m.Events, b.Events...)
+		}
+	}
+
+	indexes := make([]int, 0, len(merged))
+	for idx := range merged {
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+	buckets := make([]types.TimeBucket, 0, len(indexes))
+	for _, idx := range indexes {
+		buckets = append(buckets, *merged[idx])
+	}
+	return buckets
+}
+
+// SplitEven divides total into n shares that differ by at most one, larger
+// shares first
+func SplitEven(total, n int) []int {
+	shares := make([]int, n)
+	for i := range shares {
+		shares[i] = total / n
+		if i < total%n {
+			shares[i]++
+		}
+	}
+	return shares
+}
+
+// agentError names the agent an error came from
+func agentError(addr string, err error) error {
+	return fmt.Errorf("agent %s: %w", addr, err)
+}
@@ -0,0 +1,147 @@
+package explain
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// explainableKeywords are the statements EXPLAIN accepts
+var explainableKeywords = map[string]bool{
+	"select": true,
+	"insert": true,
+	"update": true,
+	"delete": true,
+	"merge":  true,
+	"with":   true,
+	"values": true,
+	"table":  true,
+}
+
+var (
+	lineComment    = regexp.MustCompile(`--[^\n]*`)
+	blockComment   = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	stringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numericLiteral = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	whitespace     = regexp.MustCompile(`\s+`)
+)
+
+// Explainable reports whether sql is a statement EXPLAIN accepts
+func Explainable(sql string) bool {
+	sql = strings.TrimSpace(blockComment.ReplaceAllString(lineComment.ReplaceAllString(sql, ""), ""))
+	sql = strings.TrimLeft(sql, "(")
+	keyword, _, _ := strings.Cut(sql, " ")
+	if i := strings.IndexAny(keyword, "\n\t("); i >= 0 {
+		keyword = keyword[:i]
+	}
+	return explainableKeywords[strings.ToLower(keyword)]
+}
+
+// Fingerprint identifies a statement independently of its literal values
+// and formatting, so plans of the same statement match across runs
+func Fingerprint(sql string) string {
+	normalized := lineComment.ReplaceAllString(sql, "")
+	normalized = blockComment.ReplaceAllString(normalized, "")
+	normalized = stringLiteral.ReplaceAllString(normalized, "?")
+	normalized = numericLiteral.ReplaceAllString(normalized, "?")
+	normalized = strings.ToLower(strings.TrimSpace(whitespace.ReplaceAllString(normalized, " ")))
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(normalized))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// planNode is the part of an EXPLAIN (FORMAT JSON) node the shape uses
+type planNode struct {
+	NodeType     string     `json:"Node Type"`
+	JoinType     string     `json:"Join Type"`
+	Strategy     string     `json:"Strategy"`
+	RelationName string     `json:"Relation Name"`
+	IndexName    string     `json:"Index Name"`
+	Plans        []planNode `json:"Plans"`
+}
+
+// ParsePlan returns the shape and execution time of an EXPLAIN (ANALYZE,
+// FORMAT JSON) result
+func ParsePlan(raw []byte) (string, float64, error) {
+	var result []struct {
+		Plan          planNode `json:"Plan"`
+		ExecutionTime float64  `json:"Execution Time"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return "", 0, fmt.Errorf("failed to parse plan: %w", err)
+	}
+	if len(result) == 0 || result[0].Plan.NodeType == "" {
+		return "", 0, fmt.Errorf("plan is empty")
+	}
+	var b strings.Builder
+	writeShape(&b, result[0].Plan)
+	return b.String(), result[0].ExecutionTime, nil
+}
+
+// writeShape writes a node and its children without costs, row counts or
+// timings, e.g. "Limit(Sort(Seq Scan on orders))", so two plans have the
+// same shape when the planner chose the same strategy
+func writeShape(b *strings.Builder, n planNode) {
+	if n.JoinType != "" && n.JoinType != "Inner" {
+		b.WriteString(n.JoinType + " ")
+	}
+	b.WriteString(n.NodeType)
+	if n.Strategy != "" && n.Strategy != "Plain" {
+		b.WriteString(" [" + n.Strategy + "]")
+	}
+	if n.IndexName != "" {
+		b.WriteString(" using " + n.IndexName)
+	}
+	if n.RelationName != "" {
+		b.WriteString(" on " + n.RelationName)
+	}
+	if len(n.Plans) == 0 {
+		return
+	}
+	b.WriteString("(")
+	for i, child := range n.Plans {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		writeShape(b, child)
+	}
+	b.WriteString(")")
+}
+
+// DetectChanges marks the plans whose shape the previous run's plans of
+// the same operation and statement never had, and returns how many changed.
+// Statements the previous run did not capture are not compared.
+func DetectChanges(previous, plans []types.QueryPlan) int {
+	shapes := make(map[string][]string)
+	for _, p := range previous {
+		if p.Shape != "" {
+			key := p.Operation + "\x00" + p.Fingerprint
+			shapes[key] = append(shapes[key], p.Shape)
+		}
+	}
+
+	changed := 0
+	for i := range plans {
+		p := &plans[i]
+		known := shapes[p.Operation+"\x00"+p.Fingerprint]
+		if p.Shape == "" || len(known) == 0 {
+			continue
+		}
+		p.Changed, p.PreviousShape = true, known[0]
+		for _, shape := range known {
+			if shape == p.Shape {
+				p.Changed, p.PreviousShape = false, ""
+				break
+			}
+		}
+		if p.Changed {
+			changed++
+		}
+	}
+	return changed
+}
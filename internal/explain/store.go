@@ -0,0 +1,118 @@
+package explain
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// fileTimeFormat names plan files so they sort by run start time
+const fileTimeFormat = "20060102T150405.000000000Z"
+
+// unsafeFileChars are replaced in workload names used as file prefixes
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// PlanSet is the stored form of the plans captured during one run
+type PlanSet struct {
+	Workload  string            `json:"workload"`
+	StartTime time.Time         `json:"start_time"`
+	Plans     []types.QueryPlan `json:"plans"`
+}
+
+// Store keeps the plans of each run as a JSON file in a directory:
+//
+//	<workload>-<start time>.json
+type Store struct {
+	dir string
+}
+
+// DefaultDir returns the plan store directory used when the explain
+// configuration sets none: ~/.stormdb/plans, or .stormdb/plans in the
+// working directory when there is no home directory
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return filepath.Join(".stormdb", "plans")
+	}
+	return filepath.Join(home, ".stormdb", "plans")
+}
+
+// NewStore creates a store in dir, or in DefaultDir when dir is empty
+func NewStore(dir string) *Store {
+	if dir == "" {
+		dir = DefaultDir()
+	}
+	return &Store{dir: dir}
+}
+
+// Save writes the plans of a run and returns the file path
+func (s *Store) Save(workload string, start time.Time, plans []types.QueryPlan) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create plan directory: %w", err)
+	}
+	data, err := json.MarshalIndent(PlanSet{Workload: workload, StartTime: start, Plans: plans}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode plans: %w", err)
+	}
+	path := s.path(workload, start)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write plans: %w", err)
+	}
+	return path, nil
+}
+
+// Previous returns the plans of the latest run of workload that started
+// before start, or nil when there is none
+func (s *Store) Previous(workload string, start time.Time) ([]types.QueryPlan, error) {
+	prefix := filePrefix(workload)
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan directory: %w", err)
+	}
+
+	current := filepath.Base(s.path(workload, start))
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		// The prefix is followed directly by the timestamp, so workloads
+		// sharing a prefix ("tpcc" and "tpcc-lite") are told apart
+		stamp, ok := strings.CutPrefix(name, prefix)
+		if ok && !e.IsDir() && strings.HasSuffix(name, ".json") && len(stamp) == len(fileTimeFormat)+len(".json") && name < current {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	sort.Strings(names)
+
+	data, err := os.ReadFile(filepath.Join(s.dir, names[len(names)-1]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read previous plans: %w", err)
+	}
+	var set PlanSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse previous plans: %w", err)
+	}
+	return set.Plans, nil
+}
+
+// path returns the file of the plans of a run
+func (s *Store) path(workload string, start time.Time) string {
+	return filepath.Join(s.dir, filePrefix(workload)+start.UTC().Format(fileTimeFormat)+".json")
+}
+
+// filePrefix returns the file name prefix of a workload's plans
+func filePrefix(workload string) string {
+	return unsafeFileChars.ReplaceAllString(workload, "_") + "-"
+}
@@ -0,0 +1,330 @@
+// Package explain captures query plans of slow or sampled statements. A
+// pgx query tracer times every statement of the workload pool; statements
+// above the threshold, or picked by the sample rate, are re-executed with
+// EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) in a rolled-back transaction on a
+// separate connection, and their plans are stored alongside the run.
+package explain
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/database"
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/jackc/pgx/v5"
+)
+
+// Defaults of the explain configuration
+const (
+	DefaultThreshold = 100 * time.Millisecond
+	DefaultMaxPlans  = 3
+)
+
+// Capture limits. Plans are captured one at a time so the re-executions
+// add little load; statements arriving while the queue is full are skipped.
+const (
+	queueSize        = 32
+	captureTimeout   = 30 * time.Second
+	lockTimeout      = "1s"
+	stopGracePeriod  = 30 * time.Second
+	explainStatement = "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) "
+)
+
+// Capture reasons
+const (
+	ReasonThreshold = "threshold"
+	ReasonSample    = "sample"
+)
+
+// Options controls which statements are captured
+type Options struct {
+	Threshold  time.Duration // Capture statements at least this slow
+	SampleRate float64       // Fraction of faster statements to capture
+	MaxPlans   int           // Plans captured per operation and statement
+}
+
+// OptionsFrom returns the capture options of cfg with defaults applied
+func OptionsFrom(cfg *types.Config) (Options, error) {
+	opts := Options{
+		Threshold:  DefaultThreshold,
+		SampleRate: cfg.Explain.SampleRate,
+		MaxPlans:   cfg.Explain.MaxPlans,
+	}
+	if cfg.Explain.Threshold != "" {
+		d, err := time.ParseDuration(cfg.Explain.Threshold)
+		if err != nil || d < 0 {
+			return opts, fmt.Errorf("invalid threshold: %s", cfg.Explain.Threshold)
+		}
+		opts.Threshold = d
+	}
+	if opts.SampleRate < 0 || opts.SampleRate > 1 {
+		return opts, fmt.Errorf("sample_rate must be between 0 and 1, got %g", opts.SampleRate)
+	}
+	if opts.MaxPlans < 0 {
+		return opts, fmt.Errorf("max_plans must not be negative, got %d", opts.MaxPlans)
+	}
+	if opts.MaxPlans == 0 {
+		opts.MaxPlans = DefaultMaxPlans
+	}
+	return opts, nil
+}
+
+// Tracer is a pgx.QueryTracer that captures the plans of slow or sampled
+// statements. It only captures between Start and Stop, so schema setup and
+// data loading on the same pool are not traced.
+type Tracer struct {
+	opts       Options
+	connString string
+	active     atomic.Bool
+
+	jobs   chan captureJob
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu      sync.Mutex
+	counts  map[string]int // Plans scheduled by operation and fingerprint
+	skipped int64          // Statements skipped because the queue was full
+	plans   []types.QueryPlan
+}
+
+// captureJob is a statement waiting to be explained
+type captureJob struct {
+	operation   string
+	sql         string
+	args        []any
+	fingerprint string
+	reason      string
+	latency     time.Duration
+}
+
+// traceKey is the context key of the statement being traced
+type traceKey struct{}
+
+// traceData is the statement being traced
+type traceData struct {
+	start     time.Time
+	sql       string
+	args      []any
+	operation string
+}
+
+// NewTracer creates a tracer capturing plans over its own connection to
+// the database of cfg
+func NewTracer(cfg *types.Config) (*Tracer, error) {
+	opts, err := OptionsFrom(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Tracer{
+		opts:       opts,
+		connString: database.BuildConnectionString(cfg),
+		counts:     make(map[string]int),
+	}, nil
+}
+
+// Options returns the capture options
+func (t *Tracer) Options() Options {
+	return t.opts
+}
+
+// Start begins capturing plans
+func (t *Tracer) Start() {
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+	t.jobs = make(chan captureJob, queueSize)
+	t.done = make(chan struct{})
+	go t.run()
+	t.active.Store(true)
+}
+
+// Stop ends capturing, waits for the queued captures and returns the plans
+// ordered by operation, statement and latency, slowest first
+func (t *Tracer) Stop() []types.QueryPlan {
+	if !t.active.Swap(false) {
+		return t.Plans()
+	}
+	// Tracing has stopped; statements still finishing do not enqueue
+	t.mu.Lock()
+	close(t.jobs)
+	t.mu.Unlock()
+
+	timer := time.AfterFunc(stopGracePeriod, t.cancel)
+	<-t.done
+	timer.Stop()
+	t.cancel()
+
+	if t.skipped > 0 {
+		log.Printf("⚠️  Skipped %d query plan capture(s) while the capture queue was full", t.skipped)
+	}
+	return t.Plans()
+}
+
+// Plans returns the plans captured so far
+func (t *Tracer) Plans() []types.QueryPlan {
+	t.mu.Lock()
+	plans := append([]types.QueryPlan(nil), t.plans...)
+	t.mu.Unlock()
+
+	sort.SliceStable(plans, func(i, j int) bool {
+		if plans[i].Operation != plans[j].Operation {
+			return plans[i].Operation < plans[j].Operation
+		}
+		if plans[i].Fingerprint != plans[j].Fingerprint {
+			return plans[i].Fingerprint < plans[j].Fingerprint
+		}
+		return plans[i].LatencyMs > plans[j].LatencyMs
+	})
+	return plans
+}
+
+// TraceQueryStart implements pgx.QueryTracer
+func (t *Tracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if !t.active.Load() || !Explainable(data.SQL) {
+		return ctx
+	}
+	return context.WithValue(ctx, traceKey{}, &traceData{
+		start:     time.Now(),
+		sql:       data.SQL,
+		args:      data.Args,
+		operation: types.OperationFromContext(ctx),
+	})
+}
+
+// TraceQueryEnd implements pgx.QueryTracer
+func (t *Tracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	td, ok := ctx.Value(traceKey{}).(*traceData)
+	if !ok || data.Err != nil {
+		return
+	}
+	latency := time.Since(td.start)
+
+	var reason string
+	switch {
+	case latency >= t.opts.Threshold:
+		reason = ReasonThreshold
+	case t.opts.SampleRate > 0 && rand.Float64() < t.opts.SampleRate:
+		reason = ReasonSample
+	default:
+		return
+	}
+
+	job := captureJob{
+		operation:   td.operation,
+		sql:         td.sql,
+		args:        td.args,
+		fingerprint: Fingerprint(td.sql),
+		reason:      reason,
+		latency:     latency,
+	}
+	key := job.operation + "\x00" + job.fingerprint
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.active.Load() || t.counts[key] >= t.opts.MaxPlans {
+		return
+	}
+	select {
+	case t.jobs <- job:
+		t.counts[key]++
+	default:
+		t.skipped++
+	}
+}
+
+// run captures queued statements until the queue is closed
+func (t *Tracer) run() {
+	defer close(t.done)
+
+	var conn *pgx.Conn
+	defer func() {
+		if conn != nil {
+			_ = conn.Close(context.Background())
+		}
+	}()
+
+	for job := range t.jobs {
+		plan := types.QueryPlan{
+			Operation:   job.operation,
+			Query:       job.sql,
+			Fingerprint: job.fingerprint,
+			Reason:      job.reason,
+			LatencyMs:   float64(job.latency.Nanoseconds()) / 1e6,
+			CapturedAt:  time.Now(),
+		}
+
+		var err error
+		if conn == nil || conn.IsClosed() {
+			conn, err = t.connect()
+		}
+		if err == nil {
+			err = t.capture(conn, job, &plan)
+		}
+		if err != nil {
+			plan.Error = err.Error()
+		}
+
+		t.mu.Lock()
+		t.plans = append(t.plans, plan)
+		t.mu.Unlock()
+	}
+}
+
+// connect opens the capture connection. It describes statements before
+// executing them so arguments are encoded as in the workload's modes.
+func (t *Tracer) connect() (*pgx.Conn, error) {
+	connCfg, err := pgx.ParseConfig(t.connString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection config: %w", err)
+	}
+	connCfg.DefaultQueryExecMode = pgx.QueryExecModeDescribeExec
+	connCfg.RuntimeParams["application_name"] = database.ApplicationName + "_explain"
+
+	ctx, cancel := context.WithTimeout(t.ctx, captureTimeout)
+	defer cancel()
+	conn, err := pgx.ConnectConfig(ctx, connCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect for plan capture: %w", err)
+	}
+	return conn, nil
+}
+
+// capture re-executes job under EXPLAIN ANALYZE in a transaction that is
+// always rolled back. Lock waits are bounded so statements blocked by an
+// open workload transaction fail instead of stalling the capture queue.
+func (t *Tracer) capture(conn *pgx.Conn, job captureJob, plan *types.QueryPlan) error {
+	ctx, cancel := context.WithTimeout(t.ctx, captureTimeout)
+	defer cancel()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin capture transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(context.Background()) }()
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL lock_timeout = '%s'", lockTimeout)); err != nil {
+		return fmt.Errorf("failed to set lock_timeout: %w", err)
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", captureTimeout.Milliseconds())); err != nil {
+		return fmt.Errorf("failed to set statement_timeout: %w", err)
+	}
+
+	var raw []byte
+	if err := tx.QueryRow(ctx, explainStatement+job.sql, job.args...).Scan(&raw); err != nil {
+		return fmt.Errorf("explain failed: %w", err)
+	}
+
+	shape, executionMs, err := ParsePlan(raw)
+	if err != nil {
+		return err
+	}
+	plan.Plan = raw
+	plan.Shape = shape
+	plan.ExecutionMs = executionMs
+	return nil
+}
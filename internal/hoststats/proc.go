@@ -0,0 +1,177 @@
+package hoststats
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// CPUTimes are the aggregate CPU counters of /proc/stat, in clock ticks
+type CPUTimes struct {
+	User    uint64
+	Nice    uint64
+	System  uint64
+	Idle    uint64
+	IOWait  uint64
+	IRQ     uint64
+	SoftIRQ uint64
+	Steal   uint64
+}
+
+// total returns all ticks. Guest time is already included in user time.
+func (c CPUTimes) total() uint64 {
+	return c.User + c.Nice + c.System + c.Idle + c.IOWait + c.IRQ + c.SoftIRQ + c.Steal
+}
+
+// Snapshot holds the cumulative /proc counters at one point in time
+type Snapshot struct {
+	Time            time.Time
+	CPU             CPUTimes
+	CPUs            int
+	ContextSwitches uint64
+	RunQueue        int64 // procs_running, a point-in-time value
+	MemTotal        int64 // Bytes
+	MemAvailable    int64 // Bytes
+	NetRx           uint64
+	NetTx           uint64
+}
+
+// ReadSnapshot reads the counters from the procfs mounted at procDir
+func ReadSnapshot(procDir string) (Snapshot, error) {
+	s := Snapshot{Time: time.Now()}
+	if err := readFile(filepath.Join(procDir, "stat"), s.parseStat); err != nil {
+		return s, err
+	}
+	if err := readFile(filepath.Join(procDir, "meminfo"), s.parseMeminfo); err != nil {
+		return s, err
+	}
+	if err := readFile(filepath.Join(procDir, "net", "dev"), s.parseNetDev); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// readFile calls parse with each line of a file
+func readFile(path string, parse func(fields []string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parse(strings.Fields(scanner.Text()))
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return nil
+}
+
+// parseStat reads the aggregate CPU line, the per-CPU lines, context
+// switches and runnable tasks of /proc/stat
+func (s *Snapshot) parseStat(fields []string) {
+	if len(fields) < 2 {
+		return
+	}
+	switch {
+	case fields[0] == "cpu":
+		counters := []*uint64{&s.CPU.User, &s.CPU.Nice, &s.CPU.System, &s.CPU.Idle,
+			&s.CPU.IOWait, &s.CPU.IRQ, &s.CPU.SoftIRQ, &s.CPU.Steal}
+		for i, c := range counters {
+			if i+1 < len(fields) {
+				*c = parseUint(fields[i+1])
+			}
+		}
+	case strings.HasPrefix(fields[0], "cpu"):
+		s.CPUs++
+	case fields[0] == "ctxt":
+		s.ContextSwitches = parseUint(fields[1])
+	case fields[0] == "procs_running":
+		s.RunQueue = int64(parseUint(fields[1]))
+	}
+}
+
+// parseMeminfo reads the total and available memory of /proc/meminfo
+func (s *Snapshot) parseMeminfo(fields []string) {
+	if len(fields) < 2 {
+		return
+	}
+	kb := int64(parseUint(fields[1])) * 1024
+	switch fields[0] {
+	case "MemTotal:":
+		s.MemTotal = kb
+	case "MemAvailable:":
+		s.MemAvailable = kb
+	}
+}
+
+// parseNetDev sums the received and transmitted bytes of /proc/net/dev
+// over every interface except loopback. Large counters can be joined to
+// the interface name ("eth0:123"), so lines are split at the colon.
+func (s *Snapshot) parseNetDev(fields []string) {
+	name, rest, ok := strings.Cut(strings.Join(fields, " "), ":")
+	if !ok || name == "lo" {
+		return // Header lines have no colon
+	}
+	counters := strings.Fields(rest)
+	if len(counters) < 9 {
+		return
+	}
+	s.NetRx += parseUint(counters[0])
+	s.NetTx += parseUint(counters[8])
+}
+
+// parseUint parses a counter, treating malformed values as zero
+func parseUint(v string) uint64 {
+	n, _ := strconv.ParseUint(v, 10, 64)
+	return n
+}
+
+// NewHostSample returns the resource usage of host between two snapshots
+func NewHostSample(prev, cur Snapshot, host string) types.HostSample {
+	sample := types.HostSample{
+		Time:              cur.Time,
+		Host:              host,
+		CPUs:              cur.CPUs,
+		RunQueue:          cur.RunQueue,
+		MemAvailableBytes: cur.MemAvailable,
+	}
+	if cur.MemTotal > 0 {
+		sample.MemUsedPct = float64(cur.MemTotal-cur.MemAvailable) / float64(cur.MemTotal) * 100
+	}
+
+	if total := delta(prev.CPU.total(), cur.CPU.total()); total > 0 {
+		pct := func(prev, cur uint64) float64 {
+			return float64(delta(prev, cur)) / float64(total) * 100
+		}
+		idle := pct(prev.CPU.Idle, cur.CPU.Idle)
+		sample.CPUIOWaitPct = pct(prev.CPU.IOWait, cur.CPU.IOWait)
+		sample.CPUBusyPct = 100 - idle - sample.CPUIOWaitPct
+		sample.CPUUserPct = pct(prev.CPU.User+prev.CPU.Nice, cur.CPU.User+cur.CPU.Nice)
+		sample.CPUSystemPct = pct(prev.CPU.System+prev.CPU.IRQ+prev.CPU.SoftIRQ, cur.CPU.System+cur.CPU.IRQ+cur.CPU.SoftIRQ)
+		sample.CPUStealPct = pct(prev.CPU.Steal, cur.CPU.Steal)
+	}
+
+	if seconds := cur.Time.Sub(prev.Time).Seconds(); seconds > 0 {
+		sample.NetRxBytesPerSec = float64(delta(prev.NetRx, cur.NetRx)) / seconds
+		sample.NetTxBytesPerSec = float64(delta(prev.NetTx, cur.NetTx)) / seconds
+		sample.ContextSwitchesPerSec = float64(delta(prev.ContextSwitches, cur.ContextSwitches)) / seconds
+	}
+	return sample
+}
+
+// delta returns the growth of a counter, or zero when it was reset
+func delta(prev, cur uint64) uint64 {
+	if cur < prev {
+		return 0
+	}
+	return cur - prev
+}
@@ -0,0 +1,220 @@
+// Package hoststats samples the resources of the load generator host from
+// /proc, and optionally the I/O and WAL of the database server through SQL,
+// so reports can tell whether the client or the server limited a run.
+package hoststats
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Defaults of the host_stats configuration
+const (
+	DefaultInterval     = time.Second
+	DefaultCPUThreshold = 90.0
+	DefaultProcDir      = "/proc"
+)
+
+// queryTimeout bounds each server sampling query
+const queryTimeout = 5 * time.Second
+
+// Options controls what is sampled and when a client sample is saturated
+type Options struct {
+	Interval     time.Duration
+	Server       bool
+	CPUThreshold float64 // Busy CPU percentage counted as saturated
+	ProcDir      string
+}
+
+// OptionsFrom returns the sampling options of cfg with defaults applied
+func OptionsFrom(cfg *types.Config) (Options, error) {
+	opts := Options{
+		Interval:     DefaultInterval,
+		Server:       cfg.HostStats.Server,
+		CPUThreshold: cfg.HostStats.CPUThreshold,
+		ProcDir:      cfg.HostStats.ProcDir,
+	}
+	if cfg.HostStats.Interval != "" {
+		d, err := time.ParseDuration(cfg.HostStats.Interval)
+		if err != nil || d <= 0 {
+			return opts, fmt.Errorf("invalid interval: %s", cfg.HostStats.Interval)
+		}
+		opts.Interval = d
+	}
+	if opts.CPUThreshold < 0 || opts.CPUThreshold > 100 {
+		return opts, fmt.Errorf("cpu_threshold must be between 0 and 100, got %g", opts.CPUThreshold)
+	}
+	if opts.CPUThreshold == 0 {
+		opts.CPUThreshold = DefaultCPUThreshold
+	}
+	if opts.ProcDir == "" {
+		opts.ProcDir = DefaultProcDir
+	}
+	return opts, nil
+}
+
+// Saturated reports whether a client sample shows the host out of CPU: busy
+// CPU at or above the threshold, or more runnable tasks than CPUs
+func Saturated(s types.HostSample, cpuThreshold float64) bool {
+	return s.CPUBusyPct >= cpuThreshold || (s.CPUs > 0 && s.RunQueue > int64(s.CPUs))
+}
+
+// Sampler records host and server samples into a run's metrics
+type Sampler struct {
+	opts    Options
+	metrics *types.Metrics
+	pool    *pgxpool.Pool // Server sampling pool, nil when the server is not sampled
+	client  bool
+	host    string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	prevHost   *Snapshot
+	prevServer *serverCounters
+	noIO       bool // pg_stat_io is not available
+	noWALDir   bool // pg_ls_waldir is not permitted
+	warnOnce   sync.Once
+}
+
+// serverCounters are the cumulative pg_stat_io counters of the server
+type serverCounters struct {
+	time    time.Time
+	reads   int64
+	writes  int64
+	fsyncs  int64
+	walSize int64
+	walN    int64
+}
+
+// NewSampler creates a sampler. client enables /proc sampling of this host;
+// the server is sampled over pool when the options ask for it and pool is
+// not nil.
+func NewSampler(cfg *types.Config, m *types.Metrics, pool *pgxpool.Pool, client bool) (*Sampler, error) {
+	opts, err := OptionsFrom(cfg)
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	s := &Sampler{opts: opts, metrics: m, client: client, host: host}
+	if opts.Server {
+		s.pool = pool
+	}
+	return s, nil
+}
+
+// Options returns the sampling options
+func (s *Sampler) Options() Options {
+	return s.opts
+}
+
+// Start samples every interval until Stop is called. The first readings
+// only set the baseline of the counters.
+func (s *Sampler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			s.sample(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops sampling
+func (s *Sampler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+	s.cancel = nil
+}
+
+// sample takes one client and one server sample
+func (s *Sampler) sample(ctx context.Context) {
+	if s.client {
+		s.sampleHost()
+	}
+	if s.pool != nil && !(s.noIO && s.noWALDir) {
+		s.sampleServer(ctx)
+	}
+}
+
+// sampleHost reads /proc and records the usage since the previous reading
+func (s *Sampler) sampleHost() {
+	cur, err := ReadSnapshot(s.opts.ProcDir)
+	if err != nil {
+		s.warnOnce.Do(func() { log.Printf("⚠️  Host resource sampling unavailable: %v", err) })
+		return
+	}
+	if s.prevHost != nil {
+		s.metrics.RecordHostSample(NewHostSample(*s.prevHost, cur, s.host))
+	}
+	s.prevHost = &cur
+}
+
+// sampleServer reads pg_stat_io and the WAL directory size. Either source
+// is dropped for the rest of the run after its first failure.
+func (s *Sampler) sampleServer(ctx context.Context) {
+	queryCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	cur := &serverCounters{time: time.Now()}
+	if !s.noIO {
+		err := s.pool.QueryRow(queryCtx, `SELECT COALESCE(sum(reads), 0)::bigint, COALESCE(sum(writes), 0)::bigint,
+			COALESCE(sum(fsyncs), 0)::bigint FROM pg_stat_io`).Scan(&cur.reads, &cur.writes, &cur.fsyncs)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("⚠️  Server I/O sampling unavailable (pg_stat_io needs PostgreSQL 16+): %v", err)
+			s.noIO = true
+		}
+	}
+	if !s.noWALDir {
+		err := s.pool.QueryRow(queryCtx, "SELECT COALESCE(sum(size), 0)::bigint, count(*) FROM pg_ls_waldir()").Scan(&cur.walSize, &cur.walN)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("⚠️  WAL directory sampling unavailable (pg_ls_waldir needs superuser or pg_monitor): %v", err)
+			s.noWALDir = true
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	if prev := s.prevServer; prev != nil {
+		sample := types.ServerSample{Time: cur.time, WALDirBytes: cur.walSize, WALFiles: cur.walN}
+		if seconds := cur.time.Sub(prev.time).Seconds(); seconds > 0 && !s.noIO {
+			sample.IOReadsPerSec = rate(prev.reads, cur.reads, seconds)
+			sample.IOWritesPerSec = rate(prev.writes, cur.writes, seconds)
+			sample.IOFsyncsPerSec = rate(prev.fsyncs, cur.fsyncs, seconds)
+		}
+		s.metrics.RecordServerSample(sample)
+	}
+	s.prevServer = cur
+}
+
+// rate returns the per-second growth of a counter, or zero when it was reset
+func rate(prev, cur int64, seconds float64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur-prev) / seconds
+}
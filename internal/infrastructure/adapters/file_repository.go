@@ -0,0 +1,579 @@
+// internal/infrastructure/adapters/file_repository.go
+package adapters
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/core/domain"
+	"github.com/elchinoo/stormdb/internal/core/ports"
+)
+
+// FileRepository stores executions, metrics and configurations as JSON
+// files in a directory, for machines without a results database:
+//
+//	executions/<id>.json           execution and results
+//	metrics/<id>/aggregated.json   aggregated results by band
+//	metrics/<id>/band-<n>.jsonl    raw metric points of a band
+//	configurations/<name>.json     saved configurations
+//	templates/<workload>.json      configuration templates
+type FileRepository struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// configurationRecord is the stored form of a saved configuration
+type configurationRecord struct {
+	Name        string
+	Description string
+	CreatedAt   time.Time
+	LastUsed    *time.Time
+	Config      domain.TestConfiguration
+}
+
+// NewFileRepository creates a file repository in dir, creating it if needed
+func NewFileRepository(dir string) (*FileRepository, error) {
+	for _, sub := range []string{"executions", "metrics", "configurations", "templates"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create history directory: %w", err)
+		}
+	}
+	return &FileRepository{dir: dir}, nil
+}
+
+// Dir returns the directory of the repository
+func (r *FileRepository) Dir() string {
+	return r.dir
+}
+
+// Close releases nothing; files are written synchronously
+func (r *FileRepository) Close() error {
+	return nil
+}
+
+// Store creates or replaces an execution
+func (r *FileRepository) Store(_ context.Context, execution *domain.TestExecution) error {
+	if err := checkName(execution.ID); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record := toRecord(execution)
+	// Keep stored results when the execution is updated without them
+	if record.Results == nil {
+		if old, err := r.readExecution(execution.ID); err == nil {
+			record.Results = old.Results
+		}
+	}
+	return writeJSONFile(r.executionPath(execution.ID), record)
+}
+
+// GetByID returns an execution
+func (r *FileRepository) GetByID(_ context.Context, id string) (*domain.TestExecution, error) {
+	if err := checkName(id); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, err := r.readExecution(id)
+	if err != nil {
+		return nil, err
+	}
+	return record.execution(), nil
+}
+
+// List returns the executions passing filters, newest first
+func (r *FileRepository) List(_ context.Context, filters ports.TestExecutionFilters) ([]*domain.TestExecution, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries, err := os.ReadDir(filepath.Join(r.dir, "executions"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list executions: %w", err)
+	}
+
+	var records []*executionRecord
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		record, err := r.readExecution(id)
+		if err != nil {
+			return nil, err
+		}
+		if record.matches(filters) {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].StartTime.After(records[j].StartTime) })
+
+	executions := []*domain.TestExecution{}
+	for i, record := range records {
+		if i < filters.Offset {
+			continue
+		}
+		if filters.Limit > 0 && len(executions) == filters.Limit {
+			break
+		}
+		executions = append(executions, record.execution())
+	}
+	return executions, nil
+}
+
+// Delete removes an execution and its metrics
+func (r *FileRepository) Delete(_ context.Context, id string) error {
+	if err := checkName(id); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.Remove(r.executionPath(id)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("execution %s: %w", id, ErrNotFound)
+		}
+		return fmt.Errorf("failed to delete execution: %w", err)
+	}
+	if err := os.RemoveAll(r.metricsDir(id)); err != nil {
+		return fmt.Errorf("failed to delete execution metrics: %w", err)
+	}
+	return nil
+}
+
+// StoreResults attaches results to a stored execution
+func (r *FileRepository) StoreResults(_ context.Context, executionID string, results *domain.TestResults) error {
+	if err := checkName(executionID); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, err := r.readExecution(executionID)
+	if err != nil {
+		return err
+	}
+	record.Results = results
+	return writeJSONFile(r.executionPath(executionID), record)
+}
+
+// GetResults returns the results of an execution
+func (r *FileRepository) GetResults(ctx context.Context, executionID string) (*domain.TestResults, error) {
+	e, err := r.GetByID(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+	if e.Results == nil {
+		return nil, fmt.Errorf("results of execution %s: %w", executionID, ErrNotFound)
+	}
+	return e.Results, nil
+}
+
+// GetPerformanceTrends returns the headline TPS and latency of completed executions over time
+func (r *FileRepository) GetPerformanceTrends(ctx context.Context, workloadType string, timeRange ports.TimeRange) ([]ports.PerformanceTrend, error) {
+	return performanceTrends(ctx, r, workloadType, timeRange)
+}
+
+// CompareExecutions compares the headline results of executions
+func (r *FileRepository) CompareExecutions(ctx context.Context, executionIDs []string) (*ports.ExecutionComparison, error) {
+	return compareExecutions(ctx, r, executionIDs)
+}
+
+// StoreMetricBatch appends raw metric points of a band
+func (r *FileRepository) StoreMetricBatch(_ context.Context, executionID string, bandID int, points []ports.MetricPoint) error {
+	if err := checkName(executionID); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(r.metricsDir(executionID), 0o700); err != nil {
+		return fmt.Errorf("failed to create metrics directory: %w", err)
+	}
+	f, err := os.OpenFile(r.bandPath(executionID, bandID), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open metrics file: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, p := range points {
+		p.BandID = bandID
+		if err := enc.Encode(p); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed to write metric point: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	return f.Close()
+}
+
+// StoreAggregatedMetrics creates or replaces the aggregated results of a band
+func (r *FileRepository) StoreAggregatedMetrics(_ context.Context, executionID string, bandID int, aggregated *domain.BandResults) error {
+	if err := checkName(executionID); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bands, err := r.readAggregated(executionID)
+	if err != nil {
+		return err
+	}
+	band := *aggregated
+	band.BandID = bandID
+	bands[strconv.Itoa(bandID)] = band
+
+	if err := os.MkdirAll(r.metricsDir(executionID), 0o700); err != nil {
+		return fmt.Errorf("failed to create metrics directory: %w", err)
+	}
+	return writeJSONFile(filepath.Join(r.metricsDir(executionID), "aggregated.json"), bands)
+}
+
+// GetAggregatedMetrics returns the aggregated results of every band, by band id
+func (r *FileRepository) GetAggregatedMetrics(_ context.Context, executionID string) ([]domain.BandResults, error) {
+	if err := checkName(executionID); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	bands, err := r.readAggregated(executionID)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]domain.BandResults, 0, len(bands))
+	for _, band := range bands {
+		results = append(results, band)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].BandID < results[j].BandID })
+	return results, nil
+}
+
+// GetTimeSeriesData returns the points of one metric type of a band, oldest first
+func (r *FileRepository) GetTimeSeriesData(_ context.Context, executionID string, bandID int, metricType string) ([]ports.TimeSeriesPoint, error) {
+	if err := checkName(executionID); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	points, err := readMetricPoints(r.bandPath(executionID, bandID))
+	if err != nil {
+		return nil, err
+	}
+	series := []ports.TimeSeriesPoint{}
+	for _, p := range points {
+		if p.MetricType == metricType {
+			series = append(series, ports.TimeSeriesPoint{Timestamp: p.Timestamp, Value: p.Value})
+		}
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Timestamp.Before(series[j].Timestamp) })
+	return series, nil
+}
+
+// CleanupOldMetrics drops raw metric points older than olderThan
+func (r *FileRepository) CleanupOldMetrics(_ context.Context, olderThan time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(r.dir, "metrics", "*", "band-*.jsonl"))
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		points, err := readMetricPoints(path)
+		if err != nil {
+			return err
+		}
+		kept := points[:0]
+		for _, p := range points {
+			if !p.Timestamp.Before(olderThan) {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) == len(points) {
+			continue
+		}
+		if len(kept) == 0 {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove metrics file: %w", err)
+			}
+			continue
+		}
+		if err := writeMetricPoints(path, kept); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StoreConfiguration creates or replaces a saved configuration. The name
+// comes from the "name" workload parameter.
+func (r *FileRepository) StoreConfiguration(_ context.Context, config *domain.TestConfiguration) error {
+	name, description := configurationName(config)
+	if err := checkName(name); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record := configurationRecord{Name: name, Description: description, CreatedAt: time.Now(), Config: *config}
+	var old configurationRecord
+	if err := readJSONFile(r.configurationPath(name), &old); err == nil {
+		record.CreatedAt, record.LastUsed = old.CreatedAt, old.LastUsed
+	}
+	return writeJSONFile(r.configurationPath(name), record)
+}
+
+// GetConfiguration returns a saved configuration and marks it as used
+func (r *FileRepository) GetConfiguration(_ context.Context, name string) (*domain.TestConfiguration, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var record configurationRecord
+	if err := readJSONFile(r.configurationPath(name), &record); err != nil {
+		return nil, fmt.Errorf("configuration %s: %w", name, err)
+	}
+	now := time.Now()
+	record.LastUsed = &now
+	if err := writeJSONFile(r.configurationPath(name), record); err != nil {
+		return nil, err
+	}
+	return &record.Config, nil
+}
+
+// ListConfigurations returns the saved configurations by name
+func (r *FileRepository) ListConfigurations(_ context.Context) ([]*ports.ConfigurationSummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.listConfigurations("configurations")
+}
+
+// DeleteConfiguration removes a saved configuration
+func (r *FileRepository) DeleteConfiguration(_ context.Context, name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.Remove(r.configurationPath(name)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("configuration %s: %w", name, ErrNotFound)
+		}
+		return fmt.Errorf("failed to delete configuration: %w", err)
+	}
+	return nil
+}
+
+// StoreTemplate creates or replaces the configuration template of a workload
+func (r *FileRepository) StoreTemplate(_ context.Context, config *domain.TestConfiguration) error {
+	if err := checkName(config.WorkloadType); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, description := configurationName(config)
+	record := configurationRecord{Name: config.WorkloadType, Description: description, CreatedAt: time.Now(), Config: *config}
+	return writeJSONFile(r.templatePath(config.WorkloadType), record)
+}
+
+// GetTemplate returns the stored template of a workload, or the built-in default
+func (r *FileRepository) GetTemplate(_ context.Context, workloadType string) (*domain.TestConfiguration, error) {
+	if err := checkName(workloadType); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var record configurationRecord
+	if err := readJSONFile(r.templatePath(workloadType), &record); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return defaultTemplate(workloadType), nil
+		}
+		return nil, err
+	}
+	return &record.Config, nil
+}
+
+// ListTemplates returns the stored templates by workload
+func (r *FileRepository) ListTemplates(_ context.Context) ([]*ports.ConfigurationSummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.listConfigurations("templates")
+}
+
+// listConfigurations summarizes the records of a subdirectory; callers hold r.mu
+func (r *FileRepository) listConfigurations(sub string) ([]*ports.ConfigurationSummary, error) {
+	entries, err := os.ReadDir(filepath.Join(r.dir, sub))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", sub, err)
+	}
+	summaries := []*ports.ConfigurationSummary{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		var record configurationRecord
+		if err := readJSONFile(filepath.Join(r.dir, sub, entry.Name()), &record); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, &ports.ConfigurationSummary{
+			Name:         record.Name,
+			WorkloadType: record.Config.WorkloadType,
+			Description:  record.Description,
+			LastUsed:     record.LastUsed,
+			CreatedAt:    record.CreatedAt,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries, nil
+}
+
+func (r *FileRepository) executionPath(id string) string {
+	return filepath.Join(r.dir, "executions", id+".json")
+}
+
+func (r *FileRepository) metricsDir(id string) string {
+	return filepath.Join(r.dir, "metrics", id)
+}
+
+func (r *FileRepository) bandPath(id string, bandID int) string {
+	return filepath.Join(r.metricsDir(id), fmt.Sprintf("band-%d.jsonl", bandID))
+}
+
+func (r *FileRepository) configurationPath(name string) string {
+	return filepath.Join(r.dir, "configurations", name+".json")
+}
+
+func (r *FileRepository) templatePath(workloadType string) string {
+	return filepath.Join(r.dir, "templates", workloadType+".json")
+}
+
+// readExecution reads a stored execution; callers hold r.mu
+func (r *FileRepository) readExecution(id string) (*executionRecord, error) {
+	var record executionRecord
+	if err := readJSONFile(r.executionPath(id), &record); err != nil {
+		return nil, fmt.Errorf("execution %s: %w", id, err)
+	}
+	return &record, nil
+}
+
+// readAggregated reads the aggregated band results of an execution, keyed
+// by band id; callers hold r.mu
+func (r *FileRepository) readAggregated(id string) (map[string]domain.BandResults, error) {
+	bands := make(map[string]domain.BandResults)
+	err := readJSONFile(filepath.Join(r.metricsDir(id), "aggregated.json"), &bands)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return bands, nil
+}
+
+// configurationName returns the name and description of a configuration,
+// taken from its "name" and "description" workload parameters
+func configurationName(config *domain.TestConfiguration) (string, string) {
+	name, _ := config.WorkloadParams["name"].(string)
+	description, _ := config.WorkloadParams["description"].(string)
+	return name, description
+}
+
+// readJSONFile decodes a JSON file, returning ErrNotFound when it does not exist
+func readJSONFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// writeJSONFile replaces a file atomically with the JSON encoding of v
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filepath.Base(path), err)
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Base(path), err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// readMetricPoints reads a JSON lines file of metric points; a missing file has none
+func readMetricPoints(path string) ([]ports.MetricPoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open metrics file: %w", err)
+	}
+	defer f.Close()
+
+	var points []ports.MetricPoint
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for dec.More() {
+		var p ports.MetricPoint
+		if err := dec.Decode(&p); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", filepath.Base(path), err)
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// writeMetricPoints replaces a metrics file with points
+func writeMetricPoints(path string, points []ports.MetricPoint) error {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	for _, p := range points {
+		if err := enc.Encode(p); err != nil {
+			return fmt.Errorf("failed to encode metric point: %w", err)
+		}
+	}
+	return writeFileAtomic(path, []byte(b.String()))
+}
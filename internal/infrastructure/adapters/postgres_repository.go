@@ -0,0 +1,544 @@
+// internal/infrastructure/adapters/postgres_repository.go
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/core/domain"
+	"github.com/elchinoo/stormdb/internal/core/ports"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresRepository stores executions, metrics and configurations in
+// PostgreSQL tables next to the results backend tables. The pool is shared
+// and is not closed by the repository.
+type PostgresRepository struct {
+	pool   *pgxpool.Pool
+	prefix string
+}
+
+// NewPostgresRepository creates a repository on pool, creating its tables
+// with the given prefix if needed
+func NewPostgresRepository(ctx context.Context, pool *pgxpool.Pool, prefix string) (*PostgresRepository, error) {
+	r := &PostgresRepository{pool: pool, prefix: prefix + "core_"}
+	if err := r.createTables(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Close releases nothing; the pool belongs to the results backend
+func (r *PostgresRepository) Close() error {
+	return nil
+}
+
+// table returns the prefixed name of a repository table
+func (r *PostgresRepository) table(name string) string {
+	return r.prefix + name
+}
+
+// createTables creates the repository tables and indexes
+func (r *PostgresRepository) createTables(ctx context.Context) error {
+	statements := []string{
+		fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				id VARCHAR(200) PRIMARY KEY,
+				name VARCHAR(255),
+				workload_type VARCHAR(100) NOT NULL,
+				status VARCHAR(50) NOT NULL,
+				start_time TIMESTAMPTZ NOT NULL,
+				end_time TIMESTAMPTZ,
+				error_message TEXT,
+				configuration JSONB,
+				results JSONB,
+				created_at TIMESTAMPTZ DEFAULT NOW()
+			)`, r.table("executions")),
+		fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				execution_id VARCHAR(200) REFERENCES %s(id) ON DELETE CASCADE,
+				band_id INTEGER NOT NULL,
+				results JSONB NOT NULL,
+				PRIMARY KEY (execution_id, band_id)
+			)`, r.table("band_metrics"), r.table("executions")),
+		fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				id BIGSERIAL PRIMARY KEY,
+				execution_id VARCHAR(200) REFERENCES %s(id) ON DELETE CASCADE,
+				band_id INTEGER NOT NULL,
+				metric_type VARCHAR(100) NOT NULL,
+				value DOUBLE PRECISION,
+				worker_id INTEGER,
+				timestamp TIMESTAMPTZ NOT NULL
+			)`, r.table("metric_points"), r.table("executions")),
+		fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				name VARCHAR(200) PRIMARY KEY,
+				workload_type VARCHAR(100),
+				description TEXT,
+				configuration JSONB NOT NULL,
+				last_used TIMESTAMPTZ,
+				created_at TIMESTAMPTZ DEFAULT NOW()
+			)`, r.table("configurations")),
+		fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				name VARCHAR(200) PRIMARY KEY,
+				workload_type VARCHAR(100),
+				description TEXT,
+				configuration JSONB NOT NULL,
+				last_used TIMESTAMPTZ,
+				created_at TIMESTAMPTZ DEFAULT NOW()
+			)`, r.table("templates")),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%sexecutions_workload ON %s(workload_type, start_time)", r.prefix, r.table("executions")),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%smetric_points_execution ON %s(execution_id, band_id, metric_type)", r.prefix, r.table("metric_points")),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%smetric_points_timestamp ON %s(timestamp)", r.prefix, r.table("metric_points")),
+	}
+	for _, statement := range statements {
+		if _, err := r.pool.Exec(ctx, statement); err != nil {
+			return fmt.Errorf("failed to create repository tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// Store creates or replaces an execution
+func (r *PostgresRepository) Store(ctx context.Context, execution *domain.TestExecution) error {
+	if err := checkName(execution.ID); err != nil {
+		return err
+	}
+	record := toRecord(execution)
+	config, err := json.Marshal(record.Config)
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	var results []byte
+	if record.Results != nil {
+		if results, err = json.Marshal(record.Results); err != nil {
+			return fmt.Errorf("failed to encode results: %w", err)
+		}
+	}
+
+	// Keep stored results when the execution is updated without them
+	query := fmt.Sprintf(`
+		INSERT INTO %s (id, name, workload_type, status, start_time, end_time, error_message, configuration, results)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name, workload_type = EXCLUDED.workload_type, status = EXCLUDED.status,
+			start_time = EXCLUDED.start_time, end_time = EXCLUDED.end_time,
+			error_message = EXCLUDED.error_message, configuration = EXCLUDED.configuration,
+			results = COALESCE(EXCLUDED.results, %s.results)`, r.table("executions"), r.table("executions"))
+	_, err = r.pool.Exec(ctx, query, record.ID, record.Name, record.WorkloadType, string(record.Status),
+		record.StartTime, record.EndTime, record.Error, config, results)
+	if err != nil {
+		return fmt.Errorf("failed to store execution: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns an execution
+func (r *PostgresRepository) GetByID(ctx context.Context, id string) (*domain.TestExecution, error) {
+	if err := checkName(id); err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`
+		SELECT id, name, workload_type, status, start_time, end_time, error_message, configuration, results
+		FROM %s WHERE id = $1`, r.table("executions"))
+	record, err := scanExecution(r.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("execution %s: %w", id, ErrNotFound)
+		}
+		return nil, err
+	}
+	return record.execution(), nil
+}
+
+// List returns the executions passing filters, newest first
+func (r *PostgresRepository) List(ctx context.Context, filters ports.TestExecutionFilters) ([]*domain.TestExecution, error) {
+	query := fmt.Sprintf(`
+		SELECT id, name, workload_type, status, start_time, end_time, error_message, configuration, results
+		FROM %s
+		WHERE 1=1`, r.table("executions"))
+
+	args := []interface{}{}
+	if filters.WorkloadType != nil {
+		args = append(args, *filters.WorkloadType)
+		query += fmt.Sprintf(" AND workload_type = $%d", len(args))
+	}
+	if filters.Status != nil {
+		args = append(args, string(*filters.Status))
+		query += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	if filters.StartTime != nil {
+		args = append(args, *filters.StartTime)
+		query += fmt.Sprintf(" AND start_time >= $%d", len(args))
+	}
+	if filters.EndTime != nil {
+		args = append(args, *filters.EndTime)
+		query += fmt.Sprintf(" AND start_time <= $%d", len(args))
+	}
+	query += " ORDER BY start_time DESC"
+	if filters.Limit > 0 {
+		args = append(args, filters.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filters.Offset > 0 {
+		args = append(args, filters.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query executions: %w", err)
+	}
+	defer rows.Close()
+
+	executions := []*domain.TestExecution{}
+	for rows.Next() {
+		record, err := scanExecution(rows)
+		if err != nil {
+			return nil, err
+		}
+		executions = append(executions, record.execution())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read executions: %w", err)
+	}
+	return executions, nil
+}
+
+// Delete removes an execution; its metrics cascade
+func (r *PostgresRepository) Delete(ctx context.Context, id string) error {
+	if err := checkName(id); err != nil {
+		return err
+	}
+	tag, err := r.pool.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = $1", r.table("executions")), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete execution: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("execution %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// StoreResults attaches results to a stored execution
+func (r *PostgresRepository) StoreResults(ctx context.Context, executionID string, results *domain.TestResults) error {
+	if err := checkName(executionID); err != nil {
+		return err
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("failed to encode results: %w", err)
+	}
+	tag, err := r.pool.Exec(ctx, fmt.Sprintf("UPDATE %s SET results = $2 WHERE id = $1", r.table("executions")), executionID, data)
+	if err != nil {
+		return fmt.Errorf("failed to store results: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("execution %s: %w", executionID, ErrNotFound)
+	}
+	return nil
+}
+
+// GetResults returns the results of an execution
+func (r *PostgresRepository) GetResults(ctx context.Context, executionID string) (*domain.TestResults, error) {
+	e, err := r.GetByID(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+	if e.Results == nil {
+		return nil, fmt.Errorf("results of execution %s: %w", executionID, ErrNotFound)
+	}
+	return e.Results, nil
+}
+
+// GetPerformanceTrends returns the headline TPS and latency of completed executions over time
+func (r *PostgresRepository) GetPerformanceTrends(ctx context.Context, workloadType string, timeRange ports.TimeRange) ([]ports.PerformanceTrend, error) {
+	return performanceTrends(ctx, r, workloadType, timeRange)
+}
+
+// CompareExecutions compares the headline results of executions
+func (r *PostgresRepository) CompareExecutions(ctx context.Context, executionIDs []string) (*ports.ExecutionComparison, error) {
+	return compareExecutions(ctx, r, executionIDs)
+}
+
+// StoreMetricBatch appends raw metric points of a band with a COPY
+func (r *PostgresRepository) StoreMetricBatch(ctx context.Context, executionID string, bandID int, points []ports.MetricPoint) error {
+	if err := checkName(executionID); err != nil {
+		return err
+	}
+	rows := make([][]interface{}, len(points))
+	for i, p := range points {
+		rows[i] = []interface{}{executionID, bandID, p.MetricType, p.Value, p.WorkerID, p.Timestamp}
+	}
+	_, err := r.pool.CopyFrom(ctx, pgx.Identifier{r.table("metric_points")},
+		[]string{"execution_id", "band_id", "metric_type", "value", "worker_id", "timestamp"},
+		pgx.CopyFromRows(rows))
+	if err != nil {
+		return fmt.Errorf("failed to store metric points: %w", err)
+	}
+	return nil
+}
+
+// StoreAggregatedMetrics creates or replaces the aggregated results of a band
+func (r *PostgresRepository) StoreAggregatedMetrics(ctx context.Context, executionID string, bandID int, aggregated *domain.BandResults) error {
+	if err := checkName(executionID); err != nil {
+		return err
+	}
+	band := *aggregated
+	band.BandID = bandID
+	data, err := json.Marshal(band)
+	if err != nil {
+		return fmt.Errorf("failed to encode band results: %w", err)
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (execution_id, band_id, results) VALUES ($1, $2, $3)
+		ON CONFLICT (execution_id, band_id) DO UPDATE SET results = EXCLUDED.results`, r.table("band_metrics"))
+	if _, err := r.pool.Exec(ctx, query, executionID, bandID, data); err != nil {
+		return fmt.Errorf("failed to store aggregated metrics: %w", err)
+	}
+	return nil
+}
+
+// GetAggregatedMetrics returns the aggregated results of every band, by band id
+func (r *PostgresRepository) GetAggregatedMetrics(ctx context.Context, executionID string) ([]domain.BandResults, error) {
+	if err := checkName(executionID); err != nil {
+		return nil, err
+	}
+	rows, err := r.pool.Query(ctx, fmt.Sprintf("SELECT results FROM %s WHERE execution_id = $1 ORDER BY band_id", r.table("band_metrics")), executionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aggregated metrics: %w", err)
+	}
+	defer rows.Close()
+
+	results := []domain.BandResults{}
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan aggregated metrics: %w", err)
+		}
+		var band domain.BandResults
+		if err := json.Unmarshal(data, &band); err != nil {
+			return nil, fmt.Errorf("failed to decode band results: %w", err)
+		}
+		results = append(results, band)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read aggregated metrics: %w", err)
+	}
+	return results, nil
+}
+
+// GetTimeSeriesData returns the points of one metric type of a band, oldest first
+func (r *PostgresRepository) GetTimeSeriesData(ctx context.Context, executionID string, bandID int, metricType string) ([]ports.TimeSeriesPoint, error) {
+	if err := checkName(executionID); err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`
+		SELECT timestamp, value FROM %s
+		WHERE execution_id = $1 AND band_id = $2 AND metric_type = $3
+		ORDER BY timestamp`, r.table("metric_points"))
+	rows, err := r.pool.Query(ctx, query, executionID, bandID, metricType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query time-series data: %w", err)
+	}
+	defer rows.Close()
+
+	series := []ports.TimeSeriesPoint{}
+	for rows.Next() {
+		var p ports.TimeSeriesPoint
+		if err := rows.Scan(&p.Timestamp, &p.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan time-series point: %w", err)
+		}
+		series = append(series, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read time-series data: %w", err)
+	}
+	return series, nil
+}
+
+// CleanupOldMetrics drops raw metric points older than olderThan
+func (r *PostgresRepository) CleanupOldMetrics(ctx context.Context, olderThan time.Time) error {
+	if _, err := r.pool.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE timestamp < $1", r.table("metric_points")), olderThan); err != nil {
+		return fmt.Errorf("failed to clean up metric points: %w", err)
+	}
+	return nil
+}
+
+// StoreConfiguration creates or replaces a saved configuration. The name
+// comes from the "name" workload parameter.
+func (r *PostgresRepository) StoreConfiguration(ctx context.Context, config *domain.TestConfiguration) error {
+	name, description := configurationName(config)
+	return r.storeConfiguration(ctx, "configurations", name, description, config)
+}
+
+// GetConfiguration returns a saved configuration and marks it as used
+func (r *PostgresRepository) GetConfiguration(ctx context.Context, name string) (*domain.TestConfiguration, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("UPDATE %s SET last_used = NOW() WHERE name = $1 RETURNING configuration", r.table("configurations"))
+	config, err := scanConfiguration(r.pool.QueryRow(ctx, query, name))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("configuration %s: %w", name, ErrNotFound)
+		}
+		return nil, err
+	}
+	return config, nil
+}
+
+// ListConfigurations returns the saved configurations by name
+func (r *PostgresRepository) ListConfigurations(ctx context.Context) ([]*ports.ConfigurationSummary, error) {
+	return r.listConfigurations(ctx, "configurations")
+}
+
+// DeleteConfiguration removes a saved configuration
+func (r *PostgresRepository) DeleteConfiguration(ctx context.Context, name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	tag, err := r.pool.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE name = $1", r.table("configurations")), name)
+	if err != nil {
+		return fmt.Errorf("failed to delete configuration: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("configuration %s: %w", name, ErrNotFound)
+	}
+	return nil
+}
+
+// StoreTemplate creates or replaces the configuration template of a workload
+func (r *PostgresRepository) StoreTemplate(ctx context.Context, config *domain.TestConfiguration) error {
+	_, description := configurationName(config)
+	return r.storeConfiguration(ctx, "templates", config.WorkloadType, description, config)
+}
+
+// GetTemplate returns the stored template of a workload, or the built-in default
+func (r *PostgresRepository) GetTemplate(ctx context.Context, workloadType string) (*domain.TestConfiguration, error) {
+	if err := checkName(workloadType); err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("SELECT configuration FROM %s WHERE name = $1", r.table("templates"))
+	config, err := scanConfiguration(r.pool.QueryRow(ctx, query, workloadType))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return defaultTemplate(workloadType), nil
+		}
+		return nil, err
+	}
+	return config, nil
+}
+
+// ListTemplates returns the stored templates by workload
+func (r *PostgresRepository) ListTemplates(ctx context.Context) ([]*ports.ConfigurationSummary, error) {
+	return r.listConfigurations(ctx, "templates")
+}
+
+// storeConfiguration upserts a configuration or template, keeping its
+// creation and last use times
+func (r *PostgresRepository) storeConfiguration(ctx context.Context, table, name, description string, config *domain.TestConfiguration) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (name, workload_type, description, configuration) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE SET
+			workload_type = EXCLUDED.workload_type, description = EXCLUDED.description,
+			configuration = EXCLUDED.configuration`, r.table(table))
+	if _, err := r.pool.Exec(ctx, query, name, config.WorkloadType, description, data); err != nil {
+		return fmt.Errorf("failed to store %s: %w", strings.TrimSuffix(table, "s"), err)
+	}
+	return nil
+}
+
+// listConfigurations summarizes the rows of the configurations or templates table
+func (r *PostgresRepository) listConfigurations(ctx context.Context, table string) ([]*ports.ConfigurationSummary, error) {
+	query := fmt.Sprintf("SELECT name, workload_type, description, last_used, created_at FROM %s ORDER BY name", r.table(table))
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	summaries := []*ports.ConfigurationSummary{}
+	for rows.Next() {
+		var s ports.ConfigurationSummary
+		var workloadType, description *string
+		if err := rows.Scan(&s.Name, &workloadType, &description, &s.LastUsed, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		if workloadType != nil {
+			s.WorkloadType = *workloadType
+		}
+		if description != nil {
+			s.Description = *description
+		}
+		summaries = append(summaries, &s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	return summaries, nil
+}
+
+// scanExecution reads an execution row selected by GetByID or List
+func scanExecution(row pgx.Row) (*executionRecord, error) {
+	var record executionRecord
+	var name, errorMessage *string
+	var status string
+	var config, results []byte
+	if err := row.Scan(&record.ID, &name, &record.WorkloadType, &status, &record.StartTime,
+		&record.EndTime, &errorMessage, &config, &results); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan execution: %w", err)
+	}
+	record.Status = domain.ExecutionStatus(status)
+	if name != nil {
+		record.Name = *name
+	}
+	if errorMessage != nil {
+		record.Error = *errorMessage
+	}
+	if len(config) > 0 {
+		if err := json.Unmarshal(config, &record.Config); err != nil {
+			return nil, fmt.Errorf("failed to decode execution configuration: %w", err)
+		}
+	}
+	if len(results) > 0 {
+		record.Results = &domain.TestResults{}
+		if err := json.Unmarshal(results, record.Results); err != nil {
+			return nil, fmt.Errorf("failed to decode execution results: %w", err)
+		}
+	}
+	return &record, nil
+}
+
+// scanConfiguration reads a configuration column
+func scanConfiguration(row pgx.Row) (*domain.TestConfiguration, error) {
+	var data []byte
+	if err := row.Scan(&data); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan configuration: %w", err)
+	}
+	var config domain.TestConfiguration
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode configuration: %w", err)
+	}
+	return &config, nil
+}
@@ -0,0 +1,446 @@
+// internal/infrastructure/adapters/repository.go
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/core/domain"
+	"github.com/elchinoo/stormdb/internal/core/ports"
+	"github.com/elchinoo/stormdb/internal/metrics"
+	"github.com/elchinoo/stormdb/internal/results"
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/google/uuid"
+)
+
+// Repository is a store implementing all core ports repositories
+type Repository interface {
+	ports.TestExecutionRepository
+	ports.MetricsRepository
+	ports.ConfigurationRepository
+	Close() error
+}
+
+// Errors returned by the repositories
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrInvalidName = errors.New("invalid name: use letters, digits, '.', '_' and '-'")
+)
+
+// validName matches execution ids and configuration names, which the file
+// store uses as file names
+var validName = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]{0,199}$`)
+
+// checkName validates an execution id or configuration name
+func checkName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return nil
+}
+
+// executionRecord is the stored form of a domain.TestExecution. The error
+// is kept as text and the database password is never stored.
+type executionRecord struct {
+	ID           string
+	Name         string
+	WorkloadType string
+	Status       domain.ExecutionStatus
+	StartTime    time.Time
+	EndTime      *time.Time
+	Error        string
+	Config       domain.TestConfiguration
+	Results      *domain.TestResults
+}
+
+// toRecord converts an execution for storage
+func toRecord(e *domain.TestExecution) *executionRecord {
+	r := &executionRecord{
+		ID:           e.ID,
+		Name:         e.Name,
+		WorkloadType: e.WorkloadType,
+		Status:       e.Status,
+		StartTime:    e.StartTime,
+		EndTime:      e.EndTime,
+		Config:       e.Config,
+		Results:      e.Results,
+	}
+	r.Config.DatabaseConfig.Password = ""
+	if e.Error != nil {
+		r.Error = e.Error.Error()
+	}
+	return r
+}
+
+// execution converts a stored record back into a domain execution
+func (r *executionRecord) execution() *domain.TestExecution {
+	e := &domain.TestExecution{
+		ID:           r.ID,
+		Name:         r.Name,
+		WorkloadType: r.WorkloadType,
+		Status:       r.Status,
+		StartTime:    r.StartTime,
+		EndTime:      r.EndTime,
+		Config:       r.Config,
+		Results:      r.Results,
+	}
+	if r.Error != "" {
+		e.Error = errors.New(r.Error)
+	}
+	return e
+}
+
+// matches reports whether r passes the filters, ignoring limit and offset
+func (r *executionRecord) matches(f ports.TestExecutionFilters) bool {
+	switch {
+	case f.WorkloadType != nil && r.WorkloadType != *f.WorkloadType:
+		return false
+	case f.Status != nil && r.Status != *f.Status:
+		return false
+	case f.StartTime != nil && r.StartTime.Before(*f.StartTime):
+		return false
+	case f.EndTime != nil && r.StartTime.After(*f.EndTime):
+		return false
+	}
+	return true
+}
+
+// HeadlineBand returns the band that represents an execution: the single
+// band of a standard run, or the optimal (else fastest) progressive band
+func HeadlineBand(results *domain.TestResults) *domain.BandResults {
+	if results == nil {
+		return nil
+	}
+	if results.SingleBandResults != nil {
+		return results.SingleBandResults
+	}
+	p := results.ProgressiveResults
+	if p == nil || len(p.Bands) == 0 {
+		return nil
+	}
+	if p.OptimalBand != nil {
+		return p.OptimalBand
+	}
+	best := &p.Bands[0]
+	for i := range p.Bands {
+		if p.Bands[i].Performance.TotalTPS > best.Performance.TotalTPS {
+			best = &p.Bands[i]
+		}
+	}
+	return best
+}
+
+// performanceTrends returns the headline TPS and P95 latency of completed
+// executions of a workload over time, oldest first
+func performanceTrends(ctx context.Context, repo ports.TestExecutionRepository, workloadType string, tr ports.TimeRange) ([]ports.PerformanceTrend, error) {
+	status := domain.StatusCompleted
+	filters := ports.TestExecutionFilters{WorkloadType: &workloadType, Status: &status}
+	if !tr.Start.IsZero() {
+		filters.StartTime = &tr.Start
+	}
+	if !tr.End.IsZero() {
+		filters.EndTime = &tr.End
+	}
+	executions, err := repo.List(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	trends := []ports.PerformanceTrend{}
+	for _, e := range executions {
+		band := HeadlineBand(e.Results)
+		if band == nil {
+			continue
+		}
+		trends = append(trends, ports.PerformanceTrend{
+			Timestamp: e.StartTime,
+			TPS:       band.Performance.TotalTPS,
+			Latency:   band.Performance.P95Latency,
+			Workers:   band.Workers,
+		})
+	}
+	sort.Slice(trends, func(i, j int) bool { return trends[i].Timestamp.Before(trends[j].Timestamp) })
+	return trends, nil
+}
+
+// compareExecutions summarizes executions side by side and picks the best
+// performer (highest TPS), the most efficient (highest TPS per connection)
+// and the most stable (lowest TPS coefficient of variation)
+func compareExecutions(ctx context.Context, repo ports.TestExecutionRepository, ids []string) (*ports.ExecutionComparison, error) {
+	if len(ids) < 2 {
+		return nil, fmt.Errorf("at least two executions are required for a comparison")
+	}
+
+	comparison := &ports.ExecutionComparison{}
+	var bestTPS, bestEfficiency, bestCoV, bestLatency float64
+	bestCoV, bestLatency = math.Inf(1), math.Inf(1)
+	var lowestLatency string
+	for _, id := range ids {
+		e, err := repo.GetByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("execution %s: %w", id, err)
+		}
+		band := HeadlineBand(e.Results)
+		if band == nil {
+			return nil, fmt.Errorf("execution %s has no results", id)
+		}
+
+		p := band.Performance
+		comparison.Executions = append(comparison.Executions, ports.ExecutionSummary{
+			ID:           e.ID,
+			Name:         e.Name,
+			WorkloadType: e.WorkloadType,
+			TPS:          p.TotalTPS,
+			Latency:      p.P95Latency,
+			Workers:      band.Workers,
+			Connections:  band.Connections,
+			Timestamp:    e.StartTime,
+		})
+
+		a := &comparison.Analysis
+		if p.TotalTPS > bestTPS || a.BestPerformer == "" {
+			bestTPS, a.BestPerformer = p.TotalTPS, e.ID
+		}
+		if band.Connections > 0 {
+			if eff := p.TotalTPS / float64(band.Connections); eff > bestEfficiency || a.MostEfficient == "" {
+				bestEfficiency, a.MostEfficient = eff, e.ID
+			}
+		}
+		if cov := band.Stability.CoefficientOfVariation; cov > 0 && cov < bestCoV {
+			bestCoV, a.MostStable = cov, e.ID
+		}
+		if p.P95Latency > 0 && p.P95Latency < bestLatency {
+			bestLatency, lowestLatency = p.P95Latency, e.ID
+		}
+	}
+
+	comparison.Analysis.Recommendations = compareRecommendations(comparison, lowestLatency)
+	return comparison, nil
+}
+
+// compareRecommendations describes the differences found by compareExecutions
+func compareRecommendations(c *ports.ExecutionComparison, lowestLatency string) []string {
+	byID := make(map[string]ports.ExecutionSummary, len(c.Executions))
+	worstTPS := c.Executions[0]
+	for _, e := range c.Executions {
+		byID[e.ID] = e
+		if e.TPS < worstTPS.TPS {
+			worstTPS = e
+		}
+	}
+
+	var recs []string
+	best := byID[c.Analysis.BestPerformer]
+	if worstTPS.TPS > 0 && best.ID != worstTPS.ID {
+		recs = append(recs, fmt.Sprintf("%s delivers %.1f%% more TPS than %s (%.2f vs %.2f)",
+			label(best), (best.TPS/worstTPS.TPS-1)*100, label(worstTPS), best.TPS, worstTPS.TPS))
+	}
+	if eff, ok := byID[c.Analysis.MostEfficient]; ok && eff.ID != best.ID {
+		recs = append(recs, fmt.Sprintf("%s gets the most TPS per connection; prefer it when connections are scarce", label(eff)))
+	}
+	if fast, ok := byID[lowestLatency]; ok && fast.ID != best.ID {
+		recs = append(recs, fmt.Sprintf("%s has the lowest P95 latency (%.2fms); prefer it for latency-sensitive workloads", label(fast), fast.Latency))
+	}
+	if stable, ok := byID[c.Analysis.MostStable]; ok && stable.ID != best.ID {
+		recs = append(recs, fmt.Sprintf("%s has the steadiest throughput", label(stable)))
+	}
+	if len(recs) == 0 {
+		recs = append(recs, fmt.Sprintf("%s is best on every measure", label(best)))
+	}
+	return recs
+}
+
+// label names an execution in recommendations
+func label(e ports.ExecutionSummary) string {
+	if e.Name != "" {
+		return fmt.Sprintf("%q (%s)", e.Name, e.ID)
+	}
+	return e.ID
+}
+
+// defaultTemplate returns the built-in configuration template for a
+// workload: a short linear progressive scan from 1 to 32 workers
+func defaultTemplate(workloadType string) *domain.TestConfiguration {
+	steps := []int{1, 2, 4, 8, 16, 32}
+	return &domain.TestConfiguration{
+		WorkloadType: workloadType,
+		Duration:     5 * time.Minute,
+		ProgressiveConfig: &domain.ProgressiveScalingConfig{
+			MinConnections:  steps[0],
+			MaxConnections:  steps[len(steps)-1],
+			ConnectionSteps: steps,
+			MinWorkers:      steps[0],
+			MaxWorkers:      steps[len(steps)-1],
+			WorkerSteps:     steps,
+			BandDuration:    time.Minute,
+			WarmupTime:      10 * time.Second,
+			CooldownTime:    5 * time.Second,
+			Strategy:        domain.StrategyExponential,
+		},
+		DatabaseConfig: domain.DatabaseConfiguration{Host: "localhost", Port: 5432, SSLMode: "disable"},
+		WorkloadParams: map[string]interface{}{},
+	}
+}
+
+// ExecutionFromRun converts a finished standard run into an execution with
+// single band results, plus its time-series buckets as metric points
+func ExecutionFromRun(cfg *types.Config, s *metrics.RunSummary) (*domain.TestExecution, []ports.MetricPoint) {
+	status := domain.StatusCompleted
+	switch s.Status {
+	case metrics.StatusFailed:
+		status = domain.StatusFailed
+	case metrics.StatusInterrupted:
+		status = domain.StatusCancelled
+	}
+
+	band := &domain.BandResults{
+		Workers:     s.Workers,
+		Connections: s.Connections,
+		Duration:    time.Duration(s.DurationSeconds * float64(time.Second)),
+		Performance: domain.PerformanceMetrics{
+			TotalTPS:     s.Transactions.TPS,
+			TotalQPS:     s.Queries.QPS,
+			AvgLatency:   s.Latency.AvgMs,
+			P50Latency:   s.Latency.P50Ms,
+			P95Latency:   s.Latency.P95Ms,
+			P99Latency:   s.Latency.P99Ms,
+			ErrorCount:   s.Errors.Total,
+			RowsRead:     s.Rows.Read,
+			RowsModified: s.Rows.Modified,
+		},
+	}
+	p := &band.Performance
+	if ops := s.Transactions.Total + s.Errors.Total; ops > 0 {
+		p.ErrorRate = float64(s.Errors.Total) / float64(ops) * 100
+	}
+	for _, q := range s.Queries.Mix {
+		switch q.Type {
+		case "select":
+			p.SelectQueries = q.Count
+		case "insert":
+			p.InsertQueries = q.Count
+		case "update":
+			p.UpdateQueries = q.Count
+		case "delete":
+			p.DeleteQueries = q.Count
+		}
+	}
+	if s.Workers > 0 {
+		band.Efficiency.TPSPerWorker = p.TotalTPS / float64(s.Workers)
+	}
+	if s.Connections > 0 {
+		band.Efficiency.TPSPerConnection = p.TotalTPS / float64(s.Connections)
+	}
+	band.Stability.LatencyStdDev = s.Latency.StdDevMs
+
+	// Throughput stability and drift from the time-series buckets
+	var points []ports.MetricPoint
+	var tps []float64
+	for _, b := range s.TimeSeries {
+		tps = append(tps, b.TPS)
+		points = append(points,
+			ports.MetricPoint{Timestamp: b.StartTime, MetricType: "tps", Value: b.TPS},
+			ports.MetricPoint{Timestamp: b.StartTime, MetricType: "qps", Value: b.QPS},
+			ports.MetricPoint{Timestamp: b.StartTime, MetricType: "latency_p95", Value: b.P95Ms},
+			ports.MetricPoint{Timestamp: b.StartTime, MetricType: "errors", Value: float64(b.Errors)},
+		)
+	}
+	if len(tps) > 1 {
+		mean, stddev := meanStdDev(tps)
+		band.Stability.TPSStdDev = stddev
+		if mean > 0 {
+			band.Stability.CoefficientOfVariation = stddev / mean
+			band.Stability.PerformanceDrift = (tps[len(tps)-1] - tps[0]) / mean * 100
+		}
+	}
+
+	end := s.EndTime
+	e := &domain.TestExecution{
+		ID:           uuid.New().String(),
+		Name:         runName(cfg),
+		WorkloadType: s.Workload,
+		Status:       status,
+		StartTime:    s.StartTime,
+		EndTime:      &end,
+		Config: domain.TestConfiguration{
+			WorkloadType: cfg.Workload,
+			Duration:     band.Duration,
+			DatabaseConfig: domain.DatabaseConfiguration{
+				Host:        cfg.Database.Host,
+				Port:        cfg.Database.Port,
+				Database:    cfg.Database.Dbname,
+				Username:    cfg.Database.Username,
+				SSLMode:     cfg.Database.Sslmode,
+				MaxPoolSize: cfg.Connections,
+			},
+			WorkloadParams: map[string]interface{}{
+				"mode":             cfg.Mode,
+				"scale":            cfg.Scale,
+				"workers":          cfg.Workers,
+				"connections":      cfg.Connections,
+				"duration":         cfg.Duration,
+				"key_distribution": s.KeyDistribution,
+				"query_exec_mode":  cfg.QueryExecMode,
+			},
+		},
+		Results: &domain.TestResults{SingleBandResults: band},
+	}
+	if s.Error != "" {
+		e.Error = errors.New(s.Error)
+	}
+	return e, points
+}
+
+// runName returns the test_metadata test_name of a run, if any
+func runName(cfg *types.Config) string {
+	if name, ok := cfg.TestMetadata["test_name"].(string); ok {
+		return name
+	}
+	return ""
+}
+
+// meanStdDev returns the mean and population standard deviation of values
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)))
+}
+
+// DefaultHistoryDir returns the file store directory used when the history
+// configuration sets none: ~/.stormdb/history, or .stormdb/history in the
+// working directory when there is no home directory
+func DefaultHistoryDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return filepath.Join(".stormdb", "history")
+	}
+	return filepath.Join(home, ".stormdb", "history")
+}
+
+// OpenRepository opens the run history store: tables in the results
+// database when backend is not nil, else the file store in the configured
+// history directory
+func OpenRepository(ctx context.Context, cfg *types.Config, backend *results.Backend) (Repository, error) {
+	if backend != nil {
+		return NewPostgresRepository(ctx, backend.Pool(), backend.TablePrefix())
+	}
+	dir := cfg.History.Dir
+	if dir == "" {
+		dir = DefaultHistoryDir()
+	}
+	return NewFileRepository(dir)
+}
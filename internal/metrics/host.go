@@ -0,0 +1,230 @@
+package metrics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/hoststats"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// clientBoundPct is the share of saturated samples from which a load
+// generator host is considered the bottleneck of the run
+const clientBoundPct = 50.0
+
+// HostStatsSummary summarizes the host resource samples of the run and
+// whether a load generator host limited the throughput
+type HostStatsSummary struct {
+	CPUThresholdPct  float64        `json:"cpu_threshold_pct"`
+	Clients          []HostSummary  `json:"clients,omitempty"`
+	Server           *ServerSummary `json:"server,omitempty"`
+	ClientBound      bool           `json:"client_bound"`
+	ClientBoundHosts []string       `json:"client_bound_hosts,omitempty"`
+}
+
+// HostSummary aggregates the samples of one load generator host
+type HostSummary struct {
+	Host                     string  `json:"host"`
+	Samples                  int     `json:"samples"`
+	CPUs                     int     `json:"cpus"`
+	AvgCPUBusyPct            float64 `json:"avg_cpu_busy_pct"`
+	MaxCPUBusyPct            float64 `json:"max_cpu_busy_pct"`
+	AvgCPUUserPct            float64 `json:"avg_cpu_user_pct"`
+	AvgCPUSystemPct          float64 `json:"avg_cpu_system_pct"`
+	AvgCPUIOWaitPct          float64 `json:"avg_cpu_iowait_pct"`
+	AvgCPUStealPct           float64 `json:"avg_cpu_steal_pct"`
+	AvgRunQueue              float64 `json:"avg_run_queue"`
+	MaxRunQueue              int64   `json:"max_run_queue"`
+	AvgMemUsedPct            float64 `json:"avg_mem_used_pct"`
+	MaxMemUsedPct            float64 `json:"max_mem_used_pct"`
+	AvgNetRxBytesPerSec      float64 `json:"avg_net_rx_bytes_per_sec"`
+	AvgNetTxBytesPerSec      float64 `json:"avg_net_tx_bytes_per_sec"`
+	AvgContextSwitchesPerSec float64 `json:"avg_context_switches_per_sec"`
+	SaturatedPct             float64 `json:"saturated_pct"` // Samples at the CPU threshold or with more runnable tasks than CPUs
+	ClientBound              bool    `json:"client_bound"`
+}
+
+// ServerSummary aggregates the database server samples
+type ServerSummary struct {
+	Samples           int     `json:"samples"`
+	AvgIOReadsPerSec  float64 `json:"avg_io_reads_per_sec"`
+	MaxIOReadsPerSec  float64 `json:"max_io_reads_per_sec"`
+	AvgIOWritesPerSec float64 `json:"avg_io_writes_per_sec"`
+	MaxIOWritesPerSec float64 `json:"max_io_writes_per_sec"`
+	AvgIOFsyncsPerSec float64 `json:"avg_io_fsyncs_per_sec"`
+	MaxWALDirBytes    int64   `json:"max_wal_dir_bytes"`
+	LastWALDirBytes   int64   `json:"last_wal_dir_bytes"`
+	MaxWALFiles       int64   `json:"max_wal_files"`
+}
+
+// BucketHostStats are the host samples taken during one time-series bucket.
+// Client values are those of the busiest host, except network rates, which
+// are summed over hosts.
+type BucketHostStats struct {
+	ClientCPUBusyPct       float64 `json:"client_cpu_busy_pct"`
+	ClientCPUStealPct      float64 `json:"client_cpu_steal_pct"`
+	ClientRunQueue         float64 `json:"client_run_queue"`
+	ClientNetRxBytesPerSec float64 `json:"client_net_rx_bytes_per_sec"`
+	ClientNetTxBytesPerSec float64 `json:"client_net_tx_bytes_per_sec"`
+	ClientSaturated        bool    `json:"client_saturated"`
+	ServerIOReadsPerSec    float64 `json:"server_io_reads_per_sec"`
+	ServerIOWritesPerSec   float64 `json:"server_io_writes_per_sec"`
+	ServerWALDirBytes      int64   `json:"server_wal_dir_bytes"`
+}
+
+// summarizeHostStats aggregates the host and server samples, if any, and
+// aligns them into the time-series buckets
+func summarizeHostStats(cfg *types.Config, m *types.Metrics, buckets []TimeBucketStats) *HostStatsSummary {
+	clients := m.GetHostSamples()
+	servers := m.GetServerSamples()
+	if len(clients) == 0 && len(servers) == 0 {
+		return nil
+	}
+
+	threshold := hoststats.DefaultCPUThreshold
+	if opts, err := hoststats.OptionsFrom(cfg); err == nil {
+		threshold = opts.CPUThreshold
+	}
+
+	s := &HostStatsSummary{CPUThresholdPct: threshold}
+	for _, host := range groupByHost(clients) {
+		hs := summarizeHost(host, threshold)
+		if hs.ClientBound {
+			s.ClientBound = true
+			s.ClientBoundHosts = append(s.ClientBoundHosts, hs.Host)
+		}
+		s.Clients = append(s.Clients, hs)
+	}
+	if len(servers) > 0 {
+		s.Server = summarizeServer(servers)
+	}
+
+	for i := range buckets {
+		end := buckets[i].StartTime.Add(time.Duration(buckets[i].Seconds * float64(time.Second)))
+		buckets[i].Host = bucketHostStats(clients, servers, buckets[i].StartTime, end, threshold)
+	}
+	return s
+}
+
+// groupByHost splits client samples by host, in host name order
+func groupByHost(samples []types.HostSample) [][]types.HostSample {
+	byHost := make(map[string][]types.HostSample)
+	var names []string
+	for _, sample := range samples {
+		if _, ok := byHost[sample.Host]; !ok {
+			names = append(names, sample.Host)
+		}
+		byHost[sample.Host] = append(byHost[sample.Host], sample)
+	}
+	sort.Strings(names)
+
+	groups := make([][]types.HostSample, 0, len(names))
+	for _, name := range names {
+		groups = append(groups, byHost[name])
+	}
+	return groups
+}
+
+// summarizeHost aggregates the samples of one host
+func summarizeHost(samples []types.HostSample, threshold float64) HostSummary {
+	hs := HostSummary{Host: samples[0].Host, Samples: len(samples)}
+	saturated := 0
+	for _, s := range samples {
+		hs.CPUs = s.CPUs
+		hs.AvgCPUBusyPct += s.CPUBusyPct
+		hs.AvgCPUUserPct += s.CPUUserPct
+		hs.AvgCPUSystemPct += s.CPUSystemPct
+		hs.AvgCPUIOWaitPct += s.CPUIOWaitPct
+		hs.AvgCPUStealPct += s.CPUStealPct
+		hs.AvgRunQueue += float64(s.RunQueue)
+		hs.AvgMemUsedPct += s.MemUsedPct
+		hs.AvgNetRxBytesPerSec += s.NetRxBytesPerSec
+		hs.AvgNetTxBytesPerSec += s.NetTxBytesPerSec
+		hs.AvgContextSwitchesPerSec += s.ContextSwitchesPerSec
+		hs.MaxCPUBusyPct = max(hs.MaxCPUBusyPct, s.CPUBusyPct)
+		hs.MaxRunQueue = max(hs.MaxRunQueue, s.RunQueue)
+		hs.MaxMemUsedPct = max(hs.MaxMemUsedPct, s.MemUsedPct)
+		if hoststats.Saturated(s, threshold) {
+			saturated++
+		}
+	}
+
+	n := float64(len(samples))
+	hs.AvgCPUBusyPct /= n
+	hs.AvgCPUUserPct /= n
+	hs.AvgCPUSystemPct /= n
+	hs.AvgCPUIOWaitPct /= n
+	hs.AvgCPUStealPct /= n
+	hs.AvgRunQueue /= n
+	hs.AvgMemUsedPct /= n
+	hs.AvgNetRxBytesPerSec /= n
+	hs.AvgNetTxBytesPerSec /= n
+	hs.AvgContextSwitchesPerSec /= n
+	hs.SaturatedPct = float64(saturated) / n * 100
+	hs.ClientBound = hs.SaturatedPct >= clientBoundPct
+	return hs
+}
+
+// summarizeServer aggregates the database server samples
+func summarizeServer(samples []types.ServerSample) *ServerSummary {
+	ss := &ServerSummary{Samples: len(samples)}
+	for _, s := range samples {
+		ss.AvgIOReadsPerSec += s.IOReadsPerSec
+		ss.AvgIOWritesPerSec += s.IOWritesPerSec
+		ss.AvgIOFsyncsPerSec += s.IOFsyncsPerSec
+		ss.MaxIOReadsPerSec = max(ss.MaxIOReadsPerSec, s.IOReadsPerSec)
+		ss.MaxIOWritesPerSec = max(ss.MaxIOWritesPerSec, s.IOWritesPerSec)
+		ss.MaxWALDirBytes = max(ss.MaxWALDirBytes, s.WALDirBytes)
+		ss.MaxWALFiles = max(ss.MaxWALFiles, s.WALFiles)
+	}
+	n := float64(len(samples))
+	ss.AvgIOReadsPerSec /= n
+	ss.AvgIOWritesPerSec /= n
+	ss.AvgIOFsyncsPerSec /= n
+	ss.LastWALDirBytes = samples[len(samples)-1].WALDirBytes
+	return ss
+}
+
+// bucketHostStats aggregates the samples taken in [start, end), or returns
+// nil when there are none
+func bucketHostStats(clients []types.HostSample, servers []types.ServerSample, start, end time.Time, threshold float64) *BucketHostStats {
+	in := func(t time.Time) bool { return !t.Before(start) && t.Before(end) }
+
+	var bucketClients []types.HostSample
+	for _, s := range clients {
+		if in(s.Time) {
+			bucketClients = append(bucketClients, s)
+		}
+	}
+	var bucketServers []types.ServerSample
+	for _, s := range servers {
+		if in(s.Time) {
+			bucketServers = append(bucketServers, s)
+		}
+	}
+	if len(bucketClients) == 0 && len(bucketServers) == 0 {
+		return nil
+	}
+
+	b := &BucketHostStats{}
+	busiest := -1.0
+	for _, host := range groupByHost(bucketClients) {
+		hs := summarizeHost(host, threshold)
+		b.ClientNetRxBytesPerSec += hs.AvgNetRxBytesPerSec
+		b.ClientNetTxBytesPerSec += hs.AvgNetTxBytesPerSec
+		if hs.AvgCPUBusyPct > busiest {
+			busiest = hs.AvgCPUBusyPct
+			b.ClientCPUBusyPct = hs.AvgCPUBusyPct
+			b.ClientCPUStealPct = hs.AvgCPUStealPct
+			b.ClientRunQueue = hs.AvgRunQueue
+		}
+		b.ClientSaturated = b.ClientSaturated || hs.ClientBound
+	}
+	if len(bucketServers) > 0 {
+		ss := summarizeServer(bucketServers)
+		b.ServerIOReadsPerSec = ss.AvgIOReadsPerSec
+		b.ServerIOWritesPerSec = ss.AvgIOWritesPerSec
+		b.ServerWALDirBytes = ss.LastWALDirBytes
+	}
+	return b
+}
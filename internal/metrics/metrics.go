@@ -33,12 +33,15 @@ package metrics
 
 import (
 	"fmt"
+	"io"
 	"math"
+	"os"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/elchinoo/stormdb/internal/util"
+	"github.com/elchinoo/stormdb/pkg/keydist"
 	"github.com/elchinoo/stormdb/pkg/types"
 )
 
@@ -133,6 +136,12 @@ func ReportSummary(_ *types.Config, m *types.Metrics, elapsed time.Duration) {
 // This function is called by Report() and provides the foundation for all
 // performance analysis and reporting in stormdb.
 func ReportWithContext(cfg *types.Config, m *types.Metrics, interrupted bool, endTime time.Time) {
+	ReportTo(os.Stdout, cfg, m, interrupted, endTime)
+}
+
+// ReportTo writes the report of ReportWithContext to w, so it can be saved
+// to a file instead of the terminal.
+func ReportTo(w io.Writer, cfg *types.Config, m *types.Metrics, interrupted bool, endTime time.Time) {
 	durationSec := parseDuration(cfg.Duration)
 
 	// Extract latencies safely
@@ -147,96 +156,109 @@ func ReportWithContext(cfg *types.Config, m *types.Metrics, interrupted bool, en
 	}
 
 	// Header
-	fmt.Println("===============================================================================")
-	fmt.Println("                         StormDB Benchmark Report")
-	fmt.Println("===============================================================================")
-	fmt.Printf("Date/Time:       %s\n", endTime.Format("2006-01-02 15:04:05"))
-	fmt.Printf("Duration:        %ss       Workers: %d\n", cfg.Duration, cfg.Workers)
+	fmt.Fprintln(w, "===============================================================================")
+	fmt.Fprintln(w, "                         StormDB Benchmark Report")
+	fmt.Fprintln(w, "===============================================================================")
+	fmt.Fprintf(w, "Date/Time:       %s\n", endTime.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "Duration:        %ss       Workers: %d\n", cfg.Duration, cfg.Workers)
+	if cfg.KeyDistribution.Type != "" && cfg.KeyDistribution.Type != keydist.Uniform {
+		fmt.Fprintf(w, "Key Distribution: %s\n", keydist.ConfigFrom(cfg))
+	}
 	if interrupted {
-		fmt.Println("Status:          ⚠️  Test was interrupted before completion")
+		fmt.Fprintln(w, "Status:          ⚠️  Test was interrupted before completion")
+	}
+	if md := m.GetRunMetadata(); md != nil {
+		if md.ServerVersion != "" {
+			fmt.Fprintf(w, "Server:          %s\n", serverDescription(md))
+		}
+		fmt.Fprintf(w, "Client:          stormdb %s on %s (%d CPUs)", md.ClientVersion, md.ClientHost, md.ClientCPUs)
+		if md.Plugin != "" {
+			fmt.Fprintf(w, ", plugin %s %s", md.Plugin, md.PluginVersion)
+		}
+		fmt.Fprintln(w)
 	}
 
 	// 1. TRANSACTIONS
-	fmt.Println("-------------------------------------------------------------------------------")
-	fmt.Println("1. TRANSACTIONS")
-	fmt.Println("-------------------------------------------------------------------------------")
-	fmt.Println(" Metric                          │ Value")
-	fmt.Println(" ─────────────────────────────── ┼ ────────────────────────────────────────────")
-	fmt.Printf(" Total Transactions              │ %s\n", formatNumber(totalTxns))
-	fmt.Printf(" TPS (Committed)                 │ %s\n", formatFloat(float64(m.TPS)/durationSec))
+	fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+	fmt.Fprintln(w, "1. TRANSACTIONS")
+	fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+	fmt.Fprintln(w, " Metric                          │ Value")
+	fmt.Fprintln(w, " ─────────────────────────────── ┼ ────────────────────────────────────────────")
+	fmt.Fprintf(w, " Total Transactions              │ %s\n", formatNumber(totalTxns))
+	fmt.Fprintf(w, " TPS (Committed)                 │ %s\n", formatFloat(float64(m.TPS)/durationSec))
 	if m.TPSAborted > 0 {
-		fmt.Printf(" TPS (Aborted)                   │ %s\n", formatFloat(float64(m.TPSAborted)/durationSec))
+		fmt.Fprintf(w, " TPS (Aborted)                   │ %s\n", formatFloat(float64(m.TPSAborted)/durationSec))
 	}
-	fmt.Printf(" Success Rate                    │ %.1f%%\n", successRate)
+	fmt.Fprintf(w, " Success Rate                    │ %.1f%%\n", successRate)
 
 	// 2. QUERIES
-	fmt.Println("\n-------------------------------------------------------------------------------")
-	fmt.Println("2. QUERIES")
-	fmt.Println("-------------------------------------------------------------------------------")
-	fmt.Println(" Metric                          │ Value")
-	fmt.Println(" ─────────────────────────────── ┼ ────────────────────────────────────────────")
-	fmt.Printf(" Total Queries                   │ %s\n", formatNumber(m.QPS))
-	fmt.Printf(" QPS (Overall)                   │ %s\n", formatFloat(float64(m.QPS)/durationSec))
+	fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+	fmt.Fprintln(w, "2. QUERIES")
+	fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+	fmt.Fprintln(w, " Metric                          │ Value")
+	fmt.Fprintln(w, " ─────────────────────────────── ┼ ────────────────────────────────────────────")
+	fmt.Fprintf(w, " Total Queries                   │ %s\n", formatNumber(m.QPS))
+	fmt.Fprintf(w, " QPS (Overall)                   │ %s\n", formatFloat(float64(m.QPS)/durationSec))
 
 	// Query breakdown by type in simple format
 	if m.SelectQueries > 0 || m.InsertQueries > 0 || m.UpdateQueries > 0 || m.DeleteQueries > 0 {
-		fmt.Println("\n Breakdown by Type:")
+		fmt.Fprintln(w, "\n Breakdown by Type:")
 		if m.SelectQueries > 0 {
-			fmt.Printf("   └ SELECT                      │ %s QPS (%s total)\n",
+			fmt.Fprintf(w, "   └ SELECT                      │ %s QPS (%s total)\n",
 				formatFloat(float64(m.SelectQueries)/durationSec), formatNumber(m.SelectQueries))
 		}
 		if m.InsertQueries > 0 {
-			fmt.Printf("   └ INSERT                      │ %s QPS (%s total)\n",
+			fmt.Fprintf(w, "   └ INSERT                      │ %s QPS (%s total)\n",
 				formatFloat(float64(m.InsertQueries)/durationSec), formatNumber(m.InsertQueries))
 		}
 		if m.UpdateQueries > 0 {
-			fmt.Printf("   └ UPDATE                      │ %s QPS (%s total)\n",
+			fmt.Fprintf(w, "   └ UPDATE                      │ %s QPS (%s total)\n",
 				formatFloat(float64(m.UpdateQueries)/durationSec), formatNumber(m.UpdateQueries))
 		}
 		if m.DeleteQueries > 0 {
-			fmt.Printf("   └ DELETE                      │ %s QPS (%s total)\n",
+			fmt.Fprintf(w, "   └ DELETE                      │ %s QPS (%s total)\n",
 				formatFloat(float64(m.DeleteQueries)/durationSec), formatNumber(m.DeleteQueries))
 		}
 	}
 
 	// 3. THROUGHPUT
 	if m.RowsRead > 0 || m.RowsModified > 0 {
-		fmt.Println("\n-------------------------------------------------------------------------------")
-		fmt.Println("3. THROUGHPUT")
-		fmt.Println("-------------------------------------------------------------------------------")
-		fmt.Println(" Metric                          │ Value")
-		fmt.Println(" ─────────────────────────────── ┼ ────────────────────────────────────────────")
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "3. THROUGHPUT")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, " Metric                          │ Value")
+		fmt.Fprintln(w, " ─────────────────────────────── ┼ ────────────────────────────────────────────")
 		if m.RowsRead > 0 {
-			fmt.Printf(" Read per second                 │ %s\n", formatFloat(float64(m.RowsRead)/durationSec))
+			fmt.Fprintf(w, " Read per second                 │ %s\n", formatFloat(float64(m.RowsRead)/durationSec))
 		}
 		if m.RowsModified > 0 {
-			fmt.Printf(" Modified per second             │ %s\n", formatFloat(float64(m.RowsModified)/durationSec))
+			fmt.Fprintf(w, " Modified per second             │ %s\n", formatFloat(float64(m.RowsModified)/durationSec))
 		}
 	}
 
 	// 4. LATENCY
 	if len(latencies) > 0 {
-		fmt.Println("\n-------------------------------------------------------------------------------")
-		fmt.Println("4. LATENCY (milliseconds)")
-		fmt.Println("-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "4. LATENCY (milliseconds)")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
 
 		// Percentiles in table format
-		fmt.Println(" Percentiles:")
-		fmt.Println("-------------------------------------------------------------------------------")
-		fmt.Println(" P50(ms)  │ P90(ms)  │ P95(ms)  │ P99(ms)")
-		fmt.Println(" ──────── ┼ ──────── ┼ ──────── ┼ ────────")
-		fmt.Printf(" %-8.2f │ %-8.2f │ %-8.2f │ %-8.2f\n",
+		fmt.Fprintln(w, " Percentiles:")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, " P50(ms)  │ P90(ms)  │ P95(ms)  │ P99(ms)")
+		fmt.Fprintln(w, " ──────── ┼ ──────── ┼ ──────── ┼ ────────")
+		fmt.Fprintf(w, " %-8.2f │ %-8.2f │ %-8.2f │ %-8.2f\n",
 			float64(pvals[0])/1e6, float64(pvals[1])/1e6, float64(pvals[2])/1e6, float64(pvals[3])/1e6)
 
 		// Calculate distribution shape metrics
 		distStats := util.CalculateDistributionStats(latencies)
 
 		// Distribution Shape in table format
-		fmt.Println("\n Distribution Shape:")
-		fmt.Println("-------------------------------------------------------------------------------")
-		fmt.Println(" P25(ms)  │ P75(ms)  │ IQR(ms)  │ MAD(ms)  │ Skewness │ Kurtosis │ CoV")
-		fmt.Println(" ──────── ┼ ──────── ┼ ──────── ┼ ──────── ┼ ──────── ┼ ──────── ┼ ────────")
-		fmt.Printf(" %-8.2f │ %-8.2f │ %-8.2f │ %-8.2f │ %-8.3f │ %-8.3f │ %-8.3f\n",
+		fmt.Fprintln(w, "\n Distribution Shape:")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, " P25(ms)  │ P75(ms)  │ IQR(ms)  │ MAD(ms)  │ Skewness │ Kurtosis │ CoV")
+		fmt.Fprintln(w, " ──────── ┼ ──────── ┼ ──────── ┼ ──────── ┼ ──────── ┼ ──────── ┼ ────────")
+		fmt.Fprintf(w, " %-8.2f │ %-8.2f │ %-8.2f │ %-8.2f │ %-8.3f │ %-8.3f │ %-8.3f\n",
 			float64(distStats.P25)/1e6, float64(distStats.P75)/1e6, float64(distStats.IQR)/1e6,
 			distStats.MAD/1e6, distStats.Skewness, distStats.Kurtosis, distStats.CoV)
 
@@ -247,15 +269,15 @@ func ReportWithContext(cfg *types.Config, m *types.Metrics, interrupted bool, en
 		maxMsFloat := float64(maxMs) / 1e6
 		stddevMsFloat := float64(stddevMs) / 1e6
 
-		fmt.Println("\n Transaction Time:")
-		fmt.Println("-------------------------------------------------------------------------------")
-		fmt.Println(" Min(ms)  │ Max(ms)  │ Avg(ms)  │ StdDev")
-		fmt.Println(" ──────── ┼ ──────── ┼ ──────── ┼ ────────")
-		fmt.Printf(" %-8.2f │ %-8.2f │ %-8.2f │ %-8.2f\n", minMsFloat, maxMsFloat, avgMsFloat, stddevMsFloat)
+		fmt.Fprintln(w, "\n Transaction Time:")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, " Min(ms)  │ Max(ms)  │ Avg(ms)  │ StdDev")
+		fmt.Fprintln(w, " ──────── ┼ ──────── ┼ ──────── ┼ ────────")
+		fmt.Fprintf(w, " %-8.2f │ %-8.2f │ %-8.2f │ %-8.2f\n", minMsFloat, maxMsFloat, avgMsFloat, stddevMsFloat)
 
 		// Latency Histogram
 		if len(m.LatencyHistogram) > 0 {
-			fmt.Println("\n Latency Histogram (ms):")
+			fmt.Fprintln(w, "\n Latency Histogram (ms):")
 
 			// Define bucket ranges for visualization
 			bucketRanges := []struct {
@@ -286,54 +308,128 @@ func ReportWithContext(cfg *types.Config, m *types.Metrics, interrupted bool, en
 				if rangeTotal > 0 {
 					percentage := float64(rangeTotal) / float64(totalSamples) * 100.0
 					bar := createHistogramBar(percentage, 20)
-					fmt.Printf("   %-6s │ %-20s %3.0f%%\n", bucketRange.name, bar, percentage)
+					fmt.Fprintf(w, "   %-6s │ %-20s %3.0f%%\n", bucketRange.name, bar, percentage)
 				}
 			}
 		}
 	}
 
 	// 5. ERRORS
-	fmt.Println("\n-------------------------------------------------------------------------------")
-	fmt.Println("5. ERRORS")
-	fmt.Println("-------------------------------------------------------------------------------")
-	fmt.Println(" Metric                          │ Value")
-	fmt.Println(" ─────────────────────────────── ┼ ────────────────────────────────────────────")
-	fmt.Printf(" Total Query Errors              │ %s\n", formatNumber(m.Errors))
+	fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+	fmt.Fprintln(w, "5. ERRORS")
+	fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+	fmt.Fprintln(w, " Metric                          │ Value")
+	fmt.Fprintln(w, " ─────────────────────────────── ┼ ────────────────────────────────────────────")
+	fmt.Fprintf(w, " Total Query Errors              │ %s\n", formatNumber(m.Errors))
 
 	if len(m.ErrorTypes) > 0 {
-		fmt.Println(" Error Types:")
+		fmt.Fprintln(w, " Error Types:")
 		for errType, count := range m.ErrorTypes {
 			// Show the full error message for better debugging
-			fmt.Printf("   └ %-27s │ %s\n", errType, formatNumber(count))
+			fmt.Fprintf(w, "   └ %-27s │ %s\n", errType, formatNumber(count))
 		}
 	}
 
 	// Per-transaction breakdown (TPCC-specific)
 	if m.NewOrderCount > 0 || m.PaymentCount > 0 || m.OrderStatusCount > 0 {
-		fmt.Println("\n-------------------------------------------------------------------------------")
-		fmt.Println("6. TRANSACTION MIX")
-		fmt.Println("-------------------------------------------------------------------------------")
-		fmt.Println(" Transaction Type                │ Value")
-		fmt.Println(" ─────────────────────────────── ┼ ────────────────────────────────────────────")
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "6. TRANSACTION MIX")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, " Transaction Type                │ Value")
+		fmt.Fprintln(w, " ─────────────────────────────── ┼ ────────────────────────────────────────────")
 		if m.NewOrderCount > 0 {
-			fmt.Printf(" New-Order                       │ %s TPS (%s total)\n",
+			fmt.Fprintf(w, " New-Order                       │ %s TPS (%s total)\n",
 				formatFloat(float64(m.NewOrderCount)/durationSec), formatNumber(m.NewOrderCount))
 		}
 		if m.PaymentCount > 0 {
-			fmt.Printf(" Payment                         │ %s TPS (%s total)\n",
+			fmt.Fprintf(w, " Payment                         │ %s TPS (%s total)\n",
 				formatFloat(float64(m.PaymentCount)/durationSec), formatNumber(m.PaymentCount))
 		}
 		if m.OrderStatusCount > 0 {
-			fmt.Printf(" Order-Status                    │ %s TPS (%s total)\n",
+			fmt.Fprintf(w, " Order-Status                    │ %s TPS (%s total)\n",
 				formatFloat(float64(m.OrderStatusCount)/durationSec), formatNumber(m.OrderStatusCount))
 		}
 	}
 
+	// Per-operation breakdown, for workloads that record named operations
+	if operations := summarizeOperations(m, durationSec); len(operations) > 0 {
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "OPERATIONS")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintf(w, " %-28s │ %-9s │ %-8s │ %-7s │ %-8s │ %-8s │ %-8s │ %-8s\n",
+			"Operation", "Count", "OPS", "Errors", "Avg(ms)", "P50(ms)", "P95(ms)", "P99(ms)")
+		fmt.Fprintln(w, " ─────────────────────────────┼───────────┼──────────┼─────────┼──────────┼──────────┼──────────┼─────────")
+		for _, op := range operations {
+			fmt.Fprintf(w, " %-28s │ %-9s │ %-8s │ %-7s │ %-8.2f │ %-8.2f │ %-8.2f │ %-8.2f\n",
+				op.Name, formatNumber(op.Count), formatFloat(op.OPS), formatNumber(op.Errors),
+				op.AvgMs, op.P50Ms, op.P95Ms, op.P99Ms)
+		}
+	}
+
+	// Captured query plans, by operation
+	if plans := summarizeQueryPlans(m); plans != nil {
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "QUERY PLANS")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		if plans.File != "" {
+			fmt.Fprintf(w, " Saved to %s\n", plans.File)
+		}
+		fmt.Fprintf(w, " %d plan(s) captured, %d changed since the previous run\n", plans.Captured, plans.Changed)
+		for _, p := range plans.Plans {
+			operation := p.Operation
+			if operation == "" {
+				operation = "(no operation)"
+			}
+			fmt.Fprintf(w, " %-28s │ %-9s │ %8.2fms │ %s\n", operation, p.Reason, p.LatencyMs, p.Fingerprint)
+			switch {
+			case p.Error != "":
+				fmt.Fprintf(w, "   └ capture failed: %s\n", p.Error)
+			case p.Changed:
+				fmt.Fprintf(w, "   └ ⚠️  %s\n", truncatePlanShape(p.Shape))
+				fmt.Fprintf(w, "      was %s\n", truncatePlanShape(p.PreviousShape))
+			default:
+				fmt.Fprintf(w, "   └ %s\n", truncatePlanShape(p.Shape))
+			}
+		}
+	}
+
+	// Host resources of the load generators and the database server
+	if hosts := summarizeHostStats(cfg, m, nil); hosts != nil {
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "HOST RESOURCES")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		for _, h := range hosts.Clients {
+			fmt.Fprintf(w, " Client %s (%d CPUs, %d samples)\n", h.Host, h.CPUs, h.Samples)
+			fmt.Fprintf(w, "   CPU busy          │ %.1f%% avg, %.1f%% max (user %.1f%%, system %.1f%%, iowait %.1f%%, steal %.1f%%)\n",
+				h.AvgCPUBusyPct, h.MaxCPUBusyPct, h.AvgCPUUserPct, h.AvgCPUSystemPct, h.AvgCPUIOWaitPct, h.AvgCPUStealPct)
+			fmt.Fprintf(w, "   Run queue         │ %.1f avg, %d max\n", h.AvgRunQueue, h.MaxRunQueue)
+			fmt.Fprintf(w, "   Memory used       │ %.1f%% avg, %.1f%% max\n", h.AvgMemUsedPct, h.MaxMemUsedPct)
+			fmt.Fprintf(w, "   Network           │ %s/s in, %s/s out\n",
+				formatBytes(int64(h.AvgNetRxBytesPerSec)), formatBytes(int64(h.AvgNetTxBytesPerSec)))
+			fmt.Fprintf(w, "   Context switches  │ %s/s\n", formatNumber(int64(h.AvgContextSwitchesPerSec)))
+			fmt.Fprintf(w, "   Saturated         │ %.0f%% of samples\n", h.SaturatedPct)
+		}
+		if srv := hosts.Server; srv != nil {
+			fmt.Fprintf(w, " Database server (%d samples)\n", srv.Samples)
+			fmt.Fprintf(w, "   I/O (pg_stat_io)  │ %.0f reads/s, %.0f writes/s, %.1f fsyncs/s\n",
+				srv.AvgIOReadsPerSec, srv.AvgIOWritesPerSec, srv.AvgIOFsyncsPerSec)
+			if srv.MaxWALDirBytes > 0 {
+				fmt.Fprintf(w, "   WAL directory     │ %s max, %s at end (%d files max)\n",
+					formatBytes(srv.MaxWALDirBytes), formatBytes(srv.LastWALDirBytes), srv.MaxWALFiles)
+			}
+		}
+		if hosts.ClientBound {
+			fmt.Fprintf(w, "\n ⚠️  The load generator was the bottleneck: %s had CPU busy ≥ %.0f%% or more\n",
+				strings.Join(hosts.ClientBoundHosts, ", "), hosts.CPUThresholdPct)
+			fmt.Fprintln(w, "    runnable tasks than CPUs in most samples. Throughput reflects the client, not the database.")
+		}
+	}
+
 	// Worker breakdown section
 	if len(m.WorkerMetrics) > 1 { // Only show if we have multiple workers
-		fmt.Println("\n-------------------------------------------------------------------------------")
-		fmt.Println("WORKER BREAKDOWN")
-		fmt.Println("-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "WORKER BREAKDOWN")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
 
 		// Calculate worker statistics
 		workerStats := make([]util.WorkerPerformanceStats, 0, len(m.WorkerMetrics))
@@ -356,13 +452,13 @@ func ReportWithContext(cfg *types.Config, m *types.Metrics, interrupted bool, en
 		})
 
 		// Table header
-		fmt.Printf(" %-6s │ %-8s │ %-8s │ %-8s │ %-8s │ %-8s │ %-6s\n",
+		fmt.Fprintf(w, " %-6s │ %-8s │ %-8s │ %-8s │ %-8s │ %-8s │ %-6s\n",
 			"Worker", "TPS", "QPS", "P50(ms)", "P95(ms)", "Success%", "Errors")
-		fmt.Println(" ───────┼──────────┼──────────┼──────────┼──────────┼──────────┼────────")
+		fmt.Fprintln(w, " ───────┼──────────┼──────────┼──────────┼──────────┼──────────┼────────")
 
 		// Worker rows
 		for _, stats := range workerStats {
-			fmt.Printf(" %-6d │ %-8s │ %-8s │ %-8.2f │ %-8.2f │ %-8.1f │ %-6d\n",
+			fmt.Fprintf(w, " %-6d │ %-8s │ %-8s │ %-8.2f │ %-8.2f │ %-8.1f │ %-6d\n",
 				stats.WorkerID,
 				formatFloat(stats.TPS),
 				formatFloat(stats.QPS),
@@ -375,7 +471,7 @@ func ReportWithContext(cfg *types.Config, m *types.Metrics, interrupted bool, en
 
 		// Calculate variance analysis
 		if len(workerStats) > 1 {
-			fmt.Println("\n Worker Load Distribution Analysis:")
+			fmt.Fprintln(w, "\n Worker Load Distribution Analysis:")
 
 			// Calculate TPS variance
 			var tpsValues []float64
@@ -392,68 +488,68 @@ func ReportWithContext(cfg *types.Config, m *types.Metrics, interrupted bool, en
 			qpsCoV := calculateCoV(qpsValues)
 			p50CoV := calculateCoV(p50Values)
 
-			fmt.Printf("   └ TPS Variance (CoV)            │ %.3f", tpsCoV)
+			fmt.Fprintf(w, "   └ TPS Variance (CoV)            │ %.3f", tpsCoV)
 			if tpsCoV > 0.1 {
-				fmt.Printf(" ⚠️  High variance detected")
+				fmt.Fprintf(w, " ⚠️  High variance detected")
 			}
-			fmt.Println()
+			fmt.Fprintln(w)
 
-			fmt.Printf("   └ QPS Variance (CoV)            │ %.3f", qpsCoV)
+			fmt.Fprintf(w, "   └ QPS Variance (CoV)            │ %.3f", qpsCoV)
 			if qpsCoV > 0.1 {
-				fmt.Printf(" ⚠️  High variance detected")
+				fmt.Fprintf(w, " ⚠️  High variance detected")
 			}
-			fmt.Println()
+			fmt.Fprintln(w)
 
-			fmt.Printf("   └ P50 Latency Variance (CoV)    │ %.3f", p50CoV)
+			fmt.Fprintf(w, "   └ P50 Latency Variance (CoV)    │ %.3f", p50CoV)
 			if p50CoV > 0.2 {
-				fmt.Printf(" ⚠️  High variance detected")
+				fmt.Fprintf(w, " ⚠️  High variance detected")
 			}
-			fmt.Println()
+			fmt.Fprintln(w)
 		}
 	}
 
 	// 6. TIME-SERIES ANALYSIS
 	if m.TimeSeries != nil && len(m.TimeSeries.Buckets) > 1 {
-		fmt.Println("\n-------------------------------------------------------------------------------")
-		fmt.Println("7. TIME-SERIES ANALYSIS")
-		fmt.Println("-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "7. TIME-SERIES ANALYSIS")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
 
 		tsStats := util.AnalyzeTimeSeries(m.TimeSeries.Buckets)
 
-		fmt.Println(" Metric                          │ Value")
-		fmt.Println(" ─────────────────────────────── ┼ ────────────────────────────────────────────")
-		fmt.Printf(" Time Buckets Analyzed           │ %d\n", len(m.TimeSeries.Buckets))
-		fmt.Printf(" Collection Period               │ %v\n", m.BucketInterval)
+		fmt.Fprintln(w, " Metric                          │ Value")
+		fmt.Fprintln(w, " ─────────────────────────────── ┼ ────────────────────────────────────────────")
+		fmt.Fprintf(w, " Time Buckets Analyzed           │ %d\n", len(m.TimeSeries.Buckets))
+		fmt.Fprintf(w, " Collection Period               │ %v\n", m.BucketInterval)
 
-		fmt.Println("\n Load vs Latency Correlations:")
-		fmt.Printf("   └ QPS vs Latency (Pearson)      │ %.3f", tsStats.PearsonCorrelation)
+		fmt.Fprintln(w, "\n Load vs Latency Correlations:")
+		fmt.Fprintf(w, "   └ QPS vs Latency (Pearson)      │ %.3f", tsStats.PearsonCorrelation)
 		if math.Abs(tsStats.PearsonCorrelation) > 0.7 {
-			fmt.Printf(" 🔍 Strong correlation")
+			fmt.Fprintf(w, " 🔍 Strong correlation")
 		} else if math.Abs(tsStats.PearsonCorrelation) > 0.3 {
-			fmt.Printf(" 📊 Moderate correlation")
+			fmt.Fprintf(w, " 📊 Moderate correlation")
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 
-		fmt.Printf("   └ QPS vs Latency (Spearman)     │ %.3f", tsStats.SpearmanCorrelation)
+		fmt.Fprintf(w, "   └ QPS vs Latency (Spearman)     │ %.3f", tsStats.SpearmanCorrelation)
 		if math.Abs(tsStats.SpearmanCorrelation) > 0.7 {
-			fmt.Printf(" 🔍 Strong monotonic relationship")
+			fmt.Fprintf(w, " 🔍 Strong monotonic relationship")
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 
-		fmt.Println("\n Load Characteristics:")
-		fmt.Printf("   └ Peak QPS                      │ %.2f\n", tsStats.PeakQPS)
-		fmt.Printf("   └ Median QPS                    │ %.2f\n", tsStats.MedianQPS)
-		fmt.Printf("   └ Peak Latency                  │ %.2f ms\n", tsStats.PeakLatency)
-		fmt.Printf("   └ Median Latency                │ %.2f ms\n", tsStats.MedianLatency)
+		fmt.Fprintln(w, "\n Load Characteristics:")
+		fmt.Fprintf(w, "   └ Peak QPS                      │ %.2f\n", tsStats.PeakQPS)
+		fmt.Fprintf(w, "   └ Median QPS                    │ %.2f\n", tsStats.MedianQPS)
+		fmt.Fprintf(w, "   └ Peak Latency                  │ %.2f ms\n", tsStats.PeakLatency)
+		fmt.Fprintf(w, "   └ Median Latency                │ %.2f ms\n", tsStats.MedianLatency)
 
 		if len(tsStats.LoadStabilityRegions) > 0 {
-			fmt.Println("\n Load Regions Detected:")
+			fmt.Fprintln(w, "\n Load Regions Detected:")
 			for i, region := range tsStats.LoadStabilityRegions {
 				status := "Variable"
 				if region.IsStable {
 					status = "Stable"
 				}
-				fmt.Printf("   └ Region %d: %s (QPS: %.1f-%.1f, Latency: %.2f-%.2f ms)\n",
+				fmt.Fprintf(w, "   └ Region %d: %s (QPS: %.1f-%.1f, Latency: %.2f-%.2f ms)\n",
 					i+1, status, region.QPSRange[0], region.QPSRange[1],
 					region.LatencyRange[0], region.LatencyRange[1])
 			}
@@ -461,25 +557,25 @@ func ReportWithContext(cfg *types.Config, m *types.Metrics, interrupted bool, en
 
 		// Show regression slope for trend analysis
 		if !math.IsNaN(tsStats.LatencySlope) {
-			fmt.Printf("\n Trend Analysis:\n")
-			fmt.Printf("   └ Latency increase per 100 QPS  │ %.3f ms\n", tsStats.LatencySlope)
+			fmt.Fprintf(w, "\n Trend Analysis:\n")
+			fmt.Fprintf(w, "   └ Latency increase per 100 QPS  │ %.3f ms\n", tsStats.LatencySlope)
 			if tsStats.LatencySlope > 10.0 {
-				fmt.Printf("   └ Trend: Performance degrades with load 📉\n")
+				fmt.Fprintf(w, "   └ Trend: Performance degrades with load 📉\n")
 			} else if tsStats.LatencySlope < -1.0 {
-				fmt.Printf("   └ Trend: Performance improves with load 📈\n")
+				fmt.Fprintf(w, "   └ Trend: Performance improves with load 📈\n")
 			} else {
-				fmt.Printf("   └ Trend: Stable performance across load levels ➡️\n")
+				fmt.Fprintf(w, "   └ Trend: Stable performance across load levels ➡️\n")
 			}
 		}
 	}
 
 	// 8. CONNECTION MODE COMPARISON (for connection_overhead workload)
 	if m.PersistentConnMetrics != nil || m.TransientConnMetrics != nil {
-		fmt.Println("\n-------------------------------------------------------------------------------")
-		fmt.Println("8. CONNECTION MODE COMPARISON")
-		fmt.Println("-------------------------------------------------------------------------------")
-		fmt.Println(" Metric                          │ Persistent        │ Transient         │ Overhead")
-		fmt.Println(" ─────────────────────────────── ┼ ───────────────── ┼ ───────────────── ┼ ─────────")
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "8. CONNECTION MODE COMPARISON")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, " Metric                          │ Persistent        │ Transient         │ Overhead")
+		fmt.Fprintln(w, " ─────────────────────────────── ┼ ───────────────── ┼ ───────────────── ┼ ─────────")
 
 		// Get metrics (thread-safe)
 		var persistentTPS, persistentQPS, persistentErrors int64
@@ -544,115 +640,554 @@ func ReportWithContext(cfg *types.Config, m *types.Metrics, interrupted bool, en
 			latencyOverhead = "N/A"
 		}
 
-		fmt.Printf(" Transactions/sec                │ %17s │ %17s │ %8s\n",
+		fmt.Fprintf(w, " Transactions/sec                │ %17s │ %17s │ %8s\n",
 			formatNumber(persistentTPS), formatNumber(transientTPS), tpsOverhead)
-		fmt.Printf(" Queries/sec                     │ %17s │ %17s │ %8s\n",
+		fmt.Fprintf(w, " Queries/sec                     │ %17s │ %17s │ %8s\n",
 			formatNumber(persistentQPS), formatNumber(transientQPS), qpsOverhead)
-		fmt.Printf(" Errors                          │ %17s │ %17s │ N/A\n",
+		fmt.Fprintf(w, " Errors                          │ %17s │ %17s │ N/A\n",
 			formatNumber(persistentErrors), formatNumber(transientErrors))
-		fmt.Printf(" Avg Transaction Latency (ms)    │ %17.2f │ %17.2f │ %8s\n",
+		fmt.Fprintf(w, " Avg Transaction Latency (ms)    │ %17.2f │ %17.2f │ %8s\n",
 			persistentAvgDur, transientAvgDur, latencyOverhead)
 		if avgConnSetup > 0 {
-			fmt.Printf(" Avg Connection Setup (ms)       │ %17s │ %17.2f │ N/A\n",
+			fmt.Fprintf(w, " Avg Connection Setup (ms)       │ %17s │ %17.2f │ N/A\n",
 				"N/A (pooled)", avgConnSetup)
 		}
 		if connCount > 0 {
-			fmt.Printf(" Total Connections Created       │ %17s │ %17s │ N/A\n",
+			fmt.Fprintf(w, " Total Connections Created       │ %17s │ %17s │ N/A\n",
 				"N/A (pooled)", formatNumber(connCount))
 		}
 
-		fmt.Println("\n Connection Mode Summary:")
+		fmt.Fprintln(w, "\n Connection Mode Summary:")
 		if persistentTPS > transientTPS {
-			fmt.Printf("   • Persistent connections are %.1fx faster for transactions\n",
+			fmt.Fprintf(w, "   • Persistent connections are %.1fx faster for transactions\n",
 				float64(persistentTPS)/float64(transientTPS))
 		}
 		if persistentAvgDur < transientAvgDur {
-			fmt.Printf("   • Persistent connections have %.1fms lower latency on average\n",
+			fmt.Fprintf(w, "   • Persistent connections have %.1fms lower latency on average\n",
 				transientAvgDur-persistentAvgDur)
 		}
 		if avgConnSetup > 0 {
-			fmt.Printf("   • Each transient connection adds %.2fms setup overhead\n", avgConnSetup)
+			fmt.Fprintf(w, "   • Each transient connection adds %.2fms setup overhead\n", avgConnSetup)
+		}
+	}
+
+	// Side-by-side endpoint comparison (e.g., direct vs. connection pooler)
+	m.Mu.Lock()
+	endpointOrder := append([]string(nil), m.EndpointOrder...)
+	m.Mu.Unlock()
+	if len(endpointOrder) > 0 {
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "ENDPOINT COMPARISON")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintf(w, " %-16s │ %-10s │ %-9s │ %-9s │ %-11s │ %-8s\n",
+			"Endpoint", "TPS", "Avg(ms)", "P95(ms)", "Connect(ms)", "Errors")
+		fmt.Fprintln(w, " ─────────────────┼────────────┼───────────┼───────────┼─────────────┼─────────")
+
+		failures := make(map[string]map[string]int64)
+		for _, name := range endpointOrder {
+			m.Mu.Lock()
+			em := m.EndpointMetrics[name]
+			m.Mu.Unlock()
+
+			em.Mu.RLock()
+			var avgMs, p95Ms, connectMs float64
+			if len(em.TransactionDur) > 0 {
+				avg, _, _, _ := util.Stats(em.TransactionDur)
+				avgMs = float64(avg) / 1e6
+				p95Ms = float64(util.CalculatePercentiles(em.TransactionDur, []int{95})[0]) / 1e6
+			}
+			if len(em.ConnectionSetup) > 0 {
+				avg, _, _, _ := util.Stats(em.ConnectionSetup)
+				connectMs = float64(avg) / 1e6
+			}
+			fmt.Fprintf(w, " %-16s │ %-10s │ %-9.2f │ %-9.2f │ %-11.2f │ %-8s\n",
+				name, formatFloat(float64(em.TPS)/durationSec), avgMs, p95Ms, connectMs, formatNumber(em.Errors))
+			if len(em.FailureTypes) > 0 {
+				failures[name] = make(map[string]int64, len(em.FailureTypes))
+				for category, count := range em.FailureTypes {
+					failures[name][category] = count
+				}
+			}
+			em.Mu.RUnlock()
+		}
+
+		if len(failures) > 0 {
+			fmt.Fprintln(w, "\n Failures by Category:")
+			for _, name := range endpointOrder {
+				categories := make([]string, 0, len(failures[name]))
+				for category := range failures[name] {
+					categories = append(categories, category)
+				}
+				sort.Strings(categories)
+				for _, category := range categories {
+					fmt.Fprintf(w, "   └ %-14s │ %-30s │ %s\n", name, category, formatNumber(failures[name][category]))
+				}
+			}
+			if hasFailure(failures, "prepared_statement_missing") || hasFailure(failures, "prepared_statement_exists") {
+				fmt.Fprintln(w, "\n 💡 Prepared statement errors usually mean transaction pooling without prepared")
+				fmt.Fprintln(w, "    statement support; try query_exec_mode: exec or simple_protocol")
+			}
+		}
+	}
+
+	// Read replica routing and replay lag
+	if replicaStats := m.GetReplicaStats(); len(replicaStats) > 0 {
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "REPLICA ROUTING")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintf(w, " %-24s │ %-10s │ %-8s │ %-12s │ %-12s │ %-8s\n",
+			"Replica", "Reads", "Share%", "Avg Lag(ms)", "Max Lag(ms)", "Samples")
+		fmt.Fprintln(w, " ─────────────────────────┼────────────┼──────────┼──────────────┼──────────────┼─────────")
+
+		var totalReads int64
+		for _, rs := range replicaStats {
+			totalReads += rs.Reads
+		}
+
+		var worstLag float64
+		for _, rs := range replicaStats {
+			share := 0.0
+			if totalReads > 0 {
+				share = float64(rs.Reads) / float64(totalReads) * 100.0
+			}
+			name := rs.Name
+			if len(name) > 24 {
+				name = name[:21] + "..."
+			}
+			fmt.Fprintf(w, " %-24s │ %-10s │ %-8.1f │ %-12.2f │ %-12.2f │ %-8d\n",
+				name, formatNumber(rs.Reads), share, rs.AvgLagMs, rs.MaxLagMs, rs.LagSamples)
+			if rs.MaxLagMs > worstLag {
+				worstLag = rs.MaxLagMs
+			}
+		}
+
+		fmt.Fprintf(w, "\n Reads routed to replicas: %s (%s per second)\n",
+			formatNumber(totalReads), formatFloat(float64(totalReads)/durationSec))
+		if fallback := m.GetReplicaFallbackReads(); fallback > 0 {
+			fmt.Fprintf(w, " Reads served by the primary while every replica was unhealthy or lagging: %s\n",
+				formatNumber(fallback))
+		}
+		if worstLag > 0 {
+			fmt.Fprintf(w, " Stale-read exposure: replica reads were up to %.2fms behind the primary\n", worstLag)
+		}
+	}
+
+	// Fault injection events and recovery
+	if faultEvents := m.GetFaultEvents(); len(faultEvents) > 0 {
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "FAULT INJECTION")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintf(w, " %-22s │ %-18s │ %-10s │ %-12s │ %-12s │ %-8s\n",
+			"Event", "Action", "Errors", "First Txn", "Recovery", "Status")
+		fmt.Fprintln(w, " ───────────────────────┼────────────────────┼────────────┼──────────────┼──────────────┼─────────")
+
+		untracked := false
+		for _, fe := range faultEvents {
+			name := fe.Name
+			if len(name) > 22 {
+				name = name[:19] + "..."
+			}
+			firstTxn, recovery, status := "-", "-", "FAILED"
+			if fe.FirstTxnObserved {
+				firstTxn = fe.TimeToFirstTxn.Round(time.Millisecond).String()
+				if !fe.CommitTracking {
+					firstTxn += " *"
+					untracked = true
+				}
+			}
+			if fe.Recovered {
+				recovery = fe.RecoveryTime.Round(time.Millisecond).String()
+				status = "OK"
+			}
+			fmt.Fprintf(w, " %-22s │ %-18s │ %-10s │ %-12s │ %-12s │ %-8s\n",
+				name, fe.Action, formatNumber(fe.ErrorBurst), firstTxn, recovery, status)
+		}
+
+		fmt.Fprintf(w, "\n First Txn counts transactions begun after the fault. Times are polled every %v\n", faultEvents[0].Resolution)
+		fmt.Fprintln(w, " and rounded up to that resolution.")
+		if untracked {
+			fmt.Fprintln(w, " * The workload does not record commit start times, so this may be a")
+			fmt.Fprintln(w, "   transaction that was already in flight when the fault fired.")
+		}
+
+		for _, fe := range faultEvents {
+			if fe.ActionError != "" {
+				fmt.Fprintf(w, "\n ⚠️  %s action error: %s", fe.Name, fe.ActionError)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	// Bulk load method matrix
+	if loads := m.GetBulkLoads(); len(loads) > 0 {
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "BULK LOAD MATRIX")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintf(w, " %-11s │ %-8s │ %-7s │ %-4s │ %-10s │ %-10s │ %-10s │ %-8s\n",
+			"Method", "Table", "Indexes", "Sync", "Rows", "Rows/s", "WAL B/row", "Batch ms")
+		fmt.Fprintln(w, " ────────────┼──────────┼─────────┼──────┼────────────┼────────────┼────────────┼─────────")
+
+		for _, l := range loads {
+			indexes := "no"
+			if l.Indexes {
+				indexes = "yes"
+			}
+			if l.Error != "" {
+				fmt.Fprintf(w, " %-11s │ %-8s │ %-7s │ %-4s │ FAILED: %s\n", l.Method, l.Table, indexes, l.SynchronousCommit, l.Error)
+				continue
+			}
+			fmt.Fprintf(w, " %-11s │ %-8s │ %-7s │ %-4s │ %-10s │ %-10s │ %-10.1f │ %-8.2f\n",
+				l.Method, l.Table, indexes, l.SynchronousCommit, formatNumber(l.Rows),
+				formatFloat(l.RowsPerSec), l.WALBytesPerRow, l.AvgBatchMs)
+		}
+		fmt.Fprintf(w, "\n Batch size %d. WAL bytes come from cluster-wide pg_stat_wal deltas.\n", loads[0].BatchSize)
+	}
+
+	// Partitioned ingestion: routing overhead, rows per partition, maintenance
+	if partitioning := m.GetPartitioning(); partitioning != nil {
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "PARTITIONED INGESTION")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintf(w, " Strategy: %s on %s, %d initial partitions\n",
+			partitioning.Strategy, partitioning.Key, partitioning.InitialPartitions)
+		if partitioning.RoutingCheckRows > 0 {
+			fmt.Fprintf(w, " Routing overhead: %.1f%% (%s rows/s partitioned vs %s rows/s unpartitioned over %s rows)\n",
+				partitioning.RoutingOverheadPct, formatFloat(partitioning.PartitionedRowsPerSec),
+				formatFloat(partitioning.PlainRowsPerSec), formatNumber(int64(partitioning.RoutingCheckRows)))
+		}
+
+		if len(partitioning.Stats) > 0 {
+			fmt.Fprintf(w, "\n %-32s │ %-12s │ %-7s │ %-10s\n", "Partition", "Rows", "Share", "Rows/s")
+			fmt.Fprintln(w, " ─────────────────────────────────┼──────────────┼─────────┼───────────")
+			for _, p := range partitioning.Stats {
+				fmt.Fprintf(w, " %-32s │ %-12s │ %-7s │ %-10s\n", p.Partition, formatNumber(p.Rows),
+					fmt.Sprintf("%.1f%%", p.Share*100), formatFloat(p.RowsPerSec))
+			}
+		}
+
+		if len(partitioning.Events) > 0 {
+			fmt.Fprintf(w, "\n %-8s │ %-7s │ %-32s │ %-10s │ %-10s │ %-10s\n",
+				"Offset", "Action", "Partition", "DDL time", "Before/s", "During/s")
+			fmt.Fprintln(w, " ─────────┼─────────┼──────────────────────────────────┼────────────┼────────────┼───────────")
+			for _, e := range partitioning.Events {
+				if e.Error != "" {
+					fmt.Fprintf(w, " %-8s │ %-7s │ %-32s │ FAILED: %s\n", e.Offset.Round(time.Second), e.Action, e.Partition, e.Error)
+					continue
+				}
+				fmt.Fprintf(w, " %-8s │ %-7s │ %-32s │ %-10s │ %-10s │ %-10s\n",
+					e.Offset.Round(time.Second), e.Action, e.Partition, e.Duration.Round(time.Millisecond),
+					formatFloat(e.RowsPerSecBefore), formatFloat(e.RowsPerSecDuring))
+			}
+		}
+	}
+
+	// Upsert comparison: throughput and table cost as conflicts rise
+	if upserts := m.GetUpserts(); len(upserts) > 0 {
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "UPSERT COMPARISON")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintf(w, " %-13s │ %-8s │ %-10s │ %-10s │ %-8s │ %-10s │ %-9s │ %-9s\n",
+			"Method", "Conflict", "Rows", "Rows/s", "Batch ms", "Dead tup", "Dead/row", "Lock wait")
+		fmt.Fprintln(w, " ──────────────┼──────────┼────────────┼────────────┼──────────┼────────────┼───────────┼──────────")
+
+		var deadlocks int64
+		for _, u := range upserts {
+			conflict := fmt.Sprintf("%.0f%%", u.ConflictRatio*100)
+			if u.Error != "" {
+				fmt.Fprintf(w, " %-13s │ %-8s │ FAILED: %s\n", u.Method, conflict, u.Error)
+				continue
+			}
+			fmt.Fprintf(w, " %-13s │ %-8s │ %-10s │ %-10s │ %-8.2f │ %-10s │ %-9.2f │ %.1f/%d\n",
+				u.Method, conflict, formatNumber(u.Rows), formatFloat(u.RowsPerSec), u.AvgBatchMs,
+				formatNumber(u.DeadTuples), u.DeadTuplesPerRow, u.AvgLockWaiters, u.PeakLockWaiters)
+			deadlocks += u.Deadlocks
+		}
+		fmt.Fprintf(w, "\n Batch size %d. Lock wait is average/peak sessions waiting on locks; autovacuum\n", upserts[0].BatchSize)
+		fmt.Fprintf(w, " was disabled on the table. Deadlocks: %d\n", deadlocks)
+	}
+
+	// Vector index build comparison
+	if builds := m.GetIndexBuilds(); len(builds) > 0 {
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "VECTOR INDEX BUILDS")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintf(w, " maintenance_work_mem: %s, parallel maintenance workers: %d\n\n",
+			builds[0].MaintenanceWorkMem, builds[0].ParallelWorkers)
+		fmt.Fprintf(w, " %-22s │ %-10s │ %-10s │ %-8s │ %-8s │ %-10s │ %-8s\n",
+			"Index", "Build", "Size", "Avg ms", "P95 ms", "QPS", "Recall")
+		fmt.Fprintln(w, " ───────────────────────┼────────────┼────────────┼──────────┼──────────┼────────────┼─────────")
+
+		for _, b := range builds {
+			if b.Error != "" {
+				fmt.Fprintf(w, " %-22s │ FAILED: %s\n", b.Index, b.Error)
+				continue
+			}
+			recall := "-"
+			if b.Queries > 0 {
+				recall = fmt.Sprintf("%.1f%%", b.Recall*100)
+			}
+			fmt.Fprintf(w, " %-22s │ %-10s │ %-10s │ %-8.2f │ %-8.2f │ %-10s │ %-8s\n",
+				b.Index, b.BuildTime.Round(time.Millisecond), formatBytes(b.SizeBytes),
+				b.AvgLatencyMs, b.P95LatencyMs, formatFloat(b.QPS), recall)
+		}
+		if builds[0].Queries > 0 {
+			fmt.Fprintf(w, "\n Post-build latency and recall@%d over %d queries with default search settings.\n",
+				builds[0].K, builds[0].Queries)
+		}
+	}
+
+	// Filtered and hybrid vector search per selectivity bucket
+	if filtered := m.GetFilteredSearch(); len(filtered) > 0 {
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "FILTERED VECTOR SEARCH")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintf(w, " %-7s │ %-11s │ %-14s │ %-8s │ %-8s │ %-8s │ %-9s │ %-6s\n",
+			"Mode", "Selectivity", "Iterative", "Recall", "Avg ms", "P95 ms", "QPS", "Short")
+		fmt.Fprintln(w, " ────────┼─────────────┼────────────────┼──────────┼──────────┼──────────┼───────────┼───────")
+
+		for _, r := range filtered {
+			iterative := r.IterativeScan
+			if iterative == "" {
+				iterative = "n/a"
+			}
+			fmt.Fprintf(w, " %-7s │ %-11s │ %-14s │ %-8s │ %-8.2f │ %-8.2f │ %-9s │ %-6s\n",
+				r.Mode, fmt.Sprintf("%.2f%%", r.Selectivity*100), iterative,
+				fmt.Sprintf("%.1f%%", r.Recall*100), r.AvgLatencyMs, r.P95LatencyMs,
+				formatFloat(r.QPS), formatNumber(r.ShortResults))
+		}
+		fmt.Fprintf(w, "\n Recall@%d over %d queries per bucket, filtering on %s. Short = queries returning fewer than %d rows.\n",
+			filtered[0].K, filtered[0].Queries, filtered[0].FilterColumn, filtered[0].K)
+	}
+
+	// Vector storage variants compared side by side
+	if quantization := m.GetQuantization(); len(quantization) > 0 {
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "VECTOR QUANTIZATION")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintf(w, " %-12s │ %-10s │ %-10s │ %-10s │ %-8s │ %-8s │ %-8s │ %-9s\n",
+			"Variant", "Storage", "Index", "Build", "Recall", "Avg ms", "P95 ms", "QPS")
+		fmt.Fprintln(w, " ─────────────┼────────────┼────────────┼────────────┼──────────┼──────────┼──────────┼──────────")
+
+		for _, q := range quantization {
+			if q.Error != "" {
+				fmt.Fprintf(w, " %-12s │ FAILED: %s\n", q.Variant, q.Error)
+				continue
+			}
+			fmt.Fprintf(w, " %-12s │ %-10s │ %-10s │ %-10s │ %-8s │ %-8.2f │ %-8.2f │ %-9s\n",
+				q.Variant, formatBytes(q.StorageBytes), formatBytes(q.IndexSizeBytes),
+				q.BuildTime.Round(time.Millisecond), fmt.Sprintf("%.1f%%", q.Recall*100),
+				q.AvgLatencyMs, q.P95LatencyMs, formatFloat(q.QPS))
+		}
+		fmt.Fprintf(w, "\n Recall@%d over %d queries against exact full-precision neighbours, index %s.\n",
+			quantization[0].K, quantization[0].Queries, quantization[0].Index)
+	}
+
+	// Vector search recall vs throughput
+	if accuracy := m.GetVectorAccuracy(); len(accuracy) > 0 {
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "VECTOR SEARCH ACCURACY")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintf(w, " %-22s │ %-16s │ %-8s │ %-10s │ %-8s │ %-8s │ %-8s\n",
+			"Index", "Search", "Recall", "QPS", "Avg ms", "P95 ms", "Build")
+		fmt.Fprintln(w, " ───────────────────────┼──────────────────┼──────────┼────────────┼──────────┼──────────┼─────────")
+
+		frontier := VectorAccuracyFrontier(accuracy)
+		for i, r := range accuracy {
+			marker := " "
+			if frontier[i] {
+				marker = "*"
+			}
+			fmt.Fprintf(w, "%s%-22s │ %-16s │ %-8s │ %-10s │ %-8.2f │ %-8.2f │ %-8s\n",
+				marker, r.Index, fmt.Sprintf("%s=%d", r.SearchParam, r.SearchValue),
+				fmt.Sprintf("%.1f%%", r.Recall*100), formatFloat(r.QPS),
+				r.AvgLatencyMs, r.P95LatencyMs, r.BuildTime.Round(time.Millisecond))
+		}
+
+		groundTruth := accuracy[0].GroundTruth
+		if groundTruth == "" {
+			groundTruth = "brute force"
+		}
+		fmt.Fprintf(w, "\n Recall@%d over %d queries against exact neighbours (%s).\n",
+			accuracy[0].K, accuracy[0].Queries, groundTruth)
+		fmt.Fprintln(w, " * marks the recall-vs-QPS Pareto frontier:")
+		pareto := make([]types.VectorAccuracyResult, 0, len(accuracy))
+		for i, r := range accuracy {
+			if frontier[i] {
+				pareto = append(pareto, r)
+			}
+		}
+		sort.Slice(pareto, func(i, j int) bool { return pareto[i].Recall < pareto[j].Recall })
+		for _, r := range pareto {
+			fmt.Fprintf(w, "   %5.1f%% recall at %10s QPS  (%s, %s=%d)\n",
+				r.Recall*100, formatFloat(r.QPS), r.Index, r.SearchParam, r.SearchValue)
 		}
 	}
 
 	// 9. POSTGRESQL STATISTICS
 	if pgStats := m.GetPgStats(); pgStats != nil && !pgStats.LastUpdated.IsZero() {
-		fmt.Println("\n-------------------------------------------------------------------------------")
-		fmt.Println("9. POSTGRESQL STATISTICS")
-		fmt.Println("-------------------------------------------------------------------------------")
-		fmt.Println(" Metric                          │ Value")
-		fmt.Println(" ─────────────────────────────── ┼ ────────────────────────────────────────────")
+		fmt.Fprintln(w, "\n-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "9. POSTGRESQL STATISTICS")
+		fmt.Fprintln(w, "-------------------------------------------------------------------------------")
+		fmt.Fprintln(w, " Metric                          │ Value")
+		fmt.Fprintln(w, " ─────────────────────────────── ┼ ────────────────────────────────────────────")
 
 		// Buffer cache statistics
-		fmt.Printf(" Buffer Cache Hit Ratio          │ %.1f%% (higher is better)\n", pgStats.BufferCacheHitRatio)
-		fmt.Printf(" Blocks Read (disk)              │ %s (cache misses)\n", formatLargeNumber(pgStats.BlocksRead))
-		fmt.Printf(" Blocks Hit (cache)              │ %s (cache hits)\n", formatLargeNumber(pgStats.BlocksHit))
-		fmt.Printf(" Blocks Written (bgwriter)       │ %s (background writer)\n", formatLargeNumber(pgStats.BlocksWritten))
+		fmt.Fprintf(w, " Buffer Cache Hit Ratio          │ %.1f%% (higher is better)\n", pgStats.BufferCacheHitRatio)
+		fmt.Fprintf(w, " Blocks Read (disk)              │ %s (cache misses)\n", formatLargeNumber(pgStats.BlocksRead))
+		fmt.Fprintf(w, " Blocks Hit (cache)              │ %s (cache hits)\n", formatLargeNumber(pgStats.BlocksHit))
+		fmt.Fprintf(w, " Blocks Written (bgwriter)       │ %s (background writer)\n", formatLargeNumber(pgStats.BlocksWritten))
 
 		// WAL statistics
 		if pgStats.WALRecords > 0 || pgStats.WALBytes > 0 {
-			fmt.Printf(" WAL Records                     │ %s (transaction log entries)\n", formatLargeNumber(pgStats.WALRecords))
-			fmt.Printf(" WAL Bytes                       │ %s (transaction log size)\n", formatBytes(pgStats.WALBytes))
+			fmt.Fprintf(w, " WAL Records                     │ %s (transaction log entries)\n", formatLargeNumber(pgStats.WALRecords))
+			fmt.Fprintf(w, " WAL Bytes                       │ %s (transaction log size)\n", formatBytes(pgStats.WALBytes))
 		}
 
 		// Checkpoint statistics
-		fmt.Printf(" Checkpoints (requested)         │ %s (manual checkpoints)\n", formatLargeNumber(pgStats.CheckpointsReq))
-		fmt.Printf(" Checkpoints (timed)             │ %s (automatic checkpoints)\n", formatLargeNumber(pgStats.CheckpointsTimed))
+		fmt.Fprintf(w, " Checkpoints (requested)         │ %s (manual checkpoints)\n", formatLargeNumber(pgStats.CheckpointsReq))
+		fmt.Fprintf(w, " Checkpoints (timed)             │ %s (automatic checkpoints)\n", formatLargeNumber(pgStats.CheckpointsTimed))
 
 		// Temporary files (spilling to disk)
 		if pgStats.TempFiles > 0 {
-			fmt.Printf(" Temporary Files Created         │ %s (work_mem exceeded)\n", formatLargeNumber(pgStats.TempFiles))
+			fmt.Fprintf(w, " Temporary Files Created         │ %s (work_mem exceeded)\n", formatLargeNumber(pgStats.TempFiles))
 			if pgStats.TempBytes > 0 {
-				fmt.Printf(" Temporary Bytes                 │ %s (spilled to disk)\n", formatBytes(pgStats.TempBytes))
+				fmt.Fprintf(w, " Temporary Bytes                 │ %s (spilled to disk)\n", formatBytes(pgStats.TempBytes))
 			}
 		}
 
 		// Locking and contention
 		if pgStats.Deadlocks > 0 {
-			fmt.Printf(" Deadlocks                       │ %s (concurrency conflicts)\n", formatLargeNumber(pgStats.Deadlocks))
+			fmt.Fprintf(w, " Deadlocks                       │ %s (concurrency conflicts)\n", formatLargeNumber(pgStats.Deadlocks))
 		}
 		if pgStats.LockWaitCount > 0 {
-			fmt.Printf(" Lock Wait Events                │ %s (contention indicators)\n", formatLargeNumber(pgStats.LockWaitCount))
+			fmt.Fprintf(w, " Lock Wait Events                │ %s (contention indicators)\n", formatLargeNumber(pgStats.LockWaitCount))
 		}
 
 		// Connection statistics
-		fmt.Printf(" Active Connections              │ %d / %d (%.1f%% utilization)\n",
+		fmt.Fprintf(w, " Active Connections              │ %d / %d (%.1f%% utilization)\n",
 			pgStats.ActiveConnections, pgStats.MaxConnections,
 			float64(pgStats.ActiveConnections)/float64(pgStats.MaxConnections)*100.0)
 
 		// Autovacuum statistics
 		if pgStats.AutovacuumCount > 0 {
-			fmt.Printf(" Autovacuum Processes            │ %s (maintenance operations)\n", formatLargeNumber(pgStats.AutovacuumCount))
+			fmt.Fprintf(w, " Autovacuum Processes            │ %s (maintenance operations)\n", formatLargeNumber(pgStats.AutovacuumCount))
 		}
 
 		// Add explanation for workload-specific statistics
-		fmt.Println()
-		fmt.Println(" Note: These statistics show precise changes during workload execution.")
-		fmt.Println(" Measured from workload start to completion, excluding setup/teardown activity.")
-
-		// pg_stat_statements top queries
-		if len(pgStats.TopQueries) > 0 {
-			fmt.Println("\n Top Queries by Execution Time:")
-			for i, query := range pgStats.TopQueries {
-				// Truncate long queries for display
-				displayQuery := query.Query
-				if len(displayQuery) > 60 {
-					displayQuery = displayQuery[:57] + "..."
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, " Note: These statistics show precise changes during workload execution.")
+		fmt.Fprintln(w, " Measured from workload start to completion, excluding setup/teardown activity.")
+
+		// pg_stat_statements deltas, ranked by each dimension
+		if len(pgStats.Statements) > 0 {
+			fmt.Fprintf(w, "\n Statements (pg_stat_statements deltas, top %d per dimension):\n", pgStats.StatementLimit)
+			for _, d := range types.StatementDimensions {
+				top := types.TopStatements(pgStats.Statements, d, pgStats.StatementLimit)
+				if len(top) == 0 {
+					continue
+				}
+				fmt.Fprintf(w, "\n  By %s:\n", d.Title)
+				for i, st := range top {
+					// Truncate long queries for display
+					displayQuery := strings.Join(strings.Fields(st.Query), " ")
+					if len(displayQuery) > 50 {
+						displayQuery = displayQuery[:47] + "..."
+					}
+					fmt.Fprintf(w, "   %2d. %-50s │ %s │ %s calls, %.2fms avg\n",
+						i+1, displayQuery, formatStatementValue(d.Name, d.Value(st)), formatNumber(st.Calls), st.MeanExecTime())
 				}
+			}
+		}
+
+		fmt.Fprintf(w, "\n Last Updated: %s\n", pgStats.LastUpdated.Format("15:04:05"))
+	}
 
-				fmt.Printf("   %d. %-58s │ %s calls, %.2fms avg\n",
-					i+1, displayQuery, formatNumber(query.Calls), query.MeanTime)
+	fmt.Fprintln(w, "\n===============================================================================")
+}
 
-				if query.HitPercent > 0 {
-					fmt.Printf("      %-58s │ %.1f%% cache hit ratio\n", "", query.HitPercent)
-				}
+// ReportExecModeComparison prints the results of a query execution mode
+// comparison run. Deltas are relative to the first successful mode, which
+// makes the simple protocol the baseline with the default mode order.
+func ReportExecModeComparison(results []types.ExecModeResult) {
+	fmt.Println("===============================================================================")
+	fmt.Println("                    StormDB Query Execution Mode Comparison")
+	fmt.Println("===============================================================================")
+	fmt.Printf(" %-16s │ %-10s │ %-10s │ %-8s │ %-9s │ %-9s │ %-9s │ %-9s\n",
+		"Mode", "TPS", "ΔTPS", "Errors", "Avg(ms)", "P95(ms)", "P99(ms)", "ΔAvg")
+	fmt.Println(" ─────────────────┼────────────┼────────────┼──────────┼───────────┼───────────┼───────────┼──────────")
+
+	var baseline *types.ExecModeResult
+	for i := range results {
+		r := &results[i]
+		if r.Error != "" {
+			fmt.Printf(" %-16s │ failed: %s\n", r.Mode, r.Error)
+			continue
+		}
+		if baseline == nil {
+			baseline = r
+		}
+
+		tpsDelta, latDelta := "baseline", "baseline"
+		if r != baseline {
+			tpsDelta = formatPercentDelta(r.TPS, baseline.TPS)
+			latDelta = formatPercentDelta(r.AvgLatencyMs, baseline.AvgLatencyMs)
+		}
+		fmt.Printf(" %-16s │ %-10s │ %-10s │ %-8s │ %-9.2f │ %-9.2f │ %-9.2f │ %-9s\n",
+			r.Mode, formatFloat(r.TPS), tpsDelta, formatNumber(r.Errors),
+			r.AvgLatencyMs, r.P95LatencyMs, r.P99LatencyMs, latDelta)
+	}
+
+	if baseline != nil {
+		best := baseline
+		for i := range results {
+			if results[i].Error == "" && results[i].TPS > best.TPS {
+				best = &results[i]
 			}
 		}
+		fmt.Printf("\n Fastest mode: %s (%s TPS)\n", best.Mode, formatFloat(best.TPS))
+		fmt.Println(" Note: cache_statement and prepared use named server-side statements, which need")
+		fmt.Println("       prepared statement support in the pooler under transaction pooling")
+	}
 
-		fmt.Printf("\n Last Updated: %s\n", pgStats.LastUpdated.Format("15:04:05"))
+	fmt.Println("===============================================================================")
+}
+
+// VectorAccuracyFrontier reports which results lie on the recall-vs-QPS
+// Pareto frontier, i.e. no other result has both higher-or-equal recall and
+// higher-or-equal QPS with at least one of them strictly higher
+func VectorAccuracyFrontier(results []types.VectorAccuracyResult) []bool {
+	frontier := make([]bool, len(results))
+	for i, r := range results {
+		frontier[i] = r.Queries > 0
+		for j, o := range results {
+			if i == j || o.Queries == 0 {
+				continue
+			}
+			if o.Recall >= r.Recall && o.QPS >= r.QPS && (o.Recall > r.Recall || o.QPS > r.QPS) {
+				frontier[i] = false
+				break
+			}
+		}
 	}
+	return frontier
+}
 
-	fmt.Println("\n===============================================================================")
+// hasFailure reports whether any endpoint recorded the failure category
+func hasFailure(failures map[string]map[string]int64, category string) bool {
+	for _, categories := range failures {
+		if categories[category] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// formatPercentDelta formats the relative change of value against base
+func formatPercentDelta(value, base float64) string {
+	if base == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.1f%%", (value-base)/base*100.0)
 }
 
 func parseDuration(d string) float64 {
@@ -677,6 +1212,22 @@ func formatNumber(n int64) string {
 	return result
 }
 
+// serverDescription returns the short server version with its memory and
+// WAL sizing, e.g. "PostgreSQL 16.2, shared_buffers 128MB, max_wal_size 1GB"
+func serverDescription(md *types.RunMetadata) string {
+	version := md.ServerVersion
+	if i := strings.Index(version, " on "); i > 0 {
+		version = version[:i]
+	}
+	parts := []string{version}
+	for _, name := range []string{"shared_buffers", "max_wal_size"} {
+		if v, ok := md.Settings[name]; ok {
+			parts = append(parts, name+" "+v)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
 // formatBytes formats byte values in human-readable units (B, KB, MB, GB, TB)
 func formatBytes(bytes int64) string {
 	if bytes == 0 {
@@ -790,3 +1341,25 @@ func calculateCoV(values []float64) float64 {
 	// Return coefficient of variation
 	return stddev / mean
 }
+
+// formatStatementValue formats the value of a statement ranking dimension
+func formatStatementValue(dimension string, value float64) string {
+	switch dimension {
+	case "total_time", "mean_time", "plan_time":
+		return fmt.Sprintf("%10.2fms", value)
+	case "wal_bytes":
+		return fmt.Sprintf("%12s", formatBytes(int64(value)))
+	default:
+		return fmt.Sprintf("%12s", formatNumber(int64(value)))
+	}
+}
+
+// truncatePlanShape shortens long plan shapes for the text report; the full
+// plans are in the plan file
+func truncatePlanShape(shape string) string {
+	const maxLen = 100
+	if runes := []rune(shape); len(runes) > maxLen {
+		return string(runes[:maxLen-1]) + "…"
+	}
+	return shape
+}
@@ -0,0 +1,427 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// Output formats of the final report
+const (
+	OutputText  = "text"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+	OutputJUnit = "junit"
+)
+
+// OutputFormats lists the supported output formats
+var OutputFormats = []string{OutputText, OutputJSON, OutputCSV, OutputJUnit}
+
+// ValidateOutputFormat checks that format is a supported output format
+func ValidateOutputFormat(format string) error {
+	for _, f := range OutputFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format %q (use %s)", format, strings.Join(OutputFormats, ", "))
+}
+
+// WriteSummary writes s to w in a machine-readable format (json, csv or junit)
+func WriteSummary(w io.Writer, format string, s *RunSummary) error {
+	switch format {
+	case OutputJSON:
+		return WriteSummaryJSON(w, s)
+	case OutputCSV:
+		return WriteSummaryCSV(w, s)
+	case OutputJUnit:
+		return WriteSummaryJUnit(w, s)
+	default:
+		return fmt.Errorf("format %q is not a machine-readable summary format", format)
+	}
+}
+
+// WriteSummaryJSON writes s as an indented JSON document
+func WriteSummaryJSON(w io.Writer, s *RunSummary) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// csvHeader is the header of the CSV summary. Every row is one value, so new
+// metrics add rows rather than columns.
+var csvHeader = []string{"schema_version", "section", "key", "metric", "value"}
+
+// WriteSummaryCSV writes s in long format: one row per section, key and
+// metric. The key identifies the item within a section, such as a worker id,
+// a statement type or a bucket offset, and is empty for run-level values.
+func WriteSummaryCSV(w io.Writer, s *RunSummary) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	row := func(section, key, metric string, value interface{}) {
+		var v string
+		switch val := value.(type) {
+		case float64:
+			v = strconv.FormatFloat(val, 'f', -1, 64)
+		case time.Time:
+			v = val.UTC().Format(time.RFC3339Nano)
+		default:
+			v = fmt.Sprint(val)
+		}
+		_ = cw.Write([]string{s.SchemaVersion, section, key, metric, v})
+	}
+
+	row("run", "", "stormdb_version", s.StormDBVersion)
+	row("run", "", "workload", s.Workload)
+	row("run", "", "status", s.Status)
+	row("run", "", "error", s.Error)
+	row("run", "", "start_time", s.StartTime)
+	row("run", "", "end_time", s.EndTime)
+	row("run", "", "duration_seconds", s.DurationSeconds)
+	row("run", "", "workers", s.Workers)
+	row("run", "", "connections", s.Connections)
+	row("run", "", "scale", s.Scale)
+	row("run", "", "key_distribution", s.KeyDistribution)
+
+	if md := s.Metadata; md != nil {
+		row("metadata", "server", "version", md.ServerVersion)
+		row("metadata", "server", "version_num", md.ServerVersionNum)
+		row("metadata", "client", "version", md.ClientVersion)
+		row("metadata", "client", "host", md.ClientHost)
+		row("metadata", "client", "os", md.ClientOS)
+		row("metadata", "client", "cpus", md.ClientCPUs)
+		row("metadata", "client", "go_version", md.GoVersion)
+		row("metadata", "plugin", "name", md.Plugin)
+		row("metadata", "plugin", "version", md.PluginVersion)
+		for _, name := range sortedKeys(md.Settings) {
+			row("metadata", "setting", name, md.Settings[name])
+		}
+		for _, name := range sortedKeys(md.Extensions) {
+			row("metadata", "extension", name, md.Extensions[name])
+		}
+	}
+
+	t := s.Transactions
+	row("transactions", "", "total", t.Total)
+	row("transactions", "", "committed", t.Committed)
+	row("transactions", "", "aborted", t.Aborted)
+	row("transactions", "", "tps", t.TPS)
+	row("transactions", "", "aborted_tps", t.AbortedTPS)
+	row("transactions", "", "success_rate_pct", t.SuccessRate)
+
+	row("queries", "", "total", s.Queries.Total)
+	row("queries", "", "qps", s.Queries.QPS)
+	for _, q := range s.Queries.Mix {
+		row("query_mix", q.Type, "count", q.Count)
+		row("query_mix", q.Type, "qps", q.QPS)
+		row("query_mix", q.Type, "percent", q.Percent)
+	}
+
+	row("rows", "", "read", s.Rows.Read)
+	row("rows", "", "modified", s.Rows.Modified)
+	row("rows", "", "read_per_sec", s.Rows.ReadPerSec)
+	row("rows", "", "modified_per_sec", s.Rows.ModifiedPerSec)
+
+	l := s.Latency
+	row("latency", "", "samples", l.Samples)
+	row("latency", "", "min_ms", l.MinMs)
+	row("latency", "", "max_ms", l.MaxMs)
+	row("latency", "", "avg_ms", l.AvgMs)
+	row("latency", "", "stddev_ms", l.StdDevMs)
+	row("latency", "", "p50_ms", l.P50Ms)
+	row("latency", "", "p90_ms", l.P90Ms)
+	row("latency", "", "p95_ms", l.P95Ms)
+	row("latency", "", "p99_ms", l.P99Ms)
+	row("latency", "", "p99_9_ms", l.P999Ms)
+	for _, b := range l.Histogram {
+		row("latency_histogram", b.Bucket, "count", b.Count)
+	}
+
+	row("errors", "", "total", s.Errors.Total)
+	for _, e := range s.Errors.Types {
+		row("error_types", e.Type, "count", e.Count)
+	}
+
+	for _, ws := range s.WorkerStats {
+		key := strconv.Itoa(ws.WorkerID)
+		row("workers", key, "transactions", ws.Transactions)
+		row("workers", key, "aborted", ws.Aborted)
+		row("workers", key, "queries", ws.Queries)
+		row("workers", key, "errors", ws.Errors)
+		row("workers", key, "tps", ws.TPS)
+		row("workers", key, "qps", ws.QPS)
+		row("workers", key, "success_rate_pct", ws.SuccessRate)
+		row("workers", key, "p50_ms", ws.P50Ms)
+		row("workers", key, "p95_ms", ws.P95Ms)
+		row("workers", key, "avg_ms", ws.AvgMs)
+	}
+
+	for _, op := range s.Operations {
+		row("operations", op.Name, "count", op.Count)
+		row("operations", op.Name, "errors", op.Errors)
+		row("operations", op.Name, "rows", op.Rows)
+		row("operations", op.Name, "ops", op.OPS)
+		row("operations", op.Name, "error_rate_pct", op.ErrorRate)
+		row("operations", op.Name, "min_ms", op.MinMs)
+		row("operations", op.Name, "max_ms", op.MaxMs)
+		row("operations", op.Name, "avg_ms", op.AvgMs)
+		row("operations", op.Name, "p50_ms", op.P50Ms)
+		row("operations", op.Name, "p95_ms", op.P95Ms)
+		row("operations", op.Name, "p99_ms", op.P99Ms)
+	}
+
+	for _, b := range s.TimeSeries {
+		key := strconv.FormatFloat(b.OffsetSeconds, 'f', 3, 64)
+		row("time_series", key, "start_time", b.StartTime)
+		row("time_series", key, "seconds", b.Seconds)
+		row("time_series", key, "transactions", b.Transactions)
+		row("time_series", key, "queries", b.Queries)
+		row("time_series", key, "errors", b.Errors)
+		row("time_series", key, "tps", b.TPS)
+		row("time_series", key, "qps", b.QPS)
+		row("time_series", key, "rows_read", b.RowsRead)
+		row("time_series", key, "rows_modified", b.RowsModified)
+		row("time_series", key, "p50_ms", b.P50Ms)
+		row("time_series", key, "p95_ms", b.P95Ms)
+		row("time_series", key, "p99_ms", b.P99Ms)
+		if len(b.Events) > 0 {
+			row("time_series", key, "events", strings.Join(b.Events, "; "))
+		}
+		if h := b.Host; h != nil {
+			row("time_series", key, "client_cpu_busy_pct", h.ClientCPUBusyPct)
+			row("time_series", key, "client_cpu_steal_pct", h.ClientCPUStealPct)
+			row("time_series", key, "client_run_queue", h.ClientRunQueue)
+			row("time_series", key, "client_net_rx_bytes_per_sec", h.ClientNetRxBytesPerSec)
+			row("time_series", key, "client_net_tx_bytes_per_sec", h.ClientNetTxBytesPerSec)
+			row("time_series", key, "client_saturated", h.ClientSaturated)
+			row("time_series", key, "server_io_reads_per_sec", h.ServerIOReadsPerSec)
+			row("time_series", key, "server_io_writes_per_sec", h.ServerIOWritesPerSec)
+			row("time_series", key, "server_wal_dir_bytes", h.ServerWALDirBytes)
+		}
+	}
+
+	if pg := s.PgStats; pg != nil {
+		row("pg_stats", "", "buffer_cache_hit_ratio_pct", pg.BufferCacheHitRatio)
+		row("pg_stats", "", "blocks_read", pg.BlocksRead)
+		row("pg_stats", "", "blocks_hit", pg.BlocksHit)
+		row("pg_stats", "", "blocks_written", pg.BlocksWritten)
+		row("pg_stats", "", "wal_records", pg.WALRecords)
+		row("pg_stats", "", "wal_bytes", pg.WALBytes)
+		row("pg_stats", "", "checkpoints_requested", pg.CheckpointsReq)
+		row("pg_stats", "", "checkpoints_timed", pg.CheckpointsTimed)
+		row("pg_stats", "", "temp_files", pg.TempFiles)
+		row("pg_stats", "", "temp_bytes", pg.TempBytes)
+		row("pg_stats", "", "deadlocks", pg.Deadlocks)
+		row("pg_stats", "", "lock_waits", pg.LockWaitCount)
+		row("pg_stats", "", "active_connections", pg.ActiveConnections)
+		row("pg_stats", "", "max_connections", pg.MaxConnections)
+		row("pg_stats", "", "autovacuum_count", pg.AutovacuumCount)
+		for i, q := range pg.TopQueries {
+			key := strconv.Itoa(i + 1)
+			row("pg_top_queries", key, "query", q.Query)
+			row("pg_top_queries", key, "calls", q.Calls)
+			row("pg_top_queries", key, "total_ms", q.TotalMs)
+			row("pg_top_queries", key, "mean_ms", q.MeanMs)
+			row("pg_top_queries", key, "rows", q.Rows)
+			row("pg_top_queries", key, "hit_pct", q.HitPercent)
+		}
+		for _, st := range pg.Statements {
+			key := strconv.FormatInt(st.QueryID, 10)
+			row("pg_statements", key, "query", st.Query)
+			row("pg_statements", key, "calls", st.Calls)
+			row("pg_statements", key, "total_ms", st.TotalMs)
+			row("pg_statements", key, "mean_ms", st.MeanMs)
+			row("pg_statements", key, "plan_ms", st.PlanMs)
+			row("pg_statements", key, "rows", st.Rows)
+			row("pg_statements", key, "shared_blks_hit", st.SharedBlksHit)
+			row("pg_statements", key, "shared_blks_read", st.SharedBlksRead)
+			row("pg_statements", key, "shared_blks_dirtied", st.SharedBlksDirtied)
+			row("pg_statements", key, "shared_blks_written", st.SharedBlksWritten)
+			row("pg_statements", key, "local_blks_hit", st.LocalBlksHit)
+			row("pg_statements", key, "local_blks_read", st.LocalBlksRead)
+			row("pg_statements", key, "local_blks_written", st.LocalBlksWritten)
+			row("pg_statements", key, "wal_records", st.WALRecords)
+			row("pg_statements", key, "wal_bytes", st.WALBytes)
+			row("pg_statements", key, "hit_pct", st.HitPercent)
+		}
+		for _, d := range types.StatementDimensions {
+			for i, id := range pg.StatementRankings[d.Name] {
+				row("pg_statement_rankings", d.Name, strconv.Itoa(i+1), id)
+			}
+		}
+	}
+
+	if hs := s.HostStats; hs != nil {
+		row("host_stats", "", "cpu_threshold_pct", hs.CPUThresholdPct)
+		row("host_stats", "", "client_bound", hs.ClientBound)
+		for _, h := range hs.Clients {
+			row("host_stats", h.Host, "samples", h.Samples)
+			row("host_stats", h.Host, "cpus", h.CPUs)
+			row("host_stats", h.Host, "avg_cpu_busy_pct", h.AvgCPUBusyPct)
+			row("host_stats", h.Host, "max_cpu_busy_pct", h.MaxCPUBusyPct)
+			row("host_stats", h.Host, "avg_cpu_user_pct", h.AvgCPUUserPct)
+			row("host_stats", h.Host, "avg_cpu_system_pct", h.AvgCPUSystemPct)
+			row("host_stats", h.Host, "avg_cpu_iowait_pct", h.AvgCPUIOWaitPct)
+			row("host_stats", h.Host, "avg_cpu_steal_pct", h.AvgCPUStealPct)
+			row("host_stats", h.Host, "avg_run_queue", h.AvgRunQueue)
+			row("host_stats", h.Host, "max_run_queue", h.MaxRunQueue)
+			row("host_stats", h.Host, "avg_mem_used_pct", h.AvgMemUsedPct)
+			row("host_stats", h.Host, "max_mem_used_pct", h.MaxMemUsedPct)
+			row("host_stats", h.Host, "avg_net_rx_bytes_per_sec", h.AvgNetRxBytesPerSec)
+			row("host_stats", h.Host, "avg_net_tx_bytes_per_sec", h.AvgNetTxBytesPerSec)
+			row("host_stats", h.Host, "avg_context_switches_per_sec", h.AvgContextSwitchesPerSec)
+			row("host_stats", h.Host, "saturated_pct", h.SaturatedPct)
+			row("host_stats", h.Host, "client_bound", h.ClientBound)
+		}
+		if srv := hs.Server; srv != nil {
+			row("host_stats", "server", "samples", srv.Samples)
+			row("host_stats", "server", "avg_io_reads_per_sec", srv.AvgIOReadsPerSec)
+			row("host_stats", "server", "max_io_reads_per_sec", srv.MaxIOReadsPerSec)
+			row("host_stats", "server", "avg_io_writes_per_sec", srv.AvgIOWritesPerSec)
+			row("host_stats", "server", "max_io_writes_per_sec", srv.MaxIOWritesPerSec)
+			row("host_stats", "server", "avg_io_fsyncs_per_sec", srv.AvgIOFsyncsPerSec)
+			row("host_stats", "server", "max_wal_dir_bytes", srv.MaxWALDirBytes)
+			row("host_stats", "server", "last_wal_dir_bytes", srv.LastWALDirBytes)
+			row("host_stats", "server", "max_wal_files", srv.MaxWALFiles)
+		}
+	}
+
+	if qp := s.QueryPlans; qp != nil {
+		row("query_plans", "", "file", qp.File)
+		row("query_plans", "", "captured", qp.Captured)
+		row("query_plans", "", "changed", qp.Changed)
+		for i, p := range qp.Plans {
+			key := strconv.Itoa(i + 1)
+			row("query_plans", key, "operation", p.Operation)
+			row("query_plans", key, "fingerprint", p.Fingerprint)
+			row("query_plans", key, "reason", p.Reason)
+			row("query_plans", key, "latency_ms", p.LatencyMs)
+			row("query_plans", key, "execution_ms", p.ExecutionMs)
+			row("query_plans", key, "shape", p.Shape)
+			row("query_plans", key, "changed", p.Changed)
+			if p.Error != "" {
+				row("query_plans", key, "error", p.Error)
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// JUnit XML elements
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteSummaryJUnit writes s as a JUnit XML report with one test case for
+// the run. The case fails when the workload failed and is skipped when the
+// run was interrupted. Headline metrics are suite properties, and the JSON
+// summary is the case output.
+func WriteSummaryJUnit(w io.Writer, s *RunSummary) error {
+	var out strings.Builder
+	if err := WriteSummaryJSON(&out, s); err != nil {
+		return err
+	}
+
+	seconds := strconv.FormatFloat(s.DurationSeconds, 'f', 3, 64)
+	tc := junitTestCase{
+		Name:      s.Workload,
+		ClassName: "stormdb",
+		Time:      seconds,
+		SystemOut: out.String(),
+	}
+	suite := junitTestSuite{
+		Name:      "stormdb." + s.Workload,
+		Tests:     1,
+		Time:      seconds,
+		Timestamp: s.StartTime.UTC().Format(time.RFC3339),
+	}
+	switch s.Status {
+	case StatusFailed:
+		tc.Failure = &junitMessage{Message: "workload failed", Body: s.Error}
+		suite.Failures = 1
+	case StatusInterrupted:
+		tc.Skipped = &junitMessage{Message: "run was interrupted"}
+		suite.Skipped = 1
+	}
+	suite.Cases = []junitTestCase{tc}
+
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	suite.Properties = []junitProperty{
+		{"schema_version", s.SchemaVersion},
+		{"stormdb_version", s.StormDBVersion},
+		{"status", s.Status},
+		{"workers", strconv.Itoa(s.Workers)},
+		{"connections", strconv.Itoa(s.Connections)},
+		{"tps", f(s.Transactions.TPS)},
+		{"qps", f(s.Queries.QPS)},
+		{"success_rate_pct", f(s.Transactions.SuccessRate)},
+		{"errors", strconv.FormatInt(s.Errors.Total, 10)},
+		{"latency_p50_ms", f(s.Latency.P50Ms)},
+		{"latency_p95_ms", f(s.Latency.P95Ms)},
+		{"latency_p99_ms", f(s.Latency.P99Ms)},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
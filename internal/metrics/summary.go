@@ -0,0 +1,609 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/util"
+	"github.com/elchinoo/stormdb/pkg/keydist"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// SummarySchemaVersion identifies the layout of RunSummary. It changes when
+// fields are renamed, removed or change meaning; new fields may be added
+// without a version change.
+const SummarySchemaVersion = "1.0"
+
+// Run status values
+const (
+	StatusCompleted   = "completed"
+	StatusInterrupted = "interrupted"
+	StatusFailed      = "failed"
+)
+
+// RunSummary is the machine-readable result of a run
+type RunSummary struct {
+	SchemaVersion   string    `json:"schema_version"`
+	StormDBVersion  string    `json:"stormdb_version,omitempty"`
+	Workload        string    `json:"workload"`
+	Status          string    `json:"status"`
+	Error           string    `json:"error,omitempty"`
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Workers         int       `json:"workers"`
+	Connections     int       `json:"connections"`
+	Scale           int       `json:"scale"`
+	KeyDistribution string    `json:"key_distribution"`
+
+	Metadata *types.RunMetadata `json:"metadata,omitempty"` // Server settings and client environment
+
+	Transactions TransactionSummary `json:"transactions"`
+	Queries      QuerySummary       `json:"queries"`
+	Rows         RowSummary         `json:"rows"`
+	Latency      LatencySummary     `json:"latency"`
+	Errors       ErrorSummary       `json:"errors"`
+	WorkerStats  []WorkerSummary    `json:"worker_stats"`
+	Operations   []OperationSummary `json:"operations"`
+	TimeSeries   []TimeBucketStats  `json:"time_series"`
+	PgStats      *PgStatsSummary    `json:"pg_stats,omitempty"`
+	QueryPlans   *QueryPlanSummary  `json:"query_plans,omitempty"`
+	HostStats    *HostStatsSummary  `json:"host_stats,omitempty"`
+}
+
+// TransactionSummary holds transaction counts and rates
+type TransactionSummary struct {
+	Total       int64   `json:"total"`
+	Committed   int64   `json:"committed"`
+	Aborted     int64   `json:"aborted"`
+	TPS         float64 `json:"tps"`
+	AbortedTPS  float64 `json:"aborted_tps"`
+	SuccessRate float64 `json:"success_rate_pct"`
+}
+
+// QuerySummary holds query counts, rates and the mix by statement type
+type QuerySummary struct {
+	Total int64           `json:"total"`
+	QPS   float64         `json:"qps"`
+	Mix   []QueryTypeStat `json:"mix"`
+}
+
+// QueryTypeStat is the share of one statement type in the query mix
+type QueryTypeStat struct {
+	Type    string  `json:"type"`
+	Count   int64   `json:"count"`
+	QPS     float64 `json:"qps"`
+	Percent float64 `json:"percent"`
+}
+
+// RowSummary holds row counts and rates
+type RowSummary struct {
+	Read           int64   `json:"read"`
+	Modified       int64   `json:"modified"`
+	ReadPerSec     float64 `json:"read_per_sec"`
+	ModifiedPerSec float64 `json:"modified_per_sec"`
+}
+
+// LatencySummary holds transaction latency statistics in milliseconds
+type LatencySummary struct {
+	Samples   int               `json:"samples"`
+	MinMs     float64           `json:"min_ms"`
+	MaxMs     float64           `json:"max_ms"`
+	AvgMs     float64           `json:"avg_ms"`
+	StdDevMs  float64           `json:"stddev_ms"`
+	P50Ms     float64           `json:"p50_ms"`
+	P90Ms     float64           `json:"p90_ms"`
+	P95Ms     float64           `json:"p95_ms"`
+	P99Ms     float64           `json:"p99_ms"`
+	P999Ms    float64           `json:"p99_9_ms"`
+	Histogram []HistogramBucket `json:"histogram"`
+}
+
+// HistogramBucket counts latencies up to UpperMs; the last bucket has no upper bound
+type HistogramBucket struct {
+	Bucket  string   `json:"bucket"`
+	UpperMs *float64 `json:"upper_ms"`
+	Count   int64    `json:"count"`
+}
+
+// ErrorSummary holds the error count and its breakdown by type
+type ErrorSummary struct {
+	Total int64       `json:"total"`
+	Types []ErrorStat `json:"types"`
+}
+
+// ErrorStat counts one error type
+type ErrorStat struct {
+	Type  string `json:"type"`
+	Count int64  `json:"count"`
+}
+
+// WorkerSummary holds the results of one worker
+type WorkerSummary struct {
+	WorkerID     int     `json:"worker_id"`
+	Transactions int64   `json:"transactions"`
+	Aborted      int64   `json:"aborted"`
+	Queries      int64   `json:"queries"`
+	Errors       int64   `json:"errors"`
+	TPS          float64 `json:"tps"`
+	QPS          float64 `json:"qps"`
+	SuccessRate  float64 `json:"success_rate_pct"`
+	P50Ms        float64 `json:"p50_ms"`
+	P95Ms        float64 `json:"p95_ms"`
+	AvgMs        float64 `json:"avg_ms"`
+}
+
+// OperationSummary holds the results of one named workload operation.
+// Percentiles come from the operation's latency samples.
+type OperationSummary struct {
+	Name      string            `json:"name"`
+	Count     int64             `json:"count"`
+	Errors    int64             `json:"errors"`
+	Rows      int64             `json:"rows"`
+	OPS       float64           `json:"ops"`
+	ErrorRate float64           `json:"error_rate_pct"`
+	MinMs     float64           `json:"min_ms"`
+	MaxMs     float64           `json:"max_ms"`
+	AvgMs     float64           `json:"avg_ms"`
+	P50Ms     float64           `json:"p50_ms"`
+	P95Ms     float64           `json:"p95_ms"`
+	P99Ms     float64           `json:"p99_ms"`
+	Histogram []HistogramBucket `json:"histogram"`
+	Plans     []string          `json:"plan_fingerprints,omitempty"` // Statements with captured plans, see RunSummary.QueryPlans
+}
+
+// TimeBucketStats holds the results of one time-series bucket
+type TimeBucketStats struct {
+	StartTime     time.Time        `json:"start_time"`
+	OffsetSeconds float64          `json:"offset_seconds"`
+	Seconds       float64          `json:"seconds"`
+	Transactions  int64            `json:"transactions"`
+	Queries       int64            `json:"queries"`
+	Errors        int64            `json:"errors"`
+	TPS           float64          `json:"tps"`
+	QPS           float64          `json:"qps"`
+	RowsRead      int64            `json:"rows_read"`
+	RowsModified  int64            `json:"rows_modified"`
+	P50Ms         float64          `json:"p50_ms"`
+	P95Ms         float64          `json:"p95_ms"`
+	P99Ms         float64          `json:"p99_ms"`
+	Events        []string         `json:"events,omitempty"`
+	Host          *BucketHostStats `json:"host,omitempty"`
+}
+
+// PgStatsSummary holds the PostgreSQL statistics deltas of the run
+type PgStatsSummary struct {
+	BufferCacheHitRatio float64         `json:"buffer_cache_hit_ratio_pct"`
+	BlocksRead          int64           `json:"blocks_read"`
+	BlocksHit           int64           `json:"blocks_hit"`
+	BlocksWritten       int64           `json:"blocks_written"`
+	WALRecords          int64           `json:"wal_records"`
+	WALBytes            int64           `json:"wal_bytes"`
+	CheckpointsReq      int64           `json:"checkpoints_requested"`
+	CheckpointsTimed    int64           `json:"checkpoints_timed"`
+	TempFiles           int64           `json:"temp_files"`
+	TempBytes           int64           `json:"temp_bytes"`
+	Deadlocks           int64           `json:"deadlocks"`
+	LockWaitCount       int64           `json:"lock_waits"`
+	ActiveConnections   int             `json:"active_connections"`
+	MaxConnections      int             `json:"max_connections"`
+	AutovacuumCount     int64           `json:"autovacuum_count"`
+	TopQueries          []TopQueryStats `json:"top_queries,omitempty"`
+
+	// pg_stat_statements deltas of the workload, ordered by total execution
+	// time, and the queryids of the top statements of each ranking dimension
+	Statements        []StatementSummary `json:"statements,omitempty"`
+	StatementRankings map[string][]int64 `json:"statement_rankings,omitempty"`
+}
+
+// StatementSummary is the change of one pg_stat_statements entry during the
+// workload
+type StatementSummary struct {
+	QueryID           int64   `json:"queryid"`
+	Query             string  `json:"query"`
+	Calls             int64   `json:"calls"`
+	TotalMs           float64 `json:"total_ms"`
+	MeanMs            float64 `json:"mean_ms"`
+	PlanMs            float64 `json:"plan_ms"`
+	Rows              int64   `json:"rows"`
+	SharedBlksHit     int64   `json:"shared_blks_hit"`
+	SharedBlksRead    int64   `json:"shared_blks_read"`
+	SharedBlksDirtied int64   `json:"shared_blks_dirtied"`
+	SharedBlksWritten int64   `json:"shared_blks_written"`
+	LocalBlksHit      int64   `json:"local_blks_hit"`
+	LocalBlksRead     int64   `json:"local_blks_read"`
+	LocalBlksWritten  int64   `json:"local_blks_written"`
+	WALRecords        int64   `json:"wal_records"`
+	WALBytes          int64   `json:"wal_bytes"`
+	HitPercent        float64 `json:"hit_pct"`
+}
+
+// TopQueryStats is one pg_stat_statements entry
+type TopQueryStats struct {
+	Query      string  `json:"query"`
+	Calls      int64   `json:"calls"`
+	TotalMs    float64 `json:"total_ms"`
+	MeanMs     float64 `json:"mean_ms"`
+	Rows       int64   `json:"rows"`
+	HitPercent float64 `json:"hit_pct"`
+}
+
+// QueryPlanSummary lists the query plans captured during the run. The
+// plans themselves are in File.
+type QueryPlanSummary struct {
+	File     string        `json:"file,omitempty"`
+	Captured int           `json:"captured"`
+	Changed  int           `json:"changed"`
+	Plans    []PlanSummary `json:"plans"`
+}
+
+// PlanSummary describes one captured plan
+type PlanSummary struct {
+	Operation     string  `json:"operation"`
+	Fingerprint   string  `json:"fingerprint"`
+	Query         string  `json:"query"`
+	Reason        string  `json:"reason"`
+	LatencyMs     float64 `json:"latency_ms"`
+	ExecutionMs   float64 `json:"execution_ms"`
+	Shape         string  `json:"shape"`
+	Changed       bool    `json:"changed"`
+	PreviousShape string  `json:"previous_shape,omitempty"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// BuildSummary collects the results of a run into a RunSummary. Rates are
+// per second of the actual run time between start and end. runErr is the
+// workload error, if any.
+func BuildSummary(cfg *types.Config, m *types.Metrics, interrupted bool, runErr error, start, end time.Time) *RunSummary {
+	elapsed := end.Sub(start).Seconds()
+	if elapsed <= 0 {
+		elapsed = parseDuration(cfg.Duration)
+	}
+	rate := func(n int64) float64 { return float64(n) / elapsed }
+
+	s := &RunSummary{
+		SchemaVersion:   SummarySchemaVersion,
+		Workload:        cfg.Workload,
+		Status:          StatusCompleted,
+		StartTime:       start,
+		EndTime:         end,
+		DurationSeconds: elapsed,
+		Workers:         cfg.Workers,
+		Connections:     cfg.Connections,
+		Scale:           cfg.Scale,
+		KeyDistribution: keydist.ConfigFrom(cfg).String(),
+	}
+	switch {
+	case interrupted:
+		s.Status = StatusInterrupted
+	case runErr != nil:
+		s.Status = StatusFailed
+		s.Error = runErr.Error()
+	}
+
+	// Transactions
+	s.Transactions = TransactionSummary{
+		Total:       m.TPS + m.TPSAborted,
+		Committed:   m.TPS,
+		Aborted:     m.TPSAborted,
+		TPS:         rate(m.TPS),
+		AbortedTPS:  rate(m.TPSAborted),
+		SuccessRate: 100.0,
+	}
+	if s.Transactions.Total > 0 {
+		s.Transactions.SuccessRate = float64(m.TPS) / float64(s.Transactions.Total) * 100.0
+	}
+
+	// Queries and their mix
+	s.Queries = QuerySummary{Total: m.QPS, QPS: rate(m.QPS), Mix: []QueryTypeStat{}}
+	typed := m.SelectQueries + m.InsertQueries + m.UpdateQueries + m.DeleteQueries
+	for _, q := range []struct {
+		name  string
+		count int64
+	}{
+		{"select", m.SelectQueries},
+		{"insert", m.InsertQueries},
+		{"update", m.UpdateQueries},
+		{"delete", m.DeleteQueries},
+	} {
+		stat := QueryTypeStat{Type: q.name, Count: q.count, QPS: rate(q.count)}
+		if typed > 0 {
+			stat.Percent = float64(q.count) / float64(typed) * 100.0
+		}
+		s.Queries.Mix = append(s.Queries.Mix, stat)
+	}
+
+	s.Rows = RowSummary{
+		Read:           m.RowsRead,
+		Modified:       m.RowsModified,
+		ReadPerSec:     rate(m.RowsRead),
+		ModifiedPerSec: rate(m.RowsModified),
+	}
+
+	m.Mu.Lock()
+	latencies := append([]int64(nil), m.TransactionDur...)
+	histogram := make(map[string]int64, len(m.LatencyHistogram))
+	for bucket, count := range m.LatencyHistogram {
+		histogram[bucket] = count
+	}
+	s.Errors = ErrorSummary{Total: m.Errors, Types: []ErrorStat{}}
+	for errType, count := range m.ErrorTypes {
+		s.Errors.Types = append(s.Errors.Types, ErrorStat{Type: errType, Count: count})
+	}
+	m.Mu.Unlock()
+
+	sort.Slice(s.Errors.Types, func(i, j int) bool {
+		if s.Errors.Types[i].Count != s.Errors.Types[j].Count {
+			return s.Errors.Types[i].Count > s.Errors.Types[j].Count
+		}
+		return s.Errors.Types[i].Type < s.Errors.Types[j].Type
+	})
+
+	s.Latency = summarizeLatency(latencies, histogram)
+	s.WorkerStats = summarizeWorkers(m, elapsed)
+	s.Operations = summarizeOperations(m, elapsed)
+	s.TimeSeries = summarizeTimeSeries(m)
+	s.PgStats = summarizePgStats(m)
+	s.QueryPlans = summarizeQueryPlans(m)
+	s.HostStats = summarizeHostStats(cfg, m, s.TimeSeries)
+	s.Metadata = m.GetRunMetadata()
+	for i := range s.Operations {
+		s.Operations[i].Plans = planFingerprints(s.QueryPlans, s.Operations[i].Name)
+	}
+	return s
+}
+
+// summarizeLatency computes latency statistics from nanosecond samples
+func summarizeLatency(latencies []int64, histogram map[string]int64) LatencySummary {
+	ls := LatencySummary{Samples: len(latencies), Histogram: []HistogramBucket{}}
+	if len(latencies) > 0 {
+		// CalculatePercentiles uses integer percentiles, so P99.9 is taken directly
+		pvals := util.CalculatePercentiles(latencies, []int{50, 90, 95, 99})
+		ls.P50Ms = float64(pvals[0]) / 1e6
+		ls.P90Ms = float64(pvals[1]) / 1e6
+		ls.P95Ms = float64(pvals[2]) / 1e6
+		ls.P99Ms = float64(pvals[3]) / 1e6
+		ls.P999Ms = float64(latencies[len(latencies)*999/1000]) / 1e6
+
+		avg, minVal, maxVal, stddev := util.Stats(latencies)
+		ls.AvgMs = float64(avg) / 1e6
+		ls.MinMs = float64(minVal) / 1e6
+		ls.MaxMs = float64(maxVal) / 1e6
+		ls.StdDevMs = float64(stddev) / 1e6
+	}
+
+	for _, upper := range types.LatencyBuckets {
+		upper := upper
+		name := fmt.Sprintf("%.1fms", upper) // Bucket names of types.GetLatencyBucket
+		ls.Histogram = append(ls.Histogram, HistogramBucket{Bucket: name, UpperMs: &upper, Count: histogram[name]})
+	}
+	ls.Histogram = append(ls.Histogram, HistogramBucket{Bucket: "+inf", Count: histogram["+inf"]})
+	return ls
+}
+
+// summarizeWorkers returns per-worker results ordered by worker id
+func summarizeWorkers(m *types.Metrics, elapsed float64) []WorkerSummary {
+	workers := []WorkerSummary{}
+	for workerID, w := range m.WorkerMetrics {
+		w.Mu.Lock()
+		tps, aborted, qps, errs := w.TPS, w.TPSAborted, w.QPS, w.Errors
+		latencies := append([]int64(nil), w.TransactionDur...)
+		w.Mu.Unlock()
+
+		stats := util.CalculateWorkerStats(workerID, tps, aborted, qps, errs, latencies, elapsed)
+		workers = append(workers, WorkerSummary{
+			WorkerID:     workerID,
+			Transactions: tps,
+			Aborted:      aborted,
+			Queries:      qps,
+			Errors:       errs,
+			TPS:          stats.TPS,
+			QPS:          stats.QPS,
+			SuccessRate:  stats.SuccessRate,
+			P50Ms:        stats.P50Latency,
+			P95Ms:        stats.P95Latency,
+			AvgMs:        stats.AvgLatency,
+		})
+	}
+	sort.Slice(workers, func(i, j int) bool { return workers[i].WorkerID < workers[j].WorkerID })
+	return workers
+}
+
+// summarizeOperations returns per-operation results ordered by name
+func summarizeOperations(m *types.Metrics, elapsed float64) []OperationSummary {
+	m.Mu.Lock()
+	ops := make([]*types.OperationStats, 0, len(m.Operations))
+	for _, op := range m.Operations {
+		ops = append(ops, op)
+	}
+	m.Mu.Unlock()
+
+	operations := []OperationSummary{}
+	for _, op := range ops {
+		op.Mu.Lock()
+		stat := OperationSummary{
+			Name:   op.Name,
+			Count:  op.Count,
+			Errors: op.Errors,
+			Rows:   op.Rows,
+			MinMs:  float64(op.MinNs) / 1e6,
+			MaxMs:  float64(op.MaxNs) / 1e6,
+		}
+		latencies := append([]int64(nil), op.Latencies...)
+		histogram := make(map[string]int64, len(op.Histogram))
+		for bucket, count := range op.Histogram {
+			histogram[bucket] = count
+		}
+		total := op.TotalNs
+		op.Mu.Unlock()
+
+		if elapsed > 0 {
+			stat.OPS = float64(stat.Count) / elapsed
+		}
+		if stat.Count > 0 {
+			stat.ErrorRate = float64(stat.Errors) / float64(stat.Count) * 100.0
+			stat.AvgMs = float64(total) / float64(stat.Count) / 1e6
+		}
+		if len(latencies) > 0 {
+			pvals := util.CalculatePercentiles(latencies, []int{50, 95, 99})
+			stat.P50Ms = float64(pvals[0]) / 1e6
+			stat.P95Ms = float64(pvals[1]) / 1e6
+			stat.P99Ms = float64(pvals[2]) / 1e6
+		}
+		stat.Histogram = summarizeLatency(nil, histogram).Histogram
+		operations = append(operations, stat)
+	}
+	sort.Slice(operations, func(i, j int) bool { return operations[i].Name < operations[j].Name })
+	return operations
+}
+
+// summarizeTimeSeries returns the completed time-series buckets
+func summarizeTimeSeries(m *types.Metrics) []TimeBucketStats {
+	buckets := []TimeBucketStats{}
+	if m.TimeSeries == nil {
+		return buckets
+	}
+
+	m.TimeSeries.Mu.RLock()
+	defer m.TimeSeries.Mu.RUnlock()
+
+	for _, b := range m.TimeSeries.Buckets {
+		seconds := b.EndTime.Sub(b.StartTime).Seconds()
+		stats := TimeBucketStats{
+			StartTime:     b.StartTime,
+			OffsetSeconds: b.StartTime.Sub(m.TimeSeries.StartTime).Seconds(),
+			Seconds:       seconds,
+			Transactions:  b.TPS,
+			Queries:       b.QPS,
+			Errors:        b.Errors,
+			RowsRead:      b.RowsRead,
+			RowsModified:  b.RowsModified,
+			Events:        b.Events,
+		}
+		if seconds > 0 {
+			stats.TPS = float64(b.TPS) / seconds
+			stats.QPS = float64(b.QPS) / seconds
+		}
+		if len(b.Latencies) > 0 {
+			pvals := util.CalculatePercentiles(append([]int64(nil), b.Latencies...), []int{50, 95, 99})
+			stats.P50Ms = float64(pvals[0]) / 1e6
+			stats.P95Ms = float64(pvals[1]) / 1e6
+			stats.P99Ms = float64(pvals[2]) / 1e6
+		}
+		buckets = append(buckets, stats)
+	}
+	return buckets
+}
+
+// summarizePgStats converts the collected PostgreSQL statistics, if any
+func summarizePgStats(m *types.Metrics) *PgStatsSummary {
+	pg := m.GetPgStats()
+	if pg == nil || pg.LastUpdated.IsZero() {
+		return nil
+	}
+
+	s := &PgStatsSummary{
+		BufferCacheHitRatio: pg.BufferCacheHitRatio,
+		BlocksRead:          pg.BlocksRead,
+		BlocksHit:           pg.BlocksHit,
+		BlocksWritten:       pg.BlocksWritten,
+		WALRecords:          pg.WALRecords,
+		WALBytes:            pg.WALBytes,
+		CheckpointsReq:      pg.CheckpointsReq,
+		CheckpointsTimed:    pg.CheckpointsTimed,
+		TempFiles:           pg.TempFiles,
+		TempBytes:           pg.TempBytes,
+		Deadlocks:           pg.Deadlocks,
+		LockWaitCount:       pg.LockWaitCount,
+		ActiveConnections:   pg.ActiveConnections,
+		MaxConnections:      pg.MaxConnections,
+		AutovacuumCount:     pg.AutovacuumCount,
+	}
+	for _, q := range pg.TopQueries {
+		s.TopQueries = append(s.TopQueries, TopQueryStats{
+			Query:      q.Query,
+			Calls:      q.Calls,
+			TotalMs:    q.TotalTime,
+			MeanMs:     q.MeanTime,
+			Rows:       q.Rows,
+			HitPercent: q.HitPercent,
+		})
+	}
+	for _, st := range pg.Statements {
+		s.Statements = append(s.Statements, StatementSummary{
+			QueryID:           st.QueryID,
+			Query:             st.Query,
+			Calls:             st.Calls,
+			TotalMs:           st.TotalExecTime,
+			MeanMs:            st.MeanExecTime(),
+			PlanMs:            st.TotalPlanTime,
+			Rows:              st.Rows,
+			SharedBlksHit:     st.SharedBlksHit,
+			SharedBlksRead:    st.SharedBlksRead,
+			SharedBlksDirtied: st.SharedBlksDirtied,
+			SharedBlksWritten: st.SharedBlksWritten,
+			LocalBlksHit:      st.LocalBlksHit,
+			LocalBlksRead:     st.LocalBlksRead,
+			LocalBlksWritten:  st.LocalBlksWritten,
+			WALRecords:        st.WALRecords,
+			WALBytes:          st.WALBytes,
+			HitPercent:        st.HitPercent(),
+		})
+	}
+	if len(pg.Statements) > 0 {
+		s.StatementRankings = make(map[string][]int64)
+		for _, d := range types.StatementDimensions {
+			for _, st := range types.TopStatements(pg.Statements, d, pg.StatementLimit) {
+				s.StatementRankings[d.Name] = append(s.StatementRankings[d.Name], st.QueryID)
+			}
+		}
+	}
+	return s
+}
+
+// summarizeQueryPlans lists the captured query plans, if any
+func summarizeQueryPlans(m *types.Metrics) *QueryPlanSummary {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+	if len(m.QueryPlans) == 0 {
+		return nil
+	}
+
+	s := &QueryPlanSummary{File: m.QueryPlanFile, Captured: len(m.QueryPlans)}
+	for _, p := range m.QueryPlans {
+		if p.Changed {
+			s.Changed++
+		}
+		s.Plans = append(s.Plans, PlanSummary{
+			Operation:     p.Operation,
+			Fingerprint:   p.Fingerprint,
+			Query:         p.Query,
+			Reason:        p.Reason,
+			LatencyMs:     p.LatencyMs,
+			ExecutionMs:   p.ExecutionMs,
+			Shape:         p.Shape,
+			Changed:       p.Changed,
+			PreviousShape: p.PreviousShape,
+			Error:         p.Error,
+		})
+	}
+	return s
+}
+
+// planFingerprints returns the distinct statements of an operation with
+// captured plans
+func planFingerprints(plans *QueryPlanSummary, operation string) []string {
+	if plans == nil {
+		return nil
+	}
+	var fingerprints []string
+	seen := make(map[string]bool)
+	for _, p := range plans.Plans {
+		if p.Operation == operation && !seen[p.Fingerprint] {
+			seen[p.Fingerprint] = true
+			fingerprints = append(fingerprints, p.Fingerprint)
+		}
+	}
+	return fingerprints
+}
@@ -3,6 +3,7 @@ package progress
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"time"
 )
@@ -102,14 +103,15 @@ func (p *Tracker) Display() {
 		rateStr = fmt.Sprintf(" (%.1f/s)", rate)
 	}
 
-	// Print progress line (use \r to overwrite the same line)
-	fmt.Printf("\r%s: [%s] %d/%d (%.1f%%)%s%s",
+	// Print progress line to stderr, keeping stdout free for run summaries
+	// (use \r to overwrite the same line)
+	fmt.Fprintf(os.Stderr, "\r%s: [%s] %d/%d (%.1f%%)%s%s",
 		p.title, bar, p.current, p.total, percentage, rateStr, eta)
 
 	// Print newline when complete
 	if p.current >= p.total {
 		totalTime := time.Since(p.startTime)
-		fmt.Printf(" ✅ Completed in %s\n", formatDuration(totalTime))
+		fmt.Fprintf(os.Stderr, " ✅ Completed in %s\n", formatDuration(totalTime))
 	}
 }
 
@@ -26,6 +26,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/elchinoo/stormdb/internal/database"
 	"github.com/elchinoo/stormdb/pkg/types"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -52,6 +53,13 @@ type WorkloadInterface interface {
 	Cleanup(ctx context.Context, db *pgxpool.Pool, cfg *types.Config) error
 }
 
+// RemoteWorkload is implemented by workloads that run on other hosts, such
+// as a distributed fleet. The engine does not open a band pool for them and
+// passes a nil pool to Run.
+type RemoteWorkload interface {
+	Remote() bool
+}
+
 // NewScalingEngine creates a new progressive scaling engine
 func NewScalingEngine(config *types.Config, workload WorkloadInterface, db *pgxpool.Pool) *ScalingEngine {
 	return &ScalingEngine{
@@ -536,24 +544,16 @@ func (e *ScalingEngine) executeBand(ctx context.Context, bandID int, config *typ
 
 	startTime := time.Now()
 
-	// Create a band-specific connection pool with the exact number of connections needed
-	dsn := fmt.Sprintf(
-		"user=%s password=%s host=%s port=%d dbname=%s sslmode=%s pool_max_conns=%d pool_min_conns=%d pool_max_conn_lifetime=1h pool_max_conn_idle_time=30m pool_health_check_period=1m connect_timeout=10",
-		config.Database.Username, config.Database.Password,
-		config.Database.Host, config.Database.Port,
-		config.Database.Dbname, config.Database.Sslmode,
-		config.Connections, config.Connections/2, // min connections = half of max
-	)
-
-	bandPool, err := pgxpool.New(ctx, dsn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create band-specific connection pool: %w", err)
-	}
-	defer bandPool.Close()
-
-	// Test the band connection pool
-	if err := bandPool.Ping(ctx); err != nil {
-		return nil, fmt.Errorf("failed to ping database with band pool: %w", err)
+	// Create a band-specific connection pool with the exact number of connections needed;
+	// remote workloads connect from their own hosts
+	var bandPool *pgxpool.Pool
+	if remote, ok := e.workload.(RemoteWorkload); !ok || !remote.Remote() {
+		var err error
+		bandPool, err = newBandPool(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		defer bandPool.Close()
 	}
 
 	// Create metrics for this band with memory limits
@@ -646,6 +646,37 @@ func (e *ScalingEngine) executeBand(ctx context.Context, bandID int, config *typ
 	return bandMetrics, nil
 }
 
+// newBandPool creates a connection pool sized for the band and checks it
+func newBandPool(ctx context.Context, config *types.Config) (*pgxpool.Pool, error) {
+	dsn := fmt.Sprintf(
+		"user=%s password=%s host=%s port=%d dbname=%s sslmode=%s pool_max_conns=%d pool_min_conns=%d pool_max_conn_lifetime=1h pool_max_conn_idle_time=30m pool_health_check_period=1m connect_timeout=10",
+		config.Database.Username, config.Database.Password,
+		config.Database.Host, config.Database.Port,
+		config.Database.Dbname, config.Database.Sslmode,
+		config.Connections, config.Connections/2, // min connections = half of max
+	)
+
+	poolCfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse band connection config: %w", err)
+	}
+	if err := database.ConfigureQueryExecMode(poolCfg, config.QueryExecMode, nil); err != nil {
+		return nil, err
+	}
+
+	bandPool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create band-specific connection pool: %w", err)
+	}
+
+	// Test the band connection pool
+	if err := bandPool.Ping(ctx); err != nil {
+		bandPool.Close()
+		return nil, fmt.Errorf("failed to ping database with band pool: %w", err)
+	}
+	return bandPool, nil
+}
+
 // calculateBandMetricsFromSamples computes comprehensive metrics from run-phase samples
 func (e *ScalingEngine) calculateBandMetricsFromSamples(bandID, workers, connections int,
 	startTime, endTime time.Time, duration time.Duration, samples []RunPhaseSample) *types.ProgressiveBandMetrics {
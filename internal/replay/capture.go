@@ -0,0 +1,323 @@
+// Package replay reads captured SQL traffic, from PostgreSQL logs written
+// with log_min_duration_statement = 0 or from a JSONL file of statements,
+// and replays it against a database, session by session.
+package replay
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Capture file formats
+const (
+	FormatCSVLog  = "csvlog"  // PostgreSQL log_destination = 'csvlog'
+	FormatJSONLog = "jsonlog" // PostgreSQL log_destination = 'jsonlog' (PostgreSQL 15+)
+	FormatJSONL   = "jsonl"   // One JSON statement per line, see jsonlEvent
+)
+
+// logTimeLayout is the timestamp format of csvlog and jsonlog entries
+const logTimeLayout = "2006-01-02 15:04:05.999 MST"
+
+// Event is one captured statement
+type Event struct {
+	Time     time.Time     // When the statement started, zero when unknown
+	Session  string        // Statements of a session are replayed in order on one connection
+	Database string        // Database the statement ran in, empty when unknown
+	Query    string        // Statement text, with $n placeholders for Params
+	Params   []any         // Parameter values as text, nil for NULL
+	Duration time.Duration // Captured duration, zero when unknown
+}
+
+// jsonlEvent is one line of a JSONL capture
+type jsonlEvent struct {
+	Time       time.Time         `json:"time"`
+	Session    string            `json:"session"`
+	Database   string            `json:"database"`
+	Query      string            `json:"query"`
+	Params     []json.RawMessage `json:"params"`
+	DurationMs float64           `json:"duration_ms"`
+}
+
+// DetectFormat returns the format of a capture file from its extension
+func DetectFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSVLog, nil
+	case ".json":
+		return FormatJSONLog, nil
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	}
+	return "", fmt.Errorf("cannot detect the format of %s, set replay.format to csvlog, jsonlog or jsonl", path)
+}
+
+// Load reads the statements of a capture file in the given format, or the
+// format of its extension when format is empty
+func Load(path, format string) ([]Event, error) {
+	if format == "" {
+		var err error
+		if format, err = DetectFormat(path); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open capture: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var events []Event
+	switch format {
+	case FormatCSVLog:
+		events, err = ReadCSVLog(f)
+	case FormatJSONLog:
+		events, err = ReadJSONLog(f)
+	case FormatJSONL:
+		events, err = ReadJSONL(f)
+	default:
+		return nil, fmt.Errorf("unsupported capture format: %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return events, nil
+}
+
+// ReadJSONL reads a JSONL capture. Each line holds a statement:
+//
+//	{"time": "2025-01-15T10:00:00.120Z", "session": "a1", "query": "SELECT * FROM t WHERE id = $1", "params": [42], "duration_ms": 0.3}
+//
+// Only query is required. Statements without a session are independent.
+func ReadJSONL(r io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var je jsonlEvent
+		if err := json.Unmarshal([]byte(text), &je); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if strings.TrimSpace(je.Query) == "" {
+			return nil, fmt.Errorf("line %d: query is empty", line)
+		}
+		params, err := jsonParams(je.Params)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		session := je.Session
+		if session == "" {
+			session = fmt.Sprintf("line %d", line)
+		}
+		events = append(events, Event{
+			Time:     je.Time,
+			Session:  session,
+			Database: je.Database,
+			Query:    je.Query,
+			Params:   params,
+			Duration: time.Duration(je.DurationMs * float64(time.Millisecond)),
+		})
+	}
+	return events, scanner.Err()
+}
+
+// jsonParams converts JSON parameter values to text, keeping numbers as
+// written and null as nil
+func jsonParams(raw []json.RawMessage) ([]any, error) {
+	params := make([]any, len(raw))
+	for i, value := range raw {
+		text := strings.TrimSpace(string(value))
+		switch {
+		case text == "null":
+			params[i] = nil
+		case strings.HasPrefix(text, `"`):
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil {
+				return nil, fmt.Errorf("param %d: %w", i+1, err)
+			}
+			params[i] = s
+		case strings.HasPrefix(text, "{"), strings.HasPrefix(text, "["):
+			params[i] = text // JSON documents are passed as their text
+		default:
+			params[i] = text // Numbers and booleans
+		}
+	}
+	return params, nil
+}
+
+// csvlog columns used by ReadCSVLog. They are stable since PostgreSQL 8.x;
+// later versions only append columns.
+const (
+	csvLogTime    = 0
+	csvDatabase   = 2
+	csvSessionID  = 5
+	csvSeverity   = 11
+	csvMessage    = 13
+	csvDetail     = 14
+	csvMinColumns = 15
+)
+
+// ReadCSVLog reads the statements of a PostgreSQL csvlog file
+func ReadCSVLog(r io.Reader) ([]Event, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	var events []Event
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < csvMinColumns || record[csvSeverity] != "LOG" {
+			continue
+		}
+		ev, ok := logEvent(record[csvLogTime], record[csvSessionID], record[csvDatabase], record[csvMessage], record[csvDetail])
+		if ok {
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
+
+// jsonLogEntry holds the jsonlog keys used by ReadJSONLog
+type jsonLogEntry struct {
+	Timestamp string `json:"timestamp"`
+	SessionID string `json:"session_id"`
+	Database  string `json:"dbname"`
+	Severity  string `json:"error_severity"`
+	Message   string `json:"message"`
+	Detail    string `json:"detail"`
+}
+
+// ReadJSONLog reads the statements of a PostgreSQL jsonlog file
+func ReadJSONLog(r io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var entry jsonLogEntry
+		if err := json.Unmarshal([]byte(text), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if entry.Severity != "LOG" {
+			continue
+		}
+		if ev, ok := logEvent(entry.Timestamp, entry.SessionID, entry.Database, entry.Message, entry.Detail); ok {
+			events = append(events, ev)
+		}
+	}
+	return events, scanner.Err()
+}
+
+// logEvent turns a server log entry into an event. Only entries written by
+// log_min_duration_statement are statements: "duration: 0.120 ms
+// statement: ..." for the simple protocol and "duration: 0.120 ms  execute
+// <name>: ..." for the extended protocol, whose parameters are in the
+// detail. Parse and bind entries of the extended protocol are skipped.
+func logEvent(logTime, session, database, message, detail string) (Event, bool) {
+	rest, ok := strings.CutPrefix(message, "duration: ")
+	if !ok {
+		return Event{}, false
+	}
+	ms, rest, ok := strings.Cut(rest, " ms")
+	if !ok {
+		return Event{}, false
+	}
+	rest = strings.TrimLeft(rest, " ")
+
+	var query string
+	switch {
+	case strings.HasPrefix(rest, "statement: "):
+		query = strings.TrimPrefix(rest, "statement: ")
+	case strings.HasPrefix(rest, "execute "):
+		_, query, ok = strings.Cut(rest, ": ")
+		if !ok {
+			return Event{}, false
+		}
+	default:
+		return Event{}, false
+	}
+
+	ev := Event{Session: session, Database: database, Query: query}
+	if d, err := strconv.ParseFloat(ms, 64); err == nil {
+		ev.Duration = time.Duration(d * float64(time.Millisecond))
+	}
+	// Entries are logged when statements finish
+	if t, err := time.Parse(logTimeLayout, logTime); err == nil {
+		ev.Time = t.Add(-ev.Duration)
+	}
+	if params, ok := strings.CutPrefix(detail, "parameters: "); ok {
+		ev.Params = ParseParameters(params)
+	}
+	return ev, true
+}
+
+// ParseParameters parses the parameter list of a log entry's detail:
+//
+//	$1 = '42', $2 = 'it''s', $3 = NULL
+//
+// Values are returned as text, nil for NULL, in placeholder order.
+func ParseParameters(list string) []any {
+	var params []any
+	for list != "" {
+		_, rest, ok := strings.Cut(list, " = ")
+		if !ok {
+			break
+		}
+
+		var value any
+		if after, ok := strings.CutPrefix(rest, "NULL"); ok {
+			rest = after
+		} else if strings.HasPrefix(rest, "'") {
+			var b strings.Builder
+			i := 1
+			for i < len(rest) {
+				if rest[i] == '\'' {
+					if i+1 < len(rest) && rest[i+1] == '\'' {
+						b.WriteByte('\'')
+						i += 2
+						continue
+					}
+					break
+				}
+				b.WriteByte(rest[i])
+				i++
+			}
+			value = b.String()
+			rest = rest[min(i+1, len(rest)):]
+		} else {
+			break
+		}
+
+		params = append(params, value)
+		list = strings.TrimPrefix(rest, ", ")
+	}
+	return params
+}
@@ -0,0 +1,374 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/explain"
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// legendSize is the number of statements listed after a replay
+const legendSize = 10
+
+// resetTimeout bounds resetting a connection after a replayed session
+const resetTimeout = 5 * time.Second
+
+// txIdle is the server transaction status outside a transaction block
+const txIdle = 'I'
+
+// Options controls how a capture is replayed
+type Options struct {
+	PreserveTiming bool
+	Speed          float64 // Timing multiplier, 2 replays twice as fast
+	Loop           bool
+	Database       string // Only replay statements of this database, when known
+}
+
+// OptionsFrom returns the replay options of cfg with defaults applied
+func OptionsFrom(cfg *types.Config) (Options, error) {
+	opts := Options{
+		PreserveTiming: cfg.Replay.PreserveTiming,
+		Speed:          cfg.Replay.Speed,
+		Loop:           cfg.Replay.Loop,
+		Database:       cfg.Replay.Database,
+	}
+	if opts.Speed < 0 {
+		return opts, fmt.Errorf("speed must not be negative, got %g", opts.Speed)
+	}
+	if opts.Speed == 0 {
+		opts.Speed = 1
+	}
+	return opts, nil
+}
+
+// Session holds the statements of one captured session in their order
+type Session struct {
+	ID     string
+	Events []Event
+}
+
+// Sessions groups events by session, keeping the order of the events in a
+// session, and orders sessions by their first statement
+func Sessions(events []Event) []Session {
+	index := make(map[string]int)
+	var sessions []Session
+	for _, ev := range events {
+		i, ok := index[ev.Session]
+		if !ok {
+			i = len(sessions)
+			index[ev.Session] = i
+			sessions = append(sessions, Session{ID: ev.Session})
+		}
+		sessions[i].Events = append(sessions[i].Events, ev)
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Events[0].Time.Before(sessions[j].Events[0].Time)
+	})
+	return sessions
+}
+
+// Label names the operation a statement is recorded under: its leading
+// keyword and the start of its fingerprint, the same fingerprint as the
+// captured query plans, e.g. "select_3f2a9c1b"
+func Label(query string) string {
+	keyword := strings.ToLower(strings.TrimLeft(query, " \t\r\n("))
+	if i := strings.IndexAny(keyword, " \t\r\n(;"); i >= 0 {
+		keyword = keyword[:i]
+	}
+	if keyword == "" {
+		keyword = "statement"
+	}
+	return keyword + "_" + explain.Fingerprint(query)[:8]
+}
+
+// Replayer replays the sessions of a capture
+type Replayer struct {
+	opts     Options
+	sessions []Session
+	start    time.Time // Time of the first captured statement
+	events   int
+
+	mu         sync.Mutex
+	statements map[string]string // Operation label -> first statement seen
+	maxLagNs   atomic.Int64      // Largest delay behind the captured timing
+}
+
+// NewReplayer prepares the replay of captured events
+func NewReplayer(events []Event, opts Options) (*Replayer, error) {
+	if opts.Database != "" {
+		kept := events[:0:0]
+		for _, ev := range events {
+			if ev.Database == "" || ev.Database == opts.Database {
+				kept = append(kept, ev)
+			}
+		}
+		events = kept
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("capture has no statements to replay")
+	}
+
+	r := &Replayer{opts: opts, sessions: Sessions(events), events: len(events), statements: make(map[string]string)}
+	r.start = r.sessions[0].Events[0].Time
+	if opts.PreserveTiming {
+		for _, ev := range events {
+			if ev.Time.IsZero() {
+				return nil, fmt.Errorf("preserve_timing needs timestamps on every statement")
+			}
+		}
+	}
+	return r, nil
+}
+
+// Stats returns the number of statements and sessions to replay
+func (r *Replayer) Stats() (statements, sessions int) {
+	return r.events, len(r.sessions)
+}
+
+// Offset returns when ev starts in the replay, relative to its start
+func (r *Replayer) Offset(ev Event) time.Duration {
+	return time.Duration(float64(ev.Time.Sub(r.start)) / r.opts.Speed)
+}
+
+// Run replays the capture with up to workers concurrent sessions, each on
+// its own connection, until it ends or ctx is done. With Loop the capture
+// restarts until ctx is done.
+func (r *Replayer) Run(ctx context.Context, pool *pgxpool.Pool, workers int, m *types.Metrics) error {
+	for pass := 1; ; pass++ {
+		r.pass(ctx, pool, max(workers, 1), m)
+		if !r.opts.Loop || ctx.Err() != nil {
+			break
+		}
+		log.Printf("🔁 Capture replayed, starting pass %d", pass+1)
+	}
+	r.logSummary(m)
+	return nil
+}
+
+// pass replays every session once
+func (r *Replayer) pass(ctx context.Context, pool *pgxpool.Pool, workers int, m *types.Metrics) {
+	start := time.Now()
+	queue := make(chan Session)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range queue {
+				r.replaySession(ctx, pool, s, start, m)
+			}
+		}()
+	}
+
+dispatch:
+	for _, s := range r.sessions {
+		if r.opts.PreserveTiming && !sleepUntil(ctx, start.Add(r.Offset(s.Events[0]))) {
+			break
+		}
+		select {
+		case queue <- s:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// replaySession runs the statements of a session in order on one connection
+func (r *Replayer) replaySession(ctx context.Context, pool *pgxpool.Pool, s Session, start time.Time, m *types.Metrics) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			recordError(m, "acquire", err)
+		}
+		return
+	}
+	defer resetSession(conn)
+
+	for _, ev := range s.Events {
+		if r.opts.PreserveTiming {
+			due := start.Add(r.Offset(ev))
+			if !sleepUntil(ctx, due) {
+				return
+			}
+			if lag := time.Since(due).Nanoseconds(); lag > r.maxLagNs.Load() {
+				r.maxLagNs.Store(lag)
+			}
+		}
+
+		label := r.label(ev.Query)
+		wasInTx := conn.Conn().PgConn().TxStatus() != txIdle
+		opStart := time.Now()
+		tag, err := conn.Exec(types.WithOperation(ctx, label), ev.Query, ev.Params...)
+		elapsed := time.Since(opStart).Nanoseconds()
+		if ctx.Err() != nil {
+			return // Cancelled by the end of the run, not a failure
+		}
+
+		queryType := types.GetQueryType(ev.Query)
+		m.RecordQuery(queryType)
+		m.RecordLatency(elapsed)
+		m.RecordOperation(label, elapsed, tag.RowsAffected(), err == nil)
+		m.Mu.Lock()
+		m.TransactionDur = append(m.TransactionDur, elapsed)
+		m.Mu.Unlock()
+
+		if err != nil {
+			recordError(m, label, err)
+		} else if queryType == "SELECT" {
+			atomic.AddInt64(&m.RowsRead, tag.RowsAffected())
+		} else if queryType != "OTHER" {
+			atomic.AddInt64(&m.RowsModified, tag.RowsAffected())
+		}
+
+		// The server's transaction status tells when a transaction ends,
+		// also for entries holding several statements such as
+		// "BEGIN; UPDATE ...; COMMIT". Statements outside explicit
+		// transactions are transactions of their own.
+		control := transactionControl(ev.Query)
+		switch {
+		case conn.Conn().PgConn().TxStatus() != txIdle:
+			// Transaction still open, counted when it ends
+		case !wasInTx && (control == "commit" || control == "rollback"):
+			// COMMIT or ROLLBACK without an open transaction
+		default:
+			m.RecordTransaction(err == nil && tag.String() != "ROLLBACK")
+		}
+	}
+}
+
+// resetSession rolls back any open transaction and discards the session
+// state a replayed session left on conn (settings such as search_path,
+// temporary tables, prepared statements and advisory locks) before
+// returning it to the pool, so the next session starts clean. A connection
+// that cannot be reset is closed instead.
+func resetSession(conn *pgxpool.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), resetTimeout)
+	defer cancel()
+
+	pgConn := conn.Conn().PgConn()
+	var err error
+	if pgConn.TxStatus() != txIdle {
+		_, err = pgConn.Exec(ctx, "ROLLBACK").ReadAll()
+	}
+	if err == nil {
+		_, err = pgConn.Exec(ctx, "DISCARD ALL").ReadAll()
+	}
+	if err == nil {
+		// Forget the statements DISCARD ALL deallocated on the server
+		err = conn.Conn().DeallocateAll(ctx)
+	}
+	if err != nil {
+		_ = conn.Conn().Close(ctx)
+	}
+	conn.Release()
+}
+
+// label returns the operation label of a statement, remembering the first
+// statement seen for each label
+func (r *Replayer) label(query string) string {
+	label := Label(query)
+	r.mu.Lock()
+	if _, ok := r.statements[label]; !ok {
+		r.statements[label] = query
+	}
+	r.mu.Unlock()
+	return label
+}
+
+// logSummary logs the replay lag and the statements behind the most
+// frequent operation labels
+func (r *Replayer) logSummary(m *types.Metrics) {
+	if r.opts.PreserveTiming {
+		log.Printf("📼 Largest delay behind the captured timing: %v", time.Duration(r.maxLagNs.Load()).Round(time.Millisecond))
+	}
+
+	r.mu.Lock()
+	labels := make([]string, 0, len(r.statements))
+	for label := range r.statements {
+		labels = append(labels, label)
+	}
+	r.mu.Unlock()
+
+	counts := make(map[string]int64, len(labels))
+	for _, label := range labels {
+		op := m.Operation(label)
+		op.Mu.Lock()
+		counts[label] = op.Count
+		op.Mu.Unlock()
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if counts[labels[i]] != counts[labels[j]] {
+			return counts[labels[i]] > counts[labels[j]]
+		}
+		return labels[i] < labels[j]
+	})
+
+	log.Printf("📼 %d distinct statement(s) replayed, most frequent:", len(labels))
+	for _, label := range labels[:min(legendSize, len(labels))] {
+		query := strings.Join(strings.Fields(r.statements[label]), " ")
+		if len(query) > 100 {
+			query = query[:97] + "..."
+		}
+		log.Printf("   %-20s %8d × %s", label, counts[label], query)
+	}
+}
+
+// transactionControl classifies a statement by its first word: "begin",
+// "commit", "rollback", or "" for any other statement, including savepoint
+// commands
+func transactionControl(query string) string {
+	words := strings.Fields(strings.ToLower(strings.TrimRight(strings.TrimSpace(query), ";")))
+	if len(words) == 0 {
+		return ""
+	}
+	switch words[0] {
+	case "begin", "start":
+		return "begin"
+	case "commit", "end":
+		if len(words) > 1 && words[1] == "prepared" {
+			return ""
+		}
+		return "commit"
+	case "rollback", "abort":
+		if len(words) > 1 && (words[1] == "to" || words[1] == "prepared") {
+			return ""
+		}
+		return "rollback"
+	}
+	return ""
+}
+
+// recordError counts a failed statement under its operation
+func recordError(m *types.Metrics, operation string, err error) {
+	atomic.AddInt64(&m.Errors, 1)
+	m.Mu.Lock()
+	m.ErrorTypes[fmt.Sprintf("%s: %s", operation, err.Error())]++
+	m.Mu.Unlock()
+}
+
+// sleepUntil waits until t, returning false when ctx is done first
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	d := time.Until(t)
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
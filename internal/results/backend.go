@@ -6,6 +6,7 @@ package results
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
@@ -130,6 +131,17 @@ func (b *Backend) Close() {
 	}
 }
 
+// Pool returns the connection pool of the backend, so other stores can
+// share the results database
+func (b *Backend) Pool() *pgxpool.Pool {
+	return b.db
+}
+
+// TablePrefix returns the prefix of the backend's tables
+func (b *Backend) TablePrefix() string {
+	return b.config.TablePrefix
+}
+
 // calculateLatencyPercentiles calculates latency percentiles from transaction durations
 func calculateLatencyPercentiles(durations []int64) (avg, p50, p95, p99, p999, min, max float64) {
 	if len(durations) == 0 {
@@ -275,6 +287,51 @@ func (b *Backend) createTables() error {
 				created_at TIMESTAMPTZ DEFAULT NOW()
 			)`, b.config.TablePrefix, b.config.TablePrefix),
 
+		// pg_stat_statements deltas, companion of postgresql_stats
+		fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %spostgresql_statement_stats (
+				id BIGSERIAL PRIMARY KEY,
+				test_run_id BIGINT REFERENCES %stest_runs(id) ON DELETE CASCADE,
+				queryid BIGINT,
+				query TEXT,
+				calls BIGINT,
+				total_exec_time_ms DOUBLE PRECISION,
+				mean_exec_time_ms DOUBLE PRECISION,
+				total_plan_time_ms DOUBLE PRECISION,
+				rows BIGINT,
+				shared_blks_hit BIGINT,
+				shared_blks_read BIGINT,
+				shared_blks_dirtied BIGINT,
+				shared_blks_written BIGINT,
+				local_blks_hit BIGINT,
+				local_blks_read BIGINT,
+				local_blks_written BIGINT,
+				wal_records BIGINT,
+				wal_bytes BIGINT,
+				rankings TEXT[],
+				created_at TIMESTAMPTZ DEFAULT NOW()
+			)`, b.config.TablePrefix, b.config.TablePrefix),
+
+		// Server settings and client environment of each run
+		fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %srun_metadata (
+				id BIGSERIAL PRIMARY KEY,
+				test_run_id BIGINT REFERENCES %stest_runs(id) ON DELETE CASCADE,
+				server_version TEXT,
+				server_version_num INTEGER,
+				settings JSONB,
+				extensions JSONB,
+				client_version VARCHAR(50),
+				client_host VARCHAR(255),
+				client_os VARCHAR(50),
+				client_cpus INTEGER,
+				go_version VARCHAR(50),
+				plugin VARCHAR(255),
+				plugin_version VARCHAR(50),
+				captured_at TIMESTAMPTZ,
+				created_at TIMESTAMPTZ DEFAULT NOW()
+			)`, b.config.TablePrefix, b.config.TablePrefix),
+
 		// Error metrics table
 		fmt.Sprintf(`
 			CREATE TABLE IF NOT EXISTS %serror_metrics (
@@ -326,6 +383,8 @@ func (b *Backend) createTables() error {
 		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%stest_runs_test_name ON %stest_runs(test_name)", b.config.TablePrefix, b.config.TablePrefix),
 		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%stest_results_test_run_id ON %stest_results(test_run_id)", b.config.TablePrefix, b.config.TablePrefix),
 		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%spostgresql_stats_test_run_id ON %spostgresql_stats(test_run_id)", b.config.TablePrefix, b.config.TablePrefix),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%spostgresql_statement_stats_test_run_id ON %spostgresql_statement_stats(test_run_id)", b.config.TablePrefix, b.config.TablePrefix),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%srun_metadata_test_run_id ON %srun_metadata(test_run_id)", b.config.TablePrefix, b.config.TablePrefix),
 		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%serror_metrics_test_run_id ON %serror_metrics(test_run_id)", b.config.TablePrefix, b.config.TablePrefix),
 	}
 
@@ -355,7 +414,7 @@ func (b *Backend) StoreTestRun(ctx context.Context, testRun *TestRun, metrics *t
 	defer func() {
 		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
 			// Log rollback error for debugging
-			fmt.Printf("Warning: Transaction rollback failed: %v\n", rollbackErr)
+			log.Printf("Warning: Transaction rollback failed: %v", rollbackErr)
 		}
 	}()
 
@@ -376,6 +435,16 @@ func (b *Backend) StoreTestRun(ctx context.Context, testRun *TestRun, metrics *t
 			if err := b.insertPostgreSQLStats(ctx, tx, testRunID, pgStats); err != nil {
 				return fmt.Errorf("failed to insert PostgreSQL stats: %w", err)
 			}
+			if err := b.insertStatementStats(ctx, tx, testRunID, pgStats); err != nil {
+				return fmt.Errorf("failed to insert statement stats: %w", err)
+			}
+		}
+	}
+
+	// Store the server settings and client environment
+	if md := metrics.GetRunMetadata(); md != nil {
+		if err := b.insertRunMetadata(ctx, tx, testRunID, md); err != nil {
+			return fmt.Errorf("failed to insert run metadata: %w", err)
 		}
 	}
 
@@ -384,6 +453,11 @@ func (b *Backend) StoreTestRun(ctx context.Context, testRun *TestRun, metrics *t
 		return fmt.Errorf("failed to insert error metrics: %w", err)
 	}
 
+	// Store workload-specific results (bulk load matrix, partitioned ingestion, upserts, vector index builds, filtered searches, quantization and accuracy sweeps)
+	if err := b.insertWorkloadMetrics(ctx, tx, testRunID, testRun.Workload, metrics); err != nil {
+		return fmt.Errorf("failed to insert workload metrics: %w", err)
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -463,6 +537,57 @@ func (b *Backend) insertPostgreSQLStats(ctx context.Context, tx pgx.Tx, testRunI
 	return err
 }
 
+// insertStatementStats inserts the pg_stat_statements deltas, with the
+// ranking dimensions each statement is in the top N of
+func (b *Backend) insertStatementStats(ctx context.Context, tx pgx.Tx, testRunID int64, pgStats *types.PostgreSQLStats) error {
+	if len(pgStats.Statements) == 0 {
+		return nil
+	}
+
+	rankings := make(map[int64][]string)
+	for _, d := range types.StatementDimensions {
+		for _, s := range types.TopStatements(pgStats.Statements, d, pgStats.StatementLimit) {
+			rankings[s.QueryID] = append(rankings[s.QueryID], d.Name)
+		}
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %spostgresql_statement_stats 
+		(test_run_id, queryid, query, calls, total_exec_time_ms, mean_exec_time_ms, total_plan_time_ms,
+		 rows, shared_blks_hit, shared_blks_read, shared_blks_dirtied, shared_blks_written,
+		 local_blks_hit, local_blks_read, local_blks_written, wal_records, wal_bytes, rankings)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`, b.config.TablePrefix)
+
+	for _, s := range pgStats.Statements {
+		_, err := tx.Exec(ctx, query,
+			testRunID, s.QueryID, s.Query, s.Calls, s.TotalExecTime, s.MeanExecTime(), s.TotalPlanTime,
+			s.Rows, s.SharedBlksHit, s.SharedBlksRead, s.SharedBlksDirtied, s.SharedBlksWritten,
+			s.LocalBlksHit, s.LocalBlksRead, s.LocalBlksWritten, s.WALRecords, s.WALBytes, rankings[s.QueryID])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertRunMetadata stores the environment fingerprint of a run
+func (b *Backend) insertRunMetadata(ctx context.Context, tx pgx.Tx, testRunID int64, md *types.RunMetadata) error {
+	settingsJSON, _ := json.Marshal(md.Settings)
+	extensionsJSON, _ := json.Marshal(md.Extensions)
+
+	query := fmt.Sprintf(`
+		INSERT INTO %srun_metadata
+		(test_run_id, server_version, server_version_num, settings, extensions, client_version,
+		 client_host, client_os, client_cpus, go_version, plugin, plugin_version, captured_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`, b.config.TablePrefix)
+
+	_, err := tx.Exec(ctx, query, testRunID, md.ServerVersion, md.ServerVersionNum,
+		string(settingsJSON), string(extensionsJSON), md.ClientVersion, md.ClientHost, md.ClientOS,
+		md.ClientCPUs, md.GoVersion, md.Plugin, md.PluginVersion, md.CapturedAt)
+	return err
+}
+
 // insertErrorMetrics inserts error metrics
 func (b *Backend) insertErrorMetrics(ctx context.Context, tx pgx.Tx, testRunID int64, metrics *types.Metrics) error {
 	if len(metrics.ErrorTypes) == 0 {
@@ -484,6 +609,90 @@ func (b *Backend) insertErrorMetrics(ctx context.Context, tx pgx.Tx, testRunID i
 	return nil
 }
 
+// insertWorkloadMetrics inserts workload-specific results, one row per
+// measured configuration with the full result in metric_data
+func (b *Backend) insertWorkloadMetrics(ctx context.Context, tx pgx.Tx, testRunID int64, workload string, metrics *types.Metrics) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %sworkload_metrics 
+		(test_run_id, workload_type, metric_name, metric_value, metric_unit, metric_data, timestamp)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())`, b.config.TablePrefix)
+
+	for _, load := range metrics.GetBulkLoads() {
+		data, _ := json.Marshal(load)
+		name := fmt.Sprintf("bulk_load_rows_per_sec:%s:%s:indexes=%t:synchronous_commit=%s",
+			load.Method, load.Table, load.Indexes, load.SynchronousCommit)
+		_, err := tx.Exec(ctx, query, testRunID, workload, name, load.RowsPerSec, "rows/sec", string(data))
+		if err != nil {
+			return err
+		}
+	}
+
+	if partitioning := metrics.GetPartitioning(); partitioning != nil {
+		data, _ := json.Marshal(partitioning)
+		_, err := tx.Exec(ctx, query, testRunID, workload,
+			"partition_routing_overhead:"+partitioning.Strategy, partitioning.RoutingOverheadPct, "percent", string(data))
+		if err != nil {
+			return err
+		}
+		for _, p := range partitioning.Stats {
+			data, _ := json.Marshal(p)
+			_, err := tx.Exec(ctx, query, testRunID, workload,
+				"partition_rows_per_sec:"+p.Partition, p.RowsPerSec, "rows/sec", string(data))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, upsert := range metrics.GetUpserts() {
+		data, _ := json.Marshal(upsert)
+		name := fmt.Sprintf("upsert_rows_per_sec:%s:conflict=%g", upsert.Method, upsert.ConflictRatio)
+		_, err := tx.Exec(ctx, query, testRunID, workload, name, upsert.RowsPerSec, "rows/sec", string(data))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, build := range metrics.GetIndexBuilds() {
+		data, _ := json.Marshal(build)
+		_, err := tx.Exec(ctx, query, testRunID, workload,
+			"index_build:"+build.Index, build.BuildTime.Seconds(), "seconds", string(data))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, result := range metrics.GetFilteredSearch() {
+		data, _ := json.Marshal(result)
+		name := fmt.Sprintf("filtered_recall_at_%d:%s:%s=%g:iterative=%s",
+			result.K, result.Mode, result.FilterColumn, result.Selectivity, result.IterativeScan)
+		_, err := tx.Exec(ctx, query, testRunID, workload, name, result.Recall, "ratio", string(data))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, result := range metrics.GetQuantization() {
+		data, _ := json.Marshal(result)
+		name := fmt.Sprintf("quantization_recall_at_%d:%s", result.K, result.Variant)
+		_, err := tx.Exec(ctx, query, testRunID, workload, name, result.Recall, "ratio", string(data))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, result := range metrics.GetVectorAccuracy() {
+		data, _ := json.Marshal(result)
+		name := fmt.Sprintf("recall_at_%d:%s:%s=%d", result.K, result.Index, result.SearchParam, result.SearchValue)
+		_, err := tx.Exec(ctx, query, testRunID, workload, name, result.Recall, "ratio", string(data))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // GetTestRuns retrieves test runs with optional filtering
 func (b *Backend) GetTestRuns(ctx context.Context, filters map[string]interface{}) ([]*TestRun, error) {
 	query := fmt.Sprintf(`
@@ -581,6 +790,43 @@ func (b *Backend) GetTestResults(ctx context.Context, testRunID int64) (*TestRes
 	return &tr, nil
 }
 
+// GetRunMetadata retrieves the environment fingerprint of a test run, or nil
+// for runs stored without one
+func (b *Backend) GetRunMetadata(ctx context.Context, testRunID int64) (*types.RunMetadata, error) {
+	query := fmt.Sprintf(`
+		SELECT COALESCE(server_version, ''), COALESCE(server_version_num, 0), COALESCE(settings::text, 'null'),
+		       COALESCE(extensions::text, 'null'), COALESCE(client_version, ''), COALESCE(client_host, ''),
+		       COALESCE(client_os, ''), COALESCE(client_cpus, 0), COALESCE(go_version, ''),
+		       COALESCE(plugin, ''), COALESCE(plugin_version, ''), captured_at
+		FROM %srun_metadata
+		WHERE test_run_id = $1`, b.config.TablePrefix)
+
+	var md types.RunMetadata
+	var settingsJSON, extensionsJSON string
+	var capturedAt *time.Time
+	err := b.db.QueryRow(ctx, query, testRunID).Scan(
+		&md.ServerVersion, &md.ServerVersionNum, &settingsJSON, &extensionsJSON,
+		&md.ClientVersion, &md.ClientHost, &md.ClientOS, &md.ClientCPUs, &md.GoVersion,
+		&md.Plugin, &md.PluginVersion, &capturedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run metadata: %w", err)
+	}
+
+	if capturedAt != nil {
+		md.CapturedAt = *capturedAt
+	}
+	if err := json.Unmarshal([]byte(settingsJSON), &md.Settings); err != nil {
+		log.Printf("Warning: failed to parse settings JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(extensionsJSON), &md.Extensions); err != nil {
+		log.Printf("Warning: failed to parse extensions JSON: %v", err)
+	}
+	return &md, nil
+}
+
 // CleanupOldResults removes test results older than retention period
 func (b *Backend) CleanupOldResults(ctx context.Context) error {
 	if b.config.RetentionDays <= 0 {
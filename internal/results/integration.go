@@ -9,6 +9,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/elchinoo/stormdb/pkg/keydist"
 	"github.com/elchinoo/stormdb/pkg/types"
 )
 
@@ -33,7 +34,7 @@ func StoreTestResults(ctx context.Context, backend *Backend, cfg *types.Config,
 		TestMode:       getTestMode(cfg),
 		Environment:    getEnvironment(cfg),
 		DatabaseTarget: getDatabaseTarget(cfg),
-		Version:        "1.0.0", // This should come from build info
+		Version:        getVersion(metrics),
 		Status:         "completed",
 		Notes:          getNotes(cfg),
 		Tags:           getTags(cfg),
@@ -43,6 +44,14 @@ func StoreTestResults(ctx context.Context, backend *Backend, cfg *types.Config,
 	return backend.StoreTestRun(ctx, testRun, metrics)
 }
 
+// getVersion returns the StormDB version recorded in the run metadata
+func getVersion(metrics *types.Metrics) string {
+	if md := metrics.GetRunMetadata(); md != nil && md.ClientVersion != "" {
+		return md.ClientVersion
+	}
+	return "unknown"
+}
+
 // getTestName extracts or generates a test name
 func getTestName(cfg *types.Config) string {
 	// Check if test metadata is available in config
@@ -65,6 +74,7 @@ func buildConfigMap(cfg *types.Config) map[string]interface{} {
 		"summary_interval":    cfg.SummaryInterval,
 		"collect_pg_stats":    cfg.CollectPgStats,
 		"pg_stats_statements": cfg.PgStatsStatements,
+		"key_distribution":    keydist.ConfigFrom(cfg).String(),
 	}
 
 	// Add progressive scaling config if enabled
@@ -215,7 +225,10 @@ func GetRecentTestRuns(ctx context.Context, backend *Backend, workload string, l
 	return backend.GetTestRuns(ctx, filters)
 }
 
-// CompareTestPerformance provides a simple comparison between test runs
+// CompareTestPerformance provides a simple comparison between test runs.
+// "metadata_changes" lists the server settings, extensions, server, client
+// and plugin versions that differ between the runs, so a regression caused
+// by a configuration change stands out.
 func CompareTestPerformance(ctx context.Context, backend *Backend, testRunID1, testRunID2 int64) (map[string]interface{}, error) {
 	if backend == nil {
 		return nil, fmt.Errorf("backend not configured")
@@ -232,6 +245,16 @@ func CompareTestPerformance(ctx context.Context, backend *Backend, testRunID1, t
 		return nil, fmt.Errorf("failed to get results for test run %d: %w", testRunID2, err)
 	}
 
+	metadata1, err := backend.GetRunMetadata(ctx, testRunID1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata for test run %d: %w", testRunID1, err)
+	}
+
+	metadata2, err := backend.GetRunMetadata(ctx, testRunID2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata for test run %d: %w", testRunID2, err)
+	}
+
 	// Calculate performance differences
 	comparison := map[string]interface{}{
 		"test_run_1":          testRunID1,
@@ -241,6 +264,7 @@ func CompareTestPerformance(ctx context.Context, backend *Backend, testRunID1, t
 		"success_rate_change": results2.SuccessRate - results1.SuccessRate,
 		"results_1":           results1,
 		"results_2":           results2,
+		"metadata_changes":    types.DiffRunMetadata(metadata1, metadata2),
 	}
 
 	return comparison, nil
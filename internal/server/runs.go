@@ -0,0 +1,478 @@
+// internal/server/runs.go
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/chaos"
+	"github.com/elchinoo/stormdb/internal/config"
+	"github.com/elchinoo/stormdb/internal/core/domain"
+	"github.com/elchinoo/stormdb/internal/core/usecases"
+	"github.com/elchinoo/stormdb/internal/metrics"
+	"github.com/elchinoo/stormdb/internal/util"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// Errors returned by the run manager
+var (
+	ErrRunNotFound = errors.New("run not found")
+	ErrRunActive   = errors.New("another run is active")
+	ErrRunState    = usecases.ErrExecutionState
+)
+
+// recentLatencySamples bounds the samples a live snapshot takes
+// percentiles from, so snapshots stay cheap on long runs
+const recentLatencySamples = 10000
+
+// RunOptions controls how a submitted run is prepared
+type RunOptions struct {
+	Setup   bool // Ensure the schema exists before running
+	Rebuild bool // Drop, recreate and load the schema before running
+
+	// Set by the manager from ManagerOptions.AllowShellFaults
+	AllowShellFaults bool
+}
+
+// Runner executes one workload run with cfg, recording into m. It calls
+// started once setup is done and the workload begins, and returns when the
+// workload has run for the configured duration or ctx is done.
+type Runner func(ctx context.Context, cfg *types.Config, m *types.Metrics, opts RunOptions, started func()) error
+
+// Run is a submitted workload run. Its lifecycle is an execution of the
+// test execution use case; the run adds the configuration and live metrics.
+type Run struct {
+	ID      string
+	Name    string
+	Options RunOptions
+
+	config     []byte // Configuration document, parsed when the run starts
+	configType string
+	workload   string
+	duration   string
+	exec       *usecases.ExecutionContext
+
+	mu      sync.RWMutex
+	cfg     *types.Config
+	metrics *types.Metrics
+}
+
+// RunInfo is the API view of a run
+type RunInfo struct {
+	ID              string                 `json:"id"`
+	Name            string                 `json:"name,omitempty"`
+	Workload        string                 `json:"workload"`
+	Duration        string                 `json:"duration"`
+	DurationSeconds float64                `json:"duration_seconds"`
+	Status          domain.ExecutionStatus `json:"status"`
+	Interrupted     bool                   `json:"interrupted"`
+	Error           string                 `json:"error,omitempty"`
+	CreatedAt       time.Time              `json:"created_at"`
+	StartedAt       *time.Time             `json:"started_at,omitempty"`
+	EndedAt         *time.Time             `json:"ended_at,omitempty"`
+	ElapsedSeconds  float64                `json:"elapsed_seconds"`
+	Progress        float64                `json:"progress"` // 0.0 to 1.0 of the configured duration
+	ResultsReady    bool                   `json:"results_ready"`
+	Setup           bool                   `json:"setup"`
+	Rebuild         bool                   `json:"rebuild"`
+}
+
+// Snapshot is a point-in-time view of a run's metrics. Rates are averages
+// since the workload began; interval rates cover the time since the
+// previous snapshot of the same stream.
+type Snapshot struct {
+	Timestamp      time.Time `json:"timestamp"`
+	ElapsedSeconds float64   `json:"elapsed_seconds"`
+	Transactions   int64     `json:"transactions"`
+	Aborted        int64     `json:"aborted"`
+	Queries        int64     `json:"queries"`
+	Errors         int64     `json:"errors"`
+	RowsRead       int64     `json:"rows_read"`
+	RowsModified   int64     `json:"rows_modified"`
+	TPS            float64   `json:"tps"`
+	QPS            float64   `json:"qps"`
+	IntervalTPS    float64   `json:"interval_tps"`
+	IntervalQPS    float64   `json:"interval_qps"`
+	SuccessRate    float64   `json:"success_rate_pct"`
+	P50Ms          float64   `json:"p50_ms"` // Over the recent samples
+	P95Ms          float64   `json:"p95_ms"`
+	P99Ms          float64   `json:"p99_ms"`
+
+	samples int // Latency samples seen, where the next snapshot continues
+}
+
+// Info returns the API view of r
+func (r *Run) Info() RunInfo {
+	state := r.exec.State()
+	info := RunInfo{
+		ID:           r.ID,
+		Name:         r.Name,
+		Workload:     r.workload,
+		Duration:     r.duration,
+		Status:       state.Status,
+		Interrupted:  state.Interrupted,
+		CreatedAt:    state.CreatedAt,
+		ResultsReady: hasResults(state),
+		Setup:        r.Options.Setup,
+		Rebuild:      r.Options.Rebuild,
+	}
+	if state.Err != nil {
+		info.Error = state.Err.Error()
+	}
+	if d, err := time.ParseDuration(r.duration); err == nil {
+		info.DurationSeconds = d.Seconds()
+	}
+	if !state.StartTime.IsZero() {
+		started := state.StartTime
+		info.StartedAt = &started
+		info.ElapsedSeconds = elapsed(state).Seconds()
+		if info.DurationSeconds > 0 {
+			info.Progress = info.ElapsedSeconds / info.DurationSeconds
+			if info.Progress > 1 || state.Status == domain.StatusCompleted {
+				info.Progress = 1
+			}
+		}
+	}
+	if !state.EndTime.IsZero() {
+		ended := state.EndTime
+		info.EndedAt = &ended
+	}
+	return info
+}
+
+// Status returns the current status of r
+func (r *Run) Status() domain.ExecutionStatus {
+	return r.exec.State().Status
+}
+
+// Done returns a channel closed when r has finished
+func (r *Run) Done() <-chan struct{} {
+	return r.exec.Done()
+}
+
+// Snapshot returns the current metrics of r, continuing from prev when given
+func (r *Run) Snapshot(prev *Snapshot) (*Snapshot, bool) {
+	r.mu.RLock()
+	m := r.metrics
+	r.mu.RUnlock()
+	if m == nil {
+		return nil, false
+	}
+
+	s := &Snapshot{
+		Timestamp:      time.Now(),
+		ElapsedSeconds: elapsed(r.exec.State()).Seconds(),
+		Transactions:   atomic.LoadInt64(&m.TPS),
+		Aborted:        atomic.LoadInt64(&m.TPSAborted),
+		Queries:        atomic.LoadInt64(&m.QPS),
+		Errors:         atomic.LoadInt64(&m.Errors),
+		RowsRead:       atomic.LoadInt64(&m.RowsRead),
+		RowsModified:   atomic.LoadInt64(&m.RowsModified),
+		SuccessRate:    100.0,
+	}
+	if total := s.Transactions + s.Aborted; total > 0 {
+		s.SuccessRate = float64(s.Transactions) / float64(total) * 100.0
+	}
+	if s.ElapsedSeconds > 0 {
+		s.TPS = float64(s.Transactions) / s.ElapsedSeconds
+		s.QPS = float64(s.Queries) / s.ElapsedSeconds
+		s.IntervalTPS, s.IntervalQPS = s.TPS, s.QPS
+	}
+	if prev != nil {
+		if secs := s.Timestamp.Sub(prev.Timestamp).Seconds(); secs > 0 {
+			s.IntervalTPS = float64(s.Transactions-prev.Transactions) / secs
+			s.IntervalQPS = float64(s.Queries-prev.Queries) / secs
+		}
+	}
+
+	// Percentiles over the samples since the previous snapshot, or the
+	// latest ones; the slice may wrap once max_latency_samples is reached
+	m.Mu.Lock()
+	n := len(m.TransactionDur)
+	from := n - recentLatencySamples
+	if prev != nil && prev.samples <= n && prev.samples > from {
+		from = prev.samples
+	}
+	if from < 0 {
+		from = 0
+	}
+	recent := append([]int64(nil), m.TransactionDur[from:]...)
+	m.Mu.Unlock()
+
+	s.samples = n
+	if len(recent) > 0 {
+		pvals := util.CalculatePercentiles(recent, []int{50, 95, 99})
+		s.P50Ms = float64(pvals[0]) / 1e6
+		s.P95Ms = float64(pvals[1]) / 1e6
+		s.P99Ms = float64(pvals[2]) / 1e6
+	}
+	return s, true
+}
+
+// Summary returns the machine-readable results of a finished run
+func (r *Run) Summary() (*metrics.RunSummary, error) {
+	state := r.exec.State()
+	if !hasResults(state) {
+		return nil, ErrRunState
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return metrics.BuildSummary(r.cfg, r.metrics, state.Interrupted, state.Err, state.StartTime, state.EndTime), nil
+}
+
+// Results returns the configuration and metrics of a finished run for the text report
+func (r *Run) Results() (*types.Config, *types.Metrics, bool, time.Time, error) {
+	state := r.exec.State()
+	if !hasResults(state) {
+		return nil, nil, false, time.Time{}, ErrRunState
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cfg, r.metrics, state.Interrupted, state.EndTime, nil
+}
+
+// hasResults reports whether a run in state finished after its workload began
+func hasResults(state usecases.ExecutionState) bool {
+	return state.Finished() && !state.StartTime.IsZero()
+}
+
+// elapsed returns the workload run time of a run in state so far
+func elapsed(state usecases.ExecutionState) time.Duration {
+	switch {
+	case state.StartTime.IsZero():
+		return 0
+	case !state.EndTime.IsZero():
+		return state.EndTime.Sub(state.StartTime)
+	default:
+		return time.Since(state.StartTime)
+	}
+}
+
+// Manager tracks submitted runs and executes one at a time through the
+// test execution use case. Plugins read their settings from the global
+// viper instance, so runs cannot overlap.
+type Manager struct {
+	runner     Runner
+	opts       ManagerOptions
+	executions *usecases.TestExecutionUseCase
+
+	mu     sync.Mutex
+	runs   map[string]*Run
+	order  []string // Submission order
+	active *Run
+}
+
+// ManagerOptions configures the run manager
+type ManagerOptions struct {
+	MaxHistory       int  // Finished runs to keep (0 keeps all)
+	AllowShellFaults bool // Accept fault events that run shell commands on this host
+}
+
+// NewManager creates a manager executing runs with runner. Runs record
+// themselves in the run history, so the use case needs no repositories.
+func NewManager(runner Runner, opts ManagerOptions) *Manager {
+	return &Manager{
+		runner:     runner,
+		opts:       opts,
+		executions: usecases.NewTestExecutionUseCase(nil, nil, nil, nil, nil, nil, nil),
+		runs:       make(map[string]*Run),
+	}
+}
+
+// Submit validates a configuration document and registers a pending run
+func (mgr *Manager) Submit(name string, data []byte, configType string, opts RunOptions) (*Run, error) {
+	cfg, err := config.Check(data, configType)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Progressive.Enabled || cfg.ExecModeComparison.Enabled {
+		return nil, fmt.Errorf("progressive scaling and exec mode comparison are not supported by the API")
+	}
+	// A shell fault event would let any API client run commands on this host
+	if !mgr.opts.AllowShellFaults {
+		for _, event := range cfg.FaultInjection.Events {
+			if event.Action == chaos.ActionShell {
+				return nil, fmt.Errorf("fault_injection shell actions are disabled on this server (start it with --allow-shell-faults)")
+			}
+		}
+	}
+
+	opts.AllowShellFaults = mgr.opts.AllowShellFaults
+
+	exec := mgr.executions.SubmitExecution(name, cfg.Workload)
+	run := &Run{
+		ID:         exec.ID,
+		Name:       name,
+		Options:    opts,
+		config:     data,
+		configType: configType,
+		workload:   cfg.Workload,
+		duration:   cfg.Duration,
+		exec:       exec,
+	}
+
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	mgr.runs[run.ID] = run
+	mgr.order = append(mgr.order, run.ID)
+	mgr.prune()
+	return run, nil
+}
+
+// Get returns a run by id
+func (mgr *Manager) Get(id string) (*Run, error) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	run, ok := mgr.runs[id]
+	if !ok {
+		return nil, ErrRunNotFound
+	}
+	return run, nil
+}
+
+// List returns all runs, newest first
+func (mgr *Manager) List() []*Run {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	runs := make([]*Run, 0, len(mgr.order))
+	for i := len(mgr.order) - 1; i >= 0; i-- {
+		runs = append(runs, mgr.runs[mgr.order[i]])
+	}
+	return runs
+}
+
+// Start begins a pending run in the background
+func (mgr *Manager) Start(id string) (*Run, error) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	run, ok := mgr.runs[id]
+	if !ok {
+		return nil, ErrRunNotFound
+	}
+	if mgr.active != nil {
+		return nil, ErrRunActive
+	}
+	if run.Status() != domain.StatusPending {
+		return nil, ErrRunState
+	}
+
+	// Loading into the global viper instance is safe now no run is active
+	cfg, err := config.Parse(run.config, run.configType)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &types.Metrics{ErrorTypes: make(map[string]int64)}
+	m.InitializeLatencyHistogram()
+
+	// The use case moves the run to running only if it is still pending,
+	// so a concurrent cancel wins cleanly
+	_, err = mgr.executions.StartExecution(run.ID, func(ctx context.Context, started func()) error {
+		err := mgr.runner(ctx, cfg, m, run.Options, started)
+		mgr.mu.Lock()
+		mgr.active = nil
+		mgr.mu.Unlock()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	run.mu.Lock()
+	run.cfg = cfg
+	run.metrics = m
+	run.mu.Unlock()
+	mgr.active = run
+
+	go mgr.finished(run)
+	return run, nil
+}
+
+// finished logs the outcome of run once it ends and prunes the history
+func (mgr *Manager) finished(run *Run) {
+	<-run.Done()
+	log.Printf("🏁 Run %s %s", run.ID, run.Status())
+
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	mgr.prune()
+}
+
+// Stop ends a running workload early, keeping its results
+func (mgr *Manager) Stop(id string) (*Run, error) {
+	run, err := mgr.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := mgr.executions.StopExecution(id); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// Cancel aborts a pending or running run. A running workload is stopped
+// and its partial results are kept but marked cancelled.
+func (mgr *Manager) Cancel(id string) (*Run, error) {
+	run, err := mgr.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := mgr.executions.CancelExecution(id); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// Shutdown cancels the active run and waits for it to finish or ctx to end
+func (mgr *Manager) Shutdown(ctx context.Context) error {
+	mgr.mu.Lock()
+	active := mgr.active
+	mgr.mu.Unlock()
+	if active == nil {
+		return nil
+	}
+	if _, err := mgr.Cancel(active.ID); err != nil && !errors.Is(err, ErrRunState) {
+		return err
+	}
+	select {
+	case <-active.Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// prune drops the oldest finished runs beyond MaxHistory; callers hold mgr.mu
+func (mgr *Manager) prune() {
+	if mgr.opts.MaxHistory <= 0 {
+		return
+	}
+	var finished []string
+	for _, id := range mgr.order {
+		if mgr.runs[id].exec.State().Finished() {
+			finished = append(finished, id)
+		}
+	}
+	if len(finished) <= mgr.opts.MaxHistory {
+		return
+	}
+
+	drop := make(map[string]bool)
+	for _, id := range finished[:len(finished)-mgr.opts.MaxHistory] {
+		drop[id] = true
+		delete(mgr.runs, id)
+		mgr.executions.ForgetExecution(id)
+	}
+	kept := mgr.order[:0]
+	for _, id := range mgr.order {
+		if !drop[id] {
+			kept = append(kept, id)
+		}
+	}
+	mgr.order = kept
+}
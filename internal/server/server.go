@@ -0,0 +1,382 @@
+// Package server exposes an HTTP API to submit, start, stop and cancel
+// workload runs, stream their live metrics and fetch their results, so a
+// dedicated load host can be driven remotely.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/core/usecases"
+	"github.com/elchinoo/stormdb/internal/metrics"
+)
+
+// maxConfigBytes limits the size of a submitted configuration document
+const maxConfigBytes = 1 << 20
+
+// Options configures the API server
+type Options struct {
+	Addr          string        // Listen address, e.g. 127.0.0.1:8080
+	Token         string        // Bearer token required by /api routes; empty disables auth
+	EventInterval time.Duration // Default interval between live metric events
+	Version       string        // StormDB version reported in results
+}
+
+// Server serves the run control API
+type Server struct {
+	mgr  *Manager
+	opts Options
+}
+
+// New creates an API server for the runs of mgr
+func New(mgr *Manager, opts Options) *Server {
+	if opts.EventInterval <= 0 {
+		opts.EventInterval = time.Second
+	}
+	return &Server{mgr: mgr, opts: opts}
+}
+
+// Handler returns the HTTP handler of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+
+	api := http.NewServeMux()
+	api.HandleFunc("GET /api/v1/runs", s.handleListRuns)
+	api.HandleFunc("POST /api/v1/runs", s.handleSubmitRun)
+	api.HandleFunc("GET /api/v1/runs/{id}", s.handleGetRun)
+	api.HandleFunc("POST /api/v1/runs/{id}/start", s.handleStartRun)
+	api.HandleFunc("POST /api/v1/runs/{id}/stop", s.handleStopRun)
+	api.HandleFunc("POST /api/v1/runs/{id}/cancel", s.handleCancelRun)
+	api.HandleFunc("GET /api/v1/runs/{id}/metrics", s.handleRunMetrics)
+	api.HandleFunc("GET /api/v1/runs/{id}/events", s.handleRunEvents)
+	api.HandleFunc("GET /api/v1/runs/{id}/results", s.handleRunResults)
+	mux.Handle("/api/", s.authenticate(api))
+
+	return mux
+}
+
+// ListenAndServe serves the API until ctx is done, then cancels the active
+// run and shuts the listener down
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.opts.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := s.mgr.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️  Active run did not finish before shutdown: %v", err)
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down API server: %w", err)
+	}
+	return nil
+}
+
+// authenticate requires the configured bearer token
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.opts.Token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "version": s.opts.Version})
+}
+
+func (s *Server) handleListRuns(w http.ResponseWriter, _ *http.Request) {
+	runs := s.mgr.List()
+	infos := make([]RunInfo, 0, len(runs))
+	for _, run := range runs {
+		infos = append(infos, run.Info())
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// handleSubmitRun registers a run from a YAML or JSON configuration
+// document in the request body. Query parameters: name, setup, rebuild and
+// start (start the run right away).
+func (s *Server) handleSubmitRun(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxConfigBytes))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("failed to read configuration: %w", err))
+		return
+	}
+	if len(data) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("request body must contain a configuration document"))
+		return
+	}
+
+	configType := "yaml"
+	if strings.Contains(r.Header.Get("Content-Type"), "json") {
+		configType = "json"
+	}
+
+	q := r.URL.Query()
+	opts := RunOptions{}
+	for name, dest := range map[string]*bool{"setup": &opts.Setup, "rebuild": &opts.Rebuild} {
+		if *dest, err = queryBool(q.Get(name)); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid %s: %w", name, err))
+			return
+		}
+	}
+	start, err := queryBool(q.Get("start"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid start: %w", err))
+		return
+	}
+
+	run, err := s.mgr.Submit(q.Get("name"), data, configType, opts)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	log.Printf("📥 Run %s submitted (%s, %s)", run.ID, run.Info().Workload, run.Info().Duration)
+
+	if start {
+		if _, err := s.mgr.Start(run.ID); err != nil {
+			writeError(w, statusFor(err), fmt.Errorf("run %s submitted but not started: %w", run.ID, err))
+			return
+		}
+		log.Printf("🚀 Run %s started", run.ID)
+	}
+	writeJSON(w, http.StatusCreated, run.Info())
+}
+
+func (s *Server) handleGetRun(w http.ResponseWriter, r *http.Request) {
+	run, err := s.mgr.Get(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, run.Info())
+}
+
+func (s *Server) handleStartRun(w http.ResponseWriter, r *http.Request) {
+	run, err := s.mgr.Start(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	log.Printf("🚀 Run %s started", run.ID)
+	writeJSON(w, http.StatusAccepted, run.Info())
+}
+
+func (s *Server) handleStopRun(w http.ResponseWriter, r *http.Request) {
+	run, err := s.mgr.Stop(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	log.Printf("🛑 Run %s stopping", run.ID)
+	writeJSON(w, http.StatusAccepted, run.Info())
+}
+
+func (s *Server) handleCancelRun(w http.ResponseWriter, r *http.Request) {
+	run, err := s.mgr.Cancel(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	log.Printf("🛑 Run %s cancelled", run.ID)
+	writeJSON(w, http.StatusAccepted, run.Info())
+}
+
+func (s *Server) handleRunMetrics(w http.ResponseWriter, r *http.Request) {
+	run, err := s.mgr.Get(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	snapshot, ok := run.Snapshot(nil)
+	if !ok {
+		writeError(w, http.StatusConflict, errors.New("run has not started"))
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+// handleRunEvents streams server-sent events: "status" with the run info
+// whenever it changes, "metrics" with a snapshot every interval while the
+// workload runs, and a last "status" event when the run finishes.
+func (s *Server) handleRunEvents(w http.ResponseWriter, r *http.Request) {
+	run, err := s.mgr.Get(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	interval := s.opts.EventInterval
+	if v := r.URL.Query().Get("interval"); v != "" {
+		if interval, err = time.ParseDuration(v); err != nil || interval < 100*time.Millisecond {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid interval %q, minimum is 100ms", v))
+			return
+		}
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event string, v interface{}) bool {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	info := run.Info()
+	if !send("status", info) {
+		return
+	}
+	var prev *Snapshot
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-run.Done():
+			send("status", run.Info())
+			return
+		case <-ticker.C:
+			if current := run.Info(); current.Status != info.Status || current.StartedAt != nil && info.StartedAt == nil {
+				info = current
+				if !send("status", info) {
+					return
+				}
+			}
+			if info.StartedAt == nil {
+				continue
+			}
+			if snapshot, ok := run.Snapshot(prev); ok {
+				prev = snapshot
+				if !send("metrics", snapshot) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// handleRunResults returns the results of a finished run in the format
+// given by the format query parameter: json (default), csv, junit or text
+func (s *Server) handleRunResults(w http.ResponseWriter, r *http.Request) {
+	run, err := s.mgr.Get(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = metrics.OutputJSON
+	}
+	if err := metrics.ValidateOutputFormat(format); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if format == metrics.OutputText {
+		cfg, m, interrupted, endTime, err := run.Results()
+		if err != nil {
+			writeError(w, statusFor(err), errors.New("run has no results yet"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		metrics.ReportTo(w, cfg, m, interrupted, endTime)
+		return
+	}
+
+	summary, err := run.Summary()
+	if err != nil {
+		writeError(w, statusFor(err), errors.New("run has no results yet"))
+		return
+	}
+	summary.StormDBVersion = s.opts.Version
+
+	switch format {
+	case metrics.OutputCSV:
+		w.Header().Set("Content-Type", "text/csv")
+	case metrics.OutputJUnit:
+		w.Header().Set("Content-Type", "application/xml")
+	default:
+		w.Header().Set("Content-Type", "application/json")
+	}
+	if err := metrics.WriteSummary(w, format, summary); err != nil {
+		log.Printf("⚠️  Failed to write results of run %s: %v", run.ID, err)
+	}
+}
+
+// statusFor maps manager errors to HTTP status codes
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, ErrRunNotFound), errors.Is(err, usecases.ErrExecutionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrRunActive), errors.Is(err, ErrRunState):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// queryBool parses an optional boolean query parameter
+func queryBool(v string) (bool, error) {
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("⚠️  Failed to write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
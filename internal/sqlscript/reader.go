@@ -0,0 +1,328 @@
+// Package sqlscript streams SQL scripts, such as pg_dump plain-format output,
+// one statement at a time. Data of COPY ... FROM stdin blocks is passed
+// through line by line, so scripts of any size can be read in bounded memory.
+package sqlscript
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Kind identifies what an Item holds
+type Kind int
+
+const (
+	// Statement is a complete SQL statement, including its terminating semicolon
+	Statement Kind = iota
+	// CopyStart is a COPY ... FROM stdin statement; CopyRow items follow
+	CopyStart
+	// CopyRow is one data line of a COPY block, including its newline
+	CopyRow
+	// CopyEnd is the \. line that ends a COPY block
+	CopyEnd
+	// MetaCommand is a psql backslash command line such as \connect
+	MetaCommand
+)
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	switch k {
+	case Statement:
+		return "statement"
+	case CopyStart:
+		return "copy_start"
+	case CopyRow:
+		return "copy_row"
+	case CopyEnd:
+		return "copy_end"
+	case MetaCommand:
+		return "meta_command"
+	default:
+		return fmt.Sprintf("kind(%d)", int(k))
+	}
+}
+
+// Item is one element of a script
+type Item struct {
+	Kind   Kind
+	Text   string // Statement text without leading comments, or the raw line
+	Offset int64  // Byte offset in the script just past this item
+}
+
+// copyFromStdin matches COPY statements whose data follows in the script
+var copyFromStdin = regexp.MustCompile(`(?is)^COPY\s.*\sFROM\s+STDIN\b`)
+
+// IsCopyFromStdin reports whether stmt is a COPY whose data follows it in the script
+func IsCopyFromStdin(stmt string) bool {
+	return copyFromStdin.MatchString(strings.TrimSpace(stmt))
+}
+
+// Lexer states of the statement scanner
+const (
+	stateNormal       = iota
+	stateQuote        // '...'
+	stateEscapeQuote  // E'...' with backslash escapes
+	stateIdentifier   // "..."
+	stateLineComment  // -- to end of line
+	stateBlockComment // /* ... */, which may nest
+	stateDollarQuote  // $tag$ ... $tag$
+)
+
+// Reader reads Items from a SQL script
+type Reader struct {
+	r          *bufio.Reader
+	offset     int64
+	copyHeader string
+}
+
+// NewReader returns a Reader positioned at the start of a script
+func NewReader(r io.Reader) *Reader {
+	return NewReaderAt(r, 0, "")
+}
+
+// NewReaderAt returns a Reader for a script whose remaining input r starts
+// at offset. copyHeader is the COPY statement whose data block contains the
+// offset, or "" when the offset lies between statements. Together they
+// resume reading at an Item boundary recorded earlier.
+func NewReaderAt(r io.Reader, offset int64, copyHeader string) *Reader {
+	return &Reader{r: bufio.NewReaderSize(r, 1<<20), offset: offset, copyHeader: copyHeader}
+}
+
+// Offset returns the number of script bytes consumed so far
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// CopyHeader returns the COPY statement of the data block being read, or ""
+// outside COPY data
+func (r *Reader) CopyHeader() string {
+	return r.copyHeader
+}
+
+// Next returns the next Item, or io.EOF at the end of the script. A script
+// ending inside a COPY block returns io.ErrUnexpectedEOF.
+func (r *Reader) Next() (Item, error) {
+	if r.copyHeader != "" {
+		return r.nextCopyLine()
+	}
+	return r.nextStatement()
+}
+
+// nextCopyLine returns the next line of a COPY data block
+func (r *Reader) nextCopyLine() (Item, error) {
+	line, err := r.r.ReadString('\n')
+	r.offset += int64(len(line))
+	if err != nil && err != io.EOF {
+		return Item{}, err
+	}
+	if line == "" {
+		return Item{}, io.ErrUnexpectedEOF
+	}
+
+	if strings.TrimRight(line, "\r\n") == `\.` {
+		r.copyHeader = ""
+		return Item{Kind: CopyEnd, Text: line, Offset: r.offset}, nil
+	}
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+	return Item{Kind: CopyRow, Text: line, Offset: r.offset}, nil
+}
+
+// readByte reads one byte, counting it in the offset
+func (r *Reader) readByte() (byte, error) {
+	c, err := r.r.ReadByte()
+	if err == nil {
+		r.offset++
+	}
+	return c, err
+}
+
+// peekIs reports whether the next bytes are s, without consuming them
+func (r *Reader) peekIs(s string) bool {
+	next, _ := r.r.Peek(len(s))
+	return string(next) == s
+}
+
+// skip consumes n bytes that were already peeked
+func (r *Reader) skip(n int) {
+	discarded, _ := r.r.Discard(n)
+	r.offset += int64(discarded)
+}
+
+// dollarTag returns the rest of a dollar-quote tag ("tag$" or "$") if one
+// follows the '$' just read, or ""
+func (r *Reader) dollarTag() string {
+	next, _ := r.r.Peek(64)
+	for i, c := range next {
+		switch {
+		case c == '$':
+			return string(next[:i+1])
+		case c == '_' || c >= 0x80 || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// isIdentChar reports whether c can be part of an identifier or number
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isSpace reports whether c is SQL whitespace
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// nextStatement scans up to the next top-level semicolon
+func (r *Reader) nextStatement() (Item, error) {
+	var b strings.Builder
+	state := stateNormal
+	depth := 0  // Block comment nesting
+	tag := ""   // Closing dollar-quote tag
+	start := -1 // Index of the first significant byte in b
+	var prev, prevPrev byte
+
+	for {
+		c, err := r.readByte()
+		if err == io.EOF {
+			if start >= 0 {
+				return r.statement(b.String()[start:]), nil
+			}
+			return Item{}, io.EOF
+		}
+		if err != nil {
+			return Item{}, err
+		}
+
+		switch state {
+		case stateNormal:
+			switch {
+			case c == '\\' && start < 0:
+				line, err := r.r.ReadString('\n')
+				r.offset += int64(len(line))
+				if err != nil && err != io.EOF {
+					return Item{}, err
+				}
+				return Item{Kind: MetaCommand, Text: strings.TrimRight(`\`+line, "\r\n"), Offset: r.offset}, nil
+			case c == '-' && r.peekIs("-"):
+				r.skip(1)
+				b.WriteString("--")
+				state = stateLineComment
+				continue
+			case c == '/' && r.peekIs("*"):
+				r.skip(1)
+				b.WriteString("/*")
+				state, depth = stateBlockComment, 1
+				continue
+			case c == ';':
+				if start < 0 {
+					// Empty statement
+					b.Reset()
+					continue
+				}
+				b.WriteByte(c)
+				return r.statement(b.String()[start:]), nil
+			case isSpace(c):
+				b.WriteByte(c)
+				prevPrev, prev = prev, c
+				continue
+			}
+
+			if start < 0 {
+				start = b.Len()
+			}
+			b.WriteByte(c)
+			switch {
+			case c == '\'':
+				state = stateQuote
+				if (prev == 'E' || prev == 'e') && !isIdentChar(prevPrev) {
+					state = stateEscapeQuote
+				}
+			case c == '"':
+				state = stateIdentifier
+			case c == '$' && !isIdentChar(prev):
+				if rest := r.dollarTag(); rest != "" {
+					r.skip(len(rest))
+					b.WriteString(rest)
+					tag = "$" + rest
+					state = stateDollarQuote
+				}
+			}
+
+		case stateQuote, stateEscapeQuote:
+			b.WriteByte(c)
+			if c == '\\' && state == stateEscapeQuote {
+				if next, err := r.readByte(); err == nil {
+					b.WriteByte(next)
+				}
+			} else if c == '\'' {
+				if r.peekIs("'") {
+					r.skip(1)
+					b.WriteByte('\'')
+				} else {
+					state = stateNormal
+				}
+			}
+
+		case stateIdentifier:
+			b.WriteByte(c)
+			if c == '"' {
+				if r.peekIs(`"`) {
+					r.skip(1)
+					b.WriteByte('"')
+				} else {
+					state = stateNormal
+				}
+			}
+
+		case stateLineComment:
+			b.WriteByte(c)
+			if c == '\n' {
+				state = stateNormal
+			}
+
+		case stateBlockComment:
+			b.WriteByte(c)
+			if c == '/' && r.peekIs("*") {
+				r.skip(1)
+				b.WriteByte('*')
+				depth++
+			} else if c == '*' && r.peekIs("/") {
+				r.skip(1)
+				b.WriteByte('/')
+				if depth--; depth == 0 {
+					state = stateNormal
+				}
+			}
+
+		case stateDollarQuote:
+			b.WriteByte(c)
+			if c == '$' && r.peekIs(tag[1:]) {
+				r.skip(len(tag) - 1)
+				b.WriteString(tag[1:])
+				state = stateNormal
+			}
+		}
+		prevPrev, prev = prev, c
+	}
+}
+
+// statement returns the Item for a scanned statement. COPY ... FROM stdin
+// switches to COPY data mode, which starts on the line after the statement.
+func (r *Reader) statement(text string) Item {
+	if IsCopyFromStdin(text) {
+		rest, _ := r.r.ReadString('\n')
+		r.offset += int64(len(rest))
+		r.copyHeader = text
+		return Item{Kind: CopyStart, Text: text, Offset: r.offset}
+	}
+	return Item{Kind: Statement, Text: text, Offset: r.offset}
+}
@@ -46,6 +46,9 @@ func (s *SimpleBuiltinWorkload) Setup(ctx context.Context, pool *pgxpool.Pool, c
 	return nil
 }
 
+// builtinOperations names the operations of the simple workload
+var builtinOperations = [...]string{"select", "insert", "update"}
+
 // Run executes the simple workload
 func (s *SimpleBuiltinWorkload) Run(ctx context.Context, pool *pgxpool.Pool, config *types.Config, metrics *types.Metrics) error {
 	if !s.initialized {
@@ -66,22 +69,24 @@ func (s *SimpleBuiltinWorkload) Run(ctx context.Context, pool *pgxpool.Pool, con
 		default:
 			// Perform a simple operation
 			operation := rand.Intn(3)
+			opCtx := types.WithOperation(ctx, builtinOperations[operation])
 			start := time.Now()
 
 			var err error
 			switch operation {
 			case 0: // SELECT
 				var count int
-				err = pool.QueryRow(ctx, "SELECT COUNT(*) FROM loadtest").Scan(&count)
+				err = pool.QueryRow(opCtx, "SELECT COUNT(*) FROM loadtest").Scan(&count)
 			case 1: // INSERT
-				_, err = pool.Exec(ctx, "INSERT INTO loadtest (data) VALUES ($1)",
+				_, err = pool.Exec(opCtx, "INSERT INTO loadtest (data) VALUES ($1)",
 					fmt.Sprintf("runtime-data-%d", time.Now().UnixNano()))
 			case 2: // UPDATE
-				_, err = pool.Exec(ctx, "UPDATE loadtest SET data = $1 WHERE id = $2",
+				_, err = pool.Exec(opCtx, "UPDATE loadtest SET data = $1 WHERE id = $2",
 					fmt.Sprintf("updated-%d", time.Now().UnixNano()), rand.Intn(config.Scale)+1)
 			}
 
 			latency := time.Since(start)
+			metrics.RecordOperation(builtinOperations[operation], latency.Nanoseconds(), 1, err == nil)
 
 			if err != nil {
 				metrics.Errors++
@@ -184,11 +189,12 @@ func (t *TPCCBuiltinWorkload) Run(ctx context.Context, pool *pgxpool.Pool, confi
 			start := time.Now()
 
 			// Simple "new order" transaction simulation
-			_, err := pool.Exec(ctx,
+			_, err := pool.Exec(types.WithOperation(ctx, "new_order"),
 				"INSERT INTO orders (o_w_id, o_ol_cnt) VALUES ($1, $2)",
 				rand.Intn(config.Scale)+1, rand.Intn(10)+1)
 
 			latency := time.Since(start)
+			metrics.RecordOperation("new_order", latency.Nanoseconds(), 1, err == nil)
 
 			if err != nil {
 				metrics.Errors++
@@ -61,6 +61,11 @@ func (f *Factory) Get(workloadType string) (plugin.Workload, error) {
 	return workload, nil
 }
 
+// Metadata returns the metadata of the plugin providing the workload type
+func (f *Factory) Metadata(workloadType string) (*plugin.PluginMetadata, error) {
+	return f.pluginLoader.GetWorkloadMetadata(workloadType)
+}
+
 // GetAvailableWorkloads returns a list of all available workload types
 func (f *Factory) GetAvailableWorkloads() []string {
 	// Only use plugin workloads
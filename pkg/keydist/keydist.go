@@ -0,0 +1,279 @@
+// Package keydist provides key distributions that workloads use to choose
+// which rows to operate on. Real traffic is rarely uniform: a small set of
+// popular rows receives most of the operations, which drives lock contention
+// and cache behaviour. The distributions here let every workload reproduce
+// that skew through a single key_distribution configuration option.
+//
+// Supported distributions:
+//   - uniform:    every key is equally likely (default)
+//   - zipfian:    power-law popularity controlled by theta (YCSB style)
+//   - hotspot:    a fixed share of operations hits a fixed share of keys
+//   - latest:     zipfian skew towards the most recently inserted keys
+//   - sequential: keys are visited in order, wrapping around
+//
+// Hot keys are the lowest keys for zipfian and hotspot and the highest keys
+// for latest, so with serial ids the popular rows are the oldest or newest.
+//
+// A Chooser is safe for concurrent use by multiple workers; each worker
+// passes its own *rand.Rand. A nil Chooser behaves as uniform.
+package keydist
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// Distribution names
+const (
+	Uniform    = "uniform"
+	Zipfian    = "zipfian"
+	Hotspot    = "hotspot"
+	Latest     = "latest"
+	Sequential = "sequential"
+)
+
+// Defaults applied when options are left unset
+const (
+	DefaultTheta       = 0.99
+	DefaultHotspotKeys = 0.2
+	DefaultHotspotOps  = 0.8
+)
+
+// Config selects and parameterizes a key distribution
+type Config struct {
+	Type        string  // Distribution name (default: uniform)
+	Theta       float64 // Zipfian/latest skew, 0 < theta < 1
+	HotspotKeys float64 // Fraction of the keyspace that is hot
+	HotspotOps  float64 // Fraction of operations that hit the hot keys
+}
+
+// ConfigFrom extracts the key distribution settings from the run configuration
+func ConfigFrom(cfg *types.Config) Config {
+	return Config{
+		Type:        cfg.KeyDistribution.Type,
+		Theta:       cfg.KeyDistribution.Theta,
+		HotspotKeys: cfg.KeyDistribution.HotspotKeys,
+		HotspotOps:  cfg.KeyDistribution.HotspotOps,
+	}
+}
+
+// withDefaults fills unset options
+func (c Config) withDefaults() Config {
+	if c.Type == "" {
+		c.Type = Uniform
+	}
+	if c.Theta == 0 {
+		c.Theta = DefaultTheta
+	}
+	if c.HotspotKeys == 0 {
+		c.HotspotKeys = DefaultHotspotKeys
+	}
+	if c.HotspotOps == 0 {
+		c.HotspotOps = DefaultHotspotOps
+	}
+	return c
+}
+
+// Validate checks the distribution name and its parameters
+func (c Config) Validate() error {
+	c = c.withDefaults()
+	switch c.Type {
+	case Uniform, Sequential:
+	case Zipfian, Latest:
+		if c.Theta <= 0 || c.Theta >= 1 {
+			return fmt.Errorf("theta must be between 0 and 1 (exclusive), got: %g", c.Theta)
+		}
+	case Hotspot:
+		if c.HotspotKeys <= 0 || c.HotspotKeys >= 1 {
+			return fmt.Errorf("hotspot_keys must be between 0 and 1 (exclusive), got: %g", c.HotspotKeys)
+		}
+		if c.HotspotOps <= 0 || c.HotspotOps > 1 {
+			return fmt.Errorf("hotspot_ops must be between 0 and 1, got: %g", c.HotspotOps)
+		}
+	default:
+		return fmt.Errorf("invalid key distribution: %s (valid: uniform, zipfian, hotspot, latest, sequential)", c.Type)
+	}
+	return nil
+}
+
+// String describes the distribution for reports and run metadata
+func (c Config) String() string {
+	c = c.withDefaults()
+	switch c.Type {
+	case Zipfian, Latest:
+		return fmt.Sprintf("%s(theta=%g)", c.Type, c.Theta)
+	case Hotspot:
+		return fmt.Sprintf("hotspot(%g%% ops on %g%% keys)", c.HotspotOps*100, c.HotspotKeys*100)
+	default:
+		return c.Type
+	}
+}
+
+// maxZipfians bounds the zipfian generators a Chooser caches. A keyspace
+// that keeps growing (latest over a table receiving inserts) asks for a new
+// size on almost every call, so the cache is dropped when it fills up.
+const maxZipfians = 64
+
+// Chooser draws keys from the configured distribution. Zipfian constants
+// and sequential cursors are kept per keyspace size, so one Chooser can
+// serve every table a workload touches.
+type Chooser struct {
+	cfg     Config
+	zipfs   sync.Map // int64 -> *zipfian
+	cursors sync.Map // int64 -> *int64
+
+	// Guards creating zipfian generators. The zeta sum of the largest
+	// keyspace seen so far is kept, so a growing keyspace only adds the
+	// terms of its new keys, like YCSB's incremental zeta.
+	mu     sync.Mutex
+	cached int
+	zetaN  int64
+	zeta   float64
+}
+
+// New creates a Chooser for the given configuration
+func New(cfg Config) (*Chooser, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Chooser{cfg: cfg.withDefaults()}, nil
+}
+
+// FromConfig creates a Chooser from the run configuration
+func FromConfig(cfg *types.Config) (*Chooser, error) {
+	return New(ConfigFrom(cfg))
+}
+
+// String describes the distribution
+func (c *Chooser) String() string {
+	if c == nil {
+		return Uniform
+	}
+	return c.cfg.String()
+}
+
+// Intn returns a key in [0, n), like rand.Intn
+func (c *Chooser) Intn(rng *rand.Rand, n int) int {
+	return int(c.Int63n(rng, int64(n)))
+}
+
+// Int63n returns a key in [0, n), like rand.Int63n
+func (c *Chooser) Int63n(rng *rand.Rand, n int64) int64 {
+	if n <= 1 {
+		return 0
+	}
+	if c == nil {
+		return rng.Int63n(n)
+	}
+
+	switch c.cfg.Type {
+	case Zipfian:
+		return c.zipfian(n).next(rng)
+	case Latest:
+		return n - 1 - c.zipfian(n).next(rng)
+	case Hotspot:
+		hot := int64(math.Ceil(float64(n) * c.cfg.HotspotKeys))
+		if hot >= n {
+			return rng.Int63n(n)
+		}
+		if rng.Float64() < c.cfg.HotspotOps {
+			return rng.Int63n(hot)
+		}
+		return hot + rng.Int63n(n-hot)
+	case Sequential:
+		cursor, _ := c.cursors.LoadOrStore(n, new(int64))
+		return (atomic.AddInt64(cursor.(*int64), 1) - 1) % n
+	default:
+		return rng.Int63n(n)
+	}
+}
+
+// zipfian returns the cached generator for keyspace size n
+func (c *Chooser) zipfian(n int64) *zipfian {
+	if z, ok := c.zipfs.Load(n); ok {
+		return z.(*zipfian)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if z, ok := c.zipfs.Load(n); ok {
+		return z.(*zipfian)
+	}
+
+	if c.cached >= maxZipfians {
+		c.zipfs.Range(func(key, _ any) bool {
+			c.zipfs.Delete(key)
+			return true
+		})
+		c.cached = 0
+	}
+	z := newZipfian(n, c.cfg.Theta, c.zetaFor(n))
+	c.zipfs.Store(n, z)
+	c.cached++
+	return z
+}
+
+// zetaFor returns the zeta constant of keyspace size n, extending the sum
+// of the largest keyspace seen so far when n is larger. The caller holds mu.
+func (c *Chooser) zetaFor(n int64) float64 {
+	if n < c.zetaN {
+		return zeta(0, n, c.cfg.Theta, 0)
+	}
+	c.zeta = zeta(c.zetaN, n, c.cfg.Theta, c.zeta)
+	c.zetaN = n
+	return c.zeta
+}
+
+// zeta adds the terms of keys from+1..n to the zeta sum of the first from keys
+func zeta(from, n int64, theta, sum float64) float64 {
+	for i := from + 1; i <= n; i++ {
+		sum += 1 / math.Pow(float64(i), theta)
+	}
+	return sum
+}
+
+// zipfian implements the Gray et al. zipfian generator used by YCSB. The
+// zeta constant is computed once per keyspace size.
+type zipfian struct {
+	n     float64
+	theta float64
+	alpha float64
+	zetan float64
+	eta   float64
+	half  float64 // 1 + 0.5^theta
+}
+
+func newZipfian(n int64, theta, zetan float64) *zipfian {
+	zeta2 := 1 + math.Pow(0.5, theta)
+
+	return &zipfian{
+		n:     float64(n),
+		theta: theta,
+		alpha: 1 / (1 - theta),
+		zetan: zetan,
+		eta:   (1 - math.Pow(2/float64(n), 1-theta)) / (1 - zeta2/zetan),
+		half:  zeta2,
+	}
+}
+
+// next returns a key in [0, n) with rank 0 being the most popular
+func (z *zipfian) next(rng *rand.Rand) int64 {
+	u := rng.Float64()
+	uz := u * z.zetan
+	if uz < 1 {
+		return 0
+	}
+	if uz < z.half {
+		return 1
+	}
+	key := int64(z.n * math.Pow(z.eta*u-z.eta+1, z.alpha))
+	if key >= int64(z.n) {
+		key = int64(z.n) - 1
+	}
+	return key
+}
@@ -104,6 +104,36 @@ type Workload interface {
 	Run(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics) error
 }
 
+// ReadPoolSelector picks the connection pool that should serve the next
+// read-only operation. Implementations balance reads across replica pools.
+type ReadPoolSelector interface {
+	// ReadPool returns the pool for the next read operation
+	ReadPool() *pgxpool.Pool
+}
+
+// ReadRoutingWorkload is an optional interface for workloads with a clean
+// read/write split. When read replicas are configured, StormDB hands the
+// workload a selector before Run is called; writes keep using the primary
+// pool passed to Run.
+type ReadRoutingWorkload interface {
+	Workload
+
+	// SetReadPoolSelector provides the selector used for read-only operations
+	SetReadPoolSelector(selector ReadPoolSelector)
+}
+
+// PreparedStatementWorkload is an optional interface for workloads that
+// declare the statements they execute. When query_exec_mode is "prepared",
+// every statement is explicitly prepared on each pool connection under its
+// own SQL text, so the workload's existing Exec/Query calls use the
+// prepared statement without any code changes.
+type PreparedStatementWorkload interface {
+	Workload
+
+	// PreparedStatements returns the SQL text of statements to prepare
+	PreparedStatements(cfg *types.Config) []string
+}
+
 // WorkloadPlugin is the main interface that all workload plugins must implement.
 // It provides plugin metadata and factory methods for creating workload instances.
 type WorkloadPlugin interface {
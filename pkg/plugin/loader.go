@@ -274,6 +274,25 @@ func (pl *PluginLoader) GetWorkload(workloadType string) (Workload, error) {
 	return pluginInfo.Plugin.CreateWorkload(workloadType)
 }
 
+// GetWorkloadMetadata returns the metadata of the plugin that provides the
+// specified workload type
+func (pl *PluginLoader) GetWorkloadMetadata(workloadType string) (*PluginMetadata, error) {
+	pl.mutex.RLock()
+	defer pl.mutex.RUnlock()
+
+	pluginName, exists := pl.workloadTypes[workloadType]
+	if !exists {
+		return nil, fmt.Errorf("unsupported workload type: %s", workloadType)
+	}
+
+	pluginInfo, exists := pl.plugins[pluginName]
+	if !exists || pluginInfo.Metadata == nil {
+		return nil, fmt.Errorf("plugin not found for workload type %s: %s", workloadType, pluginName)
+	}
+
+	return pluginInfo.Metadata, nil
+}
+
 // ListPlugins returns information about all discovered plugins
 func (pl *PluginLoader) ListPlugins() []*PluginInfo {
 	pl.mutex.RLock()
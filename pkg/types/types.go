@@ -9,7 +9,11 @@
 package types
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -57,6 +61,7 @@ type Config struct {
 	Duration        string `mapstructure:"duration"`         // Benchmark duration (e.g., "5m", "30s")
 	Workers         int    `mapstructure:"workers"`          // Number of concurrent worker threads
 	Connections     int    `mapstructure:"connections"`      // Maximum database connections in pool
+	QueryExecMode   string `mapstructure:"query_exec_mode"`  // pgx query execution mode (cache_statement, cache_describe, describe_exec, exec, simple_protocol, prepared)
 	SummaryInterval string `mapstructure:"summary_interval"` // Interval for progress reports (e.g., "10s", "30s")
 
 	// Progressive scaling configuration for load testing across multiple connection levels
@@ -90,12 +95,58 @@ type Config struct {
 	} `mapstructure:"progressive"`
 
 	// PostgreSQL monitoring and statistics collection options
-	CollectPgStats    bool `mapstructure:"collect_pg_stats"`    // Enable comprehensive PostgreSQL statistics collection
-	PgStatsStatements bool `mapstructure:"pg_stats_statements"` // Enable pg_stat_statements query analysis
+	CollectPgStats       bool `mapstructure:"collect_pg_stats"`        // Enable comprehensive PostgreSQL statistics collection
+	PgStatsStatements    bool `mapstructure:"pg_stats_statements"`     // Enable pg_stat_statements query analysis
+	PgStatsStatementsTop int  `mapstructure:"pg_stats_statements_top"` // Statements reported per dimension (default: 10)
 
 	// Connection management strategy for performance testing
 	ConnectionMode string `mapstructure:"connection_mode"` // "persistent", "transient", or "mixed" for connection overhead analysis
 
+	// Read replica routing for workloads with a read/write split. When enabled,
+	// read-only operations are balanced across the listed endpoints while writes
+	// stay on the primary configured in Database.
+	Replicas struct {
+		Enabled           bool              `mapstructure:"enabled"`             // Route read operations to replicas
+		Balancing         string            `mapstructure:"balancing"`           // "round_robin" (default) or "least_loaded"
+		LagSampleInterval string            `mapstructure:"lag_sample_interval"` // Interval between replay lag samples (default: 5s)
+		MaxLag            string            `mapstructure:"max_lag"`             // Skip replicas lagging further behind, e.g. "500ms" (default: no limit)
+		Endpoints         []ReplicaEndpoint `mapstructure:"endpoints"`           // Replica connection endpoints
+	} `mapstructure:"replicas"`
+
+	// KeyDistribution controls how workloads choose the rows they operate on
+	KeyDistribution struct {
+		Type        string  `mapstructure:"type"`         // uniform (default), zipfian, hotspot, latest, sequential
+		Theta       float64 `mapstructure:"theta"`        // Skew for zipfian/latest, 0 < theta < 1 (default: 0.99)
+		HotspotKeys float64 `mapstructure:"hotspot_keys"` // Fraction of keys that are hot (default: 0.2)
+		HotspotOps  float64 `mapstructure:"hotspot_ops"`  // Fraction of operations on hot keys (default: 0.8)
+	} `mapstructure:"key_distribution"`
+
+	// ExecModeComparison runs the same workload once per query execution mode
+	// and reports throughput and latency deltas against the first mode.
+	ExecModeComparison struct {
+		Enabled  bool     `mapstructure:"enabled"`  // Enable the comparison run
+		Modes    []string `mapstructure:"modes"`    // Modes to compare in order (default: all modes)
+		Duration string   `mapstructure:"duration"` // Run duration per mode (default: top-level duration)
+	} `mapstructure:"exec_mode_comparison"`
+
+	// Fault injection schedule for measuring HA behaviour under load. Each
+	// event fires at a fixed offset from the start of the workload.
+	FaultInjection struct {
+		Enabled        bool         `mapstructure:"enabled"`         // Enable the fault injection schedule
+		RecoveryWindow string       `mapstructure:"recovery_window"` // Error-free window that marks recovery (default: 1s)
+		ObserveTimeout string       `mapstructure:"observe_timeout"` // Maximum time to wait for recovery per event (default: 60s)
+		Events         []FaultEvent `mapstructure:"events"`          // Scheduled fault events
+	} `mapstructure:"fault_injection"`
+
+	// Distributed load generation. When agents are listed, this process
+	// coordinates: it splits workers and connections across the agents,
+	// starts them together and merges their metrics into one report.
+	Distributed struct {
+		Agents     []string `mapstructure:"agents"`      // Agent addresses (host:port or URL)
+		Token      string   `mapstructure:"token"`       // Bearer token of the agents (default: $STORMDB_AGENT_TOKEN)
+		StartDelay string   `mapstructure:"start_delay"` // Lead time for the synchronized start (default: 3s)
+	} `mapstructure:"distributed"`
+
 	// Plugin system configuration
 	Plugins struct {
 		// Paths to search for plugin files (.so, .dll, .dylib)
@@ -122,10 +173,422 @@ type Config struct {
 		TablePrefix      string `mapstructure:"table_prefix"`       // Prefix for results tables
 	} `mapstructure:"results_backend"`
 
+	// Query plan capture for slow or sampled statements. Each captured
+	// statement is re-executed with EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON)
+	// in a rolled-back transaction on a separate connection.
+	Explain struct {
+		Enabled    bool    `mapstructure:"enabled"`     // Capture query plans
+		Threshold  string  `mapstructure:"threshold"`   // Capture statements slower than this (default: 100ms)
+		SampleRate float64 `mapstructure:"sample_rate"` // Fraction of faster statements to capture, 0-1 (default: 0)
+		MaxPlans   int     `mapstructure:"max_plans"`   // Plans captured per operation and statement (default: 3)
+		Dir        string  `mapstructure:"dir"`         // Plan store directory (default: ~/.stormdb/plans)
+	} `mapstructure:"explain"`
+
+	// Host resource sampling of the load generator, from /proc, and
+	// optionally of the database server through SQL
+	HostStats struct {
+		Enabled      bool    `mapstructure:"enabled"`       // Sample host resources during the workload
+		Interval     string  `mapstructure:"interval"`      // Sampling interval (default: 1s)
+		Server       bool    `mapstructure:"server"`        // Also sample pg_stat_io and pg_ls_waldir on the server
+		CPUThreshold float64 `mapstructure:"cpu_threshold"` // Client CPU busy percentage counted as saturated (default: 90)
+		ProcDir      string  `mapstructure:"proc_dir"`      // procfs mount point (default: /proc)
+	} `mapstructure:"host_stats"`
+
+	// Captured SQL traffic replayed by the replay workload. Sessions are
+	// replayed concurrently, each on its own connection and in its order.
+	Replay struct {
+		File           string  `mapstructure:"file"`            // Capture file: PostgreSQL csvlog or jsonlog, or JSONL statements
+		Format         string  `mapstructure:"format"`          // csvlog, jsonlog or jsonl (default: from the file extension)
+		Database       string  `mapstructure:"database"`        // Only replay log entries of this database (default: all)
+		PreserveTiming bool    `mapstructure:"preserve_timing"` // Start statements at their captured offsets
+		Speed          float64 `mapstructure:"speed"`           // Timing multiplier, 2 replays twice as fast (default: 1)
+		Loop           bool    `mapstructure:"loop"`            // Restart the capture until the duration ends
+	} `mapstructure:"replay"`
+
+	// Run history recorded after each run, in the results backend database
+	// when one is enabled, else in a local file store
+	History struct {
+		Disabled bool   `mapstructure:"disabled"` // Do not record runs
+		Dir      string `mapstructure:"dir"`      // File store directory (default: ~/.stormdb/history)
+	} `mapstructure:"history"`
+
 	// Test metadata for enhanced test tracking and organization
 	TestMetadata map[string]interface{} `mapstructure:"test_metadata"` // Additional metadata for test organization
 }
 
+// ReplicaEndpoint describes a read replica connection. Empty fields inherit
+// the corresponding value from the primary Database configuration, so most
+// setups only need to list host and port.
+type ReplicaEndpoint struct {
+	Name     string `mapstructure:"name"`     // Display name used in reports (default: host:port)
+	Host     string `mapstructure:"host"`     // Replica hostname or IP
+	Port     int    `mapstructure:"port"`     // Replica port (default: primary port)
+	Dbname   string `mapstructure:"dbname"`   // Database name (default: primary dbname)
+	Username string `mapstructure:"username"` // Authentication username (default: primary username)
+	Password string `mapstructure:"password"` // Authentication password (default: primary password)
+	Sslmode  string `mapstructure:"sslmode"`  // SSL connection mode (default: primary sslmode)
+}
+
+// FaultEvent describes a single scheduled fault. Built-in actions cover the
+// common cases; "sql" and "shell" run arbitrary statements or scripts such as
+// a promote or network-delay script.
+type FaultEvent struct {
+	Name    string `mapstructure:"name"`    // Display name used in reports and the time series
+	At      string `mapstructure:"at"`      // Offset from workload start (e.g., "30s", "2m")
+	Action  string `mapstructure:"action"`  // "sql", "shell", "terminate_backends", or "checkpoint"
+	SQL     string `mapstructure:"sql"`     // Statement to run when action is "sql"
+	Command string `mapstructure:"command"` // Shell command to run when action is "shell"
+	Timeout string `mapstructure:"timeout"` // Maximum execution time for the action (default: 30s)
+}
+
+// FaultEventResult captures the impact of a fault event on the running
+// workload: how long it took for transactions to succeed again and how
+// many errors were observed until the workload recovered.
+type FaultEventResult struct {
+	Name             string        // Event display name
+	Action           string        // Action that was executed
+	FiredAt          time.Time     // When the action started
+	ActionDuration   time.Duration // How long the action itself took
+	ActionError      string        // Error returned by the action, if any
+	TimeToFirstTxn   time.Duration // Time from firing to the first successful transaction
+	RecoveryTime     time.Duration // Time from firing until the error-free recovery window began
+	ErrorBurst       int64         // Errors and aborted transactions observed before recovery
+	Recovered        bool          // Whether the workload recovered within the observe timeout
+	FirstTxnObserved bool          // Whether any transaction succeeded after the event
+	CommitTracking   bool          // Whether only transactions started after the event counted
+	Resolution       time.Duration // Polling interval the times are rounded up to
+}
+
+// Query execution modes accepted by the query_exec_mode option. All but
+// ExecModePrepared map directly onto pgx.QueryExecMode values.
+const (
+	ExecModeCacheStatement = "cache_statement"
+	ExecModeCacheDescribe  = "cache_describe"
+	ExecModeDescribeExec   = "describe_exec"
+	ExecModeExec           = "exec"
+	ExecModeSimpleProtocol = "simple_protocol"
+	ExecModePrepared       = "prepared"
+)
+
+// QueryExecModes lists every supported mode in comparison order
+var QueryExecModes = []string{
+	ExecModeSimpleProtocol,
+	ExecModeExec,
+	ExecModeDescribeExec,
+	ExecModeCacheDescribe,
+	ExecModeCacheStatement,
+	ExecModePrepared,
+}
+
+// ExecModeResult summarizes a single run of a query execution mode
+// comparison. Latencies are in milliseconds.
+type ExecModeResult struct {
+	Mode         string  // Query execution mode
+	Transactions int64   // Committed transactions
+	Errors       int64   // Errors and aborted transactions
+	TPS          float64 // Committed transactions per second
+	AvgLatencyMs float64 // Average transaction latency
+	P95LatencyMs float64 // 95th percentile transaction latency
+	P99LatencyMs float64 // 99th percentile transaction latency
+	Error        string  // Run failure, if the mode could not be executed
+}
+
+// VectorAccuracyResult summarizes recall and throughput for one ANN index
+// configuration at one search setting (ivfflat.probes or hnsw.ef_search).
+// Latencies are in milliseconds.
+type VectorAccuracyResult struct {
+	Index        string        // Index configuration name (e.g., hnsw_m_16_ef_64)
+	IndexType    string        // ivfflat or hnsw
+	BuildTime    time.Duration // Time taken to build the index
+	SearchParam  string        // Search-time setting that was swept
+	SearchValue  int           // Value of the search-time setting
+	K            int           // Number of neighbours requested
+	Queries      int           // Number of queries evaluated
+	Recall       float64       // Mean recall@k against the exact neighbours (0-1)
+	QPS          float64       // Queries per second across all workers
+	AvgLatencyMs float64       // Average query latency
+	P95LatencyMs float64       // 95th percentile query latency
+	Errors       int64         // Failed queries
+	GroundTruth  string        // Source of the exact neighbours (ground truth file or brute force)
+}
+
+// FilteredSearchResult summarizes recall and latency of filtered or hybrid
+// vector queries for one selectivity bucket and iterative scan setting.
+// Latencies are in milliseconds.
+type FilteredSearchResult struct {
+	Mode          string  // "vector" (filtered ANN) or "hybrid" (vector + full-text RRF)
+	FilterColumn  string  // Column the predicate filters on (tenant_id or category)
+	Selectivity   float64 // Fraction of rows matching the predicate (0-1)
+	IterativeScan string  // pgvector iterative index scan setting, empty if unavailable
+	K             int     // Neighbours requested per query
+	Queries       int     // Number of queries evaluated
+	Recall        float64 // Mean recall@k against the exact filtered results (0-1)
+	QPS           float64 // Queries per second across all workers
+	AvgLatencyMs  float64 // Average query latency
+	P95LatencyMs  float64 // 95th percentile query latency
+	ShortResults  int64   // Queries that returned fewer than K rows
+	Errors        int64   // Failed queries
+}
+
+// IndexBuildResult records the cost of building one ANN index configuration
+// and the query latency and recall measured right after the build.
+// Latencies are in milliseconds.
+type IndexBuildResult struct {
+	Index              string        // Index configuration name (e.g., ivfflat_lists_100)
+	IndexType          string        // ivfflat or hnsw
+	Parameters         string        // Build parameters as passed to WITH (...)
+	MaintenanceWorkMem string        // maintenance_work_mem used for the build
+	ParallelWorkers    int           // max_parallel_maintenance_workers used for the build
+	BuildTime          time.Duration // Time taken to build the index
+	SizeBytes          int64         // On-disk index size
+	K                  int           // Neighbours requested by the post-build queries
+	Queries            int           // Post-build queries evaluated
+	Recall             float64       // Mean recall@k of the post-build queries (0-1)
+	QPS                float64       // Post-build queries per second
+	AvgLatencyMs       float64       // Average post-build query latency
+	P95LatencyMs       float64       // 95th percentile post-build query latency
+	Error              string        // Build failure, if the index could not be built
+}
+
+// QuantizationResult compares one vector storage variant (vector, halfvec,
+// sparsevec, bit) by storage cost, index build time, recall and throughput.
+// Latencies are in milliseconds.
+type QuantizationResult struct {
+	Variant        string        // Variant name (e.g., halfvec, bit_hamming, bit_rerank)
+	Storage        string        // Column type queried (vector, halfvec, sparsevec, bit)
+	Distance       string        // Distance operator used by the variant's index
+	Index          string        // Index configuration name (e.g., hnsw_m_16_ef_64)
+	StorageBytes   int64         // Total size of the variant's column values
+	IndexSizeBytes int64         // On-disk index size
+	BuildTime      time.Duration // Time taken to build the index
+	K              int           // Neighbours requested per query
+	Queries        int           // Number of queries evaluated
+	Recall         float64       // Mean recall@k against the exact full-precision neighbours (0-1)
+	QPS            float64       // Queries per second across all workers
+	AvgLatencyMs   float64       // Average query latency
+	P95LatencyMs   float64       // 95th percentile query latency
+	Errors         int64         // Failed queries
+	Error          string        // Setup failure, if the variant could not be tested
+}
+
+// BulkLoadResult records the throughput and WAL cost of one bulk load method
+// under one table configuration. Latencies are in milliseconds.
+type BulkLoadResult struct {
+	Method            string        // insert, unnest, copy_binary, copy_text or copy_csv
+	Table             string        // logged or unlogged
+	Indexes           bool          // Whether the secondary indexes existed during the load
+	SynchronousCommit string        // synchronous_commit setting of the loading sessions
+	BatchSize         int           // Rows per statement or COPY
+	Rows              int64         // Rows loaded
+	Duration          time.Duration // Wall time of the load
+	RowsPerSec        float64       // Rows loaded per second across all workers
+	WALBytes          int64         // WAL generated during the load (pg_stat_wal delta)
+	WALBytesPerRow    float64       // WAL bytes per loaded row
+	AvgBatchMs        float64       // Average latency of one batch
+	Errors            int64         // Failed batches
+	Error             string        // Setup failure, if the combination could not be run
+}
+
+// QueryPlan is an EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) plan captured
+// for a slow or sampled execution of a statement. Latencies are in
+// milliseconds.
+type QueryPlan struct {
+	Operation     string          `json:"operation"`      // Operation the statement ran in (see WithOperation)
+	Query         string          `json:"query"`          // Statement text
+	Fingerprint   string          `json:"fingerprint"`    // Hash of the statement with literals removed
+	Reason        string          `json:"reason"`         // "threshold" or "sample"
+	LatencyMs     float64         `json:"latency_ms"`     // Latency of the traced execution
+	ExecutionMs   float64         `json:"execution_ms"`   // Execution time reported by EXPLAIN ANALYZE
+	CapturedAt    time.Time       `json:"captured_at"`    // When the plan was captured
+	Shape         string          `json:"shape"`          // Plan tree without costs and timings
+	Plan          json.RawMessage `json:"plan,omitempty"` // EXPLAIN output
+	Error         string          `json:"error,omitempty"`
+	Changed       bool            `json:"changed"`                  // Shape differs from the previous run's plans
+	PreviousShape string          `json:"previous_shape,omitempty"` // Shape in the previous run, when changed
+}
+
+// HostSample is one resource sample of a host running stormdb, read from
+// /proc. Percentages are of all CPUs; rates are per second since the
+// previous sample.
+type HostSample struct {
+	Time                  time.Time
+	Host                  string  // Host name of the load generator
+	CPUs                  int     // Online CPUs
+	CPUBusyPct            float64 // All CPU time except idle and I/O wait
+	CPUUserPct            float64 // User and nice time
+	CPUSystemPct          float64 // System, IRQ and soft IRQ time
+	CPUIOWaitPct          float64
+	CPUStealPct           float64 // Time taken by the hypervisor, included in busy
+	RunQueue              int64   // Runnable tasks
+	MemUsedPct            float64 // Memory not available for new allocations
+	MemAvailableBytes     int64
+	NetRxBytesPerSec      float64 // All interfaces except loopback
+	NetTxBytesPerSec      float64
+	ContextSwitchesPerSec float64
+}
+
+// ServerSample is one resource sample of the database server, read through
+// SQL. I/O rates come from pg_stat_io (PostgreSQL 16+) and WAL directory
+// sizes from pg_ls_waldir (superuser or pg_monitor); unavailable values are
+// zero.
+type ServerSample struct {
+	Time           time.Time
+	IOReadsPerSec  float64
+	IOWritesPerSec float64
+	IOFsyncsPerSec float64
+	WALDirBytes    int64
+	WALFiles       int64
+}
+
+// RunMetadata fingerprints the environment of a run: how the database
+// server was configured and which client and plugin generated the load, so
+// a change in results can be traced to a change in configuration
+type RunMetadata struct {
+	CapturedAt       time.Time         `json:"captured_at"`
+	ServerVersion    string            `json:"server_version,omitempty"` // version()
+	ServerVersionNum int               `json:"server_version_num,omitempty"`
+	Settings         map[string]string `json:"settings,omitempty"`   // Non-default pg_settings and sizing hints, with units
+	Extensions       map[string]string `json:"extensions,omitempty"` // Installed extension -> version
+	ClientVersion    string            `json:"client_version,omitempty"`
+	ClientHost       string            `json:"client_host,omitempty"`
+	ClientOS         string            `json:"client_os,omitempty"` // GOOS/GOARCH
+	ClientCPUs       int               `json:"client_cpus,omitempty"`
+	GoVersion        string            `json:"go_version,omitempty"`
+	Plugin           string            `json:"plugin,omitempty"` // Plugin providing the workload
+	PluginVersion    string            `json:"plugin_version,omitempty"`
+}
+
+// MetadataChange is one difference between the metadata of two runs
+type MetadataChange struct {
+	Kind   string `json:"kind"` // "server", "setting", "extension", "client" or "plugin"
+	Name   string `json:"name"`
+	Before string `json:"before"` // Empty when added
+	After  string `json:"after"`  // Empty when removed
+}
+
+// DiffRunMetadata lists what changed from the metadata of run a to that of
+// run b, sorted by kind and name. Either may be nil when a run has none.
+func DiffRunMetadata(a, b *RunMetadata) []MetadataChange {
+	if a == nil {
+		a = &RunMetadata{}
+	}
+	if b == nil {
+		b = &RunMetadata{}
+	}
+
+	var changes []MetadataChange
+	field := func(kind, name, before, after string) {
+		if before != after {
+			changes = append(changes, MetadataChange{Kind: kind, Name: name, Before: before, After: after})
+		}
+	}
+	itoa := func(n int) string {
+		if n == 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+	field("server", "version", a.ServerVersion, b.ServerVersion)
+	field("client", "version", a.ClientVersion, b.ClientVersion)
+	field("client", "host", a.ClientHost, b.ClientHost)
+	field("client", "os", a.ClientOS, b.ClientOS)
+	field("client", "cpus", itoa(a.ClientCPUs), itoa(b.ClientCPUs))
+	field("client", "go_version", a.GoVersion, b.GoVersion)
+	field("plugin", "name", a.Plugin, b.Plugin)
+	field("plugin", "version", a.PluginVersion, b.PluginVersion)
+
+	diffMap := func(kind string, before, after map[string]string) {
+		names := make(map[string]bool)
+		for name := range before {
+			names[name] = true
+		}
+		for name := range after {
+			names[name] = true
+		}
+		for name := range names {
+			field(kind, name, before[name], after[name])
+		}
+	}
+	diffMap("setting", a.Settings, b.Settings)
+	diffMap("extension", a.Extensions, b.Extensions)
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// PartitionedLoadResult summarizes ingestion into a partitioned table:
+// routing overhead against an unpartitioned copy, rows per partition, and
+// partition maintenance performed while the load was running
+type PartitionedLoadResult struct {
+	Strategy              string           // range, list or hash
+	Key                   string           // Partition key column
+	InitialPartitions     int              // Partitions created before the load
+	RoutingCheckRows      int              // Rows loaded by the routing overhead check (0 if skipped)
+	PartitionedRowsPerSec float64          // Routing check throughput into the partitioned table
+	PlainRowsPerSec       float64          // Routing check throughput into an unpartitioned copy
+	RoutingOverheadPct    float64          // Throughput lost to partitioned inserts, in percent
+	Stats                 []PartitionStats // Rows per partition after the load
+	Events                []PartitionEvent // Partition creation, attach and detach during the load
+}
+
+// PartitionStats summarizes the rows routed to one partition
+type PartitionStats struct {
+	Partition  string  // Partition table name
+	Rows       int64   // Rows stored in the partition
+	Share      float64 // Fraction of all loaded rows (0-1)
+	RowsPerSec float64 // Rows over the partition's active window (first to last row)
+}
+
+// PartitionEvent records one partition maintenance operation performed
+// during a load and the insert throughput around it
+type PartitionEvent struct {
+	Action           string        // create, attach or detach
+	Partition        string        // Partition table name
+	Offset           time.Duration // Time since the load started
+	Duration         time.Duration // Time the DDL statement took, including lock waits
+	RowsPerSecBefore float64       // Insert throughput in the seconds before the operation
+	RowsPerSecDuring float64       // Insert throughput while the operation ran (plus a short settle window)
+	Error            string        // DDL failure, if any
+}
+
+// UpsertResult records the throughput and table cost of one upsert method
+// at one conflict ratio of the upsert comparison
+type UpsertResult struct {
+	Method           string        // on_conflict, merge or delete_insert
+	ConflictRatio    float64       // Configured fraction of rows targeting an existing key (0-1)
+	BatchSize        int           // Rows per statement
+	Rows             int64         // Rows written
+	ConflictRows     int64         // Rows that targeted an existing key
+	Duration         time.Duration // Wall time of the step
+	RowsPerSec       float64       // Rows written per second across all workers
+	AvgBatchMs       float64       // Average latency of one batch
+	DeadTuples       int64         // Growth of n_dead_tup on the table (autovacuum disabled)
+	DeadTuplesPerRow float64       // Dead tuples per written row
+	AvgLockWaiters   float64       // Average sessions waiting on a heavyweight lock, sampled every 100ms
+	PeakLockWaiters  int64         // Most sessions seen waiting on a lock at once
+	Deadlocks        int64         // Deadlocks detected in the database during the step
+	Errors           int64         // Failed batches
+	Error            string        // Setup failure, if the step could not be run
+}
+
+// ReplicaStats summarizes read routing and replay lag for a single replica
+// endpoint over the course of a benchmark run. Lag values are sampled from
+// the replica itself and indicate how stale routed reads could have been.
+type ReplicaStats struct {
+	Name         string  // Replica display name
+	Reads        int64   // Read operations routed to this replica
+	LagSamples   int64   // Number of successful replay lag samples
+	SampleErrors int64   // Number of failed replay lag samples
+	AvgLagMs     float64 // Average replay lag in milliseconds
+	MaxLagMs     float64 // Maximum observed replay lag in milliseconds
+	LastLagMs    float64 // Most recent replay lag sample in milliseconds
+}
+
 // PostgreSQLStats contains comprehensive PostgreSQL database statistics
 // collected asynchronously during benchmark execution. These statistics provide
 // insights into database performance, resource utilization, and system health.
@@ -168,7 +631,9 @@ type PostgreSQLStats struct {
 	AutovacuumCount int64 // Number of autovacuum operations performed
 
 	// Query performance statistics (requires pg_stat_statements extension)
-	TopQueries []QueryStats // Top queries by execution time or frequency
+	TopQueries     []QueryStats     // Top queries by execution time during the workload
+	Statements     []StatementStats // Statement deltas ranking in the top N of any StatementDimensions
+	StatementLimit int              // N, the statements ranked per dimension
 
 	// Metadata for statistics collection
 	LastUpdated time.Time    // Timestamp of last statistics update
@@ -193,6 +658,101 @@ type QueryStats struct {
 	LastUpdated time.Time // When these statistics were last collected
 }
 
+// StatementStats is the change of one pg_stat_statements entry during the
+// workload, summed over top-level and nested executions. Times are in
+// milliseconds; planning time is only tracked with pg_stat_statements.track_planning.
+type StatementStats struct {
+	QueryID           int64
+	Query             string
+	Calls             int64
+	TotalExecTime     float64
+	TotalPlanTime     float64
+	Rows              int64
+	SharedBlksHit     int64
+	SharedBlksRead    int64
+	SharedBlksDirtied int64
+	SharedBlksWritten int64
+	LocalBlksHit      int64
+	LocalBlksRead     int64
+	LocalBlksWritten  int64
+	WALRecords        int64
+	WALBytes          int64
+}
+
+// MeanExecTime returns the average execution time per call in milliseconds
+func (s StatementStats) MeanExecTime() float64 {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.TotalExecTime / float64(s.Calls)
+}
+
+// HitPercent returns the shared buffer cache hit percentage of the statement
+func (s StatementStats) HitPercent() float64 {
+	total := s.SharedBlksHit + s.SharedBlksRead
+	if total == 0 {
+		return 0
+	}
+	return float64(s.SharedBlksHit) / float64(total) * 100
+}
+
+// StatementDimension is a measure statements are ranked by
+type StatementDimension struct {
+	Name  string                         // Key used in summaries, e.g. "total_time"
+	Title string                         // Heading used in reports
+	Value func(s StatementStats) float64 // Value ranked in descending order
+}
+
+// StatementDimensions are the measures pg_stat_statements deltas are ranked by
+var StatementDimensions = []StatementDimension{
+	{Name: "total_time", Title: "Total Execution Time", Value: func(s StatementStats) float64 { return s.TotalExecTime }},
+	{Name: "calls", Title: "Calls", Value: func(s StatementStats) float64 { return float64(s.Calls) }},
+	{Name: "mean_time", Title: "Mean Execution Time", Value: StatementStats.MeanExecTime},
+	{Name: "rows", Title: "Rows", Value: func(s StatementStats) float64 { return float64(s.Rows) }},
+	{Name: "shared_blks_read", Title: "Shared Blocks Read", Value: func(s StatementStats) float64 { return float64(s.SharedBlksRead) }},
+	{Name: "wal_bytes", Title: "WAL Bytes", Value: func(s StatementStats) float64 { return float64(s.WALBytes) }},
+	{Name: "plan_time", Title: "Planning Time", Value: func(s StatementStats) float64 { return s.TotalPlanTime }},
+}
+
+// TopStatements returns the n statements with the highest non-zero value of
+// dimension, highest first
+func TopStatements(stmts []StatementStats, dimension StatementDimension, n int) []StatementStats {
+	var top []StatementStats
+	for _, s := range stmts {
+		if dimension.Value(s) > 0 {
+			top = append(top, s)
+		}
+	}
+	sort.SliceStable(top, func(i, j int) bool {
+		return dimension.Value(top[i]) > dimension.Value(top[j])
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// SelectStatements returns the statements in the top n of any of the
+// StatementDimensions, ordered by total execution time
+func SelectStatements(stmts []StatementStats, n int) []StatementStats {
+	selected := make(map[int64]bool)
+	for _, d := range StatementDimensions {
+		for _, s := range TopStatements(stmts, d, n) {
+			selected[s.QueryID] = true
+		}
+	}
+	var result []StatementStats
+	for _, s := range stmts {
+		if selected[s.QueryID] {
+			result = append(result, s)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].TotalExecTime > result[j].TotalExecTime
+	})
+	return result
+}
+
 // ConnectionModeMetrics tracks performance metrics for a specific database
 // connection management strategy. This is particularly useful for analyzing
 // the performance impact of different connection patterns (persistent vs
@@ -205,14 +765,15 @@ type QueryStats struct {
 // Thread Safety: All fields are protected by the embedded mutex and should
 // be accessed through the provided methods for concurrent safety.
 type ConnectionModeMetrics struct {
-	TPS             int64        // Successfully completed transactions per second
-	TPSAborted      int64        // Failed/aborted transactions per second
-	QPS             int64        // Total queries executed per second
-	Errors          int64        // Total number of errors encountered
-	TransactionDur  []int64      // Individual transaction durations (nanoseconds)
-	ConnectionSetup []int64      // Connection establishment times for transient connections (nanoseconds)
-	ConnectionCount int64        // Total number of connections created (relevant for transient mode)
-	Mu              sync.RWMutex // Mutex protecting concurrent access to all metrics
+	TPS             int64            // Successfully completed transactions per second
+	TPSAborted      int64            // Failed/aborted transactions per second
+	QPS             int64            // Total queries executed per second
+	Errors          int64            // Total number of errors encountered
+	TransactionDur  []int64          // Individual transaction durations (nanoseconds)
+	ConnectionSetup []int64          // Connection establishment times for transient connections (nanoseconds)
+	ConnectionCount int64            // Total number of connections created (relevant for transient mode)
+	FailureTypes    map[string]int64 // Errors by category (used by endpoint comparisons)
+	Mu              sync.RWMutex     // Mutex protecting concurrent access to all metrics
 }
 
 type Metrics struct {
@@ -233,6 +794,10 @@ type Metrics struct {
 	ErrorTypes     map[string]int64
 	TransactionDur []int64 // in nanoseconds
 
+	// Start of the latest-started committed transaction in Unix nanoseconds,
+	// see RecordCommitStart
+	LatestCommitStartNs int64
+
 	// Memory management for metrics collection
 	MaxLatencySamples  int   // Maximum number of latency samples to keep (0 = unlimited)
 	LatencySampleCount int64 // Current number of latency samples stored
@@ -249,6 +814,9 @@ type Metrics struct {
 	// Per-worker metrics tracking
 	WorkerMetrics map[int]*WorkerStats // worker_id -> stats
 
+	// Per-operation metrics for named workload operations (see RecordOperation)
+	Operations map[string]*OperationStats // operation name -> stats
+
 	// Time-series metrics tracking
 	TimeSeries     *TimeSeriesMetrics
 	BucketInterval time.Duration // Interval for time buckets (e.g., 1s, 5s)
@@ -256,10 +824,53 @@ type Metrics struct {
 	// PostgreSQL statistics (collected asynchronously)
 	PgStats *PostgreSQLStats
 
+	// Server settings and client environment, captured before the workload
+	RunMetadata *RunMetadata
+
 	// Connection mode metrics (for connection overhead testing)
 	PersistentConnMetrics *ConnectionModeMetrics // Metrics for persistent connections
 	TransientConnMetrics  *ConnectionModeMetrics // Metrics for transient connections
 
+	// Per-endpoint metrics for side-by-side comparisons (e.g., direct vs. pooler)
+	EndpointMetrics map[string]*ConnectionModeMetrics
+	EndpointOrder   []string // Endpoint names in registration order
+
+	// Read replica routing statistics (populated when replicas are enabled)
+	ReplicaStats         []ReplicaStats
+	ReplicaFallbackReads int64 // Reads served by the primary while no replica was available
+
+	// Fault injection results (populated when fault injection is enabled)
+	FaultEvents []FaultEventResult
+
+	// Vector search accuracy sweep results (populated by accuracy tests)
+	VectorAccuracy []VectorAccuracyResult
+
+	// Vector index build results (populated by index build tests)
+	IndexBuilds []IndexBuildResult
+
+	// Filtered and hybrid vector search results (populated by filtered tests)
+	FilteredSearch []FilteredSearchResult
+
+	// Vector quantization comparison results (populated by quantization tests)
+	Quantization []QuantizationResult
+
+	// Bulk load method matrix results (populated by bulk_insert_matrix)
+	BulkLoads []BulkLoadResult
+
+	// Partitioned ingestion summary (populated by partitioned bulk_insert runs)
+	Partitioning *PartitionedLoadResult
+
+	// Upsert comparison results (populated by bulk_insert_upsert)
+	Upserts []UpsertResult
+
+	// Query plans of slow or sampled statements (populated when explain is enabled)
+	QueryPlans    []QueryPlan
+	QueryPlanFile string // File the plans were saved to
+
+	// Host resource samples (populated when host_stats is enabled)
+	HostSamples   []HostSample
+	ServerSamples []ServerSample
+
 	// Mutex to protect slices and maps
 	Mu sync.Mutex // Protects slices and maps
 }
@@ -299,6 +910,9 @@ type TimeBucket struct {
 	UpdateRows []int64 // Rows modified per UPDATE query
 	InsertRows []int64 // Rows inserted per INSERT query
 	DeleteRows []int64 // Rows deleted per DELETE query
+
+	// Events fired during this interval (e.g., injected faults)
+	Events []string
 }
 
 // WorkerStats tracks metrics for an individual worker
@@ -312,6 +926,25 @@ type WorkerStats struct {
 	Mu             sync.Mutex // Protects this worker's data
 }
 
+// MaxOperationSamples bounds the latency samples kept per operation
+const MaxOperationSamples = 10000
+
+// OperationStats tracks the executions of one named workload operation,
+// such as getUserOrders or createNewOrder
+type OperationStats struct {
+	Name        string
+	Count       int64            // Executions, including failed ones
+	Errors      int64            // Failed executions
+	Rows        int64            // Rows read or modified
+	TotalNs     int64            // Sum of latencies in nanoseconds
+	MinNs       int64            // Fastest execution
+	MaxNs       int64            // Slowest execution
+	Histogram   map[string]int64 // Latency bucket (see GetLatencyBucket) -> count
+	Latencies   []int64          // Latency samples in nanoseconds, at most MaxOperationSamples
+	SampleCount int64            // Samples recorded, the next ring buffer slot once full
+	Mu          sync.Mutex       // Protects this operation's data
+}
+
 // LatencyBucket defines histogram bucket boundaries (in milliseconds)
 var LatencyBuckets = []float64{
 	0.1, 0.5, 1.0, 2.0, 5.0, 10.0, 20.0, 50.0, 100.0, 200.0, 500.0, 1000.0,
@@ -371,6 +1004,29 @@ func (m *Metrics) RecordQuery(queryType string) {
 	}
 }
 
+// RecordCommitStart notes the start time of a committed transaction, so
+// fault injection can tell transactions begun after a fault from ones that
+// were already in flight. Call it before counting the commit.
+func (m *Metrics) RecordCommitStart(start time.Time) {
+	ns := start.UnixNano()
+	for {
+		latest := atomic.LoadInt64(&m.LatestCommitStartNs)
+		if ns <= latest || atomic.CompareAndSwapInt64(&m.LatestCommitStartNs, latest, ns) {
+			return
+		}
+	}
+}
+
+// LatestCommitStart returns the start of the latest-started committed
+// transaction, or the zero time when the workload does not record them
+func (m *Metrics) LatestCommitStart() time.Time {
+	ns := atomic.LoadInt64(&m.LatestCommitStartNs)
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
 // RecordTransaction increments transaction counters
 func (m *Metrics) RecordTransaction(success bool) {
 	if success {
@@ -417,6 +1073,9 @@ func (m *Metrics) InitializeWorkerMetrics(numWorkers int) {
 // RecordWorkerTransaction records transaction metrics for a specific worker
 func (m *Metrics) RecordWorkerTransaction(workerID int, success bool, latencyNs int64) {
 	// Also record in global metrics
+	if success {
+		m.RecordCommitStart(time.Now().Add(-time.Duration(latencyNs)))
+	}
 	m.RecordTransaction(success)
 
 	// Record latency globally with memory limits
@@ -617,6 +1276,181 @@ func (m *Metrics) RecordTimeSeriesError() {
 	atomic.AddInt64(&m.TimeSeries.CurrentBucket.Errors, 1)
 }
 
+// RecordTimeSeriesEvent marks a named event in the current bucket
+func (m *Metrics) RecordTimeSeriesEvent(name string) {
+	if m.TimeSeries == nil {
+		return
+	}
+
+	m.RotateBucketIfNeeded()
+
+	m.TimeSeries.Mu.Lock()
+	defer m.TimeSeries.Mu.Unlock()
+
+	m.TimeSeries.CurrentBucket.Events = append(m.TimeSeries.CurrentBucket.Events, name)
+}
+
+// RecordFaultEvent appends a fault injection result (thread-safe)
+func (m *Metrics) RecordFaultEvent(result FaultEventResult) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.FaultEvents = append(m.FaultEvents, result)
+}
+
+// GetFaultEvents returns a copy of the fault injection results (thread-safe)
+func (m *Metrics) GetFaultEvents() []FaultEventResult {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	return append([]FaultEventResult(nil), m.FaultEvents...)
+}
+
+// RecordHostSample appends a host resource sample (thread-safe)
+func (m *Metrics) RecordHostSample(sample HostSample) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.HostSamples = append(m.HostSamples, sample)
+}
+
+// GetHostSamples returns a copy of the host resource samples (thread-safe)
+func (m *Metrics) GetHostSamples() []HostSample {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	return append([]HostSample(nil), m.HostSamples...)
+}
+
+// RecordServerSample appends a database server resource sample (thread-safe)
+func (m *Metrics) RecordServerSample(sample ServerSample) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.ServerSamples = append(m.ServerSamples, sample)
+}
+
+// GetServerSamples returns a copy of the server resource samples (thread-safe)
+func (m *Metrics) GetServerSamples() []ServerSample {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	return append([]ServerSample(nil), m.ServerSamples...)
+}
+
+// SetRunMetadata records the environment fingerprint of the run (thread-safe)
+func (m *Metrics) SetRunMetadata(md *RunMetadata) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.RunMetadata = md
+}
+
+// GetRunMetadata returns the environment fingerprint of the run, or nil when
+// none was captured (thread-safe)
+func (m *Metrics) GetRunMetadata() *RunMetadata {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	return m.RunMetadata
+}
+
+// RecordVectorAccuracy appends a vector search accuracy result (thread-safe)
+func (m *Metrics) RecordVectorAccuracy(result VectorAccuracyResult) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.VectorAccuracy = append(m.VectorAccuracy, result)
+}
+
+// GetVectorAccuracy returns a copy of the vector accuracy results (thread-safe)
+func (m *Metrics) GetVectorAccuracy() []VectorAccuracyResult {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	return append([]VectorAccuracyResult(nil), m.VectorAccuracy...)
+}
+
+// RecordIndexBuild appends an index build result (thread-safe)
+func (m *Metrics) RecordIndexBuild(result IndexBuildResult) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.IndexBuilds = append(m.IndexBuilds, result)
+}
+
+// GetIndexBuilds returns a copy of the index build results (thread-safe)
+func (m *Metrics) GetIndexBuilds() []IndexBuildResult {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	return append([]IndexBuildResult(nil), m.IndexBuilds...)
+}
+
+// RecordFilteredSearch appends a filtered vector search result (thread-safe)
+func (m *Metrics) RecordFilteredSearch(result FilteredSearchResult) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.FilteredSearch = append(m.FilteredSearch, result)
+}
+
+// GetFilteredSearch returns a copy of the filtered search results (thread-safe)
+func (m *Metrics) GetFilteredSearch() []FilteredSearchResult {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	return append([]FilteredSearchResult(nil), m.FilteredSearch...)
+}
+
+// RecordQuantization appends a vector quantization result (thread-safe)
+func (m *Metrics) RecordQuantization(result QuantizationResult) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.Quantization = append(m.Quantization, result)
+}
+
+// GetQuantization returns a copy of the vector quantization results (thread-safe)
+func (m *Metrics) GetQuantization() []QuantizationResult {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	return append([]QuantizationResult(nil), m.Quantization...)
+}
+
+// RecordBulkLoad appends a bulk load matrix result (thread-safe)
+func (m *Metrics) RecordBulkLoad(result BulkLoadResult) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.BulkLoads = append(m.BulkLoads, result)
+}
+
+// GetBulkLoads returns a copy of the bulk load matrix results (thread-safe)
+func (m *Metrics) GetBulkLoads() []BulkLoadResult {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	return append([]BulkLoadResult(nil), m.BulkLoads...)
+}
+
+// RecordUpsert appends an upsert comparison result (thread-safe)
+func (m *Metrics) RecordUpsert(result UpsertResult) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.Upserts = append(m.Upserts, result)
+}
+
+// GetUpserts returns a copy of the upsert comparison results (thread-safe)
+func (m *Metrics) GetUpserts() []UpsertResult {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	return append([]UpsertResult(nil), m.Upserts...)
+}
+
 // RecordWorkerError records error metrics for a specific worker
 func (m *Metrics) RecordWorkerError(workerID int) {
 	// Also record in global metrics
@@ -628,6 +1462,72 @@ func (m *Metrics) RecordWorkerError(workerID int) {
 	}
 }
 
+// RecordOperation records one execution of the named operation with its
+// latency, the rows it read or modified, and whether it succeeded. It only
+// feeds the per-operation breakdown; transactions and queries are still
+// recorded with RecordWorkerTransaction and RecordQuery.
+func (m *Metrics) RecordOperation(name string, latencyNs int64, rows int64, success bool) {
+	op := m.Operation(name)
+
+	op.Mu.Lock()
+	defer op.Mu.Unlock()
+
+	op.Count++
+	if !success {
+		op.Errors++
+	}
+	op.Rows += rows
+	op.TotalNs += latencyNs
+	if op.Count == 1 || latencyNs < op.MinNs {
+		op.MinNs = latencyNs
+	}
+	if latencyNs > op.MaxNs {
+		op.MaxNs = latencyNs
+	}
+	op.Histogram[GetLatencyBucket(latencyNs)]++
+
+	if len(op.Latencies) < MaxOperationSamples {
+		op.Latencies = append(op.Latencies, latencyNs)
+	} else {
+		op.Latencies[op.SampleCount%MaxOperationSamples] = latencyNs
+	}
+	op.SampleCount++
+}
+
+// Operation returns the stats of the named operation, creating them on
+// first use
+func (m *Metrics) Operation(name string) *OperationStats {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	if m.Operations == nil {
+		m.Operations = make(map[string]*OperationStats)
+	}
+	op, exists := m.Operations[name]
+	if !exists {
+		op = &OperationStats{Name: name, Histogram: make(map[string]int64)}
+		m.Operations[name] = op
+	}
+	return op
+}
+
+// operationKey is the context key of the current operation name
+type operationKey struct{}
+
+// WithOperation returns a context that attributes the statements executed
+// with it to the named operation, so captured query plans are linked to the
+// operation in the per-operation report
+func WithOperation(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, operationKey{}, name)
+}
+
+// OperationFromContext returns the operation name set by WithOperation, or
+// an empty string
+func OperationFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(operationKey{}).(string)
+	return name
+}
+
 // UpdatePgStats updates PostgreSQL statistics (thread-safe)
 func (m *Metrics) UpdatePgStats(stats *PostgreSQLStats) {
 	if m.PgStats == nil {
@@ -654,6 +1554,8 @@ func (m *Metrics) UpdatePgStats(stats *PostgreSQLStats) {
 	m.PgStats.MaxConnections = stats.MaxConnections
 	m.PgStats.AutovacuumCount = stats.AutovacuumCount
 	m.PgStats.TopQueries = append([]QueryStats(nil), stats.TopQueries...) // Deep copy slice
+	m.PgStats.Statements = append([]StatementStats(nil), stats.Statements...)
+	m.PgStats.StatementLimit = stats.StatementLimit
 	m.PgStats.LastUpdated = time.Now()
 }
 
@@ -684,10 +1586,62 @@ func (m *Metrics) GetPgStats() *PostgreSQLStats {
 		MaxConnections:      m.PgStats.MaxConnections,
 		AutovacuumCount:     m.PgStats.AutovacuumCount,
 		TopQueries:          append([]QueryStats(nil), m.PgStats.TopQueries...), // Deep copy slice
+		Statements:          append([]StatementStats(nil), m.PgStats.Statements...),
+		StatementLimit:      m.PgStats.StatementLimit,
 		LastUpdated:         m.PgStats.LastUpdated,
 	}
 }
 
+// UpdateReplicaStats replaces the read replica statistics snapshot and the
+// count of reads that fell back to the primary (thread-safe)
+func (m *Metrics) UpdateReplicaStats(stats []ReplicaStats, fallbackReads int64) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.ReplicaStats = append([]ReplicaStats(nil), stats...)
+	m.ReplicaFallbackReads = fallbackReads
+}
+
+// GetReplicaStats returns a copy of the read replica statistics (thread-safe)
+func (m *Metrics) GetReplicaStats() []ReplicaStats {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	return append([]ReplicaStats(nil), m.ReplicaStats...)
+}
+
+// GetReplicaFallbackReads returns the reads served by the primary while no
+// replica was available (thread-safe)
+func (m *Metrics) GetReplicaFallbackReads() int64 {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	return m.ReplicaFallbackReads
+}
+
+// UpdatePartitioning replaces the partitioned ingestion summary (thread-safe)
+func (m *Metrics) UpdatePartitioning(result *PartitionedLoadResult) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.Partitioning = result
+}
+
+// GetPartitioning returns a copy of the partitioned ingestion summary, or
+// nil if the run did not use partitioning (thread-safe)
+func (m *Metrics) GetPartitioning() *PartitionedLoadResult {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	if m.Partitioning == nil {
+		return nil
+	}
+	result := *m.Partitioning
+	result.Stats = append([]PartitionStats(nil), m.Partitioning.Stats...)
+	result.Events = append([]PartitionEvent(nil), m.Partitioning.Events...)
+	return &result
+}
+
 // RecordConnectionModeTransaction records a transaction for a specific connection mode
 func (m *Metrics) RecordConnectionModeTransaction(mode string, success bool, duration int64) {
 	var connMetrics *ConnectionModeMetrics
@@ -936,3 +1890,55 @@ type ProgressiveAnalysis struct {
 		Confidence   float64 `json:"confidence"`    // Confidence in recommendation (0-1)
 	} `json:"recommendations"`
 }
+
+// endpointMetrics returns the metrics for an endpoint, creating them on first use
+func (m *Metrics) endpointMetrics(endpoint string) *ConnectionModeMetrics {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	if m.EndpointMetrics == nil {
+		m.EndpointMetrics = make(map[string]*ConnectionModeMetrics)
+	}
+	em, exists := m.EndpointMetrics[endpoint]
+	if !exists {
+		em = &ConnectionModeMetrics{FailureTypes: make(map[string]int64)}
+		m.EndpointMetrics[endpoint] = em
+		m.EndpointOrder = append(m.EndpointOrder, endpoint)
+	}
+	return em
+}
+
+// RecordEndpointTransaction records a transaction executed against a named endpoint
+func (m *Metrics) RecordEndpointTransaction(endpoint string, success bool, duration int64) {
+	em := m.endpointMetrics(endpoint)
+	em.Mu.Lock()
+	defer em.Mu.Unlock()
+
+	if success {
+		em.TPS++
+	} else {
+		em.TPSAborted++
+	}
+	em.QPS++
+	em.TransactionDur = append(em.TransactionDur, duration)
+}
+
+// RecordEndpointConnect records the time taken to obtain a connection from an endpoint
+func (m *Metrics) RecordEndpointConnect(endpoint string, setupTime int64) {
+	em := m.endpointMetrics(endpoint)
+	em.Mu.Lock()
+	defer em.Mu.Unlock()
+
+	em.ConnectionSetup = append(em.ConnectionSetup, setupTime)
+	em.ConnectionCount++
+}
+
+// RecordEndpointError records a categorized error for a named endpoint
+func (m *Metrics) RecordEndpointError(endpoint, category string) {
+	em := m.endpointMetrics(endpoint)
+	em.Mu.Lock()
+	defer em.Mu.Unlock()
+
+	em.Errors++
+	em.FailureTypes[category]++
+}
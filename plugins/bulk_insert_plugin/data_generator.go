@@ -98,6 +98,19 @@ func (dg *DataGenerator) GenerateBatch(count int) []DataRecord {
 	return records
 }
 
+// GenerateConflictKey returns, with probability conflictRatio, a random
+// existing key in [1, existing]; otherwise it returns 0 so the caller
+// assigns a new key
+func (dg *DataGenerator) GenerateConflictKey(conflictRatio float64, existing int64) int64 {
+	if dg == nil || dg.rng == nil || existing <= 0 {
+		return 0
+	}
+	if dg.rng.Float64() >= conflictRatio {
+		return 0
+	}
+	return dg.rng.Int63n(existing) + 1
+}
+
 // generateShortText creates short text strings (10-50 characters)
 func (dg *DataGenerator) generateShortText() string {
 	if dg == nil || dg.rng == nil {
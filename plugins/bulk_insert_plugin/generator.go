@@ -18,7 +18,10 @@ import (
 )
 
 // Generator implements the bulk insert workload with progressive scaling
-type Generator struct{}
+type Generator struct {
+	loadMatrix bool // Run the load method matrix (bulk_insert_matrix) instead of batch size bands
+	upsert     bool // Run the upsert comparison (bulk_insert_upsert) instead of batch size bands
+}
 
 // BulkInsertConfig holds configuration specific to bulk insert workload
 type BulkInsertConfig struct {
@@ -65,7 +68,11 @@ type WorkloadState struct {
 
 // Setup ensures the schema exists (only if --setup or --rebuild)
 func (g *Generator) Setup(ctx context.Context, db *pgxpool.Pool, cfg *types.Config) error {
-	return setupSchema(ctx, db)
+	pc, err := parsePartitionConfig()
+	if err != nil {
+		return err
+	}
+	return setupSchema(ctx, db, pc)
 }
 
 // Cleanup drops and recreates the table (only on --rebuild)
@@ -92,6 +99,13 @@ func (g *Generator) Run(ctx context.Context, db *pgxpool.Pool, cfg *types.Config
 		return fmt.Errorf("metrics is nil")
 	}
 
+	if g.loadMatrix {
+		return g.runLoadMatrix(ctx, db, cfg, metrics)
+	}
+	if g.upsert {
+		return g.runUpsertComparison(ctx, db, cfg, metrics)
+	}
+
 	// Parse bulk insert specific configuration
 	bulkCfg := g.parseBulkInsertConfig(cfg)
 	if bulkCfg == nil {
@@ -112,6 +126,14 @@ func (g *Generator) Run(ctx context.Context, db *pgxpool.Pool, cfg *types.Config
 		return fmt.Errorf("failed to create ring buffer")
 	}
 
+	pc, err := parsePartitionConfig()
+	if err != nil {
+		return err
+	}
+	if err := ensurePartitionLayout(ctx, db, pc); err != nil {
+		return err
+	}
+
 	// Clear table before starting
 	if err := truncateTable(ctx, db); err != nil {
 		return fmt.Errorf("failed to truncate table: %w", err)
@@ -124,11 +146,16 @@ func (g *Generator) Run(ctx context.Context, db *pgxpool.Pool, cfg *types.Config
 	log.Printf("   Test methods: %s", g.getTestMethods(bulkCfg))
 
 	// Progressive scaling setup
-	if cfg.Progressive.Enabled {
-		return g.runProgressiveTest(ctx, db, cfg, metrics, bulkCfg, state)
-	} else {
+	run := func() error {
+		if cfg.Progressive.Enabled {
+			return g.runProgressiveTest(ctx, db, cfg, metrics, bulkCfg, state)
+		}
 		return g.runStandardTest(ctx, db, cfg, metrics, bulkCfg, state)
 	}
+	if pc.enabled() {
+		return g.runPartitioned(ctx, db, metrics, pc, bulkCfg.DataSeed, &state.totalInserted, run)
+	}
+	return run()
 }
 
 // runProgressiveTest executes the workload with progressive scaling
@@ -457,12 +484,14 @@ func (g *Generator) consumer(ctx context.Context, dbCtx context.Context, db *pgx
 		}
 
 		duration := time.Since(start)
+		metrics.RecordOperation(method, duration.Nanoseconds(), int64(len(records)), insertErr == nil)
 
 		// Update metrics with proper nil checking
 		if insertErr != nil {
 			atomic.AddInt64(&metrics.Errors, 1)
 			log.Printf("❌ Worker %d insert error: %v", workerID, insertErr)
 		} else {
+			metrics.RecordCommitStart(start)
 			atomic.AddInt64(&metrics.TPS, 1)
 			atomic.AddInt64(&state.totalInserted, int64(len(records)))
 
@@ -526,54 +555,65 @@ func (g *Generator) validateStatusEnumForSQL(status string) string {
 	}
 }
 
-// performBatchInsert executes a batch INSERT operation
-func (g *Generator) performBatchInsert(ctx context.Context, db *pgxpool.Pool, records []DataRecord) error {
-	if len(records) == 0 {
-		return nil
+// bulkInsertColumns are the columns written by every load method; id,
+// external_id and status defaults are left to the table
+var bulkInsertColumns = []string{
+	"short_text", "medium_text", "long_text", "int_value", "bigint_value",
+	"decimal_value", "float_value", "event_date", "event_time", "is_active",
+	"metadata", "data_blob", "status_enum", "tags", "client_ip", "location",
+	"created_timestamp",
+}
+
+// recordValues returns the values of a record in bulkInsertColumns order
+func (g *Generator) recordValues(record DataRecord) []interface{} {
+	return []interface{}{
+		record.ShortText,
+		record.MediumText,
+		record.LongText,
+		record.IntValue,
+		record.BigintValue,
+		record.DecimalValue,
+		record.FloatValue,
+		record.EventDate,
+		record.EventTime,
+		record.IsActive,
+		record.Metadata,
+		record.DataBlob,
+		g.validateStatusEnumForSQL(record.StatusEnum),
+		g.formatStringArray(record.Tags),
+		record.ClientIP,
+		fmt.Sprintf("(%f,%f)", record.LocationX, record.LocationY),
+		time.Now(), // created_timestamp
 	}
+}
 
-	// Build batch insert SQL
+// buildValuesInsert builds a multi-row INSERT ... VALUES statement
+func (g *Generator) buildValuesInsert(records []DataRecord) (string, []interface{}) {
+	columns := len(bulkInsertColumns)
 	valueStrings := make([]string, len(records))
-	valueArgs := make([]interface{}, 0, len(records)*17) // 17 columns per record (excluding external_id with default)
+	valueArgs := make([]interface{}, 0, len(records)*columns)
 
 	for i, record := range records {
-		valueStrings[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
-			len(valueArgs)+1, len(valueArgs)+2, len(valueArgs)+3, len(valueArgs)+4,
-			len(valueArgs)+5, len(valueArgs)+6, len(valueArgs)+7, len(valueArgs)+8,
-			len(valueArgs)+9, len(valueArgs)+10, len(valueArgs)+11, len(valueArgs)+12,
-			len(valueArgs)+13, len(valueArgs)+14, len(valueArgs)+15, len(valueArgs)+16,
-			len(valueArgs)+17)
-
-		valueArgs = append(valueArgs,
-			record.ShortText,
-			record.MediumText,
-			record.LongText,
-			record.IntValue,
-			record.BigintValue,
-			record.DecimalValue,
-			record.FloatValue,
-			record.EventDate,
-			record.EventTime,
-			record.IsActive,
-			record.Metadata,
-			record.DataBlob,
-			g.validateStatusEnumForSQL(record.StatusEnum),
-			g.formatStringArray(record.Tags),
-			record.ClientIP,
-			fmt.Sprintf("(%f,%f)", record.LocationX, record.LocationY),
-			time.Now(), // created_timestamp
-			// external_id excluded - will use DEFAULT gen_random_uuid()
-		)
-	}
-
-	sqlQuery := fmt.Sprintf(`
-		INSERT INTO bulk_insert_test (
-			short_text, medium_text, long_text, int_value, bigint_value,
-			decimal_value, float_value, event_date, event_time, is_active,
-			metadata, data_blob, status_enum, tags, client_ip, location,
-			created_timestamp
-		) VALUES %s`, strings.Join(valueStrings, ","))
+		placeholders := make([]string, columns)
+		for j := range placeholders {
+			placeholders[j] = fmt.Sprintf("$%d", len(valueArgs)+j+1)
+		}
+		valueStrings[i] = "(" + strings.Join(placeholders, ", ") + ")"
+		valueArgs = append(valueArgs, g.recordValues(record)...)
+	}
 
+	sqlQuery := fmt.Sprintf("INSERT INTO bulk_insert_test (%s) VALUES %s",
+		strings.Join(bulkInsertColumns, ", "), strings.Join(valueStrings, ","))
+	return sqlQuery, valueArgs
+}
+
+// performBatchInsert executes a batch INSERT operation
+func (g *Generator) performBatchInsert(ctx context.Context, db *pgxpool.Pool, records []DataRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	sqlQuery, valueArgs := g.buildValuesInsert(records)
 	_, err := db.Exec(ctx, sqlQuery, valueArgs...)
 	return err
 }
@@ -593,36 +633,10 @@ func (g *Generator) performCopyInsert(ctx context.Context, db *pgxpool.Pool, rec
 
 	// Start COPY operation
 	copySource := pgx.CopyFromSlice(len(records), func(i int) ([]interface{}, error) {
-		record := records[i]
-		return []interface{}{
-			record.ShortText,
-			record.MediumText,
-			record.LongText,
-			record.IntValue,
-			record.BigintValue,
-			record.DecimalValue,
-			record.FloatValue,
-			record.EventDate,
-			record.EventTime,
-			record.IsActive,
-			record.Metadata,
-			record.DataBlob,
-			g.validateStatusEnumForSQL(record.StatusEnum), // Pass directly like other string fields
-			g.formatStringArray(record.Tags),
-			record.ClientIP,
-			fmt.Sprintf("(%f,%f)", record.LocationX, record.LocationY),
-			time.Now(), // created_timestamp
-		}, nil
+		return g.recordValues(records[i]), nil
 	})
 
-	_, err = conn.Conn().CopyFrom(ctx, pgx.Identifier{"bulk_insert_test"},
-		[]string{
-			"short_text", "medium_text", "long_text", "int_value", "bigint_value",
-			"decimal_value", "float_value", "event_date", "event_time", "is_active",
-			"metadata", "data_blob", "status_enum", "tags", "client_ip", "location",
-			"created_timestamp",
-		}, copySource)
-
+	_, err = conn.Conn().CopyFrom(ctx, pgx.Identifier{"bulk_insert_test"}, bulkInsertColumns, copySource)
 	return err
 }
 
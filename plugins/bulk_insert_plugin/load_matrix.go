@@ -0,0 +1,504 @@
+// Load method matrix: INSERT, unnest and COPY variants across table settings
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/database"
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/viper"
+)
+
+// Load methods compared by the matrix
+const (
+	loadInsert     = "insert"      // Multi-row INSERT ... VALUES
+	loadUnnest     = "unnest"      // INSERT ... SELECT FROM unnest(arrays)
+	loadCopyBinary = "copy_binary" // COPY FROM STDIN in binary format
+	loadCopyText   = "copy_text"   // COPY FROM STDIN in text format
+	loadCopyCSV    = "copy_csv"    // COPY FROM STDIN in CSV format
+)
+
+// maxBindParams is the PostgreSQL limit on parameters in one statement
+const maxBindParams = 65535
+
+// recordPoolSize caps the records generated up front and reused by every combination
+const recordPoolSize = 10000
+
+// LoadMatrixConfig holds the dimensions of the load method matrix, read
+// from workload_config.load_matrix
+type LoadMatrixConfig struct {
+	Methods           []string // Load methods (default: all)
+	Tables            []string // "logged" and/or "unlogged"
+	Indexes           []string // "with" and/or "without" secondary indexes
+	SynchronousCommit []string // synchronous_commit values, e.g. "on", "off"
+	BatchSize         int      // Rows per statement or COPY (default: 1000)
+	Rows              int64    // Rows loaded per combination (default: 100000)
+	DataSeed          int64    // Seed for data generation (0 = random)
+}
+
+// parseLoadMatrixConfig reads and validates workload_config.load_matrix
+func (g *Generator) parseLoadMatrixConfig() (*LoadMatrixConfig, error) {
+	mc := &LoadMatrixConfig{
+		Methods:           []string{loadInsert, loadUnnest, loadCopyBinary, loadCopyText, loadCopyCSV},
+		Tables:            []string{"logged", "unlogged"},
+		Indexes:           []string{"with", "without"},
+		SynchronousCommit: []string{"on", "off"},
+		BatchSize:         1000,
+		Rows:              100000,
+		DataSeed:          viper.GetInt64("workload_config.data_seed"),
+	}
+
+	if methods := viper.GetStringSlice("workload_config.load_matrix.methods"); len(methods) > 0 {
+		mc.Methods = methods
+	}
+	if tables := viper.GetStringSlice("workload_config.load_matrix.tables"); len(tables) > 0 {
+		mc.Tables = tables
+	}
+	if indexes := viper.GetStringSlice("workload_config.load_matrix.indexes"); len(indexes) > 0 {
+		mc.Indexes = indexes
+	}
+	if syncCommit := viper.GetStringSlice("workload_config.load_matrix.synchronous_commit"); len(syncCommit) > 0 {
+		mc.SynchronousCommit = syncCommit
+	}
+	if batchSize := viper.GetInt("workload_config.load_matrix.batch_size"); batchSize > 0 {
+		mc.BatchSize = batchSize
+	}
+	if rows := viper.GetInt64("workload_config.load_matrix.rows"); rows > 0 {
+		mc.Rows = rows
+	}
+
+	for _, method := range mc.Methods {
+		switch method {
+		case loadInsert, loadUnnest, loadCopyBinary, loadCopyText, loadCopyCSV:
+		default:
+			return nil, fmt.Errorf("invalid load method: %s (valid: insert, unnest, copy_binary, copy_text, copy_csv)", method)
+		}
+	}
+	for _, table := range mc.Tables {
+		if table != "logged" && table != "unlogged" {
+			return nil, fmt.Errorf("invalid table mode: %s (valid: logged, unlogged)", table)
+		}
+	}
+	for _, indexes := range mc.Indexes {
+		if indexes != "with" && indexes != "without" {
+			return nil, fmt.Errorf("invalid indexes mode: %s (valid: with, without)", indexes)
+		}
+	}
+	for _, syncCommit := range mc.SynchronousCommit {
+		switch syncCommit {
+		case "on", "off", "local", "remote_write", "remote_apply":
+		default:
+			return nil, fmt.Errorf("invalid synchronous_commit: %s", syncCommit)
+		}
+	}
+	return mc, nil
+}
+
+// runLoadMatrix loads a fixed number of rows with every combination of load
+// method, table logging, secondary indexes and synchronous_commit, recording
+// rows/sec and WAL bytes per row for each
+func (g *Generator) runLoadMatrix(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics) error {
+	mc, err := g.parseLoadMatrixConfig()
+	if err != nil {
+		return err
+	}
+
+	// UNLOGGED switching needs the plain table layout
+	if err := ensurePartitionLayout(ctx, db, &PartitionConfig{Strategy: partitionNone}); err != nil {
+		return err
+	}
+
+	collector := database.NewPgStatsCollector(db, metrics, false)
+	defer collector.Stop()
+	walAvailable := true
+	if _, _, err := collector.WALCounters(ctx); err != nil {
+		log.Printf("⚠️  WAL bytes per row unavailable (pg_stat_wal requires PostgreSQL 14+): %v", err)
+		walAvailable = false
+	}
+
+	// Records are generated once so data generation is not part of the timing
+	poolSize := int64(recordPoolSize)
+	if int64(mc.BatchSize) > poolSize {
+		poolSize = int64(mc.BatchSize)
+	}
+	if mc.Rows < poolSize {
+		poolSize = mc.Rows
+	}
+	dataGen := NewDataGenerator(mc.DataSeed)
+	if dataGen == nil {
+		return fmt.Errorf("failed to create data generator")
+	}
+	records := make([]DataRecord, poolSize)
+	for i := range records {
+		records[i] = dataGen.GenerateRecord()
+	}
+
+	combinations := len(mc.Methods) * len(mc.Tables) * len(mc.Indexes) * len(mc.SynchronousCommit)
+	log.Printf("🧮 Load matrix: %d combinations of %d rows (batch size %d, %d workers)",
+		combinations, mc.Rows, mc.BatchSize, cfg.Workers)
+
+	// Leave the table as Setup created it
+	defer func() {
+		restoreCtx := context.Background()
+		if err := truncateTable(restoreCtx, db); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+		if err := setTableLogging(restoreCtx, db, true); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+		if err := setSecondaryIndexes(restoreCtx, db, true); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+	}()
+
+	done := 0
+	for _, table := range mc.Tables {
+		for _, indexes := range mc.Indexes {
+			for _, syncCommit := range mc.SynchronousCommit {
+				for _, method := range mc.Methods {
+					if ctx.Err() != nil {
+						log.Printf("⏱️  Duration reached, skipping %d remaining combination(s)", combinations-done)
+						return nil
+					}
+					done++
+
+					result := types.BulkLoadResult{
+						Method:            method,
+						Table:             table,
+						Indexes:           indexes == "with",
+						SynchronousCommit: syncCommit,
+						BatchSize:         mc.BatchSize,
+					}
+
+					if err := g.prepareLoadTable(ctx, db, table == "logged", result.Indexes); err != nil {
+						if ctx.Err() != nil {
+							return nil
+						}
+						result.Error = err.Error()
+						metrics.RecordBulkLoad(result)
+						continue
+					}
+
+					g.runLoadCombination(ctx, db, cfg, metrics, collector, walAvailable, mc, records, &result)
+					metrics.RecordBulkLoad(result)
+
+					log.Printf("✅ [%d/%d] %s, %s, indexes=%s, synchronous_commit=%s: %d rows, %.0f rows/s, %.1f WAL bytes/row",
+						done, combinations, method, table, indexes, syncCommit, result.Rows, result.RowsPerSec, result.WALBytesPerRow)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// prepareLoadTable empties the table and applies the logging and index settings
+func (g *Generator) prepareLoadTable(ctx context.Context, db *pgxpool.Pool, logged, indexes bool) error {
+	if _, err := db.Exec(ctx, "TRUNCATE TABLE bulk_insert_test RESTART IDENTITY"); err != nil {
+		return fmt.Errorf("failed to truncate bulk insert table: %w", err)
+	}
+	if err := setTableLogging(ctx, db, logged); err != nil {
+		return err
+	}
+	return setSecondaryIndexes(ctx, db, indexes)
+}
+
+// runLoadCombination loads mc.Rows rows over cfg.Workers connections and
+// fills in the throughput and WAL figures of result
+func (g *Generator) runLoadCombination(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics,
+	collector *database.PgStatsCollector, walAvailable bool, mc *LoadMatrixConfig, records []DataRecord, result *types.BulkLoadResult) {
+
+	var walBefore int64
+	if walAvailable {
+		_, walBefore, _ = collector.WALCounters(ctx)
+	}
+
+	var claimed, loaded, failed, batches, batchNanos int64
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+
+			conn, err := db.Acquire(ctx)
+			if err != nil {
+				atomic.AddInt64(&failed, 1)
+				return
+			}
+			defer conn.Release()
+
+			defer func() { _, _ = conn.Exec(context.Background(), "RESET synchronous_commit") }()
+			if _, err := conn.Exec(ctx, "SET synchronous_commit = "+result.SynchronousCommit); err != nil {
+				atomic.AddInt64(&failed, 1)
+				log.Printf("❌ Worker %d failed to set synchronous_commit: %v", workerID, err)
+				return
+			}
+
+			for ctx.Err() == nil {
+				end := atomic.AddInt64(&claimed, int64(mc.BatchSize))
+				offset := end - int64(mc.BatchSize)
+				if offset >= mc.Rows {
+					return
+				}
+				if end > mc.Rows {
+					end = mc.Rows
+				}
+				batch := recordBatch(records, offset, int(end-offset))
+
+				batchStart := time.Now()
+				err := g.loadBatch(ctx, conn.Conn(), result.Method, batch)
+				latency := time.Since(batchStart)
+				if err != nil {
+					if ctx.Err() == nil {
+						metrics.RecordOperation(result.Method, latency.Nanoseconds(), 0, false)
+						atomic.AddInt64(&failed, 1)
+						atomic.AddInt64(&metrics.Errors, 1)
+						log.Printf("❌ Worker %d %s error: %v", workerID, result.Method, err)
+					}
+					continue
+				}
+				metrics.RecordOperation(result.Method, latency.Nanoseconds(), int64(len(batch)), true)
+
+				atomic.AddInt64(&loaded, int64(len(batch)))
+				atomic.AddInt64(&batches, 1)
+				atomic.AddInt64(&batchNanos, latency.Nanoseconds())
+				metrics.RecordCommitStart(time.Now().Add(-latency))
+				atomic.AddInt64(&metrics.TPS, 1)
+				atomic.AddInt64(&metrics.RowsModified, int64(len(batch)))
+				metrics.RecordTimeSeriesQuery("INSERT", int64(len(batch)))
+				metrics.RecordLatencyWithLimit(latency.Nanoseconds())
+			}
+		}(i)
+	}
+	wg.Wait()
+	result.Duration = time.Since(start)
+
+	result.Rows = loaded
+	result.Errors = failed
+	if result.Duration > 0 {
+		result.RowsPerSec = float64(loaded) / result.Duration.Seconds()
+	}
+	if batches > 0 {
+		result.AvgBatchMs = float64(batchNanos) / float64(batches) / 1e6
+	}
+	if walAvailable {
+		if _, walAfter, err := collector.WALCounters(context.Background()); err == nil {
+			result.WALBytes = walAfter - walBefore
+			if loaded > 0 {
+				result.WALBytesPerRow = float64(result.WALBytes) / float64(loaded)
+			}
+		}
+	}
+}
+
+// recordBatch returns n records starting at offset, wrapping around the pool
+func recordBatch(records []DataRecord, offset int64, n int) []DataRecord {
+	start := int(offset % int64(len(records)))
+	if start+n <= len(records) {
+		return records[start : start+n]
+	}
+	batch := make([]DataRecord, 0, n)
+	for len(batch) < n {
+		take := n - len(batch)
+		if take > len(records)-start {
+			take = len(records) - start
+		}
+		batch = append(batch, records[start:start+take]...)
+		start = 0
+	}
+	return batch
+}
+
+// loadBatch writes one batch with the given load method
+func (g *Generator) loadBatch(ctx context.Context, conn *pgx.Conn, method string, records []DataRecord) error {
+	switch method {
+	case loadInsert:
+		// Split batches that exceed the bind parameter limit, in one transaction
+		chunk := maxBindParams / len(bulkInsertColumns)
+		if len(records) <= chunk {
+			sqlQuery, args := g.buildValuesInsert(records)
+			_, err := conn.Exec(ctx, sqlQuery, args...)
+			return err
+		}
+		return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			for i := 0; i < len(records); i += chunk {
+				end := i + chunk
+				if end > len(records) {
+					end = len(records)
+				}
+				sqlQuery, args := g.buildValuesInsert(records[i:end])
+				if _, err := tx.Exec(ctx, sqlQuery, args...); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	case loadUnnest:
+		return g.unnestInsert(ctx, conn, records)
+	case loadCopyBinary:
+		_, err := conn.CopyFrom(ctx, pgx.Identifier{"bulk_insert_test"}, bulkInsertColumns,
+			pgx.CopyFromSlice(len(records), func(i int) ([]interface{}, error) {
+				return g.recordValues(records[i]), nil
+			}))
+		return err
+	case loadCopyText, loadCopyCSV:
+		format := "text"
+		if method == loadCopyCSV {
+			format = "csv"
+		}
+		data := g.encodeCopyData(records, format)
+		_, err := conn.PgConn().CopyFrom(ctx, bytes.NewReader(data),
+			fmt.Sprintf("COPY bulk_insert_test (%s) FROM STDIN WITH (FORMAT %s)", strings.Join(bulkInsertColumns, ", "), format))
+		return err
+	default:
+		return fmt.Errorf("unknown load method: %s", method)
+	}
+}
+
+// unnestColumnsSQL converts the unnest arrays to the table's column types;
+// types without a convenient Go array encoding are passed as text and cast
+const unnestColumnsSQL = `short_text, medium_text, long_text, int_value, bigint_value,
+	       decimal_value::numeric AS decimal_value, float_value, event_date::date AS event_date,
+	       event_time::time AS event_time, is_active, metadata::jsonb AS metadata, data_blob,
+	       status_enum::bulk_status AS status_enum, tags::text[] AS tags, client_ip::inet AS client_ip,
+	       point(location_x, location_y) AS location, created_timestamp`
+
+// unnestFromSQL expands the arrays passed by unnestArgs into one row per
+// element; withID adds an id column from an extra int8[] parameter
+func unnestFromSQL(withID bool) string {
+	ids, idColumn := "", ""
+	if withID {
+		ids, idColumn = ", $19::int8[]", ", id"
+	}
+	return `FROM unnest($1::text[], $2::text[], $3::text[], $4::int4[], $5::int8[],
+	            $6::float8[], $7::float8[], $8::text[], $9::text[], $10::bool[],
+	            $11::text[], $12::bytea[], $13::text[], $14::text[], $15::text[],
+	            $16::float8[], $17::float8[], $18::timestamptz[]` + ids + `)
+	     AS t(short_text, medium_text, long_text, int_value, bigint_value,
+	          decimal_value, float_value, event_date, event_time, is_active,
+	          metadata, data_blob, status_enum, tags, client_ip,
+	          location_x, location_y, created_timestamp` + idColumn + `)`
+}
+
+// unnestInsertSQL inserts one row per array element
+var unnestInsertSQL = fmt.Sprintf(`
+	INSERT INTO bulk_insert_test (%s)
+	SELECT %s
+	%s`, strings.Join(bulkInsertColumns, ", "), unnestColumnsSQL, unnestFromSQL(false))
+
+// unnestInsert inserts the batch as one array per column
+func (g *Generator) unnestInsert(ctx context.Context, conn *pgx.Conn, records []DataRecord) error {
+	_, err := conn.Exec(ctx, unnestInsertSQL, g.unnestArgs(records)...)
+	return err
+}
+
+// unnestArgs returns the column arrays of the batch in unnestFromSQL order
+func (g *Generator) unnestArgs(records []DataRecord) []interface{} {
+	n := len(records)
+	shortText, mediumText, longText := make([]string, n), make([]string, n), make([]string, n)
+	intValue, bigintValue := make([]int32, n), make([]int64, n)
+	decimalValue, floatValue := make([]float64, n), make([]float64, n)
+	eventDate, eventTime := make([]string, n), make([]string, n)
+	isActive := make([]bool, n)
+	metadata, dataBlob := make([]string, n), make([][]byte, n)
+	status, tags, clientIP := make([]string, n), make([]string, n), make([]string, n)
+	locationX, locationY := make([]float64, n), make([]float64, n)
+	created := make([]time.Time, n)
+
+	now := time.Now()
+	for i, r := range records {
+		shortText[i], mediumText[i], longText[i] = r.ShortText, r.MediumText, r.LongText
+		intValue[i], bigintValue[i] = r.IntValue, r.BigintValue
+		decimalValue[i], floatValue[i] = r.DecimalValue, r.FloatValue
+		eventDate[i] = r.EventDate.Format("2006-01-02")
+		eventTime[i] = r.EventTime.Format("15:04:05.999999")
+		isActive[i] = r.IsActive
+		metadata[i] = g.metadataJSON(r.Metadata)
+		dataBlob[i] = r.DataBlob
+		status[i] = g.validateStatusEnumForSQL(r.StatusEnum)
+		tags[i] = g.formatStringArray(r.Tags).(string)
+		clientIP[i] = r.ClientIP
+		locationX[i], locationY[i] = r.LocationX, r.LocationY
+		created[i] = now
+	}
+
+	return []interface{}{
+		shortText, mediumText, longText, intValue, bigintValue,
+		decimalValue, floatValue, eventDate, eventTime, isActive,
+		metadata, dataBlob, status, tags, clientIP,
+		locationX, locationY, created,
+	}
+}
+
+// encodeCopyData renders the batch in COPY text or CSV format
+func (g *Generator) encodeCopyData(records []DataRecord, format string) []byte {
+	var buf bytes.Buffer
+	now := time.Now().Format(time.RFC3339Nano)
+
+	for _, r := range records {
+		fields := []string{
+			r.ShortText,
+			r.MediumText,
+			r.LongText,
+			strconv.FormatInt(int64(r.IntValue), 10),
+			strconv.FormatInt(r.BigintValue, 10),
+			strconv.FormatFloat(r.DecimalValue, 'f', 4, 64),
+			strconv.FormatFloat(r.FloatValue, 'g', -1, 64),
+			r.EventDate.Format("2006-01-02"),
+			r.EventTime.Format("15:04:05.999999"),
+			strconv.FormatBool(r.IsActive),
+			g.metadataJSON(r.Metadata),
+			`\x` + hex.EncodeToString(r.DataBlob),
+			g.validateStatusEnumForSQL(r.StatusEnum),
+			g.formatStringArray(r.Tags).(string),
+			r.ClientIP,
+			fmt.Sprintf("(%f,%f)", r.LocationX, r.LocationY),
+			now,
+		}
+
+		for i, field := range fields {
+			if i > 0 {
+				if format == "csv" {
+					buf.WriteByte(',')
+				} else {
+					buf.WriteByte('\t')
+				}
+			}
+			if format == "csv" {
+				// Quoted so empty strings are not read as NULL
+				buf.WriteByte('"')
+				buf.WriteString(strings.ReplaceAll(field, `"`, `""`))
+				buf.WriteByte('"')
+			} else {
+				buf.WriteString(copyTextEscaper.Replace(field))
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// copyTextEscaper escapes the characters that are special in COPY text format
+var copyTextEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// metadataJSON encodes the metadata map as a JSON document
+func (g *Generator) metadataJSON(metadata map[string]interface{}) string {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
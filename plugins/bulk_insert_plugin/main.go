@@ -30,6 +30,8 @@ func (p *BulkInsertWorkloadPlugin) GetMetadata() *plugin.PluginMetadata {
 		Author:      "StormDB Team",
 		WorkloadTypes: []string{
 			"bulk_insert",
+			"bulk_insert_matrix",
+			"bulk_insert_upsert",
 		},
 		RequiredExtensions:   []string{}, // Bulk insert doesn't require special extensions
 		MinPostgreSQLVersion: "11.0",
@@ -39,12 +41,17 @@ func (p *BulkInsertWorkloadPlugin) GetMetadata() *plugin.PluginMetadata {
 
 // CreateWorkload creates a Bulk Insert workload instance
 func (p *BulkInsertWorkloadPlugin) CreateWorkload(workloadType string) (plugin.Workload, error) {
-	if workloadType != "bulk_insert" {
+	switch workloadType {
+	case "bulk_insert", "bulk_insert_matrix", "bulk_insert_upsert":
+	default:
 		return nil, fmt.Errorf("unsupported workload type: %s", workloadType)
 	}
 
 	// Create a new generator instance to avoid nil pointer issues
-	generator := &Generator{}
+	generator := &Generator{
+		loadMatrix: workloadType == "bulk_insert_matrix",
+		upsert:     workloadType == "bulk_insert_upsert",
+	}
 	if generator == nil {
 		return nil, fmt.Errorf("failed to create generator instance")
 	}
@@ -0,0 +1,572 @@
+// Partitioned table layouts and partition maintenance during ingestion
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/viper"
+)
+
+// Partitioning strategies
+const (
+	partitionNone  = "none"
+	partitionRange = "range" // RANGE (created_timestamp), one partition per interval
+	partitionList  = "list"  // LIST (status_enum), one partition per status
+	partitionHash  = "hash"  // HASH (id), N partitions
+)
+
+// sparePartition is attached and detached during the load to measure the
+// effect of partition DDL on ingestion
+const sparePartition = "bulk_insert_test_spare"
+
+// spareRangeBounds is the far-future slot the spare range partition covers
+const spareRangeBounds = "FROM ('2100-01-01 00:00:00+00') TO ('2100-01-02 00:00:00+00')"
+
+// statusValues are the bulk_status enum values, one list partition each
+var statusValues = []string{"pending", "processing", "completed", "failed", "cancelled"}
+
+// PartitionConfig holds the partitioned table options, read from
+// workload_config.partitioning
+type PartitionConfig struct {
+	Strategy             string        // none, range, list or hash
+	Partitions           int           // Range partitions created up front, or hash modulus (default: 8)
+	Interval             time.Duration // Width of each range partition (default: 1h)
+	Premake              int           // Future range partitions kept ahead of the current time (default: 2)
+	RoutingCheckRows     int           // Rows for the routing overhead check, 0 disables (default: 50000)
+	AttachDetachInterval time.Duration // Attach/detach the spare partition this often, 0 disables
+	DetachConcurrently   bool          // Use DETACH PARTITION ... CONCURRENTLY (PostgreSQL 14+)
+}
+
+// parsePartitionConfig reads and validates workload_config.partitioning
+func parsePartitionConfig() (*PartitionConfig, error) {
+	pc := &PartitionConfig{
+		Strategy:         partitionNone,
+		Partitions:       8,
+		Interval:         time.Hour,
+		Premake:          2,
+		RoutingCheckRows: 50000,
+	}
+	if !viper.IsSet("workload_config.partitioning") {
+		return pc, nil
+	}
+
+	if strategy := viper.GetString("workload_config.partitioning.strategy"); strategy != "" {
+		pc.Strategy = strategy
+	}
+	if partitions := viper.GetInt("workload_config.partitioning.partitions"); partitions > 0 {
+		pc.Partitions = partitions
+	}
+	if interval := viper.GetString("workload_config.partitioning.interval"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("invalid partitioning interval %q (must be a duration of at least 1s)", interval)
+		}
+		pc.Interval = d
+	}
+	if viper.IsSet("workload_config.partitioning.premake") {
+		pc.Premake = viper.GetInt("workload_config.partitioning.premake")
+	}
+	if viper.IsSet("workload_config.partitioning.routing_check_rows") {
+		pc.RoutingCheckRows = viper.GetInt("workload_config.partitioning.routing_check_rows")
+	}
+	if interval := viper.GetString("workload_config.partitioning.attach_detach_interval"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid attach_detach_interval %q", interval)
+		}
+		pc.AttachDetachInterval = d
+	}
+	pc.DetachConcurrently = viper.GetBool("workload_config.partitioning.detach_concurrently")
+
+	switch pc.Strategy {
+	case partitionNone, partitionRange, partitionList, partitionHash:
+	default:
+		return nil, fmt.Errorf("invalid partitioning strategy: %s (valid: none, range, list, hash)", pc.Strategy)
+	}
+	if pc.Premake < 0 {
+		return nil, fmt.Errorf("partitioning premake must not be negative")
+	}
+	return pc, nil
+}
+
+// enabled reports whether the table is partitioned
+func (pc *PartitionConfig) enabled() bool {
+	return pc != nil && pc.Strategy != partitionNone
+}
+
+// key returns the partition key column
+func (pc *PartitionConfig) key() string {
+	switch pc.Strategy {
+	case partitionRange:
+		return "created_timestamp"
+	case partitionList:
+		return "status_enum"
+	default:
+		return "id"
+	}
+}
+
+// pgStrategy returns the pg_partitioned_table.partstrat code, "n" for none
+func (pc *PartitionConfig) pgStrategy() string {
+	switch pc.Strategy {
+	case partitionRange:
+		return "r"
+	case partitionList:
+		return "l"
+	case partitionHash:
+		return "h"
+	default:
+		return "n"
+	}
+}
+
+// createPartitionedTableSQL returns the DDL of the partitioned parent table.
+// The primary key must include the partition key.
+func createPartitionedTableSQL(pc *PartitionConfig) string {
+	primaryKey := "id"
+	if pc.key() != "id" {
+		primaryKey = "id, " + pc.key()
+	}
+	return createEnumSQL + `
+-- Partitioned variant of the bulk insert test table
+CREATE TABLE IF NOT EXISTS bulk_insert_test (
+    id BIGSERIAL,
+` + tableColumnsSQL + `,
+    PRIMARY KEY (` + primaryKey + `)
+) PARTITION BY ` + fmt.Sprintf("%s (%s)", pc.Strategy, pc.key()) + `;
+` + createIndexesSQL
+}
+
+// rangePartition returns the name and DDL of the range partition starting at start
+func rangePartition(start time.Time, interval time.Duration) (string, string) {
+	start = start.UTC()
+	name := "bulk_insert_test_p" + start.Format("20060102_150405")
+	return name, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF bulk_insert_test FOR VALUES FROM ('%s') TO ('%s')",
+		name, start.Format(time.RFC3339), start.Add(interval).Format(time.RFC3339))
+}
+
+// createPartitions creates the initial partitions. Range partitions start
+// one interval before the current one, so rows stamped just before the
+// boundary still find a partition.
+func createPartitions(ctx context.Context, db *pgxpool.Pool, pc *PartitionConfig) error {
+	var statements []string
+	switch pc.Strategy {
+	case partitionRange:
+		start := time.Now().Truncate(pc.Interval).Add(-pc.Interval)
+		for i := 0; i < pc.Partitions; i++ {
+			_, stmt := rangePartition(start.Add(time.Duration(i)*pc.Interval), pc.Interval)
+			statements = append(statements, stmt)
+		}
+	case partitionList:
+		for _, status := range statusValues {
+			statements = append(statements, fmt.Sprintf(
+				"CREATE TABLE IF NOT EXISTS bulk_insert_test_%s PARTITION OF bulk_insert_test FOR VALUES IN ('%s')", status, status))
+		}
+	case partitionHash:
+		for i := 0; i < pc.Partitions; i++ {
+			statements = append(statements, fmt.Sprintf(
+				"CREATE TABLE IF NOT EXISTS bulk_insert_test_h%d PARTITION OF bulk_insert_test FOR VALUES WITH (MODULUS %d, REMAINDER %d)",
+				i, pc.Partitions, i))
+		}
+	}
+
+	for _, stmt := range statements {
+		if _, err := db.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create partition: %w", err)
+		}
+	}
+	return nil
+}
+
+// ensurePartitionLayout recreates the table if its partitioning does not
+// match the configuration, and creates any missing partitions
+func ensurePartitionLayout(ctx context.Context, db *pgxpool.Pool, pc *PartitionConfig) error {
+	var strategy string
+	var partitions int
+	err := db.QueryRow(ctx, `
+		SELECT COALESCE((SELECT partstrat::text FROM pg_partitioned_table WHERE partrelid = 'bulk_insert_test'::regclass), 'n'),
+		       (SELECT count(*) FROM pg_inherits WHERE inhparent = 'bulk_insert_test'::regclass)`).Scan(&strategy, &partitions)
+	if err != nil {
+		return fmt.Errorf("failed to inspect bulk_insert_test partitioning: %w", err)
+	}
+
+	// A different hash modulus needs a new table as well, and range layouts
+	// are always rebuilt so time slots left by earlier runs cannot overlap
+	// the new ones
+	if strategy != pc.pgStrategy() || pc.Strategy == partitionRange ||
+		(pc.Strategy == partitionHash && partitions != pc.Partitions) {
+		log.Printf("🔧 Recreating bulk_insert_test with %s partitioning", pc.Strategy)
+		if err := cleanupSchema(ctx, db); err != nil {
+			return err
+		}
+		return setupSchema(ctx, db, pc)
+	}
+	if pc.enabled() {
+		return createPartitions(ctx, db, pc)
+	}
+	return nil
+}
+
+// measureRoutingOverhead loads the same rows into the partitioned table and
+// into an unpartitioned copy with binary COPY, returning both throughputs
+func (g *Generator) measureRoutingOverhead(ctx context.Context, db *pgxpool.Pool, pc *PartitionConfig, seed int64) (partitioned, plain float64, err error) {
+	dataGen := NewDataGenerator(seed)
+	if dataGen == nil {
+		return 0, 0, fmt.Errorf("failed to create data generator")
+	}
+	records := make([]DataRecord, pc.RoutingCheckRows)
+	for i := range records {
+		records[i] = dataGen.GenerateRecord()
+	}
+
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	const plainTable = "bulk_insert_routing_check"
+	if _, err := conn.Exec(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s; CREATE TABLE %s (LIKE bulk_insert_test INCLUDING ALL)", plainTable, plainTable)); err != nil {
+		return 0, 0, fmt.Errorf("failed to create unpartitioned copy: %w", err)
+	}
+	defer func() {
+		if _, err := db.Exec(context.Background(), "DROP TABLE IF EXISTS "+plainTable); err != nil {
+			log.Printf("⚠️  Failed to drop %s: %v", plainTable, err)
+		}
+	}()
+
+	load := func(table string) (float64, error) {
+		const batchSize = 1000
+		start := time.Now()
+		for i := 0; i < len(records); i += batchSize {
+			end := i + batchSize
+			if end > len(records) {
+				end = len(records)
+			}
+			batch := records[i:end]
+			_, err := conn.Conn().CopyFrom(ctx, pgx.Identifier{table}, bulkInsertColumns,
+				pgx.CopyFromSlice(len(batch), func(j int) ([]interface{}, error) {
+					return g.recordValues(batch[j]), nil
+				}))
+			if err != nil {
+				return 0, fmt.Errorf("routing check load into %s failed: %w", table, err)
+			}
+		}
+		return float64(len(records)) / time.Since(start).Seconds(), nil
+	}
+
+	if partitioned, err = load("bulk_insert_test"); err != nil {
+		return 0, 0, err
+	}
+	if plain, err = load(plainTable); err != nil {
+		return 0, 0, err
+	}
+	if _, err := conn.Exec(ctx, "TRUNCATE TABLE bulk_insert_test RESTART IDENTITY"); err != nil {
+		return 0, 0, fmt.Errorf("failed to truncate after routing check: %w", err)
+	}
+	return partitioned, plain, nil
+}
+
+// runPartitioned runs the ingestion test against the partitioned table,
+// measuring routing overhead first and maintaining partitions while it runs
+func (g *Generator) runPartitioned(ctx context.Context, db *pgxpool.Pool, metrics *types.Metrics, pc *PartitionConfig,
+	seed int64, inserted *int64, run func() error) error {
+	result := &types.PartitionedLoadResult{
+		Strategy:          pc.Strategy,
+		Key:               pc.key(),
+		InitialPartitions: pc.Partitions,
+	}
+	if pc.Strategy == partitionList {
+		result.InitialPartitions = len(statusValues)
+	}
+
+	log.Printf("🧩 Partitioned ingestion: %s on %s, %d partitions", pc.Strategy, pc.key(), result.InitialPartitions)
+
+	if pc.RoutingCheckRows > 0 {
+		partitioned, plain, err := g.measureRoutingOverhead(ctx, db, pc, seed)
+		if err != nil {
+			log.Printf("⚠️  Routing overhead check failed: %v", err)
+		} else {
+			result.RoutingCheckRows = pc.RoutingCheckRows
+			result.PartitionedRowsPerSec = partitioned
+			result.PlainRowsPerSec = plain
+			if plain > 0 {
+				result.RoutingOverheadPct = (plain - partitioned) / plain * 100
+			}
+			log.Printf("   Routing overhead: %.1f%% (%.0f rows/s partitioned vs %.0f rows/s plain)",
+				result.RoutingOverheadPct, partitioned, plain)
+		}
+	}
+
+	maintainer := newPartitionMaintainer(db, pc, inserted)
+	maintainerCtx, stopMaintainer := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go maintainer.run(maintainerCtx, &wg)
+
+	runErr := run()
+
+	stopMaintainer()
+	wg.Wait()
+	result.Events = maintainer.finish()
+
+	// The run context may already be done, so statistics get their own
+	statsCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	stats, err := collectPartitionStats(statsCtx, db)
+	if err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+	result.Stats = stats
+
+	metrics.UpdatePartitioning(result)
+	return runErr
+}
+
+// collectPartitionStats counts the rows in each partition; the rows per
+// second use the span between the first and last row in the partition
+func collectPartitionStats(ctx context.Context, db *pgxpool.Pool) ([]types.PartitionStats, error) {
+	rows, err := db.Query(ctx, `
+		SELECT tableoid::regclass::text, count(*), min(created_timestamp), max(created_timestamp)
+		FROM bulk_insert_test GROUP BY 1 ORDER BY 1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect partition statistics: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []types.PartitionStats
+	var total int64
+	for rows.Next() {
+		var p types.PartitionStats
+		var first, last time.Time
+		if err := rows.Scan(&p.Partition, &p.Rows, &first, &last); err != nil {
+			return nil, err
+		}
+		window := last.Sub(first)
+		if window < time.Millisecond {
+			window = time.Millisecond
+		}
+		p.RowsPerSec = float64(p.Rows) / window.Seconds()
+		total += p.Rows
+		stats = append(stats, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		stats[i].Share = float64(stats[i].Rows) / float64(total)
+	}
+	return stats, nil
+}
+
+// partitionMaintainer creates future range partitions and attaches and
+// detaches the spare partition while the load runs, recording the insert
+// throughput around each operation
+type partitionMaintainer struct {
+	db       *pgxpool.Pool
+	cfg      *PartitionConfig
+	inserted *int64 // Rows inserted so far by the consumers
+	start    time.Time
+
+	mu       sync.Mutex
+	events   []types.PartitionEvent
+	samples  []rateSample // Recent insert counter samples, oldest first
+	nextSlot time.Time    // Start of the first range partition not created yet
+	attached bool         // Whether the spare partition is attached
+}
+
+// rateSample is one reading of the inserted row counter
+type rateSample struct {
+	at   time.Time
+	rows int64
+}
+
+// settleWindow extends the "during" measurement past short DDL statements
+const settleWindow = time.Second
+
+// newPartitionMaintainer creates a maintainer reading the consumers' row counter
+func newPartitionMaintainer(db *pgxpool.Pool, pc *PartitionConfig, inserted *int64) *partitionMaintainer {
+	m := &partitionMaintainer{db: db, cfg: pc, inserted: inserted, start: time.Now()}
+	if pc.Strategy == partitionRange {
+		m.nextSlot = time.Now().Truncate(pc.Interval).Add(time.Duration(pc.Partitions-1) * pc.Interval)
+	}
+	return m
+}
+
+// run performs partition maintenance until ctx is cancelled
+func (m *partitionMaintainer) run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	sampleTicker := time.NewTicker(time.Second)
+	defer sampleTicker.Stop()
+
+	var premake <-chan time.Time
+	if m.cfg.Strategy == partitionRange {
+		check := m.cfg.Interval / 4
+		if check < time.Second {
+			check = time.Second
+		}
+		ticker := time.NewTicker(check)
+		defer ticker.Stop()
+		premake = ticker.C
+		m.ensureFuturePartitions(ctx)
+	}
+
+	var attachDetach <-chan time.Time
+	if m.cfg.AttachDetachInterval > 0 {
+		if m.cfg.Strategy == partitionHash {
+			log.Printf("ℹ️  Skipping attach/detach: every hash remainder must stay attached for inserts to route")
+		} else if err := m.createSparePartition(ctx); err != nil {
+			log.Printf("⚠️  Skipping attach/detach: %v", err)
+		} else {
+			ticker := time.NewTicker(m.cfg.AttachDetachInterval)
+			defer ticker.Stop()
+			attachDetach = ticker.C
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-sampleTicker.C:
+			m.mu.Lock()
+			m.samples = append(m.samples, rateSample{at: now, rows: atomic.LoadInt64(m.inserted)})
+			if len(m.samples) > 5 {
+				m.samples = m.samples[1:]
+			}
+			m.mu.Unlock()
+		case <-premake:
+			m.ensureFuturePartitions(ctx)
+		case <-attachDetach:
+			m.toggleSparePartition(ctx)
+		}
+	}
+}
+
+// ensureFuturePartitions keeps Premake range partitions ahead of now
+func (m *partitionMaintainer) ensureFuturePartitions(ctx context.Context) {
+	horizon := time.Now().Add(time.Duration(m.cfg.Premake) * m.cfg.Interval)
+	for !m.nextSlot.After(horizon) && ctx.Err() == nil {
+		name, stmt := rangePartition(m.nextSlot, m.cfg.Interval)
+		m.measure(ctx, "create", name, func(ctx context.Context) error {
+			_, err := m.db.Exec(ctx, stmt)
+			return err
+		})
+		m.nextSlot = m.nextSlot.Add(m.cfg.Interval)
+	}
+}
+
+// createSparePartition creates the detached spare table
+func (m *partitionMaintainer) createSparePartition(ctx context.Context) error {
+	_, err := m.db.Exec(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s; CREATE TABLE %s (LIKE bulk_insert_test INCLUDING DEFAULTS)",
+		sparePartition, sparePartition))
+	if err != nil {
+		return fmt.Errorf("failed to create spare partition: %w", err)
+	}
+	return nil
+}
+
+// toggleSparePartition attaches the spare partition if it is detached and
+// detaches it otherwise
+func (m *partitionMaintainer) toggleSparePartition(ctx context.Context) {
+	if !m.attached {
+		bounds := "FOR VALUES " + spareRangeBounds
+		if m.cfg.Strategy == partitionList {
+			bounds = "DEFAULT"
+		}
+		stmt := fmt.Sprintf("ALTER TABLE bulk_insert_test ATTACH PARTITION %s %s", sparePartition, bounds)
+		if m.measure(ctx, "attach", sparePartition, func(ctx context.Context) error {
+			_, err := m.db.Exec(ctx, stmt)
+			return err
+		}) {
+			m.attached = true
+		}
+		return
+	}
+
+	stmt := "ALTER TABLE bulk_insert_test DETACH PARTITION " + sparePartition
+	if m.cfg.DetachConcurrently {
+		stmt += " CONCURRENTLY"
+	}
+	if m.measure(ctx, "detach", sparePartition, func(ctx context.Context) error {
+		_, err := m.db.Exec(ctx, stmt)
+		return err
+	}) {
+		m.attached = false
+	}
+}
+
+// measure runs one DDL operation and records it with the insert throughput
+// before and while it ran. It reports whether the operation succeeded.
+func (m *partitionMaintainer) measure(ctx context.Context, action, partition string, op func(context.Context) error) bool {
+	startedAt := time.Now()
+	startRows := atomic.LoadInt64(m.inserted)
+
+	event := types.PartitionEvent{
+		Action:           action,
+		Partition:        partition,
+		Offset:           startedAt.Sub(m.start),
+		RowsPerSecBefore: m.recentRate(startedAt, startRows),
+	}
+
+	err := op(ctx)
+	event.Duration = time.Since(startedAt)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false
+		}
+		event.Error = err.Error()
+		log.Printf("⚠️  Partition %s of %s failed: %v", action, partition, err)
+	} else {
+		log.Printf("🧩 Partition %s %s took %v", action, partition, event.Duration.Round(time.Millisecond))
+	}
+
+	select {
+	case <-time.After(settleWindow):
+	case <-ctx.Done():
+	}
+	if elapsed := time.Since(startedAt); elapsed > 0 {
+		event.RowsPerSecDuring = float64(atomic.LoadInt64(m.inserted)-startRows) / elapsed.Seconds()
+	}
+
+	m.mu.Lock()
+	m.events = append(m.events, event)
+	m.mu.Unlock()
+	return err == nil
+}
+
+// recentRate returns the insert throughput over the sampled window ending now
+func (m *partitionMaintainer) recentRate(now time.Time, rows int64) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.samples) == 0 {
+		return 0
+	}
+	oldest := m.samples[0]
+	elapsed := now.Sub(oldest.at)
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(rows-oldest.rows) / elapsed.Seconds()
+}
+
+// finish drops the spare partition and returns the recorded events
+func (m *partitionMaintainer) finish() []types.PartitionEvent {
+	if _, err := m.db.Exec(context.Background(), "DROP TABLE IF EXISTS "+sparePartition); err != nil {
+		log.Printf("⚠️  Failed to drop %s: %v", sparePartition, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]types.PartitionEvent(nil), m.events...)
+}
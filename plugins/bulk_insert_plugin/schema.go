@@ -11,7 +11,18 @@ import (
 
 // Schema for bulk insert performance testing with diverse data types
 // Designed to stress different PostgreSQL subsystems and storage patterns
-const createTableSQL = `
+const createTableSQL = createEnumSQL + `
+-- Table designed for bulk insert performance testing
+-- Includes various data types to test different storage and indexing scenarios
+CREATE TABLE IF NOT EXISTS bulk_insert_test (
+    -- Primary key with sequence for natural ordering
+    id BIGSERIAL PRIMARY KEY,
+` + tableColumnsSQL + `
+);
+` + createIndexesSQL
+
+// createEnumSQL creates the status enum used by the table
+const createEnumSQL = `
 -- Custom enum type for status testing (must be created before table)
 DO $$ 
 BEGIN
@@ -19,14 +30,11 @@ BEGIN
         CREATE TYPE bulk_status AS ENUM ('pending', 'processing', 'completed', 'failed', 'cancelled');
     END IF;
 END $$;
+`
 
--- Table designed for bulk insert performance testing
--- Includes various data types to test different storage and indexing scenarios
-CREATE TABLE IF NOT EXISTS bulk_insert_test (
-    -- Primary key with sequence for natural ordering
-    id BIGSERIAL PRIMARY KEY,
-    
-    -- Text data of varying lengths for storage pattern testing
+// tableColumnsSQL lists the data columns shared by the plain and
+// partitioned table layouts
+const tableColumnsSQL = `    -- Text data of varying lengths for storage pattern testing
     short_text VARCHAR(50),
     medium_text VARCHAR(500),
     long_text TEXT,
@@ -65,8 +73,10 @@ CREATE TABLE IF NOT EXISTS bulk_insert_test (
     
     -- Geometric type for spatial operations
     location POINT
-);
+`
 
+// Secondary indexes, kept separate so load tests can run with and without them
+const createIndexesSQL = `
 -- Indexes for different access patterns
 -- B-tree indexes for range queries
 CREATE INDEX IF NOT EXISTS idx_bulk_created_timestamp ON bulk_insert_test(created_timestamp);
@@ -86,19 +96,38 @@ CREATE INDEX IF NOT EXISTS idx_bulk_metadata_gin ON bulk_insert_test USING GIN(m
 CREATE INDEX IF NOT EXISTS idx_bulk_external_id_hash ON bulk_insert_test USING HASH(external_id);
 `
 
+// secondaryIndexes lists the indexes created by createIndexesSQL
+var secondaryIndexes = []string{
+	"idx_bulk_created_timestamp",
+	"idx_bulk_int_value",
+	"idx_bulk_status",
+	"idx_bulk_status_date",
+	"idx_bulk_active_items",
+	"idx_bulk_metadata_gin",
+	"idx_bulk_external_id_hash",
+}
+
 const dropTableSQL = `
 DROP TABLE IF EXISTS bulk_insert_test CASCADE;
 DROP TYPE IF EXISTS bulk_status CASCADE;
 `
 
-// setupSchema creates the table and indexes for bulk insert testing
-func setupSchema(ctx context.Context, db *pgxpool.Pool) error {
+// setupSchema creates the table and indexes for bulk insert testing,
+// partitioned when the partitioning config enables it
+func setupSchema(ctx context.Context, db *pgxpool.Pool, pc *PartitionConfig) error {
 	log.Printf("🔧 Setting up bulk insert test schema...")
 
-	_, err := db.Exec(ctx, createTableSQL)
+	ddl := createTableSQL
+	if pc.enabled() {
+		ddl = createPartitionedTableSQL(pc)
+	}
+	_, err := db.Exec(ctx, ddl)
 	if err != nil {
 		return fmt.Errorf("failed to create bulk insert schema: %w", err)
 	}
+	if err := createPartitions(ctx, db, pc); err != nil {
+		return err
+	}
 
 	log.Printf("✅ Bulk insert test schema created successfully")
 	return nil
@@ -139,3 +168,33 @@ func truncateTable(ctx context.Context, db *pgxpool.Pool) error {
 	log.Printf("✅ Bulk insert test table truncated successfully")
 	return nil
 }
+
+// setSecondaryIndexes creates or drops the secondary indexes
+func setSecondaryIndexes(ctx context.Context, db *pgxpool.Pool, enabled bool) error {
+	if enabled {
+		if _, err := db.Exec(ctx, createIndexesSQL); err != nil {
+			return fmt.Errorf("failed to create secondary indexes: %w", err)
+		}
+		return nil
+	}
+
+	for _, name := range secondaryIndexes {
+		if _, err := db.Exec(ctx, "DROP INDEX IF EXISTS "+name); err != nil {
+			return fmt.Errorf("failed to drop index %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setTableLogging switches the table between LOGGED and UNLOGGED. The table
+// is rewritten, so this should run while it is empty.
+func setTableLogging(ctx context.Context, db *pgxpool.Pool, logged bool) error {
+	mode := "LOGGED"
+	if !logged {
+		mode = "UNLOGGED"
+	}
+	if _, err := db.Exec(ctx, "ALTER TABLE bulk_insert_test SET "+mode); err != nil {
+		return fmt.Errorf("failed to set table %s: %w", mode, err)
+	}
+	return nil
+}
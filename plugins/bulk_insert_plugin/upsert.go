@@ -0,0 +1,461 @@
+// Upsert comparison: INSERT ... ON CONFLICT, MERGE and delete+insert as conflicts rise
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/viper"
+)
+
+// Upsert methods compared by bulk_insert_upsert
+const (
+	upsertOnConflict   = "on_conflict"   // INSERT ... ON CONFLICT (id) DO UPDATE
+	upsertMerge        = "merge"         // MERGE INTO ... WHEN MATCHED / NOT MATCHED (PostgreSQL 15+)
+	upsertDeleteInsert = "delete_insert" // DELETE existing keys, then INSERT, in one transaction
+)
+
+// statsSettle gives backends time to publish table statistics before they are read
+const statsSettle = time.Second
+
+// UpsertConfig holds the upsert comparison options, read from
+// workload_config.upsert
+type UpsertConfig struct {
+	Methods        []string  // Upsert methods (default: all)
+	ConflictRatios []float64 // Fractions of rows targeting an existing key (default: 0, 0.25, 0.5, 0.75, 1)
+	BatchSize      int       // Rows per statement (default: 500)
+	Rows           int64     // Rows written per method and ratio (default: 100000)
+	BaseRows       int64     // Existing keys loaded before each step (default: 100000)
+	DataSeed       int64     // Seed for data generation (0 = random)
+}
+
+// parseUpsertConfig reads and validates workload_config.upsert
+func (g *Generator) parseUpsertConfig() (*UpsertConfig, error) {
+	uc := &UpsertConfig{
+		Methods:        []string{upsertOnConflict, upsertMerge, upsertDeleteInsert},
+		ConflictRatios: []float64{0, 0.25, 0.5, 0.75, 1},
+		BatchSize:      500,
+		Rows:           100000,
+		BaseRows:       100000,
+		DataSeed:       viper.GetInt64("workload_config.data_seed"),
+	}
+
+	if methods := viper.GetStringSlice("workload_config.upsert.methods"); len(methods) > 0 {
+		uc.Methods = methods
+	}
+	if raw := viper.GetStringSlice("workload_config.upsert.conflict_ratios"); len(raw) > 0 {
+		uc.ConflictRatios = nil
+		for _, s := range raw {
+			ratio, err := strconv.ParseFloat(s, 64)
+			if err != nil || ratio < 0 || ratio > 1 {
+				return nil, fmt.Errorf("invalid conflict ratio %q (must be between 0 and 1)", s)
+			}
+			uc.ConflictRatios = append(uc.ConflictRatios, ratio)
+		}
+	}
+	if batchSize := viper.GetInt("workload_config.upsert.batch_size"); batchSize > 0 {
+		uc.BatchSize = batchSize
+	}
+	if rows := viper.GetInt64("workload_config.upsert.rows"); rows > 0 {
+		uc.Rows = rows
+	}
+	if baseRows := viper.GetInt64("workload_config.upsert.base_rows"); baseRows > 0 {
+		uc.BaseRows = baseRows
+	}
+
+	for _, method := range uc.Methods {
+		switch method {
+		case upsertOnConflict, upsertMerge, upsertDeleteInsert:
+		default:
+			return nil, fmt.Errorf("invalid upsert method: %s (valid: on_conflict, merge, delete_insert)", method)
+		}
+	}
+	return uc, nil
+}
+
+// upsertColumns are the columns written by every upsert method, with an
+// explicit id so conflicts can be targeted
+var upsertColumns = append([]string{"id"}, bulkInsertColumns...)
+
+// upsertAssignments returns "column = source.column" for every data column
+func upsertAssignments(source string) string {
+	assignments := make([]string, len(bulkInsertColumns))
+	for i, column := range bulkInsertColumns {
+		assignments[i] = fmt.Sprintf("%s = %s.%s", column, source, column)
+	}
+	return strings.Join(assignments, ", ")
+}
+
+// upsertInsertSQL inserts the unnest rows with their ids
+var upsertInsertSQL = fmt.Sprintf(`
+	INSERT INTO bulk_insert_test (%s)
+	SELECT id, %s
+	%s`, strings.Join(upsertColumns, ", "), unnestColumnsSQL, unnestFromSQL(true))
+
+// onConflictSQL updates rows whose id already exists
+var onConflictSQL = upsertInsertSQL + `
+	ON CONFLICT (id) DO UPDATE SET ` + upsertAssignments("EXCLUDED")
+
+// mergeSQL is the MERGE equivalent of onConflictSQL
+var mergeSQL = fmt.Sprintf(`
+	MERGE INTO bulk_insert_test AS target
+	USING (SELECT id, %s
+	       %s) AS source
+	ON target.id = source.id
+	WHEN MATCHED THEN UPDATE SET %s
+	WHEN NOT MATCHED THEN INSERT (%s) VALUES (source.%s)`,
+	unnestColumnsSQL, unnestFromSQL(true), upsertAssignments("source"),
+	strings.Join(upsertColumns, ", "), strings.Join(upsertColumns, ", source."))
+
+// runUpsertComparison writes a fixed number of rows with every upsert
+// method at every conflict ratio, recording throughput, dead tuple growth
+// and lock waits for each
+func (g *Generator) runUpsertComparison(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics) error {
+	uc, err := g.parseUpsertConfig()
+	if err != nil {
+		return err
+	}
+
+	// ON CONFLICT (id) needs the plain table, whose primary key is id alone
+	if err := ensurePartitionLayout(ctx, db, &PartitionConfig{Strategy: partitionNone}); err != nil {
+		return err
+	}
+
+	var serverVersion int
+	if err := db.QueryRow(ctx, "SELECT current_setting('server_version_num')::int").Scan(&serverVersion); err != nil {
+		return fmt.Errorf("failed to read server version: %w", err)
+	}
+
+	// Payloads are generated once; keys are drawn per batch
+	poolSize := int64(recordPoolSize)
+	if int64(uc.BatchSize) > poolSize {
+		poolSize = int64(uc.BatchSize)
+	}
+	dataGen := NewDataGenerator(uc.DataSeed)
+	if dataGen == nil {
+		return fmt.Errorf("failed to create data generator")
+	}
+	records := dataGen.GenerateBatch(int(poolSize))
+
+	// One connection is left for the lock wait sampler
+	workers := cfg.Workers
+	if maxConns := int(db.Config().MaxConns); workers >= maxConns {
+		workers = maxConns - 1
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Autovacuum would remove the dead tuples being measured
+	if _, err := db.Exec(ctx, "ALTER TABLE bulk_insert_test SET (autovacuum_enabled = false)"); err != nil {
+		return fmt.Errorf("failed to disable autovacuum on bulk_insert_test: %w", err)
+	}
+	defer func() {
+		restoreCtx := context.Background()
+		if err := truncateTable(restoreCtx, db); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+		if _, err := db.Exec(restoreCtx, "ALTER TABLE bulk_insert_test RESET (autovacuum_enabled)"); err != nil {
+			log.Printf("⚠️  Failed to reset autovacuum on bulk_insert_test: %v", err)
+		}
+	}()
+
+	steps := len(uc.Methods) * len(uc.ConflictRatios)
+	log.Printf("🔁 Upsert comparison: %d steps of %d rows over %d existing keys (batch size %d, %d workers)",
+		steps, uc.Rows, uc.BaseRows, uc.BatchSize, workers)
+
+	done := 0
+	for _, method := range uc.Methods {
+		for _, ratio := range uc.ConflictRatios {
+			if ctx.Err() != nil {
+				log.Printf("⏱️  Duration reached, skipping %d remaining step(s)", steps-done)
+				return nil
+			}
+			done++
+
+			result := types.UpsertResult{
+				Method:        method,
+				ConflictRatio: ratio,
+				BatchSize:     uc.BatchSize,
+			}
+
+			if method == upsertMerge && serverVersion < 150000 {
+				result.Error = "MERGE requires PostgreSQL 15+"
+				metrics.RecordUpsert(result)
+				continue
+			}
+			if err := g.seedUpsertTable(ctx, db, records, uc.BaseRows); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				result.Error = err.Error()
+				metrics.RecordUpsert(result)
+				continue
+			}
+
+			g.runUpsertStep(ctx, db, metrics, uc, workers, records, &result)
+			metrics.RecordUpsert(result)
+
+			log.Printf("✅ [%d/%d] %s at %.0f%% conflicts: %d rows, %.0f rows/s, %d dead tuples, %.1f avg lock waiters",
+				done, steps, method, ratio*100, result.Rows, result.RowsPerSec, result.DeadTuples, result.AvgLockWaiters)
+		}
+	}
+
+	return nil
+}
+
+// seedUpsertTable empties the table and loads ids 1..rows, the keys that
+// conflicting upserts target
+func (g *Generator) seedUpsertTable(ctx context.Context, db *pgxpool.Pool, records []DataRecord, rows int64) error {
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "TRUNCATE TABLE bulk_insert_test RESTART IDENTITY"); err != nil {
+		return fmt.Errorf("failed to truncate bulk insert table: %w", err)
+	}
+	_, err = conn.Conn().CopyFrom(ctx, pgx.Identifier{"bulk_insert_test"}, upsertColumns,
+		pgx.CopyFromSlice(int(rows), func(i int) ([]interface{}, error) {
+			return append([]interface{}{int64(i + 1)}, g.recordValues(records[i%len(records)])...), nil
+		}))
+	if err != nil {
+		return fmt.Errorf("failed to load existing keys: %w", err)
+	}
+	forceStatsFlush(ctx, conn.Conn())
+	return nil
+}
+
+// runUpsertStep writes uc.Rows rows with one method and conflict ratio and
+// fills in the throughput, dead tuple and lock figures of result
+func (g *Generator) runUpsertStep(ctx context.Context, db *pgxpool.Pool, metrics *types.Metrics,
+	uc *UpsertConfig, workers int, records []DataRecord, result *types.UpsertResult) {
+
+	time.Sleep(statsSettle)
+	deadBefore, deadlocksBefore, err := upsertCounters(ctx, db)
+	if err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+
+	samplerCtx, stopSampler := context.WithCancel(ctx)
+	sampler := &lockWaitSampler{}
+	var samplerWg sync.WaitGroup
+	samplerWg.Add(1)
+	go sampler.run(samplerCtx, db, &samplerWg)
+
+	nextKey := uc.BaseRows
+	var claimed, written, conflicts, failed, batches, batchNanos int64
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+
+			// Each worker draws keys from its own generator
+			seed := uc.DataSeed
+			if seed != 0 {
+				seed += int64(workerID) + 1
+			}
+			keyGen := NewDataGenerator(seed)
+
+			conn, err := db.Acquire(ctx)
+			if err != nil {
+				atomic.AddInt64(&failed, 1)
+				return
+			}
+			defer conn.Release()
+			defer forceStatsFlush(context.Background(), conn.Conn())
+
+			for ctx.Err() == nil {
+				end := atomic.AddInt64(&claimed, int64(uc.BatchSize))
+				offset := end - int64(uc.BatchSize)
+				if offset >= uc.Rows {
+					return
+				}
+				if end > uc.Rows {
+					end = uc.Rows
+				}
+				n := int(end - offset)
+				keys, hits := upsertKeys(keyGen, result.ConflictRatio, uc.BaseRows, n, &nextKey)
+				batch := recordBatch(records, offset, n)
+
+				batchStart := time.Now()
+				err := g.upsertBatch(ctx, conn.Conn(), result.Method, keys, batch)
+				latency := time.Since(batchStart)
+				if err != nil {
+					if ctx.Err() == nil {
+						metrics.RecordOperation(result.Method, latency.Nanoseconds(), 0, false)
+						atomic.AddInt64(&failed, 1)
+						atomic.AddInt64(&metrics.Errors, 1)
+						log.Printf("❌ Worker %d %s error: %v", workerID, result.Method, err)
+					}
+					continue
+				}
+				metrics.RecordOperation(result.Method, latency.Nanoseconds(), int64(n), true)
+
+				atomic.AddInt64(&written, int64(n))
+				atomic.AddInt64(&conflicts, int64(hits))
+				atomic.AddInt64(&batches, 1)
+				atomic.AddInt64(&batchNanos, latency.Nanoseconds())
+				metrics.RecordCommitStart(time.Now().Add(-latency))
+				atomic.AddInt64(&metrics.TPS, 1)
+				atomic.AddInt64(&metrics.RowsModified, int64(n))
+				metrics.RecordTimeSeriesQuery("INSERT", int64(n))
+				metrics.RecordLatencyWithLimit(latency.Nanoseconds())
+			}
+		}(i)
+	}
+	wg.Wait()
+	result.Duration = time.Since(start)
+	stopSampler()
+	samplerWg.Wait()
+
+	result.Rows = written
+	result.ConflictRows = conflicts
+	result.Errors = failed
+	result.AvgLockWaiters, result.PeakLockWaiters = sampler.summary()
+	if result.Duration > 0 {
+		result.RowsPerSec = float64(written) / result.Duration.Seconds()
+	}
+	if batches > 0 {
+		result.AvgBatchMs = float64(batchNanos) / float64(batches) / 1e6
+	}
+
+	time.Sleep(statsSettle)
+	deadAfter, deadlocksAfter, err := upsertCounters(context.Background(), db)
+	if err != nil {
+		log.Printf("⚠️  %v", err)
+		return
+	}
+	result.DeadTuples = deadAfter - deadBefore
+	result.Deadlocks = deadlocksAfter - deadlocksBefore
+	if written > 0 {
+		result.DeadTuplesPerRow = float64(result.DeadTuples) / float64(written)
+	}
+}
+
+// upsertKeys draws n distinct keys for one batch: existing keys with
+// probability ratio, new keys from nextKey otherwise. Keys are sorted so
+// concurrent batches lock rows in the same order. It also returns how many
+// keys target existing rows.
+func upsertKeys(keyGen *DataGenerator, ratio float64, existing int64, n int, nextKey *int64) ([]int64, int) {
+	seen := make(map[int64]bool, n)
+	keys := make([]int64, 0, n)
+	hits := 0
+	for len(keys) < n {
+		key := keyGen.GenerateConflictKey(ratio, existing)
+		for attempt := 0; key != 0 && seen[key] && attempt < 3; attempt++ {
+			key = keyGen.GenerateConflictKey(1, existing)
+		}
+		if key == 0 || seen[key] {
+			key = atomic.AddInt64(nextKey, 1)
+		} else {
+			hits++
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys, hits
+}
+
+// upsertBatch writes one batch with the given upsert method
+func (g *Generator) upsertBatch(ctx context.Context, conn *pgx.Conn, method string, keys []int64, records []DataRecord) error {
+	args := append(g.unnestArgs(records), keys)
+	switch method {
+	case upsertOnConflict:
+		_, err := conn.Exec(ctx, onConflictSQL, args...)
+		return err
+	case upsertMerge:
+		_, err := conn.Exec(ctx, mergeSQL, args...)
+		return err
+	case upsertDeleteInsert:
+		return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, "DELETE FROM bulk_insert_test WHERE id = ANY($1)", keys); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, upsertInsertSQL, args...)
+			return err
+		})
+	default:
+		return fmt.Errorf("unknown upsert method: %s", method)
+	}
+}
+
+// upsertCounters returns n_dead_tup of the table and the database's deadlock count
+func upsertCounters(ctx context.Context, db *pgxpool.Pool) (deadTuples, deadlocks int64, err error) {
+	err = db.QueryRow(ctx, `
+		SELECT COALESCE((SELECT n_dead_tup FROM pg_stat_user_tables WHERE relid = 'bulk_insert_test'::regclass), 0),
+		       COALESCE((SELECT deadlocks FROM pg_stat_database WHERE datname = current_database()), 0)`).Scan(&deadTuples, &deadlocks)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read table statistics: %w", err)
+	}
+	return deadTuples, deadlocks, nil
+}
+
+// forceStatsFlush asks the backend to publish its pending statistics when
+// it goes idle. pg_stat_force_next_flush needs PostgreSQL 15+; older
+// servers publish on their own within statsSettle.
+func forceStatsFlush(ctx context.Context, conn *pgx.Conn) {
+	_, _ = conn.Exec(ctx, "SELECT pg_stat_force_next_flush()")
+}
+
+// lockWaitSampler counts sessions waiting on heavyweight locks
+type lockWaitSampler struct {
+	mu      sync.Mutex
+	samples int64
+	total   int64
+	peak    int64
+}
+
+// run samples pg_stat_activity every 100ms until ctx is cancelled
+func (s *lockWaitSampler) run(ctx context.Context, db *pgxpool.Pool, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var waiting int64
+			err := db.QueryRow(ctx, `
+				SELECT count(*) FROM pg_stat_activity
+				WHERE datname = current_database() AND wait_event_type = 'Lock'`).Scan(&waiting)
+			if err != nil {
+				continue
+			}
+			s.mu.Lock()
+			s.samples++
+			s.total += waiting
+			if waiting > s.peak {
+				s.peak = waiting
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// summary returns the average and peak number of waiting sessions
+func (s *lockWaitSampler) summary() (float64, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.samples == 0 {
+		return 0, 0
+	}
+	return float64(s.total) / float64(s.samples), s.peak
+}
@@ -40,6 +40,7 @@ import (
 	"time"
 
 	"github.com/elchinoo/stormdb/internal/database"
+	"github.com/elchinoo/stormdb/pkg/keydist"
 	"github.com/elchinoo/stormdb/pkg/types"
 
 	"github.com/jackc/pgx/v5"
@@ -60,7 +61,8 @@ import (
 // enabling comprehensive analysis of connection overhead impact on throughput,
 // latency, and resource utilization.
 type ConnectionWorkload struct {
-	operations []Operation // Pre-generated operations to be executed during the benchmark
+	operations []Operation      // Pre-generated operations to be executed during the benchmark
+	keys       *keydist.Chooser // Picks row ids using the key_distribution option
 }
 
 // Operation represents a single database operation with its connection strategy.
@@ -69,7 +71,7 @@ type ConnectionWorkload struct {
 type Operation struct {
 	Type         string        // Operation type: "select", "insert", "update", "delete"
 	Query        string        // SQL query to execute
-	Args         []interface{} // Query parameters for prepared statement execution
+	Args         []interface{} // Query parameters, drawn afresh for every execution
 	UseTransient bool          // Connection strategy: true=transient, false=persistent
 }
 
@@ -146,9 +148,20 @@ func (w *ConnectionWorkload) Run(ctx context.Context, pool *pgxpool.Pool, config
 	runCtx, cancel := context.WithTimeout(ctx, duration)
 	defer cancel()
 
+	keys, err := keydist.FromConfig(config)
+	if err != nil {
+		return fmt.Errorf("invalid key distribution: %w", err)
+	}
+	w.keys = keys
+
 	// Prepare operations (50% persistent, 50% transient)
 	w.operations = w.generateOperations()
 
+	// Compare the direct endpoint against a connection pooler when configured
+	if pc := parsePoolerConfig(); pc.Enabled {
+		return w.runPoolerComparison(runCtx, config, metrics, pc)
+	}
+
 	// Start workers
 	var wg sync.WaitGroup
 	for i := 0; i < config.Workers; i++ {
@@ -198,7 +211,6 @@ func (w *ConnectionWorkload) generateOperations() []Operation {
 				operations = append(operations, Operation{
 					Type:         "select",
 					Query:        query,
-					Args:         w.generateArgsForQuery(query),
 					UseTransient: useTransient,
 				})
 			}
@@ -208,7 +220,6 @@ func (w *ConnectionWorkload) generateOperations() []Operation {
 				operations = append(operations, Operation{
 					Type:         "insert",
 					Query:        query,
-					Args:         w.generateArgsForQuery(query),
 					UseTransient: useTransient,
 				})
 			}
@@ -218,7 +229,6 @@ func (w *ConnectionWorkload) generateOperations() []Operation {
 				operations = append(operations, Operation{
 					Type:         "update",
 					Query:        query,
-					Args:         w.generateArgsForQuery(query),
 					UseTransient: useTransient,
 				})
 			}
@@ -228,7 +238,6 @@ func (w *ConnectionWorkload) generateOperations() []Operation {
 				operations = append(operations, Operation{
 					Type:         "delete",
 					Query:        query,
-					Args:         w.generateArgsForQuery(query),
 					UseTransient: useTransient,
 				})
 			}
@@ -238,20 +247,22 @@ func (w *ConnectionWorkload) generateOperations() []Operation {
 	return operations
 }
 
-func (w *ConnectionWorkload) generateArgsForQuery(query string) []interface{} {
+// generateArgsForQuery draws the parameters of one execution of query. Row
+// ids and test_data names follow the configured key distribution.
+func (w *ConnectionWorkload) generateArgsForQuery(rng *rand.Rand, query string) []interface{} {
 	paramCount := strings.Count(query, "$")
 	args := make([]interface{}, paramCount)
 
 	for i := 0; i < paramCount; i++ {
 		switch {
 		case strings.Contains(query, "data"):
-			args[i] = fmt.Sprintf("test_data_%d", rand.Intn(1000))
+			args[i] = fmt.Sprintf("test_data_%d", w.keys.Intn(rng, 1000))
 		case strings.Contains(query, "LIMIT"):
-			args[i] = rand.Intn(50) + 1
+			args[i] = rng.Intn(50) + 1
 		case strings.Contains(query, "created_at"):
-			args[i] = time.Now().Add(-time.Duration(rand.Intn(86400)) * time.Second)
+			args[i] = time.Now().Add(-time.Duration(rng.Intn(86400)) * time.Second)
 		default:
-			args[i] = rand.Intn(1000) + 1
+			args[i] = w.keys.Intn(rng, 1000) + 1
 		}
 	}
 
@@ -274,6 +285,7 @@ func (w *ConnectionWorkload) worker(ctx context.Context, wg *sync.WaitGroup, wor
 			}
 
 			op := w.operations[rng.Intn(len(w.operations))]
+			op.Args = w.generateArgsForQuery(rng, op.Query)
 
 			if op.UseTransient {
 				w.executeTransientOperation(ctx, workerID, op, pool, config, metrics)
@@ -288,6 +300,7 @@ func (w *ConnectionWorkload) worker(ctx context.Context, wg *sync.WaitGroup, wor
 }
 
 func (w *ConnectionWorkload) executePersistentOperation(ctx context.Context, workerID int, op Operation, pool *pgxpool.Pool, _ *types.Config, metrics *types.Metrics) {
+	ctx = types.WithOperation(ctx, "persistent_"+op.Type)
 	start := time.Now()
 
 	// Use connection from pool (persistent)
@@ -342,6 +355,7 @@ func (w *ConnectionWorkload) executePersistentOperation(ctx context.Context, wor
 
 	// Record metrics
 	metrics.RecordConnectionModeTransaction("persistent", success, duration)
+	metrics.RecordOperation("persistent_"+op.Type, duration, 0, success)
 	metrics.RecordConnectionModeQuery("persistent")
 	metrics.RecordWorkerTransaction(workerID, success, duration)
 	metrics.RecordWorkerQuery(workerID, strings.ToUpper(op.Type))
@@ -416,6 +430,7 @@ func (w *ConnectionWorkload) executeTransientOperation(ctx context.Context, work
 
 	// Record metrics
 	metrics.RecordConnectionModeTransaction("transient", success, duration)
+	metrics.RecordOperation("transient_"+op.Type, duration, 0, success)
 	metrics.RecordConnectionModeQuery("transient")
 	metrics.RecordWorkerTransaction(workerID, success, duration)
 	metrics.RecordWorkerQuery(workerID, strings.ToUpper(op.Type))
@@ -5,6 +5,7 @@ go 1.24.4
 require (
 	github.com/elchinoo/stormdb v0.0.0
 	github.com/jackc/pgx/v5 v5.7.5
+	github.com/spf13/viper v1.20.1
 )
 
 require (
@@ -26,7 +27,6 @@ require (
 	github.com/spf13/afero v1.12.0 // indirect
 	github.com/spf13/cast v1.7.1 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
-	github.com/spf13/viper v1.20.1 // indirect
 	github.com/subosito/gotenv v1.6.0 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
 	go.uber.org/zap v1.27.0 // indirect
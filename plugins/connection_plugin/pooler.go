@@ -0,0 +1,331 @@
+// Pooler comparison mode for the connection workload.
+//
+// When workload_config.pooler is configured, the workload runs the same
+// operation mix against the direct PostgreSQL endpoint and a connection
+// pooler endpoint (pgbouncer, pgcat, ...) side by side, with the configured
+// number of workers on each. Connect time, TPS and latency are tracked per
+// endpoint, and failures are classified so pooler-specific problems such as
+// missing prepared statements under transaction pooling stand out.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/database"
+	"github.com/elchinoo/stormdb/pkg/types"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/viper"
+)
+
+// Connection strategies used for each endpoint in pooler comparison mode
+const (
+	strategyTransient  = "transient"  // New connection per transaction
+	strategyPersistent = "persistent" // Long-lived client-side pool
+)
+
+// Failure categories reported per endpoint
+const (
+	failureConnect           = "connect_failed"
+	failurePreparedMissing   = "prepared_statement_missing"
+	failurePreparedExists    = "prepared_statement_exists"
+	failureStartupParameter  = "unsupported_startup_parameter"
+	failurePoolExhausted     = "pool_exhausted"
+	failureProtocolViolation = "protocol_violation"
+	failureQuery             = "query_failed"
+)
+
+// PoolerConfig describes the pooler endpoint compared against the direct
+// database connection. Empty connection fields inherit the database settings.
+type PoolerConfig struct {
+	Enabled  bool
+	Name     string
+	Host     string
+	Port     int
+	Dbname   string
+	Username string
+	Password string
+	Sslmode  string
+	Strategy string
+}
+
+// endpoint is a single target of the comparison
+type endpoint struct {
+	name       string
+	connString string
+	pool       *pgxpool.Pool
+}
+
+// parsePoolerConfig reads workload_config.pooler from the loaded configuration
+func parsePoolerConfig() *PoolerConfig {
+	pc := &PoolerConfig{
+		Name:     "pooler",
+		Strategy: strategyTransient,
+	}
+	if !viper.IsSet("workload_config.pooler") {
+		return pc
+	}
+
+	pc.Enabled = viper.GetBool("workload_config.pooler.enabled")
+	pc.Host = viper.GetString("workload_config.pooler.host")
+	pc.Port = viper.GetInt("workload_config.pooler.port")
+	pc.Dbname = viper.GetString("workload_config.pooler.dbname")
+	pc.Username = viper.GetString("workload_config.pooler.username")
+	pc.Password = viper.GetString("workload_config.pooler.password")
+	pc.Sslmode = viper.GetString("workload_config.pooler.sslmode")
+	if name := viper.GetString("workload_config.pooler.name"); name != "" {
+		pc.Name = name
+	}
+	if strategy := viper.GetString("workload_config.pooler.strategy"); strategy != "" {
+		pc.Strategy = strategy
+	}
+	return pc
+}
+
+// poolerDatabaseConfig returns a copy of cfg pointing at the pooler endpoint
+func poolerDatabaseConfig(cfg *types.Config, pc *PoolerConfig) *types.Config {
+	poolerCfg := *cfg
+	if pc.Host != "" {
+		poolerCfg.Database.Host = pc.Host
+	}
+	if pc.Port > 0 {
+		poolerCfg.Database.Port = pc.Port
+	}
+	if pc.Dbname != "" {
+		poolerCfg.Database.Dbname = pc.Dbname
+	}
+	if pc.Username != "" {
+		poolerCfg.Database.Username = pc.Username
+	}
+	if pc.Password != "" {
+		poolerCfg.Database.Password = pc.Password
+	}
+	if pc.Sslmode != "" {
+		poolerCfg.Database.Sslmode = pc.Sslmode
+	}
+	return &poolerCfg
+}
+
+// newEndpoint prepares an endpoint. Persistent endpoints get a client-side
+// pool sized to the worker count using the global query_exec_mode.
+func newEndpoint(ctx context.Context, name string, cfg *types.Config, strategy string) (*endpoint, error) {
+	ep := &endpoint{name: name, connString: database.BuildConnectionString(cfg)}
+	if strategy != strategyPersistent {
+		// Transient connections use the pgx modes directly; "prepared" needs
+		// a pool hook and falls back to the pgx default here
+		if database.IsValidQueryExecMode(cfg.QueryExecMode) && cfg.QueryExecMode != types.ExecModePrepared {
+			ep.connString += " default_query_exec_mode=" + cfg.QueryExecMode
+		}
+		return ep, nil
+	}
+
+	poolCfg, err := pgxpool.ParseConfig(fmt.Sprintf("%s pool_max_conns=%d", ep.connString, cfg.Workers))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s connection config: %w", name, err)
+	}
+	if err := database.ConfigureQueryExecMode(poolCfg, cfg.QueryExecMode, nil); err != nil {
+		return nil, err
+	}
+	if ep.pool, err = pgxpool.NewWithConfig(ctx, poolCfg); err != nil {
+		return nil, fmt.Errorf("failed to create %s pool: %w", name, err)
+	}
+	return ep, nil
+}
+
+// runPoolerComparison runs the operation mix against the direct and pooler
+// endpoints concurrently, each with config.Workers workers
+func (w *ConnectionWorkload) runPoolerComparison(ctx context.Context, config *types.Config, metrics *types.Metrics, pc *PoolerConfig) error {
+	if pc.Strategy != strategyTransient && pc.Strategy != strategyPersistent {
+		return fmt.Errorf("invalid pooler strategy: %s (valid: transient, persistent)", pc.Strategy)
+	}
+
+	direct, err := newEndpoint(ctx, "direct", config, pc.Strategy)
+	if err != nil {
+		return err
+	}
+	pooler, err := newEndpoint(ctx, pc.Name, poolerDatabaseConfig(config, pc), pc.Strategy)
+	if err != nil {
+		if direct.pool != nil {
+			direct.pool.Close()
+		}
+		return err
+	}
+	endpoints := []*endpoint{direct, pooler}
+	defer func() {
+		for _, ep := range endpoints {
+			if ep.pool != nil {
+				ep.pool.Close()
+			}
+		}
+	}()
+
+	log.Printf("Comparing direct vs %s (%s connections) with %d workers each",
+		pc.Name, pc.Strategy, config.Workers)
+
+	var wg sync.WaitGroup
+	for i, ep := range endpoints {
+		for j := 0; j < config.Workers; j++ {
+			wg.Add(1)
+			go w.endpointWorker(ctx, &wg, i*config.Workers+j, ep, metrics)
+		}
+	}
+	wg.Wait()
+
+	log.Println("Pooler comparison completed")
+	return nil
+}
+
+// endpointWorker executes random operations against a single endpoint
+func (w *ConnectionWorkload) endpointWorker(ctx context.Context, wg *sync.WaitGroup, workerID int, ep *endpoint, metrics *types.Metrics) {
+	defer wg.Done()
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano() + int64(workerID)))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			if len(w.operations) == 0 {
+				continue
+			}
+
+			op := w.operations[rng.Intn(len(w.operations))]
+			op.Args = w.generateArgsForQuery(rng, op.Query)
+			w.executeEndpointOperation(ctx, workerID, ep, op, metrics)
+
+			// Small delay to prevent overwhelming the database
+			time.Sleep(time.Millisecond * time.Duration(rng.Intn(10)+1))
+		}
+	}
+}
+
+// executeEndpointOperation runs one operation in a transaction against ep,
+// measuring connect (or pool acquire) time separately from the transaction
+func (w *ConnectionWorkload) executeEndpointOperation(ctx context.Context, workerID int, ep *endpoint, op Operation, metrics *types.Metrics) {
+	start := time.Now()
+
+	var conn *pgx.Conn
+	if ep.pool != nil {
+		pooled, err := ep.pool.Acquire(ctx)
+		if err != nil {
+			w.recordEndpointFailure(ctx, workerID, ep.name, failureConnect, err, metrics)
+			return
+		}
+		defer pooled.Release()
+		conn = pooled.Conn()
+	} else {
+		var err error
+		conn, err = pgx.Connect(ctx, ep.connString)
+		if err != nil {
+			w.recordEndpointFailure(ctx, workerID, ep.name, classifyConnectError(err), err, metrics)
+			return
+		}
+		defer conn.Close(context.Background())
+	}
+	metrics.RecordEndpointConnect(ep.name, time.Since(start).Nanoseconds())
+
+	err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if op.Type == "select" {
+			rows, err := tx.Query(ctx, op.Query, op.Args...)
+			if err != nil {
+				return err
+			}
+			for rows.Next() {
+				// Just iterate through results
+			}
+			rows.Close()
+			return rows.Err()
+		}
+		_, err := tx.Exec(ctx, op.Query, op.Args...)
+		return err
+	})
+
+	duration := time.Since(start).Nanoseconds()
+	success := err == nil
+
+	metrics.RecordEndpointTransaction(ep.name, success, duration)
+	metrics.RecordOperation(ep.name+"_"+op.Type, duration, 0, success)
+	metrics.RecordWorkerTransaction(workerID, success, duration)
+	metrics.RecordWorkerQuery(workerID, strings.ToUpper(op.Type))
+
+	if !success {
+		w.recordEndpointFailure(ctx, workerID, ep.name, classifyQueryError(err), err, metrics)
+	}
+}
+
+// recordEndpointFailure records a categorized failure unless the run is ending
+func (w *ConnectionWorkload) recordEndpointFailure(ctx context.Context, workerID int, name, category string, err error, metrics *types.Metrics) {
+	if ctx.Err() != nil {
+		return
+	}
+	metrics.RecordEndpointError(name, category)
+	metrics.RecordWorkerError(workerID)
+
+	metrics.Mu.Lock()
+	metrics.ErrorTypes[fmt.Sprintf("%s: %v", name, err)]++
+	metrics.Mu.Unlock()
+}
+
+// classifyConnectError categorizes errors returned while connecting
+func classifyConnectError(err error) string {
+	if category := classifyPgError(err); category != "" {
+		return category
+	}
+	return failureConnect
+}
+
+// classifyQueryError categorizes errors returned by a transaction
+func classifyQueryError(err error) string {
+	if category := classifyPgError(err); category != "" {
+		return category
+	}
+	return failureQuery
+}
+
+// classifyPgError maps server and pooler errors to failure categories.
+// Poolers report most of their own errors as protocol violations (08P01)
+// or FATAL messages, so the message text is checked as well.
+func classifyPgError(err error) string {
+	msg := strings.ToLower(err.Error())
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "26000": // invalid_sql_statement_name
+			if strings.Contains(msg, "prepared statement") {
+				return failurePreparedMissing
+			}
+		case "42P05": // duplicate_prepared_statement
+			return failurePreparedExists
+		case "53300": // too_many_connections
+			return failurePoolExhausted
+		}
+	}
+
+	switch {
+	case strings.Contains(msg, "prepared statement") && strings.Contains(msg, "does not exist"):
+		return failurePreparedMissing
+	case strings.Contains(msg, "prepared statement") && strings.Contains(msg, "already exists"):
+		return failurePreparedExists
+	case strings.Contains(msg, "unsupported startup parameter"):
+		return failureStartupParameter
+	case strings.Contains(msg, "no more connections allowed"),
+		strings.Contains(msg, "too many clients"),
+		strings.Contains(msg, "query_wait_timeout"),
+		strings.Contains(msg, "pool_size"):
+		return failurePoolExhausted
+	case pgErr != nil && pgErr.Code == "08P01":
+		return failureProtocolViolation
+	}
+	return ""
+}
@@ -4,6 +4,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"log"
 	"math/rand"
 	"strings"
 	"time"
@@ -25,7 +26,7 @@ func (w *ECommerceBasicWorkload) loadSampleData(ctx context.Context, db *pgxpool
 	reviewCount := scale
 	sessionCount := scale * 3
 
-	fmt.Printf("📊 Loading E-Commerce Basic sample data (scale=%d)...\n", scale)
+	log.Printf("📊 Loading E-Commerce Basic sample data (scale=%d)...", scale)
 
 	// Load users
 	if err := w.loadUsers(ctx, db, userCount); err != nil {
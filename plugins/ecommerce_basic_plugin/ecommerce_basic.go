@@ -10,6 +10,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/elchinoo/stormdb/pkg/keydist"
 	"github.com/elchinoo/stormdb/pkg/types"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -19,6 +20,9 @@ import (
 // This includes: users, products, orders, inventory, reviews, user sessions, product analytics
 type ECommerceBasicWorkload struct {
 	Mode string
+
+	// keys picks user, product and order ids using the key_distribution option
+	keys *keydist.Chooser
 }
 
 // GetName returns the workload name
@@ -305,6 +309,12 @@ func (w *ECommerceBasicWorkload) Cleanup(ctx context.Context, db *pgxpool.Pool,
 func (w *ECommerceBasicWorkload) Run(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics) error {
 	log.Printf("🌍 Starting Real-World %s workload...", w.Mode)
 
+	keys, err := keydist.FromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid key distribution: %w", err)
+	}
+	w.keys = keys
+
 	// Initialize worker-specific metrics tracking
 	metrics.InitializeWorkerMetrics(cfg.Workers)
 
@@ -345,47 +355,51 @@ func (w *ECommerceBasicWorkload) worker(ctx context.Context, db *pgxpool.Pool, c
 		default:
 			opStart := time.Now()
 			var err error
-			var operation string
+			var kind, operation string
+			var rows int64 = 1
 
 			switch w.Mode {
 			case "read":
+				kind = "read"
 				operation, err = w.executeReadOperation(ctx, db, rng)
-				atomic.AddInt64(&metrics.RowsRead, 1)
 			case "write":
-				operation = "write"
-				err = w.executeWriteOperation(ctx, db, rng)
-				atomic.AddInt64(&metrics.RowsModified, 1)
+				kind = "write"
+				operation, err = w.executeWriteOperation(ctx, db, rng)
 			case "mixed":
 				if rng.Intn(100) < 75 { // 75% reads, 25% writes
+					kind = "read"
 					operation, err = w.executeReadOperation(ctx, db, rng)
-					atomic.AddInt64(&metrics.RowsRead, 1)
 				} else {
-					operation = "write"
-					err = w.executeWriteOperation(ctx, db, rng)
-					atomic.AddInt64(&metrics.RowsModified, 1)
+					kind = "write"
+					operation, err = w.executeWriteOperation(ctx, db, rng)
 				}
 			case "oltp":
 				// OLTP workload: frequent small transactions
 				if rng.Intn(100) < 60 { // 60% reads
-					operation = "oltp_read"
-					err = w.executeOLTPReadOperation(ctx, db, rng)
-					atomic.AddInt64(&metrics.RowsRead, 1)
+					kind = "oltp_read"
+					operation, err = w.executeOLTPReadOperation(ctx, db, rng)
 				} else { // 40% writes
-					operation = "oltp_write"
-					err = w.executeOLTPWriteOperation(ctx, db, rng)
-					atomic.AddInt64(&metrics.RowsModified, 1)
+					kind = "oltp_write"
+					operation, err = w.executeOLTPWriteOperation(ctx, db, rng)
 				}
 			case "analytics":
 				// Analytics workload: complex analytical queries
-				operation = "analytics"
-				err = w.executeAnalyticsOperation(ctx, db, rng)
-				atomic.AddInt64(&metrics.RowsRead, 10) // Analytics typically read many rows
+				kind = "analytics"
+				operation, err = w.executeAnalyticsOperation(ctx, db, rng)
+				rows = 10 // Analytics typically read many rows
 			default:
+				kind = "read"
 				operation, err = w.executeReadOperation(ctx, db, rng)
-				atomic.AddInt64(&metrics.RowsRead, 1)
+			}
+
+			if kind == "write" || kind == "oltp_write" {
+				atomic.AddInt64(&metrics.RowsModified, rows)
+			} else {
+				atomic.AddInt64(&metrics.RowsRead, rows)
 			}
 
 			elapsed := time.Since(opStart).Nanoseconds()
+			metrics.RecordOperation(operation, elapsed, rows, err == nil)
 
 			// Record metrics
 			metrics.Mu.Lock()
@@ -405,10 +419,8 @@ func (w *ECommerceBasicWorkload) worker(ctx context.Context, db *pgxpool.Pool, c
 				metrics.RecordWorkerTransaction(workerID, false, elapsed)
 			} else {
 				// Record query type for breakdown table
-				switch operation {
-				case "get_user_orders", "get_product_details", "get_product_reviews", "get_products_by_category", "get_top_products_by_category", "get_recent_activity", "get_product_analytics":
-					metrics.RecordQuery("SELECT")
-				case "write":
+				switch kind {
+				case "write", "oltp_write":
 					// For write operations, randomly distribute between INSERT/UPDATE
 					if rng.Intn(2) == 0 {
 						metrics.RecordQuery("INSERT")
@@ -7,80 +7,55 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/elchinoo/stormdb/pkg/types"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // READ OPERATIONS - Mix of indexed and non-indexed queries
 
+// namedOperation is a workload operation with the name its metrics are
+// recorded under
+type namedOperation struct {
+	name string
+	run  func(context.Context, *pgxpool.Pool, *rand.Rand) error
+}
+
 // executeReadOperation performs various read operations
 func (w *ECommerceBasicWorkload) executeReadOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) (string, error) {
-	operations := []func(context.Context, *pgxpool.Pool, *rand.Rand) error{
+	operations := []namedOperation{
 		// Simple indexed queries (40% of reads)
-		w.getUserByEmail,        // Uses unique index on email
-		w.getProductBySKU,       // Uses unique index on SKU
-		w.getProductsByCategory, // Uses index on category
-		w.getUserOrders,         // Uses index on user_id
-		w.getProductReviews,     // Uses index on product_id
+		{"getUserByEmail", w.getUserByEmail},               // Uses unique index on email
+		{"getProductBySKU", w.getProductBySKU},             // Uses unique index on SKU
+		{"getProductsByCategory", w.getProductsByCategory}, // Uses index on category
+		{"getUserOrders", w.getUserOrders},                 // Uses index on user_id
+		{"getProductReviews", w.getProductReviews},         // Uses index on product_id
 
 		// Complex joins (30% of reads)
-		w.getOrderDetailsWithItems, // Multi-table join with indexes
-		w.getUserActivitySummary,   // Complex join across multiple tables
-		w.getProductAnalytics,      // Join with analytics data
+		{"getOrderDetailsWithItems", w.getOrderDetailsWithItems}, // Multi-table join with indexes
+		{"getUserActivitySummary", w.getUserActivitySummary},     // Complex join across multiple tables
+		{"getProductAnalytics", w.getProductAnalytics},           // Join with analytics data
 
 		// Full table scans / non-indexed queries (20% of reads)
-		w.searchProductsByName, // Full-text search
-		w.findSimilarUsers,     // Complex query without good indexes
-		w.getRecentActivity,    // Date range query possibly without index
+		{"searchProductsByName", w.searchProductsByName}, // Full-text search
+		{"findSimilarUsers", w.findSimilarUsers},         // Complex query without good indexes
+		{"getRecentActivity", w.getRecentActivity},       // Date range query possibly without index
 
 		// Window functions and CTEs (10% of reads)
-		w.getTopProductsByCategory, // Window functions for ranking
-		w.getUserSpendingTrends,    // CTE with window functions
-		w.getInventoryAnalysis,     // Complex CTE analysis
+		{"getTopProductsByCategory", w.getTopProductsByCategory}, // Window functions for ranking
+		{"getUserSpendingTrends", w.getUserSpendingTrends},       // CTE with window functions
+		{"getInventoryAnalysis", w.getInventoryAnalysis},         // Complex CTE analysis
 	}
 
 	op := operations[rng.Intn(len(operations))]
-	opName := ""
-
-	switch rng.Intn(14) {
-	case 0:
-		opName = "get_user_by_email"
-	case 1:
-		opName = "get_product_by_sku"
-	case 2:
-		opName = "get_products_by_category"
-	case 3:
-		opName = "get_user_orders"
-	case 4:
-		opName = "get_product_reviews"
-	case 5:
-		opName = "get_order_details_with_items"
-	case 6:
-		opName = "get_user_activity_summary"
-	case 7:
-		opName = "get_product_analytics"
-	case 8:
-		opName = "search_products_by_name"
-	case 9:
-		opName = "find_similar_users"
-	case 10:
-		opName = "get_recent_activity"
-	case 11:
-		opName = "get_top_products_by_category"
-	case 12:
-		opName = "get_user_spending_trends"
-	case 13:
-		opName = "get_inventory_analysis"
-	}
-
-	return opName, op(ctx, db, rng)
+	return op.name, op.run(types.WithOperation(ctx, op.name), db, rng)
 }
 
 // Simple indexed queries
 
 // getUserByEmail retrieves user by email (uses unique index)
 func (w *ECommerceBasicWorkload) getUserByEmail(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 	email := fmt.Sprintf("user%d@example.com", userID)
 
 	var firstName, lastName, country string
@@ -100,7 +75,7 @@ func (w *ECommerceBasicWorkload) getUserByEmail(ctx context.Context, db *pgxpool
 
 // getProductBySKU retrieves product by SKU (uses unique index)
 func (w *ECommerceBasicWorkload) getProductBySKU(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	productID := rng.Intn(500) + 1
+	productID := w.keys.Intn(rng, 500) + 1
 	sku := fmt.Sprintf("SKU-%06d", productID)
 
 	var name, brand, category string
@@ -152,7 +127,7 @@ func (w *ECommerceBasicWorkload) getProductsByCategory(ctx context.Context, db *
 
 // getOrderDetailsWithItems retrieves order with all items (multi-table join)
 func (w *ECommerceBasicWorkload) getOrderDetailsWithItems(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	orderID := rng.Intn(2000) + 1
+	orderID := w.keys.Intn(rng, 2000) + 1
 
 	rows, err := db.Query(ctx, `
 		SELECT 
@@ -192,7 +167,7 @@ func (w *ECommerceBasicWorkload) getOrderDetailsWithItems(ctx context.Context, d
 
 // getUserActivitySummary gets comprehensive user activity (complex join)
 func (w *ECommerceBasicWorkload) getUserActivitySummary(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 
 	var firstName, lastName string
 	var totalSpent float64
@@ -248,7 +223,7 @@ func (w *ECommerceBasicWorkload) getUserActivitySummary(ctx context.Context, db
 
 // getUserOrders retrieves user's recent orders (uses index on user_id)
 func (w *ECommerceBasicWorkload) getUserOrders(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 
 	rows, err := db.Query(ctx, `
 		SELECT order_id, order_number, status, total_amount, created_at
@@ -276,7 +251,7 @@ func (w *ECommerceBasicWorkload) getUserOrders(ctx context.Context, db *pgxpool.
 
 // getProductReviews retrieves reviews for a product (uses index on product_id)
 func (w *ECommerceBasicWorkload) getProductReviews(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	productID := rng.Intn(500) + 1
+	productID := w.keys.Intn(rng, 500) + 1
 
 	rows, err := db.Query(ctx, `
 		SELECT 
@@ -311,7 +286,7 @@ func (w *ECommerceBasicWorkload) getProductReviews(ctx context.Context, db *pgxp
 
 // getProductAnalytics retrieves product analytics data (join with analytics data)
 func (w *ECommerceBasicWorkload) getProductAnalytics(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	productID := rng.Intn(500) + 1
+	productID := w.keys.Intn(rng, 500) + 1
 
 	rows, err := db.Query(ctx, `
 		SELECT 
@@ -474,7 +449,7 @@ func (w *ECommerceBasicWorkload) searchProductsByName(ctx context.Context, db *p
 
 // findSimilarUsers finds users with similar preferences (complex non-indexed query)
 func (w *ECommerceBasicWorkload) findSimilarUsers(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 
 	rows, err := db.Query(ctx, `
 		SELECT 
@@ -545,7 +520,7 @@ func (w *ECommerceBasicWorkload) getTopProductsByCategory(ctx context.Context, d
 
 // getUserSpendingTrends uses CTEs with window functions for trend analysis
 func (w *ECommerceBasicWorkload) getUserSpendingTrends(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 
 	rows, err := db.Query(ctx, `
 		WITH monthly_spending AS (
@@ -591,61 +566,61 @@ func (w *ECommerceBasicWorkload) getUserSpendingTrends(ctx context.Context, db *
 // Write Operations for OLTP workloads
 
 // executeWriteOperation performs various write operations
-func (w *ECommerceBasicWorkload) executeWriteOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	operations := []func(context.Context, *pgxpool.Pool, *rand.Rand) error{
-		w.createUser,
-		w.createProduct,
-		w.createOrder,
-		w.updateUserInfo,
-		w.updateProductRating,
-		w.updateInventory,
-		w.createReview,
-		w.logProductView,
+func (w *ECommerceBasicWorkload) executeWriteOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) (string, error) {
+	operations := []namedOperation{
+		{"createUser", w.createUser},
+		{"createProduct", w.createProduct},
+		{"createOrder", w.createOrder},
+		{"updateUserInfo", w.updateUserInfo},
+		{"updateProductRating", w.updateProductRating},
+		{"updateInventory", w.updateInventory},
+		{"createReview", w.createReview},
+		{"logProductView", w.logProductView},
 	}
 
 	operation := operations[rng.Intn(len(operations))]
-	return operation(ctx, db, rng)
+	return operation.name, operation.run(ctx, db, rng)
 }
 
 // executeOLTPReadOperation performs OLTP-style read operations (fast, indexed queries)
-func (w *ECommerceBasicWorkload) executeOLTPReadOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	operations := []func(context.Context, *pgxpool.Pool, *rand.Rand) error{
-		w.getUserByEmail,
-		w.getProductBySKU,
-		w.getUserOrders,
-		w.getProductReviews,
-		w.getProductsByCategory,
+func (w *ECommerceBasicWorkload) executeOLTPReadOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) (string, error) {
+	operations := []namedOperation{
+		{"getUserByEmail", w.getUserByEmail},
+		{"getProductBySKU", w.getProductBySKU},
+		{"getUserOrders", w.getUserOrders},
+		{"getProductReviews", w.getProductReviews},
+		{"getProductsByCategory", w.getProductsByCategory},
 	}
 
 	operation := operations[rng.Intn(len(operations))]
-	return operation(ctx, db, rng)
+	return operation.name, operation.run(ctx, db, rng)
 }
 
 // executeOLTPWriteOperation performs OLTP-style write operations
-func (w *ECommerceBasicWorkload) executeOLTPWriteOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	operations := []func(context.Context, *pgxpool.Pool, *rand.Rand) error{
-		w.createOrder,
-		w.updateInventory,
-		w.logProductView,
-		w.updateUserInfo,
+func (w *ECommerceBasicWorkload) executeOLTPWriteOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) (string, error) {
+	operations := []namedOperation{
+		{"createOrder", w.createOrder},
+		{"updateInventory", w.updateInventory},
+		{"logProductView", w.logProductView},
+		{"updateUserInfo", w.updateUserInfo},
 	}
 
 	operation := operations[rng.Intn(len(operations))]
-	return operation(ctx, db, rng)
+	return operation.name, operation.run(ctx, db, rng)
 }
 
 // executeAnalyticsOperation performs analytics-style queries (complex, resource-intensive)
-func (w *ECommerceBasicWorkload) executeAnalyticsOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	operations := []func(context.Context, *pgxpool.Pool, *rand.Rand) error{
-		w.getUserSpendingTrends,
-		w.getTopProductsByCategory,
-		w.getInventoryAnalysis,
-		w.getRecentActivity,
-		w.findSimilarUsers,
+func (w *ECommerceBasicWorkload) executeAnalyticsOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) (string, error) {
+	operations := []namedOperation{
+		{"getUserSpendingTrends", w.getUserSpendingTrends},
+		{"getTopProductsByCategory", w.getTopProductsByCategory},
+		{"getInventoryAnalysis", w.getInventoryAnalysis},
+		{"getRecentActivity", w.getRecentActivity},
+		{"findSimilarUsers", w.findSimilarUsers},
 	}
 
 	operation := operations[rng.Intn(len(operations))]
-	return operation(ctx, db, rng)
+	return operation.name, operation.run(ctx, db, rng)
 }
 
 // Individual write operations
@@ -694,7 +669,7 @@ func (w *ECommerceBasicWorkload) createOrder(ctx context.Context, db *pgxpool.Po
 	}
 	defer tx.Rollback(ctx)
 
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 	orderNumber := fmt.Sprintf("ORD-%d-%d", time.Now().Unix(), rng.Intn(10000))
 
 	var orderID int
@@ -712,7 +687,7 @@ func (w *ECommerceBasicWorkload) createOrder(ctx context.Context, db *pgxpool.Po
 	totalAmount := 0.0
 
 	for i := 0; i < itemCount; i++ {
-		productID := rng.Intn(500) + 1
+		productID := w.keys.Intn(rng, 500) + 1
 		quantity := rng.Intn(3) + 1
 		unitPrice := float64(rng.Intn(200) + 10)
 		totalPrice := float64(quantity) * unitPrice
@@ -741,7 +716,7 @@ func (w *ECommerceBasicWorkload) createOrder(ctx context.Context, db *pgxpool.Po
 
 // updateUserInfo updates user information
 func (w *ECommerceBasicWorkload) updateUserInfo(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 	spentAmount := float64(rng.Intn(1000))
 
 	_, err := db.Exec(ctx, `
@@ -755,7 +730,7 @@ func (w *ECommerceBasicWorkload) updateUserInfo(ctx context.Context, db *pgxpool
 
 // updateProductRating updates product average rating
 func (w *ECommerceBasicWorkload) updateProductRating(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	productID := rng.Intn(500) + 1
+	productID := w.keys.Intn(rng, 500) + 1
 	newRating := float64(rng.Intn(5)) + 1.0 + (float64(rng.Intn(10)) / 10.0)
 
 	_, err := db.Exec(ctx, `
@@ -770,7 +745,7 @@ func (w *ECommerceBasicWorkload) updateProductRating(ctx context.Context, db *pg
 
 // updateInventory updates product inventory
 func (w *ECommerceBasicWorkload) updateInventory(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	productID := rng.Intn(500) + 1
+	productID := w.keys.Intn(rng, 500) + 1
 	quantityChange := rng.Intn(20) - 10 // -10 to +9
 
 	_, err := db.Exec(ctx, `
@@ -785,8 +760,8 @@ func (w *ECommerceBasicWorkload) updateInventory(ctx context.Context, db *pgxpoo
 
 // createReview creates a product review
 func (w *ECommerceBasicWorkload) createReview(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
-	productID := rng.Intn(500) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
+	productID := w.keys.Intn(rng, 500) + 1
 	rating := rng.Intn(5) + 1
 	titles := []string{"Great product!", "Not bad", "Amazing quality", "Could be better", "Excellent value"}
 	title := titles[rng.Intn(len(titles))]
@@ -801,8 +776,8 @@ func (w *ECommerceBasicWorkload) createReview(ctx context.Context, db *pgxpool.P
 
 // logProductView logs a product view event for analytics
 func (w *ECommerceBasicWorkload) logProductView(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
-	productID := rng.Intn(500) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
+	productID := w.keys.Intn(rng, 500) + 1
 	eventTypes := []string{"view", "add_to_cart", "purchase", "wishlist_add"}
 	eventType := eventTypes[rng.Intn(len(eventTypes))]
 
@@ -0,0 +1,377 @@
+// internal/workload/ecommerce/corpus.go
+package main
+
+import (
+	"bufio"
+	"embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// builtinCorpus holds the default word lists, used for any file missing
+// from the configured corpus directory
+//
+//go:embed corpus
+var builtinCorpus embed.FS
+
+// Corpus file names, looked up in corpus_dir before the built-in corpus
+const (
+	adjectivesFile = "adjectives.txt"
+	nounsFile      = "nouns.txt"
+	firstNamesFile = "first_names.txt"
+	lastNamesFile  = "last_names.txt"
+	streetsFile    = "streets.txt"
+	citiesFile     = "cities.csv"
+	productsFile   = "products.csv"
+	reviewsFile    = "reviews.csv"
+)
+
+// Review sentiments, chosen from the rating
+const (
+	sentimentPositive = "positive"
+	sentimentNeutral  = "neutral"
+	sentimentNegative = "negative"
+)
+
+// defaultEndDate is the latest generated timestamp when end_date is unset.
+// It is fixed rather than the current time so a seed reproduces the same
+// data on any day.
+var defaultEndDate = time.Date(2025, time.December, 31, 23, 59, 59, 0, time.UTC)
+
+// DataGenConfig controls how the sample data is generated
+type DataGenConfig struct {
+	Seed        int64     // Random seed; the same seed, scale and corpus give the same data
+	CorpusDir   string    // Directory whose files replace the built-in corpus
+	OrderSkew   float64   // Zipf exponent of orders per user (> 1)
+	ProductSkew float64   // Zipf exponent of product popularity (> 1)
+	WordSkew    float64   // Zipf exponent of adjective and noun frequency (> 1)
+	HistoryDays int       // Days of order history
+	Seasonality bool      // Weight timestamps by hour of day, weekday and month
+	EndDate     time.Time // Latest generated timestamp
+}
+
+// parseDataGenConfig reads workload_config.data_generation
+func parseDataGenConfig() (DataGenConfig, error) {
+	cfg := DataGenConfig{
+		Seed:        42,
+		OrderSkew:   1.2,
+		ProductSkew: 1.1,
+		WordSkew:    1.1,
+		HistoryDays: 90,
+		Seasonality: true,
+		EndDate:     defaultEndDate,
+	}
+
+	const prefix = "workload_config.data_generation."
+	if viper.IsSet(prefix + "seed") {
+		cfg.Seed = viper.GetInt64(prefix + "seed")
+	}
+	cfg.CorpusDir = viper.GetString(prefix + "corpus_dir")
+	if v := viper.GetFloat64(prefix + "order_skew"); v != 0 {
+		cfg.OrderSkew = v
+	}
+	if v := viper.GetFloat64(prefix + "product_skew"); v != 0 {
+		cfg.ProductSkew = v
+	}
+	if v := viper.GetFloat64(prefix + "word_skew"); v != 0 {
+		cfg.WordSkew = v
+	}
+	if v := viper.GetInt(prefix + "history_days"); v != 0 {
+		cfg.HistoryDays = v
+	}
+	if viper.IsSet(prefix + "seasonality") {
+		cfg.Seasonality = viper.GetBool(prefix + "seasonality")
+	}
+	if v := viper.GetString(prefix + "end_date"); v != "" {
+		endDate, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid end_date %q, expected YYYY-MM-DD: %w", v, err)
+		}
+		// Include the whole end day
+		cfg.EndDate = endDate.Add(24*time.Hour - time.Second)
+	}
+
+	for name, skew := range map[string]float64{"order_skew": cfg.OrderSkew, "product_skew": cfg.ProductSkew, "word_skew": cfg.WordSkew} {
+		if skew <= 1 {
+			return cfg, fmt.Errorf("%s must be greater than 1, got %g", name, skew)
+		}
+	}
+	if cfg.HistoryDays < 1 {
+		return cfg, fmt.Errorf("history_days must be positive, got %d", cfg.HistoryDays)
+	}
+	return cfg, nil
+}
+
+// cityEntry is one row of cities.csv
+type cityEntry struct {
+	City    string
+	State   string
+	Country string
+}
+
+// productEntry is one row of products.csv
+type productEntry struct {
+	Name        string
+	Category    string
+	Subcategory string
+	Brand       string
+	Price       float64 // 0 when the catalog has no price
+	Description string
+}
+
+// reviewTemplates holds the review lines of one sentiment. Templates may
+// contain {product}, {brand}, {category}, {subcategory}, {adjective} and {noun}.
+type reviewTemplates struct {
+	titles    []string
+	sentences []string
+}
+
+// corpus holds the word lists the data generator and the search operations draw from
+type corpus struct {
+	adjectives []string // In rough order of frequency
+	nouns      []string // Product aspects, in rough order of frequency
+	firstNames []string
+	lastNames  []string
+	streets    []string
+	cities     []cityEntry
+	products   []productEntry
+	reviews    map[string]*reviewTemplates
+
+	categories  []string // Distinct product categories
+	searchTerms []string // Distinct words of product names
+}
+
+// loadCorpus reads the corpus files from dir, falling back to the built-in
+// corpus for files that dir does not have
+func loadCorpus(dir string) (*corpus, error) {
+	c := &corpus{reviews: make(map[string]*reviewTemplates)}
+
+	lists := []struct {
+		file string
+		dest *[]string
+	}{
+		{adjectivesFile, &c.adjectives},
+		{nounsFile, &c.nouns},
+		{firstNamesFile, &c.firstNames},
+		{lastNamesFile, &c.lastNames},
+		{streetsFile, &c.streets},
+	}
+	for _, l := range lists {
+		lines, err := readCorpusLines(dir, l.file)
+		if err != nil {
+			return nil, err
+		}
+		*l.dest = lines
+	}
+
+	cities, err := readCorpusCSV(dir, citiesFile, "city", "country")
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range cities {
+		c.cities = append(c.cities, cityEntry{City: row["city"], State: row["state"], Country: row["country"]})
+	}
+
+	products, err := readCorpusCSV(dir, productsFile, "name", "category")
+	if err != nil {
+		return nil, err
+	}
+	for i, row := range products {
+		p := productEntry{
+			Name:        row["name"],
+			Category:    row["category"],
+			Subcategory: row["subcategory"],
+			Brand:       row["brand"],
+			Description: row["description"],
+		}
+		if p.Subcategory == "" {
+			p.Subcategory = p.Category
+		}
+		if p.Brand == "" {
+			p.Brand = "Generic"
+		}
+		if v := row["price"]; v != "" {
+			if p.Price, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, fmt.Errorf("%s row %d: invalid price %q", productsFile, i+2, v)
+			}
+		}
+		c.products = append(c.products, p)
+	}
+
+	reviews, err := readCorpusCSV(dir, reviewsFile, "sentiment", "kind", "text")
+	if err != nil {
+		return nil, err
+	}
+	for i, row := range reviews {
+		t := c.reviews[row["sentiment"]]
+		if t == nil {
+			t = &reviewTemplates{}
+			c.reviews[row["sentiment"]] = t
+		}
+		switch row["kind"] {
+		case "title":
+			t.titles = append(t.titles, row["text"])
+		case "sentence":
+			t.sentences = append(t.sentences, row["text"])
+		default:
+			return nil, fmt.Errorf("%s row %d: unknown kind %q (use title or sentence)", reviewsFile, i+2, row["kind"])
+		}
+	}
+	for _, sentiment := range []string{sentimentPositive, sentimentNeutral, sentimentNegative} {
+		if t := c.reviews[sentiment]; t == nil || len(t.titles) == 0 || len(t.sentences) == 0 {
+			return nil, fmt.Errorf("%s needs at least one %s title and sentence", reviewsFile, sentiment)
+		}
+	}
+
+	c.index()
+	if len(c.searchTerms) == 0 {
+		return nil, fmt.Errorf("%s product names have no searchable words", productsFile)
+	}
+	return c, nil
+}
+
+// index derives the category and search term lists from the catalog
+func (c *corpus) index() {
+	seenCategory := make(map[string]bool)
+	seenTerm := make(map[string]bool)
+	for _, p := range c.products {
+		if !seenCategory[p.Category] {
+			seenCategory[p.Category] = true
+			c.categories = append(c.categories, p.Category)
+		}
+		for _, field := range strings.Fields(p.Name) {
+			term := strings.ToLower(strings.Trim(field, ".,;:()!?\"'"))
+			if len(term) < 3 || strings.ContainsAny(term, "0123456789") || seenTerm[term] {
+				continue
+			}
+			seenTerm[term] = true
+			c.searchTerms = append(c.searchTerms, term)
+		}
+	}
+}
+
+// openCorpusFile opens name from dir, or from the built-in corpus when dir
+// is empty or has no such file
+func openCorpusFile(dir, name string) (io.ReadCloser, error) {
+	if dir != "" {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err == nil {
+			return f, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to open corpus file: %w", err)
+		}
+	}
+	return builtinCorpus.Open("corpus/" + name)
+}
+
+// readCorpusLines reads a list file, skipping blank lines and # comments
+func readCorpusLines(dir, name string) ([]string, error) {
+	f, err := openCorpusFile(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("corpus file %s is empty", name)
+	}
+	return lines, nil
+}
+
+// readCorpusCSV reads a CSV file with a header row into maps keyed by
+// lower-case column name, checking that the required columns exist
+func readCorpusCSV(dir, name string, required ...string) ([]map[string]string, error) {
+	f, err := openCorpusFile(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("corpus file %s needs a header and at least one row", name)
+	}
+
+	header := records[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	for _, col := range required {
+		found := false
+		for _, h := range header {
+			found = found || h == col
+		}
+		if !found {
+			return nil, fmt.Errorf("corpus file %s is missing column %q", name, col)
+		}
+	}
+
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, value := range record {
+			row[header[i]] = strings.TrimSpace(value)
+		}
+		for _, col := range required {
+			if row[col] == "" {
+				return nil, fmt.Errorf("corpus file %s has a row without %s", name, col)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// skewedIndex returns an index in [0, n) favouring low indexes, so lists
+// kept in order of frequency are sampled roughly that way
+func skewedIndex(rng *rand.Rand, n int) int {
+	u := rng.Float64()
+	return int(u * u * float64(n))
+}
+
+// searchTerm returns a word from the product names to search for
+func (c *corpus) searchTerm(rng *rand.Rand) string {
+	return c.searchTerms[rng.Intn(len(c.searchTerms))]
+}
+
+// reviewTerm returns a word that commonly appears in review text
+func (c *corpus) reviewTerm(rng *rand.Rand) string {
+	words := c.nouns
+	if rng.Intn(2) == 0 {
+		words = c.adjectives
+	}
+	return words[skewedIndex(rng, len(words))]
+}
+
+// category returns one of the catalog categories
+func (c *corpus) category(rng *rand.Rand) string {
+	return c.categories[rng.Intn(len(c.categories))]
+}
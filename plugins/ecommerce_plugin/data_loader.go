@@ -4,7 +4,8 @@ package main
 import (
 	"context"
 	"fmt"
-	"math/rand"
+	"log"
+	"math"
 	"strings"
 	"time"
 
@@ -13,11 +14,17 @@ import (
 )
 
 // loadSampleData generates realistic sample data for the e-commerce platform
+// from the corpus, using a single seeded source of randomness
 func (w *ECommerceWorkload) loadSampleData(ctx context.Context, db *pgxpool.Pool, scale int) error {
 	if scale <= 0 {
 		scale = 1000 // Default scale
 	}
 
+	genCfg, err := parseDataGenConfig()
+	if err != nil {
+		return fmt.Errorf("invalid data_generation config: %w", err)
+	}
+
 	// Scale factors
 	userCount := scale
 	vendorCount := scale / 20 // 1 vendor per 20 users
@@ -26,59 +33,61 @@ func (w *ECommerceWorkload) loadSampleData(ctx context.Context, db *pgxpool.Pool
 	reviewCount := scale      // 1 review per user
 	sessionCount := scale * 3 // 3 sessions per user
 
-	fmt.Printf("📊 Loading E-Commerce sample data (scale=%d)...\n", scale)
+	g := newDataGenerator(genCfg, w.corpus, userCount, productCount)
+
+	log.Printf("📊 Loading E-Commerce sample data (scale=%d, seed=%d)...", scale, genCfg.Seed)
 
 	// Load vendors first (required for products)
-	if err := w.loadVendors(ctx, db, vendorCount); err != nil {
+	if err := w.loadVendors(ctx, db, g, vendorCount); err != nil {
 		return err
 	}
 
 	// Load users
-	if err := w.loadUsers(ctx, db, userCount); err != nil {
+	if err := w.loadUsers(ctx, db, g, userCount); err != nil {
 		return err
 	}
 
 	// Load products
-	if err := w.loadProducts(ctx, db, productCount, vendorCount); err != nil {
+	if err := w.loadProducts(ctx, db, g, productCount, vendorCount); err != nil {
 		return err
 	}
 
 	// Load inventory
-	if err := w.loadInventory(ctx, db, productCount, vendorCount); err != nil {
+	if err := w.loadInventory(ctx, db, g, productCount, vendorCount); err != nil {
 		return err
 	}
 
 	// Load orders and order items
-	if err := w.loadOrders(ctx, db, orderCount, userCount, productCount); err != nil {
+	if err := w.loadOrders(ctx, db, g, orderCount); err != nil {
 		return err
 	}
 
 	// Load reviews
-	if err := w.loadReviews(ctx, db, reviewCount, userCount, productCount); err != nil {
+	if err := w.loadReviews(ctx, db, g, reviewCount); err != nil {
 		return err
 	}
 
 	// Load user sessions
-	if err := w.loadUserSessions(ctx, db, sessionCount, userCount); err != nil {
+	if err := w.loadUserSessions(ctx, db, g, sessionCount); err != nil {
 		return err
 	}
 
 	// Load product analytics
-	if err := w.loadProductAnalytics(ctx, db, sessionCount, userCount, productCount); err != nil {
+	if err := w.loadProductAnalytics(ctx, db, g, sessionCount); err != nil {
 		return err
 	}
 
 	// Load some initial purchase orders
-	if err := w.loadInitialPurchaseOrders(ctx, db, vendorCount, productCount); err != nil {
+	if err := w.loadInitialPurchaseOrders(ctx, db, g, vendorCount, productCount); err != nil {
 		return err
 	}
 
-	fmt.Printf("✅ E-Commerce sample data loaded successfully\n")
+	log.Printf("✅ E-Commerce sample data loaded successfully")
 	return nil
 }
 
 // loadVendors generates vendor data
-func (w *ECommerceWorkload) loadVendors(ctx context.Context, db *pgxpool.Pool, count int) error {
+func (w *ECommerceWorkload) loadVendors(ctx context.Context, db *pgxpool.Pool, g *dataGenerator, count int) error {
 	vendorNames := []string{
 		"TechSupply Corp", "Global Electronics", "Fashion Forward Inc", "Home Essentials Ltd",
 		"Sports Gear Co", "Beauty Products Inc", "Toy World Suppliers", "Book Distributors",
@@ -87,7 +96,6 @@ func (w *ECommerceWorkload) loadVendors(ctx context.Context, db *pgxpool.Pool, c
 		"Jewelry Suppliers", "Watch Company", "Shoe Distributors", "Clothing Manufacturers",
 	}
 
-	countries := []string{"USA", "Canada", "UK", "Germany", "France", "Italy", "Spain", "Brazil", "Japan", "China"}
 	paymentTerms := []string{"Net 30", "Net 60", "2/10 Net 30", "COD", "Net 15"}
 
 	// Create progress tracker
@@ -95,17 +103,14 @@ func (w *ECommerceWorkload) loadVendors(ctx context.Context, db *pgxpool.Pool, c
 
 	batch := make([][]interface{}, 0, 100)
 	for i := 1; i <= count; i++ {
-		vendorName := vendorNames[rand.Intn(len(vendorNames))] + fmt.Sprintf(" #%d", i)
+		vendorName := g.pick(vendorNames) + fmt.Sprintf(" #%d", i)
 		email := fmt.Sprintf("vendor%d@%s.com", i, strings.ToLower(strings.ReplaceAll(vendorName[:10], " ", "")))
-		phone := fmt.Sprintf("+1-%03d-%03d-%04d", rand.Intn(900)+100, rand.Intn(900)+100, rand.Intn(9000)+1000)
-		country := countries[rand.Intn(len(countries))]
-		paymentTerm := paymentTerms[rand.Intn(len(paymentTerms))]
-		leadTime := rand.Intn(14) + 3 // 3-17 days
-		minOrder := float64(rand.Intn(1000) + 100)
-		rating := 3.0 + rand.Float64()*2.0 // 3.0-5.0
-
-		address := fmt.Sprintf(`{"street": "%d Main St", "city": "Business City", "country": "%s", "postal_code": "%05d"}`,
-			rand.Intn(9999)+1, country, rand.Intn(99999))
+		phone := g.phone()
+		paymentTerm := g.pick(paymentTerms)
+		leadTime := g.rng.Intn(14) + 3 // 3-17 days
+		minOrder := float64(g.rng.Intn(1000) + 100)
+		rating := 3.0 + g.rng.Float64()*2.0 // 3.0-5.0
+		address := g.address()
 
 		batch = append(batch, []interface{}{
 			vendorName, email, phone, address, paymentTerm, leadTime, minOrder, rating,
@@ -140,46 +145,41 @@ func (w *ECommerceWorkload) insertVendorBatch(ctx context.Context, db *pgxpool.P
 }
 
 // loadUsers generates user data
-func (w *ECommerceWorkload) loadUsers(ctx context.Context, db *pgxpool.Pool, count int) error {
-	firstNames := []string{"Freya", "Sarah", "Michael", "Sofia", "Lucía", "Léa", "Júlia", "Paula", "Isabela", "Oliver", "Olivia", "Chloe", "William", "Arthur", "Zhi", "Yui", "Grace", "Ben", "Paul", "Emma", "Thomas", "Lucas", "Marie", "Haruto", "Linda", "Miguel", "Manuela", "Kim", "Alexander", "Ren", "Raphaël", "Jessica", "Leo", "Hugo", "Florence", "Isabella", "Riku", "Paul", "Susan", "Hina", "Yuna", "Michael", "Jade", "Carlos", "Clara", "Freddie", "Tommaso", "Riccardo", "Sakura", "Leo", "Anna", "Theo", "Guillaume", "Karen", "Maria", "Lukas", "Emily", "Davi", "Chloé", "Jules", "Paula", "Raphael", "Laura", "Juliette", "Maximilian", "Alina", "Zoe", "Felix", "Hannah", "Bernardo", "Ting", "Henry", "Chloe", "Ella", "Oscar", "Alice", "Isla", "Alice", "Noah", "Emma", "Gabriel", "Sophie", "Leo", "Shan", "Kiara", "Yuto", "Anna", "Thomas", "Alice", "Hannah", "Lucas", "Rin"}
-	lastNames := []string{"Nascimento", "Brown", "Tanaka", "Wright", "Campbell", "King", "Takahashi", "Bertrand", "Gao", "Fernández", "Bernard", "Liu", "Almeida", "Wright", "Jones", "Nguyen", "Schneider", "Martin", "Costa", "Suzuki", "López", "Becker", "Richter", "Rossi", "Mendes", "Leroy", "Silva", "Fontaine", "Romano", "Hernández", "Wolf", "Lopez", "Gomes", "Sanchez", "Schäfer", "Meier", "Dupont", "Russo", "Zimmermann", "González", "Miller", "Ribeiro", "Dubois", "Yamada", "Rodríguez", "Anderson", "Souza", "Brown", "Dubois", "Thomas", "Costa", "Araújo", "Müller", "Walker", "Rizzo", "Moreno", "Nguyen", "Fischer", "Alves", "Moreau", "Hayashi", "Lima", "Pérez", "Romero", "Mancini", "Lopez", "Almeida", "Alonso", "Yamada", "Gutiérrez", "Santos", "Guo", "Schwarz", "Morgan", "Marino", "Liu", "Nakamura", "De Luca", "Lee", "Muñoz", "Kimura", "Ribeiro", "Meyer", "Carvalho", "Yamaguchi", "Hoffmann", "Martínez", "Thomas", "Silva", "Dubois", "Hernández", "Schultz", "Costa", "Sato", "Cheng", "Romero", "Costa"}
-	countries := []string{"USA", "Canada", "UK", "Germany", "France", "Australia", "Japan", "Brazil", "India", "Mexico"}
-	cities := []string{"New York", "Los Angeles", "Chicago", "Houston", "Phoenix", "Philadelphia", "San Antonio", "San Diego", "Dallas", "Austin"}
+func (w *ECommerceWorkload) loadUsers(ctx context.Context, db *pgxpool.Pool, g *dataGenerator, count int) error {
 	genders := []string{"M", "F", "Other"}
+	domains := []string{"example.com", "example.net", "example.org", "mail.example.com"}
 
 	// Create progress tracker
 	progressTracker := progress.NewTracker("👥 Loading users", count)
 
 	batch := make([][]interface{}, 0, 100)
 	for i := 1; i <= count; i++ {
-		firstName := firstNames[rand.Intn(len(firstNames))]
-		lastName := lastNames[rand.Intn(len(lastNames))]
-		email := fmt.Sprintf("user%d@example.com", i)
-		username := fmt.Sprintf("%s%s%d", strings.ToLower(firstName), strings.ToLower(lastName), i)
+		firstName, lastName := g.person()
+		login := strings.ToLower(strings.ReplaceAll(firstName+"."+lastName, " ", ""))
+		email := fmt.Sprintf("%s%d@%s", login, i, g.pick(domains))
+		username := fmt.Sprintf("%s%d", strings.ReplaceAll(login, ".", ""), i)
 
 		// Birth date (18-80 years old)
-		birthYear := time.Now().Year() - (rand.Intn(62) + 18)
-		birthDate := time.Date(birthYear, time.Month(rand.Intn(12)+1), rand.Intn(28)+1, 0, 0, 0, 0, time.UTC)
+		birthYear := g.cfg.EndDate.Year() - (g.rng.Intn(62) + 18)
+		birthDate := time.Date(birthYear, time.Month(g.rng.Intn(12)+1), g.rng.Intn(28)+1, 0, 0, 0, 0, time.UTC)
 
-		gender := genders[rand.Intn(len(genders))]
-		country := countries[rand.Intn(len(countries))]
-		city := cities[rand.Intn(len(cities))]
-		postalCode := fmt.Sprintf("%05d", rand.Intn(99999))
-		phone := fmt.Sprintf("+1-%03d-%03d-%04d", rand.Intn(900)+100, rand.Intn(900)+100, rand.Intn(9000)+1000)
+		gender := g.pick(genders)
+		city := g.city()
+		postalCode := fmt.Sprintf("%05d", g.rng.Intn(99999))
+		phone := g.phone()
 
-		// Random last login within last 30 days
-		lastLogin := time.Now().AddDate(0, 0, -rand.Intn(30))
+		// Last login within last 30 days
+		lastLogin := g.timestamp(30)
 
-		loyaltyPoints := rand.Intn(10000)
-		totalSpent := float64(rand.Intn(5000))
+		loyaltyPoints := g.lengthBetween(500, 1.2, 0, 100000)
+		totalSpent := float64(g.lengthBetween(300, 1.3, 0, 100000))
 
-		preferences := fmt.Sprintf(`{"newsletter": %t, "sms_notifications": %t, "preferred_categories": ["%s", "%s"]}`,
-			rand.Float32() < 0.7, rand.Float32() < 0.3,
-			[]string{"Electronics", "Clothing", "Books", "Home"}[rand.Intn(4)],
-			[]string{"Sports", "Beauty", "Toys", "Auto"}[rand.Intn(4)])
+		preferences := fmt.Sprintf(`{"newsletter": %t, "sms_notifications": %t, "preferred_categories": [%q, %q]}`,
+			g.rng.Float32() < 0.7, g.rng.Float32() < 0.3,
+			g.corpus.category(g.rng), g.corpus.category(g.rng))
 
 		batch = append(batch, []interface{}{
-			email, username, firstName, lastName, birthDate, gender, country, city, postalCode, phone,
+			email, username, firstName, lastName, birthDate, gender, city.Country, city.City, postalCode, phone,
 			lastLogin, preferences, loyaltyPoints, totalSpent,
 		})
 
@@ -212,54 +212,47 @@ func (w *ECommerceWorkload) insertUserBatch(ctx context.Context, db *pgxpool.Poo
 }
 
 // loadProducts generates product data
-func (w *ECommerceWorkload) loadProducts(ctx context.Context, db *pgxpool.Pool, count int, vendorCount int) error {
-	fmt.Printf("📦 Loading %d products...\n", count)
-
-	categories := []string{"Electronics", "Clothing", "Books", "Home", "Sports", "Beauty", "Toys", "Auto"}
-	subcategories := map[string][]string{
-		"Electronics": {"Smartphones", "Laptops", "Tablets", "Cameras", "Audio"},
-		"Clothing":    {"Shirts", "Pants", "Dresses", "Shoes", "Accessories"},
-		"Books":       {"Fiction", "Non-Fiction", "Educational", "Children", "Comics"},
-		"Home":        {"Furniture", "Kitchen", "Bedding", "Decor", "Appliances"},
-		"Sports":      {"Fitness", "Outdoor", "Team Sports", "Water Sports", "Winter Sports"},
-		"Beauty":      {"Skincare", "Makeup", "Hair Care", "Fragrances", "Tools"},
-		"Toys":        {"Educational", "Action Figures", "Dolls", "Games", "Building"},
-		"Auto":        {"Parts", "Accessories", "Tools", "Fluids", "Electronics"},
-	}
+func (w *ECommerceWorkload) loadProducts(ctx context.Context, db *pgxpool.Pool, g *dataGenerator, count int, vendorCount int) error {
+	log.Printf("📦 Loading %d products...", count)
 
-	brands := []string{"BrandA", "BrandB", "BrandC", "BrandD", "BrandE", "Premium", "Value", "Elite", "Classic", "Modern"}
+	// Each brand ships through one vendor
+	brandVendors := make(map[string]int)
 
 	batch := make([][]interface{}, 0, 100)
 	for i := 1; i <= count; i++ {
 		sku := fmt.Sprintf("SKU-%06d", i)
-		category := categories[rand.Intn(len(categories))]
-		subcategory := subcategories[category][rand.Intn(len(subcategories[category]))]
-		brand := brands[rand.Intn(len(brands))]
-
-		name := fmt.Sprintf("%s %s %s #%d", brand, category, subcategory, i)
-		description := fmt.Sprintf("High-quality %s from %s. Perfect for your %s needs.", subcategory, brand, strings.ToLower(category))
+		p := g.product(i)
+		category, subcategory, brand := p.Category, p.Subcategory, p.Brand
+		name := p.Name
+		description := g.description(p)
 
-		cost := float64(rand.Intn(200) + 10)
-		margin := 60.0 + rand.Float64()*40.0 // 60-100% margin
-		price := cost * (1 + margin/100.0)
+		margin := 60.0 + g.rng.Float64()*40.0 // 60-100% margin
+		price := p.Price
+		cost := price / (1 + margin/100.0)
 
-		weight := rand.Float64() * 10.0 // 0-10 kg
+		weight := g.rng.Float64() * 10.0 // 0-10 kg
 
 		dimensions := fmt.Sprintf(`{"width": %.1f, "height": %.1f, "depth": %.1f}`,
-			rand.Float64()*50+5, rand.Float64()*50+5, rand.Float64()*30+5)
+			g.rng.Float64()*50+5, g.rng.Float64()*50+5, g.rng.Float64()*30+5)
 
-		tags := fmt.Sprintf(`{"%s", "%s", "%s"}`,
+		tags := fmt.Sprintf(`{%q, %q, %q}`,
 			strings.ToLower(category), strings.ToLower(brand), strings.ToLower(subcategory))
 
 		attributes := fmt.Sprintf(`{"color": "%s", "material": "%s", "warranty": "%d months"}`,
-			[]string{"Black", "White", "Red", "Blue", "Green"}[rand.Intn(5)],
-			[]string{"Plastic", "Metal", "Wood", "Fabric", "Glass"}[rand.Intn(5)],
-			[]int{6, 12, 24, 36}[rand.Intn(4)])
-
-		avgRating := 1.0 + rand.Float64()*4.0 // 1.0-5.0
-		reviewCount := rand.Intn(1000)
-		viewCount := rand.Intn(10000)
-		vendorID := rand.Intn(vendorCount) + 1
+			g.pick([]string{"Black", "White", "Red", "Blue", "Green"}),
+			g.pick([]string{"Plastic", "Metal", "Wood", "Fabric", "Glass"}),
+			[]int{6, 12, 24, 36}[g.rng.Intn(4)])
+
+		// Ratings cluster around 4 stars; views and reviews are long-tailed
+		avgRating := math.Max(1, math.Min(5, 4.2+0.5*g.rng.NormFloat64()))
+		viewCount := g.lengthBetween(200, 1.5, 0, 1000000)
+		reviewCount := viewCount / g.lengthBetween(40, 0.5, 5, 500)
+
+		vendorID, ok := brandVendors[brand]
+		if !ok {
+			vendorID = g.rng.Intn(vendorCount) + 1
+			brandVendors[brand] = vendorID
+		}
 
 		batch = append(batch, []interface{}{
 			sku, name, description, category, subcategory, brand, price, cost, margin,
@@ -294,29 +287,29 @@ func (w *ECommerceWorkload) insertProductBatch(ctx context.Context, db *pgxpool.
 }
 
 // loadInventory generates inventory data
-func (w *ECommerceWorkload) loadInventory(ctx context.Context, db *pgxpool.Pool, productCount int, vendorCount int) error {
-	fmt.Printf("📦 Loading inventory for %d products...\n", productCount)
+func (w *ECommerceWorkload) loadInventory(ctx context.Context, db *pgxpool.Pool, g *dataGenerator, productCount int, vendorCount int) error {
+	log.Printf("📦 Loading inventory for %d products...", productCount)
 
 	warehouses := []string{"Main Warehouse", "East Coast", "West Coast", "Central", "International"}
 
 	batch := make([][]interface{}, 0, 100)
 	for i := 1; i <= productCount; i++ {
-		warehouse := warehouses[rand.Intn(len(warehouses))]
-		quantityAvailable := rand.Intn(200) + 10 // 10-210
-		quantityReserved := rand.Intn(quantityAvailable / 4)
-		reorderLevel := rand.Intn(30) + 5                  // 5-35
-		maxStockLevel := reorderLevel * (rand.Intn(5) + 3) // 3-7x reorder level
+		warehouse := g.pick(warehouses)
+		quantityAvailable := g.rng.Intn(200) + 10 // 10-210
+		quantityReserved := g.rng.Intn(quantityAvailable / 4)
+		reorderLevel := g.rng.Intn(30) + 5                  // 5-35
+		maxStockLevel := reorderLevel * (g.rng.Intn(5) + 3) // 3-7x reorder level
 
 		// Some items recently restocked
 		var lastRestocked *time.Time
-		if rand.Float32() < 0.7 {
-			restock := time.Now().AddDate(0, 0, -rand.Intn(30))
+		if g.rng.Float32() < 0.7 {
+			restock := g.timestamp(30)
 			lastRestocked = &restock
 		}
 
-		supplierID := rand.Intn(vendorCount) + 1
-		unitCost := float64(rand.Intn(100) + 5)
-		autoReorder := rand.Float32() < 0.8 // 80% have auto-reorder enabled
+		supplierID := g.rng.Intn(vendorCount) + 1
+		unitCost := float64(g.rng.Intn(100) + 5)
+		autoReorder := g.rng.Float32() < 0.8 // 80% have auto-reorder enabled
 
 		batch = append(batch, []interface{}{
 			i, warehouse, quantityAvailable, quantityReserved, reorderLevel, maxStockLevel,
@@ -350,46 +343,76 @@ func (w *ECommerceWorkload) insertInventoryBatch(ctx context.Context, db *pgxpoo
 	return nil
 }
 
-// loadOrders generates order data
-func (w *ECommerceWorkload) loadOrders(ctx context.Context, db *pgxpool.Pool, count int, userCount int, productCount int) error {
-	fmt.Printf("🛒 Loading %d orders...\n", count)
+// orderItem is a generated order line
+type orderItem struct {
+	productID       int
+	quantity        int
+	unitPrice       float64
+	totalPrice      float64
+	discountApplied float64
+}
+
+// loadOrders generates order data. Orders per user follow a power law and
+// order timestamps follow the seasonal weights.
+func (w *ECommerceWorkload) loadOrders(ctx context.Context, db *pgxpool.Pool, g *dataGenerator, count int) error {
+	log.Printf("🛒 Loading %d orders...", count)
 
 	statuses := []string{"pending", "processing", "shipped", "delivered", "cancelled"}
 	paymentMethods := []string{"credit_card", "debit_card", "paypal", "apple_pay", "google_pay"}
 
 	batch := make([][]interface{}, 0, 50)
+	batchItems := make([][]orderItem, 0, 50)
 	for i := 1; i <= count; i++ {
-		userID := rand.Intn(userCount) + 1
-		orderNumber := fmt.Sprintf("ORD-%d-%06d", time.Now().Year(), i)
-		status := statuses[rand.Intn(len(statuses))]
-		paymentMethod := paymentMethods[rand.Intn(len(paymentMethods))]
+		userID := g.pickUser()
+		orderNumber := fmt.Sprintf("ORD-%d-%06d", g.cfg.EndDate.Year(), i)
+		paymentMethod := g.pick(paymentMethods)
+		createdAt := g.timestamp(g.cfg.HistoryDays)
+
+		// Recent orders are still in flight, older ones mostly delivered
+		age := g.cfg.EndDate.Sub(createdAt)
+		status := "delivered"
+		switch {
+		case g.rng.Float32() < 0.05:
+			status = "cancelled"
+		case age < 24*time.Hour:
+			status = g.pick(statuses[:2])
+		case age < 7*24*time.Hour:
+			status = g.pick(statuses[1:4])
+		}
 
-		// Order created within last 90 days
-		createdAt := time.Now().AddDate(0, 0, -rand.Intn(90))
+		items := g.orderItems()
+		subtotal := 0.0
+		for _, item := range items {
+			subtotal += item.totalPrice - item.discountApplied
+		}
 
 		// Calculate shipping and tax
-		subtotal := float64(rand.Intn(500) + 20)
-		shippingCost := 5.99 + rand.Float64()*10.0
+		shippingCost := 5.99 + g.rng.Float64()*10.0
+		if subtotal >= 50 {
+			shippingCost = 0 // Free shipping threshold
+		}
 		taxAmount := subtotal * 0.08 // 8% tax
 		discountAmount := 0.0
-		if rand.Float32() < 0.2 { // 20% chance of discount
-			discountAmount = subtotal * (0.05 + rand.Float64()*0.15) // 5-20% discount
+		if g.rng.Float32() < 0.2 { // 20% chance of discount
+			discountAmount = subtotal * (0.05 + g.rng.Float64()*0.15) // 5-20% discount
 		}
 		totalAmount := subtotal + shippingCost + taxAmount - discountAmount
 
 		// Addresses
-		shippingAddress := fmt.Sprintf(`{"street": "%d Main St", "city": "Sample City", "state": "ST", "postal_code": "%05d", "country": "USA"}`,
-			rand.Intn(9999)+1, rand.Intn(99999))
+		shippingAddress := g.address()
 		billingAddress := shippingAddress
+		if g.rng.Float32() < 0.1 { // Gifts ship elsewhere
+			billingAddress = g.address()
+		}
 
 		// Set shipped/delivered dates for completed orders
 		var shippedAt, deliveredAt *time.Time
 		if status == "shipped" || status == "delivered" {
-			shipped := createdAt.AddDate(0, 0, rand.Intn(5)+1)
+			shipped := createdAt.AddDate(0, 0, g.rng.Intn(5)+1)
 			shippedAt = &shipped
 		}
 		if status == "delivered" {
-			delivered := shippedAt.AddDate(0, 0, rand.Intn(7)+1)
+			delivered := shippedAt.AddDate(0, 0, g.rng.Intn(7)+1)
 			deliveredAt = &delivered
 		}
 
@@ -397,6 +420,7 @@ func (w *ECommerceWorkload) loadOrders(ctx context.Context, db *pgxpool.Pool, co
 			userID, orderNumber, status, totalAmount, shippingCost, taxAmount, discountAmount,
 			paymentMethod, shippingAddress, billingAddress, createdAt, shippedAt, deliveredAt,
 		})
+		batchItems = append(batchItems, items)
 
 		if len(batch) >= 50 || i == count {
 			orderIDs, err := w.insertOrderBatch(ctx, db, batch)
@@ -406,16 +430,13 @@ func (w *ECommerceWorkload) loadOrders(ctx context.Context, db *pgxpool.Pool, co
 
 			// Create order items for each order
 			for j, orderID := range orderIDs {
-				batchIndex := len(orderIDs) - len(batch) + j
-				if batchIndex >= 0 && batchIndex < len(batch) {
-					err := w.createOrderItems(ctx, db, orderID, productCount)
-					if err != nil {
-						return fmt.Errorf("failed to create order items: %w", err)
-					}
+				if err := w.createOrderItems(ctx, db, orderID, batchItems[j]); err != nil {
+					return fmt.Errorf("failed to create order items: %w", err)
 				}
 			}
 
 			batch = batch[:0]
+			batchItems = batchItems[:0]
 		}
 	}
 
@@ -441,24 +462,30 @@ func (w *ECommerceWorkload) insertOrderBatch(ctx context.Context, db *pgxpool.Po
 	return orderIDs, nil
 }
 
-// createOrderItems creates order items for an order
-func (w *ECommerceWorkload) createOrderItems(ctx context.Context, db *pgxpool.Pool, orderID int, productCount int) error {
-	numItems := rand.Intn(5) + 1 // 1-5 items per order
-
-	for i := 0; i < numItems; i++ {
-		productID := rand.Intn(productCount) + 1
-		quantity := rand.Intn(3) + 1 // 1-3 of each item
-		unitPrice := float64(rand.Intn(200) + 10)
+// orderItems generates the lines of an order, favouring popular products
+func (g *dataGenerator) orderItems() []orderItem {
+	items := make([]orderItem, g.lengthBetween(1.5, 0.6, 1, 10))
+	for i := range items {
+		productID := g.pickProduct()
+		quantity := g.lengthBetween(1, 0.5, 1, 10)
+		unitPrice := g.productByID(productID).Price
 		totalPrice := float64(quantity) * unitPrice
 		discountApplied := 0.0
-		if rand.Float32() < 0.1 { // 10% chance of item discount
-			discountApplied = totalPrice * (rand.Float64() * 0.2) // Up to 20% discount
+		if g.rng.Float32() < 0.1 { // 10% chance of item discount
+			discountApplied = totalPrice * (g.rng.Float64() * 0.2) // Up to 20% discount
 		}
+		items[i] = orderItem{productID, quantity, unitPrice, totalPrice, discountApplied}
+	}
+	return items
+}
 
+// createOrderItems inserts the items of an order
+func (w *ECommerceWorkload) createOrderItems(ctx context.Context, db *pgxpool.Pool, orderID int, items []orderItem) error {
+	for _, item := range items {
 		_, err := db.Exec(ctx, `
 			INSERT INTO order_items (order_id, product_id, quantity, unit_price, total_price, discount_applied)
 			VALUES ($1, $2, $3, $4, $5, $6)`,
-			orderID, productID, quantity, unitPrice, totalPrice, discountApplied)
+			orderID, item.productID, item.quantity, item.unitPrice, item.totalPrice, item.discountApplied)
 		if err != nil {
 			return err
 		}
@@ -467,40 +494,20 @@ func (w *ECommerceWorkload) createOrderItems(ctx context.Context, db *pgxpool.Po
 }
 
 // loadReviews generates review data
-func (w *ECommerceWorkload) loadReviews(ctx context.Context, db *pgxpool.Pool, count int, userCount int, productCount int) error {
-	fmt.Printf("⭐ Loading %d reviews...\n", count)
-
-	reviewTitles := []string{
-		"Great product!", "Excellent quality", "Not what I expected", "Amazing value",
-		"Perfect for my needs", "Could be better", "Outstanding service", "Highly recommend",
-		"Disappointed", "Exceeded expectations", "Good purchase", "Will buy again",
-	}
-
-	reviewContents := []string{
-		"This product exceeded my expectations. The quality is outstanding and it arrived quickly.",
-		"I'm very happy with this purchase. Great value for money and exactly as described.",
-		"The product is okay but not as good as I hoped. The quality could be better.",
-		"Fantastic product! Works perfectly and the customer service was excellent.",
-		"I love this item. It's exactly what I was looking for and the price was great.",
-		"Not impressed. The product doesn't match the description and quality is poor.",
-		"Excellent quality and fast shipping. Would definitely recommend to others.",
-		"The product is good but arrived damaged. Customer service was helpful though.",
-		"Perfect! This is exactly what I needed and it works flawlessly.",
-		"Average product. Nothing special but it does the job adequately.",
-	}
+func (w *ECommerceWorkload) loadReviews(ctx context.Context, db *pgxpool.Pool, g *dataGenerator, count int) error {
+	log.Printf("⭐ Loading %d reviews...", count)
 
 	batch := make([][]interface{}, 0, 100)
 	for i := 1; i <= count; i++ {
-		userID := rand.Intn(userCount) + 1
-		productID := rand.Intn(productCount) + 1
-		rating := rand.Intn(5) + 1
-		title := reviewTitles[rand.Intn(len(reviewTitles))]
-		content := reviewContents[rand.Intn(len(reviewContents))]
+		userID := g.pickUser()
+		productID := g.pickProduct()
+		rating := g.rating()
+		title, content := g.review(g.productByID(productID), rating)
 
 		// Generate random vector for content (1536 dimensions for OpenAI embeddings)
 		vector := make([]float32, 1536)
 		for j := range vector {
-			vector[j] = rand.Float32()*2 - 1 // Random values between -1 and 1
+			vector[j] = g.rng.Float32()*2 - 1 // Random values between -1 and 1
 		}
 
 		vectorStr := "["
@@ -512,12 +519,12 @@ func (w *ECommerceWorkload) loadReviews(ctx context.Context, db *pgxpool.Pool, c
 		}
 		vectorStr += "]"
 
-		helpfulVotes := rand.Intn(50)
-		totalVotes := helpfulVotes + rand.Intn(20)
-		isVerifiedPurchase := rand.Float32() < 0.8 // 80% are verified purchases
+		totalVotes := g.lengthBetween(3, 1.2, 0, 5000)
+		helpfulVotes := int(float64(totalVotes) * g.rng.Float64())
+		isVerifiedPurchase := g.rng.Float32() < 0.8 // 80% are verified purchases
 
 		// Review created within last 180 days
-		createdAt := time.Now().AddDate(0, 0, -rand.Intn(180))
+		createdAt := g.timestamp(180)
 
 		// Get a real order ID for verified purchases
 		var orderID *int
@@ -528,7 +535,7 @@ func (w *ECommerceWorkload) loadReviews(ctx context.Context, db *pgxpool.Pool, c
 				FROM orders o 
 				JOIN order_items oi ON o.order_id = oi.order_id 
 				WHERE o.user_id = $1 AND oi.product_id = $2 
-				ORDER BY RANDOM() 
+				ORDER BY o.order_id 
 				LIMIT 1`, userID, productID).Scan(&oid)
 			if err == nil {
 				orderID = &oid
@@ -575,8 +582,8 @@ func (w *ECommerceWorkload) insertReviewBatch(ctx context.Context, db *pgxpool.P
 }
 
 // loadUserSessions generates user session data
-func (w *ECommerceWorkload) loadUserSessions(ctx context.Context, db *pgxpool.Pool, count int, userCount int) error {
-	fmt.Printf("🔗 Loading %d user sessions...\n", count)
+func (w *ECommerceWorkload) loadUserSessions(ctx context.Context, db *pgxpool.Pool, g *dataGenerator, count int) error {
+	log.Printf("🔗 Loading %d user sessions...", count)
 
 	deviceTypes := []string{"Desktop", "Mobile", "Tablet"}
 	browsers := []string{"Chrome", "Firefox", "Safari", "Edge", "Opera"}
@@ -584,24 +591,24 @@ func (w *ECommerceWorkload) loadUserSessions(ctx context.Context, db *pgxpool.Po
 
 	batch := make([][]interface{}, 0, 100)
 	for i := 1; i <= count; i++ {
-		userID := rand.Intn(userCount) + 1
+		userID := g.pickUser()
 		sessionID := fmt.Sprintf("sess_%d_%d", userID, i)
-		deviceType := deviceTypes[rand.Intn(len(deviceTypes))]
-		browser := browsers[rand.Intn(len(browsers))]
-		os := operatingSystems[rand.Intn(len(operatingSystems))]
+		deviceType := g.pick(deviceTypes)
+		browser := g.pick(browsers)
+		os := g.pick(operatingSystems)
 
 		// Random IP address
 		ipAddress := fmt.Sprintf("%d.%d.%d.%d",
-			rand.Intn(255)+1, rand.Intn(255), rand.Intn(255), rand.Intn(255))
+			g.rng.Intn(255)+1, g.rng.Intn(255), g.rng.Intn(255), g.rng.Intn(255))
 
 		// Session started within last 30 days
-		startedAt := time.Now().AddDate(0, 0, -rand.Intn(30))
+		startedAt := g.timestamp(30)
 
 		// 70% of sessions have ended
 		var endedAt *time.Time
 		var durationSeconds *int
-		if rand.Float32() < 0.7 {
-			duration := rand.Intn(3600) + 60 // 1 minute to 1 hour
+		if g.rng.Float32() < 0.7 {
+			duration := g.lengthBetween(420, 1.0, 10, 4*3600) // Median 7 minutes, long tail
 			ended := startedAt.Add(time.Duration(duration) * time.Second)
 			endedAt = &ended
 			durationSeconds = &duration
@@ -640,29 +647,27 @@ func (w *ECommerceWorkload) insertUserSessionBatch(ctx context.Context, db *pgxp
 }
 
 // loadProductAnalytics generates product analytics data
-func (w *ECommerceWorkload) loadProductAnalytics(ctx context.Context, db *pgxpool.Pool, count int, userCount int, productCount int) error {
-	fmt.Printf("📊 Loading %d product analytics events...\n", count)
+func (w *ECommerceWorkload) loadProductAnalytics(ctx context.Context, db *pgxpool.Pool, g *dataGenerator, count int) error {
+	log.Printf("📊 Loading %d product analytics events...", count)
 
 	eventTypes := []string{"view", "add_to_cart", "purchase", "wishlist", "search"}
-	searchQueries := []string{
-		"laptop", "phone", "book", "shirt", "shoes", "watch", "camera", "tablet",
-		"headphones", "speaker", "keyboard", "mouse", "monitor", "desk", "chair",
-	}
-
 	batch := make([][]interface{}, 0, 100)
 	for i := 1; i <= count; i++ {
-		userID := rand.Intn(userCount) + 1
-		productID := rand.Intn(productCount) + 1
-		eventType := eventTypes[rand.Intn(len(eventTypes))]
+		userID := g.pickUser()
+		productID := g.pickProduct()
+		eventType := g.pick(eventTypes)
 
 		var searchQuery *string
 		if eventType == "search" {
-			query := searchQueries[rand.Intn(len(searchQueries))]
+			query := g.corpus.searchTerm(g.rng)
+			if g.rng.Float32() < 0.3 { // Some searches are two words
+				query += " " + g.corpus.searchTerm(g.rng)
+			}
 			searchQuery = &query
 		}
 
 		// Event within last 60 days
-		createdAt := time.Now().AddDate(0, 0, -rand.Intn(60))
+		createdAt := g.timestamp(60)
 
 		batch = append(batch, []interface{}{
 			productID, userID, eventType, searchQuery, createdAt,
@@ -696,32 +701,32 @@ func (w *ECommerceWorkload) insertProductAnalyticsBatch(ctx context.Context, db
 }
 
 // loadInitialPurchaseOrders creates some initial purchase orders
-func (w *ECommerceWorkload) loadInitialPurchaseOrders(ctx context.Context, db *pgxpool.Pool, vendorCount int, productCount int) error {
-	fmt.Printf("📋 Loading initial purchase orders...\n")
+func (w *ECommerceWorkload) loadInitialPurchaseOrders(ctx context.Context, db *pgxpool.Pool, g *dataGenerator, vendorCount int, productCount int) error {
+	log.Printf("📋 Loading initial purchase orders...")
 
 	// Create 10-20 purchase orders
-	orderCount := rand.Intn(11) + 10
+	orderCount := g.rng.Intn(11) + 10
 
 	for i := 1; i <= orderCount; i++ {
-		vendorID := rand.Intn(vendorCount) + 1
-		poNumber := fmt.Sprintf("PO-%d-%06d", time.Now().Year(), i)
+		vendorID := g.rng.Intn(vendorCount) + 1
+		poNumber := fmt.Sprintf("PO-%d-%06d", g.cfg.EndDate.Year(), i)
 
 		statuses := []string{"pending", "sent", "received"}
-		status := statuses[rand.Intn(len(statuses))]
+		status := g.pick(statuses)
 
 		// Order created within last 30 days
-		createdAt := time.Now().AddDate(0, 0, -rand.Intn(30))
-		expectedDelivery := createdAt.AddDate(0, 0, rand.Intn(14)+3) // 3-17 days later
+		createdAt := g.timestamp(30)
+		expectedDelivery := createdAt.AddDate(0, 0, g.rng.Intn(14)+3) // 3-17 days later
 
 		var actualDelivery *time.Time
 		if status == "received" {
-			delivered := expectedDelivery.AddDate(0, 0, rand.Intn(5)-2) // -2 to +3 days from expected
+			delivered := expectedDelivery.AddDate(0, 0, g.rng.Intn(5)-2) // -2 to +3 days from expected
 			actualDelivery = &delivered
 		}
 
-		totalAmount := float64(rand.Intn(5000) + 500)
+		totalAmount := float64(g.rng.Intn(5000) + 500)
 		taxAmount := totalAmount * 0.08
-		shippingCost := 50.0 + rand.Float64()*200.0
+		shippingCost := 50.0 + g.rng.Float64()*200.0
 
 		// Create purchase order
 		var poID int
@@ -737,17 +742,17 @@ func (w *ECommerceWorkload) loadInitialPurchaseOrders(ctx context.Context, db *p
 		}
 
 		// Add purchase order items
-		numItems := rand.Intn(5) + 2 // 2-6 items per PO
+		numItems := g.rng.Intn(5) + 2 // 2-6 items per PO
 		for j := 0; j < numItems; j++ {
-			productID := rand.Intn(productCount) + 1
-			quantityOrdered := rand.Intn(100) + 10
-			unitCost := float64(rand.Intn(200) + 5)
+			productID := g.rng.Intn(productCount) + 1
+			quantityOrdered := g.rng.Intn(100) + 10
+			unitCost := float64(g.rng.Intn(200) + 5)
 			totalCost := float64(quantityOrdered) * unitCost
 
 			var quantityReceived int
 			var receivedAt *time.Time
 			if status == "received" {
-				quantityReceived = quantityOrdered - rand.Intn(3) // Maybe some shortfall
+				quantityReceived = quantityOrdered - g.rng.Intn(3) // Maybe some shortfall
 				if actualDelivery != nil {
 					receivedAt = actualDelivery
 				}
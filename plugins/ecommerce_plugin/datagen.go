@@ -0,0 +1,278 @@
+// internal/workload/ecommerce/datagen.go
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Seasonal weights of order timestamps. Shopping peaks in the evening, at
+// weekends and towards the end of the year.
+var (
+	hourWeights = [24]float64{
+		0.3, 0.2, 0.1, 0.1, 0.1, 0.2, 0.4, 0.6, 0.8, 1.0, 1.1, 1.2,
+		1.3, 1.2, 1.1, 1.1, 1.2, 1.4, 1.6, 1.8, 2.0, 1.9, 1.4, 0.8,
+	}
+	weekdayWeights = [7]float64{1.3, 0.9, 0.9, 0.9, 1.0, 1.1, 1.4} // Sunday first
+	monthWeights   = [12]float64{0.8, 0.7, 0.9, 0.9, 1.0, 1.0, 1.0, 1.0, 0.9, 1.0, 1.4, 1.8}
+)
+
+// holidayWeight boosts the week of Black Friday and Cyber Monday
+const holidayWeight = 2.0
+
+// variants are appended to product names once every catalog entry is used
+var variants = []string{"Black", "White", "Red", "Blue", "Green", "Grey", "Small", "Large", "XL", "Travel Edition", "Bundle", "2-Pack"}
+
+// dataGenerator produces the sample data from a corpus with a single seeded
+// source, so a given seed, scale and corpus always load the same data
+type dataGenerator struct {
+	cfg    DataGenConfig
+	corpus *corpus
+	rng    *rand.Rand
+
+	adjectives *rand.Zipf
+	nouns      *rand.Zipf
+
+	products  []productEntry // Generated products by product_id-1
+	maxSeason float64
+
+	pickUser    func() int // Buyers and reviewers, power-law skewed
+	pickProduct func() int // Products by popularity
+}
+
+// newDataGenerator creates a generator seeded from cfg for the given
+// numbers of users and products
+func newDataGenerator(cfg DataGenConfig, c *corpus, userCount, productCount int) *dataGenerator {
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	g := &dataGenerator{
+		cfg:        cfg,
+		corpus:     c,
+		rng:        rng,
+		adjectives: rand.NewZipf(rng, cfg.WordSkew, 1, uint64(len(c.adjectives)-1)),
+		nouns:      rand.NewZipf(rng, cfg.WordSkew, 1, uint64(len(c.nouns)-1)),
+	}
+	g.maxSeason = maxOf(hourWeights[:]) * maxOf(weekdayWeights[:]) * maxOf(monthWeights[:]) * holidayWeight
+	g.pickUser = g.skewedPicker(userCount, cfg.OrderSkew)
+	g.pickProduct = g.skewedPicker(productCount, cfg.ProductSkew)
+	return g
+}
+
+// maxOf returns the largest of weights
+func maxOf(weights []float64) float64 {
+	m := 0.0
+	for _, w := range weights {
+		m = math.Max(m, w)
+	}
+	return m
+}
+
+// pick returns a uniformly chosen element of list
+func (g *dataGenerator) pick(list []string) string {
+	return list[g.rng.Intn(len(list))]
+}
+
+// adjective returns an adjective, favouring the frequent ones
+func (g *dataGenerator) adjective() string {
+	return g.corpus.adjectives[g.adjectives.Uint64()]
+}
+
+// noun returns a product aspect, favouring the frequent ones
+func (g *dataGenerator) noun() string {
+	return g.corpus.nouns[g.nouns.Uint64()]
+}
+
+// lengthBetween draws a log-normal length with the given median, clamped to [min, max]
+func (g *dataGenerator) lengthBetween(median, sigma float64, min, max int) int {
+	n := int(math.Round(median * math.Exp(sigma*g.rng.NormFloat64())))
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// skewedPicker returns a function choosing ids in [1, n] with a Zipf
+// distribution. Ids are shuffled so the popular ones are spread over the range.
+func (g *dataGenerator) skewedPicker(n int, skew float64) func() int {
+	if n <= 1 {
+		return func() int { return 1 }
+	}
+	perm := g.rng.Perm(n)
+	zipf := rand.NewZipf(g.rng, skew, 1, uint64(n-1))
+	return func() int {
+		return perm[zipf.Uint64()] + 1
+	}
+}
+
+// seasonWeight returns the relative likelihood of an order at t
+func seasonWeight(t time.Time) float64 {
+	w := hourWeights[t.Hour()] * weekdayWeights[t.Weekday()] * monthWeights[t.Month()-1]
+	if t.Month() == time.November && t.Day() >= 23 {
+		w *= holidayWeight
+	}
+	return w
+}
+
+// timestamp returns a time within the last days before the end date,
+// following the seasonal weights when seasonality is enabled
+func (g *dataGenerator) timestamp(days int) time.Time {
+	span := int64(days) * int64(24*time.Hour)
+	for {
+		t := g.cfg.EndDate.Add(-time.Duration(g.rng.Int63n(span)))
+		if !g.cfg.Seasonality || g.rng.Float64()*g.maxSeason < seasonWeight(t) {
+			return t
+		}
+	}
+}
+
+// person returns a first and last name
+func (g *dataGenerator) person() (string, string) {
+	return g.pick(g.corpus.firstNames), g.pick(g.corpus.lastNames)
+}
+
+// city returns a city from the corpus
+func (g *dataGenerator) city() cityEntry {
+	return g.corpus.cities[g.rng.Intn(len(g.corpus.cities))]
+}
+
+// street returns a street address line
+func (g *dataGenerator) street() string {
+	return fmt.Sprintf("%d %s", g.lengthBetween(120, 1.2, 1, 9999), g.pick(g.corpus.streets))
+}
+
+// address returns a JSON address
+func (g *dataGenerator) address() string {
+	c := g.city()
+	return fmt.Sprintf(`{"street": %q, "city": %q, "state": %q, "postal_code": "%05d", "country": %q}`,
+		g.street(), c.City, c.State, g.rng.Intn(99999), c.Country)
+}
+
+// phone returns a phone number
+func (g *dataGenerator) phone() string {
+	return fmt.Sprintf("+1-%03d-%03d-%04d", g.rng.Intn(900)+100, g.rng.Intn(900)+100, g.rng.Intn(9000)+1000)
+}
+
+// product returns the catalog entry for product i (1-based). The catalog is
+// used in order, then repeated with variant names and jittered prices.
+func (g *dataGenerator) product(i int) productEntry {
+	catalog := g.corpus.products
+	p := catalog[(i-1)%len(catalog)]
+	if i > len(catalog) {
+		p.Name = fmt.Sprintf("%s, %s", p.Name, g.pick(variants))
+	}
+
+	if p.Price == 0 {
+		p.Price = 35
+	}
+	p.Price = math.Round(p.Price*math.Exp(0.15*g.rng.NormFloat64())*100) / 100
+	if p.Price < 0.99 {
+		p.Price = 0.99
+	}
+
+	g.products = append(g.products, p)
+	return p
+}
+
+// productByID returns a generated product
+func (g *dataGenerator) productByID(id int) productEntry {
+	return g.products[id-1]
+}
+
+// description returns the catalog description followed by a log-normal
+// number of generated feature sentences such as "Sturdy, light and quiet handle."
+func (g *dataGenerator) description(p productEntry) string {
+	var b strings.Builder
+	b.WriteString(p.Description)
+	for n := g.lengthBetween(2, 0.7, 0, 10); n > 0; n-- {
+		var features []string
+		seen := make(map[string]bool)
+		for i := g.lengthBetween(2, 0.4, 1, 4); i > 0; i-- {
+			if adj := g.adjective(); !seen[adj] {
+				seen[adj] = true
+				features = append(features, adj)
+			}
+		}
+		list := features[0]
+		if last := len(features) - 1; last > 0 {
+			list = strings.Join(features[:last], ", ") + " and " + features[last]
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s %s.", capitalize(list), g.noun())
+	}
+	return b.String()
+}
+
+// rating returns a review rating with the J-shaped distribution of real
+// stores: mostly five stars, few middling ones
+func (g *dataGenerator) rating() int {
+	r := g.rng.Float64()
+	switch {
+	case r < 0.10:
+		return 1
+	case r < 0.15:
+		return 2
+	case r < 0.25:
+		return 3
+	case r < 0.50:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// review returns a title and text matching the rating of a review of p
+func (g *dataGenerator) review(p productEntry, rating int) (string, string) {
+	sentiment := sentimentNeutral
+	switch {
+	case rating >= 4:
+		sentiment = sentimentPositive
+	case rating <= 2:
+		sentiment = sentimentNegative
+	}
+	t := g.corpus.reviews[sentiment]
+
+	title := g.fill(g.pick(t.titles), p)
+	// Distinct sentences, as many as the log-normal length allows
+	order := g.rng.Perm(len(t.sentences))
+	sentences := make([]string, g.lengthBetween(3, 0.6, 1, len(order)))
+	for i := range sentences {
+		sentences[i] = g.fill(t.sentences[order[i]], p)
+	}
+	return title, strings.Join(sentences, " ")
+}
+
+// fill replaces the placeholders of a review template
+func (g *dataGenerator) fill(template string, p productEntry) string {
+	r := strings.NewReplacer(
+		"{product}", p.Name,
+		"{brand}", p.Brand,
+		"{category}", strings.ToLower(p.Category),
+		"{subcategory}", strings.ToLower(p.Subcategory),
+	)
+	s := r.Replace(template)
+	for strings.Contains(s, "{adjective}") {
+		s = strings.Replace(s, "{adjective}", g.adjective(), 1)
+	}
+	for strings.Contains(s, "{noun}") {
+		s = strings.Replace(s, "{noun}", g.noun(), 1)
+	}
+	return capitalize(s)
+}
+
+// capitalize upper-cases the first letter of s
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -10,15 +10,54 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/elchinoo/stormdb/pkg/keydist"
+	"github.com/elchinoo/stormdb/pkg/plugin"
 	"github.com/elchinoo/stormdb/pkg/types"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/viper"
 )
 
 // ECommerceWorkload simulates a modern e-commerce platform with advanced features
 // This includes: users, products, orders, inventory, reviews with vectors, vendor management, and automated stock control
 type ECommerceWorkload struct {
 	Mode string
+
+	// readPools routes read-only operations to replicas when configured
+	readPools plugin.ReadPoolSelector
+
+	// keys picks user, product, order and vendor ids using the key_distribution option
+	keys *keydist.Chooser
+
+	// corpus supplies the word lists for data generation and search terms
+	corpus *corpus
+}
+
+// SetReadPoolSelector enables routing of read-only operations to replicas
+func (w *ECommerceWorkload) SetReadPoolSelector(selector plugin.ReadPoolSelector) {
+	w.readPools = selector
+}
+
+// readPool returns the pool for the next read-only operation, falling back
+// to the primary when no replicas are configured
+func (w *ECommerceWorkload) readPool(db *pgxpool.Pool) *pgxpool.Pool {
+	if w.readPools == nil {
+		return db
+	}
+	return w.readPools.ReadPool()
+}
+
+// loadCorpus reads the corpus configured in workload_config.data_generation
+func (w *ECommerceWorkload) loadCorpus() error {
+	if w.corpus != nil {
+		return nil
+	}
+	c, err := loadCorpus(viper.GetString("workload_config.data_generation.corpus_dir"))
+	if err != nil {
+		return fmt.Errorf("failed to load corpus: %w", err)
+	}
+	w.corpus = c
+	return nil
 }
 
 // GetName returns the workload name
@@ -30,6 +69,10 @@ func (w *ECommerceWorkload) GetName() string {
 func (w *ECommerceWorkload) Setup(ctx context.Context, db *pgxpool.Pool, cfg *types.Config) error {
 	log.Printf("🛒 Setting up E-Commerce workload...")
 
+	if err := w.loadCorpus(); err != nil {
+		return err
+	}
+
 	// Check if schema already exists
 	var tableCount int
 	err := db.QueryRow(ctx, `
@@ -552,6 +595,16 @@ func (w *ECommerceWorkload) Cleanup(ctx context.Context, db *pgxpool.Pool, cfg *
 func (w *ECommerceWorkload) Run(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics) error {
 	log.Printf("🛒 Starting E-Commerce %s workload...", w.Mode)
 
+	keys, err := keydist.FromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid key distribution: %w", err)
+	}
+	w.keys = keys
+
+	if err := w.loadCorpus(); err != nil {
+		return err
+	}
+
 	// Initialize per-worker metrics tracking
 	metrics.InitializeWorkerMetrics(cfg.Workers)
 
@@ -581,47 +634,56 @@ func (w *ECommerceWorkload) worker(ctx context.Context, db *pgxpool.Pool, cfg *t
 		default:
 			opStart := time.Now()
 			var err error
-			var operation string
+			var kind, operation string
+			var rows int64
 
 			switch w.Mode {
 			case "read":
-				operation, err = w.executeReadOperation(ctx, db, rng)
-				atomic.AddInt64(&metrics.RowsRead, 1)
+				kind = "read"
+				operation, err = w.executeReadOperation(ctx, w.readPool(db), rng)
 			case "write":
-				operation = "write"
-				err = w.executeWriteOperation(ctx, db, rng)
-				atomic.AddInt64(&metrics.RowsModified, 1)
+				kind = "write"
+				operation, err = w.executeWriteOperation(ctx, db, rng)
 			case "mixed":
 				if rng.Intn(100) < 75 { // 75% reads, 25% writes
-					operation, err = w.executeReadOperation(ctx, db, rng)
-					atomic.AddInt64(&metrics.RowsRead, 1)
+					kind = "read"
+					operation, err = w.executeReadOperation(ctx, w.readPool(db), rng)
 				} else {
-					operation = "write"
-					err = w.executeWriteOperation(ctx, db, rng)
-					atomic.AddInt64(&metrics.RowsModified, 1)
+					kind = "write"
+					operation, err = w.executeWriteOperation(ctx, db, rng)
 				}
 			case "oltp":
 				// OLTP workload: frequent small transactions
 				if rng.Intn(100) < 60 { // 60% reads
-					operation = "oltp_read"
-					err = w.executeOLTPReadOperation(ctx, db, rng)
-					atomic.AddInt64(&metrics.RowsRead, 1)
+					kind = "oltp_read"
+					operation, err = w.executeOLTPReadOperation(ctx, w.readPool(db), rng)
 				} else { // 40% writes
-					operation = "oltp_write"
-					err = w.executeOLTPWriteOperation(ctx, db, rng)
-					atomic.AddInt64(&metrics.RowsModified, 1)
+					kind = "oltp_write"
+					operation, err = w.executeOLTPWriteOperation(ctx, db, rng)
 				}
 			case "analytics":
 				// Analytics workload: complex analytical queries
-				operation = "analytics"
-				err = w.executeAnalyticsOperation(ctx, db, rng)
-				atomic.AddInt64(&metrics.RowsRead, 10) // Analytics typically read many rows
+				kind = "analytics"
+				operation, err = w.executeAnalyticsOperation(ctx, w.readPool(db), rng)
+			default:
+				kind = "read"
+				operation, err = w.executeReadOperation(ctx, w.readPool(db), rng)
+			}
+
+			switch kind {
+			case "write", "oltp_write":
+				rows = 1
+				atomic.AddInt64(&metrics.RowsModified, rows)
+			case "analytics":
+				rows = 10 // Analytics typically read many rows
+				atomic.AddInt64(&metrics.RowsRead, rows)
 			default:
-				operation, err = w.executeReadOperation(ctx, db, rng)
-				atomic.AddInt64(&metrics.RowsRead, 1)
+				rows = 1
+				atomic.AddInt64(&metrics.RowsRead, rows)
 			}
 
 			elapsed := time.Since(opStart).Nanoseconds()
+			metrics.RecordOperation(operation, elapsed, rows, err == nil)
 
 			if err != nil {
 				atomic.AddInt64(&metrics.Errors, 1)
@@ -637,7 +699,7 @@ func (w *ECommerceWorkload) worker(ctx context.Context, db *pgxpool.Pool, cfg *t
 				metrics.RecordWorkerTransaction(workerID, true, elapsed)
 
 				// For now, estimate queries per transaction based on operation type
-				switch operation {
+				switch kind {
 				case "write", "oltp_write":
 					// Typical write operations have INSERT + SELECT
 					metrics.RecordWorkerQuery(workerID, "INSERT")
@@ -653,7 +715,7 @@ func (w *ECommerceWorkload) worker(ctx context.Context, db *pgxpool.Pool, cfg *t
 				}
 
 				// Add some variation for mixed operations
-				if w.Mode == "mixed" && operation == "write" {
+				if w.Mode == "mixed" && kind == "write" {
 					metrics.RecordWorkerQuery(workerID, "UPDATE") // Mixed write might include updates
 				}
 			}
@@ -7,98 +7,61 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/elchinoo/stormdb/pkg/types"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// namedOperation is a workload operation with the name its metrics are
+// recorded under
+type namedOperation struct {
+	name string
+	run  func(context.Context, *pgxpool.Pool, *rand.Rand) error
+}
+
 // READ OPERATIONS - Mix of indexed and non-indexed queries
 
 // executeReadOperation performs various read operations
 func (w *ECommerceWorkload) executeReadOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) (string, error) {
-	operations := []func(context.Context, *pgxpool.Pool, *rand.Rand) error{
+	operations := []namedOperation{
 		// Simple indexed queries (40% of reads)
-		w.getUserByEmail,            // Uses unique index on email
-		w.getProductBySKU,           // Uses unique index on SKU
-		w.getProductsByCategory,     // Uses index on category
-		w.getUserOrders,             // Uses index on user_id
-		w.getProductReviews,         // Uses index on product_id
-		w.getVendorProducts,         // Uses index on vendor_id
-		w.getPurchaseOrdersByVendor, // Uses index on vendor_id
+		{"getUserByEmail", w.getUserByEmail},                       // Uses unique index on email
+		{"getProductBySKU", w.getProductBySKU},                     // Uses unique index on SKU
+		{"getProductsByCategory", w.getProductsByCategory},         // Uses index on category
+		{"getUserOrders", w.getUserOrders},                         // Uses index on user_id
+		{"getProductReviews", w.getProductReviews},                 // Uses index on product_id
+		{"getVendorProducts", w.getVendorProducts},                 // Uses index on vendor_id
+		{"getPurchaseOrdersByVendor", w.getPurchaseOrdersByVendor}, // Uses index on vendor_id
 
 		// Complex joins (30% of reads)
-		w.getOrderDetailsWithItems, // Multi-table join with indexes
-		w.getUserActivitySummary,   // Complex join across multiple tables
-		w.getProductAnalytics,      // Join with analytics data
-		w.getInventoryStatus,       // Join inventory with products and vendors
-		w.getVendorPerformance,     // Complex vendor analysis
+		{"getOrderDetailsWithItems", w.getOrderDetailsWithItems}, // Multi-table join with indexes
+		{"getUserActivitySummary", w.getUserActivitySummary},     // Complex join across multiple tables
+		{"getProductAnalytics", w.getProductAnalytics},           // Join with analytics data
+		{"getInventoryStatus", w.getInventoryStatus},             // Join inventory with products and vendors
+		{"getVendorPerformance", w.getVendorPerformance},         // Complex vendor analysis
 
 		// Full table scans / non-indexed queries (20% of reads)
-		w.searchProductsByName,  // Full-text search
-		w.findSimilarUsers,      // Complex query without good indexes
-		w.getRecentActivity,     // Date range query possibly without index
-		w.searchReviewsByVector, // Vector similarity search using pgvector
+		{"searchProductsByName", w.searchProductsByName},   // Full-text search
+		{"findSimilarUsers", w.findSimilarUsers},           // Complex query without good indexes
+		{"getRecentActivity", w.getRecentActivity},         // Date range query possibly without index
+		{"searchReviewsByVector", w.searchReviewsByVector}, // Vector similarity search using pgvector
 
 		// Window functions and CTEs (10% of reads)
-		w.getTopProductsByCategory, // Window functions for ranking
-		w.getUserSpendingTrends,    // CTE with window functions
-		w.getInventoryAnalysis,     // Complex CTE analysis
-		w.getStockControlReport,    // Stock control analytics
+		{"getTopProductsByCategory", w.getTopProductsByCategory}, // Window functions for ranking
+		{"getUserSpendingTrends", w.getUserSpendingTrends},       // CTE with window functions
+		{"getInventoryAnalysis", w.getInventoryAnalysis},         // Complex CTE analysis
+		{"getStockControlReport", w.getStockControlReport},       // Stock control analytics
 	}
 
 	op := operations[rng.Intn(len(operations))]
-	opName := ""
-
-	switch rng.Intn(20) {
-	case 0:
-		opName = "get_user_by_email"
-	case 1:
-		opName = "get_product_by_sku"
-	case 2:
-		opName = "get_products_by_category"
-	case 3:
-		opName = "get_user_orders"
-	case 4:
-		opName = "get_product_reviews"
-	case 5:
-		opName = "get_vendor_products"
-	case 6:
-		opName = "get_purchase_orders_by_vendor"
-	case 7:
-		opName = "get_order_details_with_items"
-	case 8:
-		opName = "get_user_activity_summary"
-	case 9:
-		opName = "get_product_analytics"
-	case 10:
-		opName = "get_inventory_status"
-	case 11:
-		opName = "get_vendor_performance"
-	case 12:
-		opName = "search_products_by_name"
-	case 13:
-		opName = "find_similar_users"
-	case 14:
-		opName = "get_recent_activity"
-	case 15:
-		opName = "search_reviews_by_vector"
-	case 16:
-		opName = "get_top_products_by_category"
-	case 17:
-		opName = "get_user_spending_trends"
-	case 18:
-		opName = "get_inventory_analysis"
-	case 19:
-		opName = "get_stock_control_report"
-	}
-
-	return opName, op(ctx, db, rng)
+	return op.name, op.run(types.WithOperation(ctx, op.name), db, rng)
 }
 
 // Simple indexed queries
 
 // getUserByEmail retrieves user by email (uses unique index)
 func (w *ECommerceWorkload) getUserByEmail(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 	email := fmt.Sprintf("user%d@example.com", userID)
 
 	var firstName, lastName, country string
@@ -118,7 +81,7 @@ func (w *ECommerceWorkload) getUserByEmail(ctx context.Context, db *pgxpool.Pool
 
 // getProductBySKU retrieves product by SKU (uses unique index)
 func (w *ECommerceWorkload) getProductBySKU(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	productID := rng.Intn(500) + 1
+	productID := w.keys.Intn(rng, 500) + 1
 	sku := fmt.Sprintf("SKU-%06d", productID)
 
 	var name, category, brand string
@@ -138,8 +101,7 @@ func (w *ECommerceWorkload) getProductBySKU(ctx context.Context, db *pgxpool.Poo
 
 // getProductsByCategory retrieves products by category (uses index)
 func (w *ECommerceWorkload) getProductsByCategory(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	categories := []string{"Electronics", "Clothing", "Books", "Home", "Sports", "Beauty", "Toys"}
-	category := categories[rng.Intn(len(categories))]
+	category := w.corpus.category(rng)
 
 	rows, err := db.Query(ctx, `
 		SELECT product_id, name, price, avg_rating
@@ -166,7 +128,7 @@ func (w *ECommerceWorkload) getProductsByCategory(ctx context.Context, db *pgxpo
 
 // getUserOrders retrieves user's orders (uses index)
 func (w *ECommerceWorkload) getUserOrders(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 
 	rows, err := db.Query(ctx, `
 		SELECT order_id, order_number, status, total_amount, created_at
@@ -194,7 +156,7 @@ func (w *ECommerceWorkload) getUserOrders(ctx context.Context, db *pgxpool.Pool,
 
 // getProductReviews retrieves product reviews (uses index)
 func (w *ECommerceWorkload) getProductReviews(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	productID := rng.Intn(500) + 1
+	productID := w.keys.Intn(rng, 500) + 1
 
 	rows, err := db.Query(ctx, `
 		SELECT r.review_id, r.rating, r.title, r.content, r.helpful_votes, u.username
@@ -221,7 +183,7 @@ func (w *ECommerceWorkload) getProductReviews(ctx context.Context, db *pgxpool.P
 
 // getVendorProducts retrieves products from a specific vendor
 func (w *ECommerceWorkload) getVendorProducts(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	vendorID := rng.Intn(50) + 1
+	vendorID := w.keys.Intn(rng, 50) + 1
 
 	rows, err := db.Query(ctx, `
 		SELECT p.product_id, p.name, p.price, p.cost, v.vendor_name
@@ -249,7 +211,7 @@ func (w *ECommerceWorkload) getVendorProducts(ctx context.Context, db *pgxpool.P
 
 // getPurchaseOrdersByVendor retrieves purchase orders for a vendor
 func (w *ECommerceWorkload) getPurchaseOrdersByVendor(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	vendorID := rng.Intn(50) + 1
+	vendorID := w.keys.Intn(rng, 50) + 1
 
 	rows, err := db.Query(ctx, `
 		SELECT po_id, po_number, status, total_amount, expected_delivery, created_at
@@ -280,7 +242,7 @@ func (w *ECommerceWorkload) getPurchaseOrdersByVendor(ctx context.Context, db *p
 
 // getOrderDetailsWithItems retrieves order with all items (multi-table join)
 func (w *ECommerceWorkload) getOrderDetailsWithItems(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	orderID := rng.Intn(2000) + 1
+	orderID := w.keys.Intn(rng, 2000) + 1
 
 	rows, err := db.Query(ctx, `
 		SELECT o.order_number, o.status, o.total_amount, 
@@ -310,7 +272,7 @@ func (w *ECommerceWorkload) getOrderDetailsWithItems(ctx context.Context, db *pg
 
 // getUserActivitySummary retrieves comprehensive user activity (complex join)
 func (w *ECommerceWorkload) getUserActivitySummary(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 
 	var username, email string
 	var totalSpent, avgOrderValue float64
@@ -336,7 +298,7 @@ func (w *ECommerceWorkload) getUserActivitySummary(ctx context.Context, db *pgxp
 
 // getProductAnalytics retrieves product interaction analytics
 func (w *ECommerceWorkload) getProductAnalytics(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	productID := rng.Intn(500) + 1
+	productID := w.keys.Intn(rng, 500) + 1
 
 	rows, err := db.Query(ctx, `
 		SELECT pa.event_type, COUNT(*) AS event_count,
@@ -424,8 +386,7 @@ func (w *ECommerceWorkload) getVendorPerformance(ctx context.Context, db *pgxpoo
 
 // searchProductsByName performs text search on product names
 func (w *ECommerceWorkload) searchProductsByName(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	searchTerms := []string{"laptop", "phone", "book", "shirt", "shoe", "watch", "camera", "tablet"}
-	searchTerm := searchTerms[rng.Intn(len(searchTerms))]
+	searchTerm := w.corpus.searchTerm(rng)
 
 	rows, err := db.Query(ctx, `
 		SELECT product_id, name, price, avg_rating
@@ -452,7 +413,7 @@ func (w *ECommerceWorkload) searchProductsByName(ctx context.Context, db *pgxpoo
 
 // findSimilarUsers finds users with similar purchasing patterns
 func (w *ECommerceWorkload) findSimilarUsers(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 
 	rows, err := db.Query(ctx, `
 		WITH user_categories AS (
@@ -546,8 +507,7 @@ func (w *ECommerceWorkload) searchReviewsByVector(ctx context.Context, db *pgxpo
 
 	if err != nil {
 		// Fallback to text search if vector search fails
-		searchTerms := []string{"great", "good", "excellent", "poor", "bad", "amazing", "terrible", "fantastic"}
-		searchTerm := searchTerms[rng.Intn(len(searchTerms))]
+		searchTerm := w.corpus.reviewTerm(rng)
 
 		rows, err = db.Query(ctx, `
 			SELECT review_id, title, content, rating, 0.0 AS distance
@@ -605,7 +565,7 @@ func (w *ECommerceWorkload) getTopProductsByCategory(ctx context.Context, db *pg
 
 // getUserSpendingTrends analyzes user spending patterns with CTEs
 func (w *ECommerceWorkload) getUserSpendingTrends(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 
 	rows, err := db.Query(ctx, `
 		WITH monthly_spending AS (
@@ -735,28 +695,28 @@ func (w *ECommerceWorkload) getStockControlReport(ctx context.Context, db *pgxpo
 // WRITE OPERATIONS
 
 // executeWriteOperation performs various write operations
-func (w *ECommerceWorkload) executeWriteOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
+func (w *ECommerceWorkload) executeWriteOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) (string, error) {
 	// All write operations enabled - po_id ambiguity fixed
-	operations := []func(context.Context, *pgxpool.Pool, *rand.Rand) error{
-		w.createNewOrder,         // Insert new order and order items
-		w.insertProductReview,    // Insert new product review
-		w.updateInventory,        // Update stock levels
-		w.updateUserProfile,      // Update user information
-		w.updateProductPricing,   // Update product prices
-		w.insertProductAnalytics, // Track product interactions
-		w.updateVendorRating,     // Update vendor performance rating
-		w.processOrderShipment,   // Update order status to shipped
-		w.createPurchaseOrder,    // Create purchase order to vendor
-		w.receivePurchaseOrder,   // Process purchase order receipt (FIXED)
+	operations := []namedOperation{
+		{"createNewOrder", w.createNewOrder},                 // Insert new order and order items
+		{"insertProductReview", w.insertProductReview},       // Insert new product review
+		{"updateInventory", w.updateInventory},               // Update stock levels
+		{"updateUserProfile", w.updateUserProfile},           // Update user information
+		{"updateProductPricing", w.updateProductPricing},     // Update product prices
+		{"insertProductAnalytics", w.insertProductAnalytics}, // Track product interactions
+		{"updateVendorRating", w.updateVendorRating},         // Update vendor performance rating
+		{"processOrderShipment", w.processOrderShipment},     // Update order status to shipped
+		{"createPurchaseOrder", w.createPurchaseOrder},       // Create purchase order to vendor
+		{"receivePurchaseOrder", w.receivePurchaseOrder},     // Process purchase order receipt (FIXED)
 	}
 
 	op := operations[rng.Intn(len(operations))]
-	return op(ctx, db, rng)
+	return op.name, op.run(types.WithOperation(ctx, op.name), db, rng)
 }
 
 // createNewOrder creates a new customer order
 func (w *ECommerceWorkload) createNewOrder(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 	orderNumber := fmt.Sprintf("ORD-%d-%d-%d", time.Now().UnixNano(), rng.Intn(1000000), userID)
 
 	// Start transaction
@@ -780,7 +740,7 @@ func (w *ECommerceWorkload) createNewOrder(ctx context.Context, db *pgxpool.Pool
 	// Add order items
 	numItems := rng.Intn(3) + 1
 	for i := 0; i < numItems; i++ {
-		productID := rng.Intn(500) + 1
+		productID := w.keys.Intn(rng, 500) + 1
 		quantity := rng.Intn(3) + 1
 		unitPrice := float64(rng.Intn(100) + 10)
 		totalPrice := float64(quantity) * unitPrice
@@ -810,7 +770,7 @@ func (w *ECommerceWorkload) createNewOrder(ctx context.Context, db *pgxpool.Pool
 
 // updateInventory updates inventory levels
 func (w *ECommerceWorkload) updateInventory(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	productID := rng.Intn(500) + 1
+	productID := w.keys.Intn(rng, 500) + 1
 	adjustment := rng.Intn(20) - 10 // -10 to +10
 
 	_, err := db.Exec(ctx, `
@@ -824,8 +784,8 @@ func (w *ECommerceWorkload) updateInventory(ctx context.Context, db *pgxpool.Poo
 
 // insertProductReview inserts a new product review
 func (w *ECommerceWorkload) insertProductReview(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
-	productID := rng.Intn(500) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
+	productID := w.keys.Intn(rng, 500) + 1
 	rating := rng.Intn(5) + 1
 
 	reviews := []string{
@@ -862,7 +822,7 @@ func (w *ECommerceWorkload) insertProductReview(ctx context.Context, db *pgxpool
 
 // updateUserProfile updates user information
 func (w *ECommerceWorkload) updateUserProfile(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 	loyaltyPoints := rng.Intn(1000)
 
 	_, err := db.Exec(ctx, `
@@ -875,7 +835,7 @@ func (w *ECommerceWorkload) updateUserProfile(ctx context.Context, db *pgxpool.P
 
 // createPurchaseOrder creates a purchase order to a vendor
 func (w *ECommerceWorkload) createPurchaseOrder(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	vendorID := rng.Intn(50) + 1
+	vendorID := w.keys.Intn(rng, 50) + 1
 	poNumber := fmt.Sprintf("PO-%d-%d-%d", time.Now().UnixNano(), rng.Intn(1000000), vendorID)
 
 	tx, err := db.Begin(ctx)
@@ -898,7 +858,7 @@ func (w *ECommerceWorkload) createPurchaseOrder(ctx context.Context, db *pgxpool
 	// Add purchase order items
 	numItems := rng.Intn(3) + 1
 	for i := 0; i < numItems; i++ {
-		productID := rng.Intn(500) + 1
+		productID := w.keys.Intn(rng, 500) + 1
 		quantity := rng.Intn(50) + 10
 		unitCost := float64(rng.Intn(50) + 5)
 		totalCost := float64(quantity) * unitCost
@@ -947,7 +907,7 @@ func (w *ECommerceWorkload) receivePurchaseOrder(ctx context.Context, db *pgxpoo
 
 // updateProductPricing updates product prices
 func (w *ECommerceWorkload) updateProductPricing(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	productID := rng.Intn(500) + 1
+	productID := w.keys.Intn(rng, 500) + 1
 
 	// Get current cost
 	var currentCost float64
@@ -973,8 +933,8 @@ func (w *ECommerceWorkload) updateProductPricing(ctx context.Context, db *pgxpoo
 
 // insertProductAnalytics tracks product interactions
 func (w *ECommerceWorkload) insertProductAnalytics(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
-	productID := rng.Intn(500) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
+	productID := w.keys.Intn(rng, 500) + 1
 
 	eventTypes := []string{"view", "add_to_cart", "purchase", "wishlist"}
 	eventType := eventTypes[rng.Intn(len(eventTypes))]
@@ -988,7 +948,7 @@ func (w *ECommerceWorkload) insertProductAnalytics(ctx context.Context, db *pgxp
 
 // updateVendorRating updates vendor performance rating
 func (w *ECommerceWorkload) updateVendorRating(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	vendorID := rng.Intn(50) + 1
+	vendorID := w.keys.Intn(rng, 50) + 1
 	newRating := 1.0 + rand.Float64()*4.0 // Rating between 1.0 and 5.0
 
 	_, err := db.Exec(ctx, `
@@ -1029,37 +989,37 @@ func (w *ECommerceWorkload) processOrderShipment(ctx context.Context, db *pgxpoo
 // OLTP OPERATIONS
 
 // executeOLTPReadOperation performs OLTP-style read operations
-func (w *ECommerceWorkload) executeOLTPReadOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
+func (w *ECommerceWorkload) executeOLTPReadOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) (string, error) {
 	// Fast, indexed lookups typical in OLTP systems
-	operations := []func(context.Context, *pgxpool.Pool, *rand.Rand) error{
-		w.getUserByEmail,
-		w.getProductBySKU,
-		w.getUserOrders,
-		w.getInventoryByProduct,
-		w.getOrderDetails,
+	operations := []namedOperation{
+		{"getUserByEmail", w.getUserByEmail},
+		{"getProductBySKU", w.getProductBySKU},
+		{"getUserOrders", w.getUserOrders},
+		{"getInventoryByProduct", w.getInventoryByProduct},
+		{"getOrderDetails", w.getOrderDetails},
 	}
 
 	op := operations[rng.Intn(len(operations))]
-	return op(ctx, db, rng)
+	return op.name, op.run(types.WithOperation(ctx, op.name), db, rng)
 }
 
 // executeOLTPWriteOperation performs OLTP-style write operations
-func (w *ECommerceWorkload) executeOLTPWriteOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
+func (w *ECommerceWorkload) executeOLTPWriteOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) (string, error) {
 	// Fast, small transactions typical in OLTP systems
-	operations := []func(context.Context, *pgxpool.Pool, *rand.Rand) error{
-		w.updateInventory,
-		w.updateUserProfile,
-		w.insertProductAnalytics,
-		w.updateOrderStatus,
+	operations := []namedOperation{
+		{"updateInventory", w.updateInventory},
+		{"updateUserProfile", w.updateUserProfile},
+		{"insertProductAnalytics", w.insertProductAnalytics},
+		{"updateOrderStatus", w.updateOrderStatus},
 	}
 
 	op := operations[rng.Intn(len(operations))]
-	return op(ctx, db, rng)
+	return op.name, op.run(types.WithOperation(ctx, op.name), db, rng)
 }
 
 // getInventoryByProduct gets inventory for a specific product
 func (w *ECommerceWorkload) getInventoryByProduct(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	productID := rng.Intn(500) + 1
+	productID := w.keys.Intn(rng, 500) + 1
 
 	var quantityAvailable, reorderLevel int
 	var warehouseLocation string
@@ -1078,7 +1038,7 @@ func (w *ECommerceWorkload) getInventoryByProduct(ctx context.Context, db *pgxpo
 
 // getOrderDetails gets details for a specific order
 func (w *ECommerceWorkload) getOrderDetails(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	orderID := rng.Intn(2000) + 1
+	orderID := w.keys.Intn(rng, 2000) + 1
 
 	var orderNumber, status string
 	var totalAmount float64
@@ -1098,7 +1058,7 @@ func (w *ECommerceWorkload) getOrderDetails(ctx context.Context, db *pgxpool.Poo
 
 // updateOrderStatus updates order status
 func (w *ECommerceWorkload) updateOrderStatus(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	orderID := rng.Intn(2000) + 1
+	orderID := w.keys.Intn(rng, 2000) + 1
 	statuses := []string{"pending", "processing", "shipped", "delivered"}
 	status := statuses[rng.Intn(len(statuses))]
 
@@ -1113,20 +1073,20 @@ func (w *ECommerceWorkload) updateOrderStatus(ctx context.Context, db *pgxpool.P
 // ANALYTICS OPERATIONS
 
 // executeAnalyticsOperation performs analytics-style operations
-func (w *ECommerceWorkload) executeAnalyticsOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
+func (w *ECommerceWorkload) executeAnalyticsOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) (string, error) {
 	// Complex analytical queries
-	operations := []func(context.Context, *pgxpool.Pool, *rand.Rand) error{
-		w.getInventoryAnalysis,
-		w.getUserSpendingTrends,
-		w.getTopProductsByCategory,
-		w.getVendorPerformance,
-		w.getStockControlReport,
-		w.getSalesAnalytics,
-		w.getCustomerSegmentation,
+	operations := []namedOperation{
+		{"getInventoryAnalysis", w.getInventoryAnalysis},
+		{"getUserSpendingTrends", w.getUserSpendingTrends},
+		{"getTopProductsByCategory", w.getTopProductsByCategory},
+		{"getVendorPerformance", w.getVendorPerformance},
+		{"getStockControlReport", w.getStockControlReport},
+		{"getSalesAnalytics", w.getSalesAnalytics},
+		{"getCustomerSegmentation", w.getCustomerSegmentation},
 	}
 
 	op := operations[rng.Intn(len(operations))]
-	return op(ctx, db, rng)
+	return op.name, op.run(types.WithOperation(ctx, op.name), db, rng)
 }
 
 // getSalesAnalytics performs sales analytics
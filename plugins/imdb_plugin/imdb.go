@@ -9,13 +9,14 @@ import (
 	"math/rand"
 	"os"
 	"os/exec"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/elchinoo/stormdb/internal/progress"
 	"github.com/elchinoo/stormdb/internal/util"
+	"github.com/elchinoo/stormdb/pkg/keydist"
+	"github.com/elchinoo/stormdb/pkg/plugin"
 	"github.com/elchinoo/stormdb/pkg/types"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -24,6 +25,26 @@ import (
 // IMDBWorkload implements realistic IMDB-style database operations
 type IMDBWorkload struct {
 	Mode string // "read", "write", or "mixed"
+
+	// readPools routes read-only operations to replicas when configured
+	readPools plugin.ReadPoolSelector
+
+	// keys picks movie, actor and user ids using the key_distribution option
+	keys *keydist.Chooser
+}
+
+// SetReadPoolSelector enables routing of read-only operations to replicas
+func (w *IMDBWorkload) SetReadPoolSelector(selector plugin.ReadPoolSelector) {
+	w.readPools = selector
+}
+
+// readPool returns the pool for the next read-only operation, falling back
+// to the primary when no replicas are configured
+func (w *IMDBWorkload) readPool(db *pgxpool.Pool) *pgxpool.Pool {
+	if w.readPools == nil {
+		return db
+	}
+	return w.readPools.ReadPool()
 }
 
 // minLen returns the minimum of two integers
@@ -145,7 +166,7 @@ func (w *IMDBWorkload) Setup(ctx context.Context, db *pgxpool.Pool, cfg *types.C
 		return fmt.Errorf("failed to count movies: %w", err)
 	}
 
-	if movieCount == 0 {
+	if movieCount == 0 || w.loadPending(ctx, db, cfg) {
 		// Determine data loading mode
 		dataMode := cfg.DataLoading.Mode
 		if dataMode == "" {
@@ -183,6 +204,7 @@ func (w *IMDBWorkload) Cleanup(ctx context.Context, db *pgxpool.Pool, cfg *types
 	log.Printf("🧹 Cleaning up Real IMDB workload...")
 
 	tables := []string{
+		"stormdb_load_checkpoints",
 		"voting_count_history",
 		"movies_viewed_logs",
 		"movies_normalized_user_comments",
@@ -205,6 +227,12 @@ func (w *IMDBWorkload) Cleanup(ctx context.Context, db *pgxpool.Pool, cfg *types
 func (w *IMDBWorkload) Run(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics) error {
 	log.Printf("🎬 Starting IMDB %s workload...", w.Mode)
 
+	keys, err := keydist.FromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid key distribution: %w", err)
+	}
+	w.keys = keys
+
 	var wg sync.WaitGroup
 	start := time.Now()
 
@@ -242,25 +270,26 @@ func (w *IMDBWorkload) worker(ctx context.Context, db *pgxpool.Pool, cfg *types.
 			// Determine operation based on mode
 			switch w.Mode {
 			case "read":
-				operation, err = w.executeReadOperation(ctx, db, rng)
+				operation, err = w.executeReadOperation(ctx, w.readPool(db), rng)
 				atomic.AddInt64(&metrics.RowsRead, 1)
 			case "write":
 				operation, err = w.executeWriteOperation(ctx, db, rng)
 				atomic.AddInt64(&metrics.RowsModified, 1)
 			case "mixed":
 				if rng.Intn(100) < 70 { // 70% reads, 30% writes
-					operation, err = w.executeReadOperation(ctx, db, rng)
+					operation, err = w.executeReadOperation(ctx, w.readPool(db), rng)
 					atomic.AddInt64(&metrics.RowsRead, 1)
 				} else {
 					operation, err = w.executeWriteOperation(ctx, db, rng)
 					atomic.AddInt64(&metrics.RowsModified, 1)
 				}
 			default:
-				operation, err = w.executeReadOperation(ctx, db, rng)
+				operation, err = w.executeReadOperation(ctx, w.readPool(db), rng)
 				atomic.AddInt64(&metrics.RowsRead, 1)
 			}
 
 			elapsed := time.Since(start).Nanoseconds()
+			metrics.RecordOperation(operation, elapsed, 1, err == nil)
 
 			// Record metrics
 			metrics.Mu.Lock()
@@ -275,6 +304,7 @@ func (w *IMDBWorkload) worker(ctx context.Context, db *pgxpool.Pool, cfg *types.
 				metrics.ErrorTypes[fmt.Sprintf("%s: %s", operation, err.Error())]++
 				metrics.Mu.Unlock()
 			} else {
+				metrics.RecordCommitStart(start)
 				atomic.AddInt64(&metrics.TPS, 1)
 				atomic.AddInt64(&metrics.QPS, 1)
 			}
@@ -285,102 +315,55 @@ func (w *IMDBWorkload) worker(ctx context.Context, db *pgxpool.Pool, cfg *types.
 	}
 }
 
+// namedOperation is a workload operation with the name its metrics are
+// recorded under
+type namedOperation struct {
+	name string
+	run  func(context.Context, *pgxpool.Pool, *rand.Rand) error
+}
+
 // executeReadOperation performs various read-heavy operations
 func (w *IMDBWorkload) executeReadOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) (string, error) {
 	// Mix of simple index tests and complex analytical queries
-	operations := []func(context.Context, *pgxpool.Pool, *rand.Rand) error{
+	operations := []namedOperation{
 		// Basic index utilization tests (70% of queries)
-		w.testIndexedTitleSearch,   // Uses B-tree index on title
-		w.testIndexedRatingSearch,  // Uses B-tree index on imdb_rating
-		w.testCompositeIndexSearch, // Uses composite index on (country, year, imdb_rating)
-		w.testUniqueIndexSearch,    // Uses unique index on imdb_id
-		w.testGINIndexSearch,       // Uses GIN index on json_column
-		w.testFullTableScan,        // Forces full table scan (no index on overview)
-		w.testSimpleJoin,           // Tests basic join with indexes
-		w.getMovieDetails,          // Uses B-tree index on ai_myid
-		w.getActorMovies,           // Uses B-tree index on ai_actor_id
-		w.getMovieComments,         // Uses composite index on (ai_myid, comment_add_time)
+		{"indexed_title_search", w.testIndexedTitleSearch},     // Uses B-tree index on title
+		{"indexed_rating_search", w.testIndexedRatingSearch},   // Uses B-tree index on imdb_rating
+		{"composite_index_search", w.testCompositeIndexSearch}, // Uses composite index on (country, year, imdb_rating)
+		{"unique_index_search", w.testUniqueIndexSearch},       // Uses unique index on imdb_id
+		{"gin_index_search", w.testGINIndexSearch},             // Uses GIN index on json_column
+		{"full_table_scan", w.testFullTableScan},               // Forces full table scan (no index on overview)
+		{"simple_join", w.testSimpleJoin},                      // Tests basic join with indexes
+		{"get_movie_details", w.getMovieDetails},               // Uses B-tree index on ai_myid
+		{"get_actor_movies", w.getActorMovies},                 // Uses B-tree index on ai_actor_id
+		{"get_movie_comments", w.getMovieComments},             // Uses composite index on (ai_myid, comment_add_time)
 
 		// Advanced analytical queries (30% of queries)
-		w.testWindowFunctionRanking,   // Window functions for ranking analysis
-		w.testCTERecursiveAnalysis,    // Recursive CTEs with window functions
-		w.testComplexCTEAnalysis,      // Multiple CTEs with joins and aggregations
-		w.testAdvancedWindowFunctions, // Advanced window function features
-		w.testActorCareerAnalysisCTE,  // Complex CTE-based actor analysis
+		{"window_function_ranking", w.testWindowFunctionRanking},     // Window functions for ranking analysis
+		{"cte_recursive_analysis", w.testCTERecursiveAnalysis},       // Recursive CTEs with window functions
+		{"complex_cte_analysis", w.testComplexCTEAnalysis},           // Multiple CTEs with joins and aggregations
+		{"advanced_window_functions", w.testAdvancedWindowFunctions}, // Advanced window function features
+		{"actor_career_cte_analysis", w.testActorCareerAnalysisCTE},  // Complex CTE-based actor analysis
 	}
 
 	op := operations[rng.Intn(len(operations))]
-	opName := ""
-
-	switch rng.Intn(15) {
-	case 0:
-		opName = "indexed_title_search"
-	case 1:
-		opName = "indexed_rating_search"
-	case 2:
-		opName = "composite_index_search"
-	case 3:
-		opName = "unique_index_search"
-	case 4:
-		opName = "gin_index_search"
-	case 5:
-		opName = "full_table_scan"
-	case 6:
-		opName = "simple_join"
-	case 7:
-		opName = "get_movie_details"
-	case 8:
-		opName = "get_actor_movies"
-	case 9:
-		opName = "get_movie_comments"
-	case 10:
-		opName = "window_function_ranking"
-	case 11:
-		opName = "cte_recursive_analysis"
-	case 12:
-		opName = "complex_cte_analysis"
-	case 13:
-		opName = "advanced_window_functions"
-	case 14:
-		opName = "actor_career_cte_analysis"
-	}
-
-	return opName, op(ctx, db, rng)
+	return op.name, op.run(types.WithOperation(ctx, op.name), db, rng)
 }
 
 // executeWriteOperation performs various write-heavy operations
 func (w *IMDBWorkload) executeWriteOperation(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) (string, error) {
-	operations := []func(context.Context, *pgxpool.Pool, *rand.Rand) error{
-		w.insertNewComment,
-		w.updateMovieRating,
-		w.insertNewMovie,
-		w.updateCommentHelpfulness,
-		w.insertNewActor,
-		w.addMovieActor,
-		w.logMovieView,
+	operations := []namedOperation{
+		{"insert_comment", w.insertNewComment},
+		{"update_movie_rating", w.updateMovieRating},
+		{"insert_movie", w.insertNewMovie},
+		{"update_comment_votes", w.updateCommentHelpfulness},
+		{"insert_actor", w.insertNewActor},
+		{"add_movie_actor", w.addMovieActor},
+		{"log_movie_view", w.logMovieView},
 	}
 
 	op := operations[rng.Intn(len(operations))]
-	opName := ""
-
-	switch rng.Intn(7) {
-	case 0:
-		opName = "insert_comment"
-	case 1:
-		opName = "update_movie_rating"
-	case 2:
-		opName = "insert_movie"
-	case 3:
-		opName = "update_comment_votes"
-	case 4:
-		opName = "insert_actor"
-	case 5:
-		opName = "add_movie_actor"
-	case 6:
-		opName = "log_movie_view"
-	}
-
-	return opName, op(ctx, db, rng)
+	return op.name, op.run(types.WithOperation(ctx, op.name), db, rng)
 }
 
 // loadSampleData populates the database with sample IMDB data using real schema
@@ -612,104 +595,85 @@ func (w *IMDBWorkload) startRealTimeReporter(ctx context.Context, cfg *types.Con
 	return cancel
 }
 
-// loadFromDump loads data from a PostgreSQL dump file
+// loadFromDump loads data from a PostgreSQL dump. Plain-format dumps are
+// streamed directly; archives are converted to a script by pg_restore and
+// streamed from its output.
 func (w *IMDBWorkload) loadFromDump(ctx context.Context, db *pgxpool.Pool, cfg *types.Config) error {
 	if cfg.DataLoading.FilePath == "" {
 		return fmt.Errorf("dump file path is required")
 	}
 
-	log.Printf("📦 Restoring from dump file: %s", cfg.DataLoading.FilePath)
-
-	// Check if file exists
-	if _, err := os.Stat(cfg.DataLoading.FilePath); os.IsNotExist(err) {
+	plain, err := isPlainScript(cfg.DataLoading.FilePath)
+	if err != nil {
 		return fmt.Errorf("dump file not found: %s", cfg.DataLoading.FilePath)
 	}
+	if plain {
+		return w.streamScript(ctx, db, cfg, "dump", "📦 Loading dump (KB)")
+	}
 
-	// Build pg_restore command
-	cmd := exec.Command("pg_restore",
-		"--host", cfg.Database.Host,
-		"--port", fmt.Sprintf("%d", cfg.Database.Port),
-		"--username", cfg.Database.Username,
-		"--dbname", cfg.Database.Dbname,
-		"--no-password",
-		"--clean",
-		"--if-exists",
-		"--data-only",
-		"--verbose",
-		cfg.DataLoading.FilePath)
-
-	// Set PGPASSWORD environment variable
-	cmd.Env = append(os.Environ(), fmt.Sprintf("PGPASSWORD=%s", cfg.Database.Password))
-
-	// Capture output
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	loader, err := newScriptLoader(db, cfg, "dump", cfg.DataLoading.FilePath)
+	if err != nil {
+		return err
+	}
 
-	log.Printf("🔄 Running pg_restore command...")
-	err := cmd.Run()
+	log.Printf("📦 Restoring from dump archive: %s", cfg.DataLoading.FilePath)
 
+	// pg_restore writes the data as a script with COPY blocks to stdout
+	restoreCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(restoreCtx, "pg_restore", "--data-only", "-f", "-", cfg.DataLoading.FilePath)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		log.Printf("❌ pg_restore stderr: %s", stderr.String())
-		return fmt.Errorf("pg_restore failed: %w", err)
+		return fmt.Errorf("failed to start pg_restore: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start pg_restore: %w", err)
 	}
 
-	log.Printf("✅ Dump file loaded successfully")
-	if stdout.Len() > 0 {
-		log.Printf("📝 pg_restore output: %s", stdout.String())
+	loadErr := loader.load(ctx, stdout, 0, "📦 Restoring dump")
+	if loadErr != nil {
+		cancel()
+	}
+	waitErr := cmd.Wait()
+	if loadErr != nil {
+		return loadErr
+	}
+	if waitErr != nil {
+		log.Printf("❌ pg_restore stderr: %s", stderr.String())
+		return fmt.Errorf("pg_restore failed: %w", waitErr)
 	}
 
+	log.Printf("✅ Dump file loaded successfully")
 	return nil
 }
 
-// loadFromSQL loads data from an SQL file
+// loadFromSQL streams an SQL file statement by statement
 func (w *IMDBWorkload) loadFromSQL(ctx context.Context, db *pgxpool.Pool, cfg *types.Config) error {
 	if cfg.DataLoading.FilePath == "" {
 		return fmt.Errorf("SQL file path is required")
 	}
+	return w.streamScript(ctx, db, cfg, "sql", "📜 Loading SQL (KB)")
+}
 
-	log.Printf("📜 Loading from SQL file: %s", cfg.DataLoading.FilePath)
-
-	// Check if file exists
-	if _, err := os.Stat(cfg.DataLoading.FilePath); os.IsNotExist(err) {
-		return fmt.Errorf("SQL file not found: %s", cfg.DataLoading.FilePath)
-	}
-
-	// Read the SQL file
-	sqlContent, err := os.ReadFile(cfg.DataLoading.FilePath)
+// streamScript loads the plain SQL script at data_loading.filepath in
+// committed batches, resuming an interrupted load of the same file
+func (w *IMDBWorkload) streamScript(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, mode, title string) error {
+	loader, err := newScriptLoader(db, cfg, mode, cfg.DataLoading.FilePath)
 	if err != nil {
-		return fmt.Errorf("failed to read SQL file: %w", err)
+		return err
 	}
 
-	// Split SQL commands (basic implementation)
-	// This handles simple cases; for complex SQL files, consider using a proper SQL parser
-	sqlStatements := strings.Split(string(sqlContent), ";")
-
-	log.Printf("🔄 Executing %d SQL statements...", len(sqlStatements))
-
-	successCount := 0
-	for i, statement := range sqlStatements {
-		statement = strings.TrimSpace(statement)
-		if statement == "" || strings.HasPrefix(statement, "--") {
-			continue
-		}
-
-		_, err := db.Exec(ctx, statement)
-		if err != nil {
-			log.Printf("⚠️  Warning: Failed to execute statement %d: %v", i+1, err)
-			log.Printf("📄 Statement: %s", statement[:min(100, len(statement))])
-		} else {
-			successCount++
-		}
-
-		// Progress update every 100 statements
-		if (i+1)%100 == 0 {
-			log.Printf("⏳ Processed %d / %d statements...", i+1, len(sqlStatements))
-		}
+	file, err := os.Open(cfg.DataLoading.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s file: %w", mode, err)
 	}
+	defer file.Close()
 
-	log.Printf("✅ SQL file loaded successfully: %d statements executed", successCount)
-	return nil
+	log.Printf("🔄 Streaming %s (%.1f MB, batches of %d items or %d MB)",
+		cfg.DataLoading.FilePath, float64(loader.size)/(1<<20), loader.batchItems, loader.batchBytes>>20)
+	return loader.load(ctx, file, loader.size, title)
 }
 
 // min helper function for string truncation
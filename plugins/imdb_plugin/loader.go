@@ -0,0 +1,445 @@
+// Streaming, restartable loading of SQL scripts and dumps
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/progress"
+	"github.com/elchinoo/stormdb/internal/sqlscript"
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Script loading defaults, used when data_loading leaves them unset
+const (
+	defaultScriptBatchItems = 100000 // Statements and COPY rows per committed batch
+	defaultScriptMemoryMB   = 64     // Buffered statement and COPY bytes per batch
+)
+
+// checkpointTableSQL creates the table recording how far each script has
+// been applied. A row is updated in the same transaction as every batch.
+const checkpointTableSQL = `
+CREATE TABLE IF NOT EXISTS stormdb_load_checkpoints (
+    source       TEXT PRIMARY KEY,
+    source_size  BIGINT NOT NULL,
+    source_mtime TIMESTAMPTZ NOT NULL,
+    byte_offset  BIGINT NOT NULL DEFAULT 0,
+    copy_header  TEXT NOT NULL DEFAULT '',
+    session_sql  TEXT[] NOT NULL DEFAULT '{}',
+    statements   BIGINT NOT NULL DEFAULT 0,
+    failed       BIGINT NOT NULL DEFAULT 0,
+    rows_copied  BIGINT NOT NULL DEFAULT 0,
+    completed    BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+)`
+
+// sessionSetting matches statements that change session state, which must
+// be replayed on the loading connection when a load resumes
+var sessionSetting = regexp.MustCompile(`(?is)^(SET\s|RESET\s|SELECT\s+pg_catalog\.set_config\s*\()`)
+
+// nonTransactional matches statements that cannot run in a transaction block
+var nonTransactional = regexp.MustCompile(`(?is)^(VACUUM|CLUSTER|ALTER\s+SYSTEM|(CREATE|DROP)\s+DATABASE|(CREATE|DROP)\s+TABLESPACE|(CREATE|DROP)\s+(UNIQUE\s+)?INDEX\s+CONCURRENTLY|REINDEX\s.*CONCURRENTLY)\b`)
+
+// loadCheckpoint is the committed position of a script load
+type loadCheckpoint struct {
+	Offset     int64    // Script bytes applied
+	CopyHeader string   // COPY statement when Offset lies inside its data
+	Session    []string // Session settings seen before Offset
+	Statements int64    // Statements executed
+	Failed     int64    // Statements that failed and were skipped
+	RowsCopied int64    // COPY rows loaded
+}
+
+// scriptLoader streams a SQL script into the database in batches. Every
+// batch commits together with its checkpoint, so an interrupted load
+// resumes after the last committed batch.
+type scriptLoader struct {
+	db         *pgxpool.Pool
+	source     string    // Checkpoint key: loading mode and absolute path
+	size       int64     // Source size, to detect a changed source
+	mtime      time.Time // Source modification time
+	batchItems int       // Statements and COPY rows per batch
+	batchBytes int       // Buffered bytes per batch
+}
+
+// newScriptLoader creates a loader for the file or directory at path,
+// sizing batches from data_loading.batch_size and max_memory_mb
+func newScriptLoader(db *pgxpool.Pool, cfg *types.Config, mode, path string) (*scriptLoader, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s file not found: %s", mode, path)
+	}
+
+	l := &scriptLoader{
+		db:         db,
+		source:     mode + ":" + absPath,
+		size:       info.Size(),
+		mtime:      info.ModTime().Truncate(time.Microsecond),
+		batchItems: defaultScriptBatchItems,
+		batchBytes: defaultScriptMemoryMB << 20,
+	}
+	if cfg.DataLoading.BatchSize > 0 {
+		l.batchItems = cfg.DataLoading.BatchSize
+	}
+	// Half the memory budget is buffered batch data; the rest covers the
+	// reader, driver buffers and a single oversized statement
+	if cfg.DataLoading.MaxMemoryMB > 0 {
+		l.batchBytes = cfg.DataLoading.MaxMemoryMB << 20 / 2
+	}
+	return l, nil
+}
+
+// pending reports whether an earlier load of this source was interrupted
+func (l *scriptLoader) pending(ctx context.Context) bool {
+	cp, err := l.checkpoint(ctx)
+	return err == nil && cp != nil && cp.Offset > 0
+}
+
+// checkpoint returns the committed position of an unfinished load of this
+// source, or nil to start from the beginning
+func (l *scriptLoader) checkpoint(ctx context.Context) (*loadCheckpoint, error) {
+	if _, err := l.db.Exec(ctx, checkpointTableSQL); err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint table: %w", err)
+	}
+
+	var cp loadCheckpoint
+	var size int64
+	var mtime time.Time
+	var completed bool
+	err := l.db.QueryRow(ctx, `
+		SELECT source_size, source_mtime, byte_offset, copy_header, session_sql, statements, failed, rows_copied, completed
+		FROM stormdb_load_checkpoints WHERE source = $1`, l.source).Scan(
+		&size, &mtime, &cp.Offset, &cp.CopyHeader, &cp.Session, &cp.Statements, &cp.Failed, &cp.RowsCopied, &completed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read load checkpoint: %w", err)
+	}
+	if completed {
+		return nil, nil
+	}
+	if size != l.size || !mtime.Equal(l.mtime) {
+		log.Printf("⚠️  %s changed since the interrupted load, starting over", l.source)
+		return nil, nil
+	}
+	return &cp, nil
+}
+
+// scriptOp is one statement or COPY chunk of a batch
+type scriptOp struct {
+	sql  string       // Statement, or the COPY statement of a chunk
+	data bytes.Buffer // COPY rows, empty for statements
+	copy bool
+}
+
+// scriptBatch is the uncommitted work since the last checkpoint
+type scriptBatch struct {
+	ops        []*scriptOp
+	statements int
+	items      int
+	bytes      int
+	offset     int64  // Script offset after the last item
+	copyHeader string // COPY statement open at offset
+}
+
+// add appends a statement
+func (b *scriptBatch) add(sql string) {
+	b.ops = append(b.ops, &scriptOp{sql: sql})
+	b.statements++
+	b.items++
+	b.bytes += len(sql)
+}
+
+// addCopyRow appends a COPY row to the chunk of the open COPY block
+func (b *scriptBatch) addCopyRow(header, row string) {
+	var op *scriptOp
+	if n := len(b.ops); n > 0 && b.ops[n-1].copy && b.ops[n-1].sql == header {
+		op = b.ops[n-1]
+	} else {
+		op = &scriptOp{sql: header, copy: true}
+		b.ops = append(b.ops, op)
+	}
+	op.data.WriteString(row)
+	b.items++
+	b.bytes += len(row)
+}
+
+// load applies the script read from input, which starts at the beginning of
+// the source; total is its size in bytes for progress, or 0 if unknown
+func (l *scriptLoader) load(ctx context.Context, input io.Reader, total int64, title string) error {
+	cp, err := l.checkpoint(ctx)
+	if err != nil {
+		return err
+	}
+	if cp == nil {
+		cp = &loadCheckpoint{}
+	} else {
+		log.Printf("⏩ Resuming %s at byte %d (%d statements, %d COPY rows already loaded)",
+			l.source, cp.Offset, cp.Statements, cp.RowsCopied)
+		if seeker, ok := input.(io.Seeker); ok {
+			_, err = seeker.Seek(cp.Offset, io.SeekStart)
+		} else {
+			_, err = io.CopyN(io.Discard, input, cp.Offset)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to skip to byte %d: %w", cp.Offset, err)
+		}
+	}
+
+	conn, err := l.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+	// Scripts change session settings such as search_path; reset them before
+	// the connection returns to the pool
+	defer func() {
+		if _, err := conn.Exec(context.Background(), "RESET ALL"); err != nil {
+			log.Printf("⚠️  Failed to reset loader session: %v", err)
+		}
+	}()
+
+	// Restore the session settings of the already applied part
+	for _, stmt := range cp.Session {
+		if _, err := conn.Conn().PgConn().Exec(ctx, stmt).ReadAll(); err != nil {
+			log.Printf("⚠️  Failed to restore session setting %q: %v", stmt, err)
+		}
+	}
+
+	tracker := progress.NewBatchTracker(title, int(total>>10), max(1, l.batchBytes>>10))
+	tracker.Update(int(cp.Offset >> 10))
+	lastLog := time.Now()
+
+	reader := sqlscript.NewReaderAt(input, cp.Offset, cp.CopyHeader)
+	batch := &scriptBatch{offset: cp.Offset, copyHeader: cp.CopyHeader}
+	commit := func() error {
+		if err := l.commit(ctx, conn.Conn(), batch, cp); err != nil {
+			return err
+		}
+		batch = &scriptBatch{offset: cp.Offset, copyHeader: cp.CopyHeader}
+		tracker.Update(int(cp.Offset >> 10))
+		if total <= 0 && time.Since(lastLog) >= 10*time.Second {
+			log.Printf("⏳ %s: %d statements, %d COPY rows, %.1f MB", title, cp.Statements, cp.RowsCopied, float64(cp.Offset)/(1<<20))
+			lastLog = time.Now()
+		}
+		return nil
+	}
+
+	for {
+		item, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read script at byte %d: %w", reader.Offset(), err)
+		}
+
+		switch item.Kind {
+		case sqlscript.Statement:
+			if nonTransactional.MatchString(item.Text) {
+				if err := commit(); err != nil {
+					return err
+				}
+				if _, err := conn.Conn().PgConn().Exec(ctx, item.Text).ReadAll(); err != nil {
+					log.Printf("⚠️  Warning: Failed to execute statement: %v", err)
+					cp.Failed++
+				}
+				cp.Statements++
+				batch.offset = item.Offset
+				if err := commit(); err != nil {
+					return err
+				}
+				continue
+			}
+			if sessionSetting.MatchString(item.Text) {
+				cp.Session = append(cp.Session, item.Text)
+			}
+			batch.add(item.Text)
+		case sqlscript.CopyRow:
+			batch.addCopyRow(reader.CopyHeader(), item.Text)
+		case sqlscript.MetaCommand:
+			log.Printf("ℹ️  Skipping psql meta-command: %s", item.Text)
+		}
+		batch.offset, batch.copyHeader = item.Offset, reader.CopyHeader()
+
+		if batch.items >= l.batchItems || batch.bytes >= l.batchBytes {
+			if err := commit(); err != nil {
+				return err
+			}
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("load interrupted at byte %d, run setup again to resume: %w", cp.Offset, ctx.Err())
+		}
+	}
+
+	if err := commit(); err != nil {
+		return err
+	}
+	if _, err := l.db.Exec(ctx, "UPDATE stormdb_load_checkpoints SET completed = TRUE, updated_at = NOW() WHERE source = $1", l.source); err != nil {
+		return fmt.Errorf("failed to mark load complete: %w", err)
+	}
+	tracker.Finish()
+
+	log.Printf("✅ Loaded %s: %d statements (%d failed), %d COPY rows", l.source, cp.Statements, cp.Failed, cp.RowsCopied)
+	return nil
+}
+
+// commit applies a batch and its checkpoint in one transaction. If a
+// statement fails, the batch is replayed with each statement in a
+// savepoint so failures are skipped with a warning, as psql would;
+// a failed COPY stops the load.
+func (l *scriptLoader) commit(ctx context.Context, conn *pgx.Conn, batch *scriptBatch, cp *loadCheckpoint) error {
+	next := *cp
+	next.Offset, next.CopyHeader = batch.offset, batch.copyHeader
+	next.Session = cp.Session
+
+	apply := func(tolerant bool) error {
+		return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			failed, rows, err := applyOps(ctx, tx, batch.ops, tolerant)
+			if err != nil {
+				return err
+			}
+			next.Statements = cp.Statements + int64(batch.statements)
+			next.Failed = cp.Failed + failed
+			next.RowsCopied = cp.RowsCopied + rows
+
+			_, err = tx.Exec(ctx, `
+				INSERT INTO stormdb_load_checkpoints
+				    (source, source_size, source_mtime, byte_offset, copy_header, session_sql, statements, failed, rows_copied, completed, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, FALSE, NOW())
+				ON CONFLICT (source) DO UPDATE SET
+				    source_size = EXCLUDED.source_size, source_mtime = EXCLUDED.source_mtime,
+				    byte_offset = EXCLUDED.byte_offset, copy_header = EXCLUDED.copy_header,
+				    session_sql = EXCLUDED.session_sql, statements = EXCLUDED.statements,
+				    failed = EXCLUDED.failed, rows_copied = EXCLUDED.rows_copied,
+				    completed = FALSE, updated_at = NOW()`,
+				l.source, l.size, l.mtime, next.Offset, next.CopyHeader, next.Session,
+				next.Statements, next.Failed, next.RowsCopied)
+			if err != nil {
+				return fmt.Errorf("failed to record load checkpoint: %w", err)
+			}
+			return nil
+		})
+	}
+
+	err := apply(false)
+	var stmtErr *statementError
+	if errors.As(err, &stmtErr) {
+		err = apply(true)
+	}
+	if err != nil {
+		return fmt.Errorf("batch ending at byte %d failed (the load resumes from byte %d): %w", batch.offset, cp.Offset, err)
+	}
+
+	*cp = next
+	return nil
+}
+
+// statementError is a failed non-COPY statement of a batch
+type statementError struct {
+	err error
+}
+
+func (e *statementError) Error() string { return e.err.Error() }
+func (e *statementError) Unwrap() error { return e.err }
+
+// applyOps executes the operations of a batch in tx. In tolerant mode every
+// statement runs in a savepoint and failures are counted instead of
+// returned. It returns the failed statements and the COPY rows loaded.
+func applyOps(ctx context.Context, tx pgx.Tx, ops []*scriptOp, tolerant bool) (failed, rows int64, err error) {
+	pgConn := tx.Conn().PgConn()
+	for _, op := range ops {
+		if op.copy {
+			tag, err := pgConn.CopyFrom(ctx, bytes.NewReader(op.data.Bytes()), op.sql)
+			if err != nil {
+				return failed, rows, fmt.Errorf("COPY failed (%s): %w", truncateSQL(op.sql), err)
+			}
+			rows += tag.RowsAffected()
+			continue
+		}
+
+		if !tolerant {
+			if _, err := pgConn.Exec(ctx, op.sql).ReadAll(); err != nil {
+				return failed, rows, &statementError{err}
+			}
+			continue
+		}
+		if _, err := pgConn.Exec(ctx, "SAVEPOINT script_statement").ReadAll(); err != nil {
+			return failed, rows, err
+		}
+		if _, err := pgConn.Exec(ctx, op.sql).ReadAll(); err != nil {
+			log.Printf("⚠️  Warning: Failed to execute statement: %v", err)
+			log.Printf("📄 Statement: %s", truncateSQL(op.sql))
+			failed++
+			if _, err := pgConn.Exec(ctx, "ROLLBACK TO SAVEPOINT script_statement").ReadAll(); err != nil {
+				return failed, rows, err
+			}
+		}
+		if _, err := pgConn.Exec(ctx, "RELEASE SAVEPOINT script_statement").ReadAll(); err != nil {
+			return failed, rows, err
+		}
+	}
+	return failed, rows, nil
+}
+
+// truncateSQL shortens a statement for log output
+func truncateSQL(sql string) string {
+	sql = strings.Join(strings.Fields(sql), " ")
+	return sql[:min(100, len(sql))]
+}
+
+// loadPending reports whether a dump or SQL load of the configured file was
+// interrupted, so Setup resumes it even though some data exists
+func (w *IMDBWorkload) loadPending(ctx context.Context, db *pgxpool.Pool, cfg *types.Config) bool {
+	mode := cfg.DataLoading.Mode
+	if (mode != "dump" && mode != "sql") || cfg.DataLoading.FilePath == "" {
+		return false
+	}
+	loader, err := newScriptLoader(db, cfg, mode, cfg.DataLoading.FilePath)
+	return err == nil && loader.pending(ctx)
+}
+
+// isPlainScript reports whether path is a plain SQL script rather than a
+// custom, directory or tar archive that needs pg_restore
+func isPlainScript(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	if info.IsDir() {
+		return false, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	header := make([]byte, 262)
+	n, _ := io.ReadFull(file, header)
+	header = header[:n]
+	if bytes.HasPrefix(header, []byte("PGDMP")) {
+		return false, nil
+	}
+	if len(header) >= 262 && string(header[257:262]) == "ustar" {
+		return false, nil
+	}
+	return true, nil
+}
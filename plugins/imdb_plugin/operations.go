@@ -44,7 +44,7 @@ func (w *IMDBWorkload) searchMoviesByGenre(ctx context.Context, db *pgxpool.Pool
 
 // getMovieDetails retrieves detailed information about a movie using simplified schema
 func (w *IMDBWorkload) getMovieDetails(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	movieIdx := rng.Intn(1000) + 1
+	movieIdx := w.keys.Intn(rng, 1000) + 1
 
 	// Get movie info from movies_normalized_meta
 	var title, country, overview string
@@ -113,7 +113,7 @@ func (w *IMDBWorkload) getTopRatedMovies(ctx context.Context, db *pgxpool.Pool,
 
 // getActorMovies finds movies for a specific actor using simplified schema
 func (w *IMDBWorkload) getActorMovies(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	actorIdx := rng.Intn(500) + 1
+	actorIdx := w.keys.Intn(rng, 500) + 1
 
 	rows, err := db.Query(ctx, `
 		SELECT m.imdb_id, m.title, m.year, c.actor_character 
@@ -140,7 +140,7 @@ func (w *IMDBWorkload) getActorMovies(ctx context.Context, db *pgxpool.Pool, rng
 
 // getMovieComments retrieves user comments for a movie using simplified schema
 func (w *IMDBWorkload) getMovieComments(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	movieIdx := rng.Intn(1000) + 1
+	movieIdx := w.keys.Intn(rng, 1000) + 1
 
 	rows, err := db.Query(ctx, `
 		SELECT rating, comment, comment_add_time 
@@ -196,7 +196,7 @@ func (w *IMDBWorkload) searchMoviesByYear(ctx context.Context, db *pgxpool.Pool,
 
 // getRecentViewingActivity retrieves recent viewing logs using simplified schema
 func (w *IMDBWorkload) getRecentViewingActivity(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 
 	rows, err := db.Query(ctx, `
 		SELECT v.imdb_id, m.title, v.watched_time, v.time_watched_sec, v.json_payload 
@@ -282,7 +282,7 @@ func (w *IMDBWorkload) searchMoviesByCountryYear(ctx context.Context, db *pgxpoo
 
 // getMovieStatsByIMDbID uses unique index on imdb_id
 func (w *IMDBWorkload) getMovieStatsByIMDbID(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	movieID := rng.Intn(10000) + 1
+	movieID := w.keys.Intn(rng, 10000) + 1
 	imdbID := fmt.Sprintf("tt%07d", movieID)
 
 	var title, country string
@@ -455,7 +455,7 @@ func (w *IMDBWorkload) getMovieTrendsAnalysis(ctx context.Context, db *pgxpool.P
 
 // insertNewComment adds a new user comment using simplified schema
 func (w *IMDBWorkload) insertNewComment(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	movieIdx := rng.Intn(1000) + 1
+	movieIdx := w.keys.Intn(rng, 1000) + 1
 	rating := rng.Intn(10) + 1
 
 	reviewTexts := []string{
@@ -481,7 +481,7 @@ func (w *IMDBWorkload) insertNewComment(ctx context.Context, db *pgxpool.Pool, r
 
 // updateMovieRating updates rating statistics using simplified schema
 func (w *IMDBWorkload) updateMovieRating(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	movieIdx := rng.Intn(1000) + 1
+	movieIdx := w.keys.Intn(rng, 1000) + 1
 	newRating := float64(rng.Intn(100)+1) / 10.0
 	voteIncrement := rng.Intn(50) + 1
 
@@ -521,7 +521,7 @@ func (w *IMDBWorkload) insertNewMovie(ctx context.Context, db *pgxpool.Pool, rng
 
 // updateCommentHelpfulness updates a comment using simplified schema
 func (w *IMDBWorkload) updateCommentHelpfulness(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	movieIdx := rng.Intn(1000) + 1
+	movieIdx := w.keys.Intn(rng, 1000) + 1
 
 	// Update a random comment for this movie
 	_, err := db.Exec(ctx, `
@@ -553,8 +553,8 @@ func (w *IMDBWorkload) insertNewActor(ctx context.Context, db *pgxpool.Pool, rng
 
 // addMovieActor links an actor to a movie using simplified schema
 func (w *IMDBWorkload) addMovieActor(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	movieIdx := rng.Intn(1000) + 1
-	actorIdx := rng.Intn(500) + 1
+	movieIdx := w.keys.Intn(rng, 1000) + 1
+	actorIdx := w.keys.Intn(rng, 500) + 1
 	character := fmt.Sprintf("Character %d", rng.Intn(10)+1)
 
 	_, err := db.Exec(ctx, `
@@ -567,9 +567,9 @@ func (w *IMDBWorkload) addMovieActor(ctx context.Context, db *pgxpool.Pool, rng
 
 // logMovieView records a movie viewing event using simplified schema
 func (w *IMDBWorkload) logMovieView(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	movieIdx := rng.Intn(1000) + 1
+	movieIdx := w.keys.Intn(rng, 1000) + 1
 	imdbID := fmt.Sprintf("tt%07d", movieIdx)
-	userID := rng.Intn(1000) + 1
+	userID := w.keys.Intn(rng, 1000) + 1
 	timeWatchedSec := rng.Intn(180*60) + 30*60 // 30-210 minutes in seconds
 	encodedData := fmt.Sprintf("session_%d_data", rng.Intn(10000))
 	jsonPayload := fmt.Sprintf(`{"session_id": %d, "device": "web", "completed": %t}`, rng.Intn(10000), rng.Intn(3) == 0)
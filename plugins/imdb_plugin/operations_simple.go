@@ -93,7 +93,7 @@ func (w *IMDBWorkload) testCompositeIndexSearch(ctx context.Context, db *pgxpool
 
 // testUniqueIndexSearch tests unique index on imdb_id
 func (w *IMDBWorkload) testUniqueIndexSearch(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	movieID := rng.Intn(10000) + 1
+	movieID := w.keys.Intn(rng, 10000) + 1
 	imdbID := fmt.Sprintf("tt%07d", movieID)
 
 	var title, country string
@@ -167,7 +167,7 @@ func (w *IMDBWorkload) testFullTableScan(ctx context.Context, db *pgxpool.Pool,
 
 // testSimpleJoin tests basic join performance with indexes
 func (w *IMDBWorkload) testSimpleJoin(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand) error {
-	movieIdx := rng.Intn(1000) + 1
+	movieIdx := w.keys.Intn(rng, 1000) + 1
 
 	rows, err := db.Query(ctx, `
 		SELECT a.actor_name, c.actor_character 
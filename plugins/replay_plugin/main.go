@@ -0,0 +1,51 @@
+// Replay Workload Plugin
+// This plugin replays captured production SQL traffic, read from PostgreSQL
+// csvlog/jsonlog files or a JSONL capture, session by session.
+package main
+
+import (
+	"fmt"
+
+	"github.com/elchinoo/stormdb/pkg/plugin"
+)
+
+// ReplayWorkloadPlugin implements the WorkloadPlugin interface for traffic replay
+type ReplayWorkloadPlugin struct{}
+
+// WorkloadPlugin is the exported symbol that the plugin loader will look for
+var WorkloadPlugin ReplayWorkloadPlugin
+
+// GetMetadata returns metadata about this plugin
+func (p *ReplayWorkloadPlugin) GetMetadata() *plugin.PluginMetadata {
+	return &plugin.PluginMetadata{
+		Name:                 "replay_plugin",
+		Version:              "1.0.0",
+		APIVersion:           "1.0",
+		Description:          "Replays captured SQL traffic from PostgreSQL logs or JSONL captures",
+		Author:               "StormDB Team",
+		WorkloadTypes:        []string{"replay"},
+		RequiredExtensions:   []string{},
+		MinPostgreSQLVersion: "11.0",
+		Homepage:             "https://github.com/elchinoo/stormdb",
+	}
+}
+
+// CreateWorkload creates a Replay workload instance
+func (p *ReplayWorkloadPlugin) CreateWorkload(workloadType string) (plugin.Workload, error) {
+	switch workloadType {
+	case "replay":
+		return &ReplayWorkload{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported workload type: %s", workloadType)
+	}
+}
+
+// Initialize performs plugin initialization
+func (p *ReplayWorkloadPlugin) Initialize() error {
+	return nil
+}
+
+// Cleanup performs plugin cleanup
+func (p *ReplayWorkloadPlugin) Cleanup() error {
+	return nil
+}
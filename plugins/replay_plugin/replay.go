@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/elchinoo/stormdb/internal/replay"
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReplayWorkload replays the capture file of the replay configuration
+type ReplayWorkload struct{}
+
+// Setup does nothing: the replayed schema must already exist
+func (w *ReplayWorkload) Setup(ctx context.Context, db *pgxpool.Pool, cfg *types.Config) error {
+	log.Printf("📼 Replay workload has no schema, replaying against the existing database")
+	return nil
+}
+
+// Cleanup does nothing: replayed data is left in place
+func (w *ReplayWorkload) Cleanup(ctx context.Context, db *pgxpool.Pool, cfg *types.Config) error {
+	return nil
+}
+
+// Run replays the capture with cfg.Workers concurrent sessions
+func (w *ReplayWorkload) Run(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics) error {
+	if cfg.Replay.File == "" {
+		return fmt.Errorf("replay workload requires replay.file")
+	}
+	opts, err := replay.OptionsFrom(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid replay configuration: %w", err)
+	}
+
+	events, err := replay.Load(cfg.Replay.File, cfg.Replay.Format)
+	if err != nil {
+		return err
+	}
+	replayer, err := replay.NewReplayer(events, opts)
+	if err != nil {
+		return fmt.Errorf("cannot replay %s: %w", cfg.Replay.File, err)
+	}
+
+	statements, sessions := replayer.Stats()
+	log.Printf("📼 Replaying %d statement(s) in %d session(s) from %s (timing: %v, speed: %gx, loop: %v)",
+		statements, sessions, cfg.Replay.File, opts.PreserveTiming, opts.Speed, opts.Loop)
+	return replayer.Run(ctx, db, cfg.Workers, metrics)
+}
@@ -11,13 +11,28 @@ import (
 	"time"
 
 	"github.com/elchinoo/stormdb/internal/progress"
+	"github.com/elchinoo/stormdb/pkg/keydist"
 	"github.com/elchinoo/stormdb/pkg/types"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // Generator implements the simple read/write workload
-type Generator struct{}
+type Generator struct {
+	// keys picks row ids using the key_distribution option
+	keys *keydist.Chooser
+}
+
+// Statements executed by the workload, declared for explicit preparation
+const (
+	readSQL  = "SELECT val FROM loadtest WHERE id = $1"
+	writeSQL = "UPDATE loadtest SET val = $1, updated = NOW() WHERE id = $2"
+)
+
+// PreparedStatements returns the statements used by Run
+func (g *Generator) PreparedStatements(_ *types.Config) []string {
+	return []string{readSQL, writeSQL}
+}
 
 // Setup ensures the schema exists (only if --setup or --rebuild)
 func (g *Generator) Setup(ctx context.Context, db *pgxpool.Pool, _ *types.Config) error {
@@ -69,6 +84,12 @@ func (g *Generator) Cleanup(ctx context.Context, db *pgxpool.Pool, cfg *types.Co
 
 // Run starts the workload
 func (g *Generator) Run(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics) error {
+	keys, err := keydist.FromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid key distribution: %w", err)
+	}
+	g.keys = keys
+
 	var wg sync.WaitGroup
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -94,21 +115,27 @@ func (g *Generator) worker(ctx context.Context, db *pgxpool.Pool, cfg *types.Con
 		default:
 			start := time.Now()
 
-			var err error
+			operation := "read"
 			switch cfg.Workload {
 			case "read":
-				err = g.doRead(ctx, db, rng, metrics)
 			case "write":
-				err = g.doWrite(ctx, db, rng, metrics)
+				operation = "write"
 			default: // mixed
-				if rng.Intn(2) == 0 {
-					err = g.doRead(ctx, db, rng, metrics)
-				} else {
-					err = g.doWrite(ctx, db, rng, metrics)
+				if rng.Intn(2) != 0 {
+					operation = "write"
 				}
 			}
 
+			var err error
+			opCtx := types.WithOperation(ctx, operation)
+			if operation == "read" {
+				err = g.doRead(opCtx, db, rng, metrics)
+			} else {
+				err = g.doWrite(opCtx, db, rng, metrics)
+			}
+
 			elapsed := time.Since(start).Nanoseconds()
+			metrics.RecordOperation(operation, elapsed, 1, err == nil)
 
 			// Record latency
 			metrics.Mu.Lock()
@@ -121,6 +148,7 @@ func (g *Generator) worker(ctx context.Context, db *pgxpool.Pool, cfg *types.Con
 				metrics.ErrorTypes[err.Error()]++
 				metrics.Mu.Unlock()
 			} else {
+				metrics.RecordCommitStart(start)
 				atomic.AddInt64(&metrics.TPS, 1)
 				atomic.AddInt64(&metrics.QPS, 1)
 			}
@@ -132,8 +160,8 @@ func (g *Generator) worker(ctx context.Context, db *pgxpool.Pool, cfg *types.Con
 
 func (g *Generator) doRead(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand, metrics *types.Metrics) error {
 	var val string
-	id := rng.Intn(1000) + 1
-	row := db.QueryRow(ctx, "SELECT val FROM loadtest WHERE id = $1", id)
+	id := g.keys.Intn(rng, 1000) + 1
+	row := db.QueryRow(ctx, readSQL, id)
 	err := row.Scan(&val)
 	if err == nil {
 		atomic.AddInt64(&metrics.RowsRead, 1)
@@ -142,9 +170,9 @@ func (g *Generator) doRead(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand
 }
 
 func (g *Generator) doWrite(ctx context.Context, db *pgxpool.Pool, rng *rand.Rand, metrics *types.Metrics) error {
-	id := rng.Intn(1000) + 1
+	id := g.keys.Intn(rng, 1000) + 1
 	val := fmt.Sprintf("updated_%d", time.Now().UnixNano())
-	_, err := db.Exec(ctx, "UPDATE loadtest SET val = $1, updated = NOW() WHERE id = $2", val, id)
+	_, err := db.Exec(ctx, writeSQL, val, id)
 	if err == nil {
 		atomic.AddInt64(&metrics.RowsModified, 1)
 	}
@@ -79,3 +79,8 @@ func (w *SimpleWorkloadWrapper) Setup(ctx context.Context, db *pgxpool.Pool, cfg
 func (w *SimpleWorkloadWrapper) Run(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics) error {
 	return w.generator.Run(ctx, db, cfg, metrics)
 }
+
+// PreparedStatements returns the statements to prepare in "prepared" query_exec_mode
+func (w *SimpleWorkloadWrapper) PreparedStatements(cfg *types.Config) []string {
+	return w.generator.PreparedStatements(cfg)
+}
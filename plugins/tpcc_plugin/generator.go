@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"math/rand"
 	"sync"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/elchinoo/stormdb/internal/util"
+	"github.com/elchinoo/stormdb/pkg/keydist"
 	"github.com/elchinoo/stormdb/pkg/types"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -17,6 +19,12 @@ import (
 
 // Run starts the TPCC workload with multiple workers
 func (t *TPCC) Run(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics) error {
+	keys, err := keydist.FromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid key distribution: %w", err)
+	}
+	t.keys = keys
+
 	var wg sync.WaitGroup
 	start := time.Now() // ✅ Capture start time
 
@@ -49,15 +57,17 @@ func (t *TPCC) worker(ctx context.Context, db *pgxpool.Pool, _ *types.Config, me
 
 			var err error
 			var queryCount int64
-			switch txType := rollTransaction(rng); txType {
+			txType := rollTransaction(rng)
+			txCtx := types.WithOperation(ctx, txType)
+			switch txType {
 			case "new_order":
-				err, queryCount = t.newOrderTxWithQueryCount(ctx, db, rng)
+				err, queryCount = t.newOrderTxWithQueryCount(txCtx, db, rng)
 				atomic.AddInt64(&metrics.NewOrderCount, 1)
 			case "payment":
-				err, queryCount = t.paymentTxWithQueryCount(ctx, db, rng)
+				err, queryCount = t.paymentTxWithQueryCount(txCtx, db, rng)
 				atomic.AddInt64(&metrics.PaymentCount, 1)
 			case "order_status":
-				err, queryCount = t.orderStatusTxWithQueryCount(ctx, db, rng)
+				err, queryCount = t.orderStatusTxWithQueryCount(txCtx, db, rng)
 				atomic.AddInt64(&metrics.OrderStatusCount, 1)
 			default: // think
 				atomic.AddInt64(&metrics.ThinkCount, 1)
@@ -66,6 +76,7 @@ func (t *TPCC) worker(ctx context.Context, db *pgxpool.Pool, _ *types.Config, me
 			}
 
 			elapsed := time.Since(start).Nanoseconds()
+			metrics.RecordOperation(txType, elapsed, 0, err == nil)
 
 			// Record latency
 			metrics.Mu.Lock()
@@ -78,6 +89,7 @@ func (t *TPCC) worker(ctx context.Context, db *pgxpool.Pool, _ *types.Config, me
 				metrics.ErrorTypes[err.Error()]++
 				metrics.Mu.Unlock()
 			} else {
+				metrics.RecordCommitStart(start)
 				atomic.AddInt64(&metrics.TPS, 1)
 				atomic.AddInt64(&metrics.QPS, queryCount) // Add actual query count
 			}
@@ -17,7 +17,7 @@ func (t *TPCC) newOrderTxWithQueryCount(ctx context.Context, db *pgxpool.Pool, r
 	queryCount := int64(0)
 	wID := 1
 	dID := rng.Intn(10) + 1
-	cID := rng.Intn(300) + 1
+	cID := t.keys.Intn(rng, 300) + 1
 
 	// 1% of orders have a remote item (from another warehouse)
 	remote := rng.Intn(100) == 0
@@ -28,7 +28,7 @@ func (t *TPCC) newOrderTxWithQueryCount(ctx context.Context, db *pgxpool.Pool, r
 	olCount := 5 + rng.Intn(11)
 
 	for i := 0; i < olCount; i++ {
-		iID := rng.Intn(10000) + 1
+		iID := t.keys.Intn(rng, 10000) + 1
 		quantity := 1 + rng.Intn(10)
 		iIDs = append(iIDs, iID)
 		olQuantities = append(olQuantities, quantity)
@@ -55,11 +55,11 @@ func (t *TPCC) orderStatusTxWithQueryCount(ctx context.Context, db *pgxpool.Pool
 		if len(cIDs) > 0 {
 			cID = cIDs[len(cIDs)/2] // middle
 		} else {
-			cID = rng.Intn(300) + 1
+			cID = t.keys.Intn(rng, 300) + 1
 		}
 	} else {
 		// 40%: by customer ID
-		cID = rng.Intn(300) + 1
+		cID = t.keys.Intn(rng, 300) + 1
 	}
 
 	tx, err := db.Begin(ctx)
@@ -17,7 +17,7 @@ func (t *TPCC) paymentTxWithQueryCount(ctx context.Context, db *pgxpool.Pool, rn
 	queryCount := int64(0)
 	wID := 1
 	dID := rng.Intn(10) + 1
-	cID := rng.Intn(300) + 1
+	cID := t.keys.Intn(rng, 300) + 1
 
 	amount := 10.0 + rng.Float64()*90.0
 
@@ -1,6 +1,10 @@
 // internal/workload/tpcc/tpcc.go
 package main
 
+import "github.com/elchinoo/stormdb/pkg/keydist"
+
 // TPCC is the workload implementation for TPC-C-like load
-// It currently holds no state, but serves as a receiver for methods
-type TPCC struct{}
+type TPCC struct {
+	// keys picks customer and item ids using the key_distribution option
+	keys *keydist.Chooser
+}
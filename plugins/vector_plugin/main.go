@@ -29,6 +29,18 @@ func (p *VectorPlugin) GetMetadata() *plugin.PluginMetadata {
 			"pgvector_update_batch",
 			"pgvector_read_scan",
 			"pgvector_read_indexed",
+			"pgvector_accuracy",
+			"pgvector_accuracy_ivfflat",
+			"pgvector_accuracy_hnsw",
+			"pgvector_index",
+			"pgvector_index_ivfflat",
+			"pgvector_index_hnsw",
+			"pgvector_filtered",
+			"pgvector_filtered_ivfflat",
+			"pgvector_filtered_hnsw",
+			"pgvector_quantization",
+			"pgvector_quantization_ivfflat",
+			"pgvector_quantization_hnsw",
 		},
 		RequiredExtensions:   []string{"vector"},
 		MinPostgreSQLVersion: "12.0",
@@ -0,0 +1,498 @@
+// plugins/vector_plugin/pgvector_accuracy.go
+// Recall@k accuracy testing for approximate (IVFFlat/HNSW) indexes
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/util"
+	"github.com/elchinoo/stormdb/pkg/types"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pgvector/pgvector-go"
+	"github.com/spf13/viper"
+)
+
+// AccuracyConfig controls the recall@k sweep, read from workload_config.accuracy
+type AccuracyConfig struct {
+	K               int      // Neighbours per query (default 10)
+	Queries         int      // Number of query vectors (default 100)
+	Probes          []int    // ivfflat.probes values to sweep
+	EfSearch        []int    // hnsw.ef_search values to sweep
+	Indexes         []string // Index configuration names to test (default: all of IndexType)
+	QueryFile       string   // Optional CSV of query vectors
+	GroundTruthFile string   // Optional CSV of exact neighbour ids, one line per query
+	BaseLimit       int      // Base vectors loaded by a limited dataset import, 0 when all were loaded
+}
+
+// parseAccuracyConfig reads workload_config.accuracy from the loaded configuration
+func parseAccuracyConfig() *AccuracyConfig {
+	ac := &AccuracyConfig{
+		K:        10,
+		Queries:  100,
+		Probes:   []int{1, 2, 4, 8, 16, 32, 64},
+		EfSearch: []int{10, 20, 40, 80, 160, 320},
+	}
+	if !viper.IsSet("workload_config.accuracy") {
+		return ac
+	}
+
+	if k := viper.GetInt("workload_config.accuracy.k"); k > 0 {
+		ac.K = k
+	}
+	if queries := viper.GetInt("workload_config.accuracy.queries"); queries > 0 {
+		ac.Queries = queries
+	}
+	if probes := viper.GetIntSlice("workload_config.accuracy.probes"); len(probes) > 0 {
+		ac.Probes = probes
+	}
+	if efSearch := viper.GetIntSlice("workload_config.accuracy.ef_search"); len(efSearch) > 0 {
+		ac.EfSearch = efSearch
+	}
+	ac.Indexes = viper.GetStringSlice("workload_config.accuracy.indexes")
+	ac.QueryFile = viper.GetString("workload_config.accuracy.query_file")
+	ac.GroundTruthFile = viper.GetString("workload_config.accuracy.ground_truth_file")
+	return ac
+}
+
+// withDatasetDefaults uses the imported dataset's queries and neighbours
+// when no accuracy files are configured
+func (ac *AccuracyConfig) withDatasetDefaults(dc *DatasetConfig) *AccuracyConfig {
+	if dc != nil {
+		ac.BaseLimit = dc.Limit
+	}
+	if dc != nil && ac.QueryFile == "" && ac.GroundTruthFile == "" {
+		ac.QueryFile = dc.QueryFile
+		ac.GroundTruthFile = dc.GroundTruthFile
+	}
+	return ac
+}
+
+// distanceOperator returns the pgvector operator ordering rows by similarity
+func (w *ComprehensivePgVectorWorkload) distanceOperator() string {
+	switch w.SimilarityMetric {
+	case "cosine":
+		return "<=>"
+	case "inner_product":
+		return "<#>" // negative inner product, so ascending order is most similar first
+	default:
+		return "<->"
+	}
+}
+
+// runAccuracyTest measures recall@k and QPS for each index configuration
+// while sweeping its search-time parameter
+func (w *ComprehensivePgVectorWorkload) runAccuracyTest(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics) error {
+	ac := parseAccuracyConfig().withDatasetDefaults(parseDatasetConfig())
+
+	configs := w.accuracyIndexConfigurations(ac)
+	if len(configs) == 0 {
+		return fmt.Errorf("no index configurations to test for index type %s", w.IndexType)
+	}
+
+	queries, truth, source, err := w.loadAccuracyQueries(ctx, db, ac)
+	if err != nil {
+		return err
+	}
+	log.Printf("🎯 Accuracy test: recall@%d over %d queries (%s ground truth), %d index configurations", ac.K, len(queries), source, len(configs))
+
+	defer func() {
+		if _, err := db.Exec(context.Background(), "DROP INDEX IF EXISTS "+benchmarkIndexName); err != nil {
+			log.Printf("⚠️  Failed to drop benchmark index: %v", err)
+		}
+	}()
+
+	for i, ic := range configs {
+		if ctx.Err() != nil {
+			log.Printf("⏱️  Duration reached, skipping %d remaining index configuration(s)", len(configs)-i)
+			break
+		}
+
+		log.Printf("🏗️  Building %s (%s)...", ic.Name, ic.Description)
+		buildTime, err := w.buildIndex(ctx, db, ic, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Printf("⚠️  Skipping %s: %v", ic.Name, err)
+			continue
+		}
+		log.Printf("✅ Built %s in %v", ic.Name, buildTime.Round(time.Millisecond))
+
+		param, values := "ivfflat.probes", ac.Probes
+		if ic.IndexType == "hnsw" {
+			param, values = "hnsw.ef_search", ac.EfSearch
+		}
+
+		for _, value := range values {
+			// Probing more lists than exist is equivalent to probing all of them
+			if lists, ok := ic.Parameters["lists"].(int); ok && value > lists {
+				continue
+			}
+			if ctx.Err() != nil {
+				break
+			}
+
+			result := w.measureRecall(ctx, db, cfg, metrics, queries, truth, ac.K, param, value)
+			if ctx.Err() != nil {
+				break
+			}
+			result.Index = ic.Name
+			result.IndexType = ic.IndexType
+			result.BuildTime = buildTime
+			result.GroundTruth = source
+			metrics.RecordVectorAccuracy(result)
+
+			log.Printf("   %s=%d: recall@%d %.1f%%, %.1f QPS", param, value, ac.K, result.Recall*100, result.QPS)
+		}
+	}
+
+	log.Printf("✅ Accuracy test completed")
+	return nil
+}
+
+// accuracyIndexConfigurations selects the index configurations to sweep
+func (w *ComprehensivePgVectorWorkload) accuracyIndexConfigurations(ac *AccuracyConfig) []IndexConfiguration {
+	wanted := make(map[string]bool, len(ac.Indexes))
+	for _, name := range ac.Indexes {
+		wanted[name] = true
+	}
+
+	var configs []IndexConfiguration
+	for _, ic := range w.getIndexConfigurations() {
+		switch {
+		case len(wanted) > 0:
+			if !wanted[ic.Name] {
+				continue
+			}
+		case w.IndexType != "all" && ic.IndexType != w.IndexType:
+			continue
+		}
+		configs = append(configs, ic)
+	}
+	return configs
+}
+
+// Sources of the exact neighbours used to measure recall
+const (
+	groundTruthFile       = "file"
+	groundTruthBruteForce = "brute force"
+)
+
+// loadAccuracyQueries returns the query vectors, their exact top-k
+// neighbour ids and where the neighbours came from, either the configured
+// files or a brute-force search. Neighbours in a file that lie beyond the
+// base vectors a limited dataset import loaded are dropped; when that
+// leaves fewer than k for a query, the neighbours are recomputed.
+func (w *ComprehensivePgVectorWorkload) loadAccuracyQueries(ctx context.Context, db *pgxpool.Pool, ac *AccuracyConfig) ([][]float32, [][]int64, string, error) {
+	if ac.GroundTruthFile != "" {
+		if ac.QueryFile == "" {
+			return nil, nil, "", fmt.Errorf("accuracy ground_truth_file requires query_file")
+		}
+		queries, err := w.loadVectorsFromFile(ac.QueryFile)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to load query vectors: %w", err)
+		}
+		truth, err := loadNeighborsFromFile(ac.GroundTruthFile)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to load ground truth: %w", err)
+		}
+		if len(truth) < len(queries) {
+			return nil, nil, "", fmt.Errorf("ground truth has %d entries for %d queries", len(truth), len(queries))
+		}
+		truth = truth[:len(queries)]
+
+		source := groundTruthFile
+		if ac.BaseLimit > 0 {
+			var incomplete int
+			truth, incomplete = filterNeighbors(truth, ac.BaseLimit, ac.K)
+			source = fmt.Sprintf("%s, first %d base vectors", groundTruthFile, ac.BaseLimit)
+			if incomplete > 0 {
+				log.Printf("⚠️  Ground truth file lists fewer than %d neighbours within the %d loaded base vectors for %d of %d queries, computing them instead",
+					ac.K, ac.BaseLimit, incomplete, len(queries))
+				if len(queries) > ac.Queries {
+					queries = queries[:ac.Queries]
+				}
+				truth, err := w.computeGroundTruth(ctx, db, queries, ac.K)
+				if err != nil {
+					return nil, nil, "", err
+				}
+				return queries, truth, groundTruthBruteForce, nil
+			}
+		}
+
+		log.Printf("📁 Loaded %d queries with precomputed neighbours (%s)", len(queries), source)
+		return queries, truth, source, nil
+	}
+
+	var queries [][]float32
+	if ac.QueryFile != "" {
+		var err error
+		if queries, err = w.loadVectorsFromFile(ac.QueryFile); err != nil {
+			return nil, nil, "", fmt.Errorf("failed to load query vectors: %w", err)
+		}
+	} else {
+		if len(w.PreloadedData) == 0 {
+			if err := w.loadPrecomputedVectors(ctx); err != nil {
+				return nil, nil, "", err
+			}
+		}
+		rng := rand.New(rand.NewSource(42)) // Fixed seed so runs use the same queries
+		for i := 0; i < ac.Queries && len(w.PreloadedData) > 0; i++ {
+			queries = append(queries, w.PreloadedData[rng.Intn(len(w.PreloadedData))])
+		}
+	}
+	if len(queries) > ac.Queries {
+		queries = queries[:ac.Queries]
+	}
+	if len(queries) == 0 {
+		return nil, nil, "", fmt.Errorf("no query vectors available for accuracy test")
+	}
+
+	truth, err := w.computeGroundTruth(ctx, db, queries, ac.K)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return queries, truth, groundTruthBruteForce, nil
+}
+
+// computeGroundTruth finds the exact top-k neighbours of each query with
+// index scans disabled, and stores them in pgvector_ground_truth
+func (w *ComprehensivePgVectorWorkload) computeGroundTruth(ctx context.Context, db *pgxpool.Pool, queries [][]float32, k int) ([][]int64, error) {
+	log.Printf("🔢 Computing exact top-%d neighbours for %d queries (brute force)...", k, len(queries))
+	start := time.Now()
+
+	query := fmt.Sprintf("SELECT id FROM pgvector_test ORDER BY embedding %s $1 LIMIT %d", w.distanceOperator(), k)
+	truth := make([][]int64, len(queries))
+
+	err := pgx.BeginFunc(ctx, db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "SET LOCAL enable_indexscan = off"); err != nil {
+			return err
+		}
+		for i, q := range queries {
+			rows, err := tx.Query(ctx, query, pgvector.NewVector(q))
+			if err != nil {
+				return err
+			}
+			ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+			if err != nil {
+				return err
+			}
+			truth[i] = ids
+		}
+
+		if _, err := tx.Exec(ctx, "DELETE FROM pgvector_ground_truth WHERE similarity_metric = $1", w.SimilarityMetric); err != nil {
+			return err
+		}
+		batch := &pgx.Batch{}
+		for i, q := range queries {
+			batch.Queue("INSERT INTO pgvector_ground_truth (query_vector, true_neighbors, similarity_metric) VALUES ($1, $2, $3)",
+				pgvector.NewVector(q), truth[i], w.SimilarityMetric)
+		}
+		return tx.SendBatch(ctx, batch).Close()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute ground truth: %w", err)
+	}
+
+	log.Printf("✅ Computed ground truth in %v", time.Since(start).Round(time.Millisecond))
+	return truth, nil
+}
+
+// measureRecall runs every query with the search parameter set to value,
+// spreading the queries over cfg.Workers connections. An empty param keeps
+// the server's search settings.
+func (w *ComprehensivePgVectorWorkload) measureRecall(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics,
+	queries [][]float32, truth [][]int64, k int, param string, value int) types.VectorAccuracyResult {
+
+	var settings []string
+	if param != "" {
+		settings = append(settings, fmt.Sprintf("%s = %d", param, value))
+	}
+	query := fmt.Sprintf("SELECT id FROM pgvector_test ORDER BY embedding %s $1 LIMIT %d", w.distanceOperator(), k)
+	stats := measureQueries(ctx, db, cfg, metrics, query, len(queries), func(i int) []interface{} {
+		return []interface{}{pgvector.NewVector(queries[i])}
+	}, truth, k, settings)
+
+	return types.VectorAccuracyResult{
+		SearchParam:  param,
+		SearchValue:  value,
+		K:            k,
+		Queries:      len(queries),
+		Recall:       stats.Recall,
+		QPS:          stats.QPS,
+		AvgLatencyMs: stats.AvgLatencyMs,
+		P95LatencyMs: stats.P95LatencyMs,
+		Errors:       stats.Errors,
+	}
+}
+
+// queryStats summarizes a measured batch of k-NN queries
+type queryStats struct {
+	Recall       float64 // Mean recall@k of the successful queries
+	QPS          float64 // Successful queries per second across all workers
+	AvgLatencyMs float64 // Average query latency
+	P95LatencyMs float64 // 95th percentile query latency
+	ShortResults int64   // Queries that returned fewer rows than the ground truth (up to k)
+	Errors       int64   // Failed queries
+}
+
+// measureQueries runs n queries over cfg.Workers connections with the given
+// session settings applied, comparing the ids returned by query i (built
+// from args(i)) with truth[i]
+func measureQueries(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics,
+	sql string, n int, args func(i int) []interface{}, truth [][]int64, k int, settings []string) queryStats {
+
+	var stats queryStats
+	recalls := make([]float64, n)
+	latencies := make([]int64, n)
+	var next, failed, short int64
+
+	workers := cfg.Workers
+	if workers > n {
+		workers = n
+	}
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			conn, err := db.Acquire(ctx)
+			if err != nil {
+				atomic.AddInt64(&failed, 1)
+				return
+			}
+			defer conn.Release()
+
+			if len(settings) > 0 {
+				defer func() { _, _ = conn.Exec(context.Background(), "RESET ALL") }()
+				for _, setting := range settings {
+					if _, err := conn.Exec(ctx, "SET "+setting); err != nil {
+						atomic.AddInt64(&failed, 1)
+						return
+					}
+				}
+			}
+
+			for {
+				idx := int(atomic.AddInt64(&next, 1) - 1)
+				if idx >= n || ctx.Err() != nil {
+					return
+				}
+
+				qStart := time.Now()
+				rows, err := conn.Query(ctx, sql, args(idx)...)
+				if err != nil {
+					atomic.AddInt64(&failed, 1)
+					continue
+				}
+				ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+				if err != nil {
+					atomic.AddInt64(&failed, 1)
+					continue
+				}
+				latencies[idx] = time.Since(qStart).Nanoseconds()
+				recalls[idx] = recallAtK(ids, truth[idx], k)
+				if len(ids) < k && len(truth[idx]) > len(ids) {
+					atomic.AddInt64(&short, 1)
+				}
+
+				metrics.RecordOperation("recall_search", latencies[idx], int64(len(ids)), true)
+				metrics.RecordLatency(latencies[idx])
+				metrics.RecordQuery("SELECT")
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	stats.Errors = failed
+	stats.ShortResults = short
+	var sum float64
+	measured := make([]int64, 0, len(latencies))
+	for i, lat := range latencies {
+		if lat > 0 {
+			sum += recalls[i]
+			measured = append(measured, lat)
+		}
+	}
+	if len(measured) > 0 {
+		stats.Recall = sum / float64(len(measured))
+		stats.QPS = float64(len(measured)) / elapsed.Seconds()
+		avg, _, _, _ := util.Stats(measured)
+		stats.AvgLatencyMs = float64(avg) / 1e6
+		stats.P95LatencyMs = float64(util.CalculatePercentiles(measured, []int{95})[0]) / 1e6
+	}
+	return stats
+}
+
+// recallAtK returns the fraction of the true top-k neighbours that were found
+func recallAtK(found, truth []int64, k int) float64 {
+	if len(truth) > k {
+		truth = truth[:k]
+	}
+	if len(truth) == 0 {
+		return 0
+	}
+
+	expected := make(map[int64]bool, len(truth))
+	for _, id := range truth {
+		expected[id] = true
+	}
+	hits := 0
+	for _, id := range found {
+		if expected[id] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(truth))
+}
+
+// loadNeighborsFromFile loads exact neighbour ids from a CSV file with one
+// line of ids per query, or from an ivecs or .npy file of 0-based positions
+func loadNeighborsFromFile(filename string) ([][]int64, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".ivecs", ".npy":
+		return readNeighborVectors(filename)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	neighbors := make([][]int64, len(records))
+	for i, record := range records {
+		for _, val := range record {
+			id, err := strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid neighbour id %q", i+1, val)
+			}
+			neighbors[i] = append(neighbors[i], id)
+		}
+	}
+	return neighbors, nil
+}
@@ -8,21 +8,26 @@ import (
 	"log"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+
+	"github.com/elchinoo/stormdb/pkg/keydist"
 )
 
 // ComprehensivePgVectorWorkload provides extensive pgvector testing capabilities
 type ComprehensivePgVectorWorkload struct {
-	TestType         string      // "ingestion", "update", "read", "index", "accuracy"
+	TestType         string      // "ingestion", "update", "read", "index", "accuracy", "filtered", "quantization"
 	IngestionMethod  string      // "single", "batch", "copy"
 	BatchSize        int         // for batch operations
 	ReadType         string      // "full_scan", "indexed"
-	IndexType        string      // "ivfflat", "hnsw", "none"
+	IndexType        string      // "ivfflat", "hnsw", "none", "all"
 	Dimensions       int         // vector dimensions (default 1024)
 	SimilarityMetric string      // "l2", "cosine", "inner_product"
 	PreloadedData    [][]float32 // 10% pre-calculated vectors for consistent testing
 	BaselineRows     int         // number of rows to pre-load (default 1M)
+
+	keys *keydist.Chooser // picks update targets using the key_distribution option
 }
 
 // IndexConfiguration represents different index configurations to test
@@ -43,8 +48,14 @@ func (w *ComprehensivePgVectorWorkload) generateRandomVector(rng *rand.Rand) []f
 	return vector
 }
 
-// loadVectorsFromFile loads vectors from CSV file
+// loadVectorsFromFile loads vectors from a CSV file, or from an fvecs,
+// bvecs or .npy file based on the extension
 func (w *ComprehensivePgVectorWorkload) loadVectorsFromFile(filename string) ([][]float32, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".fvecs", ".bvecs", ".npy":
+		return readAllVectors(filename)
+	}
+
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, err
@@ -155,7 +166,7 @@ func (w *ComprehensivePgVectorWorkload) parseWorkloadType(workloadType string) {
 	parts := strings.Split(workloadType, "_")
 
 	if len(parts) >= 2 {
-		w.TestType = parts[1] // ingestion, update, read, index, accuracy
+		w.TestType = parts[1] // ingestion, update, read, index, accuracy, filtered, quantization
 	}
 
 	if len(parts) >= 3 {
@@ -196,7 +207,16 @@ func (w *ComprehensivePgVectorWorkload) parseWorkloadType(workloadType string) {
 		w.ReadType = "indexed"
 	}
 	if w.IndexType == "" {
-		w.IndexType = "ivfflat"
+		// Accuracy and index tests cover both index types unless one is named;
+		// quantization defaults to HNSW, which supports every vector type
+		switch w.TestType {
+		case "accuracy", "index":
+			w.IndexType = "all"
+		case "quantization":
+			w.IndexType = "hnsw"
+		default:
+			w.IndexType = "ivfflat"
+		}
 	}
 
 	log.Printf("🔧 Parsed workload configuration:")
@@ -0,0 +1,533 @@
+// plugins/vector_plugin/pgvector_dataset.go
+// Import of standard ANN benchmark datasets (fvecs/bvecs/ivecs and .npy)
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pgvector/pgvector-go"
+	"github.com/spf13/viper"
+)
+
+// DatasetConfig points at a standard ANN dataset, read from workload_config.dataset.
+// Neighbour ids in ivecs/.npy ground truth files are 0-based positions in the
+// base file; they are stored as pgvector_test ids (position + 1).
+type DatasetConfig struct {
+	BaseFile        string // Base vectors (.fvecs, .bvecs or .npy)
+	QueryFile       string // Query vectors (.fvecs, .bvecs, .npy or .csv)
+	GroundTruthFile string // Exact neighbours (.ivecs, .npy or .csv)
+	BatchSize       int    // Rows per COPY batch (default 10000)
+	Limit           int    // Maximum base vectors to load, 0 loads all
+}
+
+// parseDatasetConfig reads workload_config.dataset, returning nil when no
+// dataset is configured
+func parseDatasetConfig() *DatasetConfig {
+	if !viper.IsSet("workload_config.dataset") {
+		return nil
+	}
+
+	dc := &DatasetConfig{
+		BaseFile:        viper.GetString("workload_config.dataset.base_file"),
+		QueryFile:       viper.GetString("workload_config.dataset.query_file"),
+		GroundTruthFile: viper.GetString("workload_config.dataset.ground_truth_file"),
+		BatchSize:       viper.GetInt("workload_config.dataset.batch_size"),
+		Limit:           viper.GetInt("workload_config.dataset.limit"),
+	}
+	if dc.BaseFile == "" {
+		return nil
+	}
+	if dc.BatchSize <= 0 {
+		dc.BatchSize = 10000
+	}
+	return dc
+}
+
+// loadDataset imports the base vectors into pgvector_test and the queries
+// with their exact neighbours into pgvector_ground_truth. The queries also
+// replace the precomputed vectors used by the read tests.
+func (w *ComprehensivePgVectorWorkload) loadDataset(ctx context.Context, db *pgxpool.Pool, dc *DatasetConfig) error {
+	var existing int64
+	if err := db.QueryRow(ctx, "SELECT COUNT(*) FROM pgvector_test").Scan(&existing); err != nil {
+		return fmt.Errorf("failed to count existing rows: %w", err)
+	}
+	if existing > 0 {
+		log.Printf("⏭️  pgvector_test already contains %d rows, skipping dataset import (use --rebuild to reload)", existing)
+	} else if err := w.copyDatasetVectors(ctx, db, dc); err != nil {
+		return err
+	}
+
+	if dc.QueryFile == "" {
+		// Read tests still need query vectors
+		return w.loadPrecomputedVectors(ctx)
+	}
+	queries, err := w.loadVectorsFromFile(dc.QueryFile)
+	if err != nil {
+		return fmt.Errorf("failed to load dataset queries: %w", err)
+	}
+	if err := w.validateDimensions(queries, dc.QueryFile); err != nil {
+		return err
+	}
+	w.PreloadedData = queries
+	log.Printf("📁 Loaded %d dataset queries from %s", len(queries), dc.QueryFile)
+
+	if dc.GroundTruthFile == "" {
+		return nil
+	}
+	truth, err := loadNeighborsFromFile(dc.GroundTruthFile)
+	if err != nil {
+		return fmt.Errorf("failed to load dataset ground truth: %w", err)
+	}
+	if len(truth) < len(queries) {
+		return fmt.Errorf("ground truth has %d entries for %d queries", len(truth), len(queries))
+	}
+	if dc.Limit > 0 {
+		// Neighbours beyond the loaded base vectors can never be found
+		truth, _ = filterNeighbors(truth, dc.Limit, 0)
+		log.Printf("✂️  Kept ground truth neighbours within the first %d base vectors", dc.Limit)
+	}
+
+	err = pgx.BeginFunc(ctx, db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM pgvector_ground_truth WHERE similarity_metric = $1", w.SimilarityMetric); err != nil {
+			return err
+		}
+		batch := &pgx.Batch{}
+		for i, q := range queries {
+			batch.Queue("INSERT INTO pgvector_ground_truth (query_vector, true_neighbors, similarity_metric) VALUES ($1, $2, $3)",
+				pgvector.NewVector(q), truth[i], w.SimilarityMetric)
+		}
+		return tx.SendBatch(ctx, batch).Close()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store dataset ground truth: %w", err)
+	}
+
+	log.Printf("✅ Stored ground truth for %d queries (metric: %s)", len(queries), w.SimilarityMetric)
+	return nil
+}
+
+// copyDatasetVectors streams the base file into pgvector_test using COPY in batches
+func (w *ComprehensivePgVectorWorkload) copyDatasetVectors(ctx context.Context, db *pgxpool.Pool, dc *DatasetConfig) error {
+	reader, err := openVectorReader(dc.BaseFile)
+	if err != nil {
+		return fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer reader.Close()
+
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	log.Printf("📦 Importing %s using COPY (batch size: %d)...", dc.BaseFile, dc.BatchSize)
+	start := time.Now()
+
+	var data strings.Builder
+	var loaded, pending int
+	flush := func() error {
+		if pending == 0 {
+			return nil
+		}
+		_, err := conn.Conn().PgConn().CopyFrom(ctx,
+			strings.NewReader(data.String()),
+			"COPY pgvector_test (id, name, embedding, category, metadata) FROM STDIN")
+		if err != nil {
+			return fmt.Errorf("COPY execution failed: %w", err)
+		}
+		data.Reset()
+		pending = 0
+		return nil
+	}
+
+	for dc.Limit == 0 || loaded < dc.Limit {
+		vector, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s at vector %d: %w", dc.BaseFile, loaded, err)
+		}
+		if len(vector) != w.Dimensions {
+			return fmt.Errorf("dataset %s has %d dimensions but the workload is configured for %d (set the dimensions in the workload name, e.g. pgvector_read_indexed_l2_%d)",
+				dc.BaseFile, len(vector), w.Dimensions, len(vector))
+		}
+
+		data.WriteString(fmt.Sprintf("%d\tdataset_item_%d\t%s\tcategory_%d\t{\"index\": %d}\n",
+			loaded+1, loaded, pgvector.NewVector(vector).String(), loaded%100, loaded))
+		loaded++
+		pending++
+
+		if pending >= dc.BatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+			if loaded%(dc.BatchSize*10) == 0 {
+				log.Printf("⏳ Imported %d vectors...", loaded)
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	// Keep later inserts from colliding with the explicit ids
+	if _, err := conn.Exec(ctx, "SELECT setval(pg_get_serial_sequence('pgvector_test', 'id'), GREATEST(MAX(id), 1)) FROM pgvector_test"); err != nil {
+		return fmt.Errorf("failed to advance id sequence: %w", err)
+	}
+
+	w.BaselineRows = loaded
+	log.Printf("✅ Imported %d vectors in %v", loaded, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// validateDimensions checks that every vector matches the configured dimensions
+func (w *ComprehensivePgVectorWorkload) validateDimensions(vectors [][]float32, source string) error {
+	for i, v := range vectors {
+		if len(v) != w.Dimensions {
+			return fmt.Errorf("%s: vector %d has %d dimensions, expected %d", source, i, len(v), w.Dimensions)
+		}
+	}
+	return nil
+}
+
+// vectorReader streams vectors from a dataset file
+type vectorReader interface {
+	Next() ([]float32, error) // Returns io.EOF after the last vector
+	Close() error
+}
+
+// openVectorReader opens a vector file based on its extension
+func openVectorReader(filename string) (vectorReader, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	buffered := bufio.NewReaderSize(file, 1<<20)
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".fvecs":
+		return &vecsReader{file: file, r: buffered, elemSize: 4, decode: decodeFloat32}, nil
+	case ".bvecs":
+		return &vecsReader{file: file, r: buffered, elemSize: 1, decode: decodeUint8}, nil
+	case ".npy":
+		npy, err := newNpyReader(file, buffered)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		return npy, nil
+	default:
+		file.Close()
+		return nil, fmt.Errorf("unsupported vector file format: %s (supported: .fvecs, .bvecs, .npy)", filename)
+	}
+}
+
+// readAllVectors reads every vector from an fvecs/bvecs/npy file
+func readAllVectors(filename string) ([][]float32, error) {
+	reader, err := openVectorReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var vectors [][]float32
+	for {
+		vector, err := reader.Next()
+		if err == io.EOF {
+			return vectors, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: vector %d: %w", filename, len(vectors), err)
+		}
+		vectors = append(vectors, vector)
+	}
+}
+
+// filterNeighbors drops neighbour ids beyond the first rows base vectors,
+// keeping the distance order. It also counts the queries that lost
+// neighbours and were left with fewer than k, whose remaining neighbours
+// are no longer the exact top-k.
+func filterNeighbors(truth [][]int64, rows, k int) ([][]int64, int) {
+	filtered := make([][]int64, len(truth))
+	incomplete := 0
+	for i, ids := range truth {
+		kept := make([]int64, 0, len(ids))
+		for _, id := range ids {
+			if id >= 1 && id <= int64(rows) {
+				kept = append(kept, id)
+			}
+		}
+		if len(kept) < k && len(kept) < len(ids) {
+			incomplete++
+		}
+		filtered[i] = kept
+	}
+	return filtered, incomplete
+}
+
+// readNeighborVectors reads 0-based neighbour positions from an ivecs or
+// .npy file and converts them to pgvector_test ids
+func readNeighborVectors(filename string) ([][]int64, error) {
+	var rows [][]float64
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".ivecs":
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader := &vecsReader{file: file, r: bufio.NewReader(file), elemSize: 4}
+		for {
+			row, err := reader.nextRaw(decodeInt32)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: row %d: %w", filename, len(rows), err)
+			}
+			rows = append(rows, row)
+		}
+	case ".npy":
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader, err := newNpyReader(file, bufio.NewReader(file))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		for {
+			row, err := reader.nextRaw()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: row %d: %w", filename, len(rows), err)
+			}
+			rows = append(rows, row)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported neighbour file format: %s (supported: .ivecs, .npy, .csv)", filename)
+	}
+
+	neighbors := make([][]int64, len(rows))
+	for i, row := range rows {
+		neighbors[i] = make([]int64, len(row))
+		for j, pos := range row {
+			neighbors[i][j] = int64(pos) + 1
+		}
+	}
+	return neighbors, nil
+}
+
+// vecsReader reads the TEXMEX *vecs formats: each vector is a little-endian
+// int32 dimension followed by that many elements
+type vecsReader struct {
+	file     *os.File
+	r        *bufio.Reader
+	elemSize int
+	decode   func([]byte) float64
+	buf      []byte
+}
+
+func decodeFloat32(b []byte) float64 {
+	return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+}
+func decodeUint8(b []byte) float64 { return float64(b[0]) }
+func decodeInt32(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) }
+
+// Next returns the next vector
+func (v *vecsReader) Next() ([]float32, error) {
+	raw, err := v.nextRaw(v.decode)
+	if err != nil {
+		return nil, err
+	}
+	vector := make([]float32, len(raw))
+	for i, x := range raw {
+		vector[i] = float32(x)
+	}
+	return vector, nil
+}
+
+// nextRaw decodes the next record with the given element decoder
+func (v *vecsReader) nextRaw(decode func([]byte) float64) ([]float64, error) {
+	var dimBuf [4]byte
+	if _, err := io.ReadFull(v.r, dimBuf[:]); err != nil {
+		return nil, err // io.EOF at a record boundary
+	}
+	dim := int(int32(binary.LittleEndian.Uint32(dimBuf[:])))
+	if dim <= 0 || dim > 1<<20 {
+		return nil, fmt.Errorf("invalid dimension %d", dim)
+	}
+
+	size := dim * v.elemSize
+	if cap(v.buf) < size {
+		v.buf = make([]byte, size)
+	}
+	buf := v.buf[:size]
+	if _, err := io.ReadFull(v.r, buf); err != nil {
+		return nil, fmt.Errorf("truncated vector: %w", io.ErrUnexpectedEOF)
+	}
+
+	values := make([]float64, dim)
+	for i := range values {
+		values[i] = decode(buf[i*v.elemSize : (i+1)*v.elemSize])
+	}
+	return values, nil
+}
+
+// Close closes the underlying file
+func (v *vecsReader) Close() error {
+	return v.file.Close()
+}
+
+// npyReader reads 2-D, C-ordered numpy arrays of float32/float64/uint8/int32/int64
+type npyReader struct {
+	file     *os.File
+	r        *bufio.Reader
+	rows     int
+	cols     int
+	row      int
+	elemSize int
+	decode   func([]byte) float64
+	buf      []byte
+}
+
+var (
+	npyDescrPattern = regexp.MustCompile(`'descr':\s*'([^']+)'`)
+	npyOrderPattern = regexp.MustCompile(`'fortran_order':\s*(True|False)`)
+	npyShapePattern = regexp.MustCompile(`'shape':\s*\(([^)]*)\)`)
+)
+
+// newNpyReader parses the .npy header and positions the reader at the data
+func newNpyReader(file *os.File, r *bufio.Reader) (*npyReader, error) {
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, fmt.Errorf("failed to read npy header: %w", err)
+	}
+	if string(magic[:6]) != "\x93NUMPY" {
+		return nil, fmt.Errorf("not a numpy file")
+	}
+
+	var headerLen int
+	switch magic[6] {
+	case 1:
+		var lenBuf [2]byte
+		if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+			return nil, err
+		}
+		headerLen = int(binary.LittleEndian.Uint16(lenBuf[:]))
+	case 2, 3:
+		var lenBuf [4]byte
+		if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+			return nil, err
+		}
+		headerLen = int(binary.LittleEndian.Uint32(lenBuf[:]))
+	default:
+		return nil, fmt.Errorf("unsupported npy version %d", magic[6])
+	}
+
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read npy header: %w", err)
+	}
+
+	descr := npyDescrPattern.FindSubmatch(header)
+	order := npyOrderPattern.FindSubmatch(header)
+	shape := npyShapePattern.FindSubmatch(header)
+	if descr == nil || order == nil || shape == nil {
+		return nil, fmt.Errorf("malformed npy header: %s", header)
+	}
+	if string(order[1]) == "True" {
+		return nil, fmt.Errorf("fortran-ordered arrays are not supported")
+	}
+
+	var dims []int
+	for _, part := range strings.Split(string(shape[1]), ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid npy shape: %s", shape[1])
+		}
+		dims = append(dims, n)
+	}
+	if len(dims) != 2 {
+		return nil, fmt.Errorf("expected a 2-D array, got shape (%s)", shape[1])
+	}
+
+	n := &npyReader{file: file, r: r, rows: dims[0], cols: dims[1]}
+	switch string(descr[1]) {
+	case "<f4":
+		n.elemSize, n.decode = 4, decodeFloat32
+	case "<f8":
+		n.elemSize, n.decode = 8, func(b []byte) float64 { return math.Float64frombits(binary.LittleEndian.Uint64(b)) }
+	case "|u1", "<u1":
+		n.elemSize, n.decode = 1, decodeUint8
+	case "<i4":
+		n.elemSize, n.decode = 4, decodeInt32
+	case "<i8":
+		n.elemSize, n.decode = 8, func(b []byte) float64 { return float64(int64(binary.LittleEndian.Uint64(b))) }
+	default:
+		return nil, fmt.Errorf("unsupported npy dtype %s", descr[1])
+	}
+	return n, nil
+}
+
+// Next returns the next row as a vector
+func (n *npyReader) Next() ([]float32, error) {
+	raw, err := n.nextRaw()
+	if err != nil {
+		return nil, err
+	}
+	vector := make([]float32, len(raw))
+	for i, x := range raw {
+		vector[i] = float32(x)
+	}
+	return vector, nil
+}
+
+// nextRaw decodes the next row
+func (n *npyReader) nextRaw() ([]float64, error) {
+	if n.row >= n.rows {
+		return nil, io.EOF
+	}
+
+	size := n.cols * n.elemSize
+	if cap(n.buf) < size {
+		n.buf = make([]byte, size)
+	}
+	buf := n.buf[:size]
+	if _, err := io.ReadFull(n.r, buf); err != nil {
+		return nil, fmt.Errorf("truncated array: %w", io.ErrUnexpectedEOF)
+	}
+	n.row++
+
+	values := make([]float64, n.cols)
+	for i := range values {
+		values[i] = n.decode(buf[i*n.elemSize : (i+1)*n.elemSize])
+	}
+	return values, nil
+}
+
+// Close closes the underlying file
+func (n *npyReader) Close() error {
+	return n.file.Close()
+}
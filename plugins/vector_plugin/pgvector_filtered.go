@@ -0,0 +1,417 @@
+// plugins/vector_plugin/pgvector_filtered.go
+// Filtered and hybrid (vector + full-text) search testing
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/elchinoo/stormdb/pkg/types"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pgvector/pgvector-go"
+	"github.com/spf13/viper"
+)
+
+// Search modes of the filtered test
+const (
+	searchModeVector = "vector" // ORDER BY distance with a metadata predicate
+	searchModeHybrid = "hybrid" // Reciprocal rank fusion of vector and full-text ranking
+)
+
+// rrfK is the reciprocal rank fusion constant
+const rrfK = 60
+
+// contentVocabulary is the word list used to generate searchable row content
+var contentVocabulary = []string{
+	"database", "vector", "search", "index", "query", "latency", "replica", "storage",
+	"cluster", "backup", "schema", "tenant", "billing", "invoice", "customer", "order",
+	"shipping", "payment", "refund", "account", "security", "password", "network", "timeout",
+	"memory", "cache", "disk", "migration", "upgrade", "release", "feature", "bug",
+	"report", "dashboard", "metric", "alert", "incident", "outage", "deploy", "rollback",
+	"python", "golang", "postgres", "kubernetes", "docker", "linux", "cloud", "region",
+	"pricing", "support",
+}
+
+// FilteredConfig controls the filtered search test, read from workload_config.filtered
+type FilteredConfig struct {
+	Column         string    // "tenant" (default) or "category"
+	Tenants        int       // Number of distinct tenants (default 1000)
+	Selectivities  []float64 // Fractions of rows matching the filter
+	Queries        int       // Queries per bucket (default 100)
+	K              int       // Neighbours per query (default 10)
+	Hybrid         bool      // Also run hybrid vector + full-text queries
+	Index          string    // Index configuration to build (default by index type)
+	IterativeScans []string  // pgvector >= 0.8 iterative scan settings to sweep
+}
+
+// parseFilteredConfig reads workload_config.filtered from the loaded configuration
+func parseFilteredConfig() *FilteredConfig {
+	fc := &FilteredConfig{
+		Column:        "tenant",
+		Tenants:       1000,
+		Selectivities: []float64{0.001, 0.01, 0.1, 0.5},
+		Queries:       100,
+		K:             10,
+		Hybrid:        true,
+	}
+	if !viper.IsSet("workload_config.filtered") {
+		return fc
+	}
+
+	if column := viper.GetString("workload_config.filtered.column"); column != "" {
+		fc.Column = column
+	}
+	if tenants := viper.GetInt("workload_config.filtered.tenants"); tenants > 0 {
+		fc.Tenants = tenants
+	}
+	if raw := viper.GetStringSlice("workload_config.filtered.selectivities"); len(raw) > 0 {
+		fc.Selectivities = nil
+		for _, s := range raw {
+			var v float64
+			if _, err := fmt.Sscanf(s, "%g", &v); err == nil && v > 0 && v <= 1 {
+				fc.Selectivities = append(fc.Selectivities, v)
+			}
+		}
+	}
+	if queries := viper.GetInt("workload_config.filtered.queries"); queries > 0 {
+		fc.Queries = queries
+	}
+	if k := viper.GetInt("workload_config.filtered.k"); k > 0 {
+		fc.K = k
+	}
+	if viper.IsSet("workload_config.filtered.hybrid") {
+		fc.Hybrid = viper.GetBool("workload_config.filtered.hybrid")
+	}
+	fc.Index = viper.GetString("workload_config.filtered.index")
+	fc.IterativeScans = viper.GetStringSlice("workload_config.filtered.iterative_scan")
+	return fc
+}
+
+// filteredQuery is one generated query with its predicate argument
+type filteredQuery struct {
+	vector []float32
+	filter interface{} // tenant threshold or category list
+	term   string      // full-text term for hybrid queries
+}
+
+// runFilteredTest measures filtered and hybrid search latency and recall
+// per selectivity bucket and iterative scan setting
+func (w *ComprehensivePgVectorWorkload) runFilteredTest(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics) error {
+	fc := parseFilteredConfig()
+	if fc.Column != "tenant" && fc.Column != "category" {
+		return fmt.Errorf("invalid filtered column: %s (valid: tenant, category)", fc.Column)
+	}
+	if len(fc.Selectivities) == 0 {
+		return fmt.Errorf("no valid filtered selectivities (must be between 0 and 1)")
+	}
+
+	if err := w.prepareFilterColumns(ctx, db, fc); err != nil {
+		return err
+	}
+	if len(w.PreloadedData) == 0 {
+		if err := w.loadPrecomputedVectors(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Build the ANN index the filtered queries run against
+	if w.IndexType != "none" {
+		ic, err := w.filteredIndexConfiguration(fc)
+		if err != nil {
+			return err
+		}
+		log.Printf("🏗️  Building %s (%s)...", ic.Name, ic.Description)
+		buildTime, err := w.buildIndex(ctx, db, ic, nil)
+		if err != nil {
+			return err
+		}
+		log.Printf("✅ Built %s in %v", ic.Name, buildTime.Round(time.Millisecond))
+		defer func() {
+			if _, err := db.Exec(context.Background(), "DROP INDEX IF EXISTS "+benchmarkIndexName); err != nil {
+				log.Printf("⚠️  Failed to drop benchmark index: %v", err)
+			}
+		}()
+	}
+
+	iterativeParam, iterativeModes := w.iterativeScanSettings(ctx, db, fc)
+	modes := []string{searchModeVector}
+	if fc.Hybrid {
+		modes = append(modes, searchModeHybrid)
+	}
+	column := "tenant_id"
+	if fc.Column == "category" {
+		column = "category"
+	}
+
+	log.Printf("🔎 Filtered search test: %s filter at %s selectivity, modes %v, iterative scan %v",
+		column, describeSelectivities(fc.Selectivities), modes, iterativeModes)
+
+	rng := rand.New(rand.NewSource(42)) // Fixed seed so runs use the same queries
+	for _, selectivity := range fc.Selectivities {
+		for _, mode := range modes {
+			if ctx.Err() != nil {
+				log.Printf("⏱️  Duration reached, stopping filtered search test")
+				return nil
+			}
+
+			filter, actual := w.filterArgument(fc, selectivity)
+			queries := make([]filteredQuery, fc.Queries)
+			for i := range queries {
+				queries[i] = filteredQuery{
+					vector: w.PreloadedData[rng.Intn(len(w.PreloadedData))],
+					filter: filter,
+					term:   contentVocabulary[rng.Intn(len(contentVocabulary))],
+				}
+			}
+			sql := w.filteredSQL(mode, column, fc.K)
+
+			truth, err := w.filteredGroundTruth(ctx, db, sql, queries, mode)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+
+			for _, iterative := range iterativeModes {
+				var settings []string
+				if iterativeParam != "" {
+					settings = append(settings, fmt.Sprintf("%s = %s", iterativeParam, iterative))
+				}
+
+				result := w.measureFilteredQueries(ctx, db, cfg, metrics, sql, queries, truth, mode, fc.K, settings)
+				if ctx.Err() != nil {
+					return nil
+				}
+				result.FilterColumn = column
+				result.Selectivity = actual
+				result.IterativeScan = iterative
+				metrics.RecordFilteredSearch(result)
+
+				log.Printf("   %s %.2f%% iterative=%s: recall@%d %.1f%%, avg %.2fms, %d short",
+					mode, actual*100, iterative, fc.K, result.Recall*100, result.AvgLatencyMs, result.ShortResults)
+			}
+		}
+	}
+
+	log.Printf("✅ Filtered search test completed")
+	return nil
+}
+
+// prepareFilterColumns adds and populates the tenant and full-text columns
+// used by filtered queries, with indexes supporting the predicates
+func (w *ComprehensivePgVectorWorkload) prepareFilterColumns(ctx context.Context, db *pgxpool.Pool, fc *FilteredConfig) error {
+	statements := []string{
+		`ALTER TABLE pgvector_test
+			ADD COLUMN IF NOT EXISTS tenant_id INTEGER,
+			ADD COLUMN IF NOT EXISTS content TEXT,
+			ADD COLUMN IF NOT EXISTS content_tsv TSVECTOR
+				GENERATED ALWAYS AS (to_tsvector('english', COALESCE(content, ''))) STORED`,
+		"CREATE INDEX IF NOT EXISTS pgvector_test_tenant_idx ON pgvector_test (tenant_id)",
+		"CREATE INDEX IF NOT EXISTS pgvector_test_category_idx ON pgvector_test (category)",
+		"CREATE INDEX IF NOT EXISTS pgvector_test_content_idx ON pgvector_test USING GIN (content_tsv)",
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to prepare filter columns: %w", err)
+		}
+	}
+
+	// Tenants are spread uniformly over ids; content is 8 words chosen by hash
+	start := time.Now()
+	tag, err := db.Exec(ctx, `
+		UPDATE pgvector_test SET
+			tenant_id = ((id * 7919) % $1) + 1,
+			content = (
+				SELECT string_agg(($2::text[])[1 + (((hashint8(id * 8 + i)::bigint % $3) + $3) % $3)], ' ')
+				FROM generate_series(1, 8) AS i
+			)
+		WHERE tenant_id IS NULL OR content IS NULL OR tenant_id > $1`,
+		fc.Tenants, contentVocabulary, len(contentVocabulary))
+	if err != nil {
+		return fmt.Errorf("failed to populate filter columns: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		log.Printf("📋 Populated tenant and content columns for %d rows in %v",
+			tag.RowsAffected(), time.Since(start).Round(time.Millisecond))
+		if _, err := db.Exec(ctx, "ANALYZE pgvector_test"); err != nil {
+			log.Printf("⚠️  Failed to analyze pgvector_test: %v", err)
+		}
+	}
+	return nil
+}
+
+// filteredIndexConfiguration picks the ANN index to build for filtered queries
+func (w *ComprehensivePgVectorWorkload) filteredIndexConfiguration(fc *FilteredConfig) (IndexConfiguration, error) {
+	name := fc.Index
+	if name == "" {
+		name = "ivfflat_lists_100"
+		if w.IndexType == "hnsw" {
+			name = "hnsw_m_16_ef_64"
+		}
+	}
+	for _, ic := range w.getIndexConfigurations() {
+		if ic.Name == name {
+			return ic, nil
+		}
+	}
+	return IndexConfiguration{}, fmt.Errorf("unknown index configuration: %s", name)
+}
+
+// iterativeScanSettings returns the iterative scan parameter for the index
+// type and the values to sweep; pgvector added iterative scans in 0.8.0
+func (w *ComprehensivePgVectorWorkload) iterativeScanSettings(ctx context.Context, db *pgxpool.Pool, fc *FilteredConfig) (string, []string) {
+	if w.IndexType == "none" {
+		return "", []string{""}
+	}
+
+	var version string
+	if err := db.QueryRow(ctx, "SELECT extversion FROM pg_extension WHERE extname = 'vector'").Scan(&version); err != nil || !versionAtLeast(version, 0, 8) {
+		log.Printf("ℹ️  pgvector %s does not support iterative index scans, using default scans", version)
+		return "", []string{""}
+	}
+
+	param := "ivfflat.iterative_scan"
+	modes := []string{"off", "relaxed_order"}
+	if w.IndexType == "hnsw" {
+		param = "hnsw.iterative_scan"
+		modes = append(modes, "strict_order")
+	}
+	if len(fc.IterativeScans) > 0 {
+		modes = fc.IterativeScans
+	}
+	return param, modes
+}
+
+// versionAtLeast compares a "major.minor[.patch]" version string
+func versionAtLeast(version string, major, minor int) bool {
+	var vMajor, vMinor int
+	if _, err := fmt.Sscanf(version, "%d.%d", &vMajor, &vMinor); err != nil {
+		return false
+	}
+	return vMajor > major || (vMajor == major && vMinor >= minor)
+}
+
+// filterArgument returns the predicate argument for the requested
+// selectivity and the selectivity it actually achieves
+func (w *ComprehensivePgVectorWorkload) filterArgument(fc *FilteredConfig, selectivity float64) (interface{}, float64) {
+	if fc.Column == "category" {
+		// Rows are spread over 100 categories
+		n := int(math.Max(1, math.Round(selectivity*100)))
+		categories := make([]string, n)
+		for i := range categories {
+			categories[i] = fmt.Sprintf("category_%d", i)
+		}
+		return categories, float64(n) / 100
+	}
+
+	threshold := int(math.Max(1, math.Round(selectivity*float64(fc.Tenants))))
+	return threshold, float64(threshold) / float64(fc.Tenants)
+}
+
+// filteredSQL builds the query for a search mode. $1 is the query vector,
+// $2 the filter argument and $3 the full-text term.
+func (w *ComprehensivePgVectorWorkload) filteredSQL(mode, column string, k int) string {
+	predicate := "tenant_id <= $2"
+	if column == "category" {
+		predicate = "category = ANY($2)"
+	}
+	op := w.distanceOperator()
+
+	if mode == searchModeVector {
+		return fmt.Sprintf("SELECT id FROM pgvector_test WHERE %s ORDER BY embedding %s $1 LIMIT %d",
+			predicate, op, k)
+	}
+
+	// Each ranking contributes 1/(rrfK + rank); candidates are over-fetched
+	candidates := k * 4
+	return fmt.Sprintf(`
+		WITH semantic AS (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY embedding %[2]s $1) AS rank
+			FROM (SELECT id, embedding FROM pgvector_test WHERE %[1]s ORDER BY embedding %[2]s $1 LIMIT %[3]d) s
+		),
+		keyword AS (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY score DESC, id) AS rank
+			FROM (
+				SELECT id, ts_rank_cd(content_tsv, q) AS score
+				FROM pgvector_test, plainto_tsquery('english', $3) q
+				WHERE content_tsv @@ q AND %[1]s
+				ORDER BY score DESC, id LIMIT %[3]d
+			) k
+		)
+		SELECT COALESCE(s.id, k.id) AS id
+		FROM semantic s FULL OUTER JOIN keyword k ON s.id = k.id
+		ORDER BY COALESCE(1.0 / (%[5]d + s.rank), 0) + COALESCE(1.0 / (%[5]d + k.rank), 0) DESC, 1
+		LIMIT %[4]d`, predicate, op, candidates, k, rrfK)
+}
+
+// filteredArgs returns the query arguments for a mode
+func filteredArgs(mode string, q filteredQuery) []interface{} {
+	if mode == searchModeHybrid {
+		return []interface{}{pgvector.NewVector(q.vector), q.filter, q.term}
+	}
+	return []interface{}{pgvector.NewVector(q.vector), q.filter}
+}
+
+// filteredGroundTruth runs every query exactly, with index scans disabled so
+// the ANN index cannot drop rows that match the filter
+func (w *ComprehensivePgVectorWorkload) filteredGroundTruth(ctx context.Context, db *pgxpool.Pool, sql string, queries []filteredQuery, mode string) ([][]int64, error) {
+	truth := make([][]int64, len(queries))
+	err := pgx.BeginFunc(ctx, db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "SET LOCAL enable_indexscan = off"); err != nil {
+			return err
+		}
+		for i, q := range queries {
+			rows, err := tx.Query(ctx, sql, filteredArgs(mode, q)...)
+			if err != nil {
+				return err
+			}
+			if truth[i], err = pgx.CollectRows(rows, pgx.RowTo[int64]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute filtered ground truth: %w", err)
+	}
+	return truth, nil
+}
+
+// measureFilteredQueries runs the queries over cfg.Workers connections with
+// the given session settings and compares the results with the ground truth
+func (w *ComprehensivePgVectorWorkload) measureFilteredQueries(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics,
+	sql string, queries []filteredQuery, truth [][]int64, mode string, k int, settings []string) types.FilteredSearchResult {
+
+	stats := measureQueries(ctx, db, cfg, metrics, sql, len(queries), func(i int) []interface{} {
+		return filteredArgs(mode, queries[i])
+	}, truth, k, settings)
+
+	return types.FilteredSearchResult{
+		Mode:         mode,
+		K:            k,
+		Queries:      len(queries),
+		Recall:       stats.Recall,
+		QPS:          stats.QPS,
+		AvgLatencyMs: stats.AvgLatencyMs,
+		P95LatencyMs: stats.P95LatencyMs,
+		ShortResults: stats.ShortResults,
+		Errors:       stats.Errors,
+	}
+}
+
+// describeSelectivities formats the buckets for logging
+func describeSelectivities(values []float64) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("%g%%", v*100)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,229 @@
+// plugins/vector_plugin/pgvector_index.go
+// Index build benchmarking across IVFFlat/HNSW configurations
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/elchinoo/stormdb/pkg/types"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/viper"
+)
+
+// benchmarkIndexName is the index rebuilt for every configuration under test
+const benchmarkIndexName = "pgvector_benchmark_idx"
+
+// IndexBuildConfig controls the index build benchmark, read from
+// workload_config.index_build
+type IndexBuildConfig struct {
+	MaintenanceWorkMem string   // maintenance_work_mem for builds (default: server setting)
+	ParallelWorkers    int      // max_parallel_maintenance_workers (default: server setting)
+	Indexes            []string // Index configuration names to build (default: all of IndexType)
+	K                  int      // Neighbours per post-build query (default 10)
+	Queries            int      // Post-build queries, 0 disables them (default 100)
+}
+
+// parseIndexBuildConfig reads workload_config.index_build from the loaded configuration
+func parseIndexBuildConfig() *IndexBuildConfig {
+	ib := &IndexBuildConfig{
+		ParallelWorkers: -1,
+		K:               10,
+		Queries:         100,
+	}
+	if !viper.IsSet("workload_config.index_build") {
+		return ib
+	}
+
+	ib.MaintenanceWorkMem = viper.GetString("workload_config.index_build.maintenance_work_mem")
+	if viper.IsSet("workload_config.index_build.parallel_workers") {
+		ib.ParallelWorkers = viper.GetInt("workload_config.index_build.parallel_workers")
+	}
+	ib.Indexes = viper.GetStringSlice("workload_config.index_build.indexes")
+	if k := viper.GetInt("workload_config.index_build.k"); k > 0 {
+		ib.K = k
+	}
+	if viper.IsSet("workload_config.index_build.queries") {
+		ib.Queries = viper.GetInt("workload_config.index_build.queries")
+	}
+	return ib
+}
+
+// runIndexBuildTest drops and rebuilds the index for each configuration,
+// recording build time, index size, and post-build latency and recall
+func (w *ComprehensivePgVectorWorkload) runIndexBuildTest(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics) error {
+	ib := parseIndexBuildConfig()
+
+	configs := w.accuracyIndexConfigurations(&AccuracyConfig{Indexes: ib.Indexes})
+	if len(configs) == 0 {
+		return fmt.Errorf("no index configurations to test for index type %s", w.IndexType)
+	}
+
+	var settings []string
+	if ib.MaintenanceWorkMem != "" {
+		settings = append(settings, fmt.Sprintf("maintenance_work_mem = '%s'", ib.MaintenanceWorkMem))
+	}
+	if ib.ParallelWorkers >= 0 {
+		settings = append(settings, fmt.Sprintf("max_parallel_maintenance_workers = %d", ib.ParallelWorkers))
+	}
+	workMem, parallelWorkers, err := w.effectiveBuildSettings(ctx, db, settings)
+	if err != nil {
+		return err
+	}
+	log.Printf("🏗️  Index build test: %d configurations, maintenance_work_mem=%s, parallel workers=%d",
+		len(configs), workMem, parallelWorkers)
+
+	// Exact neighbours are computed before any index exists
+	var queries [][]float32
+	var truth [][]int64
+	if ib.Queries > 0 {
+		queries, truth, _, err = w.loadAccuracyQueries(ctx, db, (&AccuracyConfig{K: ib.K, Queries: ib.Queries}).withDatasetDefaults(parseDatasetConfig()))
+		if err != nil {
+			return err
+		}
+	}
+
+	defer func() {
+		if _, err := db.Exec(context.Background(), "DROP INDEX IF EXISTS "+benchmarkIndexName); err != nil {
+			log.Printf("⚠️  Failed to drop benchmark index: %v", err)
+		}
+	}()
+
+	for i, ic := range configs {
+		if ctx.Err() != nil {
+			log.Printf("⏱️  Duration reached, skipping %d remaining index configuration(s)", len(configs)-i)
+			break
+		}
+
+		params, _ := indexParameters(ic)
+		result := types.IndexBuildResult{
+			Index:              ic.Name,
+			IndexType:          ic.IndexType,
+			Parameters:         params,
+			MaintenanceWorkMem: workMem,
+			ParallelWorkers:    parallelWorkers,
+		}
+
+		log.Printf("🏗️  Building %s (%s)...", ic.Name, ic.Description)
+		result.BuildTime, err = w.buildIndex(ctx, db, ic, settings)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Printf("⚠️  Build of %s failed: %v", ic.Name, err)
+			result.Error = err.Error()
+			metrics.RecordIndexBuild(result)
+			continue
+		}
+
+		if err := db.QueryRow(ctx, "SELECT pg_relation_size($1::regclass)", benchmarkIndexName).Scan(&result.SizeBytes); err != nil {
+			log.Printf("⚠️  Failed to read size of %s: %v", ic.Name, err)
+		}
+
+		if len(queries) > 0 {
+			measured := w.measureRecall(ctx, db, cfg, metrics, queries, truth, ib.K, "", 0)
+			if ctx.Err() != nil {
+				break
+			}
+			result.K = measured.K
+			result.Queries = measured.Queries
+			result.Recall = measured.Recall
+			result.QPS = measured.QPS
+			result.AvgLatencyMs = measured.AvgLatencyMs
+			result.P95LatencyMs = measured.P95LatencyMs
+		}
+		metrics.RecordIndexBuild(result)
+
+		log.Printf("✅ Built %s in %v (%.1f MB), recall@%d %.1f%%, avg %.2fms",
+			ic.Name, result.BuildTime.Round(time.Millisecond), float64(result.SizeBytes)/(1024*1024),
+			ib.K, result.Recall*100, result.AvgLatencyMs)
+	}
+
+	log.Printf("✅ Index build test completed")
+	return nil
+}
+
+// effectiveBuildSettings applies the build settings on a connection and
+// reports the resulting maintenance_work_mem and parallel worker count
+func (w *ComprehensivePgVectorWorkload) effectiveBuildSettings(ctx context.Context, db *pgxpool.Pool, settings []string) (string, int, error) {
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	defer func() { _, _ = conn.Exec(context.Background(), "RESET ALL") }()
+	for _, setting := range settings {
+		if _, err := conn.Exec(ctx, "SET "+setting); err != nil {
+			return "", 0, fmt.Errorf("invalid index build setting %q: %w", setting, err)
+		}
+	}
+
+	var workMem, parallel string
+	if err := conn.QueryRow(ctx, "SHOW maintenance_work_mem").Scan(&workMem); err != nil {
+		return "", 0, fmt.Errorf("failed to read maintenance_work_mem: %w", err)
+	}
+	if err := conn.QueryRow(ctx, "SHOW max_parallel_maintenance_workers").Scan(&parallel); err != nil {
+		return "", 0, fmt.Errorf("failed to read max_parallel_maintenance_workers: %w", err)
+	}
+	parallelWorkers, _ := strconv.Atoi(parallel)
+	return workMem, parallelWorkers, nil
+}
+
+// indexParameters returns the WITH (...) clause contents for a configuration
+func indexParameters(ic IndexConfiguration) (string, error) {
+	switch ic.IndexType {
+	case "ivfflat":
+		return fmt.Sprintf("lists = %v", ic.Parameters["lists"]), nil
+	case "hnsw":
+		return fmt.Sprintf("m = %v, ef_construction = %v", ic.Parameters["m"], ic.Parameters["ef_construction"]), nil
+	default:
+		return "", fmt.Errorf("unsupported index type: %s", ic.IndexType)
+	}
+}
+
+// buildIndex replaces the benchmark index with the given configuration,
+// applying the session settings (e.g., maintenance_work_mem) for the build
+func (w *ComprehensivePgVectorWorkload) buildIndex(ctx context.Context, db *pgxpool.Pool, ic IndexConfiguration, settings []string) (time.Duration, error) {
+	return w.buildIndexOn(ctx, db, ic, "embedding", ic.Ops, settings)
+}
+
+// buildIndexOn replaces the benchmark index with the given configuration on
+// another column or operator class (e.g., a halfvec or bit column)
+func (w *ComprehensivePgVectorWorkload) buildIndexOn(ctx context.Context, db *pgxpool.Pool, ic IndexConfiguration, column, ops string, settings []string) (time.Duration, error) {
+	params, err := indexParameters(ic)
+	if err != nil {
+		return 0, err
+	}
+
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "DROP INDEX IF EXISTS "+benchmarkIndexName); err != nil {
+		return 0, fmt.Errorf("failed to drop previous index: %w", err)
+	}
+
+	if len(settings) > 0 {
+		defer func() { _, _ = conn.Exec(context.Background(), "RESET ALL") }()
+		for _, setting := range settings {
+			if _, err := conn.Exec(ctx, "SET "+setting); err != nil {
+				return 0, fmt.Errorf("failed to apply %q: %w", setting, err)
+			}
+		}
+	}
+
+	start := time.Now()
+	_, err = conn.Exec(ctx, fmt.Sprintf("CREATE INDEX %s ON pgvector_test USING %s (%s %s) WITH (%s)",
+		benchmarkIndexName, ic.IndexType, column, ops, params))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build index: %w", err)
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,353 @@
+// plugins/vector_plugin/pgvector_quantization.go
+// halfvec, sparsevec and binary quantization comparison
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/elchinoo/stormdb/pkg/types"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pgvector/pgvector-go"
+	"github.com/spf13/viper"
+)
+
+// Quantization variants
+const (
+	variantVector     = "vector"      // Full-precision float32 baseline
+	variantHalfvec    = "halfvec"     // float16 copy of the embedding
+	variantSparsevec  = "sparsevec"   // Largest-magnitude dimensions only
+	variantBitHamming = "bit_hamming" // Binary quantization, Hamming distance
+	variantBitJaccard = "bit_jaccard" // Binary quantization, Jaccard distance
+	variantBitRerank  = "bit_rerank"  // Hamming candidates re-ranked with full precision
+)
+
+// quantizationColumns are the columns added for the quantized copies of embedding
+var quantizationColumns = []string{"embedding_half", "embedding_sparse", "embedding_bit"}
+
+// QuantizationConfig controls the quantization comparison, read from
+// workload_config.quantization
+type QuantizationConfig struct {
+	Variants      []string // Variants to compare (default: all)
+	K             int      // Neighbours per query (default 10)
+	Queries       int      // Number of query vectors (default 100)
+	RerankFactor  int      // bit_rerank fetches K * RerankFactor candidates (default 4)
+	SparseNonZero int      // Dimensions kept per sparsevec (default min(dimensions, 256))
+	Index         string   // Index configuration to build (default by index type)
+	EfSearch      int      // hnsw.ef_search for queries (0 = server setting)
+	Probes        int      // ivfflat.probes for queries (0 = server setting)
+}
+
+// parseQuantizationConfig reads workload_config.quantization from the loaded configuration
+func parseQuantizationConfig(dimensions int) *QuantizationConfig {
+	qc := &QuantizationConfig{
+		Variants: []string{variantVector, variantHalfvec, variantSparsevec,
+			variantBitHamming, variantBitJaccard, variantBitRerank},
+		K:             10,
+		Queries:       100,
+		RerankFactor:  4,
+		SparseNonZero: 256,
+	}
+	if dimensions < qc.SparseNonZero {
+		qc.SparseNonZero = dimensions
+	}
+	if !viper.IsSet("workload_config.quantization") {
+		return qc
+	}
+
+	if variants := viper.GetStringSlice("workload_config.quantization.variants"); len(variants) > 0 {
+		qc.Variants = variants
+	}
+	if k := viper.GetInt("workload_config.quantization.k"); k > 0 {
+		qc.K = k
+	}
+	if queries := viper.GetInt("workload_config.quantization.queries"); queries > 0 {
+		qc.Queries = queries
+	}
+	if factor := viper.GetInt("workload_config.quantization.rerank_factor"); factor > 0 {
+		qc.RerankFactor = factor
+	}
+	if nonZero := viper.GetInt("workload_config.quantization.sparse_nonzero"); nonZero > 0 && nonZero <= dimensions {
+		qc.SparseNonZero = nonZero
+	}
+	qc.Index = viper.GetString("workload_config.quantization.index")
+	qc.EfSearch = viper.GetInt("workload_config.quantization.ef_search")
+	qc.Probes = viper.GetInt("workload_config.quantization.probes")
+	return qc
+}
+
+// quantizationVariant describes how one variant is stored, indexed and queried
+type quantizationVariant struct {
+	Name     string
+	Storage  string // Column type
+	Column   string // Column holding the variant's values
+	Ops      string // Index operator class
+	Operator string // Distance operator
+	Query    string // Query returning the ids of the k nearest rows to $1
+	HNSWOnly bool   // No IVFFlat operator class exists
+}
+
+// quantizationVariant returns the definition of a named variant
+func (w *ComprehensivePgVectorWorkload) quantizationVariant(name string, qc *QuantizationConfig) (quantizationVariant, error) {
+	suffix := "l2_ops"
+	switch w.SimilarityMetric {
+	case "cosine":
+		suffix = "cosine_ops"
+	case "inner_product":
+		suffix = "ip_ops"
+	}
+	op := w.distanceOperator()
+	knn := func(column, operator, arg string) string {
+		return fmt.Sprintf("SELECT id FROM pgvector_test ORDER BY %s %s %s LIMIT %d", column, operator, arg, qc.K)
+	}
+
+	switch name {
+	case variantVector:
+		return quantizationVariant{Name: name, Storage: "vector", Column: "embedding", Ops: "vector_" + suffix,
+			Operator: op, Query: knn("embedding", op, "$1")}, nil
+	case variantHalfvec:
+		return quantizationVariant{Name: name, Storage: "halfvec", Column: "embedding_half", Ops: "halfvec_" + suffix,
+			Operator: op, Query: knn("embedding_half", op, fmt.Sprintf("$1::vector::halfvec(%d)", w.Dimensions))}, nil
+	case variantSparsevec:
+		return quantizationVariant{Name: name, Storage: "sparsevec", Column: "embedding_sparse", Ops: "sparsevec_" + suffix,
+			Operator: op, Query: knn("embedding_sparse", op, fmt.Sprintf("$1::vector::sparsevec(%d)", w.Dimensions)), HNSWOnly: true}, nil
+	case variantBitHamming:
+		return quantizationVariant{Name: name, Storage: "bit", Column: "embedding_bit", Ops: "bit_hamming_ops",
+			Operator: "<~>", Query: knn("embedding_bit", "<~>", "binary_quantize($1::vector)")}, nil
+	case variantBitJaccard:
+		return quantizationVariant{Name: name, Storage: "bit", Column: "embedding_bit", Ops: "bit_jaccard_ops",
+			Operator: "<%>", Query: knn("embedding_bit", "<%>", "binary_quantize($1::vector)"), HNSWOnly: true}, nil
+	case variantBitRerank:
+		// Hamming distance selects candidates, full precision orders them
+		query := fmt.Sprintf(`
+			SELECT id FROM (
+				SELECT id, embedding FROM pgvector_test
+				ORDER BY embedding_bit <~> binary_quantize($1::vector) LIMIT %d
+			) candidates
+			ORDER BY embedding %s $1 LIMIT %d`, qc.K*qc.RerankFactor, op, qc.K)
+		return quantizationVariant{Name: name, Storage: "bit", Column: "embedding_bit", Ops: "bit_hamming_ops",
+			Operator: "<~>", Query: query}, nil
+	default:
+		return quantizationVariant{}, fmt.Errorf("unknown quantization variant: %s (valid: vector, halfvec, sparsevec, bit_hamming, bit_jaccard, bit_rerank)", name)
+	}
+}
+
+// runQuantizationTest compares storage size, index build time, recall and
+// QPS of the full-precision embedding against its quantized copies
+func (w *ComprehensivePgVectorWorkload) runQuantizationTest(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics) error {
+	qc := parseQuantizationConfig(w.Dimensions)
+
+	variants := make([]quantizationVariant, 0, len(qc.Variants))
+	for _, name := range qc.Variants {
+		v, err := w.quantizationVariant(name, qc)
+		if err != nil {
+			return err
+		}
+		variants = append(variants, v)
+	}
+
+	var ic IndexConfiguration
+	if w.IndexType != "none" {
+		var err error
+		if ic, err = w.quantizationIndexConfiguration(qc); err != nil {
+			return err
+		}
+	}
+
+	// halfvec, sparsevec, bit operator classes and binary_quantize need pgvector 0.7
+	var version string
+	if err := db.QueryRow(ctx, "SELECT extversion FROM pg_extension WHERE extname = 'vector'").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read pgvector version: %w", err)
+	}
+	quantizationSupported := versionAtLeast(version, 0, 7)
+
+	// Exact neighbours come from the full-precision column before any index exists
+	queries, truth, _, err := w.loadAccuracyQueries(ctx, db, (&AccuracyConfig{K: qc.K, Queries: qc.Queries}).withDatasetDefaults(parseDatasetConfig()))
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if _, err := db.Exec(context.Background(), "DROP INDEX IF EXISTS "+benchmarkIndexName); err != nil {
+			log.Printf("⚠️  Failed to drop benchmark index: %v", err)
+		}
+		w.dropQuantizationColumns(context.Background(), db)
+	}()
+	w.dropQuantizationColumns(ctx, db)
+
+	var settings []string
+	switch {
+	case ic.IndexType == "hnsw" && qc.EfSearch > 0:
+		settings = append(settings, fmt.Sprintf("hnsw.ef_search = %d", qc.EfSearch))
+	case ic.IndexType == "ivfflat" && qc.Probes > 0:
+		settings = append(settings, fmt.Sprintf("ivfflat.probes = %d", qc.Probes))
+	}
+
+	indexName := ic.Name
+	if indexName == "" {
+		indexName = "none"
+	}
+	log.Printf("🗜️  Quantization test: %d variants, recall@%d over %d queries, index %s", len(variants), qc.K, len(queries), indexName)
+
+	var builtColumn, builtOps string
+	var buildTime time.Duration
+	for i, v := range variants {
+		if ctx.Err() != nil {
+			log.Printf("⏱️  Duration reached, skipping %d remaining variant(s)", len(variants)-i)
+			break
+		}
+
+		result := types.QuantizationResult{
+			Variant:  v.Name,
+			Storage:  v.Storage,
+			Distance: v.Operator,
+			Index:    indexName,
+			K:        qc.K,
+			Queries:  len(queries),
+		}
+
+		switch {
+		case v.Name != variantVector && !quantizationSupported:
+			result.Error = fmt.Sprintf("requires pgvector 0.7.0 or later (installed %s)", version)
+		case v.HNSWOnly && ic.IndexType == "ivfflat":
+			result.Error = fmt.Sprintf("%s %s has no ivfflat support, use an hnsw index", v.Storage, v.Operator)
+		}
+		if result.Error != "" {
+			log.Printf("⚠️  Skipping %s: %s", v.Name, result.Error)
+			metrics.RecordQuantization(result)
+			continue
+		}
+
+		if err := w.prepareQuantizedColumn(ctx, db, v.Column, qc); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Printf("⚠️  Skipping %s: %v", v.Name, err)
+			result.Error = err.Error()
+			metrics.RecordQuantization(result)
+			continue
+		}
+		if err := db.QueryRow(ctx, fmt.Sprintf("SELECT COALESCE(SUM(pg_column_size(%s)), 0) FROM pgvector_test", v.Column)).Scan(&result.StorageBytes); err != nil {
+			log.Printf("⚠️  Failed to read storage size of %s: %v", v.Column, err)
+		}
+
+		if ic.IndexType != "" {
+			// Variants sharing a column and operator class reuse the index
+			if v.Column != builtColumn || v.Ops != builtOps {
+				log.Printf("🏗️  Building %s on %s (%s)...", ic.Name, v.Column, v.Ops)
+				if buildTime, err = w.buildIndexOn(ctx, db, ic, v.Column, v.Ops, nil); err != nil {
+					builtColumn, builtOps = "", ""
+					if ctx.Err() != nil {
+						break
+					}
+					log.Printf("⚠️  Build for %s failed: %v", v.Name, err)
+					result.Error = err.Error()
+					metrics.RecordQuantization(result)
+					continue
+				}
+				builtColumn, builtOps = v.Column, v.Ops
+			}
+			result.BuildTime = buildTime
+			if err := db.QueryRow(ctx, "SELECT pg_relation_size($1::regclass)", benchmarkIndexName).Scan(&result.IndexSizeBytes); err != nil {
+				log.Printf("⚠️  Failed to read size of %s: %v", ic.Name, err)
+			}
+		}
+
+		variantSettings := settings
+		if v.Name == variantBitRerank && ic.IndexType == "hnsw" && qc.EfSearch < qc.K*qc.RerankFactor {
+			// HNSW returns at most ef_search rows, so widen it to cover the candidates
+			variantSettings = []string{fmt.Sprintf("hnsw.ef_search = %d", qc.K*qc.RerankFactor)}
+		}
+
+		stats := measureQueries(ctx, db, cfg, metrics, v.Query, len(queries), func(i int) []interface{} {
+			return []interface{}{pgvector.NewVector(queries[i])}
+		}, truth, qc.K, variantSettings)
+		if ctx.Err() != nil {
+			break
+		}
+		result.Recall = stats.Recall
+		result.QPS = stats.QPS
+		result.AvgLatencyMs = stats.AvgLatencyMs
+		result.P95LatencyMs = stats.P95LatencyMs
+		result.Errors = stats.Errors
+		metrics.RecordQuantization(result)
+
+		log.Printf("   %s: %.1f MB stored, recall@%d %.1f%%, %.1f QPS",
+			v.Name, float64(result.StorageBytes)/(1024*1024), qc.K, result.Recall*100, result.QPS)
+	}
+
+	log.Printf("✅ Quantization test completed")
+	return nil
+}
+
+// quantizationIndexConfiguration picks the index to build for every variant
+func (w *ComprehensivePgVectorWorkload) quantizationIndexConfiguration(qc *QuantizationConfig) (IndexConfiguration, error) {
+	name := qc.Index
+	if name == "" {
+		name = "hnsw_m_16_ef_64"
+		if w.IndexType == "ivfflat" {
+			name = "ivfflat_lists_100"
+		}
+	}
+	for _, ic := range w.getIndexConfigurations() {
+		if ic.Name == name {
+			return ic, nil
+		}
+	}
+	return IndexConfiguration{}, fmt.Errorf("unknown index configuration: %s", name)
+}
+
+// prepareQuantizedColumn adds and fills the quantized copy of embedding
+// stored in column, if it does not exist yet
+func (w *ComprehensivePgVectorWorkload) prepareQuantizedColumn(ctx context.Context, db *pgxpool.Pool, column string, qc *QuantizationConfig) error {
+	var columnType, value string
+	switch column {
+	case "embedding":
+		return nil
+	case "embedding_half":
+		columnType = fmt.Sprintf("HALFVEC(%d)", w.Dimensions)
+		value = fmt.Sprintf("embedding::halfvec(%d)", w.Dimensions)
+	case "embedding_bit":
+		columnType = fmt.Sprintf("BIT(%d)", w.Dimensions)
+		value = fmt.Sprintf("binary_quantize(embedding)::bit(%d)", w.Dimensions)
+	case "embedding_sparse":
+		// Keep the largest-magnitude dimensions (1-based indices in sparsevec text form)
+		columnType = fmt.Sprintf("SPARSEVEC(%d)", w.Dimensions)
+		value = fmt.Sprintf(`(
+			SELECT ('{' || string_agg(i || ':' || v, ',' ORDER BY i) || '}/%d')::sparsevec
+			FROM (
+				SELECT i, v FROM unnest(embedding::real[]) WITH ORDINALITY AS t(v, i)
+				ORDER BY abs(v) DESC LIMIT %d
+			) top
+		)`, w.Dimensions, qc.SparseNonZero)
+	default:
+		return fmt.Errorf("unknown quantized column: %s", column)
+	}
+
+	if _, err := db.Exec(ctx, fmt.Sprintf("ALTER TABLE pgvector_test ADD COLUMN IF NOT EXISTS %s %s", column, columnType)); err != nil {
+		return fmt.Errorf("failed to add %s: %w", column, err)
+	}
+
+	start := time.Now()
+	tag, err := db.Exec(ctx, fmt.Sprintf("UPDATE pgvector_test SET %s = %s WHERE %s IS NULL", column, value, column))
+	if err != nil {
+		return fmt.Errorf("failed to populate %s: %w", column, err)
+	}
+	if tag.RowsAffected() > 0 {
+		log.Printf("📋 Populated %s for %d rows in %v", column, tag.RowsAffected(), time.Since(start).Round(time.Millisecond))
+	}
+	return nil
+}
+
+// dropQuantizationColumns removes the quantized copies so other tests see
+// the original table layout
+func (w *ComprehensivePgVectorWorkload) dropQuantizationColumns(ctx context.Context, db *pgxpool.Pool) {
+	for _, column := range quantizationColumns {
+		if _, err := db.Exec(ctx, "ALTER TABLE pgvector_test DROP COLUMN IF EXISTS "+column); err != nil {
+			log.Printf("⚠️  Failed to drop %s: %v", column, err)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/elchinoo/stormdb/pkg/keydist"
 	"github.com/elchinoo/stormdb/pkg/types"
 
 	"github.com/jackc/pgx/v5"
@@ -57,6 +58,15 @@ func (w *ComprehensivePgVectorWorkload) Setup(ctx context.Context, db *pgxpool.P
 		return fmt.Errorf("table creation failed: %w", err)
 	}
 
+	// Import a standard ANN dataset instead of generating random vectors
+	if dc := parseDatasetConfig(); dc != nil {
+		if err := w.loadDataset(ctx, db, dc); err != nil {
+			return fmt.Errorf("dataset import failed: %w", err)
+		}
+		log.Printf("✅ Setup completed successfully")
+		return nil
+	}
+
 	// Load precomputed vectors for consistent testing
 	if err := w.loadPrecomputedVectors(ctx); err != nil {
 		return fmt.Errorf("precomputed vectors loading failed: %w", err)
@@ -73,8 +83,18 @@ func (w *ComprehensivePgVectorWorkload) Setup(ctx context.Context, db *pgxpool.P
 
 // Run executes the main test logic
 func (w *ComprehensivePgVectorWorkload) Run(ctx context.Context, db *pgxpool.Pool, cfg *types.Config, metrics *types.Metrics) error {
+	// Setup is skipped unless --setup or --rebuild is used
+	if w.TestType == "" {
+		w.parseWorkloadType(cfg.Workload)
+	}
 	log.Printf("🏃 Running pgvector test: %s", w.TestType)
 
+	keys, err := keydist.FromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid key distribution: %w", err)
+	}
+	w.keys = keys
+
 	switch w.TestType {
 	case "ingestion":
 		return w.runIngestionTest(ctx, db, cfg, metrics)
@@ -82,6 +102,14 @@ func (w *ComprehensivePgVectorWorkload) Run(ctx context.Context, db *pgxpool.Poo
 		return w.runUpdateTest(ctx, db, cfg, metrics)
 	case "read":
 		return w.runReadTest(ctx, db, cfg, metrics)
+	case "accuracy":
+		return w.runAccuracyTest(ctx, db, cfg, metrics)
+	case "index":
+		return w.runIndexBuildTest(ctx, db, cfg, metrics)
+	case "filtered":
+		return w.runFilteredTest(ctx, db, cfg, metrics)
+	case "quantization":
+		return w.runQuantizationTest(ctx, db, cfg, metrics)
 	default:
 		return fmt.Errorf("unknown test type: %s", w.TestType)
 	}
@@ -263,6 +291,7 @@ func (w *ComprehensivePgVectorWorkload) runSingleInsertTest(ctx context.Context,
 						fmt.Sprintf(`{"worker": %d, "timestamp": "%s"}`, workerID, time.Now().Format(time.RFC3339)),
 					)
 					duration := time.Since(start)
+					metrics.RecordOperation("single_insert", duration.Nanoseconds(), 1, err == nil)
 
 					if err != nil {
 						atomic.AddInt64(&metrics.Errors, 1)
@@ -318,15 +347,18 @@ func (w *ComprehensivePgVectorWorkload) runBatchInsertTest(ctx context.Context,
 					start := time.Now()
 					results := conn.SendBatch(ctx, batch)
 
+					failed := false
 					for j := 0; j < w.BatchSize; j++ {
 						_, err := results.Exec()
 						if err != nil {
+							failed = true
 							atomic.AddInt64(&metrics.Errors, 1)
 							log.Printf("Batch insert error at position %d: %v", j, err)
 						}
 					}
 					results.Close()
 					duration := time.Since(start)
+					metrics.RecordOperation("batch_insert", duration.Nanoseconds(), int64(w.BatchSize), !failed)
 
 					metrics.RecordLatency(duration.Nanoseconds())
 					metrics.RecordQuery("INSERT")
@@ -385,6 +417,7 @@ func (w *ComprehensivePgVectorWorkload) runCopyInsertTest(ctx context.Context, d
 						strings.NewReader(data.String()),
 						"COPY pgvector_test (name, embedding, category, metadata) FROM STDIN")
 					duration := time.Since(start)
+					metrics.RecordOperation("copy_insert", duration.Nanoseconds(), int64(batchSize), err == nil)
 
 					if err != nil {
 						atomic.AddInt64(&metrics.Errors, 1)
@@ -437,7 +470,7 @@ func (w *ComprehensivePgVectorWorkload) runUpdateTest(ctx context.Context, db *p
 					return
 				default:
 					// Pick a random existing ID
-					targetID := rng.Int63n(maxID) + 1
+					targetID := w.keys.Int63n(rng, maxID) + 1
 					vector := w.generateRandomVector(rng)
 
 					start := time.Now()
@@ -448,6 +481,7 @@ func (w *ComprehensivePgVectorWorkload) runUpdateTest(ctx context.Context, db *p
 						targetID,
 					)
 					duration := time.Since(start)
+					metrics.RecordOperation("vector_update", duration.Nanoseconds(), 1, err == nil)
 
 					if err != nil {
 						atomic.AddInt64(&metrics.Errors, 1)
@@ -530,6 +564,7 @@ func (w *ComprehensivePgVectorWorkload) runReadTest(ctx context.Context, db *pgx
 					}
 					rows.Close()
 					duration := time.Since(start)
+					metrics.RecordOperation("similarity_search", duration.Nanoseconds(), int64(len(results)), true)
 
 					metrics.RecordLatency(duration.Nanoseconds())
 					metrics.RecordQuery("SELECT")
@@ -0,0 +1,209 @@
+package unit_test
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/chaos"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// faultConfig builds a fault injection configuration with the given events
+func faultConfig(events ...types.FaultEvent) *types.Config {
+	cfg := &types.Config{}
+	cfg.FaultInjection.Enabled = true
+	cfg.FaultInjection.Events = events
+	return cfg
+}
+
+func TestNewInjectorValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *types.Config
+		opts    chaos.Options
+		wantErr string
+	}{
+		{
+			name: "valid schedule",
+			cfg: faultConfig(
+				types.FaultEvent{At: "10s", Action: chaos.ActionCheckpoint},
+				types.FaultEvent{At: "20s", Action: chaos.ActionSQL, SQL: "SELECT 1", Timeout: "5s"},
+				types.FaultEvent{At: "30s", Action: chaos.ActionTerminateBackends},
+			),
+		},
+		{
+			name: "shell with opt-in",
+			cfg:  faultConfig(types.FaultEvent{At: "1s", Action: chaos.ActionShell, Command: "true"}),
+			opts: chaos.Options{AllowShell: true},
+		},
+		{
+			name:    "shell without opt-in",
+			cfg:     faultConfig(types.FaultEvent{At: "1s", Action: chaos.ActionShell, Command: "true"}),
+			wantErr: "shell actions are not allowed",
+		},
+		{
+			name:    "shell without command",
+			cfg:     faultConfig(types.FaultEvent{At: "1s", Action: chaos.ActionShell}),
+			opts:    chaos.Options{AllowShell: true},
+			wantErr: "command is required",
+		},
+		{
+			name:    "sql without statement",
+			cfg:     faultConfig(types.FaultEvent{At: "1s", Action: chaos.ActionSQL}),
+			wantErr: "sql is required",
+		},
+		{
+			name:    "unknown action",
+			cfg:     faultConfig(types.FaultEvent{At: "1s", Action: "reboot"}),
+			wantErr: "unknown fault action: reboot",
+		},
+		{
+			name:    "invalid at",
+			cfg:     faultConfig(types.FaultEvent{At: "soon", Action: chaos.ActionCheckpoint}),
+			wantErr: "invalid at 'soon'",
+		},
+		{
+			name:    "invalid timeout",
+			cfg:     faultConfig(types.FaultEvent{At: "1s", Action: chaos.ActionCheckpoint, Timeout: "long"}),
+			wantErr: "invalid timeout 'long'",
+		},
+		{
+			name: "invalid recovery window",
+			cfg: func() *types.Config {
+				cfg := faultConfig(types.FaultEvent{At: "1s", Action: chaos.ActionCheckpoint})
+				cfg.FaultInjection.RecoveryWindow = "1x"
+				return cfg
+			}(),
+			wantErr: "invalid recovery_window",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := chaos.NewInjector(tt.cfg, &types.Metrics{}, tt.opts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// fakeWorkload drives the metrics counters while a fault is observed
+type fakeWorkload func(ctx context.Context, m *types.Metrics, fired time.Time)
+
+// tick calls step every few milliseconds until ctx is done
+func tick(ctx context.Context, step func(now time.Time)) {
+	ticker := time.NewTicker(2 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			step(now)
+		}
+	}
+}
+
+// observeFault fires a single no-op fault while workload runs and returns
+// the recorded result
+func observeFault(t *testing.T, workload fakeWorkload) types.FaultEventResult {
+	t.Helper()
+
+	cfg := faultConfig(types.FaultEvent{Name: "fault", At: "0s", Action: chaos.ActionShell, Command: "true"})
+	cfg.FaultInjection.RecoveryWindow = "50ms"
+	cfg.FaultInjection.ObserveTimeout = "500ms"
+
+	m := &types.Metrics{}
+	injector, err := chaos.NewInjector(cfg, m, chaos.Options{AllowShell: true})
+	if err != nil {
+		t.Fatalf("Failed to create injector: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fired := time.Now()
+	go workload(ctx, m, fired)
+
+	injector.Start(ctx)
+	injector.Wait()
+
+	events := m.GetFaultEvents()
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 fault event, got %d", len(events))
+	}
+	return events[0]
+}
+
+func TestInjectorObserve(t *testing.T) {
+	// In-flight transactions that started before the fault commit during
+	// the first 100ms, new transactions commit afterwards
+	t.Run("ignores in-flight transactions", func(t *testing.T) {
+		result := observeFault(t, func(ctx context.Context, m *types.Metrics, fired time.Time) {
+			tick(ctx, func(now time.Time) {
+				start := fired.Add(-time.Second)
+				if now.Sub(fired) >= 100*time.Millisecond {
+					start = now
+				}
+				m.RecordCommitStart(start)
+				atomic.AddInt64(&m.TPS, 1)
+			})
+		})
+
+		if !result.CommitTracking || !result.FirstTxnObserved || !result.Recovered {
+			t.Fatalf("Expected a tracked recovery, got %+v", result)
+		}
+		if result.TimeToFirstTxn < 100*time.Millisecond {
+			t.Errorf("Expected the first txn after the in-flight ones, got %v", result.TimeToFirstTxn)
+		}
+		if result.Resolution <= 0 {
+			t.Errorf("Expected the polling resolution in the result, got %v", result.Resolution)
+		}
+	})
+
+	// Errors for the first 100ms, then successful transactions from a
+	// workload that does not record commit starts
+	t.Run("recovers after error burst", func(t *testing.T) {
+		result := observeFault(t, func(ctx context.Context, m *types.Metrics, fired time.Time) {
+			tick(ctx, func(now time.Time) {
+				if now.Sub(fired) < 100*time.Millisecond {
+					atomic.AddInt64(&m.Errors, 1)
+					return
+				}
+				atomic.AddInt64(&m.TPS, 1)
+			})
+		})
+
+		if result.CommitTracking || !result.Recovered {
+			t.Fatalf("Expected an untracked recovery, got %+v", result)
+		}
+		if result.ErrorBurst == 0 {
+			t.Errorf("Expected errors in the burst, got %+v", result)
+		}
+		if result.RecoveryTime < 90*time.Millisecond || result.TimeToFirstTxn < 90*time.Millisecond {
+			t.Errorf("Expected recovery after the error burst, got %+v", result)
+		}
+	})
+
+	t.Run("does not recover while errors continue", func(t *testing.T) {
+		result := observeFault(t, func(ctx context.Context, m *types.Metrics, fired time.Time) {
+			tick(ctx, func(now time.Time) {
+				atomic.AddInt64(&m.Errors, 1)
+				atomic.AddInt64(&m.TPS, 1)
+			})
+		})
+
+		if result.Recovered || result.ErrorBurst == 0 {
+			t.Errorf("Expected no recovery with continuous errors, got %+v", result)
+		}
+	})
+}
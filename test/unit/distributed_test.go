@@ -0,0 +1,264 @@
+package unit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/distributed"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// fakeAgentRunner records one transaction per worker every millisecond
+// until the run ends
+func fakeAgentRunner(ctx context.Context, cfg *types.Config, m *types.Metrics, startAt time.Time, started func()) error {
+	duration, err := time.ParseDuration(cfg.Duration)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-time.After(time.Until(startAt)):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	started()
+	m.InitializeWorkerMetrics(cfg.Workers)
+
+	runCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-runCtx.Done():
+			return nil
+		case <-ticker.C:
+			for w := 0; w < cfg.Workers; w++ {
+				m.RecordWorkerTransaction(w, true, int64(time.Millisecond))
+				m.RecordQuery("SELECT")
+			}
+		}
+	}
+}
+
+func distributedSettings() map[string]interface{} {
+	return map[string]interface{}{
+		"database": map[string]interface{}{
+			"type": "postgres", "host": "localhost", "port": 5432,
+			"dbname": "storm", "username": "storm",
+		},
+		"workload":    "basic",
+		"workers":     4,
+		"connections": 4,
+		"duration":    "1s",
+		"distributed": map[string]interface{}{"agents": []string{"a", "b"}},
+	}
+}
+
+func distributedConfig(workers int, duration string) *types.Config {
+	cfg := &types.Config{Workload: "basic", Workers: workers, Connections: workers, Duration: duration}
+	cfg.Database.Type = "postgres"
+	cfg.Database.Host = "localhost"
+	cfg.Database.Port = 5432
+	cfg.Database.Dbname = "storm"
+	cfg.Database.Username = "storm"
+	return cfg
+}
+
+func TestSplitEven(t *testing.T) {
+	tests := []struct {
+		total, n int
+		want     []int
+	}{
+		{10, 3, []int{4, 3, 3}},
+		{4, 2, []int{2, 2}},
+		{2, 3, []int{1, 1, 0}},
+	}
+	for _, tt := range tests {
+		got := distributed.SplitEven(tt.total, tt.n)
+		if len(got) != len(tt.want) {
+			t.Fatalf("SplitEven(%d, %d) = %v, want %v", tt.total, tt.n, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("SplitEven(%d, %d) = %v, want %v", tt.total, tt.n, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestMergeMetrics(t *testing.T) {
+	start := time.Now().Truncate(time.Second)
+	interval := time.Second
+	bucket := func(agentStart time.Time, idx int, tps int64) types.TimeBucket {
+		s := agentStart.Add(time.Duration(idx) * interval)
+		return types.TimeBucket{StartTime: s, EndTime: s.Add(interval), TPS: tps}
+	}
+	// The second agent started 100ms late, its buckets still align by index
+	late := start.Add(100 * time.Millisecond)
+	parts := []*distributed.MetricsPayload{
+		{
+			Counters:         distributed.Counters{Transactions: 10, Queries: 20, Errors: 1},
+			ErrorTypes:       map[string]int64{"timeout": 1},
+			LatencyHistogram: map[string]int64{"1-5ms": 10},
+			TransactionDur:   []int64{1, 2},
+			Operations: []distributed.OperationPayload{
+				{Name: "read", Count: 2, Errors: 1, MinNs: 3, MaxNs: 8, Latencies: []int64{3, 8}, SampleCount: 2},
+			},
+			Workers:        []distributed.WorkerPayload{{WorkerID: 0, TPS: 6}, {WorkerID: 1, TPS: 4}},
+			StartedAt:      start,
+			BucketInterval: interval,
+			TimeSeries:     []types.TimeBucket{bucket(start, 0, 5), bucket(start, 1, 5)},
+			HostSamples:    []types.HostSample{{Time: start.Add(2 * interval), Host: "agent-a"}},
+		},
+		{
+			Counters:         distributed.Counters{Transactions: 7, Queries: 14, Errors: 2},
+			ErrorTypes:       map[string]int64{"timeout": 2},
+			LatencyHistogram: map[string]int64{"1-5ms": 7},
+			TransactionDur:   []int64{3},
+			Operations: []distributed.OperationPayload{
+				{Name: "read", Count: 1, MinNs: 2, MaxNs: 2, Latencies: []int64{2}, SampleCount: 1},
+			},
+			Workers:        []distributed.WorkerPayload{{WorkerID: 0, TPS: 7}},
+			WorkerOffset:   2,
+			StartedAt:      late,
+			BucketInterval: interval,
+			TimeSeries:     []types.TimeBucket{bucket(late, 1, 7)},
+			HostSamples:    []types.HostSample{{Time: start.Add(interval), Host: "agent-b"}},
+		},
+	}
+
+	m := &types.Metrics{ErrorTypes: make(map[string]int64)}
+	distributed.MergeMetrics(m, parts, start)
+
+	if m.TPS != 17 || m.QPS != 34 || m.Errors != 3 {
+		t.Errorf("counters = tps %d qps %d errors %d, want 17 34 3", m.TPS, m.QPS, m.Errors)
+	}
+	if m.ErrorTypes["timeout"] != 3 || m.LatencyHistogram["1-5ms"] != 17 {
+		t.Errorf("maps = %v %v", m.ErrorTypes, m.LatencyHistogram)
+	}
+	if len(m.TransactionDur) != 3 {
+		t.Errorf("latency samples = %d, want 3", len(m.TransactionDur))
+	}
+	if op := m.Operations["read"]; op == nil || op.Count != 3 || op.Errors != 1 || op.MinNs != 2 || op.MaxNs != 8 || len(op.Latencies) != 3 {
+		t.Errorf("operations not merged: %+v", m.Operations["read"])
+	}
+	if len(m.WorkerMetrics) != 3 || m.WorkerMetrics[2] == nil || m.WorkerMetrics[2].TPS != 7 {
+		t.Errorf("worker 2 not shifted by the agent offset: %+v", m.WorkerMetrics)
+	}
+	buckets := m.TimeSeries.Buckets
+	if len(buckets) != 2 || buckets[0].TPS != 5 || buckets[1].TPS != 12 {
+		t.Fatalf("buckets = %+v, want TPS 5 and 12", buckets)
+	}
+	if !buckets[1].StartTime.Equal(start.Add(interval)) {
+		t.Errorf("bucket 1 starts at %v, want %v", buckets[1].StartTime, start.Add(interval))
+	}
+	if hosts := m.GetHostSamples(); len(hosts) != 2 || hosts[0].Host != "agent-b" || hosts[1].Host != "agent-a" {
+		t.Errorf("host samples not merged in time order: %+v", hosts)
+	}
+}
+
+func TestAgentRejectsConcurrentRuns(t *testing.T) {
+	agent := distributed.NewAgent(fakeAgentRunner, distributed.AgentOptions{})
+	settings := distributedSettings()
+	settings["duration"] = "1m"
+
+	req := &distributed.StartRequest{ID: "one", Settings: settings, StartAt: time.Now()}
+	if _, err := agent.Start(req); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	req.ID = "two"
+	if _, err := agent.Start(req); err != distributed.ErrAgentBusy {
+		t.Errorf("second Start() error = %v, want ErrAgentBusy", err)
+	}
+
+	srv := httptest.NewServer(agent.Handler())
+	defer srv.Close()
+	resp, err := http.Post(srv.URL+"/agent/v1/runs/one/stop", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("stop status = %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+}
+
+func TestAgentRequiresToken(t *testing.T) {
+	agent := distributed.NewAgent(fakeAgentRunner, distributed.AgentOptions{Token: "secret"})
+	srv := httptest.NewServer(agent.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("healthz status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	resp, err = http.Post(srv.URL+"/agent/v1/runs", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("start without token status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	coord := distributed.NewCoordinator([]string{srv.URL}, "wrong", 0)
+	if err := coord.Check(context.Background()); err != nil {
+		t.Errorf("Check() error = %v, health is open", err)
+	}
+}
+
+func TestCoordinatorRun(t *testing.T) {
+	var agentTxns [2]int64
+	var addrs []string
+	for i := range agentTxns {
+		i := i
+		runner := func(ctx context.Context, cfg *types.Config, m *types.Metrics, startAt time.Time, started func()) error {
+			err := fakeAgentRunner(ctx, cfg, m, startAt, started)
+			atomic.StoreInt64(&agentTxns[i], atomic.LoadInt64(&m.TPS))
+			return err
+		}
+		agent := distributed.NewAgent(runner, distributed.AgentOptions{Token: "secret"})
+		srv := httptest.NewServer(agent.Handler())
+		defer srv.Close()
+		addrs = append(addrs, srv.URL)
+	}
+
+	coord := distributed.NewCoordinator(addrs, "secret", 200*time.Millisecond)
+	if err := coord.Check(context.Background()); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	cfg := distributedConfig(5, "1s")
+	m := &types.Metrics{ErrorTypes: make(map[string]int64)}
+	m.InitializeLatencyHistogram()
+	if err := coord.Run(context.Background(), distributedSettings(), cfg, m); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := agentTxns[0] + agentTxns[1]
+	if want == 0 || m.TPS != want {
+		t.Errorf("merged transactions = %d, want %d (%v)", m.TPS, want, agentTxns)
+	}
+	if len(m.WorkerMetrics) != 5 {
+		t.Errorf("merged workers = %d, want 5", len(m.WorkerMetrics))
+	}
+	for id := 0; id < 5; id++ {
+		if m.WorkerMetrics[id] == nil {
+			t.Errorf("missing worker %d", id)
+		}
+	}
+	if coord.StartedAt().IsZero() {
+		t.Error("StartedAt() is zero after a run")
+	}
+}
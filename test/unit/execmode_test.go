@@ -0,0 +1,62 @@
+package unit_test
+
+import (
+	"testing"
+
+	"github.com/elchinoo/stormdb/internal/database"
+	"github.com/elchinoo/stormdb/pkg/types"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestConfigureQueryExecMode(t *testing.T) {
+	tests := []struct {
+		mode        string
+		expected    pgx.QueryExecMode
+		afterHook   bool
+		expectError bool
+	}{
+		{mode: "", expected: pgx.QueryExecModeCacheStatement},
+		{mode: "simple_protocol", expected: pgx.QueryExecModeSimpleProtocol},
+		{mode: "exec", expected: pgx.QueryExecModeExec},
+		{mode: "describe_exec", expected: pgx.QueryExecModeDescribeExec},
+		{mode: "cache_describe", expected: pgx.QueryExecModeCacheDescribe},
+		{mode: "cache_statement", expected: pgx.QueryExecModeCacheStatement},
+		{mode: "prepared", expected: pgx.QueryExecModeCacheStatement, afterHook: true},
+		{mode: "bogus", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			poolCfg, err := pgxpool.ParseConfig("host=localhost user=test dbname=test")
+			if err != nil {
+				t.Fatalf("Failed to parse config: %v", err)
+			}
+
+			err = database.ConfigureQueryExecMode(poolCfg, tt.mode, []string{"SELECT 1 WHERE $1::int > 0"})
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for mode %q", tt.mode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if poolCfg.ConnConfig.DefaultQueryExecMode != tt.expected {
+				t.Errorf("Expected exec mode %v, got %v", tt.expected, poolCfg.ConnConfig.DefaultQueryExecMode)
+			}
+			if (poolCfg.AfterConnect != nil) != tt.afterHook {
+				t.Errorf("Expected AfterConnect hook set=%v", tt.afterHook)
+			}
+		})
+	}
+
+	for _, mode := range types.QueryExecModes {
+		if !database.IsValidQueryExecMode(mode) {
+			t.Errorf("Mode %s listed but not valid", mode)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package unit_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/core/domain"
+	"github.com/elchinoo/stormdb/internal/core/usecases"
+)
+
+// waitForExecution waits for a submitted execution to finish
+func waitForExecution(t *testing.T, exec *usecases.ExecutionContext) usecases.ExecutionState {
+	t.Helper()
+	select {
+	case <-exec.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Execution %s did not finish", exec.ID)
+	}
+	return exec.State()
+}
+
+// blockingExecution starts the workload and runs until ctx is done
+func blockingExecution(ctx context.Context, started func()) error {
+	started()
+	<-ctx.Done()
+	return nil
+}
+
+func TestExecutionLifecycle(t *testing.T) {
+	uc := usecases.NewTestExecutionUseCase(nil, nil, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		name        string
+		run         usecases.ExecutionFunc
+		action      func(id string) error
+		status      domain.ExecutionStatus
+		interrupted bool
+		failed      bool
+	}{
+		{
+			name:   "completes",
+			run:    func(_ context.Context, started func()) error { started(); return nil },
+			status: domain.StatusCompleted,
+		},
+		{
+			name:   "fails",
+			run:    func(_ context.Context, _ func()) error { return errors.New("setup failed") },
+			status: domain.StatusFailed,
+			failed: true,
+		},
+		{
+			name:   "returns before starting",
+			run:    func(_ context.Context, _ func()) error { return nil },
+			status: domain.StatusFailed,
+			failed: true,
+		},
+		{
+			name:        "stopped",
+			run:         blockingExecution,
+			action:      func(id string) error { _, err := uc.StopExecution(id); return err },
+			status:      domain.StatusCompleted,
+			interrupted: true,
+		},
+		{
+			name:        "cancelled while running",
+			run:         blockingExecution,
+			action:      uc.CancelExecution,
+			status:      domain.StatusCancelled,
+			interrupted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := uc.SubmitExecution(tt.name, "simple")
+			if exec.State().Status != domain.StatusPending {
+				t.Fatalf("Expected a pending execution, got %s", exec.State().Status)
+			}
+			if _, err := uc.StartExecution(exec.ID, tt.run); err != nil {
+				t.Fatalf("Failed to start execution: %v", err)
+			}
+			if tt.action != nil {
+				if err := tt.action(exec.ID); err != nil {
+					t.Fatalf("Action failed: %v", err)
+				}
+			}
+
+			state := waitForExecution(t, exec)
+			if state.Status != tt.status || state.Interrupted != tt.interrupted || (state.Err != nil) != tt.failed {
+				t.Errorf("Unexpected final state: %+v", state)
+			}
+			if state.EndTime.IsZero() || !state.Finished() {
+				t.Errorf("Expected a finished execution with an end time, got %+v", state)
+			}
+			if _, err := uc.StartExecution(exec.ID, tt.run); !errors.Is(err, usecases.ErrExecutionState) {
+				t.Errorf("Expected ErrExecutionState when restarting, got %v", err)
+			}
+		})
+	}
+
+	exec := uc.SubmitExecution("pending", "simple")
+	if err := uc.CancelExecution(exec.ID); err != nil {
+		t.Fatalf("Failed to cancel pending execution: %v", err)
+	}
+	if state := waitForExecution(t, exec); state.Status != domain.StatusCancelled || !state.StartTime.IsZero() {
+		t.Errorf("Expected a cancelled execution that never started, got %+v", state)
+	}
+	if _, err := uc.StopExecution(exec.ID); !errors.Is(err, usecases.ErrExecutionState) {
+		t.Errorf("Expected ErrExecutionState when stopping a cancelled execution, got %v", err)
+	}
+
+	uc.ForgetExecution(exec.ID)
+	if _, err := uc.TrackedExecution(exec.ID); !errors.Is(err, usecases.ErrExecutionNotFound) {
+		t.Errorf("Expected ErrExecutionNotFound after forgetting, got %v", err)
+	}
+}
+
+// A cancel racing a start either wins before the execution runs or cancels
+// it while running; the execution finishes exactly once either way
+func TestExecutionStartCancelRace(t *testing.T) {
+	uc := usecases.NewTestExecutionUseCase(nil, nil, nil, nil, nil, nil, nil)
+
+	for i := 0; i < 200; i++ {
+		exec := uc.SubmitExecution("race", "simple")
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = uc.StartExecution(exec.ID, blockingExecution)
+		}()
+		go func() {
+			defer wg.Done()
+			_ = uc.CancelExecution(exec.ID)
+		}()
+		wg.Wait()
+
+		if state := waitForExecution(t, exec); state.Status != domain.StatusCancelled {
+			t.Fatalf("Expected a cancelled execution, got %s", state.Status)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package unit_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/explain"
+	"github.com/elchinoo/stormdb/pkg/types"
+	"github.com/jackc/pgx/v5"
+)
+
+const samplePlan = `[{"Plan": {"Node Type": "Limit", "Total Cost": 12.5, "Actual Total Time": 0.2,
+  "Plans": [{"Node Type": "Nested Loop", "Join Type": "Left",
+    "Plans": [
+      {"Node Type": "Index Scan", "Index Name": "orders_user_idx", "Relation Name": "orders"},
+      {"Node Type": "Seq Scan", "Relation Name": "order_items"}]}]},
+  "Planning Time": 0.1, "Execution Time": 1.25}]`
+
+func TestExplainable(t *testing.T) {
+	tests := map[string]bool{
+		"SELECT 1":                               true,
+		"  with x as (select 1) select * from x": true,
+		"-- comment\nUPDATE t SET a = 1":         true,
+		"(SELECT 1) UNION (SELECT 2)":            true,
+		"BEGIN":                                  false,
+		"COPY t FROM STDIN":                      false,
+		"EXPLAIN SELECT 1":                       false,
+		"SET LOCAL lock_timeout = '1s'":          false,
+	}
+	for sql, want := range tests {
+		if got := explain.Explainable(sql); got != want {
+			t.Errorf("Explainable(%q) = %v, want %v", sql, got, want)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := explain.Fingerprint("SELECT * FROM orders WHERE id = 42 AND status = 'new'")
+	b := explain.Fingerprint("select *  from orders\n WHERE id = 7 AND status = 'shipped'")
+	if a != b {
+		t.Errorf("Expected literals and formatting to be ignored: %s != %s", a, b)
+	}
+	if c := explain.Fingerprint("SELECT * FROM order_items WHERE id = 42"); c == a {
+		t.Error("Expected different statements to have different fingerprints")
+	}
+}
+
+func TestParsePlan(t *testing.T) {
+	shape, executionMs, err := explain.ParsePlan([]byte(samplePlan))
+	if err != nil {
+		t.Fatalf("ParsePlan failed: %v", err)
+	}
+	want := "Limit(Left Nested Loop(Index Scan using orders_user_idx on orders, Seq Scan on order_items))"
+	if shape != want {
+		t.Errorf("shape = %q, want %q", shape, want)
+	}
+	if executionMs != 1.25 {
+		t.Errorf("execution time = %f, want 1.25", executionMs)
+	}
+	if _, _, err := explain.ParsePlan([]byte(`[]`)); err == nil {
+		t.Error("Expected an error for an empty plan")
+	}
+}
+
+func TestDetectChanges(t *testing.T) {
+	previous := []types.QueryPlan{
+		{Operation: "getUserOrders", Fingerprint: "a", Shape: "Index Scan on orders"},
+		{Operation: "getUserOrders", Fingerprint: "a", Shape: "Bitmap Heap Scan on orders"},
+	}
+	plans := []types.QueryPlan{
+		{Operation: "getUserOrders", Fingerprint: "a", Shape: "Seq Scan on orders"},
+		{Operation: "getUserOrders", Fingerprint: "a", Shape: "Bitmap Heap Scan on orders"},
+		{Operation: "createNewOrder", Fingerprint: "b", Shape: "Insert on orders"},
+		{Operation: "getUserOrders", Fingerprint: "a", Error: "lock timeout"},
+	}
+
+	if changed := explain.DetectChanges(previous, plans); changed != 1 {
+		t.Errorf("changed = %d, want 1", changed)
+	}
+	if !plans[0].Changed || plans[0].PreviousShape != "Index Scan on orders" {
+		t.Errorf("Expected the seq scan to be a change, got %+v", plans[0])
+	}
+	if plans[1].Changed || plans[2].Changed || plans[3].Changed {
+		t.Errorf("Expected known, new and failed plans to be unchanged: %+v", plans[1:])
+	}
+}
+
+func TestPlanStorePrevious(t *testing.T) {
+	store := explain.NewStore(t.TempDir())
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if plans, err := store.Previous("tpcc", start); err != nil || plans != nil {
+		t.Fatalf("Expected no previous plans in an empty store, got %v, %v", plans, err)
+	}
+
+	for i, shape := range []string{"first", "second"} {
+		plans := []types.QueryPlan{{Operation: "new_order", Shape: shape}}
+		if _, err := store.Save("tpcc", start.Add(time.Duration(i)*time.Hour), plans); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	// Another workload sharing the name prefix must not be picked up
+	if _, err := store.Save("tpcc-lite", start.Add(90*time.Minute), []types.QueryPlan{{Shape: "other"}}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	plans, err := store.Previous("tpcc", start.Add(2*time.Hour))
+	if err != nil || len(plans) != 1 || plans[0].Shape != "second" {
+		t.Errorf("Expected the latest earlier run's plans, got %+v, %v", plans, err)
+	}
+	plans, err = store.Previous("tpcc", start.Add(30*time.Minute))
+	if err != nil || len(plans) != 1 || plans[0].Shape != "first" {
+		t.Errorf("Expected the plans before the given start, got %+v, %v", plans, err)
+	}
+}
+
+func TestPlanStoreSaveFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "plans")
+	path, err := explain.NewStore(dir).Save("ecommerce/v2", time.Now(), nil)
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if filepath.Dir(path) != dir || filepath.Base(path)[:len("ecommerce_v2-")] != "ecommerce_v2-" {
+		t.Errorf("Unexpected plan file %s", path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Plan file not written: %v", err)
+	}
+}
+
+func TestTracerSchedulesCaptures(t *testing.T) {
+	cfg := &types.Config{}
+	cfg.Database.Host = "127.0.0.1"
+	cfg.Database.Port = 1 // Nothing listens, captures fail fast
+	cfg.Database.Sslmode = "disable"
+	cfg.Explain.Threshold = "0s"
+	cfg.Explain.MaxPlans = 2
+
+	tracer, err := explain.NewTracer(cfg)
+	if err != nil {
+		t.Fatalf("NewTracer failed: %v", err)
+	}
+	trace := func(ctx context.Context, sql string) {
+		ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: sql})
+		tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	}
+
+	// Not traced before Start
+	trace(context.Background(), "SELECT 1")
+
+	tracer.Start()
+	ctx := types.WithOperation(context.Background(), "getUserOrders")
+	for i := 0; i < 5; i++ {
+		trace(ctx, "SELECT * FROM orders WHERE user_id = $1")
+	}
+	trace(ctx, "BEGIN")
+	trace(context.Background(), "UPDATE orders SET status = $1")
+	plans := tracer.Stop()
+
+	if len(plans) != 3 {
+		t.Fatalf("Expected 3 captures (2 per statement, BEGIN skipped), got %d: %+v", len(plans), plans)
+	}
+	if plans[0].Operation != "" || plans[1].Operation != "getUserOrders" || plans[1].Reason != explain.ReasonThreshold {
+		t.Errorf("Unexpected plans: %+v", plans)
+	}
+	for _, p := range plans {
+		if p.Error == "" {
+			t.Errorf("Expected the capture to fail without a database: %+v", p)
+		}
+	}
+}
+
+func TestExplainOptions(t *testing.T) {
+	cfg := &types.Config{}
+	opts, err := explain.OptionsFrom(cfg)
+	if err != nil || opts.Threshold != explain.DefaultThreshold || opts.MaxPlans != explain.DefaultMaxPlans {
+		t.Errorf("Unexpected defaults %+v, %v", opts, err)
+	}
+	cfg.Explain.SampleRate = 1.5
+	if _, err := explain.OptionsFrom(cfg); err == nil {
+		t.Error("Expected an error for a sample rate above 1")
+	}
+}
@@ -0,0 +1,214 @@
+package unit_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/hoststats"
+	"github.com/elchinoo/stormdb/internal/metrics"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// writeProc writes a fake procfs with the given /proc/stat content
+func writeProc(t *testing.T, stat string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"stat":    stat,
+		"meminfo": "MemTotal:       1000 kB\nMemFree:         100 kB\nMemAvailable:    250 kB\n",
+		"net/dev": "Inter-|   Receive                            |  Transmit\n" +
+			" face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed\n" +
+			"    lo: 5000 10 0 0 0 0 0 0 5000 10 0 0 0 0 0 0\n" +
+			"  eth0:1000 10 0 0 0 0 0 0 2000 20 0 0 0 0 0 0\n" +
+			"  eth1: 500 5 0 0 0 0 0 0 300 3 0 0 0 0 0 0\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadSnapshot(t *testing.T) {
+	dir := writeProc(t, "cpu  100 0 50 800 50 0 0 0 0 0\ncpu0 50 0 25 400 25 0 0 0 0 0\ncpu1 50 0 25 400 25 0 0 0 0 0\n"+
+		"intr 12345\nctxt 9000\nprocs_running 3\nprocs_blocked 0\n")
+
+	snap, err := hoststats.ReadSnapshot(dir)
+	if err != nil {
+		t.Fatalf("ReadSnapshot failed: %v", err)
+	}
+	if snap.CPUs != 2 || snap.CPU.User != 100 || snap.CPU.Idle != 800 || snap.CPU.IOWait != 50 {
+		t.Errorf("Unexpected CPU counters: %+v", snap)
+	}
+	if snap.ContextSwitches != 9000 || snap.RunQueue != 3 {
+		t.Errorf("Unexpected scheduler counters: %+v", snap)
+	}
+	if snap.MemTotal != 1000*1024 || snap.MemAvailable != 250*1024 {
+		t.Errorf("Unexpected memory: %+v", snap)
+	}
+	if snap.NetRx != 1500 || snap.NetTx != 2300 {
+		t.Errorf("Expected loopback to be excluded from network bytes, got rx %d tx %d", snap.NetRx, snap.NetTx)
+	}
+
+	if _, err := hoststats.ReadSnapshot(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Expected an error for a missing procfs")
+	}
+}
+
+func TestNewHostSample(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	prev := hoststats.Snapshot{
+		Time:            start,
+		CPU:             hoststats.CPUTimes{User: 100, System: 50, Idle: 800, IOWait: 50},
+		ContextSwitches: 1000,
+		NetRx:           1000,
+		NetTx:           2000,
+	}
+	cur := hoststats.Snapshot{
+		Time:            start.Add(2 * time.Second),
+		CPU:             hoststats.CPUTimes{User: 160, System: 70, Idle: 810, IOWait: 50, Steal: 10},
+		CPUs:            4,
+		ContextSwitches: 3000,
+		RunQueue:        6,
+		MemTotal:        1000,
+		MemAvailable:    400,
+		NetRx:           5000,
+		NetTx:           1000, // Counter reset
+	}
+
+	s := hoststats.NewHostSample(prev, cur, "client-1")
+
+	if s.Host != "client-1" || s.CPUs != 4 || s.RunQueue != 6 {
+		t.Errorf("Unexpected sample identity: %+v", s)
+	}
+	if s.CPUBusyPct != 90 || s.CPUUserPct != 60 || s.CPUSystemPct != 20 || s.CPUStealPct != 10 || s.CPUIOWaitPct != 0 {
+		t.Errorf("Unexpected CPU percentages: %+v", s)
+	}
+	if s.MemUsedPct != 60 {
+		t.Errorf("Expected 60%% memory used, got %g", s.MemUsedPct)
+	}
+	if s.NetRxBytesPerSec != 2000 || s.NetTxBytesPerSec != 0 || s.ContextSwitchesPerSec != 1000 {
+		t.Errorf("Unexpected rates: %+v", s)
+	}
+}
+
+func TestHostStatsOptions(t *testing.T) {
+	cfg := &types.Config{}
+	opts, err := hoststats.OptionsFrom(cfg)
+	if err != nil {
+		t.Fatalf("OptionsFrom failed: %v", err)
+	}
+	if opts.Interval != hoststats.DefaultInterval || opts.CPUThreshold != hoststats.DefaultCPUThreshold || opts.ProcDir != hoststats.DefaultProcDir {
+		t.Errorf("Expected defaults, got %+v", opts)
+	}
+
+	cfg.HostStats.Interval = "500ms"
+	cfg.HostStats.CPUThreshold = 75
+	if opts, _ = hoststats.OptionsFrom(cfg); opts.Interval != 500*time.Millisecond || opts.CPUThreshold != 75 {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+
+	cfg.HostStats.Interval = "0s"
+	if _, err := hoststats.OptionsFrom(cfg); err == nil {
+		t.Error("Expected an error for a zero interval")
+	}
+	cfg.HostStats.Interval = ""
+	cfg.HostStats.CPUThreshold = 120
+	if _, err := hoststats.OptionsFrom(cfg); err == nil {
+		t.Error("Expected an error for a threshold above 100")
+	}
+}
+
+func TestHostSaturated(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample types.HostSample
+		want   bool
+	}{
+		{"idle", types.HostSample{CPUs: 4, CPUBusyPct: 30, RunQueue: 2}, false},
+		{"busy CPU", types.HostSample{CPUs: 4, CPUBusyPct: 95, RunQueue: 2}, true},
+		{"long run queue", types.HostSample{CPUs: 4, CPUBusyPct: 60, RunQueue: 5}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hoststats.Saturated(tt.sample, 90); got != tt.want {
+				t.Errorf("Saturated = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSummaryHostStats(t *testing.T) {
+	cfg := &types.Config{Workload: "tpcc", Duration: "10s"}
+	m := newSummaryMetrics()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.TimeSeries = &types.TimeSeriesMetrics{
+		StartTime: start,
+		Buckets: []types.TimeBucket{
+			{StartTime: start, EndTime: start.Add(5 * time.Second), TPS: 50},
+			{StartTime: start.Add(5 * time.Second), EndTime: start.Add(10 * time.Second), TPS: 40},
+		},
+	}
+	for i := 0; i < 4; i++ {
+		at := start.Add(time.Duration(i*2+1) * time.Second)
+		m.RecordHostSample(types.HostSample{Time: at, Host: "loadgen", CPUs: 2, CPUBusyPct: 97, RunQueue: 4, NetRxBytesPerSec: 100})
+		m.RecordHostSample(types.HostSample{Time: at, Host: "agent", CPUs: 8, CPUBusyPct: 20, RunQueue: 1, NetRxBytesPerSec: 50})
+	}
+	m.RecordServerSample(types.ServerSample{Time: start.Add(time.Second), IOReadsPerSec: 10, WALDirBytes: 1 << 20, WALFiles: 1})
+	m.RecordServerSample(types.ServerSample{Time: start.Add(6 * time.Second), IOReadsPerSec: 30, WALDirBytes: 2 << 20, WALFiles: 2})
+
+	s := metrics.BuildSummary(cfg, m, false, nil, start, start.Add(10*time.Second))
+
+	hs := s.HostStats
+	if hs == nil || len(hs.Clients) != 2 || hs.Server == nil {
+		t.Fatalf("Expected host stats for two clients and the server, got %+v", hs)
+	}
+	if !hs.ClientBound || len(hs.ClientBoundHosts) != 1 || hs.ClientBoundHosts[0] != "loadgen" {
+		t.Errorf("Expected only loadgen to be client-bound, got %+v", hs)
+	}
+	if agent := hs.Clients[0]; agent.Host != "agent" || agent.ClientBound || agent.SaturatedPct != 0 || agent.Samples != 4 {
+		t.Errorf("Unexpected agent summary: %+v", agent)
+	}
+	if srv := hs.Server; srv.AvgIOReadsPerSec != 20 || srv.MaxWALDirBytes != 2<<20 || srv.LastWALDirBytes != 2<<20 || srv.MaxWALFiles != 2 {
+		t.Errorf("Unexpected server summary: %+v", srv)
+	}
+
+	if len(s.TimeSeries) != 2 {
+		t.Fatalf("Expected 2 time-series buckets, got %d", len(s.TimeSeries))
+	}
+	for i, b := range s.TimeSeries {
+		if b.Host == nil || b.Host.ClientCPUBusyPct != 97 || !b.Host.ClientSaturated || b.Host.ClientNetRxBytesPerSec != 150 {
+			t.Errorf("Unexpected host stats in bucket %d: %+v", i, b.Host)
+		}
+	}
+	if r := s.TimeSeries[1].Host.ServerIOReadsPerSec; r != 30 {
+		t.Errorf("Expected the second bucket to hold the second server sample, got %g", r)
+	}
+
+	var buf bytes.Buffer
+	if err := metrics.WriteSummary(&buf, metrics.OutputCSV, s); err != nil {
+		t.Fatalf("WriteSummary failed: %v", err)
+	}
+	for _, want := range []string{"host_stats,,client_bound,true", "host_stats,loadgen,saturated_pct,100", "host_stats,server,max_wal_files,2"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("CSV summary is missing %q", want)
+		}
+	}
+}
+
+func TestBuildSummaryWithoutHostStats(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := metrics.BuildSummary(&types.Config{Workload: "tpcc"}, newSummaryMetrics(), false, nil, start, start.Add(time.Second))
+	if s.HostStats != nil {
+		t.Errorf("Expected no host stats without samples, got %+v", s.HostStats)
+	}
+}
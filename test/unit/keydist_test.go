@@ -0,0 +1,158 @@
+package unit_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/elchinoo/stormdb/pkg/keydist"
+)
+
+func TestKeyDistributionRange(t *testing.T) {
+	for _, dist := range []string{"", keydist.Uniform, keydist.Zipfian, keydist.Hotspot, keydist.Latest, keydist.Sequential} {
+		t.Run(dist, func(t *testing.T) {
+			chooser, err := keydist.New(keydist.Config{Type: dist})
+			if err != nil {
+				t.Fatalf("Failed to create chooser: %v", err)
+			}
+
+			rng := rand.New(rand.NewSource(1))
+			for i := 0; i < 10000; i++ {
+				if key := chooser.Intn(rng, 100); key < 0 || key >= 100 {
+					t.Fatalf("Key %d out of range [0, 100)", key)
+				}
+			}
+			if key := chooser.Intn(rng, 1); key != 0 {
+				t.Errorf("Expected key 0 for a single-key keyspace, got %d", key)
+			}
+		})
+	}
+}
+
+func TestKeyDistributionSkew(t *testing.T) {
+	const n, samples = 1000, 100000
+	rng := rand.New(rand.NewSource(1))
+
+	hotspot, err := keydist.New(keydist.Config{Type: keydist.Hotspot, HotspotKeys: 0.1, HotspotOps: 0.9})
+	if err != nil {
+		t.Fatalf("Failed to create hotspot chooser: %v", err)
+	}
+	hot := 0
+	for i := 0; i < samples; i++ {
+		if hotspot.Intn(rng, n) < n/10 {
+			hot++
+		}
+	}
+	if share := float64(hot) / samples; share < 0.88 || share > 0.92 {
+		t.Errorf("Expected ~90%% of operations on hot keys, got %.2f%%", share*100)
+	}
+
+	zipf, err := keydist.New(keydist.Config{Type: keydist.Zipfian})
+	if err != nil {
+		t.Fatalf("Failed to create zipfian chooser: %v", err)
+	}
+	latest, err := keydist.New(keydist.Config{Type: keydist.Latest})
+	if err != nil {
+		t.Fatalf("Failed to create latest chooser: %v", err)
+	}
+	first, last := 0, 0
+	for i := 0; i < samples; i++ {
+		if zipf.Intn(rng, n) == 0 {
+			first++
+		}
+		if latest.Intn(rng, n) == n-1 {
+			last++
+		}
+	}
+	// Under uniform access each key gets 0.1% of the operations
+	if first < samples/20 {
+		t.Errorf("Expected zipfian to favour key 0, got %d of %d", first, samples)
+	}
+	if last < samples/20 {
+		t.Errorf("Expected latest to favour key %d, got %d of %d", n-1, last, samples)
+	}
+}
+
+// A keyspace that grows on every call, as with latest over a table that
+// receives inserts, draws the same keys as a fresh chooser for each size
+func TestKeyDistributionGrowingKeyspace(t *testing.T) {
+	for _, dist := range []string{keydist.Zipfian, keydist.Latest} {
+		t.Run(dist, func(t *testing.T) {
+			chooser, err := keydist.New(keydist.Config{Type: dist})
+			if err != nil {
+				t.Fatalf("Failed to create chooser: %v", err)
+			}
+
+			rng := rand.New(rand.NewSource(1))
+			fresh := rand.New(rand.NewSource(1))
+			// Growing past the generator cache, then back to smaller sizes
+			sizes := make([]int, 0, 300)
+			for n := 1000; n < 1200; n++ {
+				sizes = append(sizes, n)
+			}
+			for n := 1100; n > 1000; n-- {
+				sizes = append(sizes, n)
+			}
+
+			for _, n := range sizes {
+				reference, err := keydist.New(keydist.Config{Type: dist})
+				if err != nil {
+					t.Fatalf("Failed to create chooser: %v", err)
+				}
+				for i := 0; i < 5; i++ {
+					got, want := chooser.Intn(rng, n), reference.Intn(fresh, n)
+					if got != want {
+						t.Fatalf("Keyspace %d: expected key %d, got %d", n, want, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestKeyDistributionSequential(t *testing.T) {
+	chooser, err := keydist.New(keydist.Config{Type: keydist.Sequential})
+	if err != nil {
+		t.Fatalf("Failed to create chooser: %v", err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		if key := chooser.Intn(rng, 4); key != i%4 {
+			t.Errorf("Step %d: expected key %d, got %d", i, i%4, key)
+		}
+	}
+}
+
+func TestKeyDistributionValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         keydist.Config
+		expectError bool
+		description string
+	}{
+		{name: "default", cfg: keydist.Config{}, description: "uniform"},
+		{name: "zipfian", cfg: keydist.Config{Type: "zipfian", Theta: 0.8}, description: "zipfian(theta=0.8)"},
+		{name: "hotspot", cfg: keydist.Config{Type: "hotspot"}, description: "hotspot(80% ops on 20% keys)"},
+		{name: "bad type", cfg: keydist.Config{Type: "gaussian"}, expectError: true},
+		{name: "bad theta", cfg: keydist.Config{Type: "zipfian", Theta: 1.5}, expectError: true},
+		{name: "bad hotspot keys", cfg: keydist.Config{Type: "hotspot", HotspotKeys: 1}, expectError: true},
+		{name: "bad hotspot ops", cfg: keydist.Config{Type: "hotspot", HotspotOps: -0.5}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %+v", tt.cfg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := tt.cfg.String(); got != tt.description {
+				t.Errorf("Expected description %q, got %q", tt.description, got)
+			}
+		})
+	}
+}
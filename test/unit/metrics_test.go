@@ -3,7 +3,9 @@ package unit_test
 import (
 	"testing"
 
+	"github.com/elchinoo/stormdb/internal/metrics"
 	"github.com/elchinoo/stormdb/internal/util"
+	"github.com/elchinoo/stormdb/pkg/types"
 )
 
 func TestCalculatePercentiles(t *testing.T) {
@@ -125,3 +127,22 @@ func TestStats(t *testing.T) {
 		})
 	}
 }
+
+func TestVectorAccuracyFrontier(t *testing.T) {
+	results := []types.VectorAccuracyResult{
+		{Index: "a", Queries: 10, Recall: 0.50, QPS: 1000},
+		{Index: "b", Queries: 10, Recall: 0.80, QPS: 600},
+		{Index: "c", Queries: 10, Recall: 0.70, QPS: 500}, // dominated by b
+		{Index: "d", Queries: 10, Recall: 0.99, QPS: 100},
+		{Index: "e", Queries: 10, Recall: 0.99, QPS: 80}, // dominated by d
+		{Index: "f", Queries: 0},                         // no measurements
+	}
+	expected := []bool{true, true, false, true, false, false}
+
+	frontier := metrics.VectorAccuracyFrontier(results)
+	for i, onFrontier := range frontier {
+		if onFrontier != expected[i] {
+			t.Errorf("Result %s: expected frontier=%v, got %v", results[i].Index, expected[i], onFrontier)
+		}
+	}
+}
@@ -1,12 +1,116 @@
 package unit_test
 
 import (
+	"bytes"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/database"
+	"github.com/elchinoo/stormdb/internal/metrics"
+	"github.com/elchinoo/stormdb/pkg/types"
 )
 
-// Placeholder test for pgstats functionality
-func TestPgStatsPlaceholder(t *testing.T) {
-	// This is a placeholder test file
-	// TODO: Add actual pgstats tests when functionality is implemented
-	t.Skip("pgstats tests not yet implemented")
+func TestStatementDeltas(t *testing.T) {
+	baseline := map[int64]types.StatementStats{
+		1: {QueryID: 1, Query: "SELECT a", Calls: 100, TotalExecTime: 50, Rows: 100, SharedBlksHit: 900, SharedBlksRead: 100, WALBytes: 0},
+		2: {QueryID: 2, Query: "UPDATE b", Calls: 10, TotalExecTime: 20, WALBytes: 4096},
+		3: {QueryID: 3, Query: "SELECT idle", Calls: 5, TotalExecTime: 1},
+		4: {QueryID: 4, Query: "SELECT reset", Calls: 500, TotalExecTime: 300},
+	}
+	final := map[int64]types.StatementStats{
+		1: {QueryID: 1, Query: "SELECT a", Calls: 150, TotalExecTime: 80, Rows: 150, SharedBlksHit: 1290, SharedBlksRead: 110},
+		2: {QueryID: 2, Query: "UPDATE b", Calls: 30, TotalExecTime: 60, WALBytes: 12288},
+		3: {QueryID: 3, Query: "SELECT idle", Calls: 5, TotalExecTime: 1},
+		4: {QueryID: 4, Query: "SELECT reset", Calls: 7, TotalExecTime: 2},
+		5: {QueryID: 5, Query: "INSERT c", Calls: 3, TotalExecTime: 9},
+	}
+
+	deltas := database.StatementDeltas(baseline, final)
+
+	if len(deltas) != 4 {
+		t.Fatalf("Expected 4 statements with calls during the run, got %+v", deltas)
+	}
+	byID := make(map[int64]types.StatementStats)
+	for _, d := range deltas {
+		byID[d.QueryID] = d
+	}
+	if _, ok := byID[3]; ok {
+		t.Error("Expected the statement without calls to be omitted")
+	}
+	if a := byID[1]; a.Calls != 50 || a.TotalExecTime != 30 || a.Rows != 50 || a.MeanExecTime() != 0.6 || a.HitPercent() != 97.5 {
+		t.Errorf("Unexpected delta for statement 1: %+v", a)
+	}
+	if b := byID[2]; b.Calls != 20 || b.WALBytes != 8192 {
+		t.Errorf("Unexpected delta for statement 2: %+v", b)
+	}
+	if r := byID[4]; r.Calls != 7 || r.TotalExecTime != 2 {
+		t.Errorf("Expected a reset statement to report its final counters, got %+v", r)
+	}
+	if n := byID[5]; n.Calls != 3 {
+		t.Errorf("Expected a new statement to report its final counters, got %+v", n)
+	}
+}
+
+func TestSelectStatements(t *testing.T) {
+	stmts := []types.StatementStats{
+		{QueryID: 1, Calls: 1000, TotalExecTime: 100},
+		{QueryID: 2, Calls: 10, TotalExecTime: 500},
+		{QueryID: 3, Calls: 5, TotalExecTime: 50, WALBytes: 1 << 20},
+		{QueryID: 4, Calls: 1, TotalExecTime: 1},
+	}
+
+	top := types.TopStatements(stmts, types.StatementDimensions[0], 2)
+	if len(top) != 2 || top[0].QueryID != 2 || top[1].QueryID != 1 {
+		t.Errorf("Unexpected top statements by total time: %+v", top)
+	}
+
+	// Statement 3 is only in the top 1 by WAL bytes and 4 is in no top 1
+	selected := types.SelectStatements(stmts, 1)
+	if len(selected) != 3 || selected[0].QueryID != 2 || selected[1].QueryID != 1 || selected[2].QueryID != 3 {
+		t.Errorf("Unexpected selected statements: %+v", selected)
+	}
+}
+
+func TestBuildSummaryStatements(t *testing.T) {
+	cfg := &types.Config{Workload: "tpcc", Duration: "10s"}
+	m := newSummaryMetrics()
+	m.UpdatePgStats(&types.PostgreSQLStats{
+		StatementLimit: 1,
+		Statements: []types.StatementStats{
+			{QueryID: 11, Query: "SELECT 1", Calls: 100, TotalExecTime: 200},
+			{QueryID: 22, Query: "UPDATE t", Calls: 10, TotalExecTime: 50, WALBytes: 8192},
+		},
+		LastUpdated: time.Now(),
+	})
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	s := metrics.BuildSummary(cfg, m, false, nil, start, start.Add(10*time.Second))
+
+	if s.PgStats == nil || len(s.PgStats.Statements) != 2 {
+		t.Fatalf("Expected statement deltas in the summary, got %+v", s.PgStats)
+	}
+	if s.PgStats.Statements[0].MeanMs != 2 {
+		t.Errorf("Unexpected mean time: %+v", s.PgStats.Statements[0])
+	}
+	rankings := s.PgStats.StatementRankings
+	if got := rankings["total_time"]; len(got) != 1 || got[0] != 11 {
+		t.Errorf("Unexpected total_time ranking %v", got)
+	}
+	if got := rankings["wal_bytes"]; len(got) != 1 || got[0] != 22 {
+		t.Errorf("Unexpected wal_bytes ranking %v", got)
+	}
+	if _, ok := rankings["plan_time"]; ok {
+		t.Error("Expected no planning time ranking without planning time")
+	}
+
+	var buf bytes.Buffer
+	if err := metrics.WriteSummary(&buf, metrics.OutputCSV, s); err != nil {
+		t.Fatalf("WriteSummary failed: %v", err)
+	}
+	for _, want := range []string{"pg_statements,22,wal_bytes,8192", "pg_statement_rankings,calls,1,11"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("CSV summary is missing %q", want)
+		}
+	}
 }
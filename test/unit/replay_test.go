@@ -0,0 +1,217 @@
+package unit_test
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/replay"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+// csvLogRecord builds a csvlog row with the columns ReadCSVLog uses
+func csvLogRecord(logTime, database, session, severity, message, detail string) []string {
+	record := make([]string, 23)
+	record[0] = logTime
+	record[1] = "app"
+	record[2] = database
+	record[5] = session
+	record[11] = severity
+	record[13] = message
+	record[14] = detail
+	return record
+}
+
+func TestReadJSONL(t *testing.T) {
+	input := `{"time": "2025-01-15T10:00:00.100Z", "session": "a", "query": "SELECT * FROM t WHERE id = $1 AND name = $2", "params": [42, "it's", null], "duration_ms": 0.5}
+
+{"query": "SELECT 1"}
+{"time": "2025-01-15T10:00:00.200Z", "session": "a", "database": "app", "query": "UPDATE t SET v = $1", "params": [{"k": 1}]}
+`
+	events, err := replay.ReadJSONL(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadJSONL failed: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+
+	first := events[0]
+	if first.Session != "a" || first.Duration != 500*time.Microsecond || !first.Time.Equal(time.Date(2025, 1, 15, 10, 0, 0, 100e6, time.UTC)) {
+		t.Errorf("Unexpected first event: %+v", first)
+	}
+	if !reflect.DeepEqual(first.Params, []any{"42", "it's", nil}) {
+		t.Errorf("Expected params as text, got %#v", first.Params)
+	}
+	if events[1].Session != "line 3" || !events[1].Time.IsZero() {
+		t.Errorf("Expected a statement without session to get its own, got %+v", events[1])
+	}
+	if events[2].Database != "app" || !reflect.DeepEqual(events[2].Params, []any{`{"k": 1}`}) {
+		t.Errorf("Unexpected last event: %+v", events[2])
+	}
+
+	if _, err := replay.ReadJSONL(strings.NewReader(`{"session": "a"}`)); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected an error for a statement without query, got %v", err)
+	}
+}
+
+func TestReadCSVLog(t *testing.T) {
+	var buf strings.Builder
+	w := csv.NewWriter(&buf)
+	for _, record := range [][]string{
+		csvLogRecord("2025-01-15 10:00:01.000 UTC", "app", "65a1.1", "LOG", "connection authorized: user=app", ""),
+		csvLogRecord("2025-01-15 10:00:01.500 UTC", "app", "65a1.1", "LOG", "duration: 500.000 ms  statement: SELECT a,\n  b FROM t", ""),
+		csvLogRecord("2025-01-15 10:00:02.000 UTC", "app", "65a1.2", "LOG", "duration: 0.100 ms  parse <unnamed>: SELECT * FROM t WHERE id = $1", ""),
+		csvLogRecord("2025-01-15 10:00:02.000 UTC", "app", "65a1.2", "LOG", "duration: 0.050 ms  bind <unnamed>: SELECT * FROM t WHERE id = $1", "parameters: $1 = '7'"),
+		csvLogRecord("2025-01-15 10:00:02.010 UTC", "app", "65a1.2", "LOG", "duration: 10.000 ms  execute <unnamed>: SELECT * FROM t WHERE id = $1 AND s = $2", "parameters: $1 = '7', $2 = NULL"),
+		csvLogRecord("2025-01-15 10:00:03.000 UTC", "app", "65a1.2", "ERROR", "duration: 1.000 ms  statement: SELECT broken", ""),
+		{"too", "short"},
+	} {
+		if err := w.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Flush()
+
+	events, err := replay.ReadCSVLog(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("ReadCSVLog failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected the statement and the execute entries only, got %+v", events)
+	}
+
+	stmt := events[0]
+	if stmt.Query != "SELECT a,\n  b FROM t" || stmt.Session != "65a1.1" || stmt.Database != "app" || stmt.Duration != 500*time.Millisecond {
+		t.Errorf("Unexpected statement event: %+v", stmt)
+	}
+	if !stmt.Time.Equal(time.Date(2025, 1, 15, 10, 0, 1, 0, time.UTC)) {
+		t.Errorf("Expected the start time to exclude the duration, got %v", stmt.Time)
+	}
+
+	exec := events[1]
+	if exec.Query != "SELECT * FROM t WHERE id = $1 AND s = $2" || !reflect.DeepEqual(exec.Params, []any{"7", nil}) {
+		t.Errorf("Unexpected execute event: %+v", exec)
+	}
+}
+
+func TestReadJSONLog(t *testing.T) {
+	input := `{"timestamp":"2025-01-15 10:00:00.250 UTC","dbname":"app","session_id":"65a1.3","error_severity":"LOG","message":"duration: 250.000 ms  execute S_1: INSERT INTO t VALUES ($1)","detail":"parameters: $1 = 'x'"}
+{"timestamp":"2025-01-15 10:00:00.300 UTC","dbname":"app","session_id":"65a1.3","error_severity":"LOG","message":"checkpoint starting: time"}
+`
+	events, err := replay.ReadJSONLog(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadJSONLog failed: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %+v", events)
+	}
+	ev := events[0]
+	if ev.Query != "INSERT INTO t VALUES ($1)" || ev.Session != "65a1.3" || !reflect.DeepEqual(ev.Params, []any{"x"}) {
+		t.Errorf("Unexpected event: %+v", ev)
+	}
+	if !ev.Time.Equal(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected start time 10:00:00, got %v", ev.Time)
+	}
+}
+
+func TestParseParameters(t *testing.T) {
+	tests := []struct {
+		list string
+		want []any
+	}{
+		{"$1 = '42'", []any{"42"}},
+		{"$1 = 'it''s', $2 = NULL, $3 = 'a, $4 = b'", []any{"it's", nil, "a, $4 = b"}},
+		{"$1 = ''", []any{""}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := replay.ParseParameters(tt.list); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseParameters(%q) = %#v, want %#v", tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestLoadCapture(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "capture.ndjson")
+	if err := os.WriteFile(path, []byte(`{"query": "SELECT 1"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := replay.Load(path, "")
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Expected the format to be detected from the extension, got %v, %v", events, err)
+	}
+	if _, err := replay.Load(filepath.Join(dir, "capture.log"), ""); err == nil {
+		t.Error("Expected an error for an unknown extension")
+	}
+}
+
+func TestReplaySessions(t *testing.T) {
+	base := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	events := []replay.Event{
+		{Session: "b", Time: base.Add(2 * time.Second), Query: "SELECT 1", Database: "app"},
+		{Session: "a", Time: base.Add(3 * time.Second), Query: "SELECT 2", Database: "app"},
+		{Session: "b", Time: base.Add(1 * time.Second), Query: "SELECT 3", Database: "other"},
+		{Session: "a", Time: base.Add(4 * time.Second), Query: "SELECT 4"},
+	}
+
+	sessions := replay.Sessions(events)
+	if len(sessions) != 2 || sessions[0].ID != "b" || sessions[1].ID != "a" {
+		t.Fatalf("Expected sessions ordered by first statement, got %+v", sessions)
+	}
+	if sessions[0].Events[0].Query != "SELECT 1" || sessions[0].Events[1].Query != "SELECT 3" {
+		t.Errorf("Expected statements to keep their order within a session, got %+v", sessions[0].Events)
+	}
+
+	r, err := replay.NewReplayer(events, replay.Options{Database: "app", Speed: 2, PreserveTiming: true})
+	if err != nil {
+		t.Fatalf("NewReplayer failed: %v", err)
+	}
+	if statements, sessions := r.Stats(); statements != 3 || sessions != 2 {
+		t.Errorf("Expected 3 statements in 2 sessions after filtering, got %d in %d", statements, sessions)
+	}
+	if offset := r.Offset(events[3]); offset != time.Second {
+		t.Errorf("Expected a 2s offset at speed 2 to be 1s, got %v", offset)
+	}
+
+	if _, err := replay.NewReplayer(events[:3], replay.Options{Database: "none", Speed: 1}); err == nil {
+		t.Error("Expected an error when no statement is left to replay")
+	}
+	if _, err := replay.NewReplayer([]replay.Event{{Session: "a", Query: "SELECT 1"}}, replay.Options{PreserveTiming: true, Speed: 1}); err == nil {
+		t.Error("Expected an error when timing is preserved without timestamps")
+	}
+}
+
+func TestReplayLabel(t *testing.T) {
+	label := replay.Label("SELECT * FROM t WHERE id = 1")
+	if !strings.HasPrefix(label, "select_") || len(label) != len("select_")+8 {
+		t.Errorf("Unexpected label %q", label)
+	}
+	if replay.Label("select *  from t where id = 2") != label {
+		t.Error("Expected statements with the same fingerprint to share a label")
+	}
+	if got := replay.Label("(SELECT 1) UNION (SELECT 2)"); !strings.HasPrefix(got, "select_") {
+		t.Errorf("Expected a parenthesized statement to be labelled select, got %q", got)
+	}
+}
+
+func TestReplayOptions(t *testing.T) {
+	cfg := &types.Config{}
+	cfg.Replay.PreserveTiming = true
+
+	opts, err := replay.OptionsFrom(cfg)
+	if err != nil || opts.Speed != 1 || !opts.PreserveTiming {
+		t.Errorf("Expected speed to default to 1, got %+v, %v", opts, err)
+	}
+
+	cfg.Replay.Speed = -1
+	if _, err := replay.OptionsFrom(cfg); err == nil {
+		t.Error("Expected an error for a negative speed")
+	}
+}
@@ -0,0 +1,273 @@
+package unit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/core/domain"
+	"github.com/elchinoo/stormdb/internal/core/ports"
+	"github.com/elchinoo/stormdb/internal/core/usecases"
+	"github.com/elchinoo/stormdb/internal/infrastructure/adapters"
+	"github.com/elchinoo/stormdb/internal/metrics"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+func newTestExecution(id, workload string, start time.Time, tps float64, connections int) *domain.TestExecution {
+	end := start.Add(time.Minute)
+	return &domain.TestExecution{
+		ID:           id,
+		Name:         "run " + id,
+		WorkloadType: workload,
+		Status:       domain.StatusCompleted,
+		StartTime:    start,
+		EndTime:      &end,
+		Config: domain.TestConfiguration{
+			WorkloadType:   workload,
+			DatabaseConfig: domain.DatabaseConfiguration{Host: "localhost", Password: "secret"},
+		},
+		Results: &domain.TestResults{SingleBandResults: &domain.BandResults{
+			Workers:     connections,
+			Connections: connections,
+			Performance: domain.PerformanceMetrics{TotalTPS: tps, P95Latency: 1000 / tps},
+			Stability:   domain.StabilityMetrics{CoefficientOfVariation: 100 / tps},
+		}},
+	}
+}
+
+func TestFileRepositoryExecutions(t *testing.T) {
+	ctx := context.Background()
+	repo, err := adapters.NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, e := range []*domain.TestExecution{
+		newTestExecution("a", "simple", base, 100, 4),
+		newTestExecution("b", "simple", base.Add(time.Hour), 200, 16),
+		newTestExecution("c", "tpcc", base.Add(2*time.Hour), 50, 4),
+	} {
+		if i == 2 {
+			e.Status = domain.StatusFailed
+			e.Error = errors.New("connection refused")
+		}
+		if err := repo.Store(ctx, e); err != nil {
+			t.Fatalf("Failed to store execution %s: %v", e.ID, err)
+		}
+	}
+
+	got, err := repo.GetByID(ctx, "c")
+	if err != nil {
+		t.Fatalf("Failed to get execution: %v", err)
+	}
+	if got.Error == nil || got.Error.Error() != "connection refused" {
+		t.Errorf("Expected stored error, got %v", got.Error)
+	}
+	if got.Config.DatabaseConfig.Password != "" {
+		t.Error("Expected the database password not to be stored")
+	}
+	if _, err := repo.GetByID(ctx, "missing"); !errors.Is(err, adapters.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := repo.GetByID(ctx, "../escape"); !errors.Is(err, adapters.ErrInvalidName) {
+		t.Errorf("Expected ErrInvalidName, got %v", err)
+	}
+
+	all, _ := repo.List(ctx, ports.TestExecutionFilters{})
+	if len(all) != 3 || all[0].ID != "c" || all[2].ID != "a" {
+		t.Errorf("Expected three executions, newest first, got %d", len(all))
+	}
+	workload := "simple"
+	simple, _ := repo.List(ctx, ports.TestExecutionFilters{WorkloadType: &workload, Limit: 1})
+	if len(simple) != 1 || simple[0].ID != "b" {
+		t.Errorf("Expected the newest simple execution, got %+v", simple)
+	}
+	paged, _ := repo.List(ctx, ports.TestExecutionFilters{Offset: 2})
+	if len(paged) != 1 || paged[0].ID != "a" {
+		t.Errorf("Expected the oldest execution after the offset, got %+v", paged)
+	}
+
+	// Updating an execution without results keeps the stored ones
+	got.Results = nil
+	got.Status = domain.StatusCancelled
+	if err := repo.Store(ctx, got); err != nil {
+		t.Fatalf("Failed to update execution: %v", err)
+	}
+	if results, err := repo.GetResults(ctx, "c"); err != nil || results.SingleBandResults.Performance.TotalTPS != 50 {
+		t.Errorf("Expected results to survive the update, got %v %v", results, err)
+	}
+
+	trends, err := repo.GetPerformanceTrends(ctx, "simple", ports.TimeRange{})
+	if err != nil || len(trends) != 2 || trends[0].TPS != 100 || trends[1].TPS != 200 {
+		t.Errorf("Expected two trends, oldest first, got %+v %v", trends, err)
+	}
+
+	comparison, err := repo.CompareExecutions(ctx, []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Failed to compare executions: %v", err)
+	}
+	if comparison.Analysis.BestPerformer != "b" || comparison.Analysis.MostEfficient != "a" || comparison.Analysis.MostStable != "b" {
+		t.Errorf("Unexpected comparison analysis: %+v", comparison.Analysis)
+	}
+	if len(comparison.Analysis.Recommendations) == 0 {
+		t.Error("Expected comparison recommendations")
+	}
+	if _, err := repo.CompareExecutions(ctx, []string{"a"}); err == nil {
+		t.Error("Expected an error comparing a single execution")
+	}
+
+	if err := repo.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Failed to delete execution: %v", err)
+	}
+	if err := repo.Delete(ctx, "a"); !errors.Is(err, adapters.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+func TestFileRepositoryMetrics(t *testing.T) {
+	ctx := context.Background()
+	repo, err := adapters.NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	base := time.Now().Add(-time.Hour)
+	for band := 0; band < 2; band++ {
+		aggregated := &domain.BandResults{Workers: (band + 1) * 4, Performance: domain.PerformanceMetrics{TotalTPS: float64(band+1) * 100}}
+		if err := repo.StoreAggregatedMetrics(ctx, "run1", 1-band, aggregated); err != nil {
+			t.Fatalf("Failed to store aggregated metrics: %v", err)
+		}
+	}
+	bands, err := repo.GetAggregatedMetrics(ctx, "run1")
+	if err != nil || len(bands) != 2 || bands[0].BandID != 0 || bands[0].Workers != 8 {
+		t.Errorf("Expected two bands by band id, got %+v %v", bands, err)
+	}
+
+	points := []ports.MetricPoint{
+		{Timestamp: base.Add(2 * time.Second), MetricType: "tps", Value: 20},
+		{Timestamp: base, MetricType: "tps", Value: 10},
+		{Timestamp: base, MetricType: "qps", Value: 30},
+	}
+	if err := repo.StoreMetricBatch(ctx, "run1", 0, points[:2]); err != nil {
+		t.Fatalf("Failed to store metric batch: %v", err)
+	}
+	if err := repo.StoreMetricBatch(ctx, "run1", 0, points[2:]); err != nil {
+		t.Fatalf("Failed to append metric batch: %v", err)
+	}
+	series, err := repo.GetTimeSeriesData(ctx, "run1", 0, "tps")
+	if err != nil || len(series) != 2 || series[0].Value != 10 || series[1].Value != 20 {
+		t.Errorf("Expected two tps points, oldest first, got %+v %v", series, err)
+	}
+
+	if err := repo.CleanupOldMetrics(ctx, base.Add(time.Second)); err != nil {
+		t.Fatalf("Failed to clean up metrics: %v", err)
+	}
+	series, _ = repo.GetTimeSeriesData(ctx, "run1", 0, "tps")
+	if len(series) != 1 || series[0].Value != 20 {
+		t.Errorf("Expected only the newer point after cleanup, got %+v", series)
+	}
+	if series, _ = repo.GetTimeSeriesData(ctx, "run1", 0, "qps"); len(series) != 0 {
+		t.Errorf("Expected old qps points to be cleaned up, got %+v", series)
+	}
+}
+
+func TestFileRepositoryConfigurations(t *testing.T) {
+	ctx := context.Background()
+	repo, err := adapters.NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	config := &domain.TestConfiguration{
+		WorkloadType:   "tpcc",
+		Duration:       time.Minute,
+		WorkloadParams: map[string]interface{}{"name": "nightly", "description": "Nightly TPC-C"},
+	}
+	if err := repo.StoreConfiguration(ctx, config); err != nil {
+		t.Fatalf("Failed to store configuration: %v", err)
+	}
+	if err := repo.StoreConfiguration(ctx, &domain.TestConfiguration{WorkloadType: "tpcc"}); !errors.Is(err, adapters.ErrInvalidName) {
+		t.Errorf("Expected ErrInvalidName for an unnamed configuration, got %v", err)
+	}
+
+	got, err := repo.GetConfiguration(ctx, "nightly")
+	if err != nil || got.Duration != time.Minute {
+		t.Fatalf("Failed to get configuration: %+v %v", got, err)
+	}
+	summaries, _ := repo.ListConfigurations(ctx)
+	if len(summaries) != 1 || summaries[0].Description != "Nightly TPC-C" || summaries[0].LastUsed == nil {
+		t.Errorf("Expected one used configuration, got %+v", summaries)
+	}
+	if err := repo.DeleteConfiguration(ctx, "nightly"); err != nil {
+		t.Fatalf("Failed to delete configuration: %v", err)
+	}
+	if _, err := repo.GetConfiguration(ctx, "nightly"); !errors.Is(err, adapters.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+
+	// Workloads without a stored template get the built-in one
+	template, err := repo.GetTemplate(ctx, "simple")
+	if err != nil || template.ProgressiveConfig == nil || template.WorkloadType != "simple" {
+		t.Errorf("Expected the default template, got %+v %v", template, err)
+	}
+	if err := repo.StoreTemplate(ctx, config); err != nil {
+		t.Fatalf("Failed to store template: %v", err)
+	}
+	if template, _ = repo.GetTemplate(ctx, "tpcc"); template.ProgressiveConfig != nil {
+		t.Errorf("Expected the stored template, got %+v", template)
+	}
+	if templates, _ := repo.ListTemplates(ctx); len(templates) != 1 || templates[0].Name != "tpcc" {
+		t.Errorf("Expected one stored template, got %+v", templates)
+	}
+}
+
+func TestRecordRun(t *testing.T) {
+	ctx := context.Background()
+	repo, err := adapters.NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	cfg := &types.Config{Workload: "simple", Duration: "10s", Workers: 4, Connections: 8}
+	cfg.TestMetadata = map[string]interface{}{"test_name": "baseline"}
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := metrics.BuildSummary(cfg, newSummaryMetrics(), true, nil, start, start.Add(10*time.Second))
+	s.TimeSeries = []metrics.TimeBucketStats{
+		{StartTime: start, TPS: 8, P95Ms: 90},
+		{StartTime: start.Add(5 * time.Second), TPS: 10, P95Ms: 95},
+	}
+
+	execution, points := adapters.ExecutionFromRun(cfg, s)
+	uc := usecases.NewTestExecutionUseCase(repo, repo, repo, nil, nil, nil, nil)
+	if err := uc.RecordExecution(ctx, execution, points); err != nil {
+		t.Fatalf("Failed to record run: %v", err)
+	}
+	id := execution.ID
+
+	e, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("Failed to get recorded run: %v", err)
+	}
+	if e.Name != "baseline" || e.WorkloadType != "simple" || e.Status != domain.StatusCancelled {
+		t.Errorf("Unexpected recorded execution: %s %s %s", e.Name, e.WorkloadType, e.Status)
+	}
+	band := e.Results.SingleBandResults
+	if band.Performance.TotalTPS != 9 || band.Connections != 8 || band.Efficiency.TPSPerConnection != 9.0/8 {
+		t.Errorf("Unexpected recorded band: %+v", band)
+	}
+	if band.Stability.CoefficientOfVariation == 0 || band.Stability.PerformanceDrift <= 0 {
+		t.Errorf("Expected stability from the time series, got %+v", band.Stability)
+	}
+
+	if listed, _ := uc.ListExecutions(ctx, ports.TestExecutionFilters{}); len(listed) != 1 || listed[0].ID != id {
+		t.Errorf("Expected the recorded run to be listed, got %d runs", len(listed))
+	}
+	if bands, _ := repo.GetAggregatedMetrics(ctx, id); len(bands) != 1 {
+		t.Errorf("Expected one aggregated band, got %d", len(bands))
+	}
+	if series, _ := repo.GetTimeSeriesData(ctx, id, 0, "latency_p95"); len(series) != 2 || series[1].Value != 95 {
+		t.Errorf("Expected the P95 time series, got %+v", series)
+	}
+}
@@ -0,0 +1,287 @@
+package unit_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/core/domain"
+	"github.com/elchinoo/stormdb/internal/server"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+const serverTestConfig = `
+database:
+  host: localhost
+  port: 5432
+  dbname: test
+  username: test
+workload: simple
+duration: 500ms
+workers: 2
+connections: 4
+`
+
+// fakeRunner records transactions until ctx is done or the duration passes
+func fakeRunner(ctx context.Context, cfg *types.Config, m *types.Metrics, opts server.RunOptions, started func()) error {
+	duration, err := time.ParseDuration(cfg.Duration)
+	if err != nil {
+		return err
+	}
+	if opts.Rebuild {
+		return errors.New("rebuild failed")
+	}
+	started()
+
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			atomic.AddInt64(&m.TPS, 1)
+			atomic.AddInt64(&m.QPS, 2)
+			m.Mu.Lock()
+			m.TransactionDur = append(m.TransactionDur, int64(time.Millisecond))
+			m.Mu.Unlock()
+		}
+	}
+}
+
+func newTestAPI(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	mgr := server.NewManager(fakeRunner, server.ManagerOptions{MaxHistory: 10})
+	srv := httptest.NewServer(server.New(mgr, server.Options{Token: token, EventInterval: 50 * time.Millisecond}).Handler())
+	t.Cleanup(func() {
+		_ = mgr.Shutdown(context.Background())
+		srv.Close()
+	})
+	return srv
+}
+
+func apiRequest(t *testing.T, method, url, body string, out interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("Failed to decode %s %s response: %v", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func waitForStatus(t *testing.T, url string, want domain.ExecutionStatus) server.RunInfo {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var info server.RunInfo
+		apiRequest(t, http.MethodGet, url, "", &info)
+		if info.Status == want {
+			return info
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Run did not reach status %s, last status %s", want, info.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestServerRunLifecycle(t *testing.T) {
+	srv := newTestAPI(t, "")
+
+	var info server.RunInfo
+	if code := apiRequest(t, http.MethodPost, srv.URL+"/api/v1/runs?name=smoke", serverTestConfig, &info); code != http.StatusCreated {
+		t.Fatalf("Expected 201 on submit, got %d", code)
+	}
+	if info.Status != domain.StatusPending || info.Workload != "simple" || info.Name != "smoke" {
+		t.Fatalf("Unexpected submitted run: %+v", info)
+	}
+	runURL := srv.URL + "/api/v1/runs/" + info.ID
+
+	if code := apiRequest(t, http.MethodGet, runURL+"/results", "", nil); code != http.StatusConflict {
+		t.Errorf("Expected 409 for results of a pending run, got %d", code)
+	}
+	if code := apiRequest(t, http.MethodPost, runURL+"/start", "", nil); code != http.StatusAccepted {
+		t.Fatalf("Expected 202 on start, got %d", code)
+	}
+	if code := apiRequest(t, http.MethodPost, runURL+"/start", "", nil); code != http.StatusConflict {
+		t.Errorf("Expected 409 when starting a running run, got %d", code)
+	}
+
+	// A second run cannot start while the first is active
+	var second server.RunInfo
+	apiRequest(t, http.MethodPost, srv.URL+"/api/v1/runs", serverTestConfig, &second)
+	if code := apiRequest(t, http.MethodPost, srv.URL+"/api/v1/runs/"+second.ID+"/start", "", nil); code != http.StatusConflict {
+		t.Errorf("Expected 409 when another run is active, got %d", code)
+	}
+
+	info = waitForStatus(t, runURL, domain.StatusCompleted)
+	if !info.ResultsReady || info.Interrupted {
+		t.Errorf("Expected completed run with results, got %+v", info)
+	}
+
+	var summary map[string]interface{}
+	if code := apiRequest(t, http.MethodGet, runURL+"/results", "", &summary); code != http.StatusOK {
+		t.Fatalf("Expected 200 for results, got %d", code)
+	}
+	if tx := summary["transactions"].(map[string]interface{}); tx["committed"].(float64) == 0 {
+		t.Errorf("Expected committed transactions in results, got %v", tx)
+	}
+
+	resp, err := http.Get(runURL + "/results?format=text")
+	if err != nil {
+		t.Fatalf("Failed to get text results: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+		t.Errorf("Unexpected text results response: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	var runs []server.RunInfo
+	apiRequest(t, http.MethodGet, srv.URL+"/api/v1/runs", "", &runs)
+	if len(runs) != 2 || runs[0].ID != second.ID {
+		t.Errorf("Expected two runs, newest first, got %+v", runs)
+	}
+}
+
+func TestServerStopAndCancel(t *testing.T) {
+	srv := newTestAPI(t, "")
+	config := strings.Replace(serverTestConfig, "500ms", "1m", 1)
+
+	var info server.RunInfo
+	apiRequest(t, http.MethodPost, srv.URL+"/api/v1/runs?start=true", config, &info)
+	runURL := srv.URL + "/api/v1/runs/" + info.ID
+	time.Sleep(50 * time.Millisecond)
+
+	if code := apiRequest(t, http.MethodPost, runURL+"/stop", "", nil); code != http.StatusAccepted {
+		t.Fatalf("Expected 202 on stop, got %d", code)
+	}
+	info = waitForStatus(t, runURL, domain.StatusCompleted)
+	if !info.Interrupted || !info.ResultsReady {
+		t.Errorf("Expected an interrupted run with results, got %+v", info)
+	}
+
+	var summary map[string]interface{}
+	apiRequest(t, http.MethodGet, runURL+"/results", "", &summary)
+	if summary["status"] != "interrupted" {
+		t.Errorf("Expected interrupted summary status, got %v", summary["status"])
+	}
+
+	// Pending runs can be cancelled without running
+	apiRequest(t, http.MethodPost, srv.URL+"/api/v1/runs", config, &info)
+	if code := apiRequest(t, http.MethodPost, srv.URL+"/api/v1/runs/"+info.ID+"/cancel", "", &info); code != http.StatusAccepted {
+		t.Fatalf("Expected 202 on cancel, got %d", code)
+	}
+	if info.Status != domain.StatusCancelled || info.ResultsReady {
+		t.Errorf("Expected cancelled run without results, got %+v", info)
+	}
+}
+
+func TestServerFailedRun(t *testing.T) {
+	srv := newTestAPI(t, "")
+
+	var info server.RunInfo
+	apiRequest(t, http.MethodPost, srv.URL+"/api/v1/runs?start=true&rebuild=true", serverTestConfig, &info)
+	info = waitForStatus(t, srv.URL+"/api/v1/runs/"+info.ID, domain.StatusFailed)
+	if info.Error != "rebuild failed" || info.ResultsReady {
+		t.Errorf("Expected failed run without results, got %+v", info)
+	}
+}
+
+func TestServerRejectsInvalidRequests(t *testing.T) {
+	srv := newTestAPI(t, "secret")
+
+	if code := apiRequest(t, http.MethodGet, srv.URL+"/api/v1/runs", "", nil); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", code)
+	}
+	if code := apiRequest(t, http.MethodGet, srv.URL+"/healthz", "", nil); code != http.StatusOK {
+		t.Errorf("Expected health check without token, got %d", code)
+	}
+
+	do := func(method, path, body string) int {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := do(http.MethodPost, "/api/v1/runs", "workload: simple\nduration: bogus\n"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid config, got %d", code)
+	}
+	if code := do(http.MethodPost, "/api/v1/runs", ""); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an empty body, got %d", code)
+	}
+	shellFault := serverTestConfig + `
+fault_injection:
+  enabled: true
+  events:
+    - at: 100ms
+      action: shell
+      command: "true"
+`
+	if code := do(http.MethodPost, "/api/v1/runs", shellFault); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a shell fault action, got %d", code)
+	}
+	if code := do(http.MethodGet, "/api/v1/runs/missing", ""); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown run, got %d", code)
+	}
+}
+
+func TestServerEvents(t *testing.T) {
+	srv := newTestAPI(t, "")
+
+	var info server.RunInfo
+	apiRequest(t, http.MethodPost, srv.URL+"/api/v1/runs?start=true", serverTestConfig, &info)
+
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/runs/%s/events?interval=100ms", srv.URL, info.ID))
+	if err != nil {
+		t.Fatalf("Failed to open event stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %s", ct)
+	}
+
+	events := map[string]int{}
+	var last string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if event, ok := strings.CutPrefix(line, "event: "); ok {
+			events[event]++
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			last = data
+		}
+	}
+
+	if events["metrics"] == 0 || events["status"] < 2 {
+		t.Errorf("Expected status and metrics events, got %v", events)
+	}
+	var final server.RunInfo
+	if err := json.Unmarshal([]byte(last), &final); err != nil || final.Status != domain.StatusCompleted {
+		t.Errorf("Expected the stream to end with a completed status, got %s", last)
+	}
+}
@@ -194,3 +194,31 @@ func TestConnectionSetupTimeMeasurement(t *testing.T) {
 	}
 	metrics.TransientConnMetrics.Mu.RUnlock()
 }
+
+// TestEndpointMetrics tests per-endpoint recording used by the pooler comparison
+func TestEndpointMetrics(t *testing.T) {
+	metrics := &types.Metrics{}
+
+	metrics.RecordEndpointConnect("direct", 2_000_000)
+	metrics.RecordEndpointTransaction("direct", true, 5_000_000)
+	metrics.RecordEndpointConnect("pgbouncer", 300_000)
+	metrics.RecordEndpointTransaction("pgbouncer", false, 1_000_000)
+	metrics.RecordEndpointError("pgbouncer", "prepared_statement_missing")
+
+	if len(metrics.EndpointOrder) != 2 || metrics.EndpointOrder[0] != "direct" || metrics.EndpointOrder[1] != "pgbouncer" {
+		t.Fatalf("Expected endpoints [direct pgbouncer], got %v", metrics.EndpointOrder)
+	}
+
+	direct := metrics.EndpointMetrics["direct"]
+	if direct.TPS != 1 || direct.ConnectionCount != 1 || direct.Errors != 0 {
+		t.Errorf("Unexpected direct metrics: TPS=%d conns=%d errors=%d", direct.TPS, direct.ConnectionCount, direct.Errors)
+	}
+
+	pooler := metrics.EndpointMetrics["pgbouncer"]
+	if pooler.TPSAborted != 1 || pooler.Errors != 1 {
+		t.Errorf("Unexpected pooler metrics: aborted=%d errors=%d", pooler.TPSAborted, pooler.Errors)
+	}
+	if pooler.FailureTypes["prepared_statement_missing"] != 1 {
+		t.Errorf("Expected one prepared_statement_missing failure, got %v", pooler.FailureTypes)
+	}
+}
@@ -0,0 +1,141 @@
+package unit_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/elchinoo/stormdb/internal/sqlscript"
+)
+
+const dumpScript = `--
+-- PostgreSQL database dump
+--
+
+SET statement_timeout = 0;
+SELECT pg_catalog.set_config('search_path', '', false);
+\connect imdb
+
+CREATE FUNCTION public.touch() RETURNS trigger AS $body$
+BEGIN
+    NEW.note := 'semi;colon';
+    RETURN NEW;
+END;
+$body$ LANGUAGE plpgsql;
+
+-- Data for Name: movies
+COPY public.movies (id, title) FROM stdin;
+1	Alien; the director's cut
+2	Brazil
+\.
+
+INSERT INTO public.notes VALUES (1, 'it''s; fine', E'back\'slash;', "odd""name", /* c; */ $$x;y$$);
+;
+SELECT 1`
+
+func readAll(t *testing.T, r *sqlscript.Reader) []sqlscript.Item {
+	t.Helper()
+	var items []sqlscript.Item
+	for {
+		item, err := r.Next()
+		if err == io.EOF {
+			return items
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error after %d items: %v", len(items), err)
+		}
+		items = append(items, item)
+	}
+}
+
+func TestSQLScriptReader(t *testing.T) {
+	items := readAll(t, sqlscript.NewReader(strings.NewReader(dumpScript)))
+
+	expected := []struct {
+		kind   sqlscript.Kind
+		prefix string
+	}{
+		{sqlscript.Statement, "SET statement_timeout = 0;"},
+		{sqlscript.Statement, "SELECT pg_catalog.set_config("},
+		{sqlscript.MetaCommand, `\connect imdb`},
+		{sqlscript.Statement, "CREATE FUNCTION public.touch()"},
+		{sqlscript.CopyStart, "COPY public.movies (id, title) FROM stdin;"},
+		{sqlscript.CopyRow, "1\tAlien; the director's cut\n"},
+		{sqlscript.CopyRow, "2\tBrazil\n"},
+		{sqlscript.CopyEnd, `\.`},
+		{sqlscript.Statement, "INSERT INTO public.notes"},
+		{sqlscript.Statement, "SELECT 1"},
+	}
+	if len(items) != len(expected) {
+		for _, item := range items {
+			t.Logf("%s: %q", item.Kind, item.Text)
+		}
+		t.Fatalf("Expected %d items, got %d", len(expected), len(items))
+	}
+	for i, e := range expected {
+		if items[i].Kind != e.kind || !strings.HasPrefix(items[i].Text, e.prefix) {
+			t.Errorf("Item %d: expected %s %q, got %s %q", i, e.kind, e.prefix, items[i].Kind, items[i].Text)
+		}
+	}
+
+	if !strings.HasSuffix(items[3].Text, "$body$ LANGUAGE plpgsql;") {
+		t.Errorf("Dollar-quoted body was split: %q", items[3].Text)
+	}
+	if !strings.HasSuffix(items[8].Text, "$$x;y$$);") {
+		t.Errorf("Quoted semicolons were treated as terminators: %q", items[8].Text)
+	}
+	if last := items[len(items)-1]; last.Offset != int64(len(dumpScript)) {
+		t.Errorf("Expected final offset %d, got %d", len(dumpScript), last.Offset)
+	}
+}
+
+func TestSQLScriptReaderResume(t *testing.T) {
+	all := readAll(t, sqlscript.NewReader(strings.NewReader(dumpScript)))
+
+	// Resume after every item and check the remaining items match
+	reader := sqlscript.NewReader(strings.NewReader(dumpScript))
+	for i := range all {
+		if _, err := reader.Next(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		offset, header := reader.Offset(), reader.CopyHeader()
+
+		rest := readAll(t, sqlscript.NewReaderAt(strings.NewReader(dumpScript[offset:]), offset, header))
+		if len(rest) != len(all)-i-1 {
+			t.Fatalf("Resuming after item %d: expected %d items, got %d", i, len(all)-i-1, len(rest))
+		}
+		for j, item := range rest {
+			if item != all[i+1+j] {
+				t.Errorf("Resuming after item %d: item %d differs: %+v vs %+v", i, j, item, all[i+1+j])
+			}
+		}
+	}
+}
+
+func TestSQLScriptReaderUnterminatedCopy(t *testing.T) {
+	reader := sqlscript.NewReader(strings.NewReader("COPY t (a) FROM stdin;\n1\n"))
+	for {
+		_, err := reader.Next()
+		if err == io.ErrUnexpectedEOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("Expected io.ErrUnexpectedEOF, got %v", err)
+		}
+	}
+}
+
+func TestIsCopyFromStdin(t *testing.T) {
+	cases := map[string]bool{
+		"COPY public.movies (id, title) FROM stdin;":     true,
+		"copy t from STDIN with (format csv)":            true,
+		"COPY t TO stdout;":                              false,
+		"COPY t FROM '/tmp/data.csv';":                   false,
+		"INSERT INTO t SELECT 'COPY x FROM stdin' AS q;": false,
+	}
+	for stmt, want := range cases {
+		if got := sqlscript.IsCopyFromStdin(stmt); got != want {
+			t.Errorf("IsCopyFromStdin(%q) = %v, want %v", stmt, got, want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package unit_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elchinoo/stormdb/internal/metrics"
+	"github.com/elchinoo/stormdb/pkg/types"
+)
+
+func newSummaryMetrics() *types.Metrics {
+	m := &types.Metrics{
+		TPS:           90,
+		TPSAborted:    10,
+		QPS:           400,
+		SelectQueries: 300,
+		InsertQueries: 60,
+		UpdateQueries: 30,
+		DeleteQueries: 10,
+		RowsRead:      1000,
+		Errors:        10,
+		ErrorTypes:    map[string]int64{"deadlock": 3, "serialization_failure": 7},
+	}
+	m.InitializeLatencyHistogram()
+	for i := int64(1); i <= 100; i++ {
+		m.TransactionDur = append(m.TransactionDur, i*int64(time.Millisecond))
+		m.RecordLatency(i * int64(time.Millisecond))
+	}
+	return m
+}
+
+func TestBuildSummary(t *testing.T) {
+	cfg := &types.Config{Workload: "simple", Duration: "10s", Workers: 4, Connections: 8}
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Second)
+
+	s := metrics.BuildSummary(cfg, newSummaryMetrics(), false, nil, start, end)
+
+	if s.SchemaVersion != metrics.SummarySchemaVersion {
+		t.Errorf("Expected schema version %s, got %s", metrics.SummarySchemaVersion, s.SchemaVersion)
+	}
+	if s.Status != metrics.StatusCompleted {
+		t.Errorf("Expected status %s, got %s", metrics.StatusCompleted, s.Status)
+	}
+	if s.Transactions.TPS != 9 {
+		t.Errorf("Expected 9 TPS, got %f", s.Transactions.TPS)
+	}
+	if s.Transactions.SuccessRate != 90 {
+		t.Errorf("Expected 90%% success rate, got %f", s.Transactions.SuccessRate)
+	}
+	if s.Queries.QPS != 40 || len(s.Queries.Mix) != 4 {
+		t.Errorf("Unexpected query summary: %+v", s.Queries)
+	}
+	if s.Queries.Mix[0].Type != "select" || s.Queries.Mix[0].Percent != 75 {
+		t.Errorf("Expected select to be 75%% of the mix, got %+v", s.Queries.Mix[0])
+	}
+	if s.Latency.Samples != 100 || s.Latency.MinMs != 1 || s.Latency.MaxMs != 100 {
+		t.Errorf("Unexpected latency summary: %+v", s.Latency)
+	}
+	if s.Latency.P50Ms < 50 || s.Latency.P50Ms > 51 {
+		t.Errorf("Expected P50 around 50ms, got %f", s.Latency.P50Ms)
+	}
+	if len(s.Errors.Types) != 2 || s.Errors.Types[0].Type != "serialization_failure" {
+		t.Errorf("Expected error types sorted by count, got %+v", s.Errors.Types)
+	}
+
+	failed := metrics.BuildSummary(cfg, newSummaryMetrics(), false, errors.New("boom"), start, end)
+	if failed.Status != metrics.StatusFailed || failed.Error != "boom" {
+		t.Errorf("Expected failed status with error, got %s %q", failed.Status, failed.Error)
+	}
+	interrupted := metrics.BuildSummary(cfg, newSummaryMetrics(), true, nil, start, end)
+	if interrupted.Status != metrics.StatusInterrupted {
+		t.Errorf("Expected interrupted status, got %s", interrupted.Status)
+	}
+}
+
+func TestBuildSummaryEmptyMetrics(t *testing.T) {
+	cfg := &types.Config{Workload: "simple", Duration: "10s"}
+	m := &types.Metrics{}
+	start := time.Now()
+
+	s := metrics.BuildSummary(cfg, m, false, nil, start, start)
+
+	if s.DurationSeconds != 10 {
+		t.Errorf("Expected the configured duration as fallback, got %f", s.DurationSeconds)
+	}
+	if s.Latency.Samples != 0 || s.Latency.P99Ms != 0 {
+		t.Errorf("Expected empty latency summary, got %+v", s.Latency)
+	}
+	if _, err := json.Marshal(s); err != nil {
+		t.Errorf("Failed to marshal empty summary: %v", err)
+	}
+}
+
+func TestWriteSummaryFormats(t *testing.T) {
+	cfg := &types.Config{Workload: "simple", Duration: "10s", Workers: 4}
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := metrics.BuildSummary(cfg, newSummaryMetrics(), false, errors.New("boom"), start, start.Add(10*time.Second))
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := metrics.WriteSummary(&buf, metrics.OutputJSON, s); err != nil {
+			t.Fatalf("WriteSummary failed: %v", err)
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		for _, key := range []string{"schema_version", "transactions", "queries", "latency", "errors", "worker_stats", "time_series"} {
+			if _, ok := decoded[key]; !ok {
+				t.Errorf("JSON summary is missing %q", key)
+			}
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := metrics.WriteSummary(&buf, metrics.OutputCSV, s); err != nil {
+			t.Fatalf("WriteSummary failed: %v", err)
+		}
+		records, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("Invalid CSV: %v", err)
+		}
+		if strings.Join(records[0], ",") != "schema_version,section,key,metric,value" {
+			t.Errorf("Unexpected CSV header: %v", records[0])
+		}
+		found := false
+		for _, r := range records[1:] {
+			if r[1] == "transactions" && r[3] == "tps" {
+				found = r[4] == "9"
+			}
+		}
+		if !found {
+			t.Error("CSV summary is missing transactions tps = 9")
+		}
+	})
+
+	t.Run("junit", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := metrics.WriteSummary(&buf, metrics.OutputJUnit, s); err != nil {
+			t.Fatalf("WriteSummary failed: %v", err)
+		}
+		var suites struct {
+			Suites []struct {
+				Tests    int `xml:"tests,attr"`
+				Failures int `xml:"failures,attr"`
+			} `xml:"testsuite"`
+		}
+		if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+			t.Fatalf("Invalid JUnit XML: %v", err)
+		}
+		if len(suites.Suites) != 1 || suites.Suites[0].Tests != 1 || suites.Suites[0].Failures != 1 {
+			t.Errorf("Expected one failed test case, got %+v", suites.Suites)
+		}
+	})
+
+	if err := metrics.WriteSummary(&bytes.Buffer{}, metrics.OutputText, s); err == nil {
+		t.Error("Expected an error for the text format")
+	}
+	if err := metrics.ValidateOutputFormat("yaml"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestRecordOperation(t *testing.T) {
+	m := &types.Metrics{}
+	for i := int64(1); i <= 4; i++ {
+		m.RecordOperation("getUserOrders", i*int64(time.Millisecond), 10, i != 4)
+	}
+
+	op := m.Operation("getUserOrders")
+	if op.Count != 4 || op.Errors != 1 || op.Rows != 40 {
+		t.Errorf("Unexpected counters: count %d, errors %d, rows %d", op.Count, op.Errors, op.Rows)
+	}
+	if op.MinNs != int64(time.Millisecond) || op.MaxNs != 4*int64(time.Millisecond) {
+		t.Errorf("Unexpected min/max: %d/%d", op.MinNs, op.MaxNs)
+	}
+	if len(op.Latencies) != 4 || op.Histogram["5.0ms"] != 2 {
+		t.Errorf("Unexpected samples %v or histogram %v", op.Latencies, op.Histogram)
+	}
+
+	for i := 0; i < types.MaxOperationSamples; i++ {
+		m.RecordOperation("createNewOrder", int64(time.Millisecond), 1, true)
+	}
+	m.RecordOperation("createNewOrder", int64(time.Second), 1, true)
+	ring := m.Operation("createNewOrder")
+	if len(ring.Latencies) != types.MaxOperationSamples || ring.Latencies[0] != int64(time.Second) {
+		t.Errorf("Expected the ring buffer to wrap at %d samples", types.MaxOperationSamples)
+	}
+}
+
+func TestBuildSummaryOperations(t *testing.T) {
+	cfg := &types.Config{Workload: "ecommerce", Duration: "10s"}
+	m := newSummaryMetrics()
+	for i := int64(1); i <= 100; i++ {
+		m.RecordOperation("getUserOrders", i*int64(time.Millisecond), 1, true)
+	}
+	for i := 0; i < 10; i++ {
+		m.RecordOperation("createNewOrder", 5*int64(time.Millisecond), 3, i%2 == 0)
+	}
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	s := metrics.BuildSummary(cfg, m, false, nil, start, start.Add(10*time.Second))
+
+	if len(s.Operations) != 2 || s.Operations[0].Name != "createNewOrder" {
+		t.Fatalf("Expected operations sorted by name, got %+v", s.Operations)
+	}
+	create, orders := s.Operations[0], s.Operations[1]
+	if create.ErrorRate != 50 || create.Rows != 30 || create.OPS != 1 {
+		t.Errorf("Unexpected createNewOrder summary: %+v", create)
+	}
+	if orders.P50Ms < 50 || orders.P50Ms > 51 || orders.P99Ms < 99 || orders.AvgMs != 50.5 {
+		t.Errorf("Unexpected getUserOrders latencies: %+v", orders)
+	}
+
+	var buf bytes.Buffer
+	if err := metrics.WriteSummary(&buf, metrics.OutputCSV, s); err != nil {
+		t.Fatalf("WriteSummary failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "operations,getUserOrders,count,100") {
+		t.Error("CSV summary is missing the getUserOrders count")
+	}
+}
+
+func TestBuildSummaryQueryPlans(t *testing.T) {
+	cfg := &types.Config{Workload: "ecommerce", Duration: "10s"}
+	m := newSummaryMetrics()
+	m.RecordOperation("getUserOrders", int64(time.Millisecond), 1, true)
+	m.RecordOperation("createNewOrder", int64(time.Millisecond), 1, true)
+	m.QueryPlanFile = "/tmp/plans/ecommerce.json"
+	m.QueryPlans = []types.QueryPlan{
+		{Operation: "getUserOrders", Fingerprint: "a", Shape: "Seq Scan on orders", Changed: true, PreviousShape: "Index Scan on orders"},
+		{Operation: "getUserOrders", Fingerprint: "a", Shape: "Seq Scan on orders"},
+		{Operation: "getUserOrders", Fingerprint: "b", Error: "lock timeout"},
+	}
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	s := metrics.BuildSummary(cfg, m, false, nil, start, start.Add(10*time.Second))
+
+	if s.QueryPlans == nil || s.QueryPlans.Captured != 3 || s.QueryPlans.Changed != 1 {
+		t.Fatalf("Unexpected query plan summary: %+v", s.QueryPlans)
+	}
+	if got := s.Operations[1].Plans; len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Expected getUserOrders to link fingerprints a and b, got %v", got)
+	}
+	if len(s.Operations[0].Plans) != 0 {
+		t.Errorf("Expected no plans for createNewOrder, got %v", s.Operations[0].Plans)
+	}
+
+	var buf bytes.Buffer
+	if err := metrics.WriteSummary(&buf, metrics.OutputCSV, s); err != nil {
+		t.Fatalf("WriteSummary failed: %v", err)
+	}
+	for _, want := range []string{"query_plans,,changed,1", "query_plans,1,shape,Seq Scan on orders", "query_plans,3,error,lock timeout"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("CSV summary is missing %q", want)
+		}
+	}
+}